@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+	"github.com/alchemy-labs-co/riptide/internal/daemon"
+)
+
+// runAttachCommand handles "riptide attach [name] [--list] [options]" and returns
+// the exit code. It starts the named session in the background if it is not
+// already running, then attaches this terminal to it.
+func runAttachCommand(args []string) int {
+	name := "default"
+	var options []string
+	for _, arg := range args {
+		switch {
+		case arg == "--list":
+			return listSessions()
+		case arg == "--demo" || arg == "--deterministic" || arg == "--no-cache" || arg == "--plan":
+			options = append(options, arg)
+		case strings.HasPrefix(arg, "-"):
+			fmt.Fprintf(os.Stderr, "Unknown attach option %q (use --list, --demo, --deterministic, --no-cache or --plan)\n", arg)
+			return 2
+		default:
+			name = arg
+		}
+	}
+
+	socket, err := daemon.SocketPath(name)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+
+	if !daemon.Running(socket) {
+		if err := daemon.Start(socket, append([]string{"--serve", socket}, options...)); err != nil {
+			fmt.Fprintf(os.Stderr, "Error starting session %s: %v\n", name, err)
+			return 1
+		}
+	} else if len(options) > 0 {
+		fmt.Fprintf(os.Stderr, "Session %s is already running; ignoring %s\n", name, strings.Join(options, " "))
+	}
+
+	running, err := daemon.Attach(socket)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error attaching to session %s: %v\n", name, err)
+		return 1
+	}
+	if running {
+		fmt.Printf("Detached from session %s. Reattach with: riptide attach %s\n", name, name)
+	} else {
+		fmt.Printf("Session %s ended.\n", name)
+	}
+	return 0
+}
+
+// listSessions prints the running background sessions
+func listSessions() int {
+	names, err := daemon.List()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error listing sessions: %v\n", err)
+		return 1
+	}
+	if len(names) == 0 {
+		fmt.Println("No background sessions are running.")
+		return 0
+	}
+	for _, name := range names {
+		fmt.Println(name)
+	}
+	return 0
+}
+
+// startServer serves the TUI on socket for riptide attach instead of this
+// process's terminal
+func startServer(socket string) (*daemon.Server, error) {
+	server, err := daemon.Listen(socket)
+	if err != nil {
+		return nil, err
+	}
+
+	// Output is not a terminal, so take the colors from the environment the
+	// launching terminal passed down
+	lipgloss.SetColorProfile(termenv.NewOutput(os.Stdout, termenv.WithTTY(true)).EnvColorProfile())
+	return server, nil
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/alchemy-labs-co/riptide/internal/importer"
+	"github.com/alchemy-labs-co/riptide/internal/session"
+)
+
+// runImportCommand handles "riptide import [--format F] FILE..." and returns
+// the exit code. Every conversation in each export is saved as a session that
+// can be resumed like any other.
+func runImportCommand(args []string) int {
+	flags := flag.NewFlagSet("riptide import", flag.ContinueOnError)
+	format := flags.String("format", "", "export format: chatgpt, claude or aider (detected when omitted)")
+	dir := flags.String("dir", "", "working directory to record for the imported sessions (default: current directory)")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "Usage: riptide import [--format chatgpt|claude|aider] [--dir DIR] FILE...")
+		return 2
+	}
+
+	workingDir := *dir
+	if workingDir == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error getting working directory: %v\n", err)
+			return 1
+		}
+		workingDir = wd
+	}
+
+	sessionsDir, err := session.DefaultDir()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	store := session.NewStore(sessionsDir)
+
+	imported := 0
+	for _, path := range flags.Args() {
+		conversations, err := importer.ParseFile(path, *format)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error importing %s: %v\n", path, err)
+			return 1
+		}
+
+		for _, conv := range conversations {
+			sess := session.New(workingDir, "imported")
+			if !conv.Created.IsZero() {
+				sess.CreatedAt = conv.Created
+			}
+			sess.Preview = conv.Title
+			if err := store.Save(sess, conv.Messages); err != nil {
+				fmt.Fprintf(os.Stderr, "Error saving session: %v\n", err)
+				return 1
+			}
+			fmt.Printf("  %s  %s (%d messages)\n", sess.ID, sess.Preview, len(conv.Messages))
+			imported++
+		}
+		fmt.Printf("Imported %d conversation(s) from %s\n", len(conversations), path)
+	}
+
+	if imported > 0 {
+		fmt.Println("Resume them from the welcome screen or the Ctrl+K palette.")
+	}
+	return 0
+}
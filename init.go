@@ -0,0 +1,271 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/x/term"
+
+	"github.com/alchemy-labs-co/riptide/internal/api"
+	"github.com/alchemy-labs-co/riptide/internal/config"
+	"github.com/alchemy-labs-co/riptide/internal/ui"
+)
+
+// deepSeekBaseURL is the endpoint the wizard sets for the DeepSeek provider
+const deepSeekBaseURL = "https://api.deepseek.com/v1"
+
+// errSetupCancelled is returned when stdin ends before the wizard is done
+var errSetupCancelled = errors.New("setup cancelled")
+
+// runInitCommand handles "riptide init" and returns the exit code
+func runInitCommand(args []string) int {
+	for _, arg := range args {
+		switch arg {
+		case "-h", "--help":
+			fmt.Println("Usage: riptide init")
+			fmt.Println()
+			fmt.Println("Asks for the provider, API key, model and theme, stores the key in the OS")
+			fmt.Println("keyring or .env, and writes the rest to config.json.")
+			return 0
+		default:
+			fmt.Fprintf(os.Stderr, "Unknown init option %q\n", arg)
+			return 2
+		}
+	}
+
+	if err := runSetup(os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// isInteractive reports whether stdin and stdout are both terminals, so the
+// wizard can be offered instead of failing on a missing API key
+func isInteractive() bool {
+	return term.IsTerminal(os.Stdin.Fd()) && term.IsTerminal(os.Stdout.Fd())
+}
+
+// setupWizard asks the setup questions on a line-based input
+type setupWizard struct {
+	in     *bufio.Reader
+	out    io.Writer
+	secret func() (string, error) // Reads the API key without echoing it, when stdin is a terminal
+}
+
+// runSetup walks through the setup questions and saves the answers: the API key
+// to the keyring or .env, everything else to config.json. Options the wizard
+// does not ask about keep their current values.
+func runSetup(in *os.File, out io.Writer) error {
+	w := &setupWizard{in: bufio.NewReader(in), out: out}
+	if term.IsTerminal(in.Fd()) {
+		w.secret = func() (string, error) {
+			key, err := term.ReadPassword(in.Fd())
+			fmt.Fprintln(out)
+			return string(key), err
+		}
+	}
+
+	path := config.Path()
+	cfg, err := config.LoadFile(path)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(out, "Riptide setup")
+	fmt.Fprintln(out)
+
+	// Provider
+	provider, err := w.choose("Provider", []string{"DeepSeek", "Another OpenAI-compatible API"}, boolIndex(cfg.API.BaseURL != deepSeekBaseURL))
+	if err != nil {
+		return err
+	}
+	if provider == 0 {
+		cfg.API.BaseURL = deepSeekBaseURL
+	} else {
+		current := cfg.API.BaseURL
+		if current == deepSeekBaseURL {
+			current = ""
+		}
+		for {
+			if cfg.API.BaseURL, err = w.ask("Base URL", current); err != nil {
+				return err
+			}
+			if strings.HasPrefix(cfg.API.BaseURL, "http://") || strings.HasPrefix(cfg.API.BaseURL, "https://") {
+				break
+			}
+			fmt.Fprintln(out, "  The base URL must start with http:// or https://")
+		}
+	}
+
+	// API key
+	key, err := w.askSecret("API key (leave empty to skip)")
+	if err != nil {
+		return err
+	}
+	keyNote := ""
+	if key != "" {
+		if keyNote, err = w.storeKey(key); err != nil {
+			return err
+		}
+	}
+
+	// Model
+	if provider == 0 {
+		models := []string{api.ReasonerModel, api.ChatModel}
+		choice, err := w.choose("Model", []string{api.ReasonerModel + " (thinks before answering)", api.ChatModel + " (faster and cheaper)"}, boolIndex(cfg.API.Model == api.ChatModel))
+		if err != nil {
+			return err
+		}
+		cfg.API.Model = models[choice]
+	} else if cfg.API.Model, err = w.ask("Model", cfg.API.Model); err != nil {
+		return err
+	}
+
+	// Theme
+	themes := ui.ThemeNames(cfg.UI.Themes)
+	current := 0
+	for i, theme := range themes {
+		if theme == cfg.UI.Theme {
+			current = i
+		}
+	}
+	choice, err := w.choose("Theme", themes, current)
+	if err != nil {
+		return err
+	}
+	cfg.UI.Theme = themes[choice]
+
+	if err := cfg.Save(path); err != nil {
+		return err
+	}
+	fmt.Fprintln(out)
+	fmt.Fprintf(out, "Saved configuration to %s\n", path)
+	if keyNote != "" {
+		fmt.Fprintln(out, keyNote)
+	} else {
+		fmt.Fprintln(out, "No API key saved; set DEEPSEEK_API_KEY before starting riptide")
+	}
+	return nil
+}
+
+// storeKey asks where to keep the API key, saves it there and returns a line
+// saying where it went
+func (w *setupWizard) storeKey(key string) (string, error) {
+	type store struct {
+		label string
+		save  func() (string, error)
+	}
+	var stores []store
+	if config.KeyringAvailable() {
+		stores = append(stores, store{"OS keyring", func() (string, error) {
+			if err := config.SaveAPIKeyToKeyring(key); err != nil {
+				return "", err
+			}
+			return "Saved the API key to the OS keyring", nil
+		}})
+	}
+	stores = append(stores,
+		store{config.EnvFile + " in this directory", func() (string, error) {
+			if err := config.SaveAPIKeyToEnvFile(config.EnvFile, key); err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("Saved the API key to %s; keep it out of version control", config.EnvFile), nil
+		}},
+		store{"Don't save it (set DEEPSEEK_API_KEY yourself)", func() (string, error) {
+			return "Not saved; run: export DEEPSEEK_API_KEY=<your key>", nil
+		}},
+	)
+
+	labels := make([]string, len(stores))
+	for i, s := range stores {
+		labels[i] = s.label
+	}
+	for {
+		choice, err := w.choose("Store the API key in", labels, 0)
+		if err != nil {
+			return "", err
+		}
+		note, err := stores[choice].save()
+		if err == nil {
+			return note, nil
+		}
+		// The keyring can refuse, e.g. with no session bus, so offer the others
+		fmt.Fprintf(w.out, "  %v\n", err)
+	}
+}
+
+// choose lists numbered options and returns the index picked, def on an empty answer
+func (w *setupWizard) choose(question string, options []string, def int) (int, error) {
+	fmt.Fprintf(w.out, "%s:\n", question)
+	for i, option := range options {
+		fmt.Fprintf(w.out, "  %d) %s\n", i+1, option)
+	}
+	for {
+		answer, err := w.ask("Choose", strconv.Itoa(def+1))
+		if err != nil {
+			return 0, err
+		}
+		if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= len(options) {
+			fmt.Fprintln(w.out)
+			return n - 1, nil
+		}
+		fmt.Fprintf(w.out, "  Enter a number from 1 to %d\n", len(options))
+	}
+}
+
+// ask reads one answer, returning def when it is empty
+func (w *setupWizard) ask(question, def string) (string, error) {
+	if def != "" {
+		fmt.Fprintf(w.out, "%s [%s]: ", question, def)
+	} else {
+		fmt.Fprintf(w.out, "%s: ", question)
+	}
+	answer, err := w.readLine()
+	if err != nil {
+		return "", err
+	}
+	if answer == "" {
+		return def, nil
+	}
+	return answer, nil
+}
+
+// askSecret reads an answer without echoing it when stdin is a terminal
+func (w *setupWizard) askSecret(question string) (string, error) {
+	fmt.Fprintf(w.out, "%s: ", question)
+	if w.secret == nil {
+		return w.readLine()
+	}
+	answer, err := w.secret()
+	if err != nil {
+		return "", fmt.Errorf("reading API key: %w", err)
+	}
+	return strings.TrimSpace(answer), nil
+}
+
+// readLine reads a trimmed line, failing with errSetupCancelled at the end of input
+func (w *setupWizard) readLine() (string, error) {
+	line, err := w.in.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		if err == io.EOF {
+			fmt.Fprintln(w.out)
+			return "", errSetupCancelled
+		}
+		return "", fmt.Errorf("reading answer: %w", err)
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// boolIndex returns 1 for true and 0 for false, to pick a default option
+func boolIndex(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
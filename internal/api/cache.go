@@ -0,0 +1,149 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// ResponseCache keeps finished responses on disk, keyed by a hash of the whole
+// request, so an identical request is answered instantly without calling the API
+type ResponseCache struct {
+	dir string
+	ttl time.Duration
+}
+
+// cachedResponse is one finished response as stored on disk
+type cachedResponse struct {
+	Model     string     `json:"model"`
+	CreatedAt time.Time  `json:"created_at"`
+	Reasoning string     `json:"reasoning,omitempty"`
+	Content   string     `json:"content,omitempty"`
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+}
+
+// DefaultCacheDir returns $XDG_DATA_HOME/riptide/cache, falling back to ~/.local/share
+func DefaultCacheDir() (string, error) {
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("finding home directory: %w", err)
+		}
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(dataHome, "riptide", "cache"), nil
+}
+
+// NewResponseCache returns a cache in dir whose entries expire after ttl
+func NewResponseCache(dir string, ttl time.Duration) *ResponseCache {
+	return &ResponseCache{dir: dir, ttl: ttl}
+}
+
+// key hashes everything that affects the answer: model, sampling, tools and messages
+func (c *ResponseCache) key(req openai.ChatCompletionRequest) (string, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return "", fmt.Errorf("marshaling request: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// path returns the file holding the response for key
+func (c *ResponseCache) path(key string) string {
+	return filepath.Join(c.dir, key[:2], key+".json")
+}
+
+// get returns the unexpired response for key
+func (c *ResponseCache) get(key string) (*cachedResponse, bool) {
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return nil, false
+	}
+	var resp cachedResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, false
+	}
+	if c.ttl > 0 && time.Since(resp.CreatedAt) > c.ttl {
+		return nil, false
+	}
+	return &resp, true
+}
+
+// put stores a response under key, writing through a temporary file so readers
+// never see a partial entry
+func (c *ResponseCache) put(key string, resp *cachedResponse) error {
+	path := c.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("creating cache directory: %w", err)
+	}
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return fmt.Errorf("marshaling response: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("writing cache entry: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("saving cache entry: %w", err)
+	}
+	return nil
+}
+
+// replay streams a cached response as the events of a live one, marking the
+// final event as cached. No usage is reported since nothing was billed.
+func (r *cachedResponse) replay() <-chan StreamEvent {
+	events := make(chan StreamEvent, 4)
+	if r.Reasoning != "" {
+		events <- StreamEvent{Type: EventTypeReasoning, ReasoningContent: r.Reasoning}
+	}
+	if r.Content != "" {
+		events <- StreamEvent{Type: EventTypeContent, Content: r.Content}
+	}
+	if len(r.ToolCalls) > 0 {
+		events <- StreamEvent{Type: EventTypeToolCall, ToolCalls: r.ToolCalls}
+	}
+	events <- StreamEvent{Type: EventTypeDone, Cached: true}
+	close(events)
+	return events
+}
+
+// record passes a live stream through and stores the response once it finishes
+// without error. Failed, cancelled and empty responses are not cached.
+func (c *ResponseCache) record(key, model string, live <-chan StreamEvent) <-chan StreamEvent {
+	events := make(chan StreamEvent, 100)
+	go func() {
+		defer close(events)
+		resp := &cachedResponse{Model: model}
+		for event := range live {
+			switch event.Type {
+			case EventTypeReasoning:
+				resp.Reasoning += event.ReasoningContent
+			case EventTypeContent:
+				resp.Content += event.Content
+			case EventTypeToolCall:
+				resp.ToolCalls = event.ToolCalls
+			case EventTypeRetry:
+				if event.Retry.Restart {
+					resp.Reasoning, resp.Content = "", ""
+				}
+			case EventTypeDone:
+				if resp.Content != "" || len(resp.ToolCalls) > 0 {
+					resp.CreatedAt = time.Now()
+					// A cache that cannot be written only costs the next request
+					_ = c.put(key, resp)
+				}
+			}
+			events <- event
+		}
+	}()
+	return events
+}
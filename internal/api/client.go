@@ -2,185 +2,188 @@ package api
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
+	"math"
+	"net/http"
 	"time"
 
 	"github.com/alchemy-labs-co/riptide/internal/config"
 	openai "github.com/sashabaranov/go-openai"
 )
 
-// Client wraps the OpenAI client for DeepSeek API access
+// Client sends chat completions to DeepSeek or another OpenAI-compatible API
 type Client struct {
-	client *openai.Client
-	config *config.Config
+	http        *http.Client
+	config      *config.Config
+	temperature *float32
+	noTools     bool
+	jsonOutput  bool
+	cache       *ResponseCache // Nil when caching is off
+	fresh       bool           // Skip cached answers, still caching the new one
 }
 
-// NewClient creates a new API client
-func NewClient(cfg *config.Config) *Client {
-	openaiConfig := openai.DefaultConfig(cfg.APIKey)
-	openaiConfig.BaseURL = cfg.API.BaseURL
+// NewClient creates a new API client, connecting through the configured proxy
+// and trusting the configured CA bundle
+func NewClient(cfg *config.Config) (*Client, error) {
+	transport, err := newTransport(cfg.API)
+	if err != nil {
+		return nil, err
+	}
 
-	// Client created
+	// Requests are limited by their context rather than a client timeout, which
+	// would also cut off long streams
+	client := &Client{
+		http:   &http.Client{Transport: transport},
+		config: cfg,
+	}
+	if cfg.Cache.Enabled {
+		if dir, err := DefaultCacheDir(); err == nil {
+			client.cache = NewResponseCache(dir, time.Duration(cfg.Cache.TTLHours)*time.Hour)
+		}
+	}
+	return client, nil
+}
 
+// WithOverrides returns a client that shares this one's connection but sends
+// requests with the given overrides applied to a copy of the configuration
+func (c *Client) WithOverrides(overrides Overrides) Provider {
+	cfg := *c.config
+	if overrides.Model != "" {
+		cfg.API.Model = overrides.Model
+	}
+	if overrides.MaxTokens > 0 {
+		cfg.API.MaxCompletionTokens = overrides.MaxTokens
+	}
+	temperature := c.temperature
+	if overrides.Temperature != nil {
+		temperature = overrides.Temperature
+	}
 	return &Client{
-		client: openai.NewClientWithConfig(openaiConfig),
-		config: cfg,
+		http:        c.http,
+		config:      &cfg,
+		temperature: temperature,
+		noTools:     c.noTools || overrides.NoTools,
+		jsonOutput:  c.jsonOutput || overrides.JSON,
+		cache:       c.cache,
+		fresh:       c.fresh || overrides.Fresh,
+	}
+}
+
+// tools returns the tools offered with a request, none for models that cannot call them
+func (c *Client) tools() []openai.Tool {
+	if c.noTools || !LookupModel(c.config.API.Model).Tools {
+		return nil
 	}
+	return ToolsForMode(c.config.Permissions.Mode)
+}
+
+// responseFormat asks for a JSON object when JSON output was requested
+func (c *Client) responseFormat() *openai.ChatCompletionResponseFormat {
+	if !c.jsonOutput {
+		return nil
+	}
+	return &openai.ChatCompletionResponseFormat{Type: openai.ChatCompletionResponseFormatTypeJSONObject}
 }
 
 // CreateChatCompletionStream creates a streaming chat completion
 func (c *Client) CreateChatCompletionStream(ctx context.Context, messages []openai.ChatCompletionMessage) (<-chan StreamEvent, error) {
-	// Removed log to prevent UI interference
-
-	// Create timeout context if configured
+	// The timeout covers the whole response, including retries; it is cancelled
+	// in the goroutine once streaming completes
 	var cancel context.CancelFunc
 	if c.config.API.TimeoutSeconds > 0 {
 		ctx, cancel = context.WithTimeout(ctx, time.Duration(c.config.API.TimeoutSeconds)*time.Second)
-		// Timeout context created
-		// Don't defer cancel here - it will be called in the goroutine when streaming completes
 	}
 
 	// Create the request
 	req := openai.ChatCompletionRequest{
 		Model:    c.config.API.Model,
 		Messages: messages,
-		Tools:    GetTools(),
+		Tools:    c.tools(),
 		Stream:   true,
+		// Usage, including cache hits, arrives in the last event
+		StreamOptions: &openai.StreamOptions{IncludeUsage: true},
+		// Nil unless the answer must be JSON
+		ResponseFormat: c.responseFormat(),
 		// MaxTokens is the standard field (not MaxCompletionTokens)
-		MaxTokens: c.config.API.MaxCompletionTokens,
+		MaxTokens: MaxOutputTokens(c.config),
+	}
+	c.applySampling(&req)
+
+	// An identical earlier request is answered from the cache, unless a fresh
+	// answer was asked for; that one then replaces the cached answer
+	var cacheKey string
+	if c.cache != nil {
+		if key, err := c.cache.key(req); err == nil {
+			if resp, ok := c.cache.get(key); ok && !c.fresh {
+				if cancel != nil {
+					cancel()
+				}
+				return resp.replay(), nil
+			}
+			cacheKey = key
+		}
 	}
 
-	// Create the stream
-	// Creating stream
-	stream, err := c.client.CreateChatCompletionStream(ctx, req)
+	// The first attempt is made here so a request that cannot succeed, such as
+	// one with a bad API key, fails at once; retries are reported as events
+	url, body, err := c.encodeRequest(req)
 	if err != nil {
-		// Stream creation failed
 		if cancel != nil {
 			cancel()
 		}
 		return nil, fmt.Errorf("creating chat completion stream: %w", err)
 	}
-	// Stream created successfully
+	resp, err := c.sendOnce(ctx, url, body)
+	if err != nil && !c.canRetry(ctx, err, 0) {
+		if cancel != nil {
+			cancel()
+		}
+		return nil, fmt.Errorf("creating chat completion stream: %w", c.describeTimeout(err))
+	}
 
-	// Create event channel
 	eventChan := make(chan StreamEvent, 100)
-
-	// Start goroutine to process stream
 	go func() {
-		// Starting stream processing
 		defer close(eventChan)
-		defer stream.Close()
-		// Cancel the timeout context when done
 		if cancel != nil {
 			defer cancel()
 		}
 
-		var currentContent string
-		var toolCalls []ToolCall
-
-		for {
-			response, err := stream.Recv()
-			if err != nil {
-				if errors.Is(err, io.EOF) {
-					// Stream finished
-					// Stream finished
-					eventChan <- StreamEvent{Type: EventTypeDone}
-					return
-				}
-				// Stream error
-				// Stream error occurred
-				eventChan <- StreamEvent{
-					Type:  EventTypeError,
-					Error: fmt.Errorf("stream error: %w", err),
-				}
-				return
-			}
-
-			// Process the response
-			if len(response.Choices) > 0 {
-				choice := response.Choices[0]
-				delta := choice.Delta
-
-				// Handle reasoning content if available
-				if delta.ReasoningContent != "" {
-					eventChan <- StreamEvent{
-						Type:             EventTypeReasoning,
-						ReasoningContent: delta.ReasoningContent,
-					}
-				}
-
-				// Handle regular content
-				if delta.Content != "" {
-					currentContent += delta.Content
-					eventChan <- StreamEvent{
-						Type:    EventTypeContent,
-						Content: delta.Content,
-					}
-				}
-
-				// Handle tool calls
-				if len(delta.ToolCalls) > 0 {
-					// Process tool call deltas
-					for _, toolCallDelta := range delta.ToolCalls {
-						if toolCallDelta.Index == nil {
-							continue
-						}
-
-						index := *toolCallDelta.Index
-						// Ensure we have enough tool calls
-						for len(toolCalls) <= index {
-							toolCalls = append(toolCalls, ToolCall{
-								Type:     "function",
-								Function: FunctionCall{},
-							})
-						}
-
-						// Update tool call
-						if toolCallDelta.ID != "" {
-							toolCalls[index].ID = toolCallDelta.ID
-						}
-						// Function is not a pointer in go-openai, so we check the fields directly
-						if toolCallDelta.Function.Name != "" {
-							toolCalls[index].Function.Name += toolCallDelta.Function.Name
-						}
-						if toolCallDelta.Function.Arguments != "" {
-							toolCalls[index].Function.Arguments += toolCallDelta.Function.Arguments
-						}
-					}
-				}
-
-				// Check if we have complete tool calls
-				if choice.FinishReason == openai.FinishReasonToolCalls && len(toolCalls) > 0 {
-					eventChan <- StreamEvent{
-						Type:      EventTypeToolCall,
-						ToolCalls: toolCalls,
-					}
-				}
-			}
-
-			// Check for usage information (typically sent at the end of stream)
-			if response.Usage != nil {
-				usage := &TokenUsage{
-					InputTokens:  response.Usage.PromptTokens,
-					OutputTokens: response.Usage.CompletionTokens,
-					// Note: DeepSeek's cached tokens might be in a custom field
-					// For now, we'll need to check if the API provides this
-					CachedTokens: 0,
-				}
-				eventChan <- StreamEvent{
-					Type:  EventTypeDone,
-					Usage: usage,
-				}
-				return
-			}
+		if err := c.streamResponse(ctx, url, body, resp, err, eventChan); err != nil {
+			eventChan <- StreamEvent{Type: EventTypeError, Error: err}
 		}
 	}()
 
+	if cacheKey != "" {
+		return c.cache.record(cacheKey, req.Model, eventChan), nil
+	}
 	return eventChan, nil
 }
 
+// describeTimeout names the configured limit when a request runs out of time
+func (c *Client) describeTimeout(err error) error {
+	if errors.Is(err, context.DeadlineExceeded) && c.config.API.TimeoutSeconds > 0 {
+		return fmt.Errorf("no complete response within %d seconds (api.timeout_seconds): %w", c.config.API.TimeoutSeconds, err)
+	}
+	return err
+}
+
+// applySampling pins temperature and seed in deterministic mode, then applies a
+// temperature override. A temperature of 0 would be dropped from the request by
+// omitempty, so the smallest positive value is sent instead; providers treat it
+// as greedy decoding.
+func (c *Client) applySampling(req *openai.ChatCompletionRequest) {
+	if c.config.API.Deterministic {
+		seed := c.config.API.Seed
+		req.Temperature = math.SmallestNonzeroFloat32
+		req.Seed = &seed
+	}
+	if c.temperature != nil {
+		req.Temperature = max(*c.temperature, math.SmallestNonzeroFloat32)
+	}
+}
+
 // CreateChatCompletion creates a non-streaming chat completion (for follow-ups)
 func (c *Client) CreateChatCompletion(ctx context.Context, messages []openai.ChatCompletionMessage) (*openai.ChatCompletionResponse, error) {
 	// Create timeout context if configured
@@ -192,17 +195,28 @@ func (c *Client) CreateChatCompletion(ctx context.Context, messages []openai.Cha
 
 	// Create the request
 	req := openai.ChatCompletionRequest{
-		Model:     c.config.API.Model,
-		Messages:  messages,
-		Tools:     GetTools(),
-		MaxTokens: c.config.API.MaxCompletionTokens,
+		Model:          c.config.API.Model,
+		Messages:       messages,
+		Tools:          c.tools(),
+		MaxTokens:      MaxOutputTokens(c.config),
+		ResponseFormat: c.responseFormat(),
 	}
+	c.applySampling(&req)
 
 	// Make the request
-	resp, err := c.client.CreateChatCompletion(ctx, req)
+	url, body, err := c.encodeRequest(req)
 	if err != nil {
 		return nil, fmt.Errorf("creating chat completion: %w", err)
 	}
+	resp, err := c.send(ctx, url, body)
+	if err != nil {
+		return nil, fmt.Errorf("creating chat completion: %w", c.describeTimeout(err))
+	}
+	defer resp.Body.Close()
 
-	return &resp, nil
+	var completion openai.ChatCompletionResponse
+	if err := json.NewDecoder(resp.Body).Decode(&completion); err != nil {
+		return nil, fmt.Errorf("reading chat completion: %w", c.describeTimeout(err))
+	}
+	return &completion, nil
 }
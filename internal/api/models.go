@@ -0,0 +1,173 @@
+package api
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/alchemy-labs-co/riptide/internal/config"
+)
+
+// ModelInfo is what the registry knows about a model: its limits, the features
+// it supports and its price
+type ModelInfo struct {
+	ContextWindow   int  // Tokens of input and output together
+	MaxOutputTokens int  // Largest completion the model will produce
+	Tools           bool // Whether the model can call tools
+	Reasoning       bool // Whether the model streams its reasoning
+	Pricing         Pricing
+}
+
+// Pricing is a model's price in US dollars per million tokens at regular hours
+type Pricing struct {
+	Input       float64
+	CachedInput float64
+	Output      float64
+}
+
+// defaultModel is assumed for models the registry doesn't know about
+var defaultModel = ModelInfo{
+	ContextWindow:   64000,
+	MaxOutputTokens: 8192,
+	Tools:           true,
+	Pricing:         Pricing{Input: 0.55, CachedInput: 0.14, Output: 2.19},
+}
+
+// bundledModels are the models Riptide knows out of the box
+var bundledModels = map[string]ModelInfo{
+	"deepseek-chat": {
+		ContextWindow:   64000,
+		MaxOutputTokens: 8192,
+		Tools:           true,
+		Pricing:         Pricing{Input: 0.27, CachedInput: 0.07, Output: 1.10},
+	},
+	"deepseek-reasoner": {
+		ContextWindow:   64000,
+		MaxOutputTokens: 64000,
+		Tools:           true,
+		Reasoning:       true,
+		Pricing:         Pricing{Input: 0.55, CachedInput: 0.14, Output: 2.19},
+	},
+}
+
+var (
+	registryMu sync.RWMutex
+	userModels map[string]config.ModelConfig
+)
+
+// RegisterModels adds models from the configuration to the registry, or
+// overrides what it knows about bundled ones
+func RegisterModels(models map[string]config.ModelConfig) error {
+	for name, model := range models {
+		if model.ContextWindow < 0 || model.MaxOutputTokens < 0 {
+			return fmt.Errorf("model %s: token limits cannot be negative", name)
+		}
+		if model.ContextWindow > 0 && model.MaxOutputTokens > model.ContextWindow {
+			return fmt.Errorf("model %s: max_output_tokens is larger than context_window", name)
+		}
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	userModels = models
+	return nil
+}
+
+// LookupModel returns what the registry knows about a model, falling back to
+// the defaults for anything unknown
+func LookupModel(name string) ModelInfo {
+	info, ok := bundledModels[name]
+	if !ok {
+		info = defaultModel
+	}
+
+	registryMu.RLock()
+	user, ok := userModels[name]
+	registryMu.RUnlock()
+	if !ok {
+		return info
+	}
+
+	if user.ContextWindow > 0 {
+		info.ContextWindow = user.ContextWindow
+	}
+	if user.MaxOutputTokens > 0 {
+		info.MaxOutputTokens = user.MaxOutputTokens
+	}
+	if user.Tools != nil {
+		info.Tools = *user.Tools
+	}
+	if user.Reasoning != nil {
+		info.Reasoning = *user.Reasoning
+	}
+	if user.InputPrice != nil {
+		info.Pricing.Input = *user.InputPrice
+	}
+	if user.CachedInputPrice != nil {
+		info.Pricing.CachedInput = *user.CachedInputPrice
+	}
+	if user.OutputPrice != nil {
+		info.Pricing.Output = *user.OutputPrice
+	}
+	return info
+}
+
+// ModelNames returns the bundled and configured model names, sorted
+func ModelNames() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	seen := make(map[string]bool)
+	var names []string
+	for name := range bundledModels {
+		seen[name] = true
+		names = append(names, name)
+	}
+	for name := range userModels {
+		if !seen[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ContextWindow returns the context window size in tokens for the given model
+func ContextWindow(model string) int {
+	return LookupModel(model).ContextWindow
+}
+
+// PricingFor returns the regular-hours prices for the given model
+func PricingFor(model string) Pricing {
+	return LookupModel(model).Pricing
+}
+
+// MaxOutputTokens returns the completion limit to request: the configured
+// limit capped at what the model can produce, or the model's limit when none
+// is configured
+func MaxOutputTokens(cfg *config.Config) int {
+	limit := LookupModel(cfg.API.Model).MaxOutputTokens
+	if configured := cfg.API.MaxCompletionTokens; configured > 0 && configured < limit {
+		return configured
+	}
+	return limit
+}
+
+// ContextBudget returns how many estimated tokens of history to keep before
+// trimming: the configured budget, capped so the history and a full-size
+// answer still fit in the model's context window
+func ContextBudget(cfg *config.Config) int {
+	info := LookupModel(cfg.API.Model)
+	limit := info.ContextWindow - min(MaxOutputTokens(cfg), info.ContextWindow/4)
+	if configured := cfg.UI.MaxContextTokens; configured > 0 && configured < limit {
+		return configured
+	}
+	return limit
+}
+
+// Cost returns the price of the given token usage in US dollars
+func (p Pricing) Cost(usage TokenUsage) float64 {
+	return (float64(usage.InputTokens)*p.Input +
+		float64(usage.CachedTokens)*p.CachedInput +
+		float64(usage.OutputTokens)*p.Output) / 1_000_000
+}
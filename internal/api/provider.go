@@ -0,0 +1,32 @@
+package api
+
+import (
+	"context"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// Provider streams chat completions from a model backend. Client is the
+// OpenAI-compatible implementation; others can stand in for it, e.g. in demo mode.
+type Provider interface {
+	CreateChatCompletionStream(ctx context.Context, messages []openai.ChatCompletionMessage) (<-chan StreamEvent, error)
+}
+
+// Overrides changes how individual requests are made without touching the configuration
+type Overrides struct {
+	Model       string   // Empty keeps the configured model
+	Temperature *float32 // Nil keeps the configured sampling
+	MaxTokens   int      // Zero keeps the configured completion limit
+	NoTools     bool     // Ask for a plain answer, without offering tools
+	JSON        bool     // Ask for the answer as a single JSON object
+	Fresh       bool     // Ask the API even when the response cache has an answer
+}
+
+// OverridableProvider is a Provider that can also send requests with Overrides
+type OverridableProvider interface {
+	Provider
+	WithOverrides(overrides Overrides) Provider
+}
+
+// Client must keep satisfying OverridableProvider
+var _ OverridableProvider = (*Client)(nil)
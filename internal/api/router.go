@@ -0,0 +1,114 @@
+package api
+
+import (
+	"regexp"
+	"strings"
+)
+
+// The models the router chooses between
+const (
+	ChatModel     = "deepseek-chat"
+	ReasonerModel = "deepseek-reasoner"
+)
+
+// Route is the model picked for a prompt and why
+type Route struct {
+	Model  string
+	Reason string
+}
+
+// routeThreshold is the score at which a prompt goes to the reasoner
+const routeThreshold = 3
+
+// Words that suggest a prompt asks for a change. Large changes usually span
+// several files; small ones may be a one-line edit.
+var (
+	largeChangeWords = wordSet("implement", "refactor", "rewrite", "migrate", "redesign", "port", "restructure", "integrate")
+	smallChangeWords = wordSet("fix", "add", "update", "change", "create", "write", "remove", "replace", "convert", "support")
+	reasoningWords   = wordSet("why", "debug", "design", "architecture", "tradeoff", "tradeoffs", "prove", "race",
+		"deadlock", "leak", "bug", "failing", "fails", "crash", "crashes", "optimize", "performance", "security")
+	questionWords = wordSet("what", "what's", "how", "where", "which", "who", "when", "is", "are", "does", "do", "can", "explain", "show", "list")
+)
+
+// filePattern matches file names and paths mentioned in a prompt
+var filePattern = regexp.MustCompile(`[\w./-]+\.(?:go|py|js|jsx|ts|tsx|rs|java|kt|c|h|cc|cpp|hpp|cs|rb|php|swift|scala|sql|sh|md|json|ya?ml|toml|html|css)\b`)
+
+// wordSet builds a lookup set of words
+func wordSet(words ...string) map[string]bool {
+	set := make(map[string]bool, len(words))
+	for _, word := range words {
+		set[word] = true
+	}
+	return set
+}
+
+// RoutePrompt picks the chat model for quick questions and small edits, and the
+// reasoner for changes that span several files or need careful thought. It is
+// a local heuristic over the prompt's wording, the files it names and the
+// files in context, so routing costs no extra request.
+func RoutePrompt(prompt string, contextFiles int) Route {
+	words := strings.Fields(strings.ToLower(prompt))
+	for i, word := range words {
+		words[i] = strings.Trim(word, ".,;:!?()[]{}\"'`")
+	}
+
+	score := 0
+	var reasons []string
+	switch {
+	case containsAny(words, largeChangeWords):
+		score += 2
+		reasons = append(reasons, "larger change")
+	case containsAny(words, smallChangeWords):
+		score++
+		reasons = append(reasons, "asks for a change")
+	}
+	if containsAny(words, reasoningWords) {
+		score++
+		reasons = append(reasons, "needs reasoning")
+	}
+
+	files := make(map[string]bool)
+	for _, match := range filePattern.FindAllString(prompt, -1) {
+		files[match] = true
+	}
+	switch {
+	case len(files) >= 2:
+		score += 2
+		reasons = append(reasons, "names several files")
+	case len(files) == 1:
+		score++
+	}
+	if contextFiles >= 2 {
+		score++
+		reasons = append(reasons, "several files in context")
+	}
+
+	switch {
+	case len(prompt) > 600:
+		score += 2
+		reasons = append(reasons, "long prompt")
+	case len(prompt) > 200:
+		score++
+	}
+
+	if score >= routeThreshold {
+		return Route{Model: ReasonerModel, Reason: strings.Join(reasons, ", ")}
+	}
+	switch {
+	case len(words) > 0 && questionWords[words[0]]:
+		return Route{Model: ChatModel, Reason: "quick question"}
+	case containsAny(words, largeChangeWords) || containsAny(words, smallChangeWords):
+		return Route{Model: ChatModel, Reason: "small change"}
+	}
+	return Route{Model: ChatModel, Reason: "simple request"}
+}
+
+// containsAny reports whether any of words is in set
+func containsAny(words []string, set map[string]bool) bool {
+	for _, word := range words {
+		if set[word] {
+			return true
+		}
+	}
+	return false
+}
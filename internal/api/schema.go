@@ -0,0 +1,339 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// schema is the subset of JSON Schema used by the tool definitions
+type schema struct {
+	Type                 string             `json:"type"`
+	Description          string             `json:"description"`
+	Properties           map[string]*schema `json:"properties"`
+	Required             []string           `json:"required"`
+	Items                *schema            `json:"items"`
+	AdditionalProperties *bool              `json:"additionalProperties"`
+}
+
+// schemaTypes are the types the validator understands
+var schemaTypes = map[string]bool{
+	"object": true, "array": true, "string": true,
+	"boolean": true, "integer": true, "number": true,
+}
+
+// FieldError is one problem with a tool call's arguments
+type FieldError struct {
+	Path    string // Dotted path to the field, e.g. files[0].path
+	Message string
+}
+
+// ArgumentError reports every problem found in a tool call's arguments so the
+// model can correct all of them in one retry
+type ArgumentError struct {
+	Tool   string
+	Fields []FieldError
+}
+
+func (e *ArgumentError) Error() string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("invalid arguments for %s:", e.Tool))
+	for _, field := range e.Fields {
+		b.WriteString(fmt.Sprintf("\n- %s: %s", field.Path, field.Message))
+	}
+	return b.String()
+}
+
+var (
+	schemasOnce sync.Once
+	schemas     map[string]*schema
+	schemasErr  error
+)
+
+// toolSchemas parses and checks the parameter schema of every tool once
+func toolSchemas() (map[string]*schema, error) {
+	schemasOnce.Do(func() {
+		schemas = make(map[string]*schema)
+		for _, tool := range GetTools() {
+			name := tool.Function.Name
+			raw, err := json.Marshal(tool.Function.Parameters)
+			if err != nil {
+				schemasErr = fmt.Errorf("marshaling %s parameters: %w", name, err)
+				return
+			}
+
+			var s schema
+			if err := json.Unmarshal(raw, &s); err != nil {
+				schemasErr = fmt.Errorf("parsing %s parameters: %w", name, err)
+				return
+			}
+			if tool.Function.Description == "" {
+				schemasErr = fmt.Errorf("%s: missing description", name)
+				return
+			}
+			if err := checkSchema(name, &s, true); err != nil {
+				schemasErr = err
+				return
+			}
+			schemas[name] = &s
+		}
+	})
+	return schemas, schemasErr
+}
+
+// checkSchema verifies a schema is well formed. Top-level properties must be
+// described, since the descriptions are all the model has to go on.
+func checkSchema(path string, s *schema, topLevel bool) error {
+	if !schemaTypes[s.Type] {
+		return fmt.Errorf("%s: unsupported type %q", path, s.Type)
+	}
+
+	switch s.Type {
+	case "object":
+		for _, name := range s.Required {
+			if _, ok := s.Properties[name]; !ok {
+				return fmt.Errorf("%s: required field %q is not a property", path, name)
+			}
+		}
+		for name, prop := range s.Properties {
+			if prop == nil {
+				return fmt.Errorf("%s.%s: empty schema", path, name)
+			}
+			if topLevel && prop.Description == "" {
+				return fmt.Errorf("%s.%s: missing description", path, name)
+			}
+			if err := checkSchema(path+"."+name, prop, false); err != nil {
+				return err
+			}
+		}
+	case "array":
+		if s.Items == nil {
+			return fmt.Errorf("%s: array without items", path)
+		}
+		return checkSchema(path+"[]", s.Items, false)
+	}
+	return nil
+}
+
+// ValidateToolDefinitions checks every tool's parameter schema. Call it at
+// startup so a broken definition fails fast instead of confusing the model.
+func ValidateToolDefinitions() error {
+	_, err := toolSchemas()
+	return err
+}
+
+// ValidateToolArguments checks a tool call's arguments against the tool's schema,
+// rejecting missing required fields, wrong types and unknown fields. It returns
+// nil or an *ArgumentError.
+func ValidateToolArguments(name, arguments string) error {
+	all, err := toolSchemas()
+	if err != nil {
+		return err
+	}
+	s, ok := all[name]
+	if !ok {
+		return fmt.Errorf("unknown function: %s", name)
+	}
+
+	// Some models send an empty string for calls without arguments
+	if strings.TrimSpace(arguments) == "" {
+		arguments = "{}"
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader([]byte(arguments)))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return &ArgumentError{Tool: name, Fields: []FieldError{{Path: "(arguments)", Message: fmt.Sprintf("not valid JSON: %v", err)}}}
+	}
+
+	var fields []FieldError
+	validateValue("(arguments)", "", s, value, &fields)
+	if len(fields) > 0 {
+		return &ArgumentError{Tool: name, Fields: fields}
+	}
+	return nil
+}
+
+// OutputSchema is a user-supplied JSON Schema that a structured answer must
+// match. Only the subset the tool definitions use is enforced: types, required
+// fields, properties, items and additionalProperties. As with tool arguments,
+// unknown fields are rejected unless additionalProperties is true.
+type OutputSchema struct {
+	Source string // The schema as written, for the prompt
+	root   *schema
+}
+
+// ParseOutputSchema parses and checks a JSON Schema for an object
+func ParseOutputSchema(data []byte) (*OutputSchema, error) {
+	var s schema
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("parsing schema: %w", err)
+	}
+	// JSON mode only returns objects, so the answer must be one
+	if s.Type != "object" {
+		return nil, fmt.Errorf("checking schema: the top level must be an object, not %q", s.Type)
+	}
+	if err := checkSchema("(schema)", &s, false); err != nil {
+		return nil, fmt.Errorf("checking schema: %w", err)
+	}
+
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, data, "", "  "); err != nil {
+		return nil, fmt.Errorf("formatting schema: %w", err)
+	}
+	return &OutputSchema{Source: indented.String(), root: &s}, nil
+}
+
+// OutputError reports every way an answer fails to match its OutputSchema
+type OutputError struct {
+	Fields []FieldError
+}
+
+func (e *OutputError) Error() string {
+	var b strings.Builder
+	b.WriteString("answer does not match the schema:")
+	for _, field := range e.Fields {
+		b.WriteString(fmt.Sprintf("\n- %s: %s", field.Path, field.Message))
+	}
+	return b.String()
+}
+
+// Validate checks that text is a single JSON value matching the schema. It
+// returns nil or an *OutputError.
+func (o *OutputSchema) Validate(text string) error {
+	decoder := json.NewDecoder(strings.NewReader(text))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return &OutputError{Fields: []FieldError{{Path: "(answer)", Message: fmt.Sprintf("not valid JSON: %v", err)}}}
+	}
+	if decoder.More() {
+		return &OutputError{Fields: []FieldError{{Path: "(answer)", Message: "more than one JSON value"}}}
+	}
+
+	var fields []FieldError
+	validateValue("(answer)", "", o.root, value, &fields)
+	if len(fields) > 0 {
+		return &OutputError{Fields: fields}
+	}
+	return nil
+}
+
+// validateValue appends a FieldError for each way value fails to match s. root
+// names the value itself in errors about it.
+func validateValue(root, path string, s *schema, value interface{}, fields *[]FieldError) {
+	display := path
+	if display == "" {
+		display = root
+	}
+	fail := func(format string, args ...interface{}) {
+		*fields = append(*fields, FieldError{Path: display, Message: fmt.Sprintf(format, args...)})
+	}
+
+	switch s.Type {
+	case "object":
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			fail("expected an object, got %s", jsonType(value))
+			return
+		}
+		for _, name := range s.Required {
+			if _, ok := obj[name]; !ok {
+				*fields = append(*fields, FieldError{Path: joinPath(path, name), Message: "missing required field"})
+			}
+		}
+
+		// Visit fields in a stable order so errors read the same on every run
+		names := make([]string, 0, len(obj))
+		for name := range obj {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			prop, ok := s.Properties[name]
+			if !ok {
+				if s.AdditionalProperties == nil || !*s.AdditionalProperties {
+					*fields = append(*fields, FieldError{
+						Path:    joinPath(path, name),
+						Message: fmt.Sprintf("unknown field (expected one of %s)", strings.Join(propertyNames(s), ", ")),
+					})
+				}
+				continue
+			}
+			validateValue(root, joinPath(path, name), prop, obj[name], fields)
+		}
+
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			fail("expected an array, got %s", jsonType(value))
+			return
+		}
+		for i, item := range items {
+			validateValue(root, fmt.Sprintf("%s[%d]", path, i), s.Items, item, fields)
+		}
+
+	case "string":
+		if _, ok := value.(string); !ok {
+			fail("expected a string, got %s", jsonType(value))
+		}
+
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			fail("expected a boolean, got %s", jsonType(value))
+		}
+
+	case "integer", "number":
+		n, ok := value.(json.Number)
+		if !ok {
+			fail("expected a %s, got %s", s.Type, jsonType(value))
+			return
+		}
+		if s.Type == "integer" {
+			if _, err := n.Int64(); err != nil {
+				fail("expected an integer, got %s", n)
+			}
+		}
+	}
+}
+
+// joinPath appends a field name to a dotted path
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// propertyNames returns the sorted property names of an object schema
+func propertyNames(s *schema) []string {
+	names := make([]string, 0, len(s.Properties))
+	for name := range s.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// jsonType names the JSON type of a decoded value for error messages
+func jsonType(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case map[string]interface{}:
+		return "an object"
+	case []interface{}:
+		return "an array"
+	case string:
+		return "a string"
+	case bool:
+		return "a boolean"
+	case json.Number:
+		return "a number"
+	}
+	return fmt.Sprintf("%T", value)
+}
@@ -0,0 +1,387 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// Chat completions are sent with net/http and streamed responses are parsed
+// here, rather than through go-openai's client, so that provider-specific
+// fields such as DeepSeek's cache hit counts reach Riptide and retries and
+// timeouts are under its control. go-openai's types are still used for the
+// messages and tools in a request.
+
+// FinishReasonLength is the finish reason of an answer cut off at the completion limit
+const FinishReasonLength = "length"
+
+// Backoff between retries: the delay doubles with each attempt and up to half
+// of it is random, so clients that failed together do not retry together. A
+// Retry-After from the server is honoured up to the cap.
+const (
+	retryBaseDelay = time.Second
+	maxRetryDelay  = 30 * time.Second
+)
+
+// maxErrorBody limits how much of an error response is read
+const maxErrorBody = 64 * 1024
+
+// errStreamTruncated is returned when a stream ends before the model finished
+var errStreamTruncated = errors.New("stream ended before the response finished")
+
+// APIError is an error returned by the chat completions endpoint, either as a
+// response status or as an event in the middle of a stream
+type APIError struct {
+	StatusCode int // Zero for errors reported inside a stream
+	Message    string
+	Type       string
+	retryAfter time.Duration
+}
+
+// Error implements the error interface
+func (e *APIError) Error() string {
+	if e.StatusCode == 0 {
+		return "API error: " + e.Message
+	}
+	if e.Message == "" {
+		return fmt.Sprintf("API error (%d %s)", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	return fmt.Sprintf("API error (%d): %s", e.StatusCode, e.Message)
+}
+
+// retryable reports whether sending the same request again may succeed
+func (e *APIError) retryable() bool {
+	switch e.StatusCode {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// errorBody is the JSON body of an error response
+type errorBody struct {
+	Error *struct {
+		Message string `json:"message"`
+		Type    string `json:"type"`
+	} `json:"error"`
+}
+
+// streamChunk is one event of a streamed chat completion
+type streamChunk struct {
+	Choices []struct {
+		Delta struct {
+			Content          string          `json:"content"`
+			ReasoningContent string          `json:"reasoning_content"`
+			Reasoning        string          `json:"reasoning"` // Name some other OpenAI-compatible providers use
+			ToolCalls        []toolCallDelta `json:"tool_calls"`
+		} `json:"delta"`
+		FinishReason string `json:"finish_reason"`
+	} `json:"choices"`
+	Usage *chatUsage `json:"usage"`
+	errorBody
+}
+
+// toolCallDelta is a fragment of a tool call; fragments with the same index
+// belong to the same call
+type toolCallDelta struct {
+	Index    *int   `json:"index"`
+	ID       string `json:"id"`
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
+// chatUsage is the token usage of a response. DeepSeek reports the prompt
+// tokens served from its context cache separately; OpenAI-style providers put
+// them in the prompt details.
+type chatUsage struct {
+	PromptTokens         int `json:"prompt_tokens"`
+	CompletionTokens     int `json:"completion_tokens"`
+	PromptCacheHitTokens int `json:"prompt_cache_hit_tokens"`
+	PromptTokensDetails  *struct {
+		CachedTokens int `json:"cached_tokens"`
+	} `json:"prompt_tokens_details"`
+}
+
+// tokenUsage splits the prompt into uncached and cached tokens, which are priced differently
+func (u *chatUsage) tokenUsage() *TokenUsage {
+	cached := u.PromptCacheHitTokens
+	if cached == 0 && u.PromptTokensDetails != nil {
+		cached = u.PromptTokensDetails.CachedTokens
+	}
+	return &TokenUsage{
+		InputTokens:  u.PromptTokens - cached,
+		OutputTokens: u.CompletionTokens,
+		CachedTokens: cached,
+	}
+}
+
+// encodeRequest returns the endpoint and JSON body of a chat completion request
+func (c *Client) encodeRequest(req openai.ChatCompletionRequest) (string, []byte, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return "", nil, fmt.Errorf("marshaling request: %w", err)
+	}
+	return strings.TrimRight(c.config.API.BaseURL, "/") + "/chat/completions", body, nil
+}
+
+// send posts a chat completion request and returns the successful response,
+// retrying rate limits, server errors and failed connections with exponential
+// backoff
+func (c *Client) send(ctx context.Context, url string, body []byte) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		resp, err := c.sendOnce(ctx, url, body)
+		if err == nil {
+			return resp, nil
+		}
+		if !c.canRetry(ctx, err, attempt) {
+			return nil, err
+		}
+		if !sleep(ctx, retryDelay(err, attempt)) {
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// streamResponse reads a streamed response into events. A send that failed, or
+// a stream that breaks off part way, is sent again with backoff while retries
+// remain. A retry event announces each wait and, when the failed attempt had
+// already streamed output, tells the reader to discard it: the new attempt
+// streams the answer from the start.
+func (c *Client) streamResponse(ctx context.Context, url string, body []byte, resp *http.Response, err error, events chan<- StreamEvent) error {
+	for attempt := 0; ; attempt++ {
+		restart := false
+		if err == nil {
+			var streamed bool
+			streamed, err = readStream(resp.Body, events)
+			resp.Body.Close()
+			if err == nil {
+				return nil
+			}
+			if !streamTransient(err) || !c.canRetry(ctx, err, attempt) {
+				return fmt.Errorf("stream error: %w", c.describeTimeout(err))
+			}
+			restart = streamed
+		} else if !c.canRetry(ctx, err, attempt) {
+			return fmt.Errorf("creating chat completion stream: %w", c.describeTimeout(err))
+		}
+
+		delay := retryDelay(err, attempt)
+		events <- StreamEvent{Type: EventTypeRetry, Retry: &RetryInfo{
+			Attempt:     attempt + 2,
+			MaxAttempts: c.config.API.MaxRetries + 1,
+			Delay:       delay,
+			Err:         err,
+			Restart:     restart,
+		}}
+		if !sleep(ctx, delay) {
+			return fmt.Errorf("creating chat completion stream: %w", c.describeTimeout(ctx.Err()))
+		}
+		resp, err = c.sendOnce(ctx, url, body)
+	}
+}
+
+// canRetry reports whether a request that failed with err on the given attempt
+// (counting from 0) should be sent again
+func (c *Client) canRetry(ctx context.Context, err error, attempt int) bool {
+	if attempt >= c.config.API.MaxRetries || ctx.Err() != nil {
+		return false
+	}
+	var apiErr *APIError
+	return !errors.As(err, &apiErr) || apiErr.retryable()
+}
+
+// streamTransient reports whether a stream broke off from a dropped connection
+// rather than a malformed or rejected response
+func streamTransient(err error) bool {
+	var netErr net.Error
+	return errors.Is(err, errStreamTruncated) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.As(err, &netErr)
+}
+
+// retryDelay returns how long to wait before sending a request again after
+// attempt (counting from 0) failed with err
+func retryDelay(err error, attempt int) time.Duration {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.retryAfter > 0 {
+		return min(apiErr.retryAfter, maxRetryDelay)
+	}
+	delay := min(retryBaseDelay<<attempt, maxRetryDelay)
+	return delay/2 + rand.N(delay/2+1)
+}
+
+// sleep waits for d, returning false if ctx is done first
+func sleep(ctx context.Context, d time.Duration) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(d):
+		return true
+	}
+}
+
+// sendOnce makes a single attempt at a request, turning error statuses into APIErrors
+func (c *Client) sendOnce(ctx context.Context, url string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("building request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.config.APIKey)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusOK {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+	return nil, readAPIError(resp)
+}
+
+// readAPIError builds an APIError from an error response, using the message in
+// its JSON body when there is one
+func readAPIError(resp *http.Response) *APIError {
+	apiErr := &APIError{StatusCode: resp.StatusCode}
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+		apiErr.retryAfter = time.Duration(seconds) * time.Second
+	}
+
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	var body errorBody
+	if json.Unmarshal(data, &body) == nil && body.Error != nil {
+		apiErr.Message = body.Error.Message
+		apiErr.Type = body.Error.Type
+	} else {
+		apiErr.Message = strings.TrimSpace(string(data))
+	}
+	return apiErr
+}
+
+// sseReader reads the data of server-sent events one event at a time
+type sseReader struct {
+	r *bufio.Reader
+}
+
+// next returns the data of the next event, joining multi-line data with
+// newlines. Comments such as keep-alives and fields other than data are skipped.
+func (s *sseReader) next() ([]byte, error) {
+	var data []byte
+	hasData := false
+	for {
+		line, err := s.r.ReadBytes('\n')
+		if err != nil && len(line) == 0 {
+			if errors.Is(err, io.EOF) && hasData {
+				return data, nil
+			}
+			return nil, err
+		}
+
+		line = bytes.TrimRight(line, "\r\n")
+		switch {
+		case len(line) == 0:
+			if hasData {
+				return data, nil
+			}
+		case bytes.HasPrefix(line, []byte("data:")):
+			value := bytes.TrimPrefix(line[len("data:"):], []byte(" "))
+			if hasData {
+				data = append(data, '\n')
+			}
+			data = append(data, value...)
+			hasData = true
+		}
+	}
+}
+
+// readStream sends the events of a streamed completion as they arrive, ending
+// with the tool calls, if any, and a done event carrying the usage and finish
+// reason. A stream that ends before the model finished is an error rather than
+// a silently truncated answer. It reports whether any event was sent.
+func readStream(body io.Reader, events chan<- StreamEvent) (bool, error) {
+	stream := &sseReader{r: bufio.NewReader(body)}
+	var toolCalls []ToolCall
+	var usage *TokenUsage
+	finishReason := ""
+	streamed := false
+
+	for {
+		data, err := stream.next()
+		if errors.Is(err, io.EOF) {
+			if finishReason == "" {
+				return streamed, errStreamTruncated
+			}
+			break
+		}
+		if err != nil {
+			return streamed, err
+		}
+		if string(data) == "[DONE]" {
+			break
+		}
+
+		var chunk streamChunk
+		if err := json.Unmarshal(data, &chunk); err != nil {
+			return streamed, fmt.Errorf("parsing stream event: %w", err)
+		}
+		if chunk.Error != nil {
+			return streamed, &APIError{Message: chunk.Error.Message, Type: chunk.Error.Type}
+		}
+		if chunk.Usage != nil {
+			usage = chunk.Usage.tokenUsage()
+		}
+		if len(chunk.Choices) == 0 {
+			continue
+		}
+
+		choice := chunk.Choices[0]
+		delta := choice.Delta
+		if reasoning := delta.ReasoningContent + delta.Reasoning; reasoning != "" {
+			events <- StreamEvent{Type: EventTypeReasoning, ReasoningContent: reasoning}
+			streamed = true
+		}
+		if delta.Content != "" {
+			events <- StreamEvent{Type: EventTypeContent, Content: delta.Content}
+			streamed = true
+		}
+		for _, fragment := range delta.ToolCalls {
+			if fragment.Index == nil {
+				continue
+			}
+			index := *fragment.Index
+			for len(toolCalls) <= index {
+				toolCalls = append(toolCalls, ToolCall{Type: "function"})
+			}
+			if fragment.ID != "" {
+				toolCalls[index].ID = fragment.ID
+			}
+			toolCalls[index].Function.Name += fragment.Function.Name
+			toolCalls[index].Function.Arguments += fragment.Function.Arguments
+		}
+		if choice.FinishReason != "" {
+			finishReason = choice.FinishReason
+		}
+	}
+
+	if len(toolCalls) > 0 {
+		events <- StreamEvent{Type: EventTypeToolCall, ToolCalls: toolCalls}
+	}
+	events <- StreamEvent{Type: EventTypeDone, Usage: usage, FinishReason: finishReason}
+	return true, nil
+}
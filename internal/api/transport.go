@@ -0,0 +1,49 @@
+package api
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/alchemy-labs-co/riptide/internal/config"
+)
+
+// newTransport builds the transport API requests go through: the configured
+// proxy, or the one HTTPS_PROXY or HTTP_PROXY names for the base URL unless
+// NO_PROXY excludes it, trusting the system roots plus any custom CA bundle
+func newTransport(cfg config.APIConfig) (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	transport.Proxy = http.ProxyFromEnvironment
+	if cfg.Proxy != "" {
+		proxy, err := url.Parse(cfg.Proxy)
+		if err != nil || proxy.Host == "" {
+			return nil, fmt.Errorf("proxy must be a URL such as http://proxy:3128, got %q", cfg.Proxy)
+		}
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+
+	if cfg.CACert == "" && !cfg.InsecureSkipVerify {
+		return transport, nil
+	}
+	tlsConfig := &tls.Config{InsecureSkipVerify: cfg.InsecureSkipVerify}
+	if cfg.CACert != "" {
+		pem, err := os.ReadFile(cfg.CACert)
+		if err != nil {
+			return nil, fmt.Errorf("reading CA bundle: %w", err)
+		}
+		roots, err := x509.SystemCertPool()
+		if err != nil {
+			roots = x509.NewCertPool()
+		}
+		if !roots.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no PEM certificates found in %s", cfg.CACert)
+		}
+		tlsConfig.RootCAs = roots
+	}
+	transport.TLSClientConfig = tlsConfig
+	return transport, nil
+}
@@ -5,16 +5,37 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
 
 // Config represents the application configuration
 type Config struct {
-	API            APIConfig            `json:"api"`
-	UI             UIConfig             `json:"ui"`
-	FileOperations FileOperationsConfig `json:"file_operations"`
-	APIKey         string               `json:"-"` // Not stored in JSON, loaded from env
+	API              APIConfig              `json:"api"`
+	UI               UIConfig               `json:"ui"`
+	FileOperations   FileOperationsConfig   `json:"file_operations"`
+	Ambient          AmbientConfig          `json:"ambient"`
+	Permissions      PermissionsConfig      `json:"permissions"`
+	Redaction        RedactionConfig        `json:"redaction"`
+	Commands         CommandPolicyConfig    `json:"commands"`
+	Telemetry        TelemetryConfig        `json:"telemetry"`
+	Updates          UpdatesConfig          `json:"updates"`
+	Hooks            HooksConfig            `json:"hooks"`
+	Format           FormatConfig           `json:"format"`
+	LSP              LSPConfig              `json:"lsp"`
+	RepoMap          RepoMapConfig          `json:"repo_map"`
+	Databases        DatabasesConfig        `json:"databases"`
+	HTTP             HTTPConfig             `json:"http"`
+	Docker           DockerConfig           `json:"docker"`
+	Cache            CacheConfig            `json:"cache"`
+	Models           map[string]ModelConfig `json:"models"`             // Added to or overriding the bundled model registry
+	SystemPrompt     string                 `json:"system_prompt"`      // Appended to the built-in system prompt, e.g. project conventions
+	SystemPromptPath string                 `json:"system_prompt_path"` // File replacing the built-in system prompt, with {{cwd}}-style variables
+	APIKey           string                 `json:"-"`                  // Not stored in JSON, loaded from env
+	ProjectPath      string                 `json:"-"`                  // The project file merged over the global config, if one was found
+	ProjectIgnored   []string               `json:"-"`                  // Options of an untrusted project file that were not applied
+	Outdated         []string               `json:"-"`                  // Options of config.json that no longer apply, each with what replaced it
 }
 
 // APIConfig contains API-related settings
@@ -23,87 +44,542 @@ type APIConfig struct {
 	Model               string `json:"model"`
 	MaxCompletionTokens int    `json:"max_completion_tokens"`
 	TimeoutSeconds      int    `json:"timeout_seconds"`
+	Deterministic       bool   `json:"deterministic"`        // Temperature 0 and a fixed seed for reproducible runs
+	Seed                int    `json:"seed"`                 // Seed sent in deterministic mode
+	Routing             bool   `json:"routing"`              // Pick deepseek-chat or deepseek-reasoner for each prompt
+	MaxRetries          int    `json:"max_retries"`          // Retries of a request that failed before streaming began
+	TitleModel          string `json:"title_model"`          // Model that titles new sessions; empty turns titles off
+	SummaryModel        string `json:"summary_model"`        // Model that summarizes turns trimmed from the context; empty drops them
+	Proxy               string `json:"proxy"`                // Proxy URL for API requests; empty uses HTTPS_PROXY or HTTP_PROXY
+	CACert              string `json:"ca_cert"`              // PEM bundle trusted besides the system roots, e.g. a gateway's private CA
+	InsecureSkipVerify  bool   `json:"insecure_skip_verify"` // Skip TLS certificate checks; only for testing a gateway
 }
 
 // UIConfig contains UI-related settings
 type UIConfig struct {
-	Theme              string `json:"theme"`
-	EnableEmoji        bool   `json:"enable_emoji"`
-	MaxHistoryMessages int    `json:"max_history_messages"`
+	Theme              string                 `json:"theme"`
+	EnableEmoji        bool                   `json:"enable_emoji"`
+	MaxContextTokens   int                    `json:"max_context_tokens"`             // History is trimmed to stay under this estimate
+	MaxHistoryMessages int                    `json:"max_history_messages,omitempty"` // Replaced by max_context_tokens; only read to say so
+	Timestamps         string                 `json:"timestamps"`                     // relative, absolute or hidden
+	PasteLines         int                    `json:"paste_lines"`                    // Pastes with more lines are attached instead of typed; 0 never attaches by lines
+	PasteChars         int                    `json:"paste_chars"`                    // Pastes with more characters are attached instead of typed; 0 never attaches by size
+	Themes             map[string]ThemeConfig `json:"themes,omitempty"`               // Custom themes by name, selectable as theme
+	Keymap             string                 `json:"keymap"`                         // default or vim
+	ShowReasoning      string                 `json:"show_reasoning"`                 // always, collapsed or never
 }
 
 // FileOperationsConfig contains file operation settings
 type FileOperationsConfig struct {
-	MaxFileSizeMB   int `json:"max_file_size_mb"`
-	MaxFilesPerScan int `json:"max_files_per_scan"`
-	BinaryPeekSize  int `json:"binary_peek_size"`
+	MaxFileSizeMB   int      `json:"max_file_size_mb"`
+	MaxFilesPerScan int      `json:"max_files_per_scan"`
+	BinaryPeekSize  int      `json:"binary_peek_size"`
+	Exclude         []string `json:"exclude"`         // File and directory names or globs such as "*.pb.go" skipped when scanning
+	RefreshContext  bool     `json:"refresh_context"` // Re-read files in context that changed on disk before each request
+	WorkspaceRoot   string   `json:"workspace_root"`  // Directory tool calls are confined to; empty is the directory Riptide starts in
+	AllowedPaths    []string `json:"allowed_paths"`   // Directories outside the workspace that tool calls may also use
+	BlockOutside    bool     `json:"block_outside"`   // Refuse other paths outside the workspace instead of asking
 }
 
-// Load loads configuration from config.json and environment variables
+// IsExcluded reports whether a file or directory name matches one of the
+// configured exclude patterns
+func (f FileOperationsConfig) IsExcluded(name string) bool {
+	for _, pattern := range f.Exclude {
+		if matched, err := filepath.Match(pattern, name); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+// Timestamp display modes for UIConfig.Timestamps
+const (
+	TimestampsRelative = "relative"
+	TimestampsAbsolute = "absolute"
+	TimestampsHidden   = "hidden"
+)
+
+// How reasoning is shown in the transcript, for UIConfig.ShowReasoning
+const (
+	ReasoningAlways    = "always"
+	ReasoningCollapsed = "collapsed" // A one-line summary, expanded with Ctrl+G
+	ReasoningNever     = "never"
+)
+
+// Key bindings for UIConfig.Keymap
+const (
+	KeymapDefault = "default"
+	KeymapVim     = "vim" // Normal and insert modes in the input, j/k scrolling and / search
+)
+
+// AmbientConfig controls the environment reminder injected before each request
+type AmbientConfig struct {
+	Enabled       bool `json:"enabled"`
+	Time          bool `json:"time"`
+	WorkingDir    bool `json:"working_dir"`
+	Git           bool `json:"git"`
+	OS            bool `json:"os"`
+	MaxDirtyFiles int  `json:"max_dirty_files"`
+}
+
+// PermissionsConfig controls which tools the model is offered and when writes need approval
+type PermissionsConfig struct {
+	Mode             string `json:"mode"`               // readonly, edit or auto
+	AutoApproveEdits bool   `json:"auto_approve_edits"` // In edit mode, write files without showing the diff for approval
+	MaxIterations    int    `json:"max_iterations"`     // Rounds of tool calls run for one prompt before stopping to ask; 0 is unlimited
+}
+
+// Permission modes for PermissionsConfig.Mode
+const (
+	ModeReadOnly = "readonly" // Only read tools are offered
+	ModeEdit     = "edit"     // Writes run after the user approves them
+	ModeAuto     = "auto"     // Every tool runs without asking
+)
+
+// RedactionConfig controls secret scrubbing of file content and tool output
+type RedactionConfig struct {
+	Enabled  bool     `json:"enabled"`
+	Patterns []string `json:"patterns"` // Extra regular expressions; a capture group limits what is replaced
+	Entropy  bool     `json:"entropy"`  // Also redact quoted strings random enough to be keys, in prompts and env or config files
+}
+
+// CommandPolicyConfig controls how dangerous shell commands are handled, and
+// which commands run_command may run without asking
+type CommandPolicyConfig struct {
+	Dangerous      string        `json:"dangerous"`       // confirm (ask twice) or block, for built-in rules
+	Rules          []CommandRule `json:"rules"`           // Extra rules checked alongside the built-in ones
+	Allow          []string      `json:"allow"`           // Command prefixes run_command runs without approval, e.g. "go test"
+	Deny           []string      `json:"deny"`            // Command prefixes that are never run, by any tool
+	TimeoutSeconds int           `json:"timeout_seconds"` // run_command stops commands still running after this
+}
+
+// CommandRule flags shell commands whose pipeline segments match Pattern
+type CommandRule struct {
+	Name    string `json:"name"`
+	Pattern string `json:"pattern"` // Regular expression matched against each segment
+	Action  string `json:"action"`  // confirm or block; empty uses the dangerous policy
+}
+
+// Actions for CommandPolicyConfig.Dangerous and CommandRule.Action
+const (
+	CommandActionConfirm = "confirm"
+	CommandActionBlock   = "block"
+)
+
+// TelemetryConfig controls usage analytics. Riptide currently collects none; the
+// setting exists so any future collection is opt-in and off by default.
+type TelemetryConfig struct {
+	Enabled bool `json:"enabled"`
+}
+
+// UpdatesConfig controls the once-a-day check for new releases
+type UpdatesConfig struct {
+	Check bool `json:"check"`
+}
+
+// HooksConfig lists shell commands run at lifecycle events. Each receives a JSON
+// description of the event on stdin and RIPTIDE_EVENT in its environment.
+type HooksConfig struct {
+	PreTool        []string `json:"pre_tool"`  // A non-zero exit blocks the tool call
+	PostEdit       []string `json:"post_edit"` // Failures are reported to the model
+	PostTurn       []string `json:"post_turn"`
+	SessionEnd     []string `json:"session_end"`
+	TimeoutSeconds int      `json:"timeout_seconds"`
+}
+
+// FormatConfig runs formatters and linters on the files the model writes,
+// picked by file extension. A command gets the files as arguments, or in
+// place of {files}; {dirs} is replaced by their directories, as ./path.
+type FormatConfig struct {
+	Enabled        bool              `json:"enabled"`
+	Formatters     map[string]string `json:"formatters"` // Extension to command, e.g. ".go": "gofmt -w"; "" turns one off
+	Linters        map[string]string `json:"linters"`    // Extension to command, run after formatting; any output goes to the model
+	TimeoutSeconds int               `json:"timeout_seconds"`
+}
+
+// LSPConfig picks the language servers behind find_definition,
+// find_references and document_symbols, by file extension. A server is
+// started the first time a file it handles is asked about.
+type LSPConfig struct {
+	Servers        map[string]string `json:"servers"`         // Extension to server command line, e.g. ".go": "gopls"; "" turns one off
+	TimeoutSeconds int               `json:"timeout_seconds"` // Limit on one request, including starting the server
+}
+
+// RepoMapConfig controls the repository map: the project's files with their
+// top-level declarations, cut down to fit MaxTokens
+type RepoMapConfig struct {
+	Auto      bool `json:"auto"` // Add the map to the system prompt when a session starts
+	MaxTokens int  `json:"max_tokens"`
+}
+
+// Database drivers supported by query_database, each run through its command-line client
+const (
+	DriverPostgres = "postgres" // psql
+	DriverMySQL    = "mysql"    // mysql
+	DriverSQLite   = "sqlite"   // sqlite3
+)
+
+// DatabasesConfig lists the databases the query_database tool may read
+type DatabasesConfig struct {
+	Connections map[string]DatabaseConnection `json:"connections"` // Keyed by the name the model uses
+	MaxRows     int                           `json:"max_rows"`
+	MaxBytes    int                           `json:"max_bytes"`
+}
+
+// DatabaseConnection is one named database. The DSN may reference environment
+// variables as ${NAME} so credentials stay out of the config file.
+type DatabaseConnection struct {
+	Driver string `json:"driver"`
+	DSN    string `json:"dsn"` // URL for postgres and mysql, file path for sqlite
+}
+
+// HTTPConfig controls the http_request tool. Requests go to localhost only,
+// unless the host is allowlisted ("api.example.com" or "*.example.com").
+type HTTPConfig struct {
+	AllowedHosts     []string `json:"allowed_hosts"`
+	MaxResponseBytes int      `json:"max_response_bytes"`
+	TimeoutSeconds   int      `json:"timeout_seconds"`
+}
+
+// DockerConfig limits the containers docker_run starts. They always run without
+// host mounts, with all capabilities dropped and no privilege escalation.
+type DockerConfig struct {
+	Network        bool   `json:"network"`         // Give containers network access (off by default)
+	Memory         string `json:"memory"`          // docker run --memory, e.g. "1g"
+	CPUs           string `json:"cpus"`            // docker run --cpus
+	TimeoutSeconds int    `json:"timeout_seconds"` // Containers still running after this are removed
+}
+
+// CacheConfig controls the response cache, which answers a request identical to
+// an earlier one (same model, parameters and messages) without calling the API
+type CacheConfig struct {
+	Enabled  bool `json:"enabled"`
+	TTLHours int  `json:"ttl_hours"` // Cached responses older than this are ignored
+}
+
+// ModelConfig describes a model for the capability registry. Fields left out
+// keep the bundled values for a known model, or the defaults for a new one.
+type ModelConfig struct {
+	ContextWindow    int      `json:"context_window"`
+	MaxOutputTokens  int      `json:"max_output_tokens"`
+	Tools            *bool    `json:"tools"`       // Whether the model can call tools
+	Reasoning        *bool    `json:"reasoning"`   // Whether the model streams its reasoning
+	InputPrice       *float64 `json:"input_price"` // US dollars per million tokens
+	CachedInputPrice *float64 `json:"cached_input_price"`
+	OutputPrice      *float64 `json:"output_price"`
+}
+
+// Path returns the config file location, honoring DEEPSEEK_CONFIG_PATH. By
+// default it is riptide/config.json in the user config directory, so a
+// repository cannot supply it; it is "" when that directory is unknown.
+func Path() string {
+	if configPath := os.Getenv("DEEPSEEK_CONFIG_PATH"); configPath != "" {
+		return configPath
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "riptide", "config.json")
+}
+
+// LegacyFile is where the global config used to be read from: the working
+// directory. Any repository can ship one, so it is now merged like a project
+// file, with the same trust check.
+const LegacyFile = "config.json"
+
+// ProjectFile is the project configuration, read from the working directory.
+// It has the same shape as config.json and only the options it sets override
+// the global ones.
+const ProjectFile = ".riptide.json"
+
+// Load loads configuration from the global config.json, then config.json and
+// .riptide.json in the working directory, then environment variables, each
+// overriding the one before. The API key comes from the environment or .env,
+// falling back to the OS keyring.
 func Load() (*Config, error) {
 	// Load .env file if it exists
-	_ = godotenv.Load()
+	_ = godotenv.Load(EnvFile)
 
-	// Get config file path
-	configPath := os.Getenv("DEEPSEEK_CONFIG_PATH")
-	if configPath == "" {
-		// Default to config.json in current directory
-		configPath = "config.json"
+	path := Path()
+	cfg, err := LoadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cfg.mergeLegacy(path); err != nil {
+		return nil, err
+	}
+	if err := cfg.mergeProject(ProjectFile); err != nil {
+		return nil, err
 	}
 
-	// Read config file
-	data, err := os.ReadFile(configPath)
+	// Load API key and overrides from environment
+	if model := os.Getenv("DEEPSEEK_MODEL"); model != "" {
+		cfg.API.Model = model
+	}
+	if baseURL := os.Getenv("DEEPSEEK_BASE_URL"); baseURL != "" {
+		cfg.API.BaseURL = baseURL
+	}
+	cfg.APIKey = os.Getenv("DEEPSEEK_API_KEY")
+	if cfg.APIKey == "" {
+		cfg.APIKey = apiKeyFromKeyring()
+	}
+	if cfg.APIKey == "" {
+		return nil, ErrNoAPIKey
+	}
+
+	return cfg, nil
+}
+
+// mergeProject reads a project config file over c, so options the file leaves
+// out keep their global values. Lists such as exclude replace the global list
+// while maps such as models add to it. A missing file changes nothing.
+//
+// A cloned repository should not be able to run commands or redirect the API
+// key, so only the options in projectSafeKeys apply until the user trusts this
+// version of the file; the rest are listed in ProjectIgnored.
+func (c *Config) mergeProject(path string) error {
+	found, ignored, err := c.mergeUntrusted(path)
+	if err != nil || !found {
+		return err
+	}
+	c.ProjectPath = path
+	c.ProjectIgnored = ignored
+	return nil
+}
+
+// mergeLegacy merges a config.json left in the working directory the way
+// mergeProject merges .riptide.json, and notes in Outdated that it should move
+// to the global config. Nothing happens when it is the global config itself.
+func (c *Config) mergeLegacy(global string) error {
+	if os.Getenv("DEEPSEEK_CONFIG_PATH") != "" || sameFile(LegacyFile, global) {
+		return nil
+	}
+	found, ignored, err := c.mergeUntrusted(LegacyFile)
+	if err != nil || !found {
+		return err
+	}
+	note := fmt.Sprintf("%s in the working directory is read like a project file; move your own settings to %s", LegacyFile, global)
+	if len(ignored) > 0 {
+		note += fmt.Sprintf(" (ignoring %s until it is trusted)", strings.Join(ignored, ", "))
+	}
+	c.Outdated = append(c.Outdated, note)
+	return nil
+}
+
+// mergeUntrusted reads a config file from the working directory over c,
+// applying only its projectSafeKeys options unless the user trusts this
+// version of it. It reports whether the file exists and which options were
+// left out.
+func (c *Config) mergeUntrusted(path string) (bool, []string, error) {
+	data, err := os.ReadFile(path)
 	if err != nil {
-		// If config doesn't exist, use defaults
 		if os.IsNotExist(err) {
-			return loadDefaults()
+			return false, nil, nil
+		}
+		return false, nil, fmt.Errorf("reading project config: %w", err)
+	}
+	safe, unsafe, err := splitProject(data)
+	if err != nil {
+		return false, nil, fmt.Errorf("parsing project config %s: %w", path, err)
+	}
+
+	var ignored []string
+	trusted := isTrustedProject(path, data)
+	if len(unsafe) > 0 && !trusted {
+		if ConfirmProject != nil && ConfirmProject(path, unsafe) {
+			if err := trustProject(path, data); err != nil {
+				return false, nil, err
+			}
+			trusted = true
+		} else {
+			data = safe
+			ignored = unsafe
 		}
-		return nil, fmt.Errorf("reading config file: %w", err)
 	}
+	// Only the user's own config turns off TLS checks, and hooks run without
+	// any approval, so an untrusted file never sets them whatever
+	// projectSafeKeys allows
+	insecure, hooks := c.API.InsecureSkipVerify, c.Hooks
+	if err := json.Unmarshal(data, c); err != nil {
+		return false, nil, fmt.Errorf("parsing project config %s: %w", path, err)
+	}
+	c.API.InsecureSkipVerify = insecure
+	if !trusted {
+		c.Hooks = hooks
+	}
+	if c.API.Deterministic {
+		c.MakeDeterministic()
+	}
+	return true, ignored, nil
+}
+
+// sameFile reports whether a and b name the same existing file
+func sameFile(a, b string) bool {
+	infoA, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+	infoB, err := os.Stat(b)
+	if err != nil {
+		return false
+	}
+	return os.SameFile(infoA, infoB)
+}
 
-	// Parse JSON
-	var cfg Config
-	if err := json.Unmarshal(data, &cfg); err != nil {
+// LoadFile reads a config file over the defaults without requiring an API key.
+// A missing file yields the defaults.
+func LoadFile(path string) (*Config, error) {
+	// Start from defaults so options missing from the file keep sensible values
+	cfg := defaultConfig()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		// A missing config file just means the defaults are used
+		if !os.IsNotExist(err) {
+			return nil, fmt.Errorf("reading config file: %w", err)
+		}
+	} else if err := json.Unmarshal(data, cfg); err != nil {
 		return nil, fmt.Errorf("parsing config file: %w", err)
 	}
 
-	// Load API key from environment
-	cfg.APIKey = os.Getenv("DEEPSEEK_API_KEY")
-	if cfg.APIKey == "" {
-		return nil, fmt.Errorf("DEEPSEEK_API_KEY environment variable not set")
+	// A message count cannot be turned into a token budget, so the old limit
+	// is reported rather than converted
+	if cfg.UI.MaxHistoryMessages > 0 {
+		cfg.Outdated = append(cfg.Outdated, fmt.Sprintf("ui.max_history_messages is no longer used; the history is trimmed to ui.max_context_tokens (%d) instead", cfg.UI.MaxContextTokens))
+	}
+
+	if cfg.API.Deterministic {
+		cfg.MakeDeterministic()
 	}
 
-	return &cfg, nil
+	return cfg, nil
+}
+
+// MakeDeterministic configures reproducible runs: temperature 0, a fixed seed and
+// no time-dependent context in the prompt
+func (c *Config) MakeDeterministic() {
+	c.API.Deterministic = true
+	c.Ambient.Time = false
+}
+
+// Default returns the default configuration without reading any file
+func Default() *Config {
+	return defaultConfig()
 }
 
-// loadDefaults returns a configuration with default values
-func loadDefaults() (*Config, error) {
-	cfg := &Config{
+// defaultConfig returns a configuration with default values
+func defaultConfig() *Config {
+	return &Config{
 		API: APIConfig{
 			BaseURL:             "https://api.deepseek.com/v1",
 			Model:               "deepseek-reasoner",
 			MaxCompletionTokens: 64000,
 			TimeoutSeconds:      300,
+			Seed:                42,
+			MaxRetries:          2,
+			TitleModel:          "deepseek-chat",
+			SummaryModel:        "deepseek-chat",
 		},
 		UI: UIConfig{
-			Theme:              "default",
-			EnableEmoji:        true,
-			MaxHistoryMessages: 15,
+			Theme:            "default",
+			EnableEmoji:      true,
+			MaxContextTokens: 48000,
+			Timestamps:       TimestampsRelative,
+			PasteLines:       20,
+			PasteChars:       2000,
+			Keymap:           KeymapDefault,
+			ShowReasoning:    ReasoningCollapsed,
 		},
 		FileOperations: FileOperationsConfig{
 			MaxFileSizeMB:   5,
 			MaxFilesPerScan: 1000,
 			BinaryPeekSize:  1024,
+			RefreshContext:  true,
+		},
+		Ambient: AmbientConfig{
+			Enabled:       true,
+			Time:          true,
+			WorkingDir:    true,
+			Git:           true,
+			OS:            true,
+			MaxDirtyFiles: 10,
+		},
+		Permissions: PermissionsConfig{
+			Mode:          ModeEdit,
+			MaxIterations: 25,
+		},
+		Redaction: RedactionConfig{
+			Enabled: true,
+			Entropy: true,
+		},
+		Commands: CommandPolicyConfig{
+			Dangerous:      CommandActionConfirm,
+			TimeoutSeconds: 120,
+		},
+		Telemetry: TelemetryConfig{
+			Enabled: false,
+		},
+		Updates: UpdatesConfig{
+			Check: true,
+		},
+		Hooks: HooksConfig{
+			TimeoutSeconds: 30,
+		},
+		Format: FormatConfig{
+			Formatters: map[string]string{
+				".go":  "gofmt -w",
+				".py":  "black -q",
+				".js":  "prettier --write",
+				".jsx": "prettier --write",
+				".ts":  "prettier --write",
+				".tsx": "prettier --write",
+				".css": "prettier --write",
+			},
+			Linters: map[string]string{
+				".go": "go vet {dirs}",
+			},
+			TimeoutSeconds: 30,
+		},
+		LSP: LSPConfig{
+			Servers: map[string]string{
+				".go":  "gopls",
+				".py":  "pyright-langserver --stdio",
+				".js":  "typescript-language-server --stdio",
+				".jsx": "typescript-language-server --stdio",
+				".ts":  "typescript-language-server --stdio",
+				".tsx": "typescript-language-server --stdio",
+				".rs":  "rust-analyzer",
+				".c":   "clangd",
+				".h":   "clangd",
+				".cc":  "clangd",
+				".cpp": "clangd",
+				".hpp": "clangd",
+			},
+			TimeoutSeconds: 60,
+		},
+		RepoMap: RepoMapConfig{
+			MaxTokens: 2000,
+		},
+		Databases: DatabasesConfig{
+			MaxRows:  200,
+			MaxBytes: 32 * 1024,
+		},
+		HTTP: HTTPConfig{
+			MaxResponseBytes: 16 * 1024,
+			TimeoutSeconds:   30,
+		},
+		Docker: DockerConfig{
+			Memory:         "1g",
+			CPUs:           "2",
+			TimeoutSeconds: 120,
+		},
+		Cache: CacheConfig{
+			Enabled:  true,
+			TTLHours: 24,
 		},
 	}
-
-	// Load API key from environment
-	cfg.APIKey = os.Getenv("DEEPSEEK_API_KEY")
-	if cfg.APIKey == "" {
-		return nil, fmt.Errorf("DEEPSEEK_API_KEY environment variable not set")
-	}
-
-	return cfg, nil
 }
 
 // GetExcludedFiles returns the list of files to exclude from scanning
@@ -0,0 +1,119 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+const (
+	// keyringService and keyringAccount name the API key's entry in the OS keyring
+	keyringService = "riptide"
+	keyringAccount = "DEEPSEEK_API_KEY"
+
+	// EnvFile is the dotenv file Load reads from the working directory
+	EnvFile = ".env"
+)
+
+// ErrNoAPIKey is returned by Load when no API key is set in the environment,
+// a .env file or the OS keyring
+var ErrNoAPIKey = errors.New("DEEPSEEK_API_KEY is not set in the environment, .env or the OS keyring")
+
+// KeyringAvailable reports whether the OS keyring can be reached: the security
+// tool on macOS or secret-tool (libsecret) elsewhere
+func KeyringAvailable() bool {
+	_, err := exec.LookPath(keyringTool())
+	return err == nil
+}
+
+// keyringTool names the command used to reach the OS keyring
+func keyringTool() string {
+	if runtime.GOOS == "darwin" {
+		return "security"
+	}
+	return "secret-tool"
+}
+
+// SaveAPIKeyToKeyring stores the API key in the OS keyring, replacing any key
+// saved before
+func SaveAPIKeyToKeyring(key string) error {
+	// Both tools read the secret from stdin, keeping it off the command line
+	// where other users could see it
+	var cmd *exec.Cmd
+	if runtime.GOOS == "darwin" {
+		// -w last and without a value prompts for the password, then asks for
+		// it again to confirm
+		cmd = exec.Command("security", "add-generic-password", "-U", "-s", keyringService, "-a", keyringAccount, "-w")
+		cmd.Stdin = strings.NewReader(key + "\n" + key + "\n")
+	} else {
+		cmd = exec.Command("secret-tool", "store", "--label=Riptide API key", "service", keyringService, "account", keyringAccount)
+		cmd.Stdin = strings.NewReader(key)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("storing API key in keyring: %s", msg)
+		}
+		return fmt.Errorf("storing API key in keyring: %w", err)
+	}
+	return nil
+}
+
+// apiKeyFromKeyring returns the API key saved in the OS keyring, or "" when there
+// is none or the keyring cannot be reached
+func apiKeyFromKeyring() string {
+	if !KeyringAvailable() {
+		return ""
+	}
+	var cmd *exec.Cmd
+	if runtime.GOOS == "darwin" {
+		cmd = exec.Command("security", "find-generic-password", "-s", keyringService, "-a", keyringAccount, "-w")
+	} else {
+		cmd = exec.Command("secret-tool", "lookup", "service", keyringService, "account", keyringAccount)
+	}
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// SaveAPIKeyToEnvFile sets DEEPSEEK_API_KEY in the dotenv file at path, keeping
+// its other lines, and makes the file readable by the owner only
+func SaveAPIKeyToEnvFile(path, key string) error {
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("reading %s: %w", path, err)
+	}
+
+	entry := keyringAccount + "=" + key
+	var lines []string
+	replaced := false
+	if len(data) > 0 {
+		lines = strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	}
+	for i, line := range lines {
+		name, _, ok := strings.Cut(strings.TrimPrefix(strings.TrimSpace(line), "export "), "=")
+		if ok && strings.TrimSpace(name) == keyringAccount {
+			lines[i] = entry
+			replaced = true
+		}
+	}
+	if !replaced {
+		lines = append(lines, entry)
+	}
+
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0600); err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	// WriteFile keeps the mode of an existing file, so tighten it explicitly
+	if err := os.Chmod(path, 0600); err != nil {
+		return fmt.Errorf("restricting %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+)
+
+// ThemeConfig is a UI color palette. Colors are hex values such as "#3b82f6" or
+// ANSI color numbers from 0 to 255. A custom theme only needs the colors it
+// changes; the rest come from the theme named by Base, "default" if empty.
+type ThemeConfig struct {
+	Base string `json:"base,omitempty"`
+
+	Primary   string `json:"primary,omitempty"`   // Titles and the input prompt
+	Secondary string `json:"secondary,omitempty"` // Borders, the prompt marker and headers
+	Accent    string `json:"accent,omitempty"`    // Info messages, selections and the spinner
+	Success   string `json:"success,omitempty"`
+	Warning   string `json:"warning,omitempty"`
+	Error     string `json:"error,omitempty"`
+
+	Text      string `json:"text,omitempty"`       // Emphasized text, the answer marker and the cursor
+	DimText   string `json:"dim_text,omitempty"`   // Help and secondary text
+	MutedText string `json:"muted_text,omitempty"` // Descriptions in lists
+	Link      string `json:"link,omitempty"`
+	FilePath  string `json:"file_path,omitempty"`
+	Reasoning string `json:"reasoning,omitempty"` // The model's thinking
+
+	HeaderBackground     string `json:"header_background,omitempty"`
+	HighlightBackground  string `json:"highlight_background,omitempty"`
+	CodeBackground       string `json:"code_background,omitempty"`
+	InlineCodeBackground string `json:"inline_code_background,omitempty"`
+	InlineCodeText       string `json:"inline_code_text,omitempty"`
+	BannerText           string `json:"banner_text,omitempty"` // Text on the error banner
+	CursorText           string `json:"cursor_text,omitempty"` // Text under the block cursor
+
+	CodeKeyword  string `json:"code_keyword,omitempty"`
+	CodeType     string `json:"code_type,omitempty"`
+	CodeString   string `json:"code_string,omitempty"`
+	CodeComment  string `json:"code_comment,omitempty"`
+	CodeNumber   string `json:"code_number,omitempty"`
+	CodeFunction string `json:"code_function,omitempty"`
+	CodeInserted string `json:"code_inserted,omitempty"` // Added lines in diffs
+	CodeDeleted  string `json:"code_deleted,omitempty"`  // Removed lines in diffs
+	CodeHunk     string `json:"code_hunk,omitempty"`     // Hunk headers in diffs
+}
+
+// Inherit returns t with every field it leaves empty taken from base
+func (t ThemeConfig) Inherit(base ThemeConfig) ThemeConfig {
+	fields := reflect.ValueOf(&t).Elem()
+	inherited := reflect.ValueOf(base)
+	for i := 0; i < fields.NumField(); i++ {
+		if fields.Field(i).String() == "" {
+			fields.Field(i).SetString(inherited.Field(i).String())
+		}
+	}
+	return t
+}
+
+// Colors returns the theme's colors by their JSON names, for validation
+func (t ThemeConfig) Colors() map[string]string {
+	colors := make(map[string]string)
+	fields := reflect.ValueOf(t)
+	for i := 0; i < fields.NumField(); i++ {
+		name, _, _ := strings.Cut(fields.Type().Field(i).Tag.Get("json"), ",")
+		if name != "base" {
+			colors[name] = fields.Field(i).String()
+		}
+	}
+	return colors
+}
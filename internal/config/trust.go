@@ -0,0 +1,157 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// projectSafeKeys are the options a project file may set without being
+// trusted, by section; a nil list allows the whole top-level key. Everything
+// else can run commands, reach the network with the API key, read files into
+// the prompt or loosen approvals.
+var projectSafeKeys = map[string][]string{
+	"api":             {"model"},
+	"file_operations": {"exclude"},
+	"system_prompt":   nil,
+}
+
+// projectNeverKeys are options a project file may not set even when trusted,
+// because they quietly weaken the connection the API key travels over
+var projectNeverKeys = []string{"api.insecure_skip_verify"}
+
+// ConfirmProject asks whether to apply the options of an untrusted project
+// file outside projectSafeKeys, which it is given as dotted names. It is nil
+// unless someone can answer, and then only the safe options are applied.
+var ConfirmProject func(path string, keys []string) bool
+
+// splitProject separates a project file into the JSON of its safe options and
+// the dotted names of the rest
+func splitProject(data []byte) ([]byte, []string, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, nil, err
+	}
+
+	safe := make(map[string]json.RawMessage)
+	var unsafe []string
+	for key, value := range raw {
+		fields, known := projectSafeKeys[key]
+		switch {
+		case !known:
+			unsafe = append(unsafe, key)
+		case fields == nil:
+			safe[key] = value
+		default:
+			var section map[string]json.RawMessage
+			if err := json.Unmarshal(value, &section); err != nil {
+				return nil, nil, err
+			}
+			kept := make(map[string]json.RawMessage)
+			for name, field := range section {
+				if contains(projectNeverKeys, key+"."+name) {
+					continue
+				}
+				if contains(fields, name) {
+					kept[name] = field
+				} else {
+					unsafe = append(unsafe, key+"."+name)
+				}
+			}
+			if len(kept) > 0 {
+				encoded, err := json.Marshal(kept)
+				if err != nil {
+					return nil, nil, err
+				}
+				safe[key] = encoded
+			}
+		}
+	}
+	sort.Strings(unsafe)
+
+	encoded, err := json.Marshal(safe)
+	if err != nil {
+		return nil, nil, err
+	}
+	return encoded, unsafe, nil
+}
+
+// contains reports whether list holds s
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// trustFile returns where trusted project files are recorded
+func trustFile() (string, error) {
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("finding home directory: %w", err)
+		}
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(dataHome, "riptide", "trusted_projects.json"), nil
+}
+
+// projectHash identifies a project file by its absolute path and content, so
+// trust lapses when the file changes
+func projectHash(path string, data []byte) (string, string) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		abs = path
+	}
+	sum := sha256.Sum256(data)
+	return abs, hex.EncodeToString(sum[:])
+}
+
+// loadTrusted reads the recorded project files, path to content hash
+func loadTrusted() map[string]string {
+	trusted := make(map[string]string)
+	path, err := trustFile()
+	if err != nil {
+		return trusted
+	}
+	if data, err := os.ReadFile(path); err == nil {
+		_ = json.Unmarshal(data, &trusted)
+	}
+	return trusted
+}
+
+// isTrustedProject reports whether the user approved this version of the file
+func isTrustedProject(path string, data []byte) bool {
+	abs, hash := projectHash(path, data)
+	return loadTrusted()[abs] == hash
+}
+
+// trustProject records that the user approved this version of the file
+func trustProject(path string, data []byte) error {
+	file, err := trustFile()
+	if err != nil {
+		return err
+	}
+	trusted := loadTrusted()
+	abs, hash := projectHash(path, data)
+	trusted[abs] = hash
+
+	encoded, err := json.MarshalIndent(trusted, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling trusted projects: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+		return fmt.Errorf("creating data directory: %w", err)
+	}
+	if err := os.WriteFile(file, encoded, 0600); err != nil {
+		return fmt.Errorf("writing trusted projects: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package conversation
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/alchemy-labs-co/riptide/internal/config"
+	"github.com/alchemy-labs-co/riptide/internal/git"
+)
+
+// BuildAmbientReminder returns a short system reminder describing the current
+// environment, or an empty string when ambient injection is disabled. It is
+// rebuilt before every request so the model never works from stale state.
+func BuildAmbientReminder(cfg config.AmbientConfig) string {
+	if !cfg.Enabled {
+		return ""
+	}
+
+	var lines []string
+
+	if cfg.Time {
+		now := time.Now()
+		lines = append(lines, fmt.Sprintf("Current time: %s (%s UTC)",
+			now.Format("2006-01-02 15:04 MST, Monday"),
+			now.UTC().Format("15:04"),
+		))
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		cwd = ""
+	}
+
+	if cfg.WorkingDir && cwd != "" {
+		lines = append(lines, fmt.Sprintf("Working directory: %s", cwd))
+	}
+
+	if cfg.Git && cwd != "" && git.IsRepository(cwd) {
+		if branch, err := git.CurrentBranch(cwd); err == nil {
+			lines = append(lines, fmt.Sprintf("Git branch: %s", branch))
+		}
+		if dirty, err := git.DirtyFiles(cwd); err == nil {
+			lines = append(lines, formatDirtyFiles(dirty, cfg.MaxDirtyFiles))
+		}
+	}
+
+	if cfg.OS {
+		lines = append(lines, fmt.Sprintf("OS: %s/%s", runtime.GOOS, runtime.GOARCH))
+	}
+
+	if len(lines) == 0 {
+		return ""
+	}
+
+	return "Ambient state (refreshed before each request, trust it over earlier assumptions):\n" +
+		strings.Join(lines, "\n")
+}
+
+// formatDirtyFiles summarizes uncommitted files, listing at most limit of them
+func formatDirtyFiles(files []string, limit int) string {
+	if len(files) == 0 {
+		return "Uncommitted changes: none"
+	}
+
+	shown := files
+	if limit > 0 && len(shown) > limit {
+		shown = shown[:limit]
+	}
+
+	summary := fmt.Sprintf("Uncommitted changes (%d): %s", len(files), strings.Join(shown, ", "))
+	if len(shown) < len(files) {
+		summary += fmt.Sprintf(", ... and %d more", len(files)-len(shown))
+	}
+	return summary
+}
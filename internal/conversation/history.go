@@ -2,6 +2,7 @@ package conversation
 
 import (
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 
@@ -23,17 +24,21 @@ type History struct {
 	offPeakCachedTokens int
 }
 
-// NewHistory creates a new conversation history
-func NewHistory(cfg *config.Config) *History {
+// NewHistory creates a new conversation history, starting with the system
+// prompt the config asks for
+func NewHistory(cfg *config.Config) (*History, error) {
 	h := &History{
 		messages: make([]api.ConversationMessage, 0),
 		config:   cfg,
 	}
 
-	// Add system prompt
-	h.AddSystemMessage(api.GetSystemPrompt())
+	prompt, err := SystemPrompt(cfg)
+	if err != nil {
+		return nil, err
+	}
+	h.AddSystemMessage(prompt)
 
-	return h
+	return h, nil
 }
 
 // AddMessage adds a message to the conversation history
@@ -55,6 +60,7 @@ func (h *History) AddMessage(role, content string, toolCalls []api.ToolCall, too
 		msg.ToolCallID = toolCallID
 	}
 
+	msg.Tokens = estimateMessageTokens(msg)
 	h.messages = append(h.messages, msg)
 }
 
@@ -68,6 +74,28 @@ func (h *History) AddAssistantMessage(content string, toolCalls []api.ToolCall)
 	h.AddMessage("assistant", content, toolCalls, "")
 }
 
+// AddReasoningAssistantMessage adds an assistant message together with the reasoning
+// that preceded it. The reasoning is kept for display and export only; it is never
+// sent back to the API.
+func (h *History) AddReasoningAssistantMessage(content, reasoning string, reasoningDuration time.Duration, toolCalls []api.ToolCall) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	msg := api.ConversationMessage{
+		Role:             "assistant",
+		Content:          content,
+		ReasoningContent: reasoning,
+		ReasoningMillis:  reasoningDuration.Milliseconds(),
+		Timestamp:        time.Now(),
+	}
+	if len(toolCalls) > 0 {
+		msg.ToolCalls = toolCalls
+	}
+
+	msg.Tokens = estimateMessageTokens(msg)
+	h.messages = append(h.messages, msg)
+}
+
 // AddToolMessage adds a tool response message to the history
 func (h *History) AddToolMessage(toolCallID, content string) {
 	h.AddMessage("tool", content, nil, toolCallID)
@@ -78,6 +106,21 @@ func (h *History) AddSystemMessage(content string) {
 	h.AddMessage("system", content, nil, "")
 }
 
+// AddFileMessage adds file content to the history as a system message tagged with its path
+func (h *History) AddFileMessage(filePath, content string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	msg := api.ConversationMessage{
+		Role:      "system",
+		Content:   content,
+		FilePath:  filePath,
+		Timestamp: time.Now(),
+	}
+	msg.Tokens = estimateMessageTokens(msg)
+	h.messages = append(h.messages, msg)
+}
+
 // GetMessages returns OpenAI-formatted messages for API calls
 func (h *History) GetMessages() []openai.ChatCompletionMessage {
 	h.mu.RLock()
@@ -128,40 +171,115 @@ func (h *History) GetRawMessages() []api.ConversationMessage {
 	return messages
 }
 
-// Trim trims the conversation history to prevent token overflow
+// trimNotePrefix starts the system note that stands in for trimmed messages
+const trimNotePrefix = "Earlier parts of this conversation were trimmed to fit the context window"
+
+// elidedToolOutput replaces a tool result that no longer fits the context window
+const elidedToolOutput = "[output removed to fit the context window; run the tool again if it is still needed]"
+
+// Trim drops the oldest non-system messages until the estimated request fits within
+// the token budget for the model, reserving room for the tool definitions. Messages
+// are dropped in whole exchanges so a tool result never outlives the assistant
+// message that requested it, and a note tells the model that earlier turns are gone.
+// When the current exchange alone is too large, its older tool results are replaced
+// by a placeholder instead, so a long tool loop cannot overflow the window mid-request.
 func (h *History) Trim() {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
-	// Don't trim if conversation is still small
-	if len(h.messages) <= 20 {
+	budget := api.ContextBudget(h.config) - toolDefinitionTokens()
+	if budget <= 0 {
+		return
+	}
+
+	total := 0
+	for _, msg := range h.messages {
+		total += msg.Tokens
+	}
+	if total <= budget {
 		return
 	}
 
-	// Separate system messages and other messages
+	// Separate system messages and other messages, keeping aside any earlier note
 	var systemMessages []api.ConversationMessage
 	var otherMessages []api.ConversationMessage
+	trimmed := 0
 
 	for _, msg := range h.messages {
-		if msg.Role == "system" {
+		switch {
+		case msg.Role == "system" && strings.HasPrefix(msg.Content, trimNotePrefix):
+			fmt.Sscanf(msg.Content[len(trimNotePrefix):], " (%d messages)", &trimmed)
+			total -= msg.Tokens
+		case msg.Role == "system":
 			systemMessages = append(systemMessages, msg)
-		} else {
+		default:
 			otherMessages = append(otherMessages, msg)
 		}
 	}
 
-	// Keep only the configured number of recent messages
-	maxMessages := h.config.UI.MaxHistoryMessages
-	if len(otherMessages) > maxMessages {
-		otherMessages = otherMessages[len(otherMessages)-maxMessages:]
+	drop, total := exchangesToDrop(otherMessages, total, budget)
+	otherMessages = otherMessages[drop:]
+	trimmed += drop
+
+	// Shrink the oldest tool results of the current exchange, leaving the newest intact
+	lastTool := -1
+	for i := len(otherMessages) - 1; i >= 0; i-- {
+		if otherMessages[i].Role == "tool" {
+			lastTool = i
+			break
+		}
+	}
+	for i := 0; i < lastTool && total > budget; i++ {
+		msg := otherMessages[i]
+		if msg.Role != "tool" || msg.Content == elidedToolOutput {
+			continue
+		}
+		msg.Content = elidedToolOutput
+		msg.Tokens = estimateMessageTokens(msg)
+		total -= otherMessages[i].Tokens - msg.Tokens
+		otherMessages[i] = msg
 	}
 
 	// Rebuild conversation history
-	h.messages = make([]api.ConversationMessage, 0, len(systemMessages)+len(otherMessages))
+	h.messages = make([]api.ConversationMessage, 0, len(systemMessages)+len(otherMessages)+1)
 	h.messages = append(h.messages, systemMessages...)
+	if trimmed > 0 {
+		note := api.ConversationMessage{
+			Role:      "system",
+			Content:   fmt.Sprintf("%s (%d messages). Ask the user to repeat anything you still need from them.", trimNotePrefix, trimmed),
+			Timestamp: time.Now(),
+		}
+		note.Tokens = estimateMessageTokens(note)
+		h.messages = append(h.messages, note)
+	}
 	h.messages = append(h.messages, otherMessages...)
 }
 
+// exchangesToDrop counts how many of the oldest messages to drop, in whole
+// exchanges, to bring total down to budget, and returns the total left. The
+// most recent user message and anything after it are never dropped.
+func exchangesToDrop(messages []api.ConversationMessage, total, budget int) (int, int) {
+	lastUser := len(messages)
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == "user" {
+			lastUser = i
+			break
+		}
+	}
+
+	drop := 0
+	for drop < lastUser && total > budget {
+		total -= messages[drop].Tokens
+		drop++
+		// Keep dropping until the next exchange starts with a user message
+		for drop < lastUser && messages[drop].Role != "user" {
+			total -= messages[drop].Tokens
+			drop++
+		}
+	}
+	return drop, total
+}
+
 // Clear clears the conversation history (except system prompt)
 func (h *History) Clear() {
 	h.mu.Lock()
@@ -180,6 +298,124 @@ func (h *History) Clear() {
 	h.offPeakCachedTokens = 0
 }
 
+// SetSystemPrompt replaces the system prompt, for instance after the project
+// memory changed, keeping the rest of the history
+func (h *History) SetSystemPrompt(prompt string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.messages[0].Content = prompt
+	h.messages[0].Tokens = estimateMessageTokens(h.messages[0])
+}
+
+// Restore replaces the history with messages from a saved session. The saved system
+// prompt is swapped for the current one so prompt updates apply to resumed sessions.
+func (h *History) Restore(messages []api.ConversationMessage) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	systemPrompt := h.messages[0]
+	if len(messages) > 0 && messages[0].Role == "system" && messages[0].FilePath == "" {
+		messages = messages[1:]
+	}
+
+	h.messages = make([]api.ConversationMessage, 0, len(messages)+1)
+	h.messages = append(h.messages, systemPrompt)
+	h.messages = append(h.messages, messages...)
+
+	// Re-estimate so sessions saved by older versions are measured the same way
+	for i := range h.messages {
+		h.messages[i].Tokens = estimateMessageTokens(h.messages[i])
+	}
+
+	// Token usage is not saved with the session
+	h.inputTokens = 0
+	h.outputTokens = 0
+	h.cachedTokens = 0
+	h.offPeakInputTokens = 0
+	h.offPeakOutputTokens = 0
+	h.offPeakCachedTokens = 0
+}
+
+// Rewind drops the n-th most recent user message (1 is the latest) and
+// everything after it, so the conversation can continue from just before that
+// prompt. It reports false, leaving the history unchanged, when the history
+// holds fewer than n user messages (for example after older ones were trimmed).
+func (h *History) Rewind(n int) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	seen := 0
+	for i := len(h.messages) - 1; i > 0; i-- {
+		if h.messages[i].Role != "user" {
+			continue
+		}
+		seen++
+		if seen == n {
+			h.messages = h.messages[:i]
+			return true
+		}
+	}
+	return false
+}
+
+// RewindTo drops the user message at index and everything after it. It
+// reports false, leaving the history unchanged, when the message there is not
+// that prompt, for example after older messages were trimmed or summarized.
+func (h *History) RewindTo(index int, content string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if index <= 0 || index >= len(h.messages) {
+		return false
+	}
+	if msg := h.messages[index]; msg.Role != "user" || msg.Content != content {
+		return false
+	}
+	h.messages = h.messages[:index]
+	return true
+}
+
+// RemoveFile drops a file added to the context and reports whether it was there
+func (h *History) RemoveFile(filePath string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	kept := h.messages[:0]
+	removed := false
+	for _, msg := range h.messages {
+		if msg.Role == "system" && msg.FilePath == filePath {
+			removed = true
+			continue
+		}
+		kept = append(kept, msg)
+	}
+	h.messages = kept
+	return removed
+}
+
+// ReplaceFile swaps the content of a file in the context for a fresh read,
+// keeping its place in the history, and reports whether the content changed.
+// The read time is updated either way.
+func (h *History) ReplaceFile(filePath, content string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for i := range h.messages {
+		msg := &h.messages[i]
+		if msg.Role == "system" && msg.FilePath == filePath {
+			msg.Timestamp = time.Now()
+			if msg.Content == content {
+				return false
+			}
+			msg.Content = content
+			msg.Tokens = estimateMessageTokens(*msg)
+			return true
+		}
+	}
+	return false
+}
+
 // FileAlreadyInContext checks if a file is already in the conversation context
 func (h *History) FileAlreadyInContext(filePath string) bool {
 	h.mu.RLock()
@@ -187,15 +423,57 @@ func (h *History) FileAlreadyInContext(filePath string) bool {
 
 	fileMarker := fmt.Sprintf("Content of file '%s'", filePath)
 	for _, msg := range h.messages {
-		if msg.Role == "system" &&
-			len(msg.Content) > 0 &&
-			contains(msg.Content, fileMarker) {
+		if msg.Role != "system" {
+			continue
+		}
+		if msg.FilePath == filePath || (len(msg.Content) > 0 && contains(msg.Content, fileMarker)) {
 			return true
 		}
 	}
 	return false
 }
 
+// GetContextItems returns the files currently held in the conversation context
+func (h *History) GetContextItems() []ContextItem {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	var items []ContextItem
+	for _, msg := range h.messages {
+		if msg.Role == "system" && msg.FilePath != "" {
+			items = append(items, ContextItem{
+				Path:    msg.FilePath,
+				Tokens:  msg.Tokens,
+				Bytes:   len(msg.Content),
+				AddedAt: msg.Timestamp,
+			})
+		}
+	}
+	return items
+}
+
+// EstimatedTokens returns the estimated number of tokens the history occupies in a request
+func (h *History) EstimatedTokens() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	total := 0
+	for _, msg := range h.messages {
+		total += msg.Tokens
+	}
+	return total
+}
+
+// RequestTokens estimates the tokens the next request would send with draft as
+// its prompt: the history, the tool definitions and the draft itself
+func (h *History) RequestTokens(draft string) int {
+	tokens := h.EstimatedTokens() + toolDefinitionTokens()
+	if draft != "" {
+		tokens += EstimateTokens(draft)
+	}
+	return tokens
+}
+
 // GetConversationLength returns the number of messages in the history
 func (h *History) GetConversationLength() int {
 	h.mu.RLock()
@@ -251,6 +529,7 @@ type ConversationStats struct {
 	AssistantMessages   int
 	SystemMessages      int
 	ToolMessages        int
+	ContextTokens       int
 	InputTokens         int
 	OutputTokens        int
 	CachedTokens        int
@@ -277,6 +556,7 @@ func (h *History) GetStats() ConversationStats {
 	}
 
 	for _, msg := range h.messages {
+		stats.ContextTokens += msg.Tokens
 		switch msg.Role {
 		case "user":
 			stats.UserMessages++
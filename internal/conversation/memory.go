@@ -0,0 +1,70 @@
+package conversation
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// MemoryFiles are the names a project memory file may have, in the order they
+// are looked for; new memory goes to the first
+var MemoryFiles = []string{"RIPTIDE.md", "AGENTS.md"}
+
+// FindMemory returns the path of the project memory file in dir, or "" when
+// there is none
+func FindMemory(dir string) string {
+	for _, name := range MemoryFiles {
+		path := filepath.Join(dir, name)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+	}
+	return ""
+}
+
+// AppendMemory adds an instruction to the project memory file in dir as a list
+// item, creating RIPTIDE.md when there is no memory file yet. It returns the
+// path of the file written.
+func AppendMemory(dir, instruction string) (string, error) {
+	path := FindMemory(dir)
+	if path == "" {
+		path = filepath.Join(dir, MemoryFiles[0])
+	}
+
+	existing, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return "", fmt.Errorf("reading memory file: %w", err)
+	}
+	entry := "- " + strings.TrimSpace(instruction) + "\n"
+	if len(existing) > 0 && !strings.HasSuffix(string(existing), "\n") {
+		entry = "\n" + entry
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return "", fmt.Errorf("opening memory file: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(entry); err != nil {
+		return "", fmt.Errorf("writing memory file: %w", err)
+	}
+	return path, nil
+}
+
+// memoryPrompt returns the project memory file in dir as a section of the
+// system prompt, or "" when there is none or it is empty
+func memoryPrompt(dir string) (string, error) {
+	path := FindMemory(dir)
+	if path == "" {
+		return "", nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("reading memory file: %w", err)
+	}
+	if strings.TrimSpace(string(data)) == "" {
+		return "", nil
+	}
+	return fmt.Sprintf("\n\nProject memory from %s (always follow these instructions):\n%s", filepath.Base(path), strings.TrimSpace(string(data))), nil
+}
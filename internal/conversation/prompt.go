@@ -0,0 +1,128 @@
+package conversation
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/alchemy-labs-co/riptide/internal/api"
+	"github.com/alchemy-labs-co/riptide/internal/config"
+)
+
+// maxPromptFiles caps the paths {{project_files}} lists, so a large tree does
+// not fill the system prompt
+const maxPromptFiles = 200
+
+// SystemPrompt builds the system prompt a session starts with. It is the
+// built-in prompt, or the file system_prompt_path names with its template
+// variables filled in, followed by the project instructions in system_prompt,
+// the project memory file in the working directory and, with repo_map.auto,
+// the repository map.
+func SystemPrompt(cfg *config.Config) (string, error) {
+	prompt := api.GetSystemPrompt()
+	if cfg == nil {
+		return prompt, nil
+	}
+
+	if path := cfg.SystemPromptPath; path != "" {
+		if rest, ok := strings.CutPrefix(path, "~/"); ok {
+			if home, err := os.UserHomeDir(); err == nil {
+				path = filepath.Join(home, rest)
+			}
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("reading system prompt file: %w", err)
+		}
+		prompt = expandPromptTemplate(string(data), cfg)
+	}
+
+	if strings.TrimSpace(cfg.SystemPrompt) != "" {
+		prompt += "\n\nProject instructions:\n" + strings.TrimSpace(cfg.SystemPrompt)
+	}
+
+	memory, err := memoryPrompt(".")
+	if err != nil {
+		return "", err
+	}
+	prompt += memory
+
+	// A project the map cannot be built for starts without one
+	if cfg.RepoMap.Auto {
+		if repoMap, err := BuildRepoMap(cfg, "."); err == nil {
+			prompt += "\n\n" + repoMap.Text
+		}
+	}
+	return prompt, nil
+}
+
+// expandPromptTemplate fills in the variables of a system prompt file:
+// {{builtin}} (Riptide's own prompt, for files that extend rather than replace
+// it), {{cwd}}, {{os}}, {{date}} and {{project_files}}. Other text in braces is
+// left as it is. {{date}} is left empty in deterministic mode, so the prompt and
+// the response cache key stay the same from day to day.
+func expandPromptTemplate(template string, cfg *config.Config) string {
+	cwd, err := os.Getwd()
+	if err != nil {
+		cwd = ""
+	}
+	date := time.Now().Format("2006-01-02")
+	if cfg.API.Deterministic {
+		date = ""
+	}
+
+	pairs := []string{
+		"{{builtin}}", api.GetSystemPrompt(),
+		"{{cwd}}", cwd,
+		"{{os}}", runtime.GOOS + "/" + runtime.GOARCH,
+		"{{date}}", date,
+	}
+	// Listing the tree is only worth it when the file asks for it
+	if strings.Contains(template, "{{project_files}}") {
+		pairs = append(pairs, "{{project_files}}", listProjectFiles(cfg, cwd))
+	}
+	return strings.NewReplacer(pairs...).Replace(template)
+}
+
+// listProjectFiles lists the files under root one per line, skipping hidden and
+// excluded names the way directory scans do, up to maxPromptFiles
+func listProjectFiles(cfg *config.Config, root string) string {
+	excluded := config.GetExcludedFiles()
+	skip := func(name string) bool {
+		return strings.HasPrefix(name, ".") || excluded[name] || cfg.FileOperations.IsExcluded(name)
+	}
+
+	var files []string
+	more := 0
+	filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || path == root {
+			return nil
+		}
+		if skip(entry.Name()) {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		if len(files) == maxPromptFiles {
+			more++
+			return nil
+		}
+		if rel, err := filepath.Rel(root, path); err == nil {
+			files = append(files, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+
+	if more > 0 {
+		files = append(files, fmt.Sprintf("... and %d more", more))
+	}
+	return strings.Join(files, "\n")
+}
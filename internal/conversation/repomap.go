@@ -0,0 +1,333 @@
+package conversation
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/alchemy-labs-co/riptide/internal/config"
+	"github.com/alchemy-labs-co/riptide/internal/functions"
+)
+
+const (
+	// defaultRepoMapTokens budgets the map when repo_map.max_tokens is unset
+	defaultRepoMapTokens = 2000
+
+	// maxRepoMapFiles caps the files walked, so a huge tree does not stall
+	// the session start
+	maxRepoMapFiles = 5000
+
+	// maxRepoMapParseBytes is the largest file whose declarations are read;
+	// bigger ones, usually generated, are listed by name only
+	maxRepoMapParseBytes = 512 * 1024
+)
+
+// RepoMap is a compact picture of a project for the model: its files grouped
+// by directory, each followed by its top-level declarations
+type RepoMap struct {
+	Text   string
+	Files  int
+	Tokens int
+	Detail string // What was left out to fit the budget, empty when nothing was
+}
+
+// repoFile is one file in the map with the names it declares
+type repoFile struct {
+	dir     string // Slash-separated, relative to the root; "" for the root
+	name    string
+	symbols []repoSymbol // Most important first
+	kept    int          // How many of symbols fit in the budget
+}
+
+// repoSymbol is a declaration, ranked so types outlast functions, functions
+// outlast methods and methods outlast constants when the map is cut down
+type repoSymbol struct {
+	name  string
+	rank  int
+	index int // Position in the file, to list kept names in source order
+}
+
+// BuildRepoMap walks root, skipping hidden and excluded names the way
+// directory scans do, and lays out its files with their top-level
+// declarations, dropping detail until the map fits repo_map.max_tokens
+func BuildRepoMap(cfg *config.Config, root string) (*RepoMap, error) {
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, fmt.Errorf("accessing path: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", root)
+	}
+	budget := cfg.RepoMap.MaxTokens
+	if budget <= 0 {
+		budget = defaultRepoMapTokens
+	}
+
+	files, more := collectRepoFiles(cfg, root)
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no files found under %s", root)
+	}
+	name := filepath.Base(root)
+	if abs, err := filepath.Abs(root); err == nil {
+		name = filepath.Base(abs)
+	}
+	count := fmt.Sprintf("%d files", len(files))
+	if more > 0 {
+		count = fmt.Sprintf("the first %d files", len(files))
+	}
+	header := fmt.Sprintf("Repository map of %s (%s; each file is followed by its top-level declarations):", name, count)
+
+	// File names come first; declarations are then added a round at a time,
+	// each file's most important first, while the budget lasts
+	text := header + "\n" + renderRepoFiles(files)
+	if tokens := EstimateTokens(text); tokens <= budget {
+		fillRepoSymbols(files, budget-tokens)
+		text = header + "\n" + renderRepoFiles(files)
+		// The estimate of each name is close but not exact; give back names
+		// from the busiest files until the whole map fits
+		for tokens = EstimateTokens(text); tokens > budget && trimRepoSymbols(files); tokens = EstimateTokens(text) {
+			text = header + "\n" + renderRepoFiles(files)
+		}
+
+		total, kept := 0, 0
+		for _, file := range files {
+			total += len(file.symbols)
+			kept += file.kept
+		}
+		detail := ""
+		switch {
+		case kept == 0 && total > 0:
+			detail = "file names only"
+		case kept < total:
+			detail = fmt.Sprintf("%d of %d declarations", kept, total)
+		}
+		return &RepoMap{Text: text, Files: len(files), Tokens: tokens, Detail: detail}, nil
+	}
+
+	// Even the file names are too many: list directories with their file
+	// counts, as far as the budget goes
+	header = fmt.Sprintf("Repository map of %s (%s, counted by directory):", name, count)
+	text, cut := renderRepoDirs(header, files, budget)
+	detail := "directories only"
+	if cut > 0 {
+		detail = fmt.Sprintf("directories only, %d left out", cut)
+	}
+	return &RepoMap{Text: text, Files: len(files), Tokens: EstimateTokens(text), Detail: detail}, nil
+}
+
+// collectRepoFiles lists the files under root sorted by directory, reading
+// the declarations of those get_file_outline understands. It also returns
+// how many files were past maxRepoMapFiles.
+func collectRepoFiles(cfg *config.Config, root string) ([]repoFile, int) {
+	excluded := config.GetExcludedFiles()
+	excludedExtensions := config.GetExcludedExtensions()
+	skip := func(name string) bool {
+		return strings.HasPrefix(name, ".") || excluded[name] || cfg.FileOperations.IsExcluded(name)
+	}
+
+	var files []repoFile
+	more := 0
+	filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || path == root {
+			return nil
+		}
+		if skip(entry.Name()) {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if entry.IsDir() || excludedExtensions[strings.ToLower(filepath.Ext(path))] {
+			return nil
+		}
+		if len(files) == maxRepoMapFiles {
+			more++
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return nil
+		}
+		dir := filepath.ToSlash(filepath.Dir(rel))
+		if dir == "." {
+			dir = ""
+		}
+		files = append(files, repoFile{dir: dir, name: entry.Name(), symbols: repoSymbols(path, entry)})
+		return nil
+	})
+
+	sort.SliceStable(files, func(i, j int) bool {
+		if files[i].dir != files[j].dir {
+			return files[i].dir < files[j].dir
+		}
+		return files[i].name < files[j].name
+	})
+	return files, more
+}
+
+// repoSymbols reads the top-level declarations of a file, or none when its
+// language has no outline support or it cannot be parsed
+func repoSymbols(path string, entry fs.DirEntry) []repoSymbol {
+	if info, err := entry.Info(); err != nil || info.Size() > maxRepoMapParseBytes {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	outline, err := functions.ParseOutline(path, data)
+	if err != nil {
+		return nil
+	}
+
+	var symbols []repoSymbol
+	for _, item := range outline.Items {
+		// Imports are noise here, and an impl repeats the type it belongs to
+		if item.Depth > 0 || item.Kind == "import" || item.Kind == "impl" {
+			continue
+		}
+		rank := 1
+		switch item.Kind {
+		case "type", "struct", "interface", "class", "trait", "enum", "namespace", "mod":
+			rank = 0
+		case "method":
+			rank = 2
+		case "const", "var", "macro":
+			rank = 3
+		}
+		// Go groups constants and variables; list each name
+		for _, name := range strings.Split(item.Name, ", ") {
+			if name != "_" {
+				symbols = append(symbols, repoSymbol{name: name, rank: rank, index: len(symbols)})
+			}
+		}
+	}
+	sort.SliceStable(symbols, func(i, j int) bool { return symbols[i].rank < symbols[j].rank })
+	return symbols
+}
+
+// fillRepoSymbols keeps declarations round-robin across files, so every file
+// gets its most important names before any gets its less important ones,
+// until their estimated cost would pass budget
+func fillRepoSymbols(files []repoFile, budget int) {
+	for round := 0; ; round++ {
+		added := false
+		for i := range files {
+			file := &files[i]
+			if round >= len(file.symbols) {
+				continue
+			}
+			cost := EstimateTokens(", " + file.symbols[round].name)
+			if round == 0 {
+				// The first name also brings the colon and the count of the rest
+				cost = EstimateTokens(": "+file.symbols[0].name) + 4
+			}
+			if cost > budget {
+				return
+			}
+			budget -= cost
+			file.kept++
+			added = true
+		}
+		if !added {
+			return
+		}
+	}
+}
+
+// trimRepoSymbols drops the least important kept declaration of the file
+// keeping the most, reporting false when no file keeps any
+func trimRepoSymbols(files []repoFile) bool {
+	busiest := -1
+	for i, file := range files {
+		if file.kept > 0 && (busiest < 0 || file.kept > files[busiest].kept) {
+			busiest = i
+		}
+	}
+	if busiest < 0 {
+		return false
+	}
+	files[busiest].kept--
+	return true
+}
+
+// renderRepoFiles lays out files under directory headings, each with the
+// declarations it keeps
+func renderRepoFiles(files []repoFile) string {
+	var b strings.Builder
+	dir := ""
+	for _, file := range files {
+		indent := ""
+		if file.dir != "" {
+			if file.dir != dir {
+				b.WriteString(file.dir + "/\n")
+				dir = file.dir
+			}
+			indent = "  "
+		}
+		b.WriteString(indent + file.name)
+		if names := keptSymbols(file); names != "" {
+			b.WriteString(": " + names)
+		}
+		b.WriteString("\n")
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// keptSymbols lists the declarations a file keeps in source order, noting how
+// many were left out
+func keptSymbols(file repoFile) string {
+	if file.kept == 0 {
+		return ""
+	}
+	kept := append([]repoSymbol(nil), file.symbols[:file.kept]...)
+	sort.Slice(kept, func(i, j int) bool { return kept[i].index < kept[j].index })
+
+	names := make([]string, len(kept))
+	for i, symbol := range kept {
+		names[i] = symbol.name
+	}
+	text := strings.Join(names, ", ")
+	if left := len(file.symbols) - file.kept; left > 0 {
+		text += fmt.Sprintf(" (+%d more)", left)
+	}
+	return text
+}
+
+// renderRepoDirs lists each directory with how many files it holds, stopping
+// before the budget is spent, and returns how many directories were left out
+func renderRepoDirs(header string, files []repoFile, budget int) (string, int) {
+	var dirs []string
+	counts := make(map[string]int)
+	for _, file := range files {
+		dir := file.dir + "/"
+		if file.dir == "" {
+			dir = "./"
+		}
+		if counts[dir] == 0 {
+			dirs = append(dirs, dir)
+		}
+		counts[dir]++
+	}
+
+	var b strings.Builder
+	b.WriteString(header)
+	tokens := EstimateTokens(header)
+	for i, dir := range dirs {
+		line := fmt.Sprintf("\n%s (%d files)", dir, counts[dir])
+		if counts[dir] == 1 {
+			line = fmt.Sprintf("\n%s (1 file)", dir)
+		}
+		// Leave room for the closing note
+		if tokens+EstimateTokens(line) > budget-10 {
+			b.WriteString(fmt.Sprintf("\n... and %d more directories", len(dirs)-i))
+			return b.String(), len(dirs) - i
+		}
+		b.WriteString(line)
+		tokens += EstimateTokens(line)
+	}
+	return b.String(), 0
+}
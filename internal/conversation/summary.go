@@ -0,0 +1,180 @@
+package conversation
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+
+	"github.com/alchemy-labs-co/riptide/internal/api"
+)
+
+const (
+	// summaryNotePrefix starts the system note that holds the summary of turns
+	// removed to fit the context window
+	summaryNotePrefix = "Summary of earlier parts of this conversation, which were removed to fit the context window:"
+
+	// SummaryMaxTokens bounds the summary; room for it is left when choosing the
+	// turns it replaces
+	SummaryMaxTokens = 1500
+
+	// summaryTarget is the share of the budget the history is brought down to when
+	// turns are summarized, so the next few turns fit without summarizing again
+	summaryTarget = 0.75
+
+	// maxSummaryMessageChars and maxSummaryInputChars bound what the summary
+	// model is sent: each message, and the transcript as a whole
+	maxSummaryMessageChars = 4000
+	maxSummaryInputChars   = 120000
+)
+
+// summaryPrompt asks the summary model for a note the assistant can work from
+const summaryPrompt = `You compress the start of a conversation between a user and a coding assistant so the assistant can carry on without it. Write a concise summary as short bullet points covering:
+- what the user wants, including requirements and preferences they stated
+- decisions made and the reasons for them
+- files read, created or changed, and what matters about their contents
+- commands and tests run and their outcomes
+- open questions and the next steps agreed on
+Keep names, paths, identifiers and numbers exact. Only include what happened in the transcript. If an earlier summary is given, merge it in so nothing it records is lost.`
+
+// Overflow returns the oldest whole exchanges to fold into a summary so the
+// history fits the context budget with room to spare, together with the summary
+// from an earlier fold, if any. It returns no messages while the history fits,
+// or when only the current exchange could be removed.
+func (h *History) Overflow() (previous string, messages []api.ConversationMessage) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	budget := api.ContextBudget(h.config) - toolDefinitionTokens()
+	if budget <= 0 {
+		return "", nil
+	}
+
+	total := 0
+	for _, msg := range h.messages {
+		total += msg.Tokens
+	}
+	if total <= budget {
+		return "", nil
+	}
+
+	var otherMessages []api.ConversationMessage
+	for _, msg := range h.messages {
+		switch {
+		case msg.Role == "system" && strings.HasPrefix(msg.Content, summaryNotePrefix):
+			// The new summary replaces this one
+			previous = strings.TrimSpace(msg.Content[len(summaryNotePrefix):])
+			total -= msg.Tokens
+		case msg.Role != "system":
+			otherMessages = append(otherMessages, msg)
+		}
+	}
+
+	target := int(float64(budget)*summaryTarget) - SummaryMaxTokens
+	drop, _ := exchangesToDrop(otherMessages, total, target)
+	if drop == 0 {
+		return "", nil
+	}
+	return previous, otherMessages[:drop]
+}
+
+// Summarize replaces messages, which must be the oldest non-system messages as
+// returned by Overflow, with a system note holding summary. It reports false,
+// changing nothing, when the history no longer starts with those messages.
+func (h *History) Summarize(messages []api.ConversationMessage, summary string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	summary = strings.TrimSpace(summary)
+	if len(messages) == 0 || summary == "" {
+		return false
+	}
+
+	note := api.ConversationMessage{
+		Role:      "system",
+		Content:   summaryNotePrefix + "\n\n" + summary,
+		Timestamp: time.Now(),
+	}
+	note.Tokens = estimateMessageTokens(note)
+
+	kept := make([]api.ConversationMessage, 0, len(h.messages)-len(messages)+1)
+	removed := 0
+	for _, msg := range h.messages {
+		switch {
+		case msg.Role == "system" && strings.HasPrefix(msg.Content, summaryNotePrefix):
+			continue
+		case msg.Role == "system":
+			kept = append(kept, msg)
+		case removed < len(messages):
+			want := messages[removed]
+			if msg.Role != want.Role || msg.Content != want.Content || !msg.Timestamp.Equal(want.Timestamp) {
+				return false
+			}
+			removed++
+			if removed == len(messages) {
+				// The note takes the place of the turns it summarizes
+				kept = append(kept, note)
+			}
+		default:
+			kept = append(kept, msg)
+		}
+	}
+	if removed < len(messages) {
+		return false
+	}
+
+	h.messages = kept
+	return true
+}
+
+// SummaryRequest builds the messages asking a model to summarize the given turns,
+// folding in the summary of earlier ones
+func SummaryRequest(previous string, messages []api.ConversationMessage) []openai.ChatCompletionMessage {
+	var b strings.Builder
+	if previous != "" {
+		b.WriteString("Earlier summary:\n" + previous + "\n\n")
+	}
+	b.WriteString("Transcript:\n")
+	for _, msg := range messages {
+		if b.Len() >= maxSummaryInputChars {
+			b.WriteString("\n[rest of the transcript omitted]\n")
+			break
+		}
+		b.WriteString("\n" + summaryLine(msg) + "\n")
+	}
+
+	return []openai.ChatCompletionMessage{
+		{Role: openai.ChatMessageRoleSystem, Content: summaryPrompt},
+		{Role: openai.ChatMessageRoleUser, Content: b.String()},
+	}
+}
+
+// summaryLine renders one message of the transcript sent to the summary model
+func summaryLine(msg api.ConversationMessage) string {
+	var line string
+	switch msg.Role {
+	case "user":
+		line = "User: " + clip(msg.Content, maxSummaryMessageChars)
+	case "assistant":
+		line = "Assistant: " + clip(msg.Content, maxSummaryMessageChars)
+		for _, call := range msg.ToolCalls {
+			line += fmt.Sprintf("\nAssistant called %s(%s)", call.Function.Name, clip(call.Function.Arguments, 500))
+		}
+	case "tool":
+		// Tool output is bulky; the start says enough about what it was
+		line = "Tool result: " + clip(msg.Content, maxSummaryMessageChars/2)
+	default:
+		line = msg.Role + ": " + clip(msg.Content, maxSummaryMessageChars)
+	}
+	return line
+}
+
+// clip cuts s to at most n bytes, marking the cut
+func clip(s string, n int) string {
+	s = strings.TrimSpace(s)
+	if len(s) <= n {
+		return s
+	}
+	return strings.ToValidUTF8(s[:n], "") + " [...]"
+}
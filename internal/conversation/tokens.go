@@ -0,0 +1,198 @@
+package conversation
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/alchemy-labs-co/riptide/internal/api"
+)
+
+const (
+	// messageOverheadTokens approximates the per-message framing cost (role, separators)
+	messageOverheadTokens = 4
+
+	// wordTokenChars is how many letters of a long word one token covers on average
+	wordTokenChars = 6
+
+	// singleTokenWordLength is the longest word assumed to be a single token
+	singleTokenWordLength = 8
+)
+
+// ContextItem describes a file that has been added to the conversation context
+type ContextItem struct {
+	Path    string
+	Tokens  int
+	Bytes   int
+	AddedAt time.Time // When the content was read, to tell whether the file has changed since
+}
+
+// EstimateTokens returns an estimated token count for the given text. The text is
+// split the way tiktoken's cl100k and o200k encodings pre-tokenize it (contractions,
+// words with their leading space, runs of up to three digits, punctuation runs and
+// whitespace), and each piece is costed by its length, so code and prose both land
+// close to the count the API reports without shipping a vocabulary.
+func EstimateTokens(text string) int {
+	tokens := 0
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		switch {
+		case r == '\'' && contractionLength(text[i+size:]) > 0:
+			i += size + contractionLength(text[i+size:])
+			tokens++
+
+		case isLetter(r) || (!isDigit(r) && r != '\r' && r != '\n' && isLetterAt(text, i+size)):
+			if !isLetter(r) {
+				i += size
+			}
+			start := i
+			for i < len(text) {
+				r, size := utf8.DecodeRuneInString(text[i:])
+				if !isLetter(r) {
+					break
+				}
+				i += size
+			}
+			tokens += wordTokens(text[start:i])
+
+		case isDigit(r):
+			for n := 0; n < 3 && i < len(text); n++ {
+				r, size := utf8.DecodeRuneInString(text[i:])
+				if !isDigit(r) {
+					break
+				}
+				i += size
+			}
+			tokens++
+
+		case unicode.IsSpace(r) && !(r == ' ' && isPunctuationAt(text, i+size)):
+			for i < len(text) {
+				r, size := utf8.DecodeRuneInString(text[i:])
+				if !unicode.IsSpace(r) {
+					break
+				}
+				i += size
+			}
+			tokens++
+
+		default:
+			if r == ' ' {
+				i += size
+			}
+			n := 0
+			for i < len(text) {
+				r, size := utf8.DecodeRuneInString(text[i:])
+				if unicode.IsSpace(r) || isLetter(r) || isDigit(r) {
+					break
+				}
+				i += size
+				n++
+			}
+			tokens += (n + 1) / 2
+		}
+	}
+	return tokens
+}
+
+// wordTokens estimates the tokens in a run of letters. Short ASCII words are almost
+// always a single token; longer ones and identifiers split into several, and
+// non-Latin scripts take a token for every one or two characters.
+func wordTokens(word string) int {
+	n := 0
+	wide := 0
+	ascii := true
+	for _, r := range word {
+		n++
+		if r >= utf8.RuneSelf {
+			ascii = false
+		}
+		if unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) {
+			wide++
+		}
+	}
+	if !ascii {
+		return wide + (n-wide+1)/2
+	}
+	if n <= singleTokenWordLength {
+		return 1
+	}
+	return (n + wordTokenChars - 1) / wordTokenChars
+}
+
+// contractionLength returns the length of the English contraction suffix ('s, 't,
+// 're, 've, 'm, 'll, 'd) that text starts with, after the apostrophe, or 0
+func contractionLength(text string) int {
+	for _, suffix := range []string{"re", "ve", "ll", "s", "t", "m", "d"} {
+		if len(text) >= len(suffix) && equalFoldASCII(text[:len(suffix)], suffix) {
+			return len(suffix)
+		}
+	}
+	return 0
+}
+
+// equalFoldASCII reports whether two ASCII strings are equal ignoring case
+func equalFoldASCII(a, b string) bool {
+	for i := 0; i < len(a); i++ {
+		if a[i]|0x20 != b[i]|0x20 {
+			return false
+		}
+	}
+	return true
+}
+
+// isLetter reports whether r belongs to a word piece
+func isLetter(r rune) bool {
+	return unicode.IsLetter(r) || unicode.Is(unicode.Mn, r)
+}
+
+// isDigit reports whether r belongs to a number piece
+func isDigit(r rune) bool {
+	return unicode.IsNumber(r)
+}
+
+// isLetterAt reports whether the rune at byte offset i of text is a letter
+func isLetterAt(text string, i int) bool {
+	if i >= len(text) {
+		return false
+	}
+	r, _ := utf8.DecodeRuneInString(text[i:])
+	return isLetter(r)
+}
+
+// isPunctuationAt reports whether the rune at byte offset i of text starts a
+// punctuation piece, which takes a single preceding space with it
+func isPunctuationAt(text string, i int) bool {
+	if i >= len(text) {
+		return false
+	}
+	r, _ := utf8.DecodeRuneInString(text[i:])
+	return !unicode.IsSpace(r) && !isLetter(r) && !isDigit(r)
+}
+
+// estimateMessageTokens estimates how many tokens a message occupies in a request.
+// Reasoning content is excluded because it is never sent back to the API.
+func estimateMessageTokens(msg api.ConversationMessage) int {
+	tokens := messageOverheadTokens + EstimateTokens(msg.Content)
+	for _, tc := range msg.ToolCalls {
+		tokens += EstimateTokens(tc.Function.Name) + EstimateTokens(tc.Function.Arguments)
+	}
+	return tokens
+}
+
+var (
+	toolTokensOnce sync.Once
+	toolTokens     int
+)
+
+// toolDefinitionTokens estimates the tokens the tool definitions add to every request
+func toolDefinitionTokens() int {
+	toolTokensOnce.Do(func() {
+		data, err := json.Marshal(api.GetTools())
+		if err == nil {
+			toolTokens = EstimateTokens(string(data))
+		}
+	})
+	return toolTokens
+}
@@ -0,0 +1,137 @@
+package daemon
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/charmbracelet/x/term"
+)
+
+// Terminal sequences the client uses while attached
+const (
+	enterAltScreen = "\x1b[?1049h\x1b[?25l"
+	exitAltScreen  = "\x1b[?25h\x1b[?1049l"
+)
+
+// startTimeout bounds how long Start waits for a new daemon's socket
+const startTimeout = 5 * time.Second
+
+// Start launches a daemon by running this executable with args in a new
+// session detached from the terminal, then waits until socket accepts
+// connections. The daemon's stderr goes to LogPath(socket).
+func Start(socket string, args []string) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("finding executable: %w", err)
+	}
+
+	logFile, err := os.OpenFile(LogPath(socket), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("opening daemon log: %w", err)
+	}
+	defer logFile.Close()
+
+	cmd := exec.Command(exe, args...)
+	cmd.Stderr = logFile
+	cmd.SysProcAttr = detachedProcess()
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("starting daemon: %w", err)
+	}
+
+	exited := make(chan struct{})
+	go func() {
+		cmd.Wait()
+		close(exited)
+	}()
+
+	deadline := time.After(startTimeout)
+	for !Running(socket) {
+		select {
+		case <-exited:
+			return fmt.Errorf("daemon exited during startup; see %s", LogPath(socket))
+		case <-deadline:
+			return fmt.Errorf("daemon did not start within %s; see %s", startTimeout, LogPath(socket))
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+	return nil
+}
+
+// Attach connects this terminal to the session on socket until the user
+// presses DetachKey or the session ends. It reports whether the session is
+// still running afterwards.
+func Attach(socket string) (bool, error) {
+	stdin, stdout := os.Stdin.Fd(), os.Stdout.Fd()
+	if !term.IsTerminal(stdin) || !term.IsTerminal(stdout) {
+		return false, errors.New("attaching needs a terminal")
+	}
+
+	conn, err := net.Dial("unix", socket)
+	if err != nil {
+		return false, fmt.Errorf("connecting to session: %w", err)
+	}
+	defer conn.Close()
+
+	state, err := term.MakeRaw(stdin)
+	if err != nil {
+		return false, fmt.Errorf("entering raw mode: %w", err)
+	}
+	defer term.Restore(stdin, state)
+
+	os.Stdout.WriteString(enterAltScreen)
+	defer os.Stdout.WriteString(exitAltScreen)
+
+	// The first resize tells the daemon a client is ready and triggers a full redraw
+	sendSize := func() error {
+		width, height, err := term.GetSize(stdout)
+		if err != nil {
+			return fmt.Errorf("getting terminal size: %w", err)
+		}
+		return writeFrame(conn, frameResize, resizePayload(width, height))
+	}
+	if err := sendSize(); err != nil {
+		return false, err
+	}
+
+	resized, stopResize := notifyResize()
+	defer stopResize()
+	go func() {
+		for range resized {
+			if sendSize() != nil {
+				return
+			}
+		}
+	}()
+
+	// Forward keystrokes until the detach key, then hang up
+	go func() {
+		buf := make([]byte, 4096)
+		for {
+			n, err := os.Stdin.Read(buf)
+			if err != nil {
+				conn.Close()
+				return
+			}
+			input := buf[:n]
+			if i := bytes.IndexByte(input, DetachKey); i >= 0 {
+				if i > 0 {
+					writeFrame(conn, frameInput, input[:i])
+				}
+				conn.Close()
+				return
+			}
+			if writeFrame(conn, frameInput, input) != nil {
+				return
+			}
+		}
+	}()
+
+	io.Copy(os.Stdout, conn)
+	return Running(socket), nil
+}
@@ -0,0 +1,137 @@
+// Package daemon keeps a Riptide session running in a background process and
+// lets terminals attach to and detach from it over a Unix socket, so a long
+// task survives a closed laptop lid or a dropped SSH connection.
+//
+// The daemon runs the normal Bubble Tea program with its input and output
+// connected to whichever client is attached. Clients send framed input and
+// resize events; the daemon sends back raw terminal output.
+package daemon
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DetachKey is the byte for ctrl+\, which detaches a client and leaves the session running
+const DetachKey = 0x1c
+
+// Frame types sent from client to daemon
+const (
+	frameInput  = 'i' // Raw terminal input
+	frameResize = 'r' // Width and height as two big-endian uint16s
+)
+
+// maxFrameBytes bounds a single client frame
+const maxFrameBytes = 64 * 1024
+
+// SocketDir returns the per-user directory holding session sockets and logs.
+// In a shared temporary directory another user could create it first, so an
+// existing directory is only used when it is private to this user.
+func SocketDir() (string, error) {
+	base := os.Getenv("XDG_RUNTIME_DIR")
+	if base == "" {
+		base = os.TempDir()
+	}
+	dir := filepath.Join(base, fmt.Sprintf("riptide-%d", os.Getuid()))
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("creating socket directory: %w", err)
+	}
+	if err := checkPrivateDir(dir); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// SocketPath returns the socket for a named session
+func SocketPath(name string) (string, error) {
+	if name == "" || strings.ContainsAny(name, `/\`) || strings.HasPrefix(name, ".") {
+		return "", fmt.Errorf("invalid session name %q", name)
+	}
+	dir, err := SocketDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, name+".sock"), nil
+}
+
+// LogPath returns where a session's daemon writes its errors
+func LogPath(socket string) string {
+	return strings.TrimSuffix(socket, ".sock") + ".log"
+}
+
+// Running reports whether a daemon is accepting connections on socket
+func Running(socket string) bool {
+	conn, err := net.DialTimeout("unix", socket, time.Second)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
+
+// List returns the names of running sessions, removing sockets left behind by
+// daemons that exited without cleaning up
+func List() ([]string, error) {
+	dir, err := SocketDir()
+	if err != nil {
+		return nil, err
+	}
+	sockets, err := filepath.Glob(filepath.Join(dir, "*.sock"))
+	if err != nil {
+		return nil, fmt.Errorf("listing sessions: %w", err)
+	}
+
+	var names []string
+	for _, socket := range sockets {
+		if !Running(socket) {
+			os.Remove(socket)
+			continue
+		}
+		names = append(names, strings.TrimSuffix(filepath.Base(socket), ".sock"))
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// writeFrame sends one typed frame
+func writeFrame(w io.Writer, kind byte, payload []byte) error {
+	header := make([]byte, 5)
+	header[0] = kind
+	binary.BigEndian.PutUint32(header[1:], uint32(len(payload)))
+	if _, err := w.Write(append(header, payload...)); err != nil {
+		return fmt.Errorf("writing frame: %w", err)
+	}
+	return nil
+}
+
+// readFrame reads one typed frame
+func readFrame(r io.Reader) (byte, []byte, error) {
+	header := make([]byte, 5)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return 0, nil, err
+	}
+	size := binary.BigEndian.Uint32(header[1:])
+	if size > maxFrameBytes {
+		return 0, nil, fmt.Errorf("frame of %d bytes exceeds limit", size)
+	}
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return 0, nil, err
+	}
+	return header[0], payload, nil
+}
+
+// resizePayload encodes a terminal size for a resize frame
+func resizePayload(width, height int) []byte {
+	payload := make([]byte, 4)
+	binary.BigEndian.PutUint16(payload[0:], uint16(width))
+	binary.BigEndian.PutUint16(payload[2:], uint16(height))
+	return payload
+}
@@ -0,0 +1,45 @@
+//go:build !windows
+
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// detachedProcess starts the daemon in its own session so it outlives the terminal
+func detachedProcess() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{Setsid: true}
+}
+
+// notifyResize delivers a value whenever the terminal is resized
+func notifyResize() (<-chan os.Signal, func()) {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, syscall.SIGWINCH)
+	return ch, func() {
+		signal.Stop(ch)
+		close(ch)
+	}
+}
+
+// checkPrivateDir refuses a directory that is a symlink, belongs to another
+// user or can be entered by others, any of which would let someone else
+// replace or listen on the sockets in it
+func checkPrivateDir(dir string) error {
+	info, err := os.Lstat(dir)
+	if err != nil {
+		return fmt.Errorf("checking socket directory: %w", err)
+	}
+	if info.Mode()&os.ModeSymlink != 0 || !info.IsDir() {
+		return fmt.Errorf("socket directory %s is not a directory; remove it and try again", dir)
+	}
+	if stat, ok := info.Sys().(*syscall.Stat_t); !ok || int(stat.Uid) != os.Getuid() {
+		return fmt.Errorf("socket directory %s belongs to another user; remove it or set XDG_RUNTIME_DIR", dir)
+	}
+	if perm := info.Mode().Perm(); perm != 0700 {
+		return fmt.Errorf("socket directory %s has mode %04o; it must be 0700", dir, perm)
+	}
+	return nil
+}
@@ -0,0 +1,27 @@
+//go:build windows
+
+package daemon
+
+import (
+	"os"
+	"syscall"
+)
+
+// detachedProcess starts the daemon without a console so it outlives the terminal
+func detachedProcess() *syscall.SysProcAttr {
+	const detachedProcessFlag = 0x00000008
+	return &syscall.SysProcAttr{CreationFlags: detachedProcessFlag | syscall.CREATE_NEW_PROCESS_GROUP}
+}
+
+// notifyResize never fires: Windows consoles have no resize signal, so the
+// size is only sent on attach
+func notifyResize() (<-chan os.Signal, func()) {
+	ch := make(chan os.Signal)
+	return ch, func() { close(ch) }
+}
+
+// checkPrivateDir accepts any directory: access on Windows is governed by
+// ACLs, and the default ones keep a user's profile directories private
+func checkPrivateDir(dir string) error {
+	return nil
+}
@@ -0,0 +1,180 @@
+package daemon
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// writeTimeout is how long a client may stall output before it is disconnected
+const writeTimeout = 5 * time.Second
+
+// Server relays a Bubble Tea program to the attached client. At most one
+// client is attached; a new one takes over from the last.
+type Server struct {
+	socket   string
+	listener net.Listener
+
+	input  *io.PipeReader // Read by the program
+	feed   *io.PipeWriter // Written with client input
+	output *switchWriter  // Program output, sent to the attached client
+
+	mu     sync.Mutex
+	client net.Conn
+}
+
+// Listen creates the session socket. It fails if another daemon is already
+// serving it and replaces a stale socket left by one that crashed.
+func Listen(socket string) (*Server, error) {
+	if Running(socket) {
+		return nil, fmt.Errorf("session %s is already running", socket)
+	}
+	os.Remove(socket)
+
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		return nil, fmt.Errorf("listening on %s: %w", socket, err)
+	}
+	if err := os.Chmod(socket, 0600); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("securing socket: %w", err)
+	}
+
+	input, feed := io.Pipe()
+	return &Server{
+		socket:   socket,
+		listener: listener,
+		input:    input,
+		feed:     feed,
+		output:   &switchWriter{},
+	}, nil
+}
+
+// ProgramOptions connects a program's input and output to the attached client
+func (s *Server) ProgramOptions() []tea.ProgramOption {
+	return []tea.ProgramOption{
+		tea.WithInput(s.input),
+		tea.WithOutput(s.output),
+		tea.WithoutSignalHandler(),
+	}
+}
+
+// Serve accepts clients until Close is called, sending their input and
+// resizes to p
+func (s *Server) Serve(p *tea.Program) {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.attach(conn)
+		go s.relayInput(conn, p)
+	}
+}
+
+// attach makes conn the attached client, disconnecting any previous one
+func (s *Server) attach(conn net.Conn) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.client != nil {
+		s.client.Close()
+	}
+	s.client = conn
+	s.output.set(conn)
+}
+
+// detach forgets conn if it is still the attached client
+func (s *Server) detach(conn net.Conn) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.client == conn {
+		s.client = nil
+		s.output.set(nil)
+	}
+	conn.Close()
+}
+
+// relayInput forwards one client's frames to the program until it disconnects
+func (s *Server) relayInput(conn net.Conn, p *tea.Program) {
+	defer s.detach(conn)
+
+	attached := false
+	for {
+		kind, payload, err := readFrame(conn)
+		if err != nil {
+			return
+		}
+		switch kind {
+		case frameInput:
+			if _, err := s.feed.Write(payload); err != nil {
+				return
+			}
+		case frameResize:
+			if len(payload) != 4 {
+				return
+			}
+			p.Send(tea.WindowSizeMsg{
+				Width:  int(binary.BigEndian.Uint16(payload[0:])),
+				Height: int(binary.BigEndian.Uint16(payload[2:])),
+			})
+			// The new terminal has none of the screen; redraw all of it
+			if !attached {
+				attached = true
+				p.Send(tea.ClearScreen())
+			}
+		}
+	}
+}
+
+// Close stops accepting clients, disconnects the attached one and removes the socket
+func (s *Server) Close() error {
+	err := s.listener.Close()
+	s.feed.Close()
+
+	s.mu.Lock()
+	if s.client != nil {
+		s.client.Close()
+		s.client = nil
+	}
+	s.mu.Unlock()
+
+	if rmErr := os.Remove(s.socket); rmErr != nil && !errors.Is(rmErr, os.ErrNotExist) && err == nil {
+		err = rmErr
+	}
+	return err
+}
+
+// switchWriter sends output to the attached client, or discards it while none is
+type switchWriter struct {
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+func (s *switchWriter) set(conn net.Conn) {
+	s.mu.Lock()
+	s.conn = conn
+	s.mu.Unlock()
+}
+
+// Write never fails, so a vanished client cannot stop the program. A client
+// that stops reading (e.g. a suspended SSH connection) is dropped after
+// writeTimeout rather than blocking the renderer.
+func (s *switchWriter) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn != nil {
+		s.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+		if _, err := s.conn.Write(p); err != nil {
+			s.conn.Close()
+			s.conn = nil
+		}
+	}
+	return len(p), nil
+}
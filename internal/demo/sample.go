@@ -0,0 +1,96 @@
+package demo
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// sampleFiles is the small Go project the demo works on. stats.go has a bug
+// (Mean skips the first value and divides by zero on empty input) that the
+// scripted conversation finds and fixes.
+var sampleFiles = map[string]string{
+	"go.mod": `module example.com/stats
+
+go 1.22
+`,
+	"README.md": `# stats
+
+A tiny statistics package used by the Riptide demo.
+`,
+	"stats.go": `package stats
+
+// Mean returns the arithmetic mean of values
+func Mean(values []float64) float64 {
+	var sum float64
+	for i := 1; i < len(values); i++ {
+		sum += values[i]
+	}
+	return sum / float64(len(values))
+}
+
+// Max returns the largest of values, or 0 for an empty slice
+func Max(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	max := values[0]
+	for _, v := range values[1:] {
+		if v > max {
+			max = v
+		}
+	}
+	return max
+}
+`,
+	"cmd/stats/main.go": `package main
+
+import (
+	"fmt"
+
+	"example.com/stats"
+)
+
+func main() {
+	values := []float64{4, 8, 15, 16, 23, 42}
+	fmt.Printf("mean=%.2f max=%.0f\n", stats.Mean(values), stats.Max(values))
+}
+`,
+}
+
+// Mean's buggy loop and its fix, used by the scripted edit
+const (
+	buggyMean = `	var sum float64
+	for i := 1; i < len(values); i++ {
+		sum += values[i]
+	}
+	return sum / float64(len(values))`
+	fixedMean = `	if len(values) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))`
+)
+
+// Setup writes the sample project to a fresh temporary directory and returns it
+func Setup() (string, error) {
+	dir, err := os.MkdirTemp("", "riptide-demo-*")
+	if err != nil {
+		return "", fmt.Errorf("creating demo directory: %w", err)
+	}
+
+	for name, content := range sampleFiles {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return "", fmt.Errorf("creating demo directory: %w", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			return "", fmt.Errorf("writing demo file: %w", err)
+		}
+	}
+
+	return dir, nil
+}
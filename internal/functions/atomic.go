@@ -0,0 +1,149 @@
+package functions
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// writeFileAtomic writes data to a temporary file next to path and renames it into
+// place, so an interrupted write never leaves a truncated file behind
+func (f *FileOperations) writeFileAtomic(path string, data []byte) error {
+	f.writeMu.Lock()
+	defer f.writeMu.Unlock()
+
+	if f.closed {
+		return fmt.Errorf("file operations are shut down")
+	}
+
+	// Capture the previous contents for the write ledger, and keep a copy for /undo
+	beforeHash, bytesBefore := snapshotFile(path)
+	var backup string
+	if f.undoDir != "" {
+		var err error
+		if backup, err = f.saveBeforeImage(path); err != nil {
+			return fmt.Errorf("backing up file: %w", err)
+		}
+	}
+
+	if err := replaceFile(path, data); err != nil {
+		return err
+	}
+
+	f.recordEditedFile(path)
+
+	// The write already succeeded, so a ledger failure must not fail the tool call
+	_ = f.appendWriteRecord(WriteRecord{
+		Time:        time.Now(),
+		Path:        path,
+		Tool:        f.tool,
+		Turn:        f.turn,
+		BeforeHash:  beforeHash,
+		AfterHash:   hashBytes(data),
+		BytesBefore: bytesBefore,
+		BytesAfter:  len(data),
+	})
+	if f.undoDir != "" {
+		_ = f.recordUndoEntry(UndoEntry{
+			Call:      f.call,
+			Turn:      f.turn,
+			Time:      time.Now(),
+			Path:      path,
+			Tool:      f.tool,
+			Backup:    backup,
+			AfterHash: hashBytes(data),
+		})
+	}
+	return nil
+}
+
+// replaceFile writes data to a temporary file next to path and renames it into
+// place. Like os.WriteFile it writes through a symlink to the file it points at
+// and keeps an existing file's mode, so scripts stay executable.
+func replaceFile(path string, data []byte) error {
+	path = resolveSymlinks(path)
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".riptide-*")
+	if err != nil {
+		return fmt.Errorf("creating temp file: %w", err)
+	}
+	tmpPath := tmp.Name()
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return fmt.Errorf("writing temp file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("closing temp file: %w", err)
+	}
+	if err := os.Chmod(tmpPath, mode); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("setting file mode: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("replacing file: %w", err)
+	}
+
+	return nil
+}
+
+// maxSymlinks bounds how many links resolveSymlinks follows, as the kernel does
+const maxSymlinks = 40
+
+// resolveSymlinks returns the file path finally points at, following links
+// one at a time so a link to a file not yet created resolves too. A path that
+// is not a link is returned as it is.
+func resolveSymlinks(path string) string {
+	for i := 0; i < maxSymlinks; i++ {
+		info, err := os.Lstat(path)
+		if err != nil || info.Mode()&os.ModeSymlink == 0 {
+			return path
+		}
+		target, err := os.Readlink(path)
+		if err != nil {
+			return path
+		}
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(filepath.Dir(path), target)
+		}
+		path = target
+	}
+	return path
+}
+
+// recordEditedFile remembers a written file for coverage reports. Callers hold writeMu.
+func (f *FileOperations) recordEditedFile(path string) {
+	for _, edited := range f.editedFiles {
+		if edited == path {
+			return
+		}
+	}
+	f.editedFiles = append(f.editedFiles, path)
+}
+
+// EditedFiles returns the files written this session, oldest first
+func (f *FileOperations) EditedFiles() []string {
+	f.writeMu.Lock()
+	defer f.writeMu.Unlock()
+	return append([]string(nil), f.editedFiles...)
+}
+
+// Cleanup waits for an in-flight write to finish renaming or removing its temp file,
+// then rejects further writes so tool calls still queued at exit cannot touch disk.
+// It also stops the background processes and language servers started this session.
+func (f *FileOperations) Cleanup() {
+	f.writeMu.Lock()
+	f.closed = true
+	f.writeMu.Unlock()
+
+	f.processes.stopAll()
+	f.lsp.Close()
+}
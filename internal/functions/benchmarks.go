@@ -0,0 +1,305 @@
+package functions
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// BenchmarkBaselineFile stores saved baselines relative to the workspace root
+	BenchmarkBaselineFile = ".riptide/benchmarks.json"
+
+	// benchmarkTimeout bounds a single run_benchmarks invocation
+	benchmarkTimeout = 20 * time.Minute
+
+	// benchmarkNoise is the relative change treated as measurement noise
+	benchmarkNoise = 0.05
+
+	// goBenchmarkCount is how many times each Go benchmark runs; the median is kept
+	goBenchmarkCount = 5
+)
+
+// BenchmarkResult is the measured cost of one benchmark
+type BenchmarkResult struct {
+	NsPerOp     float64 `json:"ns_per_op"`
+	BytesPerOp  float64 `json:"bytes_per_op,omitempty"`
+	AllocsPerOp float64 `json:"allocs_per_op,omitempty"`
+}
+
+// benchmarkBaseline is a named set of results saved for later comparison
+type benchmarkBaseline struct {
+	Saved   time.Time                  `json:"saved"`
+	Results map[string]BenchmarkResult `json:"results"`
+}
+
+// goBenchLine matches a result line such as
+// "BenchmarkParse-8   1000   1234 ns/op   56 B/op   2 allocs/op"
+var goBenchLine = regexp.MustCompile(`^(Benchmark\S+?)(?:-\d+)?\s+\d+\s+([\d.]+) ns/op(?:\s+([\d.]+) B/op)?(?:\s+([\d.]+) allocs/op)?`)
+
+// runBenchmarks runs Go benchmarks (or a hyperfine command when command is set),
+// compares the results with a stored baseline and optionally saves them as the
+// new baseline
+func (f *FileOperations) runBenchmarks(pattern, target, command, baseline string, save bool) (string, error) {
+	root, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("getting working directory: %w", err)
+	}
+	if baseline == "" {
+		baseline = "default"
+	}
+
+	var args []string
+	var exportPath string
+	if command != "" {
+		if _, err := exec.LookPath("hyperfine"); err != nil {
+			return "", fmt.Errorf("hyperfine is not installed; install it or benchmark Go code with the benchmark pattern instead")
+		}
+		tmp, err := os.CreateTemp("", "riptide-hyperfine-*.json")
+		if err != nil {
+			return "", fmt.Errorf("creating hyperfine export: %w", err)
+		}
+		exportPath = tmp.Name()
+		tmp.Close()
+		defer os.Remove(exportPath)
+
+		args = []string{"hyperfine", "--style", "basic", "--warmup", "1", "--export-json", exportPath, command}
+	} else {
+		if pattern == "" {
+			pattern = "."
+		}
+		if target == "" {
+			target = "./..."
+		}
+		args = append([]string{"go", "test", "-run=^$", "-bench=" + pattern, "-benchmem", fmt.Sprintf("-count=%d", goBenchmarkCount)}, strings.Fields(target)...)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), benchmarkTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Dir = root
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	start := time.Now()
+	runErr := cmd.Run()
+	elapsed := time.Since(start).Round(100 * time.Millisecond)
+
+	shown := displayTestCommand(args, exportPath)
+	header := fmt.Sprintf("Ran `%s` in %s", strings.Join(shown, " "), elapsed)
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Sprintf("%s — TIMED OUT after %s\n\n%s", header, benchmarkTimeout, tailOutput(output.String(), maxTestOutput)), nil
+	}
+	if runErr != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(runErr, &exitErr) {
+			return "", fmt.Errorf("running %s: %w", args[0], runErr)
+		}
+		return fmt.Sprintf("%s — FAILED (%v)\n\n%s", header, runErr, tailOutput(output.String(), maxTestOutput)), nil
+	}
+
+	var current map[string]BenchmarkResult
+	if command != "" {
+		current, err = parseHyperfineExport(exportPath)
+	} else {
+		current = parseGoBenchmarks(output.String())
+	}
+	if err != nil {
+		return "", err
+	}
+	if len(current) == 0 {
+		return fmt.Sprintf("%s — no benchmark results found\n\n%s", header, tailOutput(output.String(), maxTestOutput)), nil
+	}
+
+	baselinePath := filepath.Join(root, BenchmarkBaselineFile)
+	baselines, err := loadBenchmarkBaselines(baselinePath)
+	if err != nil {
+		return "", err
+	}
+
+	var result strings.Builder
+	result.WriteString(header + "\n\n")
+	result.WriteString(compareBenchmarks(baseline, baselines[baseline], current))
+
+	if save {
+		// Merge so saving one benchmark keeps the rest of the baseline
+		saved := baselines[baseline]
+		if saved.Results == nil {
+			saved.Results = make(map[string]BenchmarkResult)
+		}
+		for name, res := range current {
+			saved.Results[name] = res
+		}
+		saved.Saved = time.Now()
+		baselines[baseline] = saved
+
+		if err := saveBenchmarkBaselines(baselinePath, baselines); err != nil {
+			return "", err
+		}
+		result.WriteString(fmt.Sprintf("\n\nSaved results for %d benchmarks as baseline %q in %s", len(current), baseline, BenchmarkBaselineFile))
+	}
+
+	return result.String(), nil
+}
+
+// parseGoBenchmarks extracts the median result of each benchmark from go test output
+func parseGoBenchmarks(output string) map[string]BenchmarkResult {
+	samples := make(map[string][]BenchmarkResult)
+	for _, line := range strings.Split(output, "\n") {
+		match := goBenchLine.FindStringSubmatch(strings.TrimSpace(line))
+		if match == nil {
+			continue
+		}
+		var res BenchmarkResult
+		res.NsPerOp, _ = strconv.ParseFloat(match[2], 64)
+		res.BytesPerOp, _ = strconv.ParseFloat(match[3], 64)
+		res.AllocsPerOp, _ = strconv.ParseFloat(match[4], 64)
+		samples[match[1]] = append(samples[match[1]], res)
+	}
+
+	results := make(map[string]BenchmarkResult, len(samples))
+	for name, runs := range samples {
+		results[name] = BenchmarkResult{
+			NsPerOp:     median(runs, func(r BenchmarkResult) float64 { return r.NsPerOp }),
+			BytesPerOp:  median(runs, func(r BenchmarkResult) float64 { return r.BytesPerOp }),
+			AllocsPerOp: median(runs, func(r BenchmarkResult) float64 { return r.AllocsPerOp }),
+		}
+	}
+	return results
+}
+
+// median returns the median of one metric across runs
+func median(runs []BenchmarkResult, metric func(BenchmarkResult) float64) float64 {
+	values := make([]float64, len(runs))
+	for i, run := range runs {
+		values[i] = metric(run)
+	}
+	sort.Float64s(values)
+
+	mid := len(values) / 2
+	if len(values)%2 == 0 {
+		return (values[mid-1] + values[mid]) / 2
+	}
+	return values[mid]
+}
+
+// parseHyperfineExport reads the mean time per command from a hyperfine JSON export
+func parseHyperfineExport(path string) (map[string]BenchmarkResult, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading hyperfine export: %w", err)
+	}
+
+	var export struct {
+		Results []struct {
+			Command string  `json:"command"`
+			Mean    float64 `json:"mean"` // Seconds
+		} `json:"results"`
+	}
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, fmt.Errorf("parsing hyperfine export: %w", err)
+	}
+
+	results := make(map[string]BenchmarkResult, len(export.Results))
+	for _, res := range export.Results {
+		results[res.Command] = BenchmarkResult{NsPerOp: res.Mean * 1e9}
+	}
+	return results, nil
+}
+
+// compareBenchmarks formats current results against a baseline with relative deltas
+func compareBenchmarks(name string, baseline benchmarkBaseline, current map[string]BenchmarkResult) string {
+	names := make([]string, 0, len(current))
+	for benchmark := range current {
+		names = append(names, benchmark)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	if baseline.Results == nil {
+		b.WriteString(fmt.Sprintf("No baseline %q yet; set save_baseline to record these results.\n", name))
+	} else {
+		b.WriteString(fmt.Sprintf("Compared with baseline %q saved %s (changes within ±%.0f%% are noise):\n",
+			name, baseline.Saved.Format("2006-01-02 15:04"), benchmarkNoise*100))
+	}
+
+	for _, benchmark := range names {
+		res := current[benchmark]
+		line := fmt.Sprintf("  %s: %s", benchmark, formatBenchmarkResult(res))
+
+		if base, ok := baseline.Results[benchmark]; ok && base.NsPerOp > 0 {
+			delta := (res.NsPerOp - base.NsPerOp) / base.NsPerOp
+			verdict := "no significant change"
+			switch {
+			case delta > benchmarkNoise:
+				verdict = "REGRESSION"
+			case delta < -benchmarkNoise:
+				verdict = "improvement"
+			}
+			line += fmt.Sprintf(" (was %s, %+.1f%% time, %s)", formatBenchmarkResult(base), delta*100, verdict)
+		} else if baseline.Results != nil {
+			line += " (not in baseline)"
+		}
+		b.WriteString(line + "\n")
+	}
+
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// formatBenchmarkResult renders a result as e.g. "1.23µs/op, 56 B/op, 2 allocs/op"
+func formatBenchmarkResult(res BenchmarkResult) string {
+	s := fmt.Sprintf("%.2fns/op", res.NsPerOp)
+	if res.NsPerOp >= 1000 {
+		s = time.Duration(math.Round(res.NsPerOp)).String() + "/op"
+	}
+	if res.BytesPerOp > 0 || res.AllocsPerOp > 0 {
+		s += fmt.Sprintf(", %.0f B/op, %.0f allocs/op", res.BytesPerOp, res.AllocsPerOp)
+	}
+	return s
+}
+
+// loadBenchmarkBaselines reads saved baselines; a missing file yields none
+func loadBenchmarkBaselines(path string) (map[string]benchmarkBaseline, error) {
+	baselines := make(map[string]benchmarkBaseline)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return baselines, nil
+		}
+		return nil, fmt.Errorf("reading benchmark baselines: %w", err)
+	}
+	if err := json.Unmarshal(data, &baselines); err != nil {
+		return nil, fmt.Errorf("parsing benchmark baselines: %w", err)
+	}
+	return baselines, nil
+}
+
+// saveBenchmarkBaselines writes the baselines file
+func saveBenchmarkBaselines(path string, baselines map[string]benchmarkBaseline) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("creating baseline directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(baselines, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling benchmark baselines: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("writing benchmark baselines: %w", err)
+	}
+	return nil
+}
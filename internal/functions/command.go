@@ -0,0 +1,159 @@
+package functions
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultCommandTimeout bounds run_command when no timeout is configured
+	defaultCommandTimeout = 2 * time.Minute
+
+	// maxCommandOutput is how much of the end of a command's output is returned
+	maxCommandOutput = 12000
+
+	// maxLiveOutput is how much of a running command's output is kept for the live view
+	maxLiveOutput = 16 * 1024
+)
+
+// errCommandStopped ends a command the user stopped from the live view
+var errCommandStopped = errors.New("stopped by the user")
+
+// RunningCommand is a snapshot of the command run_command is running
+type RunningCommand struct {
+	Command string
+	Started time.Time
+	Output  string // The end of the output so far, stdout and stderr interleaved
+}
+
+// liveCommand records a running command's output as it is written, so it can
+// be shown before the command finishes
+type liveCommand struct {
+	mu      sync.Mutex
+	command string
+	started time.Time
+	output  []byte
+	stop    context.CancelCauseFunc
+}
+
+// Write keeps the end of the output
+func (c *liveCommand) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.output = append(c.output, p...)
+	if len(c.output) > 2*maxLiveOutput {
+		c.output = append([]byte(nil), c.output[len(c.output)-maxLiveOutput:]...)
+	}
+	return len(p), nil
+}
+
+// RunningCommand returns the command run_command is running, with its output
+// so far, and false when none is running
+func (f *FileOperations) RunningCommand() (RunningCommand, bool) {
+	f.commandMu.Lock()
+	c := f.command
+	f.commandMu.Unlock()
+	if c == nil {
+		return RunningCommand{}, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	output := c.output
+	if len(output) > maxLiveOutput {
+		output = output[len(output)-maxLiveOutput:]
+	}
+	return RunningCommand{Command: c.command, Started: c.started, Output: string(output)}, true
+}
+
+// StopCommand stops the command run_command is running, with everything it
+// started, and reports whether one was running. The call returns the output
+// up to that point.
+func (f *FileOperations) StopCommand() bool {
+	f.commandMu.Lock()
+	defer f.commandMu.Unlock()
+	if f.command == nil {
+		return false
+	}
+	f.command.stop(errCommandStopped)
+	return true
+}
+
+// runCommand runs a shell command from the project root, such as a build or a
+// grep, and returns its exit status and the end of its output
+func (f *FileOperations) runCommand(command string) (string, error) {
+	if strings.TrimSpace(command) == "" {
+		return "", fmt.Errorf("command is required")
+	}
+
+	root, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("getting working directory: %w", err)
+	}
+
+	timeout := defaultCommandTimeout
+	if seconds := f.config.Commands.TimeoutSeconds; seconds > 0 {
+		timeout = time.Duration(seconds) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	ctx, stop := context.WithCancelCause(ctx)
+	defer stop(nil)
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	cmd.Dir = root
+	var output bytes.Buffer
+	live := &liveCommand{command: command, started: time.Now(), stop: stop}
+	// One writer for both streams, so they are written one at a time
+	writer := io.MultiWriter(&output, live)
+	cmd.Stdout = writer
+	cmd.Stderr = writer
+	// Stop everything the command started, not just the shell, when it times out
+	setProcessGroup(cmd)
+	cmd.Cancel = func() error {
+		terminateProcess(cmd, true)
+		return nil
+	}
+	cmd.WaitDelay = time.Second
+
+	f.commandMu.Lock()
+	f.command = live
+	f.commandMu.Unlock()
+	defer func() {
+		f.commandMu.Lock()
+		f.command = nil
+		f.commandMu.Unlock()
+	}()
+
+	runErr := cmd.Run()
+	elapsed := time.Since(live.started).Round(10 * time.Millisecond)
+
+	status := "exit status 0"
+	if context.Cause(ctx) == errCommandStopped {
+		status = fmt.Sprintf("STOPPED by the user after %s", elapsed)
+	} else if ctx.Err() == context.DeadlineExceeded {
+		status = fmt.Sprintf("TIMED OUT after %s", timeout)
+	} else if runErr != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(runErr, &exitErr) {
+			return "", fmt.Errorf("running command: %w", runErr)
+		}
+		status = fmt.Sprintf("FAILED (%v)", runErr)
+	}
+
+	return fmt.Sprintf("$ %s\n%s in %s\n\n%s", command, status, elapsed, tailOutput(output.String(), maxCommandOutput)), nil
+}
@@ -0,0 +1,77 @@
+package functions
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/alchemy-labs-co/riptide/internal/config"
+)
+
+func TestCheckReadOnlyQuery(t *testing.T) {
+	tests := []struct {
+		name   string
+		driver string
+		query  string
+		ok     bool
+	}{
+		{"select", config.DriverPostgres, "SELECT * FROM users", true},
+		{"with", config.DriverPostgres, "WITH x AS (SELECT 1) SELECT * FROM x", true},
+		{"explain", config.DriverSQLite, "EXPLAIN SELECT 1", true},
+		{"trailing semicolon", config.DriverPostgres, "SELECT 1;", true},
+		{"keyword in string", config.DriverPostgres, "SELECT 'delete from users' AS note", true},
+		{"keyword in comment", config.DriverPostgres, "SELECT 1 -- drop table users", true},
+		{"empty", config.DriverPostgres, "  -- nothing", false},
+		{"delete", config.DriverPostgres, "DELETE FROM users", false},
+		{"second statement", config.DriverPostgres, "SELECT 1; DROP TABLE users", false},
+		{"writing cte", config.DriverPostgres, "WITH d AS (DELETE FROM users RETURNING *) SELECT * FROM d", false},
+		{"select into", config.DriverPostgres, "SELECT * INTO copy FROM users", false},
+		{"file function", config.DriverPostgres, "SELECT pg_read_file('/etc/passwd')", false},
+		{"dollar quoting", config.DriverPostgres, "SELECT $x$; DROP TABLE users; $x$", true},
+		{"doubled quote", config.DriverPostgres, "SELECT 'it''s'; DELETE FROM users", false},
+		{"sqlite writefile", config.DriverSQLite, "SELECT writefile('/tmp/x', 'y')", false},
+		{"mysql select", config.DriverMySQL, "SELECT `name` FROM users WHERE id = 1", true},
+		{"mysql system command", config.DriverMySQL, "SELECT 1 \\! touch /tmp/x", false},
+		{"mysql source command", config.DriverMySQL, "SELECT 1 \\. /tmp/evil.sql", false},
+		{"mysql backslash escape", config.DriverMySQL, "SELECT '\\'' ; DELETE FROM users -- '", false},
+		{"mysql executable comment", config.DriverMySQL, "SELECT 1 /*! ; DELETE FROM users */", false},
+		{"mysql dash without space", config.DriverMySQL, "SELECT 1--'\n'; DELETE FROM users; -- '", false},
+		{"mysql dash comment", config.DriverMySQL, "SELECT 1 -- delete\n", true},
+		{"mysql hash comment", config.DriverMySQL, "SELECT 1 # delete from users", true},
+		{"mysql load_file", config.DriverMySQL, "SELECT LOAD_FILE('/etc/passwd')", false},
+		{"postgres hash", config.DriverPostgres, "SELECT 1 # 2; DELETE FROM users", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkReadOnlyQuery(tt.query, tt.driver)
+			if tt.ok && err != nil {
+				t.Errorf("checkReadOnlyQuery(%q) = %v, want nil", tt.query, err)
+			}
+			if !tt.ok && err == nil {
+				t.Errorf("checkReadOnlyQuery(%q) accepted the query", tt.query)
+			}
+		})
+	}
+}
+
+func TestQueryCommandMySQLUsesStdin(t *testing.T) {
+	conn := config.DatabaseConnection{Driver: config.DriverMySQL, DSN: "mysql://app:secret@db:3306/shop"}
+	query := "SELECT 1"
+	args, env, stdin, err := queryCommand(conn, query)
+	if err != nil {
+		t.Fatalf("queryCommand: %v", err)
+	}
+	for _, arg := range args {
+		if strings.Contains(arg, query) || strings.Contains(arg, "secret") {
+			t.Errorf("argument %q carries the query or password", arg)
+		}
+	}
+	if !strings.Contains(strings.Join(args, " "), "--skip-named-commands") {
+		t.Errorf("args %v do not turn off named commands", args)
+	}
+	if want := "START TRANSACTION READ ONLY;\n" + query + "\n;\nROLLBACK;\n"; stdin != want {
+		t.Errorf("stdin = %q, want %q", stdin, want)
+	}
+	if len(env) != 1 || env[0] != "MYSQL_PWD=secret" {
+		t.Errorf("env = %v, want the password in MYSQL_PWD", env)
+	}
+}
@@ -0,0 +1,391 @@
+package functions
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// dependencyTimeout bounds a single check_dependencies invocation
+const dependencyTimeout = 3 * time.Minute
+
+// maxDependencyOutput is how much raw tool output is returned when it is not parsed
+const maxDependencyOutput = 12000
+
+// check_dependencies actions
+const (
+	dependencyWhy      = "why"
+	dependencyOutdated = "outdated"
+	dependencyAudit    = "audit"
+)
+
+// Package managers detected from the project layout
+const (
+	managerGo  = "go"
+	managerNpm = "npm"
+	managerPip = "pip"
+)
+
+// checkDependencies explains why a package is needed, lists outdated
+// dependencies or audits them for known vulnerabilities, using the project's
+// own package manager
+func (f *FileOperations) checkDependencies(action, pkg string) (string, error) {
+	root, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("getting working directory: %w", err)
+	}
+
+	manager := detectPackageManager(root)
+	if manager == "" {
+		return "", fmt.Errorf("no supported package manager found (expected go.mod, package.json, pyproject.toml, setup.py or requirements.txt)")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), dependencyTimeout)
+	defer cancel()
+
+	switch action {
+	case dependencyWhy:
+		if pkg == "" {
+			return "", fmt.Errorf("package is required for the why action")
+		}
+		// The name is passed to the package manager, which would take it as a flag
+		if strings.HasPrefix(pkg, "-") {
+			return "", fmt.Errorf("invalid package name %q", pkg)
+		}
+		return dependencyReason(ctx, manager, root, pkg)
+	case dependencyOutdated:
+		return outdatedDependencies(ctx, manager, root)
+	case dependencyAudit:
+		return auditDependencies(ctx, manager, root)
+	}
+	return "", fmt.Errorf("unknown action %q (use why, outdated or audit)", action)
+}
+
+// detectPackageManager picks a package manager from the files at the project root
+func detectPackageManager(root string) string {
+	switch detectTestRunner(root) {
+	case runnerGo:
+		return managerGo
+	case runnerNpm:
+		return managerNpm
+	case runnerPytest:
+		return managerPip
+	}
+	if _, err := os.Stat(filepath.Join(root, "requirements.txt")); err == nil {
+		return managerPip
+	}
+	return ""
+}
+
+// runDependencyTool runs a package manager command and returns its stdout.
+// Tools that report findings through their exit code (npm outdated, npm audit)
+// still return their output; err is set only when nothing was printed.
+func runDependencyTool(ctx context.Context, root string, args ...string) ([]byte, error) {
+	if _, err := exec.LookPath(args[0]); err != nil {
+		return nil, fmt.Errorf("%s is not installed", args[0])
+	}
+
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Dir = root
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, fmt.Errorf("%s timed out after %s", strings.Join(args, " "), dependencyTimeout)
+	}
+	if err != nil && stdout.Len() == 0 {
+		message := strings.TrimSpace(stderr.String())
+		if message == "" {
+			message = err.Error()
+		}
+		return nil, fmt.Errorf("running %s: %s", strings.Join(args, " "), message)
+	}
+	return stdout.Bytes(), nil
+}
+
+// dependencyReason explains why pkg is in the dependency tree
+func dependencyReason(ctx context.Context, manager, root, pkg string) (string, error) {
+	switch manager {
+	case managerGo:
+		why, err := runDependencyTool(ctx, root, "go", "mod", "why", "-m", pkg)
+		if err != nil {
+			return "", err
+		}
+		graph, err := runDependencyTool(ctx, root, "go", "mod", "graph")
+		if err != nil {
+			return "", err
+		}
+
+		requiredBy, direct := goDependents(graph, pkg)
+		var b strings.Builder
+		b.WriteString(fmt.Sprintf("Module %s\n", pkg))
+		switch {
+		case len(requiredBy) == 0:
+			b.WriteString("Required by: nothing (not in the module graph)\n")
+		case direct:
+			b.WriteString("Required directly in go.mod\n")
+		}
+		if len(requiredBy) > 0 {
+			b.WriteString(fmt.Sprintf("Required by (%d): %s\n", len(requiredBy), strings.Join(requiredBy, ", ")))
+		}
+		b.WriteString("\nImport path from the main module (go mod why):\n")
+		b.WriteString(strings.TrimSpace(string(why)))
+		b.WriteString("\n\nIf it is only needed by modules you can drop, or go mod why says the main module does not need it, `go mod tidy` will remove it.")
+		return b.String(), nil
+
+	case managerNpm:
+		output, err := runDependencyTool(ctx, root, "npm", "ls", pkg, "--all")
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Packages depending on %s (npm ls):\n%s", pkg, tailOutput(string(output), maxDependencyOutput)), nil
+
+	default:
+		output, err := runDependencyTool(ctx, root, "python3", "-m", "pip", "show", pkg)
+		if err != nil {
+			return "", err
+		}
+		var b strings.Builder
+		b.WriteString(fmt.Sprintf("Package %s (pip show)\n", pkg))
+		scanner := bufio.NewScanner(bytes.NewReader(output))
+		for scanner.Scan() {
+			line := scanner.Text()
+			for _, field := range []string{"Version:", "Requires:", "Required-by:", "Location:"} {
+				if strings.HasPrefix(line, field) {
+					b.WriteString(line + "\n")
+				}
+			}
+		}
+		return b.String(), nil
+	}
+}
+
+// goDependents returns the modules that require pkg in go mod graph output,
+// and whether the main module requires it directly
+func goDependents(graph []byte, pkg string) ([]string, bool) {
+	seen := make(map[string]bool)
+	direct := false
+	scanner := bufio.NewScanner(bytes.NewReader(graph))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		target, _, _ := strings.Cut(fields[1], "@")
+		if target != pkg {
+			continue
+		}
+		// The main module is listed without a version
+		if !strings.Contains(fields[0], "@") {
+			direct = true
+			continue
+		}
+		seen[fields[0]] = true
+	}
+
+	dependents := make([]string, 0, len(seen))
+	for name := range seen {
+		dependents = append(dependents, name)
+	}
+	sort.Strings(dependents)
+	return dependents, direct
+}
+
+// outdatedDependency is one dependency with a newer version available
+type outdatedDependency struct {
+	name, current, latest string
+	indirect              bool
+}
+
+// outdatedDependencies lists dependencies with newer versions
+func outdatedDependencies(ctx context.Context, manager, root string) (string, error) {
+	var deps []outdatedDependency
+	var checkErrors []string
+
+	switch manager {
+	case managerGo:
+		output, err := runDependencyTool(ctx, root, "go", "list", "-m", "-u", "-json", "all")
+		if err != nil {
+			return "", err
+		}
+		decoder := json.NewDecoder(bytes.NewReader(output))
+		for {
+			var module struct {
+				Path     string
+				Version  string
+				Main     bool
+				Indirect bool
+				Update   *struct{ Version string }
+				Error    *struct{ Err string }
+			}
+			if err := decoder.Decode(&module); err != nil {
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				return "", fmt.Errorf("parsing go list output: %w", err)
+			}
+			if module.Error != nil {
+				checkErrors = append(checkErrors, fmt.Sprintf("%s: %s", module.Path, module.Error.Err))
+			}
+			if !module.Main && module.Update != nil {
+				deps = append(deps, outdatedDependency{module.Path, module.Version, module.Update.Version, module.Indirect})
+			}
+		}
+
+	case managerNpm:
+		output, err := runDependencyTool(ctx, root, "npm", "outdated", "--json")
+		if err != nil {
+			return "", err
+		}
+		var packages map[string]struct {
+			Current string `json:"current"`
+			Wanted  string `json:"wanted"`
+			Latest  string `json:"latest"`
+		}
+		if len(bytes.TrimSpace(output)) > 0 {
+			if err := json.Unmarshal(output, &packages); err != nil {
+				return "", fmt.Errorf("parsing npm outdated output: %w", err)
+			}
+		}
+		for name, p := range packages {
+			deps = append(deps, outdatedDependency{name: name, current: p.Current, latest: p.Latest})
+		}
+
+	default:
+		output, err := runDependencyTool(ctx, root, "python3", "-m", "pip", "list", "--outdated", "--format=json")
+		if err != nil {
+			return "", err
+		}
+		var packages []struct {
+			Name          string `json:"name"`
+			Version       string `json:"version"`
+			LatestVersion string `json:"latest_version"`
+		}
+		if err := json.Unmarshal(output, &packages); err != nil {
+			return "", fmt.Errorf("parsing pip list output: %w", err)
+		}
+		for _, p := range packages {
+			deps = append(deps, outdatedDependency{name: p.Name, current: p.Version, latest: p.LatestVersion})
+		}
+	}
+
+	if len(checkErrors) > 0 && len(deps) == 0 {
+		return "", fmt.Errorf("checking for updates failed for %d modules, e.g. %s", len(checkErrors), checkErrors[0])
+	}
+	if len(deps) == 0 {
+		return fmt.Sprintf("All %s dependencies are up to date.", manager), nil
+	}
+	sort.Slice(deps, func(i, j int) bool {
+		if deps[i].indirect != deps[j].indirect {
+			return !deps[i].indirect
+		}
+		return deps[i].name < deps[j].name
+	})
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("%d outdated %s dependencies:\n", len(deps), manager))
+	for _, dep := range deps {
+		line := fmt.Sprintf("- %s: %s -> %s", dep.name, dep.current, dep.latest)
+		if dep.indirect {
+			line += " (indirect)"
+		}
+		b.WriteString(line + "\n")
+	}
+	return b.String(), nil
+}
+
+// auditDependencies reports known vulnerabilities in dependencies
+func auditDependencies(ctx context.Context, manager, root string) (string, error) {
+	switch manager {
+	case managerGo:
+		output, err := runDependencyTool(ctx, root, "govulncheck", "./...")
+		if err != nil {
+			return "", fmt.Errorf("%w (install it with: go install golang.org/x/vuln/cmd/govulncheck@latest)", err)
+		}
+		return "govulncheck ./...:\n" + tailOutput(string(output), maxDependencyOutput), nil
+
+	case managerNpm:
+		output, err := runDependencyTool(ctx, root, "npm", "audit", "--json")
+		if err != nil {
+			return "", err
+		}
+		return formatNpmAudit(output)
+
+	default:
+		// Without arguments pip-audit checks whatever environment is active,
+		// and auditing the project itself builds it, running its setup.py, so
+		// only the pinned requirements are read, without pip
+		if _, err := os.Stat(filepath.Join(root, "requirements.txt")); err != nil {
+			return "", fmt.Errorf("pip-audit needs a requirements.txt with pinned versions in %s", root)
+		}
+		args := []string{"pip-audit", "-r", "requirements.txt", "--no-deps", "--disable-pip"}
+		output, err := runDependencyTool(ctx, root, args...)
+		if err != nil {
+			return "", fmt.Errorf("%w (install it with: pip install pip-audit)", err)
+		}
+		return strings.Join(args, " ") + ":\n" + tailOutput(string(output), maxDependencyOutput), nil
+	}
+}
+
+// formatNpmAudit summarizes npm audit --json output by package and severity
+func formatNpmAudit(output []byte) (string, error) {
+	var report struct {
+		Vulnerabilities map[string]struct {
+			Severity     string            `json:"severity"`
+			Via          []json.RawMessage `json:"via"`
+			FixAvailable json.RawMessage   `json:"fixAvailable"`
+		} `json:"vulnerabilities"`
+	}
+	if err := json.Unmarshal(output, &report); err != nil {
+		return "", fmt.Errorf("parsing npm audit output: %w", err)
+	}
+	if len(report.Vulnerabilities) == 0 {
+		return "npm audit found no known vulnerabilities.", nil
+	}
+
+	names := make([]string, 0, len(report.Vulnerabilities))
+	for name := range report.Vulnerabilities {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("npm audit found %d vulnerable packages:\n", len(names)))
+	for _, name := range names {
+		vuln := report.Vulnerabilities[name]
+
+		// via holds advisories (objects) or the names of vulnerable dependencies (strings)
+		var causes []string
+		for _, raw := range vuln.Via {
+			var advisory struct {
+				Title string `json:"title"`
+				URL   string `json:"url"`
+			}
+			var dependency string
+			if json.Unmarshal(raw, &advisory) == nil && advisory.Title != "" {
+				causes = append(causes, fmt.Sprintf("%s (%s)", advisory.Title, advisory.URL))
+			} else if json.Unmarshal(raw, &dependency) == nil {
+				causes = append(causes, "via "+dependency)
+			}
+		}
+
+		fix := "no fix available"
+		if string(vuln.FixAvailable) != "false" && len(vuln.FixAvailable) > 0 {
+			fix = "fix available (npm audit fix)"
+		}
+		b.WriteString(fmt.Sprintf("- %s [%s], %s: %s\n", name, vuln.Severity, fix, strings.Join(causes, "; ")))
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,242 @@
+package functions
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContextLines is how many unchanged lines surround each change in a hunk
+const diffContextLines = 3
+
+// diffOp is one line of an edit script: kept, deleted or inserted
+type diffOp struct {
+	kind byte // ' ', '-' or '+'
+	line string
+}
+
+// UnifiedDiff returns a unified diff turning before into after, labelled with
+// path, or "" when the contents are the same. A file that does not exist yet is
+// shown as a diff from /dev/null.
+func UnifiedDiff(path, before, after string, created bool) string {
+	if before == after && !created {
+		return ""
+	}
+
+	ops := diffLines(splitLines(before), splitLines(after))
+
+	var b strings.Builder
+	if created {
+		b.WriteString("--- /dev/null\n")
+	} else {
+		b.WriteString("--- a/" + path + "\n")
+	}
+	b.WriteString("+++ b/" + path + "\n")
+
+	// Group the changes into hunks, merging those whose context would overlap
+	for start := 0; start < len(ops); {
+		first := start
+		for first < len(ops) && ops[first].kind == ' ' {
+			first++
+		}
+		if first == len(ops) {
+			break
+		}
+		last := first
+		for i := first; i < len(ops); i++ {
+			if ops[i].kind != ' ' {
+				last = i
+			} else if i-last > 2*diffContextLines {
+				break
+			}
+		}
+
+		from := max(first-diffContextLines, start)
+		to := min(last+diffContextLines+1, len(ops))
+		writeHunk(&b, ops, from, to)
+		start = to
+	}
+	return b.String()
+}
+
+// DiffStat counts the lines a unified diff adds and removes
+func DiffStat(diff string) (added, removed int) {
+	for _, line := range strings.Split(diff, "\n") {
+		switch {
+		case strings.HasPrefix(line, "+++ "), strings.HasPrefix(line, "--- "):
+		case strings.HasPrefix(line, "+"):
+			added++
+		case strings.HasPrefix(line, "-"):
+			removed++
+		}
+	}
+	return added, removed
+}
+
+// writeHunk writes ops[from:to] as one hunk with its line ranges
+func writeHunk(b *strings.Builder, ops []diffOp, from, to int) {
+	oldStart, newStart := 1, 1
+	for _, op := range ops[:from] {
+		if op.kind != '+' {
+			oldStart++
+		}
+		if op.kind != '-' {
+			newStart++
+		}
+	}
+	oldCount, newCount := 0, 0
+	for _, op := range ops[from:to] {
+		if op.kind != '+' {
+			oldCount++
+		}
+		if op.kind != '-' {
+			newCount++
+		}
+	}
+	if oldCount == 0 {
+		oldStart--
+	}
+	if newCount == 0 {
+		newStart--
+	}
+
+	fmt.Fprintf(b, "@@ -%s +%s @@\n", hunkRange(oldStart, oldCount), hunkRange(newStart, newCount))
+	for _, op := range ops[from:to] {
+		b.WriteByte(op.kind)
+		b.WriteString(strings.TrimSuffix(op.line, "\n"))
+		b.WriteByte('\n')
+		if !strings.HasSuffix(op.line, "\n") {
+			b.WriteString("\\ No newline at end of file\n")
+		}
+	}
+}
+
+// hunkRange formats a hunk's start and length, omitting a length of one
+func hunkRange(start, count int) string {
+	if count == 1 {
+		return fmt.Sprint(start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+// splitLines splits text into lines that keep their newline
+func splitLines(text string) []string {
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines returns an edit script from a to b. The common prefix and suffix are
+// matched directly and the middle is diffed with Myers' algorithm; middles too
+// different to diff cheaply are shown as replaced outright.
+func diffLines(a, b []string) []diffOp {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	ops := make([]diffOp, 0, len(a)+len(b))
+	for _, line := range a[:prefix] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	midA, midB := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	if middle := myersDiff(midA, midB); middle != nil {
+		ops = append(ops, middle...)
+	} else {
+		for _, line := range midA {
+			ops = append(ops, diffOp{'-', line})
+		}
+		for _, line := range midB {
+			ops = append(ops, diffOp{'+', line})
+		}
+	}
+	for _, line := range a[len(a)-suffix:] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	return ops
+}
+
+// maxDiffEdits bounds the edit distance myersDiff searches, which caps its memory
+const maxDiffEdits = 1000
+
+// myersDiff returns the shortest edit script from a to b, or nil when it is longer
+// than maxDiffEdits
+func myersDiff(a, b []string) []diffOp {
+	n, m := len(a), len(b)
+	if n == 0 && m == 0 {
+		return []diffOp{}
+	}
+	limit := min(n+m, maxDiffEdits)
+	offset := limit + 1
+	v := make([]int, 2*offset+1)
+	var trace [][]int
+
+	for d := 0; d <= limit; d++ {
+		snapshot := make([]int, len(v))
+		copy(snapshot, v)
+		trace = append(trace, snapshot)
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrack(trace, a, b, offset, d)
+			}
+		}
+	}
+	return nil
+}
+
+// backtrack walks the saved frontiers back from the end to build the edit script
+func backtrack(trace [][]int, a, b []string, offset, depth int) []diffOp {
+	var ops []diffOp
+	x, y := len(a), len(b)
+	for d := depth; d > 0; d-- {
+		v := trace[d]
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[offset+prevK]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x--
+			y--
+			ops = append(ops, diffOp{' ', a[x]})
+		}
+		if x == prevX {
+			y--
+			ops = append(ops, diffOp{'+', b[y]})
+		} else {
+			x--
+			ops = append(ops, diffOp{'-', a[x]})
+		}
+	}
+	for x > 0 {
+		x--
+		ops = append(ops, diffOp{' ', a[x]})
+	}
+
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+	return ops
+}
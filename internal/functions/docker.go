@@ -0,0 +1,159 @@
+package functions
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// dockerBuildTimeout bounds a single docker_build invocation
+	dockerBuildTimeout = 15 * time.Minute
+
+	// maxDockerOutput is how much of the end of build or container output is returned
+	maxDockerOutput = 8000
+
+	// defaultDockerTag names images built without an explicit tag
+	defaultDockerTag = "riptide-build:latest"
+
+	// dockerPidsLimit caps the processes a docker_run container may start
+	dockerPidsLimit = "256"
+)
+
+// dockerRuns numbers docker_run containers so each gets a unique name
+var dockerRuns atomic.Int64
+
+// dockerBuild builds an image from a Dockerfile and reports whether it built,
+// returning the tail of the build output
+func (f *FileOperations) dockerBuild(contextDir, dockerfile, tag string) (string, error) {
+	if _, err := exec.LookPath("docker"); err != nil {
+		return "", fmt.Errorf("docker is not installed or not in PATH")
+	}
+
+	if contextDir == "" {
+		contextDir = "."
+	}
+	contextPath, err := NormalizePath(contextDir)
+	if err != nil {
+		return "", fmt.Errorf("normalizing path: %w", err)
+	}
+	if info, err := os.Stat(contextPath); err != nil {
+		return "", fmt.Errorf("accessing build context: %w", err)
+	} else if !info.IsDir() {
+		return "", fmt.Errorf("build context is not a directory: %s", contextPath)
+	}
+
+	if dockerfile == "" {
+		dockerfile = filepath.Join(contextPath, "Dockerfile")
+	}
+	dockerfilePath, err := NormalizePath(dockerfile)
+	if err != nil {
+		return "", fmt.Errorf("normalizing path: %w", err)
+	}
+	if _, err := os.Stat(dockerfilePath); err != nil {
+		return "", fmt.Errorf("accessing Dockerfile: %w", err)
+	}
+
+	if tag == "" {
+		tag = defaultDockerTag
+	}
+
+	args := []string{"docker", "build", "--progress=plain", "-f", dockerfilePath, "-t", tag, contextPath}
+	output, elapsed, status, err := runDocker(args, dockerBuildTimeout)
+	if err != nil {
+		return "", err
+	}
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("Ran `%s` in %s — %s\n\n", strings.Join(args, " "), elapsed, status))
+	result.WriteString(tailOutput(output, maxDockerOutput))
+	return result.String(), nil
+}
+
+// dockerRun runs command (through sh -c) or the image's default command in a
+// throwaway container with no host mounts, dropped capabilities and, unless
+// configured otherwise, no network, and returns its exit status and output
+func (f *FileOperations) dockerRun(image, command string) (string, error) {
+	if _, err := exec.LookPath("docker"); err != nil {
+		return "", fmt.Errorf("docker is not installed or not in PATH")
+	}
+	if strings.TrimSpace(image) == "" {
+		return "", fmt.Errorf("no image given")
+	}
+	if strings.HasPrefix(image, "-") {
+		return "", fmt.Errorf("invalid image name: %s", image)
+	}
+
+	cfg := f.config.Docker
+	name := fmt.Sprintf("riptide-run-%d-%d", os.Getpid(), dockerRuns.Add(1))
+
+	args := []string{"docker", "run", "--rm", "--name", name,
+		"--cap-drop", "ALL",
+		"--security-opt", "no-new-privileges",
+		"--pids-limit", dockerPidsLimit,
+	}
+	if !cfg.Network {
+		args = append(args, "--network", "none")
+	}
+	if cfg.Memory != "" {
+		args = append(args, "--memory", cfg.Memory)
+	}
+	if cfg.CPUs != "" {
+		args = append(args, "--cpus", cfg.CPUs)
+	}
+	args = append(args, image)
+	if command != "" {
+		args = append(args, "sh", "-c", command)
+	}
+
+	timeout := time.Duration(cfg.TimeoutSeconds) * time.Second
+	output, elapsed, status, err := runDocker(args, timeout)
+
+	// Stopping the docker CLI leaves the container running, so remove it explicitly
+	if strings.HasPrefix(status, "TIMED OUT") {
+		exec.Command("docker", "rm", "-f", name).Run()
+	}
+	if err != nil {
+		return "", err
+	}
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("Ran `%s` in %s — %s\n\n", strings.Join(args, " "), elapsed, status))
+	result.WriteString(tailOutput(output, maxDockerOutput))
+	return result.String(), nil
+}
+
+// runDocker runs a docker command with a timeout and returns its combined
+// output, how long it took and a status of SUCCEEDED, FAILED or TIMED OUT
+func runDocker(args []string, timeout time.Duration) (string, time.Duration, string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	start := time.Now()
+	runErr := cmd.Run()
+	elapsed := time.Since(start).Round(100 * time.Millisecond)
+
+	status := "SUCCEEDED"
+	if ctx.Err() == context.DeadlineExceeded {
+		status = fmt.Sprintf("TIMED OUT after %s", timeout)
+	} else if runErr != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(runErr, &exitErr) {
+			return "", elapsed, "", fmt.Errorf("running docker: %w", runErr)
+		}
+		status = fmt.Sprintf("FAILED (%v)", runErr)
+	}
+	return output.String(), elapsed, status, nil
+}
@@ -0,0 +1,132 @@
+package functions
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// versionTimeout bounds each toolchain version probe
+const versionTimeout = 5 * time.Second
+
+// toolchains are the programs inspect_environment looks for, with the
+// arguments that print their version
+var toolchains = []struct {
+	name string
+	args []string
+}{
+	{"go", []string{"version"}},
+	{"node", []string{"--version"}},
+	{"npm", []string{"--version"}},
+	{"python3", []string{"--version"}},
+	{"python", []string{"--version"}},
+	{"pip3", []string{"--version"}},
+	{"cargo", []string{"--version"}},
+	{"java", []string{"-version"}},
+	{"docker", []string{"--version"}},
+	{"git", []string{"--version"}},
+	{"make", []string{"--version"}},
+}
+
+// environmentVariables are shown with their values when set
+var environmentVariables = []string{
+	"SHELL", "LANG", "CI",
+	"GOPATH", "GOROOT", "GOFLAGS", "GOPROXY", "CGO_ENABLED",
+	"NODE_ENV", "VIRTUAL_ENV", "CONDA_DEFAULT_ENV", "PYTHONPATH",
+	"JAVA_HOME", "DOCKER_HOST",
+}
+
+// secretMarkers identify variables whose values are never shown
+var secretMarkers = []string{"KEY", "TOKEN", "SECRET", "PASSWORD", "PASSWD", "CREDENTIAL", "AUTH"}
+
+// inspectEnvironment reports the OS, the installed toolchains and their
+// versions, and relevant environment variables, hiding secret values
+func (f *FileOperations) inspectEnvironment() (string, error) {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("OS: %s\nArchitecture: %s\n", runtime.GOOS, runtime.GOARCH))
+	if cwd, err := os.Getwd(); err == nil {
+		b.WriteString(fmt.Sprintf("Working directory: %s\n", cwd))
+	}
+
+	b.WriteString("\nToolchains:\n")
+	versions := make([]string, len(toolchains))
+	var wg sync.WaitGroup
+	for i, tool := range toolchains {
+		wg.Add(1)
+		go func(i int, name string, args []string) {
+			defer wg.Done()
+			versions[i] = toolVersion(name, args)
+		}(i, tool.name, tool.args)
+	}
+	wg.Wait()
+	for i, tool := range toolchains {
+		b.WriteString(fmt.Sprintf("- %s: %s\n", tool.name, versions[i]))
+	}
+
+	b.WriteString("\nEnvironment:\n")
+	for _, name := range environmentVariables {
+		if value, ok := os.LookupEnv(name); ok {
+			if isSecretVariable(name) {
+				value = "(set, value hidden)"
+			}
+			b.WriteString(fmt.Sprintf("- %s=%s\n", name, value))
+		}
+	}
+	if path := os.Getenv("PATH"); path != "" {
+		b.WriteString(fmt.Sprintf("- PATH has %d entries\n", len(strings.Split(path, string(os.PathListSeparator)))))
+	}
+
+	var secrets []string
+	for _, entry := range os.Environ() {
+		name, _, _ := strings.Cut(entry, "=")
+		if isSecretVariable(name) {
+			secrets = append(secrets, name)
+		}
+	}
+	if len(secrets) > 0 {
+		sort.Strings(secrets)
+		b.WriteString(fmt.Sprintf("- Credentials set (values hidden): %s\n", strings.Join(secrets, ", ")))
+	}
+
+	return b.String(), nil
+}
+
+// toolVersion returns the first line a toolchain prints for its version, or
+// "not installed"
+func toolVersion(name string, args []string) string {
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return "not installed"
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), versionTimeout)
+	defer cancel()
+
+	// Some tools (java) print their version on stderr
+	output, err := exec.CommandContext(ctx, path, args...).CombinedOutput()
+	line, _, _ := strings.Cut(strings.TrimSpace(string(output)), "\n")
+	switch {
+	case ctx.Err() == context.DeadlineExceeded:
+		return fmt.Sprintf("installed at %s (version check timed out)", path)
+	case err != nil && line == "":
+		return fmt.Sprintf("installed at %s (version check failed: %v)", path, err)
+	}
+	return strings.TrimSpace(line)
+}
+
+// isSecretVariable reports whether a variable name looks like it holds a credential
+func isSecretVariable(name string) bool {
+	upper := strings.ToUpper(name)
+	for _, marker := range secretMarkers {
+		if strings.Contains(upper, marker) {
+			return true
+		}
+	}
+	return false
+}
@@ -6,33 +6,95 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
 
 	"github.com/alchemy-labs-co/riptide/internal/api"
 	"github.com/alchemy-labs-co/riptide/internal/config"
+	"github.com/alchemy-labs-co/riptide/internal/lsp"
 )
 
 // FileOperations handles all file-related operations
 type FileOperations struct {
 	config *config.Config
+
+	// writeMu serializes writes with Cleanup so shutdown never interrupts a rename
+	writeMu sync.Mutex
+	closed  bool
+
+	// Write ledger state, recorded with every write
+	writeLogPath string
+	tool         string
+	turn         int
+	call         int // Counts tool calls so /undo can revert each one's writes together
+
+	// Undo state: this session's backup directory and changelog
+	undoDir   string
+	undoReady bool
+	undoLog   []UndoEntry
+
+	// editedFiles lists every file written this session, oldest first
+	editedFiles []string
+
+	// processes holds the background processes started by start_process
+	processes *processRegistry
+
+	// command is the run_command call in progress, watched by the live view
+	commandMu sync.Mutex
+	command   *liveCommand
+
+	// lsp runs the language servers behind the code navigation tools
+	lsp *lsp.Manager
 }
 
 // NewFileOperations creates a new FileOperations instance
 func NewFileOperations(cfg *config.Config) *FileOperations {
-	return &FileOperations{
-		config: cfg,
+	f := &FileOperations{
+		config:    cfg,
+		processes: newProcessRegistry(),
 	}
+
+	// Keep the write ledger in the workspace; without a working directory it is disabled
+	cwd, err := os.Getwd()
+	if err == nil {
+		f.writeLogPath = filepath.Join(cwd, WriteLogFile)
+		f.undoDir = filepath.Join(cwd, UndoDir, undoSessionName(time.Now()))
+	}
+	f.lsp = lsp.NewManager(cfg.LSP, cwd)
+
+	return f
 }
 
 // ExecuteFunction executes a function call and returns the result
 func (f *FileOperations) ExecuteFunction(toolCall api.ToolCall) (string, error) {
+	// Reject malformed arguments with every problem listed, instead of running
+	// with zero values for missing or misspelled fields
+	if err := api.ValidateToolArguments(toolCall.Function.Name, toolCall.Function.Arguments); err != nil {
+		return "", err
+	}
+
 	var args api.FileOperationArgs
-	if err := json.Unmarshal([]byte(toolCall.Function.Arguments), &args); err != nil {
-		return "", fmt.Errorf("parsing arguments: %w", err)
+	if arguments := strings.TrimSpace(toolCall.Function.Arguments); arguments != "" {
+		if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+			return "", fmt.Errorf("parsing arguments: %w", err)
+		}
 	}
 
+	// Record which tool caused any writes in the ledger
+	f.writeMu.Lock()
+	f.tool = toolCall.Function.Name
+	f.call++
+	f.writeMu.Unlock()
+
 	switch toolCall.Function.Name {
 	case "read_file":
+		if args.Offset != 0 || args.Limit != 0 {
+			return f.readFileLines(args.FilePath, args.Offset, args.Limit)
+		}
 		return f.readFile(args.FilePath)
+	case "read_file_lines":
+		return f.readFileRange(args.FilePath, args.StartLine, args.EndLine)
 	case "read_multiple_files":
 		return f.readMultipleFiles(args.FilePaths)
 	case "create_file":
@@ -41,6 +103,64 @@ func (f *FileOperations) ExecuteFunction(toolCall api.ToolCall) (string, error)
 		return f.createMultipleFiles(args.Files)
 	case "edit_file":
 		return f.editFile(args.FilePath, args.OriginalSnippet, args.NewSnippet)
+	case "validate_file":
+		return f.validateFile(args.FilePath, args.Schema)
+	case "git_status":
+		return f.gitStatus()
+	case "git_diff":
+		return f.gitDiff(args.Staged, args.FilePaths)
+	case "git_commit":
+		return f.gitCommit(args.Message, args.FilePaths)
+	case "run_tests":
+		return f.runTests(args.Target, args.Coverage, args.FilePaths)
+	case "run_benchmarks":
+		return f.runBenchmarks(args.Benchmark, args.Target, args.Command, args.Baseline, args.SaveBaseline)
+	case "execute_snippet":
+		return f.executeSnippet(args.Language, args.Code)
+	case "query_database":
+		return f.queryDatabase(args.Database, args.Query, args.MaxRows)
+	case "inspect_environment":
+		return f.inspectEnvironment()
+	case "check_dependencies":
+		return f.checkDependencies(args.Action, args.Package)
+	case "search_files":
+		opts := SearchOptions{
+			Pattern:    args.Pattern,
+			Literal:    args.Literal,
+			IgnoreCase: args.IgnoreCase,
+			Include:    args.Include,
+			Context:    defaultSearchContext,
+		}
+		if args.ContextLines != nil {
+			opts.Context = *args.ContextLines
+		}
+		return f.searchFiles(args.Path, opts)
+	case "find_todos":
+		return f.findTodos(args.Path, args.Tags)
+	case "find_definition":
+		return f.findDefinition(args.FilePath, args.Symbol, args.Line)
+	case "find_references":
+		return f.findReferences(args.FilePath, args.Symbol, args.Line)
+	case "document_symbols":
+		return f.documentSymbols(args.FilePath)
+	case "get_file_outline":
+		return f.getFileOutline(args.FilePath)
+	case "code_metrics":
+		return f.codeMetrics(args.Path)
+	case "run_command":
+		return f.runCommand(args.Command)
+	case "start_process":
+		return f.startProcess(args.Name, args.Command)
+	case "read_process_output":
+		return f.readProcessOutput(args.Name, args.Lines)
+	case "stop_process":
+		return f.stopProcess(args.Name)
+	case "docker_build":
+		return f.dockerBuild(args.Path, args.FilePath, args.Tag)
+	case "docker_run":
+		return f.dockerRun(args.Image, args.Command)
+	case "http_request":
+		return f.httpRequest(args.Method, args.URL, args.Headers, args.Body)
 	default:
 		return "", fmt.Errorf("unknown function: %s", toolCall.Function.Name)
 	}
@@ -52,6 +172,9 @@ func (f *FileOperations) readFile(filePath string) (string, error) {
 	if err != nil {
 		return "", fmt.Errorf("normalizing path: %w", err)
 	}
+	if err := f.checkReadSize(normalizedPath); err != nil {
+		return "", err
+	}
 
 	content, err := os.ReadFile(normalizedPath)
 	if err != nil {
@@ -72,6 +195,10 @@ func (f *FileOperations) readMultipleFiles(filePaths []string) (string, error) {
 			results = append(results, fmt.Sprintf("Error reading '%s': %v", filePath, err))
 			continue
 		}
+		if err := f.checkReadSize(normalizedPath); err != nil {
+			results = append(results, fmt.Sprintf("Error reading '%s': %v", filePath, err))
+			continue
+		}
 
 		content, err := os.ReadFile(normalizedPath)
 		if err != nil {
@@ -105,7 +232,7 @@ func (f *FileOperations) createFile(filePath, content string) (string, error) {
 	}
 
 	// Write the file
-	if err := os.WriteFile(normalizedPath, []byte(content), 0644); err != nil {
+	if err := f.writeFileAtomic(normalizedPath, []byte(content)); err != nil {
 		return "", fmt.Errorf("writing file: %w", err)
 	}
 
@@ -128,37 +255,83 @@ func (f *FileOperations) createMultipleFiles(files []api.FileToCreate) (string,
 
 // editFile edits a file by replacing a snippet
 func (f *FileOperations) editFile(filePath, originalSnippet, newSnippet string) (string, error) {
+	normalizedPath, content, updatedContent, note, err := f.editedContent(filePath, originalSnippet, newSnippet)
+	if err != nil {
+		return "", err
+	}
+
+	// Write the updated content
+	if err := f.writeFileAtomic(normalizedPath, []byte(updatedContent)); err != nil {
+		return "", fmt.Errorf("writing file: %w", err)
+	}
+
+	// Show an approximate match's change so the model can check it landed where intended
+	if note != "" {
+		return fmt.Sprintf("Successfully edited file '%s'. Note: %s. The change made:\n\n%s",
+			normalizedPath, note, UnifiedDiff(filePath, content, updatedContent, false)), nil
+	}
+	return fmt.Sprintf("Successfully edited file '%s'", normalizedPath), nil
+}
+
+// editedContent returns a file's normalized path, its current content, the
+// content after replacing the snippet and, when the snippet only matched
+// approximately, a note saying where it was applied, without writing anything
+func (f *FileOperations) editedContent(filePath, originalSnippet, newSnippet string) (string, string, string, string, error) {
 	normalizedPath, err := NormalizePath(filePath)
 	if err != nil {
-		return "", fmt.Errorf("normalizing path: %w", err)
+		return "", "", "", "", fmt.Errorf("normalizing path: %w", err)
+	}
+
+	maxSize := f.config.FileOperations.MaxFileSizeMB * 1024 * 1024
+	if err := validateSnippets(originalSnippet, newSnippet, maxSize); err != nil {
+		return "", "", "", "", err
 	}
 
 	// Read the current content
 	content, err := os.ReadFile(normalizedPath)
 	if err != nil {
-		return "", fmt.Errorf("reading file: %w", err)
+		return "", "", "", "", fmt.Errorf("reading file: %w", err)
+	}
+
+	// Snippet matching is text-based; editing binary or mis-encoded files could corrupt them
+	if !utf8.Valid(content) {
+		return "", "", "", "", fmt.Errorf("file is not valid UTF-8 text; refusing to edit it")
 	}
 
 	contentStr := string(content)
 
 	// Check occurrences
-	occurrences := strings.Count(contentStr, originalSnippet)
-	if occurrences == 0 {
-		return "", fmt.Errorf("original snippet not found in file")
+	index, occurrences := findSnippet(contentStr, originalSnippet)
+	if occurrences >= maxReportedMatches {
+		return "", "", "", "", fmt.Errorf("ambiguous edit: at least %d matches found for the snippet", occurrences)
 	}
 	if occurrences > 1 {
-		return "", fmt.Errorf("ambiguous edit: %d matches found for the snippet", occurrences)
+		return "", "", "", "", fmt.Errorf("ambiguous edit: %d matches found for the snippet (including overlapping ones)", occurrences)
 	}
 
-	// Replace the snippet
-	updatedContent := strings.Replace(contentStr, originalSnippet, newSnippet, 1)
-
-	// Write the updated content
-	if err := os.WriteFile(normalizedPath, []byte(updatedContent), 0644); err != nil {
-		return "", fmt.Errorf("writing file: %w", err)
+	// Replace the snippet, falling back to a match that ignores whitespace
+	// differences so trivial formatting slips do not fail the edit
+	var updatedContent, note string
+	if occurrences == 1 {
+		updatedContent = contentStr[:index] + newSnippet + contentStr[index+len(originalSnippet):]
+	} else {
+		match, err := findSnippetFuzzy(contentStr, originalSnippet)
+		if err != nil {
+			return "", "", "", "", err
+		}
+		region := contentStr[match.start:match.end]
+		updatedContent = contentStr[:match.start] + adaptSnippet(newSnippet, originalSnippet, region, contentStr) + contentStr[match.end:]
+		if match.similarity == 1 {
+			note = fmt.Sprintf("original_snippet did not match exactly; it was applied to lines %d-%d, which match it apart from whitespace, indentation or line endings", match.firstLine, match.lastLine)
+		} else {
+			note = fmt.Sprintf("original_snippet did not match exactly; it was applied to lines %d-%d, the closest text (%.0f%% similar)", match.firstLine, match.lastLine, match.similarity*100)
+		}
+	}
+	if len(updatedContent) > maxSize {
+		return "", "", "", "", fmt.Errorf("edited file would exceed the %dMB size limit", f.config.FileOperations.MaxFileSizeMB)
 	}
 
-	return fmt.Sprintf("Successfully edited file '%s'", normalizedPath), nil
+	return normalizedPath, contentStr, updatedContent, note, nil
 }
 
 // ReadFileForContext reads a file and returns it formatted for conversation context
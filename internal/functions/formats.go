@@ -0,0 +1,217 @@
+package functions
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// composeTopLevel are the top-level keys of a Compose file
+var composeTopLevel = keySet("version", "name", "services", "networks", "volumes", "configs", "secrets", "include")
+
+// composeServiceKeys are the keys a Compose service may have
+var composeServiceKeys = keySet(
+	"annotations", "attach", "blkio_config", "build", "cap_add", "cap_drop", "cgroup", "cgroup_parent",
+	"command", "configs", "container_name", "cpu_count", "cpu_percent", "cpu_period", "cpu_quota",
+	"cpu_rt_period", "cpu_rt_runtime", "cpu_shares", "cpus", "cpuset", "credential_spec", "depends_on",
+	"deploy", "develop", "device_cgroup_rules", "devices", "dns", "dns_opt", "dns_search", "domainname",
+	"entrypoint", "env_file", "environment", "expose", "extends", "external_links", "extra_hosts",
+	"gpus", "group_add", "healthcheck", "hostname", "image", "init", "ipc", "isolation", "labels",
+	"label_file", "links", "logging", "mac_address", "mem_limit", "mem_reservation", "mem_swappiness",
+	"memswap_limit", "models", "network_mode", "networks", "oom_kill_disable", "oom_score_adj", "pid",
+	"pids_limit", "platform", "ports", "post_start", "pre_stop", "privileged", "profiles", "provider",
+	"pull_policy", "read_only", "restart", "runtime", "scale", "secrets", "security_opt", "shm_size",
+	"stdin_open", "stop_grace_period", "stop_signal", "storage_opt", "sysctls", "tmpfs", "tty",
+	"ulimits", "user", "userns_mode", "uts", "volumes", "volumes_from", "working_dir",
+)
+
+// workflowTopLevel are the top-level keys of a GitHub Actions workflow
+var workflowTopLevel = keySet("name", "run-name", "on", "permissions", "env", "defaults", "concurrency", "jobs")
+
+// workflowJobKeys are the keys a workflow job may have
+var workflowJobKeys = keySet(
+	"name", "permissions", "needs", "if", "runs-on", "environment", "concurrency", "outputs", "env",
+	"defaults", "steps", "timeout-minutes", "strategy", "continue-on-error", "container", "services",
+	"uses", "with", "secrets",
+)
+
+// workflowStepKeys are the keys a workflow step may have
+var workflowStepKeys = keySet(
+	"id", "if", "name", "uses", "run", "working-directory", "shell", "with", "env",
+	"continue-on-error", "timeout-minutes",
+)
+
+// keySet builds a lookup set from keys
+func keySet(keys ...string) map[string]bool {
+	set := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		set[key] = true
+	}
+	return set
+}
+
+// unknownKeys reports keys of m that are not in allowed, ignoring x- extension keys
+func unknownKeys(path string, m map[string]interface{}, allowed map[string]bool) []string {
+	var problems []string
+	for _, key := range sortedKeys(m) {
+		if !allowed[key] && !strings.HasPrefix(key, "x-") {
+			problems = append(problems, fmt.Sprintf("%s: unknown key %q", path, key))
+		}
+	}
+	return problems
+}
+
+// sortedKeys returns the keys of m in order, so problems read the same on every run
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// dependencyNames reads a list or map of names, as used by depends_on and needs
+func dependencyNames(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		var names []string
+		for _, item := range v {
+			if name, ok := item.(string); ok {
+				names = append(names, name)
+			}
+		}
+		return names
+	case map[string]interface{}:
+		return sortedKeys(v)
+	}
+	return nil
+}
+
+// checkCompose checks the structure of a Docker Compose file: known keys,
+// services with an image or build, and depends_on naming real services
+func checkCompose(value interface{}) []string {
+	doc, ok := value.(map[string]interface{})
+	if !ok {
+		return []string{"/: expected a mapping at the top level"}
+	}
+	problems := unknownKeys("/", doc, composeTopLevel)
+
+	services, ok := doc["services"].(map[string]interface{})
+	if !ok {
+		if _, exists := doc["services"]; exists {
+			return append(problems, "/services: expected a mapping of service names")
+		}
+		if _, included := doc["include"]; !included {
+			problems = append(problems, "/: missing services")
+		}
+		return problems
+	}
+
+	for _, name := range sortedKeys(services) {
+		path := "/services/" + name
+		service, ok := services[name].(map[string]interface{})
+		if !ok {
+			problems = append(problems, path+": expected a mapping")
+			continue
+		}
+		problems = append(problems, unknownKeys(path, service, composeServiceKeys)...)
+
+		_, hasImage := service["image"]
+		_, hasBuild := service["build"]
+		_, hasExtends := service["extends"]
+		if !hasImage && !hasBuild && !hasExtends {
+			problems = append(problems, path+": needs image or build")
+		}
+
+		for _, dependency := range dependencyNames(service["depends_on"]) {
+			if _, exists := services[dependency]; !exists {
+				problems = append(problems, fmt.Sprintf("%s/depends_on: unknown service %q", path, dependency))
+			}
+		}
+
+		if ports, ok := service["ports"]; ok {
+			if _, isList := ports.([]interface{}); !isList {
+				problems = append(problems, path+"/ports: expected a list")
+			}
+		}
+	}
+	return problems
+}
+
+// checkWorkflow checks the structure of a GitHub Actions workflow: known keys,
+// a trigger, jobs with runs-on (or a reusable workflow), steps with exactly one
+// of uses or run, and needs naming real jobs
+func checkWorkflow(value interface{}) []string {
+	doc, ok := value.(map[string]interface{})
+	if !ok {
+		return []string{"/: expected a mapping at the top level"}
+	}
+	problems := unknownKeys("/", doc, workflowTopLevel)
+
+	if _, ok := doc["on"]; !ok {
+		problems = append(problems, "/: missing on (the events that trigger the workflow)")
+	}
+
+	jobs, ok := doc["jobs"].(map[string]interface{})
+	if !ok {
+		return append(problems, "/jobs: missing or not a mapping of job ids")
+	}
+	if len(jobs) == 0 {
+		problems = append(problems, "/jobs: no jobs defined")
+	}
+
+	for _, id := range sortedKeys(jobs) {
+		path := "/jobs/" + id
+		job, ok := jobs[id].(map[string]interface{})
+		if !ok {
+			problems = append(problems, path+": expected a mapping")
+			continue
+		}
+		problems = append(problems, unknownKeys(path, job, workflowJobKeys)...)
+
+		for _, need := range dependencyNames(job["needs"]) {
+			if _, exists := jobs[need]; !exists {
+				problems = append(problems, fmt.Sprintf("%s/needs: unknown job %q", path, need))
+			}
+		}
+
+		// Jobs that call a reusable workflow have no runner or steps of their own
+		if _, reusable := job["uses"]; reusable {
+			if _, hasSteps := job["steps"]; hasSteps {
+				problems = append(problems, path+": a job with uses cannot have steps")
+			}
+			continue
+		}
+		if _, ok := job["runs-on"]; !ok {
+			problems = append(problems, path+": missing runs-on")
+		}
+
+		steps, ok := job["steps"].([]interface{})
+		if !ok {
+			problems = append(problems, path+"/steps: missing or not a list")
+			continue
+		}
+		for i, item := range steps {
+			stepPath := fmt.Sprintf("%s/steps/%d", path, i)
+			step, ok := item.(map[string]interface{})
+			if !ok {
+				problems = append(problems, stepPath+": expected a mapping")
+				continue
+			}
+			problems = append(problems, unknownKeys(stepPath, step, workflowStepKeys)...)
+
+			_, hasUses := step["uses"]
+			_, hasRun := step["run"]
+			switch {
+			case hasUses && hasRun:
+				problems = append(problems, stepPath+": has both uses and run")
+			case !hasUses && !hasRun:
+				problems = append(problems, stepPath+": needs uses or run")
+			}
+		}
+	}
+	return problems
+}
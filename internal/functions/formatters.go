@@ -0,0 +1,214 @@
+package functions
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// maxFormatOutput is how much of a formatter's or linter's output is returned
+const maxFormatOutput = 4000
+
+// formatJob is one formatter or linter command with the files it covers
+type formatJob struct {
+	command string
+	files   []string // Relative to the workspace root where possible
+}
+
+// FormatFiles runs the configured formatters, then linters, on files a write
+// tool has just written, and returns what the model should know: files a
+// formatter changed, and anything that failed or printed. It returns "" when
+// formatting is off or there is nothing to say. A formatter's changes are
+// recorded as part of the write, so /undo still reverts them.
+func (f *FileOperations) FormatFiles(paths []string) string {
+	cfg := f.config.Format
+	if !cfg.Enabled || len(paths) == 0 {
+		return ""
+	}
+	root, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+
+	var files []string
+	for _, path := range paths {
+		normalized, err := NormalizePath(path)
+		if err != nil {
+			continue
+		}
+		if info, err := os.Stat(normalized); err == nil && info.Mode().IsRegular() {
+			files = append(files, normalized)
+		}
+	}
+	if len(files) == 0 {
+		return ""
+	}
+
+	timeout := time.Duration(cfg.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+
+	var report []string
+	hashes := make(map[string]string, len(files))
+	sizes := make(map[string]int, len(files))
+	for _, file := range files {
+		hashes[file], sizes[file] = snapshotFile(file)
+	}
+	for _, job := range formatJobs(cfg.Formatters, files, root) {
+		if output, err := runFormatCommand(job, root, timeout); err != nil {
+			report = append(report, fmt.Sprintf("Formatter `%s` failed (%v):\n%s", job.command, err, output))
+		}
+	}
+
+	var reformatted []string
+	for _, file := range files {
+		if f.recordFormatted(file, hashes[file], sizes[file]) {
+			reformatted = append(reformatted, relativeTo(root, file))
+		}
+	}
+	if len(reformatted) > 0 {
+		report = append([]string{fmt.Sprintf("Formatted %s; read it again before editing, since snippets may have changed", strings.Join(reformatted, ", "))}, report...)
+	}
+
+	for _, job := range formatJobs(cfg.Linters, files, root) {
+		output, err := runFormatCommand(job, root, timeout)
+		switch {
+		case err != nil:
+			report = append(report, fmt.Sprintf("Linter `%s` failed (%v):\n%s", job.command, err, output))
+		case output != "":
+			report = append(report, fmt.Sprintf("Linter `%s` reported:\n%s", job.command, output))
+		}
+	}
+	return strings.Join(report, "\n\n")
+}
+
+// formatJobs groups files by the command configured for their extension,
+// keeping the order the commands are first needed in
+func formatJobs(commands map[string]string, files []string, root string) []formatJob {
+	var jobs []formatJob
+	index := make(map[string]int)
+	for _, file := range files {
+		command := strings.TrimSpace(commands[strings.ToLower(filepath.Ext(file))])
+		if command == "" {
+			continue
+		}
+		i, ok := index[command]
+		if !ok {
+			i = len(jobs)
+			index[command] = i
+			jobs = append(jobs, formatJob{command: command})
+		}
+		jobs[i].files = append(jobs[i].files, relativeTo(root, file))
+	}
+	return jobs
+}
+
+// runFormatCommand runs a formatter or linter through the shell from the
+// workspace root and returns the end of its trimmed output
+func runFormatCommand(job formatJob, root string, timeout time.Duration) (string, error) {
+	var files, dirs []string
+	seen := make(map[string]bool)
+	for _, file := range job.files {
+		files = append(files, shellQuote(file))
+		dir := filepath.Dir(file)
+		if !filepath.IsAbs(dir) && dir != "." {
+			dir = "." + string(filepath.Separator) + dir
+		}
+		if !seen[dir] {
+			seen[dir] = true
+			dirs = append(dirs, shellQuote(dir))
+		}
+	}
+
+	command := job.command
+	if strings.Contains(command, "{files}") || strings.Contains(command, "{dirs}") {
+		command = strings.NewReplacer("{files}", strings.Join(files, " "), "{dirs}", strings.Join(dirs, " ")).Replace(command)
+	} else {
+		command += " " + strings.Join(files, " ")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	cmd.Dir = root
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	err := cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		err = fmt.Errorf("timed out after %s", timeout)
+	}
+	text := strings.TrimSpace(output.String())
+	if text != "" {
+		text = tailOutput(text, maxFormatOutput)
+	}
+	return text, err
+}
+
+// recordFormatted checks whether a formatter changed path, and if so adds the
+// change to the write ledger and to the undo entry of the write before it, so
+// /undo does not take the formatting for a change made by the user
+func (f *FileOperations) recordFormatted(path, beforeHash string, bytesBefore int) bool {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	afterHash := hashBytes(data)
+	if afterHash == beforeHash {
+		return false
+	}
+
+	f.writeMu.Lock()
+	defer f.writeMu.Unlock()
+
+	_ = f.appendWriteRecord(WriteRecord{
+		Time:        time.Now(),
+		Path:        path,
+		Tool:        "formatter",
+		Turn:        f.turn,
+		BeforeHash:  beforeHash,
+		AfterHash:   afterHash,
+		BytesBefore: bytesBefore,
+		BytesAfter:  len(data),
+	})
+	for i := len(f.undoLog) - 1; i >= 0; i-- {
+		entry := &f.undoLog[i]
+		if entry.Path == path && !entry.Undone && entry.AfterHash == beforeHash {
+			entry.AfterHash = afterHash
+			_ = f.saveUndoLog()
+			break
+		}
+	}
+	return true
+}
+
+// relativeTo returns path relative to root, or path itself when it is outside
+func relativeTo(root, path string) string {
+	rel, err := filepath.Rel(root, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return path
+	}
+	return rel
+}
+
+// shellQuote quotes s as one argument for the shell commands are run with
+func shellQuote(s string) string {
+	if runtime.GOOS == "windows" {
+		return `"` + s + `"`
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,239 @@
+package functions
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// fuzzyMatchThreshold is the similarity a region needs for edit_file to apply
+	// a snippet that does not match exactly
+	fuzzyMatchThreshold = 0.9
+
+	// closestMatchThreshold is the similarity a region needs to be suggested when
+	// no region is close enough to edit
+	closestMatchThreshold = 0.5
+
+	// maxFuzzyComparisons bounds the line comparisons an approximate search makes,
+	// so a large file and snippet cannot stall an edit
+	maxFuzzyComparisons = 200000
+)
+
+// snippetMatch is the region of a file an approximate snippet search settled on
+type snippetMatch struct {
+	start, end          int // Byte offsets of the region in the content
+	firstLine, lastLine int // 1-based line numbers of the region
+	similarity          float64
+}
+
+// fuzzySnippetError reports that a snippet matched no region closely enough,
+// pointing at the closest one when there is a plausible candidate
+type fuzzySnippetError struct {
+	closest *snippetMatch
+	text    string // The closest region's lines
+}
+
+func (e *fuzzySnippetError) Error() string {
+	if e.closest == nil {
+		return "original snippet not found in file"
+	}
+	return fmt.Sprintf("original snippet not found in file; the closest text is lines %d-%d (%.0f%% similar). Copy it exactly into original_snippet:\n%s",
+		e.closest.firstLine, e.closest.lastLine, e.closest.similarity*100, e.text)
+}
+
+// findSnippetFuzzy looks for the region of content whose lines match the snippet's
+// once indentation, runs of whitespace and line endings are ignored, accepting
+// the most similar region when no region matches outright. It fails when no
+// region reaches fuzzyMatchThreshold or when two regions are equally good.
+func findSnippetFuzzy(content, snippet string) (snippetMatch, error) {
+	lines := strings.SplitAfter(content, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	offsets := make([]int, len(lines)+1)
+	normalized := make([]string, len(lines))
+	for i, line := range lines {
+		offsets[i+1] = offsets[i] + len(line)
+		normalized[i] = normalizeWhitespace(line)
+	}
+
+	// Blank lines around the snippet carry no information to match on
+	var wanted []string
+	for _, line := range strings.Split(snippet, "\n") {
+		wanted = append(wanted, normalizeWhitespace(line))
+	}
+	for len(wanted) > 0 && wanted[0] == "" {
+		wanted = wanted[1:]
+	}
+	for len(wanted) > 0 && wanted[len(wanted)-1] == "" {
+		wanted = wanted[:len(wanted)-1]
+	}
+	k := len(wanted)
+	if k == 0 || k > len(lines) {
+		return snippetMatch{}, &fuzzySnippetError{}
+	}
+
+	var best, runnerUp snippetMatch
+	comparisons := 0
+	// A window is abandoned once its lost similarity rules it out as even the closest candidate
+	maxLoss := (1 - closestMatchThreshold) * float64(k)
+	for j := 0; j+k <= len(lines) && comparisons < maxFuzzyComparisons; j++ {
+		loss, worst := 0.0, 1.0
+		for i := 0; i < k && loss <= maxLoss; i++ {
+			similarity := lineSimilarity(normalized[j+i], wanted[i])
+			loss += 1 - similarity
+			worst = min(worst, similarity)
+			comparisons++
+		}
+		if loss > maxLoss {
+			continue
+		}
+
+		similarity := 1 - loss/float64(k)
+		if worst < closestMatchThreshold {
+			// A line that matches nothing would be silently overwritten, so the
+			// region can at most be suggested
+			similarity = min(similarity, fuzzyMatchThreshold-0.01)
+		}
+		match := snippetMatch{start: offsets[j], end: offsets[j+k], firstLine: j + 1, lastLine: j + k, similarity: similarity}
+		if similarity > best.similarity {
+			// Overlapping windows are the same candidate shifted, not a rival
+			if best.lastLine < match.firstLine {
+				runnerUp = best
+			}
+			best = match
+		} else if similarity > runnerUp.similarity && best.lastLine < match.firstLine {
+			runnerUp = match
+		}
+	}
+
+	if best.similarity < fuzzyMatchThreshold {
+		notFound := &fuzzySnippetError{}
+		if best.similarity >= closestMatchThreshold {
+			notFound.closest = &best
+			notFound.text = strings.TrimRight(content[best.start:best.end], "\r\n")
+		}
+		return snippetMatch{}, notFound
+	}
+	if runnerUp.similarity >= fuzzyMatchThreshold && runnerUp.similarity >= best.similarity-0.01 {
+		return snippetMatch{}, fmt.Errorf("ambiguous edit: the snippet does not match exactly and lines %d-%d and %d-%d match it equally well",
+			min(best.firstLine, runnerUp.firstLine), min(best.firstLine, runnerUp.firstLine)+k-1,
+			max(best.firstLine, runnerUp.firstLine), max(best.firstLine, runnerUp.firstLine)+k-1)
+	}
+
+	// Keep the final line break unless the snippet replaces it too
+	if !strings.HasSuffix(strings.TrimRight(snippet, " \t\r"), "\n") {
+		best.end -= len(lines[best.lastLine-1]) - len(strings.TrimRight(lines[best.lastLine-1], "\r\n"))
+	}
+	return best, nil
+}
+
+// adaptSnippet rewrites a replacement snippet to fit the region it replaces: its
+// indentation is shifted from the original snippet's to the region's, spaces
+// become tabs in a tab-indented file, and line endings follow the file's
+func adaptSnippet(newSnippet, originalSnippet, region, content string) string {
+	from := leadingIndent(originalSnippet)
+	to := leadingIndent(region)
+
+	useTabs := strings.Contains("\n"+region, "\n\t") && !strings.Contains("\n"+originalSnippet, "\n\t")
+	width := indentWidth(originalSnippet)
+
+	lines := strings.Split(strings.ReplaceAll(newSnippet, "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if strings.HasPrefix(line, from) {
+			rest := line[len(from):]
+			if useTabs {
+				rest = spacesToTabs(rest, width)
+			}
+			line = to + rest
+		}
+		lines[i] = line
+	}
+
+	adapted := strings.Join(lines, "\n")
+	if strings.Contains(content, "\r\n") {
+		adapted = strings.ReplaceAll(adapted, "\n", "\r\n")
+	}
+	return adapted
+}
+
+// normalizeWhitespace trims a line and collapses its inner whitespace to single spaces
+func normalizeWhitespace(line string) string {
+	return strings.Join(strings.Fields(line), " ")
+}
+
+// leadingIndent returns the indentation of the first non-blank line of text
+func leadingIndent(text string) string {
+	for _, line := range strings.Split(text, "\n") {
+		if strings.TrimSpace(line) != "" {
+			return line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		}
+	}
+	return ""
+}
+
+// indentWidth guesses how many spaces make one indentation level in text from
+// the smallest indentation step between its lines, defaulting to four
+func indentWidth(text string) int {
+	base := len(leadingIndent(text))
+	width := 0
+	for _, line := range strings.Split(text, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		if step := indent - base; step > 0 && (width == 0 || step < width) {
+			width = step
+		}
+	}
+	if width == 0 {
+		return 4
+	}
+	return width
+}
+
+// spacesToTabs replaces each width spaces at the start of line with a tab
+func spacesToTabs(line string, width int) string {
+	spaces := len(line) - len(strings.TrimLeft(line, " "))
+	return strings.Repeat("\t", spaces/width) + strings.Repeat(" ", spaces%width) + line[spaces:]
+}
+
+// lineSimilarity scores two normalized lines from 0 to 1 by their edit distance
+// relative to the longer one
+func lineSimilarity(a, b string) float64 {
+	if a == b {
+		return 1
+	}
+	la, lb := utf8.RuneCountInString(a), utf8.RuneCountInString(b)
+	longest := max(la, lb)
+	// The distance is at least the difference in length, so skip lines that cannot be close
+	if float64(min(la, lb)) < float64(longest)*closestMatchThreshold {
+		return 0
+	}
+	return 1 - float64(levenshtein([]rune(a), []rune(b)))/float64(longest)
+}
+
+// levenshtein returns the number of single-rune edits turning a into b
+func levenshtein(a, b []rune) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, min(current[j-1]+1, previous[j-1]+cost))
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
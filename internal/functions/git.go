@@ -0,0 +1,89 @@
+package functions
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/alchemy-labs-co/riptide/internal/git"
+)
+
+// maxGitDiff is how much of a diff is returned to the model
+const maxGitDiff = 24000
+
+// gitRoot returns the working directory, which must be inside a git repository
+func gitRoot() (string, error) {
+	root, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("getting working directory: %w", err)
+	}
+	if !git.IsRepository(root) {
+		return "", fmt.Errorf("%s is not inside a git repository", root)
+	}
+	return root, nil
+}
+
+// gitStatus returns the current branch and the changed files
+func (f *FileOperations) gitStatus() (string, error) {
+	root, err := gitRoot()
+	if err != nil {
+		return "", err
+	}
+	status, err := git.Status(root)
+	if err != nil {
+		return "", err
+	}
+	if !strings.Contains(status, "\n") {
+		status += "\n(working tree clean)"
+	}
+	return status, nil
+}
+
+// gitDiff returns the unstaged or staged changes, optionally for some paths only
+func (f *FileOperations) gitDiff(staged bool, paths []string) (string, error) {
+	root, err := gitRoot()
+	if err != nil {
+		return "", err
+	}
+	diff, err := git.Diff(root, staged, paths...)
+	if err != nil {
+		return "", err
+	}
+
+	if diff == "" {
+		if staged {
+			return "No staged changes", nil
+		}
+		return "No unstaged changes (set staged to see changes already added)", nil
+	}
+	if len(diff) > maxGitDiff {
+		cut := strings.LastIndexByte(diff[:maxGitDiff], '\n')
+		if cut < 0 {
+			cut = maxGitDiff
+		}
+		diff = fmt.Sprintf("%s\n... (diff truncated, %d more bytes; pass file_paths to see the rest)", diff[:cut], len(diff)-cut)
+	}
+	return diff, nil
+}
+
+// gitCommit stages the given paths, if any, and commits everything staged
+func (f *FileOperations) gitCommit(message string, paths []string) (string, error) {
+	root, err := gitRoot()
+	if err != nil {
+		return "", err
+	}
+	if err := git.Add(root, paths...); err != nil {
+		return "", err
+	}
+	if staged, err := git.Diff(root, true); err != nil {
+		return "", err
+	} else if staged == "" {
+		return "", fmt.Errorf("nothing is staged to commit; pass file_paths to stage changed files")
+	}
+
+	commit, err := git.Commit(root, message)
+	if err != nil {
+		return "", err
+	}
+	return "Committed " + commit, nil
+}
@@ -0,0 +1,184 @@
+package functions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// httpMethods are the methods http_request accepts
+var httpMethods = map[string]bool{
+	http.MethodGet: true, http.MethodHead: true, http.MethodPost: true, http.MethodPut: true,
+	http.MethodPatch: true, http.MethodDelete: true, http.MethodOptions: true,
+}
+
+// maxRedirects bounds how many redirects http_request follows
+const maxRedirects = 5
+
+// httpRequest sends a request to a local server (or an allowlisted host) and
+// returns the status, headers and the start of the body
+func (f *FileOperations) httpRequest(method, rawURL string, headers map[string]string, body string) (string, error) {
+	cfg := f.config.HTTP
+
+	method = strings.ToUpper(strings.TrimSpace(method))
+	if method == "" {
+		method = http.MethodGet
+	}
+	if !httpMethods[method] {
+		return "", fmt.Errorf("unsupported method %s", method)
+	}
+
+	target, err := url.Parse(rawURL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return "", fmt.Errorf("url must be an absolute http or https URL, got %q", rawURL)
+	}
+	if err := checkHTTPHost(target.Hostname(), cfg.AllowedHosts); err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.TimeoutSeconds)*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, method, target.String(), strings.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("creating request: %w", err)
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			Proxy:       nil, // A proxy would hide where the request really goes
+			DialContext: guardedDialer(cfg.AllowedHosts),
+		},
+		CheckRedirect: func(next *http.Request, via []*http.Request) error {
+			if len(via) >= maxRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxRedirects)
+			}
+			return checkHTTPHost(next.URL.Hostname(), cfg.AllowedHosts)
+		},
+	}
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("sending request: %w", err)
+	}
+	defer resp.Body.Close()
+	elapsed := time.Since(start).Round(time.Millisecond)
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, int64(cfg.MaxResponseBytes)+1))
+	if err != nil {
+		return "", fmt.Errorf("reading response: %w", err)
+	}
+	truncated := len(data) > cfg.MaxResponseBytes
+	if truncated {
+		data = data[:cfg.MaxResponseBytes]
+	}
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("%s %s\n%s %s in %s\n", method, resp.Request.URL, resp.Proto, resp.Status, elapsed))
+
+	names := make([]string, 0, len(resp.Header))
+	for name := range resp.Header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, value := range resp.Header[name] {
+			result.WriteString(fmt.Sprintf("%s: %s\n", name, value))
+		}
+	}
+
+	result.WriteString("\n")
+	switch {
+	case len(data) == 0:
+		result.WriteString("(empty body)")
+	case !utf8.Valid(data) && !truncated:
+		result.WriteString(fmt.Sprintf("(%d bytes of binary data)", len(data)))
+	default:
+		result.WriteString(strings.ToValidUTF8(string(data), "�"))
+		if truncated {
+			result.WriteString(fmt.Sprintf("\n... (body truncated at %d bytes)", cfg.MaxResponseBytes))
+		}
+	}
+	return result.String(), nil
+}
+
+// checkHTTPHost allows loopback hosts and hosts matching the allowlist, where
+// "*.example.com" matches any subdomain of example.com
+func checkHTTPHost(host string, allowed []string) error {
+	if hostAllowlisted(host, allowed) || isLoopbackHost(host) {
+		return nil
+	}
+	return fmt.Errorf("requests to %s are not allowed; only localhost is allowed unless the host is listed in http.allowed_hosts", host)
+}
+
+// hostAllowlisted reports whether host matches an allowlist entry
+func hostAllowlisted(host string, allowed []string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for _, pattern := range allowed {
+		pattern = strings.ToLower(pattern)
+		if host == pattern {
+			return true
+		}
+		if suffix, ok := strings.CutPrefix(pattern, "*."); ok && strings.HasSuffix(host, "."+suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// isLoopbackHost reports whether host names this machine without a DNS lookup
+func isLoopbackHost(host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// guardedDialer resolves hosts itself and refuses to connect a host that is not
+// allowlisted to anything but a loopback address, so a name cannot be pointed
+// at another machine after the URL check
+func guardedDialer(allowed []string) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		if hostAllowlisted(host, allowed) {
+			return dialer.DialContext(ctx, network, addr)
+		}
+
+		ips, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		var lastErr error = errors.New("no addresses found")
+		for _, ip := range ips {
+			if !ip.IP.IsLoopback() {
+				return nil, fmt.Errorf("%s resolves to %s, which is not a loopback address", host, ip.IP)
+			}
+		}
+		for _, ip := range ips {
+			conn, err := dialer.DialContext(ctx, network, net.JoinHostPort(ip.IP.String(), port))
+			if err == nil {
+				return conn, nil
+			}
+			lastErr = err
+		}
+		return nil, lastErr
+	}
+}
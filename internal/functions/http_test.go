@@ -0,0 +1,92 @@
+package functions
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+)
+
+func TestCheckHTTPHost(t *testing.T) {
+	allowed := []string{"api.example.com", "*.internal.test"}
+	tests := []struct {
+		name string
+		host string
+		ok   bool
+	}{
+		{"localhost", "localhost", true},
+		{"localhost subdomain", "app.localhost", true},
+		{"trailing dot", "localhost.", true},
+		{"loopback v4", "127.0.0.1", true},
+		{"loopback v4 range", "127.8.9.10", true},
+		{"loopback v6", "::1", true},
+		{"allowlisted", "api.example.com", true},
+		{"allowlisted case", "API.Example.com", true},
+		{"wildcard", "svc.internal.test", true},
+		{"wildcard apex", "internal.test", false},
+		{"wildcard lookalike", "evilinternal.test", false},
+		{"allowlisted parent", "example.com", false},
+		{"allowlisted suffix trick", "api.example.com.evil.test", false},
+		{"localhost lookalike", "localhost.evil.test", false},
+		{"private address", "10.0.0.1", false},
+		{"metadata address", "169.254.169.254", false},
+		{"unspecified", "0.0.0.0", false},
+		{"public host", "example.org", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkHTTPHost(tt.host, allowed)
+			if (err == nil) != tt.ok {
+				t.Errorf("checkHTTPHost(%q) error = %v, want allowed %v", tt.host, err, tt.ok)
+			}
+		})
+	}
+}
+
+func TestGuardedDialer(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+
+	dial := guardedDialer(nil)
+	tests := []struct {
+		name    string
+		host    string
+		wantErr string
+	}{
+		{"loopback address", "127.0.0.1", ""},
+		{"localhost", "localhost", ""},
+		{"private address", "10.0.0.1", "not a loopback address"},
+		{"metadata address", "169.254.169.254", "not a loopback address"},
+		{"unspecified", "0.0.0.0", "not a loopback address"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, err := dial(context.Background(), "tcp", net.JoinHostPort(tt.host, port))
+			if conn != nil {
+				conn.Close()
+			}
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("dialing %s: %v", tt.host, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("dialing %s: error = %v, want %q", tt.host, err, tt.wantErr)
+			}
+		})
+	}
+}
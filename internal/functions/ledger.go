@@ -0,0 +1,118 @@
+package functions
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// WriteLogFile is the write ledger location relative to the workspace root
+const WriteLogFile = ".riptide/writes.log"
+
+// WriteRecord describes one file write in the ledger
+type WriteRecord struct {
+	Time        time.Time `json:"time"`
+	Path        string    `json:"path"`
+	Tool        string    `json:"tool"`
+	Turn        int       `json:"turn"`                    // User message number that led to the write
+	BeforeHash  string    `json:"before_sha256,omitempty"` // Empty when the file was created
+	AfterHash   string    `json:"after_sha256"`            // Empty when /undo deleted the file
+	BytesBefore int       `json:"bytes_before"`
+	BytesAfter  int       `json:"bytes_after"`
+}
+
+// Delta returns the change in file size in bytes
+func (r WriteRecord) Delta() int {
+	return r.BytesAfter - r.BytesBefore
+}
+
+// SetTurn sets the conversation turn recorded for subsequent writes
+func (f *FileOperations) SetTurn(turn int) {
+	f.writeMu.Lock()
+	defer f.writeMu.Unlock()
+	f.turn = turn
+}
+
+// WriteLogPath returns the path of the write ledger, or "" when it is disabled
+func (f *FileOperations) WriteLogPath() string {
+	return f.writeLogPath
+}
+
+// snapshotFile returns the hash and size of a file, or empty values if it does not exist
+func snapshotFile(path string) (string, int) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", 0
+	}
+	return hashBytes(data), len(data)
+}
+
+// hashBytes returns the hex-encoded SHA-256 of data
+func hashBytes(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// appendWriteRecord adds a record to the ledger. Callers hold writeMu.
+func (f *FileOperations) appendWriteRecord(record WriteRecord) error {
+	if f.writeLogPath == "" {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(f.writeLogPath), 0755); err != nil {
+		return fmt.Errorf("creating ledger directory: %w", err)
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("marshaling write record: %w", err)
+	}
+
+	file, err := os.OpenFile(f.writeLogPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("opening write ledger: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("appending write record: %w", err)
+	}
+	return nil
+}
+
+// ReadWriteLog returns the last limit records from a ledger, oldest first.
+// A limit of zero or less returns every record.
+func ReadWriteLog(path string, limit int) ([]WriteRecord, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("opening write ledger: %w", err)
+	}
+	defer file.Close()
+
+	var records []WriteRecord
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var record WriteRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			// Skip lines damaged by an interrupted append
+			continue
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading write ledger: %w", err)
+	}
+
+	if limit > 0 && len(records) > limit {
+		records = records[len(records)-limit:]
+	}
+	return records, nil
+}
@@ -0,0 +1,121 @@
+package functions
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// maxReadLines caps the lines one ranged read returns, and is how many it
+	// returns when no limit is given
+	maxReadLines = 2000
+
+	// maxReadLineLength caps the bytes kept for each line of a ranged read
+	maxReadLineLength = 2000
+)
+
+// readFileLines reads limit lines of a file starting at the 1-based line offset
+// and returns them numbered, so the model can page through files too large to
+// read whole. The file is read line by line, so its size does not matter.
+func (f *FileOperations) readFileLines(filePath string, offset, limit int) (string, error) {
+	if offset < 0 || limit < 0 {
+		return "", fmt.Errorf("offset and limit cannot be negative")
+	}
+	if offset == 0 {
+		offset = 1
+	}
+	if limit == 0 || limit > maxReadLines {
+		limit = maxReadLines
+	}
+
+	normalizedPath, err := NormalizePath(filePath)
+	if err != nil {
+		return "", fmt.Errorf("normalizing path: %w", err)
+	}
+	file, err := os.Open(normalizedPath)
+	if err != nil {
+		return "", fmt.Errorf("reading file: %w", err)
+	}
+	defer file.Close()
+
+	var lines []string
+	total := 0
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadString('\n')
+		if line != "" {
+			total++
+			if total >= offset && total < offset+limit {
+				lines = append(lines, truncateReadLine(strings.TrimRight(line, "\r\n")))
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("reading file: %w", err)
+		}
+	}
+
+	if offset > total {
+		return "", fmt.Errorf("offset %d is past the end of the file, which has %d lines", offset, total)
+	}
+
+	last := offset + len(lines) - 1
+	width := len(strconv.Itoa(last))
+	var b strings.Builder
+	fmt.Fprintf(&b, "Lines %d-%d of %d in file '%s':\n\n", offset, last, total, normalizedPath)
+	for i, line := range lines {
+		fmt.Fprintf(&b, "%*d\t%s\n", width, offset+i, line)
+	}
+	if last < total {
+		fmt.Fprintf(&b, "\n(%d more lines; continue from line %d)", total-last, last+1)
+	}
+	return b.String(), nil
+}
+
+// readFileRange reads the inclusive range of lines from start to end, or
+// maxReadLines lines from start when end is 0
+func (f *FileOperations) readFileRange(filePath string, start, end int) (string, error) {
+	if start < 1 {
+		return "", fmt.Errorf("start_line must be 1 or more")
+	}
+	if end == 0 {
+		return f.readFileLines(filePath, start, 0)
+	}
+	if end < start {
+		return "", fmt.Errorf("end_line %d is before start_line %d", end, start)
+	}
+	return f.readFileLines(filePath, start, end-start+1)
+}
+
+// checkReadSize refuses to read a whole file larger than the configured limit,
+// pointing to a ranged read instead
+func (f *FileOperations) checkReadSize(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("reading file: %w", err)
+	}
+	if maxSize := int64(f.config.FileOperations.MaxFileSizeMB) * 1024 * 1024; info.Size() > maxSize {
+		return fmt.Errorf("file is %.1fMB, over the %dMB limit for reading it whole; read it in parts with offset and limit, or search_files for what you need",
+			float64(info.Size())/(1024*1024), f.config.FileOperations.MaxFileSizeMB)
+	}
+	return nil
+}
+
+// truncateReadLine shortens a line longer than maxReadLineLength, saying how much was cut
+func truncateReadLine(line string) string {
+	if len(line) <= maxReadLineLength {
+		return line
+	}
+	cut := maxReadLineLength
+	for cut > 0 && !utf8.RuneStart(line[cut]) {
+		cut--
+	}
+	return line[:cut] + fmt.Sprintf("... (%d more bytes)", len(line)-cut)
+}
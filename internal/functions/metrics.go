@@ -0,0 +1,435 @@
+package functions
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/alchemy-labs-co/riptide/internal/config"
+)
+
+const (
+	// maxMetricsFiles caps how many files code_metrics reads
+	maxMetricsFiles = 5000
+
+	// duplicateWindow is how many consecutive significant lines make a duplicate block
+	duplicateWindow = 6
+
+	// metricsTopN is how many complex functions and duplicate blocks are listed
+	metricsTopN = 10
+)
+
+// codeExtensions are the file types code_metrics counts; documentation and
+// data files would skew the line counts
+var codeExtensions = map[string]bool{
+	".go": true, ".py": true, ".js": true, ".jsx": true, ".ts": true, ".tsx": true,
+	".mjs": true, ".cjs": true, ".java": true, ".kt": true, ".scala": true,
+	".c": true, ".h": true, ".cc": true, ".cpp": true, ".hpp": true, ".cs": true,
+	".rs": true, ".rb": true, ".php": true, ".swift": true, ".m": true,
+	".sh": true, ".bash": true, ".lua": true, ".sql": true, ".vue": true, ".svelte": true,
+}
+
+// cPreprocessor are extensions where # starts a directive rather than a comment
+var cPreprocessor = map[string]bool{
+	".c": true, ".h": true, ".cc": true, ".cpp": true, ".hpp": true, ".cs": true, ".m": true,
+}
+
+// PackageMetrics holds the line counts of one directory
+type PackageMetrics struct {
+	Dir     string
+	Files   int
+	Code    int
+	Comment int
+	Blank   int
+
+	Functions  int // Go functions measured for complexity
+	Complexity int // Sum of their cyclomatic complexity
+	MaxFunc    string
+	MaxComplex int
+}
+
+// FunctionComplexity is the cyclomatic complexity of one Go function
+type FunctionComplexity struct {
+	Name       string
+	Path       string
+	Line       int
+	Complexity int
+}
+
+// DuplicateBlock is a run of lines that appears in more than one place
+type DuplicateBlock struct {
+	Lines     int
+	Locations []string // path:line of each copy
+	Preview   string   // First line of the block
+}
+
+// MetricsResult is the outcome of a code_metrics scan
+type MetricsResult struct {
+	Packages   []PackageMetrics
+	Functions  []FunctionComplexity // Most complex first
+	Duplicates []DuplicateBlock     // Most repeated lines first
+	Truncated  bool                 // Stopped after maxMetricsFiles
+}
+
+// blockLocation is where a duplicateWindow-line block starts
+type blockLocation struct {
+	path string
+	line int
+}
+
+// significantLine is a normalized line used for duplicate detection
+type significantLine struct {
+	text string
+	line int
+}
+
+// CollectMetrics measures the code under root: line counts per directory,
+// cyclomatic complexity of Go functions, and blocks of duplicated lines
+func CollectMetrics(cfg *config.Config, root string) (*MetricsResult, error) {
+	normalizedRoot, err := NormalizePath(root)
+	if err != nil {
+		return nil, fmt.Errorf("normalizing path: %w", err)
+	}
+
+	result := &MetricsResult{}
+	packages := make(map[string]*PackageMetrics)
+	fileLines := make(map[string][]significantLine)
+	blocks := make(map[[sha256.Size]byte][]blockLocation)
+	files := 0
+
+	err = walkTextFiles(cfg, normalizedRoot, func(path string) error {
+		if !codeExtensions[strings.ToLower(filepath.Ext(path))] {
+			return nil
+		}
+		if files >= maxMetricsFiles {
+			result.Truncated = true
+			return filepath.SkipAll
+		}
+		files++
+
+		rel, err := filepath.Rel(normalizedRoot, path)
+		if err != nil || rel == "." {
+			rel = filepath.Base(path)
+		}
+		rel = filepath.ToSlash(rel)
+
+		dir := filepath.ToSlash(filepath.Dir(rel))
+		pkg := packages[dir]
+		if pkg == nil {
+			pkg = &PackageMetrics{Dir: dir}
+			packages[dir] = pkg
+		}
+
+		lines, err := countLines(path, pkg, !cPreprocessor[strings.ToLower(filepath.Ext(path))])
+		if err != nil {
+			return nil
+		}
+		pkg.Files++
+		fileLines[rel] = lines
+		for i := 0; i+duplicateWindow <= len(lines); i++ {
+			hash := sha256.New()
+			for _, l := range lines[i : i+duplicateWindow] {
+				hash.Write([]byte(l.text))
+				hash.Write([]byte{'\n'})
+			}
+			var key [sha256.Size]byte
+			copy(key[:], hash.Sum(nil))
+			blocks[key] = append(blocks[key], blockLocation{path: rel, line: i})
+		}
+
+		if strings.HasSuffix(path, ".go") {
+			for _, fn := range goComplexity(path, rel) {
+				pkg.Functions++
+				pkg.Complexity += fn.Complexity
+				if fn.Complexity > pkg.MaxComplex {
+					pkg.MaxComplex = fn.Complexity
+					pkg.MaxFunc = fn.Name
+				}
+				result.Functions = append(result.Functions, fn)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, pkg := range packages {
+		result.Packages = append(result.Packages, *pkg)
+	}
+	sort.Slice(result.Packages, func(i, j int) bool {
+		if result.Packages[i].Code != result.Packages[j].Code {
+			return result.Packages[i].Code > result.Packages[j].Code
+		}
+		return result.Packages[i].Dir < result.Packages[j].Dir
+	})
+
+	sort.SliceStable(result.Functions, func(i, j int) bool {
+		return result.Functions[i].Complexity > result.Functions[j].Complexity
+	})
+
+	result.Duplicates = duplicateBlocks(blocks, fileLines)
+	return result, nil
+}
+
+// countLines adds the code, comment and blank lines of path to pkg and returns
+// its significant lines for duplicate detection: trimmed code lines longer
+// than a lone brace or keyword. hashComments treats lines starting with # as
+// comments.
+func countLines(path string, pkg *PackageMetrics, hashComments bool) ([]significantLine, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var significant []significantLine
+	inBlock := false
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		switch {
+		case text == "":
+			pkg.Blank++
+		case inBlock:
+			pkg.Comment++
+			if strings.Contains(text, "*/") {
+				inBlock = false
+			}
+		case strings.HasPrefix(text, "/*"):
+			pkg.Comment++
+			inBlock = !strings.Contains(text[2:], "*/")
+		case strings.HasPrefix(text, "//"), hashComments && strings.HasPrefix(text, "#"),
+			strings.HasPrefix(text, "--"), strings.HasPrefix(text, "<!--"):
+			pkg.Comment++
+		default:
+			pkg.Code++
+			if len(text) > 3 && !isBoilerplate(text) {
+				significant = append(significant, significantLine{text: text, line: line})
+			}
+		}
+	}
+	return significant, scanner.Err()
+}
+
+// boilerplatePrefixes start lines such as imports that are expected to repeat
+// across files and are left out of duplicate detection
+var boilerplatePrefixes = []string{"package ", "import ", "from ", "using ", "#include", "require(", "use "}
+
+// isBoilerplate reports whether a trimmed code line is an import, package
+// clause or bare import path
+func isBoilerplate(text string) bool {
+	for _, prefix := range boilerplatePrefixes {
+		if strings.HasPrefix(text, prefix) {
+			return true
+		}
+	}
+	return len(text) > 1 && text[0] == '"' && text[len(text)-1] == '"' && !strings.Contains(text[1:len(text)-1], `"`)
+}
+
+// goComplexity returns the cyclomatic complexity of every function in a Go
+// file: one plus each if, loop, case, select case, && and ||
+func goComplexity(path, rel string) []FunctionComplexity {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, nil, parser.SkipObjectResolution)
+	if err != nil {
+		return nil
+	}
+
+	var functions []FunctionComplexity
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Body == nil {
+			continue
+		}
+
+		name := fn.Name.Name
+		if fn.Recv != nil && len(fn.Recv.List) > 0 {
+			name = receiverName(fn.Recv.List[0].Type) + "." + name
+		}
+
+		complexity := 1
+		ast.Inspect(fn.Body, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.IfStmt, *ast.ForStmt, *ast.RangeStmt:
+				complexity++
+			case *ast.CaseClause:
+				if n.List != nil {
+					complexity++
+				}
+			case *ast.CommClause:
+				if n.Comm != nil {
+					complexity++
+				}
+			case *ast.BinaryExpr:
+				if n.Op == token.LAND || n.Op == token.LOR {
+					complexity++
+				}
+			}
+			return true
+		})
+
+		functions = append(functions, FunctionComplexity{
+			Name:       name,
+			Path:       rel,
+			Line:       fset.Position(fn.Pos()).Line,
+			Complexity: complexity,
+		})
+	}
+	return functions
+}
+
+// receiverName returns the type name of a method receiver
+func receiverName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return receiverName(t.X)
+	case *ast.IndexExpr:
+		return receiverName(t.X)
+	case *ast.IndexListExpr:
+		return receiverName(t.X)
+	case *ast.Ident:
+		return t.Name
+	}
+	return "?"
+}
+
+// duplicateBlocks turns windows seen more than once into blocks, extending
+// each run of consecutive duplicated windows into one block so a 20-line copy
+// is reported once rather than as 15 overlapping windows
+func duplicateBlocks(blocks map[[sha256.Size]byte][]blockLocation, fileLines map[string][]significantLine) []DuplicateBlock {
+	// Windows at each position that have a copy elsewhere
+	duplicated := make(map[blockLocation][]blockLocation)
+	for _, locations := range blocks {
+		if len(locations) < 2 {
+			continue
+		}
+		for _, loc := range locations {
+			duplicated[loc] = locations
+		}
+	}
+
+	var starts []blockLocation
+	for loc := range duplicated {
+		// Only start a block where the previous window was not duplicated too
+		if _, ok := duplicated[blockLocation{path: loc.path, line: loc.line - 1}]; !ok {
+			starts = append(starts, loc)
+		}
+	}
+	sort.Slice(starts, func(i, j int) bool {
+		if starts[i].path != starts[j].path {
+			return starts[i].path < starts[j].path
+		}
+		return starts[i].line < starts[j].line
+	})
+
+	var result []DuplicateBlock
+	reported := make(map[blockLocation]bool)
+	for _, start := range starts {
+		if reported[start] {
+			continue
+		}
+
+		length := duplicateWindow
+		for next := start.line + 1; ; next++ {
+			if _, ok := duplicated[blockLocation{path: start.path, line: next}]; !ok {
+				break
+			}
+			length++
+		}
+
+		lines := fileLines[start.path]
+		block := DuplicateBlock{Lines: length, Preview: lines[start.line].text}
+		for _, dup := range duplicated[start] {
+			reported[dup] = true
+			block.Locations = append(block.Locations, fmt.Sprintf("%s:%d", dup.path, fileLines[dup.path][dup.line].line))
+		}
+		sort.Strings(block.Locations)
+		result = append(result, block)
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Lines*len(result[i].Locations) > result[j].Lines*len(result[j].Locations)
+	})
+	return result
+}
+
+// FormatMetrics renders a metrics result as plain text tables
+func FormatMetrics(result *MetricsResult) string {
+	if len(result.Packages) == 0 {
+		return "No source files found"
+	}
+
+	var b strings.Builder
+	var total PackageMetrics
+	for _, pkg := range result.Packages {
+		total.Files += pkg.Files
+		total.Code += pkg.Code
+		total.Comment += pkg.Comment
+		total.Blank += pkg.Blank
+	}
+	b.WriteString(fmt.Sprintf("%d files, %d code lines, %d comment lines, %d blank lines", total.Files, total.Code, total.Comment, total.Blank))
+	if result.Truncated {
+		b.WriteString(fmt.Sprintf(" (stopped after %d files)", maxMetricsFiles))
+	}
+
+	b.WriteString("\n\nBy directory (code lines, largest first):\n")
+	b.WriteString(fmt.Sprintf("%-40s %6s %8s %8s %8s %10s  %s\n", "DIR", "FILES", "CODE", "COMMENT", "BLANK", "AVG CC", "MAX CC"))
+	for _, pkg := range result.Packages {
+		avg, max := "-", "-"
+		if pkg.Functions > 0 {
+			avg = fmt.Sprintf("%.1f", float64(pkg.Complexity)/float64(pkg.Functions))
+			max = fmt.Sprintf("%d %s", pkg.MaxComplex, pkg.MaxFunc)
+		}
+		b.WriteString(fmt.Sprintf("%-40s %6d %8d %8d %8d %10s  %s\n", pkg.Dir, pkg.Files, pkg.Code, pkg.Comment, pkg.Blank, avg, max))
+	}
+
+	if len(result.Functions) > 0 {
+		b.WriteString("\nMost complex Go functions (cyclomatic complexity):\n")
+		for i, fn := range result.Functions {
+			if i == metricsTopN {
+				break
+			}
+			b.WriteString(fmt.Sprintf("%4d  %s  %s:%d\n", fn.Complexity, fn.Name, fn.Path, fn.Line))
+		}
+	}
+
+	if len(result.Duplicates) == 0 {
+		b.WriteString(fmt.Sprintf("\nNo duplicated blocks of %d or more lines\n", duplicateWindow))
+	} else {
+		duplicatedLines := 0
+		for _, block := range result.Duplicates {
+			duplicatedLines += block.Lines * (len(block.Locations) - 1)
+		}
+		b.WriteString(fmt.Sprintf("\nDuplicated blocks: %d (%d repeated lines)\n", len(result.Duplicates), duplicatedLines))
+		for i, block := range result.Duplicates {
+			if i == metricsTopN {
+				b.WriteString(fmt.Sprintf("... and %d more\n", len(result.Duplicates)-metricsTopN))
+				break
+			}
+			b.WriteString(fmt.Sprintf("%d lines x%d: %s\n    %s\n", block.Lines, len(block.Locations), strings.Join(block.Locations, ", "), block.Preview))
+		}
+	}
+
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// codeMetrics implements the code_metrics tool
+func (f *FileOperations) codeMetrics(path string) (string, error) {
+	if path == "" {
+		path = "."
+	}
+	result, err := CollectMetrics(f.config, path)
+	if err != nil {
+		return "", fmt.Errorf("collecting metrics: %w", err)
+	}
+	return FormatMetrics(result), nil
+}
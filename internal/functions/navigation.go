@@ -0,0 +1,165 @@
+package functions
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/alchemy-labs-co/riptide/internal/lsp"
+)
+
+// maxReferences caps the locations find_references lists
+const maxReferences = 100
+
+// findDefinition implements the find_definition tool
+func (f *FileOperations) findDefinition(filePath, symbol string, line int) (string, error) {
+	path, pos, err := f.symbolPosition(filePath, symbol, line)
+	if err != nil {
+		return "", err
+	}
+	locations, err := f.lsp.Definition(path, pos)
+	if err != nil {
+		return "", navigationError("finding definition", err)
+	}
+	if len(locations) == 0 {
+		return fmt.Sprintf("The language server found no definition of %s", symbol), nil
+	}
+	return fmt.Sprintf("Definition of %s:\n%s", symbol, formatLocations(locations)), nil
+}
+
+// findReferences implements the find_references tool
+func (f *FileOperations) findReferences(filePath, symbol string, line int) (string, error) {
+	path, pos, err := f.symbolPosition(filePath, symbol, line)
+	if err != nil {
+		return "", err
+	}
+	locations, err := f.lsp.References(path, pos)
+	if err != nil {
+		return "", navigationError("finding references", err)
+	}
+	if len(locations) == 0 {
+		return fmt.Sprintf("The language server found no references to %s", symbol), nil
+	}
+
+	sort.Slice(locations, func(i, j int) bool {
+		if locations[i].Path != locations[j].Path {
+			return locations[i].Path < locations[j].Path
+		}
+		return locations[i].Range.Start.Line < locations[j].Range.Start.Line
+	})
+	total := len(locations)
+	listing := formatLocations(locations[:min(total, maxReferences)])
+	if total > maxReferences {
+		listing += fmt.Sprintf("\n... and %d more", total-maxReferences)
+	}
+	return fmt.Sprintf("%d references to %s:\n%s", total, symbol, listing), nil
+}
+
+// documentSymbols implements the document_symbols tool
+func (f *FileOperations) documentSymbols(filePath string) (string, error) {
+	path, err := NormalizePath(filePath)
+	if err != nil {
+		return "", fmt.Errorf("normalizing path: %w", err)
+	}
+	symbols, err := f.lsp.Symbols(path)
+	if err != nil {
+		return "", navigationError("listing symbols", err)
+	}
+	if len(symbols) == 0 {
+		return fmt.Sprintf("The language server found no symbols in %s", filePath), nil
+	}
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("Symbols in %s (line: kind name):", filePath))
+	for _, symbol := range symbols {
+		b.WriteString(fmt.Sprintf("\n%d: %s%s %s", symbol.Range.Start.Line+1, strings.Repeat("  ", symbol.Depth), symbol.Kind, symbol.Name))
+		if symbol.Container != "" {
+			b.WriteString(" in " + symbol.Container)
+		}
+		if detail := strings.Join(strings.Fields(symbol.Detail), " "); detail != "" {
+			b.WriteString(" — " + clipSearchLine(detail))
+		}
+	}
+	return b.String(), nil
+}
+
+// symbolPosition finds symbol on the given line of a file, or its first
+// occurrence when line is 0, and returns the file's path and the position of
+// the symbol's name. For a qualified name such as pkg.Func the position is
+// that of Func.
+func (f *FileOperations) symbolPosition(filePath, symbol string, line int) (string, lsp.Position, error) {
+	path, err := NormalizePath(filePath)
+	if err != nil {
+		return "", lsp.Position{}, fmt.Errorf("normalizing path: %w", err)
+	}
+	symbol = strings.TrimSpace(symbol)
+	if symbol == "" {
+		return "", lsp.Position{}, fmt.Errorf("symbol is required")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", lsp.Position{}, fmt.Errorf("reading file: %w", err)
+	}
+
+	lines := strings.Split(string(data), "\n")
+	if line > len(lines) {
+		return "", lsp.Position{}, fmt.Errorf("line %d is past the end of %s, which has %d lines", line, filePath, len(lines))
+	}
+	name := symbol[strings.LastIndex(symbol, ".")+1:]
+	pattern := regexp.MustCompile(`(^|[^\w$])(` + regexp.QuoteMeta(symbol) + `)([^\w$]|$)`)
+
+	for i, text := range lines {
+		if line > 0 && i != line-1 {
+			continue
+		}
+		if match := pattern.FindStringSubmatchIndex(text); match != nil {
+			offset := match[5] - len(name)
+			return path, lsp.Position{Line: i, Character: lsp.Character(text, offset)}, nil
+		}
+	}
+	if line > 0 {
+		return "", lsp.Position{}, fmt.Errorf("%s does not appear on line %d of %s", symbol, line, filePath)
+	}
+	return "", lsp.Position{}, fmt.Errorf("%s does not appear in %s", symbol, filePath)
+}
+
+// formatLocations lists locations as path:line: text
+func formatLocations(locations []lsp.Location) string {
+	root := ""
+	if cwd, err := os.Getwd(); err == nil {
+		root = cwd
+	}
+
+	files := make(map[string][]string)
+	var b strings.Builder
+	for i, location := range locations {
+		lines, ok := files[location.Path]
+		if !ok {
+			if data, err := os.ReadFile(location.Path); err == nil {
+				lines = strings.Split(string(data), "\n")
+			}
+			files[location.Path] = lines
+		}
+		text := ""
+		if n := location.Range.Start.Line; n < len(lines) {
+			text = clipSearchLine(strings.TrimSpace(lines[n]))
+		}
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(fmt.Sprintf("%s:%d: %s", relativeTo(root, location.Path), location.Range.Start.Line+1, text))
+	}
+	return b.String()
+}
+
+// navigationError explains a language server failure, pointing the model at
+// search_files when no server can answer
+func navigationError(action string, err error) error {
+	if errors.Is(err, lsp.ErrNoServer) {
+		return fmt.Errorf("%w; use search_files instead", err)
+	}
+	return fmt.Errorf("%s: %w", action, err)
+}
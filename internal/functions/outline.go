@@ -0,0 +1,549 @@
+package functions
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const (
+	// maxOutlineItems caps the declarations get_file_outline lists
+	maxOutlineItems = 400
+
+	// maxOutlineImports caps the import names listed on the imports line
+	maxOutlineImports = 12
+
+	// maxSignatureLines is how far a declaration is followed to find the
+	// brace that opens its body
+	maxSignatureLines = 10
+)
+
+// OutlineItem is a declaration in a file, with the lines it spans
+type OutlineItem struct {
+	Kind      string // import, func, method, type, class, const, ...
+	Name      string
+	Signature string // The declaration's first line, without its body
+	Start     int    // First line, from 1
+	End       int    // Last line
+	Depth     int    // Nesting, e.g. 1 for a method inside a class
+}
+
+// Outline is the declarations of a file in source order
+type Outline struct {
+	Language    string
+	Lines       int
+	Items       []OutlineItem
+	Approximate bool // Read line by line rather than parsed, so end lines may be off
+}
+
+// outlineLanguages names the languages get_file_outline reads, by extension
+var outlineLanguages = map[string]string{
+	".go": "Go", ".py": "Python",
+	".js": "JavaScript", ".jsx": "JavaScript", ".mjs": "JavaScript", ".cjs": "JavaScript",
+	".ts": "TypeScript", ".tsx": "TypeScript",
+	".rs": "Rust", ".java": "Java",
+}
+
+// ParseOutline finds the imports, types, functions and other top-level
+// declarations of a source file, with the methods of classes and similar
+// containers nested under them. Go is parsed exactly; the other languages are
+// read line by line, which is right for conventionally formatted code.
+func ParseOutline(path string, src []byte) (*Outline, error) {
+	language, ok := outlineLanguages[strings.ToLower(filepath.Ext(path))]
+	if !ok {
+		return nil, fmt.Errorf("no outline support for %s files", filepath.Ext(path))
+	}
+
+	text := strings.ReplaceAll(string(src), "\r\n", "\n")
+	lines := strings.Split(text, "\n")
+	outline := &Outline{Language: language, Lines: len(lines), Approximate: language != "Go"}
+	if strings.HasSuffix(text, "\n") {
+		outline.Lines--
+	}
+
+	switch language {
+	case "Go":
+		items, err := goOutline(path, src, lines)
+		if err != nil {
+			return nil, err
+		}
+		outline.Items = items
+	case "Python":
+		outline.Items = pythonOutline(lines)
+	default:
+		outline.Items = braceOutline(braceLanguages[language], lines)
+	}
+	return outline, nil
+}
+
+// goOutline lists the declarations of a Go file from its syntax tree
+func goOutline(path string, src []byte, lines []string) ([]OutlineItem, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, src, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", filepath.Base(path), err)
+	}
+	line := func(pos token.Pos) int { return fset.Position(pos).Line }
+	item := func(kind, name string, from, to token.Pos) OutlineItem {
+		return OutlineItem{Kind: kind, Name: name, Signature: signatureLine(lines, line(from)), Start: line(from), End: line(to)}
+	}
+
+	var items []OutlineItem
+	var imports []string
+	importStart, importEnd := 0, 0
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			if decl.Recv != nil && len(decl.Recv.List) > 0 {
+				items = append(items, item("method", receiverName(decl.Recv.List[0].Type)+"."+decl.Name.Name, decl.Pos(), decl.End()))
+			} else {
+				items = append(items, item("func", decl.Name.Name, decl.Pos(), decl.End()))
+			}
+
+		case *ast.GenDecl:
+			switch decl.Tok {
+			case token.IMPORT:
+				if importStart == 0 {
+					importStart = line(decl.Pos())
+				}
+				importEnd = line(decl.End())
+				for _, spec := range decl.Specs {
+					imports = append(imports, strings.Trim(spec.(*ast.ImportSpec).Path.Value, `"`))
+				}
+
+			case token.TYPE:
+				for _, spec := range decl.Specs {
+					spec := spec.(*ast.TypeSpec)
+					from, to := spec.Pos(), spec.End()
+					if !decl.Lparen.IsValid() {
+						from, to = decl.Pos(), decl.End()
+					}
+					kind := "type"
+					switch spec.Type.(type) {
+					case *ast.StructType:
+						kind = "struct"
+					case *ast.InterfaceType:
+						kind = "interface"
+					}
+					items = append(items, item(kind, spec.Name.Name, from, to))
+				}
+
+			case token.CONST, token.VAR:
+				var names []string
+				for _, spec := range decl.Specs {
+					for _, name := range spec.(*ast.ValueSpec).Names {
+						names = append(names, name.Name)
+					}
+				}
+				entry := item(decl.Tok.String(), strings.Join(names, ", "), decl.Pos(), decl.End())
+				if decl.Lparen.IsValid() {
+					entry.Signature = fmt.Sprintf("%s (%s)", decl.Tok, listNames(names, maxOutlineImports))
+				}
+				items = append(items, entry)
+			}
+		}
+	}
+
+	if len(imports) > 0 {
+		items = append([]OutlineItem{importsItem(imports, importStart, importEnd)}, items...)
+	}
+	return items, nil
+}
+
+// pythonOutline lists imports, classes, functions with the methods of
+// classes, and constants, using indentation to find where each ends
+func pythonOutline(lines []string) []OutlineItem {
+	declaration := regexp.MustCompile(`^(\s*)(?:async\s+)?(def|class)\s+(\w+)`)
+	constant := regexp.MustCompile(`^([A-Z][A-Z0-9_]*)\s*(?::[^=]+)?=`)
+	importLine := regexp.MustCompile(`^(?:from\s+(\S+)\s+import|import\s+(.+))`)
+
+	type open struct {
+		index  int
+		indent int
+		class  bool
+	}
+	var items []OutlineItem
+	var stack []open
+	var imports []string
+	importStart, importEnd := 0, 0
+	lastCode := 0
+
+	for i, text := range lines {
+		n := i + 1
+		trimmed := strings.TrimSpace(text)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		indent := len(text) - len(strings.TrimLeft(text, " \t"))
+
+		// A line indented no deeper than a declaration ends it
+		for len(stack) > 0 && indent <= stack[len(stack)-1].indent {
+			items[stack[len(stack)-1].index].End = lastCode
+			stack = stack[:len(stack)-1]
+		}
+		lastCode = n
+
+		// Only classes are looked into; nested functions are not listed
+		inFunction := len(stack) > 0 && !stack[len(stack)-1].class
+		switch match := declaration.FindStringSubmatch(text); {
+		case match != nil && !inFunction:
+			items = append(items, OutlineItem{
+				Kind:      match[2],
+				Name:      match[3],
+				Signature: strings.TrimSuffix(trimmed, ":"),
+				Start:     n,
+				End:       n,
+				Depth:     len(stack),
+			})
+			stack = append(stack, open{index: len(items) - 1, indent: indent, class: match[2] == "class"})
+		case indent == 0 && importLine.MatchString(text):
+			match := importLine.FindStringSubmatch(text)
+			if match[1] != "" {
+				imports = append(imports, match[1])
+			} else {
+				for _, name := range strings.Split(match[2], ",") {
+					if fields := strings.Fields(name); len(fields) > 0 {
+						imports = append(imports, fields[0])
+					}
+				}
+			}
+			if importStart == 0 {
+				importStart = n
+			}
+			importEnd = n
+		case indent == 0 && constant.MatchString(text):
+			name := constant.FindStringSubmatch(text)[1]
+			items = append(items, OutlineItem{Kind: "const", Name: name, Signature: clipSearchLine(trimmed), Start: n, End: n})
+		}
+	}
+	for _, entry := range stack {
+		items[entry.index].End = lastCode
+	}
+
+	if len(imports) > 0 {
+		items = append([]OutlineItem{importsItem(imports, importStart, importEnd)}, items...)
+	}
+	return items
+}
+
+// outlineRule recognises a declaration in a brace-delimited language
+type outlineRule struct {
+	kind      string
+	pattern   *regexp.Regexp // The last submatch is the name
+	container bool           // Declarations inside its body are listed too
+	member    bool           // Only matched directly inside a container, such as a method
+}
+
+// braceLanguage describes how to outline a brace-delimited language
+type braceLanguage struct {
+	imports *regexp.Regexp // The first non-empty submatch names the import
+	rules   []outlineRule
+}
+
+// jsImportFrom finds the module named at the end of a multi-line import
+var jsImportFrom = regexp.MustCompile(`from\s*['"]([^'"]+)['"]`)
+
+// braceLanguages holds the declaration rules of each brace-delimited language
+var braceLanguages = map[string]braceLanguage{
+	"JavaScript": jsLanguage,
+	"TypeScript": jsLanguage,
+	"Rust": {
+		imports: regexp.MustCompile(`^use\s+([\w:]+)`),
+		rules: []outlineRule{
+			{kind: "fn", pattern: regexp.MustCompile(`^\s*(?:pub(?:\([^)]*\))?\s+)?(?:const\s+|async\s+|unsafe\s+|extern\s+"[^"]*"\s+)*fn\s+(\w+)`)},
+			{kind: "struct", pattern: regexp.MustCompile(`^(?:pub(?:\([^)]*\))?\s+)?struct\s+(\w+)`)},
+			{kind: "enum", pattern: regexp.MustCompile(`^(?:pub(?:\([^)]*\))?\s+)?enum\s+(\w+)`)},
+			{kind: "trait", pattern: regexp.MustCompile(`^(?:pub(?:\([^)]*\))?\s+)?(?:unsafe\s+)?trait\s+(\w+)`), container: true},
+			{kind: "impl", pattern: regexp.MustCompile(`^(?:unsafe\s+)?impl(?:<[^>]*>)?\s+(?:[\w:<>, ]+\s+for\s+)?([\w:]+)`), container: true},
+			{kind: "mod", pattern: regexp.MustCompile(`^(?:pub(?:\([^)]*\))?\s+)?mod\s+(\w+)`), container: true},
+			{kind: "type", pattern: regexp.MustCompile(`^(?:pub(?:\([^)]*\))?\s+)?type\s+(\w+)`)},
+			{kind: "const", pattern: regexp.MustCompile(`^(?:pub(?:\([^)]*\))?\s+)?(?:const|static)\s+(?:mut\s+)?(\w+)`)},
+			{kind: "macro", pattern: regexp.MustCompile(`^macro_rules!\s+(\w+)`)},
+		},
+	},
+	"Java": {
+		imports: regexp.MustCompile(`^import\s+(?:static\s+)?([\w.*]+)`),
+		rules: []outlineRule{
+			{kind: "class", pattern: regexp.MustCompile(`^\s*(?:(?:public|protected|private|abstract|final|static|sealed|non-sealed)\s+)*(?:class|record)\s+(\w+)`), container: true},
+			{kind: "interface", pattern: regexp.MustCompile(`^\s*(?:(?:public|protected|private|abstract|static|sealed)\s+)*@?interface\s+(\w+)`), container: true},
+			{kind: "enum", pattern: regexp.MustCompile(`^\s*(?:(?:public|protected|private|static)\s+)*enum\s+(\w+)`), container: true},
+			{kind: "method", member: true, pattern: regexp.MustCompile(`^\s*(?:@\w+\s+)*(?:(?:public|protected|private|abstract|final|static|synchronized|native|default)\s+)*(?:<[^>]+>\s+)?[\w.<>\[\], ?]+\s+(\w+)\s*\(`)},
+			{kind: "constructor", member: true, pattern: regexp.MustCompile(`^\s*(?:(?:public|protected|private)\s+)?([A-Z]\w*)\s*\([^;]*$`)},
+		},
+	},
+}
+
+// jsLanguage covers JavaScript and TypeScript
+var jsLanguage = braceLanguage{
+	imports: regexp.MustCompile(`^import\s+(?:.*\sfrom\s*)?['"]([^'"]+)['"]|^(?:const|let|var)\s+.+=\s*require\(['"]([^'"]+)['"]\)|^(import)\b`),
+	rules: []outlineRule{
+		{kind: "function", pattern: regexp.MustCompile(`^(?:export\s+)?(?:default\s+)?(?:async\s+)?function\s*\*?\s*(\w+)`)},
+		{kind: "class", pattern: regexp.MustCompile(`^(?:export\s+)?(?:default\s+)?(?:abstract\s+)?class\s+(\w+)`), container: true},
+		{kind: "interface", pattern: regexp.MustCompile(`^(?:export\s+)?(?:declare\s+)?interface\s+(\w+)`)},
+		{kind: "type", pattern: regexp.MustCompile(`^(?:export\s+)?(?:declare\s+)?type\s+(\w+)`)},
+		{kind: "enum", pattern: regexp.MustCompile(`^(?:export\s+)?(?:declare\s+)?(?:const\s+)?enum\s+(\w+)`)},
+		{kind: "namespace", pattern: regexp.MustCompile(`^(?:export\s+)?(?:declare\s+)?(?:namespace|module)\s+([\w.]+)`), container: true},
+		{kind: "function", pattern: regexp.MustCompile(`^(?:export\s+)?(?:const|let|var)\s+(\w+)\s*(?::[^=]+)?=\s*(?:async\s+)?(?:function\b|\([^)]*\)\s*(?::[^=]+)?=>|\w+\s*=>)`)},
+		{kind: "const", pattern: regexp.MustCompile(`^(?:export\s+)?const\s+(\w+)`)},
+		{kind: "method", member: true, pattern: regexp.MustCompile(`^\s*(?:(?:public|private|protected|static|async|readonly|override|abstract|get|set)\s+)*\*?\s*(#?\w+)\s*(?:<[^>]*>)?\s*\([^;]*$`)},
+	},
+}
+
+// notMethods are keywords that look like a method call or declaration at the
+// start of a line
+var notMethods = map[string]bool{
+	"if": true, "for": true, "while": true, "switch": true, "catch": true,
+	"return": true, "function": true, "new": true, "else": true, "do": true,
+	"try": true, "synchronized": true, "super": true, "this": true,
+}
+
+// braceOutline lists the declarations of a brace-delimited language, finding
+// where each ends by counting braces outside strings and comments
+func braceOutline(language braceLanguage, lines []string) []OutlineItem {
+	type open struct {
+		index     int
+		depth     int // Brace depth the declaration starts at
+		container bool
+	}
+	var items []OutlineItem
+	var stack []open
+	var imports []string
+	importStart, importEnd := 0, 0
+	pending := -1 // Declaration whose opening brace has not been seen yet
+	pendingLines, pendingParens := 0, 0
+	depth := 0
+	inComment := false
+	inImport := false // Inside an import spread over several lines
+
+	for i, text := range lines {
+		n := i + 1
+		code := stripCode(text, &inComment)
+		trimmed := strings.TrimSpace(code)
+		startDepth := depth
+
+		if inImport {
+			importEnd = n
+			if match := jsImportFrom.FindStringSubmatch(text); match != nil {
+				imports = append(imports, match[1])
+				inImport = false
+			}
+		}
+
+		// Declarations are looked for at the top level and directly inside containers
+		inContainer := len(stack) > 0 && stack[len(stack)-1].container && startDepth == stack[len(stack)-1].depth+1
+		topLevel := len(stack) == 0 && startDepth == 0
+		if pending < 0 && trimmed != "" && (topLevel || inContainer) {
+			if topLevel && language.imports != nil && language.imports.MatchString(text) {
+				for _, name := range language.imports.FindStringSubmatch(text)[1:] {
+					if name == "import" {
+						inImport = true
+					} else if name != "" {
+						imports = append(imports, strings.TrimRight(name, ":"))
+					} else {
+						continue
+					}
+					break
+				}
+				if importStart == 0 {
+					importStart = n
+				}
+				importEnd = n
+			} else if rule, name := matchOutlineRule(language.rules, code, inContainer); rule != nil {
+				items = append(items, OutlineItem{
+					Kind:      rule.kind,
+					Name:      name,
+					Signature: strings.TrimSpace(strings.TrimSuffix(trimmed, "{")),
+					Start:     n,
+					End:       n,
+					Depth:     len(stack),
+				})
+				stack = append(stack, open{index: len(items) - 1, depth: startDepth, container: rule.container})
+				pending, pendingLines, pendingParens = len(stack)-1, 0, 0
+			}
+		}
+
+		depth += strings.Count(code, "{") - strings.Count(code, "}")
+		if depth < 0 {
+			depth = 0
+		}
+
+		// A declaration whose line ends without opening a body, such as a
+		// type alias or an abstract method, ends where its statement does
+		if pending >= 0 {
+			entry := stack[pending]
+			pendingParens += strings.Count(code, "(") + strings.Count(code, "[") - strings.Count(code, ")") - strings.Count(code, "]")
+			switch {
+			case depth > entry.depth:
+				pending = -1
+			case pendingParens <= 0 && !continuesStatement(trimmed), pendingLines >= maxSignatureLines:
+				items[entry.index].End = n
+				stack = stack[:pending]
+				pending = -1
+			default:
+				pendingLines++
+				continue
+			}
+		}
+
+		for len(stack) > 0 && depth <= stack[len(stack)-1].depth {
+			items[stack[len(stack)-1].index].End = n
+			stack = stack[:len(stack)-1]
+		}
+	}
+	for _, entry := range stack {
+		items[entry.index].End = len(lines)
+	}
+
+	if len(imports) > 0 {
+		items = append([]OutlineItem{importsItem(imports, importStart, importEnd)}, items...)
+	}
+	return items
+}
+
+// matchOutlineRule returns the first rule matching a line and the name it
+// captures. Member rules are only tried inside containers.
+func matchOutlineRule(rules []outlineRule, code string, inContainer bool) (*outlineRule, string) {
+	for i := range rules {
+		rule := &rules[i]
+		if rule.member && !inContainer {
+			continue
+		}
+		match := rule.pattern.FindStringSubmatch(code)
+		if match == nil {
+			continue
+		}
+		name := match[len(match)-1]
+		if rule.member && notMethods[name] {
+			continue
+		}
+		return rule, name
+	}
+	return nil, ""
+}
+
+// continuesStatement reports whether a line ends in a way that carries the
+// statement onto the next line, such as an operator or a comma
+func continuesStatement(line string) bool {
+	if line == "" {
+		return true
+	}
+	for _, suffix := range []string{",", "=", "=>", "->", ":", "|", "&", "+", "-", "<", "?", "."} {
+		if strings.HasSuffix(line, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// stripCode blanks out string literals and comments so the braces inside them
+// are not counted. Block comments can span lines, tracked by inComment.
+func stripCode(line string, inComment *bool) string {
+	var b strings.Builder
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case *inComment:
+			if c == '*' && i+1 < len(line) && line[i+1] == '/' {
+				*inComment = false
+				i++
+			}
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+				b.WriteByte(c)
+			}
+		case c == '/' && i+1 < len(line) && line[i+1] == '/':
+			return b.String()
+		case c == '/' && i+1 < len(line) && line[i+1] == '*':
+			*inComment = true
+			i++
+		case c == '"' || c == '\'' || c == '`':
+			quote = c
+			b.WriteByte(c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// signatureLine returns a declaration's first line without the brace that
+// opens its body
+func signatureLine(lines []string, n int) string {
+	if n < 1 || n > len(lines) {
+		return ""
+	}
+	return clipSearchLine(strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(lines[n-1]), "{")))
+}
+
+// importsItem gathers a file's imports into one item
+func importsItem(imports []string, start, end int) OutlineItem {
+	return OutlineItem{
+		Kind:      "import",
+		Name:      strings.Join(imports, ", "),
+		Signature: "imports: " + listNames(imports, maxOutlineImports),
+		Start:     start,
+		End:       end,
+	}
+}
+
+// listNames joins names, cutting the list at limit
+func listNames(names []string, limit int) string {
+	if len(names) <= limit {
+		return strings.Join(names, ", ")
+	}
+	return fmt.Sprintf("%s, ... and %d more", strings.Join(names[:limit], ", "), len(names)-limit)
+}
+
+// FormatOutline lists an outline's items as start-end: signature, indented
+// by nesting. Approximate end lines are marked with ~.
+func FormatOutline(path string, outline *Outline) string {
+	var b strings.Builder
+	note := "line ranges are inclusive"
+	end := ""
+	if outline.Approximate {
+		note = "line ranges are inclusive; ~ marks an end line found line by line, which may be off for nested or multi-line declarations"
+		end = "~"
+	}
+	b.WriteString(fmt.Sprintf("Outline of %s (%s, %d lines; %s):", path, outline.Language, outline.Lines, note))
+	if len(outline.Items) == 0 {
+		b.WriteString("\n(no declarations found)")
+	}
+	for i, item := range outline.Items {
+		if i == maxOutlineItems {
+			b.WriteString(fmt.Sprintf("\n... and %d more", len(outline.Items)-maxOutlineItems))
+			break
+		}
+		lines := fmt.Sprintf("%d", item.Start)
+		if item.End > item.Start {
+			lines = fmt.Sprintf("%d-%s%d", item.Start, end, item.End)
+		}
+		b.WriteString(fmt.Sprintf("\n%s%s: %s", strings.Repeat("  ", item.Depth), lines, item.Signature))
+	}
+	return b.String()
+}
+
+// getFileOutline implements the get_file_outline tool
+func (f *FileOperations) getFileOutline(filePath string) (string, error) {
+	path, err := NormalizePath(filePath)
+	if err != nil {
+		return "", fmt.Errorf("normalizing path: %w", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("reading file: %w", err)
+	}
+	outline, err := ParseOutline(path, data)
+	if err != nil {
+		return "", fmt.Errorf("%w; use document_symbols or search_files instead", err)
+	}
+	return FormatOutline(filePath, outline), nil
+}
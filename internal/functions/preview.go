@@ -0,0 +1,112 @@
+package functions
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/alchemy-labs-co/riptide/internal/api"
+)
+
+// FileChange is what a write tool would leave in one file
+type FileChange struct {
+	Path    string // Normalized absolute path
+	Before  string // Current content, empty for a new file
+	After   string
+	Created bool // The file does not exist yet
+}
+
+// Diff returns the change as a unified diff labelled with the given path
+func (c FileChange) Diff(path string) string {
+	return UnifiedDiff(path, c.Before, c.After, c.Created)
+}
+
+// PreviewWrite works out what a create_file, create_multiple_files or edit_file
+// call would write, without touching the disk. It fails with the error the tool
+// itself would return, so a call that cannot succeed is never offered for approval.
+func (f *FileOperations) PreviewWrite(toolCall api.ToolCall) ([]FileChange, error) {
+	if err := api.ValidateToolArguments(toolCall.Function.Name, toolCall.Function.Arguments); err != nil {
+		return nil, err
+	}
+
+	var args api.FileOperationArgs
+	if arguments := strings.TrimSpace(toolCall.Function.Arguments); arguments != "" {
+		if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+			return nil, fmt.Errorf("parsing arguments: %w", err)
+		}
+	}
+
+	switch toolCall.Function.Name {
+	case "create_file":
+		change, err := f.previewCreate(args.FilePath, args.Content)
+		if err != nil {
+			return nil, err
+		}
+		return []FileChange{change}, nil
+	case "create_multiple_files":
+		var changes []FileChange
+		for _, file := range args.Files {
+			change, err := f.previewCreate(file.Path, file.Content)
+			if err != nil {
+				return nil, fmt.Errorf("creating file '%s': %w", file.Path, err)
+			}
+			changes = append(changes, change)
+		}
+		return changes, nil
+	case "edit_file":
+		path, before, after, _, err := f.editedContent(args.FilePath, args.OriginalSnippet, args.NewSnippet)
+		if err != nil {
+			return nil, err
+		}
+		return []FileChange{{Path: path, Before: before, After: after}}, nil
+	default:
+		return nil, fmt.Errorf("%s does not write files", toolCall.Function.Name)
+	}
+}
+
+// previewCreate returns the change creating or overwriting a file would make
+func (f *FileOperations) previewCreate(filePath, content string) (FileChange, error) {
+	normalizedPath, err := NormalizePath(filePath)
+	if err != nil {
+		return FileChange{}, fmt.Errorf("normalizing path: %w", err)
+	}
+
+	maxSize := f.config.FileOperations.MaxFileSizeMB * 1024 * 1024
+	if len(content) > maxSize {
+		return FileChange{}, fmt.Errorf("file content exceeds %dMB size limit", f.config.FileOperations.MaxFileSizeMB)
+	}
+
+	existing, err := os.ReadFile(normalizedPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return FileChange{Path: normalizedPath, After: content, Created: true}, nil
+	}
+	if err != nil {
+		return FileChange{}, fmt.Errorf("reading file: %w", err)
+	}
+	return FileChange{Path: normalizedPath, Before: string(existing), After: content}, nil
+}
+
+// ApplyChanges writes the given contents, as approved or edited by the user, and
+// records them in the write ledger under the tool that proposed them
+func (f *FileOperations) ApplyChanges(tool string, changes []FileChange) (string, error) {
+	f.writeMu.Lock()
+	f.tool = tool
+	f.call++
+	f.writeMu.Unlock()
+
+	var written []string
+	for _, change := range changes {
+		if err := os.MkdirAll(filepath.Dir(change.Path), 0755); err != nil {
+			return "", fmt.Errorf("creating parent directory: %w", err)
+		}
+		if err := f.writeFileAtomic(change.Path, []byte(change.After)); err != nil {
+			return "", fmt.Errorf("writing file: %w", err)
+		}
+		written = append(written, change.Path)
+	}
+	return fmt.Sprintf("Successfully wrote %s", strings.Join(written, ", ")), nil
+}
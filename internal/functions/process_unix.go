@@ -0,0 +1,37 @@
+//go:build !windows
+
+package functions
+
+import (
+	"fmt"
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup starts the command in a new process group
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// terminateProcess signals the command's whole process group, with SIGTERM or,
+// when force is set, SIGKILL
+func terminateProcess(cmd *exec.Cmd, force bool) {
+	signal := syscall.SIGTERM
+	if force {
+		signal = syscall.SIGKILL
+	}
+	syscall.Kill(-cmd.Process.Pid, signal)
+}
+
+// limitMemory wraps args so the program's data segment, which covers heap
+// allocations in Go, Python and Node alike, is capped at megabytes
+func limitMemory(args []string, megabytes int) []string {
+	script := fmt.Sprintf(`ulimit -d %d && exec "$@"`, megabytes*1024)
+	return append([]string{"sh", "-c", script, "sh"}, args...)
+}
+
+// confineProcess does nothing once the process has started; limitMemory has
+// already capped it
+func confineProcess(cmd *exec.Cmd, megabytes int) (func(), error) {
+	return func() {}, nil
+}
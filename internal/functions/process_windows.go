@@ -0,0 +1,68 @@
+//go:build windows
+
+package functions
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// setProcessGroup starts the command in a new process group
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP}
+}
+
+// terminateProcess ends the command and its children with taskkill, which
+// Windows needs to reach the whole tree
+func terminateProcess(cmd *exec.Cmd, force bool) {
+	args := []string{"/T", "/PID", strconv.Itoa(cmd.Process.Pid)}
+	if force {
+		args = append([]string{"/F"}, args...)
+	}
+	exec.Command("taskkill", args...).Run()
+}
+
+// limitMemory returns args unchanged; on Windows the limit is set by
+// confineProcess once the process has started
+func limitMemory(args []string, megabytes int) []string {
+	return args
+}
+
+// confineProcess puts a started process in a job object that caps the memory
+// each of its processes may commit at megabytes, and kills them all when the
+// returned function closes the job. The process runs unconfined for the
+// moment between starting and joining the job.
+func confineProcess(cmd *exec.Cmd, megabytes int) (func(), error) {
+	job, err := windows.CreateJobObject(nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating job object: %w", err)
+	}
+	info := windows.JOBOBJECT_EXTENDED_LIMIT_INFORMATION{
+		BasicLimitInformation: windows.JOBOBJECT_BASIC_LIMIT_INFORMATION{
+			LimitFlags: windows.JOB_OBJECT_LIMIT_PROCESS_MEMORY | windows.JOB_OBJECT_LIMIT_KILL_ON_JOB_CLOSE,
+		},
+		ProcessMemoryLimit: uintptr(megabytes) << 20,
+	}
+	if _, err := windows.SetInformationJobObject(job, windows.JobObjectExtendedLimitInformation,
+		uintptr(unsafe.Pointer(&info)), uint32(unsafe.Sizeof(info))); err != nil {
+		windows.CloseHandle(job)
+		return nil, fmt.Errorf("setting memory limit: %w", err)
+	}
+
+	process, err := windows.OpenProcess(windows.PROCESS_SET_QUOTA|windows.PROCESS_TERMINATE, false, uint32(cmd.Process.Pid))
+	if err != nil {
+		windows.CloseHandle(job)
+		return nil, fmt.Errorf("opening process: %w", err)
+	}
+	defer windows.CloseHandle(process)
+	if err := windows.AssignProcessToJobObject(job, process); err != nil {
+		windows.CloseHandle(job)
+		return nil, fmt.Errorf("assigning process to job object: %w", err)
+	}
+	return func() { windows.CloseHandle(job) }, nil
+}
@@ -0,0 +1,315 @@
+package functions
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// maxProcesses bounds how many background processes may run at once
+	maxProcesses = 8
+
+	// maxProcessLog is how much recent output is kept per process
+	maxProcessLog = 256 * 1024
+
+	// defaultLogLines is how many lines read_process_output returns by default
+	defaultLogLines = 100
+
+	// startupWait is how long start_process watches a new process, so the model
+	// sees immediate failures such as a port already in use
+	startupWait = 2 * time.Second
+
+	// stopGrace is how long a process has to exit after being asked to
+	stopGrace = 5 * time.Second
+)
+
+// processRegistry tracks the long-running processes started by start_process
+// so they can be inspected, stopped and cleaned up on exit
+type processRegistry struct {
+	mu        sync.Mutex
+	processes map[string]*managedProcess
+	closed    bool // Set at exit so no process outlives Riptide
+}
+
+// managedProcess is one background process and its recent output
+type managedProcess struct {
+	name    string
+	command string
+	cmd     *exec.Cmd
+	started time.Time
+	log     *logBuffer
+
+	done     chan struct{} // Closed when the process exits
+	exitCode int
+	exitErr  error
+}
+
+func newProcessRegistry() *processRegistry {
+	return &processRegistry{processes: make(map[string]*managedProcess)}
+}
+
+// startProcess runs command in the background under name and returns its
+// first output
+func (f *FileOperations) startProcess(name, command string) (string, error) {
+	if strings.TrimSpace(command) == "" {
+		return "", fmt.Errorf("command is required")
+	}
+	if name == "" {
+		name = strings.Fields(command)[0]
+	}
+
+	root, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("getting working directory: %w", err)
+	}
+
+	p, err := f.processes.start(name, command, root)
+	if err != nil {
+		return "", err
+	}
+
+	select {
+	case <-p.done:
+		return fmt.Sprintf("Process %s exited immediately (%s)\n\n%s", name, p.status(), p.log.tail(defaultLogLines)), nil
+	case <-time.After(startupWait):
+	}
+
+	result := fmt.Sprintf("Started %s (pid %d): %s\nUse read_process_output to see new output and stop_process to stop it.", name, p.cmd.Process.Pid, command)
+	if output := p.log.tail(defaultLogLines); output != "" {
+		result += "\n\nOutput so far:\n" + output
+	}
+	return result, nil
+}
+
+// readProcessOutput returns the status and recent output of a process, or
+// lists every process when name is empty
+func (f *FileOperations) readProcessOutput(name string, lines int) (string, error) {
+	if name == "" {
+		return f.processes.list(), nil
+	}
+	p, err := f.processes.get(name)
+	if err != nil {
+		return "", err
+	}
+	if lines <= 0 {
+		lines = defaultLogLines
+	}
+
+	output := p.log.tail(lines)
+	if output == "" {
+		output = "(no output yet)"
+	}
+	return fmt.Sprintf("%s: %s\n\n%s", name, p.status(), output), nil
+}
+
+// stopProcess stops a process and returns its last output
+func (f *FileOperations) stopProcess(name string) (string, error) {
+	p, err := f.processes.get(name)
+	if err != nil {
+		return "", err
+	}
+	p.stop()
+	f.processes.remove(name)
+	return fmt.Sprintf("Stopped %s (%s)\n\n%s", name, p.status(), p.log.tail(20)), nil
+}
+
+// start launches a process in its own process group, so stopping it also
+// stops the children it spawns (npm run dev starts node, for example)
+func (r *processRegistry) start(name, command, dir string) (*managedProcess, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return nil, fmt.Errorf("riptide is shutting down")
+	}
+	if existing, ok := r.processes[name]; ok {
+		if existing.running() {
+			return nil, fmt.Errorf("process %s is already running; stop it first or choose another name", name)
+		}
+		delete(r.processes, name)
+	}
+	running := 0
+	for _, p := range r.processes {
+		if p.running() {
+			running++
+		}
+	}
+	if running >= maxProcesses {
+		return nil, fmt.Errorf("%d processes are already running; stop one first", running)
+	}
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command)
+	} else {
+		cmd = exec.Command("sh", "-c", command)
+	}
+	cmd.Dir = dir
+	log := &logBuffer{}
+	cmd.Stdout = log
+	cmd.Stderr = log
+	setProcessGroup(cmd)
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("starting %s: %w", name, err)
+	}
+
+	p := &managedProcess{
+		name:    name,
+		command: command,
+		cmd:     cmd,
+		started: time.Now(),
+		log:     log,
+		done:    make(chan struct{}),
+	}
+	go func() {
+		p.exitErr = cmd.Wait()
+		p.exitCode = cmd.ProcessState.ExitCode()
+		close(p.done)
+	}()
+
+	r.processes[name] = p
+	return p, nil
+}
+
+// get returns a registered process
+func (r *processRegistry) get(name string) (*managedProcess, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p, ok := r.processes[name]
+	if !ok {
+		return nil, fmt.Errorf("no process named %s (see read_process_output without a name for the list)", name)
+	}
+	return p, nil
+}
+
+// remove forgets a process
+func (r *processRegistry) remove(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.processes, name)
+}
+
+// list describes every registered process
+func (r *processRegistry) list() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.processes) == 0 {
+		return "No background processes."
+	}
+	names := make([]string, 0, len(r.processes))
+	for name := range r.processes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		p := r.processes[name]
+		b.WriteString(fmt.Sprintf("%s: %s — %s\n", name, p.status(), p.command))
+	}
+	return b.String()
+}
+
+// stopAll stops every running process; it is called when Riptide exits
+func (r *processRegistry) stopAll() {
+	r.mu.Lock()
+	processes := make([]*managedProcess, 0, len(r.processes))
+	for _, p := range r.processes {
+		processes = append(processes, p)
+	}
+	r.processes = make(map[string]*managedProcess)
+	r.closed = true
+	r.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, p := range processes {
+		wg.Add(1)
+		go func(p *managedProcess) {
+			defer wg.Done()
+			p.stop()
+		}(p)
+	}
+	wg.Wait()
+}
+
+// running reports whether the process has not exited
+func (p *managedProcess) running() bool {
+	select {
+	case <-p.done:
+		return false
+	default:
+		return true
+	}
+}
+
+// status describes whether the process is running or how it exited
+func (p *managedProcess) status() string {
+	if p.running() {
+		return fmt.Sprintf("running for %s", time.Since(p.started).Round(time.Second))
+	}
+	if p.exitCode >= 0 {
+		return fmt.Sprintf("exited with code %d", p.exitCode)
+	}
+	return fmt.Sprintf("exited (%v)", p.exitErr)
+}
+
+// stop asks the process group to exit and kills it after stopGrace
+func (p *managedProcess) stop() {
+	if !p.running() {
+		return
+	}
+	terminateProcess(p.cmd, false)
+	select {
+	case <-p.done:
+	case <-time.After(stopGrace):
+		terminateProcess(p.cmd, true)
+		<-p.done
+	}
+}
+
+// logBuffer keeps the most recent output of a process
+type logBuffer struct {
+	mu   sync.Mutex
+	data []byte
+}
+
+func (l *logBuffer) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.data = append(l.data, p...)
+	if excess := len(l.data) - maxProcessLog; excess > 0 {
+		l.data = l.data[excess:]
+		// Drop the partial first line
+		if i := bytes.IndexByte(l.data, '\n'); i >= 0 {
+			l.data = l.data[i+1:]
+		}
+	}
+	return len(p), nil
+}
+
+// tail returns the last n lines of output
+func (l *logBuffer) tail(n int) string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	text := strings.TrimRight(string(l.data), "\n")
+	if text == "" {
+		return ""
+	}
+	lines := strings.Split(text, "\n")
+	if len(lines) > n {
+		lines = append([]string{fmt.Sprintf("... (%d earlier lines)", len(lines)-n)}, lines[len(lines)-n:]...)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -2,9 +2,15 @@ package functions
 
 import (
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"runtime"
+	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/alchemy-labs-co/riptide/internal/config"
 )
@@ -33,8 +39,37 @@ func NewDirectoryScanner(cfg *config.Config) *DirectoryScanner {
 	}
 }
 
+// Directory scans check files concurrently: one goroutine walks the tree and
+// filters by name, while a pool of workers reads each remaining file's size and
+// first bytes, which is where a scan of a large tree spends its time
+const (
+	maxScanWorkers       = 16
+	scanProgressInterval = 100 * time.Millisecond
+)
+
+// ScanProgress reports how far a directory scan has got
+type ScanProgress struct {
+	Found   int // Files the walk has reached
+	Checked int // Files whose checks have finished
+	Added   int
+}
+
+// fileCheck is the outcome of checking one file found by a directory scan
+type fileCheck struct {
+	path string
+	skip string // Why the file is skipped; empty when it is added
+	err  error
+}
+
 // ScanDirectory scans a directory and returns the results
 func (s *DirectoryScanner) ScanDirectory(dirPath string) (*ScanResult, error) {
+	return s.ScanDirectoryWithProgress(dirPath, nil)
+}
+
+// ScanDirectoryWithProgress scans a directory like ScanDirectory, calling
+// progress, if it is not nil, every scanProgressInterval while the scan runs and
+// once when it ends. Added and skipped files are listed in path order.
+func (s *DirectoryScanner) ScanDirectoryWithProgress(dirPath string, progress func(ScanProgress)) (*ScanResult, error) {
 	normalizedPath, err := NormalizePath(dirPath)
 	if err != nil {
 		return nil, fmt.Errorf("normalizing directory path: %w", err)
@@ -55,10 +90,49 @@ func (s *DirectoryScanner) ScanDirectory(dirPath string) (*ScanResult, error) {
 		Errors:       make([]error, 0),
 	}
 
+	candidates := make(chan string, 256)
+	checks := make(chan fileCheck, 256)
+
+	// Workers check the files that passed the name filters
+	var workers sync.WaitGroup
+	for range min(runtime.NumCPU()*2, maxScanWorkers) {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for path := range candidates {
+				checks <- s.checkFile(path)
+			}
+		}()
+	}
+
+	// Only the collector touches the result until the scan is over
+	var found atomic.Int64
+	collected := make(chan struct{})
+	go func() {
+		defer close(collected)
+		checked := 0
+		lastReport := time.Now()
+		for check := range checks {
+			checked++
+			if check.err != nil {
+				result.Errors = append(result.Errors, check.err)
+			}
+			if check.skip != "" {
+				result.SkippedFiles = append(result.SkippedFiles, check.path+" ("+check.skip+")")
+			} else if check.err == nil {
+				result.AddedFiles = append(result.AddedFiles, check.path)
+			}
+			if progress != nil && time.Since(lastReport) >= scanProgressInterval {
+				progress(ScanProgress{Found: int(found.Load()), Checked: checked, Added: len(result.AddedFiles)})
+				lastReport = time.Now()
+			}
+		}
+	}()
+
 	// Walk the directory
-	err = filepath.Walk(normalizedPath, func(path string, info os.FileInfo, err error) error {
+	err = filepath.WalkDir(normalizedPath, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
-			result.Errors = append(result.Errors, fmt.Errorf("accessing %s: %w", path, err))
+			checks <- fileCheck{err: fmt.Errorf("accessing %s: %w", path, err)}
 			return nil // Continue walking
 		}
 
@@ -68,16 +142,16 @@ func (s *DirectoryScanner) ScanDirectory(dirPath string) (*ScanResult, error) {
 		}
 
 		// Handle directories
-		if info.IsDir() {
+		if d.IsDir() {
 			// Skip hidden directories
-			if IsHiddenFile(info.Name()) && path != normalizedPath {
-				result.SkippedFiles = append(result.SkippedFiles, path+" (hidden directory)")
+			if IsHiddenFile(d.Name()) && path != normalizedPath {
+				checks <- fileCheck{path: path, skip: "hidden directory"}
 				return filepath.SkipDir
 			}
 
 			// Skip excluded directories
-			if s.excludedFiles[info.Name()] {
-				result.SkippedFiles = append(result.SkippedFiles, path+" (excluded directory)")
+			if s.excludedFiles[d.Name()] || s.config.FileOperations.IsExcluded(d.Name()) {
+				checks <- fileCheck{path: path, skip: "excluded directory"}
 				return filepath.SkipDir
 			}
 
@@ -86,57 +160,136 @@ func (s *DirectoryScanner) ScanDirectory(dirPath string) (*ScanResult, error) {
 
 		// Handle files
 		result.TotalScanned++
+		found.Add(1)
+
+		switch {
+		case IsHiddenFile(d.Name()):
+			checks <- fileCheck{path: path, skip: "hidden file"}
+		case s.excludedFiles[d.Name()] || s.config.FileOperations.IsExcluded(d.Name()):
+			checks <- fileCheck{path: path, skip: "excluded file"}
+		case s.excludedExtensions[strings.ToLower(filepath.Ext(d.Name()))]:
+			checks <- fileCheck{path: path, skip: "excluded extension"}
+		default:
+			candidates <- path
+		}
+		return nil
+	})
 
-		// Skip hidden files
-		if IsHiddenFile(info.Name()) {
-			result.SkippedFiles = append(result.SkippedFiles, path+" (hidden file)")
-			return nil
+	close(candidates)
+	workers.Wait()
+	close(checks)
+	<-collected
+
+	if err != nil && err != filepath.SkipAll {
+		return nil, fmt.Errorf("walking directory: %w", err)
+	}
+
+	sort.Strings(result.AddedFiles)
+	sort.Strings(result.SkippedFiles)
+	if progress != nil {
+		progress(ScanProgress{Found: result.TotalScanned, Checked: result.TotalScanned, Added: len(result.AddedFiles)})
+	}
+	return result, nil
+}
+
+// Excludes reports whether scans skip a file or directory by its name: hidden
+// names, the built-in and configured exclusions and, for files, the excluded
+// extensions
+func (s *DirectoryScanner) Excludes(name string, isDir bool) bool {
+	if IsHiddenFile(name) || s.excludedFiles[name] || s.config.FileOperations.IsExcluded(name) {
+		return true
+	}
+	return !isDir && s.excludedExtensions[strings.ToLower(filepath.Ext(name))]
+}
+
+// SkipReason reports why a directory scan would skip a file it reached: too
+// large, binary or unreadable. It is empty when the file would be added.
+func (s *DirectoryScanner) SkipReason(path string) string {
+	check := s.checkFile(path)
+	if check.skip == "" && check.err != nil {
+		return check.err.Error()
+	}
+	return check.skip
+}
+
+// checkFile checks what a directory scan cannot tell from a file's name: that
+// it is within the size limit and not binary
+func (s *DirectoryScanner) checkFile(path string) fileCheck {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return fileCheck{path: path, err: fmt.Errorf("accessing %s: %w", path, err)}
+	}
+
+	// Skip files that are too large
+	maxSize := int64(s.config.FileOperations.MaxFileSizeMB * 1024 * 1024)
+	if info.Size() > maxSize {
+		return fileCheck{path: path, skip: fmt.Sprintf("exceeds %dMB limit", s.config.FileOperations.MaxFileSizeMB)}
+	}
+
+	// Skip binary files
+	isBinary, err := IsBinaryFile(path, s.config.FileOperations.BinaryPeekSize)
+	if err != nil {
+		return fileCheck{path: path, skip: "error checking file type", err: fmt.Errorf("checking if %s is binary: %w", path, err)}
+	}
+	if isBinary {
+		return fileCheck{path: path, skip: "binary file"}
+	}
+
+	// File passed all checks
+	return fileCheck{path: path}
+}
+
+// walkTextFiles calls fn for every file under root that a directory scan would
+// add: hidden, excluded, oversized and binary files are skipped, as are
+// unreadable entries. fn may return filepath.SkipAll to stop early. A root that
+// is a file is passed to fn directly.
+func walkTextFiles(cfg *config.Config, root string, fn func(path string) error) error {
+	info, err := os.Stat(root)
+	if err != nil {
+		return fmt.Errorf("accessing path: %w", err)
+	}
+	if !info.IsDir() {
+		if err := fn(root); err != nil && err != filepath.SkipAll {
+			return err
 		}
+		return nil
+	}
 
-		// Skip excluded files
-		if s.excludedFiles[info.Name()] {
-			result.SkippedFiles = append(result.SkippedFiles, path+" (excluded file)")
-			return nil
+	excludedFiles := config.GetExcludedFiles()
+	excludedExtensions := config.GetExcludedExtensions()
+	maxSize := int64(cfg.FileOperations.MaxFileSizeMB * 1024 * 1024)
+
+	err = filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil // Skip unreadable entries
 		}
 
-		// Skip by extension
-		ext := strings.ToLower(filepath.Ext(info.Name()))
-		if s.excludedExtensions[ext] {
-			result.SkippedFiles = append(result.SkippedFiles, path+" (excluded extension)")
+		if info.IsDir() {
+			if path != root && (IsHiddenFile(info.Name()) || excludedFiles[info.Name()] || cfg.FileOperations.IsExcluded(info.Name())) {
+				return filepath.SkipDir
+			}
 			return nil
 		}
 
-		// Skip files that are too large
-		maxSize := int64(s.config.FileOperations.MaxFileSizeMB * 1024 * 1024)
-		if info.Size() > maxSize {
-			result.SkippedFiles = append(result.SkippedFiles,
-				fmt.Sprintf("%s (exceeds %dMB limit)", path, s.config.FileOperations.MaxFileSizeMB))
+		if IsHiddenFile(info.Name()) || excludedFiles[info.Name()] || cfg.FileOperations.IsExcluded(info.Name()) {
 			return nil
 		}
-
-		// Skip binary files
-		isBinary, err := IsBinaryFile(path, s.config.FileOperations.BinaryPeekSize)
-		if err != nil {
-			result.Errors = append(result.Errors, fmt.Errorf("checking if %s is binary: %w", path, err))
-			result.SkippedFiles = append(result.SkippedFiles, path+" (error checking file type)")
+		if excludedExtensions[strings.ToLower(filepath.Ext(info.Name()))] {
 			return nil
 		}
-		if isBinary {
-			result.SkippedFiles = append(result.SkippedFiles, path+" (binary file)")
+		if info.Size() > maxSize {
+			return nil
+		}
+		if isBinary, err := IsBinaryFile(path, cfg.FileOperations.BinaryPeekSize); err != nil || isBinary {
 			return nil
 		}
 
-		// File passed all checks
-		result.AddedFiles = append(result.AddedFiles, path)
-
-		return nil
+		return fn(path)
 	})
-
 	if err != nil && err != filepath.SkipAll {
-		return nil, fmt.Errorf("walking directory: %w", err)
+		return fmt.Errorf("walking directory: %w", err)
 	}
-
-	return result, nil
+	return nil
 }
 
 // ReadFiles reads the content of multiple files and returns them as a map
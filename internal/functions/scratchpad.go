@@ -0,0 +1,191 @@
+package functions
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// snippetTimeout bounds how long a snippet may run
+	snippetTimeout = 10 * time.Second
+
+	// snippetBuildTimeout bounds compiling a Go snippet
+	snippetBuildTimeout = time.Minute
+
+	// snippetMemoryMB caps the memory a snippet may allocate
+	snippetMemoryMB = 512
+
+	// maxSnippetOutput is how much of the end of a snippet's output is returned
+	maxSnippetOutput = 8000
+)
+
+// Snippet languages accepted by execute_snippet
+const (
+	snippetGo     = "go"
+	snippetPython = "python"
+	snippetNode   = "javascript"
+)
+
+// snippetLanguages maps accepted language names to a snippet language
+var snippetLanguages = map[string]string{
+	"go": snippetGo, "golang": snippetGo,
+	"python": snippetPython, "python3": snippetPython, "py": snippetPython,
+	"javascript": snippetNode, "js": snippetNode, "node": snippetNode,
+}
+
+// executeSnippet runs a Go, Python or JavaScript snippet in a temporary
+// directory outside the workspace with time and memory limits, and returns its
+// exit status and output
+func (f *FileOperations) executeSnippet(language, code string) (string, error) {
+	lang, ok := snippetLanguages[strings.ToLower(strings.TrimSpace(language))]
+	if !ok {
+		return "", fmt.Errorf("unsupported language %q (use go, python or javascript)", language)
+	}
+	if strings.TrimSpace(code) == "" {
+		return "", fmt.Errorf("no code given")
+	}
+
+	dir, err := os.MkdirTemp("", "riptide-snippet-*")
+	if err != nil {
+		return "", fmt.Errorf("creating snippet directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	var args []string
+	switch lang {
+	case snippetGo:
+		binary, buildOutput, err := buildGoSnippet(dir, code)
+		if err != nil {
+			return "", err
+		}
+		if binary == "" {
+			return fmt.Sprintf("Go snippet did not compile:\n\n%s", tailOutput(buildOutput, maxSnippetOutput)), nil
+		}
+		args = []string{binary}
+
+	case snippetPython:
+		python := "python3"
+		if _, err := exec.LookPath(python); err != nil {
+			python = "python"
+		}
+		path := filepath.Join(dir, "snippet.py")
+		if err := os.WriteFile(path, []byte(code), 0644); err != nil {
+			return "", fmt.Errorf("writing snippet: %w", err)
+		}
+		args = []string{python, path}
+
+	case snippetNode:
+		path := filepath.Join(dir, "snippet.js")
+		if err := os.WriteFile(path, []byte(code), 0644); err != nil {
+			return "", fmt.Errorf("writing snippet: %w", err)
+		}
+		// V8 reserves far more address space than it uses, so its heap is capped directly as well
+		args = []string{"node", fmt.Sprintf("--max-old-space-size=%d", snippetMemoryMB), path}
+	}
+
+	if _, err := exec.LookPath(args[0]); err != nil && lang != snippetGo {
+		return "", fmt.Errorf("%s is not installed or not in PATH", args[0])
+	}
+
+	output, elapsed, status, err := runLimited(dir, args, snippetTimeout, snippetMemoryMB)
+	if err != nil {
+		return "", err
+	}
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("Ran %s snippet in %s — %s\n\n", lang, elapsed, status))
+	result.WriteString(tailOutput(output, maxSnippetOutput))
+	return result.String(), nil
+}
+
+// buildGoSnippet compiles code as a standalone module in dir and returns the
+// binary path, or an empty path and the compiler output when it does not build
+func buildGoSnippet(dir, code string) (string, string, error) {
+	if _, err := exec.LookPath("go"); err != nil {
+		return "", "", fmt.Errorf("go is not installed or not in PATH")
+	}
+	if !strings.Contains(code, "package ") {
+		code = "package main\n\n" + code
+	}
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte(code), 0644); err != nil {
+		return "", "", fmt.Errorf("writing snippet: %w", err)
+	}
+
+	// go mod init records the installed Go version, so new language features work
+	binary := filepath.Join(dir, "snippet")
+	for _, args := range [][]string{
+		{"go", "mod", "init", "snippet"},
+		{"go", "build", "-o", binary, "."},
+	} {
+		output, _, status, err := runLimited(dir, args, snippetBuildTimeout, 0)
+		if err != nil {
+			return "", "", err
+		}
+		if status != "SUCCEEDED" {
+			return "", output, nil
+		}
+	}
+	return binary, "", nil
+}
+
+// runLimited runs args in dir, killing the process tree after timeout and,
+// when memoryMB is set, capping its memory. It returns the combined output,
+// how long it ran and a status of SUCCEEDED, FAILED or TIMED OUT. A memory
+// limit that cannot be applied is an error rather than a run without one.
+func runLimited(dir string, args []string, timeout time.Duration, memoryMB int) (string, time.Duration, string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if memoryMB > 0 {
+		args = limitMemory(args, memoryMB)
+	}
+
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Dir = dir
+	// Keep snippet builds off the network and independent of any workspace
+	cmd.Env = append(os.Environ(), "GOFLAGS=", "GOWORK=off", "GOTOOLCHAIN=local", "GOPROXY=off")
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	setProcessGroup(cmd)
+	cmd.Cancel = func() error {
+		terminateProcess(cmd, true)
+		return nil
+	}
+	cmd.WaitDelay = time.Second
+
+	start := time.Now()
+	if err := cmd.Start(); err != nil {
+		return "", 0, "", fmt.Errorf("running %s: %w", args[0], err)
+	}
+	if memoryMB > 0 {
+		release, err := confineProcess(cmd, memoryMB)
+		if err != nil {
+			terminateProcess(cmd, true)
+			cmd.Wait()
+			return "", 0, "", fmt.Errorf("limiting snippet memory: %w", err)
+		}
+		defer release()
+	}
+	runErr := cmd.Wait()
+	elapsed := time.Since(start).Round(10 * time.Millisecond)
+
+	status := "SUCCEEDED"
+	if ctx.Err() == context.DeadlineExceeded {
+		status = fmt.Sprintf("TIMED OUT after %s", timeout)
+	} else if runErr != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(runErr, &exitErr) {
+			return "", elapsed, "", fmt.Errorf("running %s: %w", args[0], runErr)
+		}
+		status = fmt.Sprintf("FAILED (%v)", runErr)
+	}
+	return output.String(), elapsed, status, nil
+}
@@ -0,0 +1,248 @@
+package functions
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/alchemy-labs-co/riptide/internal/config"
+)
+
+const (
+	// maxSearchMatches caps how many matching lines SearchFiles returns
+	maxSearchMatches = 200
+
+	// maxSearchLineLength caps the text kept for each reported line
+	maxSearchLineLength = 300
+
+	// defaultSearchContext is how many lines around each match are shown by default
+	defaultSearchContext = 2
+
+	// maxSearchContext caps the context_lines argument
+	maxSearchContext = 10
+)
+
+// SearchOptions configures a SearchFiles run
+type SearchOptions struct {
+	Pattern    string
+	Literal    bool   // Match Pattern as plain text instead of a regular expression
+	IgnoreCase bool   // Match regardless of case
+	Include    string // Only search files whose name or relative path matches this glob
+	Context    int    // Lines of context around each match
+}
+
+// SearchLine is one line of a search result: a match or the context around one
+type SearchLine struct {
+	Line  int
+	Text  string
+	Match bool
+}
+
+// SearchFileResult holds the matches found in one file, in line order
+type SearchFileResult struct {
+	Path    string // Relative to the searched root
+	Lines   []SearchLine
+	Matches int
+}
+
+// SearchResult is the outcome of a SearchFiles run
+type SearchResult struct {
+	Files     []SearchFileResult
+	Matches   int
+	Truncated bool // More than maxSearchMatches matches were found
+}
+
+// searchPattern compiles the pattern the options describe
+func searchPattern(opts SearchOptions) (*regexp.Regexp, error) {
+	if opts.Pattern == "" {
+		return nil, fmt.Errorf("pattern is empty")
+	}
+	pattern := opts.Pattern
+	if opts.Literal {
+		pattern = regexp.QuoteMeta(pattern)
+	}
+	if opts.IgnoreCase {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regular expression (set literal to search for the text as is): %w", err)
+	}
+	return re, nil
+}
+
+// SearchFiles walks root for lines matching the pattern, skipping the same files
+// as directory scans, and keeps the lines of context around each match
+func SearchFiles(cfg *config.Config, root string, opts SearchOptions) (*SearchResult, error) {
+	pattern, err := searchPattern(opts)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Include != "" {
+		if _, err := filepath.Match(opts.Include, ""); err != nil {
+			return nil, fmt.Errorf("invalid include glob %q: %w", opts.Include, err)
+		}
+	}
+	context := min(max(opts.Context, 0), maxSearchContext)
+
+	normalizedRoot, err := NormalizePath(root)
+	if err != nil {
+		return nil, fmt.Errorf("normalizing path: %w", err)
+	}
+	result := &SearchResult{}
+
+	searchFile := func(path string) error {
+		rel, err := filepath.Rel(normalizedRoot, path)
+		if err != nil || rel == "." {
+			rel = filepath.Base(path)
+		}
+		rel = filepath.ToSlash(rel)
+		if !includedInSearch(opts.Include, rel) {
+			return nil
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			return nil
+		}
+		defer file.Close()
+
+		found := SearchFileResult{Path: rel}
+		var before []SearchLine // The lines just before the current one
+		after := 0              // Context lines still to keep after the last match
+		last := 0               // The last line number kept
+
+		scanner := bufio.NewScanner(file)
+		scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+		for line := 1; scanner.Scan(); line++ {
+			text := scanner.Text()
+			if !pattern.MatchString(text) {
+				if after > 0 {
+					found.Lines = append(found.Lines, SearchLine{Line: line, Text: clipSearchLine(text)})
+					last = line
+					after--
+				} else if context > 0 {
+					before = append(before, SearchLine{Line: line, Text: clipSearchLine(text)})
+					if len(before) > context {
+						before = before[1:]
+					}
+				}
+				continue
+			}
+
+			if result.Matches >= maxSearchMatches {
+				result.Truncated = true
+				break
+			}
+			for _, kept := range before {
+				if kept.Line > last {
+					found.Lines = append(found.Lines, kept)
+				}
+			}
+			before = before[:0]
+			found.Lines = append(found.Lines, SearchLine{Line: line, Text: clipSearchLine(text), Match: true})
+			found.Matches++
+			result.Matches++
+			last = line
+			after = context
+		}
+
+		if found.Matches > 0 {
+			result.Files = append(result.Files, found)
+		}
+		if result.Truncated {
+			return filepath.SkipAll
+		}
+		return nil
+	}
+
+	if err := walkTextFiles(cfg, normalizedRoot, searchFile); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// includedInSearch reports whether a relative path passes the include glob,
+// which may match either the file name or the whole path
+func includedInSearch(include, rel string) bool {
+	if include == "" {
+		return true
+	}
+	if ok, _ := filepath.Match(include, filepath.Base(rel)); ok {
+		return true
+	}
+	ok, _ := filepath.Match(include, rel)
+	return ok
+}
+
+// clipSearchLine shortens a long line such as minified code to maxSearchLineLength
+func clipSearchLine(text string) string {
+	if len(text) <= maxSearchLineLength {
+		return text
+	}
+	cut := maxSearchLineLength
+	for cut > 0 && !isRuneStart(text[cut]) {
+		cut--
+	}
+	return text[:cut] + "…"
+}
+
+// isRuneStart reports whether b begins a UTF-8 encoded rune
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
+
+// FormatSearch lists matches grep-style: "path:line: text" for matching lines,
+// "path-line- text" for context, and "--" between groups that are not adjacent
+func FormatSearch(result *SearchResult, opts SearchOptions) string {
+	if result.Matches == 0 {
+		return fmt.Sprintf("No matches for `%s`", opts.Pattern)
+	}
+
+	var b strings.Builder
+	noun := "matches"
+	if result.Matches == 1 {
+		noun = "match"
+	}
+	files := "files"
+	if len(result.Files) == 1 {
+		files = "file"
+	}
+	b.WriteString(fmt.Sprintf("Found %d %s for `%s` in %d %s", result.Matches, noun, opts.Pattern, len(result.Files), files))
+	if result.Truncated {
+		b.WriteString(fmt.Sprintf("; stopped after the first %d (narrow the pattern, path or include)", maxSearchMatches))
+	}
+	b.WriteString(":\n")
+
+	for _, file := range result.Files {
+		b.WriteString("\n")
+		previous := 0
+		for _, line := range file.Lines {
+			if previous > 0 && line.Line > previous+1 {
+				b.WriteString("--\n")
+			}
+			separator := "-"
+			if line.Match {
+				separator = ":"
+			}
+			b.WriteString(fmt.Sprintf("%s%s%d%s %s\n", file.Path, separator, line.Line, separator, line.Text))
+			previous = line.Line
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// searchFiles implements the search_files tool
+func (f *FileOperations) searchFiles(path string, opts SearchOptions) (string, error) {
+	if path == "" {
+		path = "."
+	}
+	result, err := SearchFiles(f.config, path, opts)
+	if err != nil {
+		return "", fmt.Errorf("searching files: %w", err)
+	}
+	return FormatSearch(result, opts), nil
+}
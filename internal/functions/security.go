@@ -39,6 +39,54 @@ func NormalizePath(pathStr string) (string, error) {
 	return cleanPath, nil
 }
 
+// IsWithinRoot reports whether path resolves to root or somewhere beneath it.
+// Symlinks are resolved for the deepest existing ancestor so a link inside the
+// workspace cannot hide a target outside it.
+func IsWithinRoot(root, path string) bool {
+	resolvedRoot, err := resolveExisting(root)
+	if err != nil {
+		return false
+	}
+	resolvedPath, err := resolveExisting(path)
+	if err != nil {
+		return false
+	}
+
+	rel, err := filepath.Rel(resolvedRoot, resolvedPath)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// resolveExisting returns the absolute path with symlinks resolved for the
+// deepest part of it that exists; missing trailing elements are kept as-is
+func resolveExisting(path string) (string, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("resolving absolute path: %w", err)
+	}
+
+	var missing []string
+	current := filepath.Clean(absPath)
+	for {
+		resolved, err := filepath.EvalSymlinks(current)
+		if err == nil {
+			for i := len(missing) - 1; i >= 0; i-- {
+				resolved = filepath.Join(resolved, missing[i])
+			}
+			return resolved, nil
+		}
+
+		parent := filepath.Dir(current)
+		if parent == current {
+			return absPath, nil
+		}
+		missing = append(missing, filepath.Base(current))
+		current = parent
+	}
+}
+
 // IsBinaryFile checks if a file is likely binary by peeking at its content
 func IsBinaryFile(filePath string, peekSize int) (bool, error) {
 	file, err := os.Open(filePath)
@@ -0,0 +1,61 @@
+package functions
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIsWithinRoot(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	if err := os.Mkdir(filepath.Join(root, "src"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(root, "escape")); err != nil {
+		t.Skipf("symlinks unavailable: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		path string
+		want bool
+	}{
+		{"root itself", root, true},
+		{"existing child", filepath.Join(root, "src"), true},
+		{"new file", filepath.Join(root, "src", "new", "file.go"), true},
+		{"sibling", outside, false},
+		{"prefix lookalike", root + "-other", false},
+		{"parent", filepath.Dir(root), false},
+		{"through symlink", filepath.Join(root, "escape", "secret"), false},
+		{"system file", "/etc/passwd", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsWithinRoot(root, tt.path); got != tt.want {
+				t.Errorf("IsWithinRoot(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizePath(t *testing.T) {
+	tests := []struct {
+		path string
+		ok   bool
+	}{
+		{"main.go", true},
+		{"./internal/ui", true},
+		{"/tmp/file", true},
+		{"", false},
+		{"../outside", false},
+		{"a/../../outside", false},
+		{"~/.ssh/id_rsa", false},
+	}
+	for _, tt := range tests {
+		_, err := NormalizePath(tt.path)
+		if (err == nil) != tt.ok {
+			t.Errorf("NormalizePath(%q) error = %v, want ok %v", tt.path, err, tt.ok)
+		}
+	}
+}
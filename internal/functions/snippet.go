@@ -0,0 +1,65 @@
+package functions
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// maxSnippetBytes caps the text an edit may search for; larger edits should
+	// rewrite the file with create_file instead
+	maxSnippetBytes = 256 * 1024
+
+	// maxReportedMatches bounds the search for duplicate matches so a pathological
+	// snippet cannot make an edit scan the file indefinitely
+	maxReportedMatches = 100
+)
+
+// validateSnippets rejects edit inputs that could corrupt a file or stall the search
+func validateSnippets(originalSnippet, newSnippet string, maxFileBytes int) error {
+	if originalSnippet == "" {
+		return fmt.Errorf("original snippet is empty")
+	}
+	if len(originalSnippet) > maxSnippetBytes {
+		return fmt.Errorf("original snippet is %d bytes, over the %d byte limit; use create_file to rewrite large sections", len(originalSnippet), maxSnippetBytes)
+	}
+	if len(newSnippet) > maxFileBytes {
+		return fmt.Errorf("new snippet is %d bytes, over the %d byte file size limit", len(newSnippet), maxFileBytes)
+	}
+	if !utf8.ValidString(originalSnippet) {
+		return fmt.Errorf("original snippet is not valid UTF-8")
+	}
+	if !utf8.ValidString(newSnippet) {
+		return fmt.Errorf("new snippet is not valid UTF-8")
+	}
+	if originalSnippet == newSnippet {
+		return fmt.Errorf("original and new snippets are identical; nothing to change")
+	}
+	return nil
+}
+
+// findSnippet returns the byte offset of the only occurrence of snippet in content.
+// Overlapping occurrences count separately, so "aa" in "aaa" is ambiguous rather
+// than silently matching the first. The count stops at maxReportedMatches.
+func findSnippet(content, snippet string) (int, int) {
+	first := -1
+	count := 0
+
+	for offset := 0; offset <= len(content)-len(snippet) && count < maxReportedMatches; {
+		i := strings.Index(content[offset:], snippet)
+		if i < 0 {
+			break
+		}
+		if first < 0 {
+			first = offset + i
+		}
+		count++
+
+		// Step past the first rune of the match to find overlapping occurrences
+		_, size := utf8.DecodeRuneInString(content[offset+i:])
+		offset += i + size
+	}
+
+	return first, count
+}
@@ -0,0 +1,117 @@
+package functions
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/alchemy-labs-co/riptide/internal/config"
+)
+
+// FuzzFindSnippet checks findSnippet against a brute-force count of every
+// occurrence, overlapping ones included
+func FuzzFindSnippet(f *testing.F) {
+	f.Add("aaa", "aa")
+	f.Add("func main() {}\nfunc main() {}\n", "func main() {}")
+	f.Add("héllo wörld", "ö")
+	f.Add("abc", "abcd")
+	f.Add("\xff\xfe\xff", "\xff")
+	f.Add(strings.Repeat("a", 500), "a")
+
+	f.Fuzz(func(t *testing.T, content, snippet string) {
+		if snippet == "" {
+			return
+		}
+		first, count := findSnippet(content, snippet)
+
+		if count > maxReportedMatches {
+			t.Fatalf("count %d is over the %d limit", count, maxReportedMatches)
+		}
+		if want := strings.Index(content, snippet); first != want {
+			t.Fatalf("first match at %d, want %d", first, want)
+		}
+		if (count == 0) != (first < 0) {
+			t.Fatalf("count %d does not agree with first match at %d", count, first)
+		}
+
+		// The search steps a rune at a time, so compare against every rune
+		// boundary only where runes are well defined
+		if !utf8.ValidString(content) || !utf8.ValidString(snippet) {
+			return
+		}
+		want := 0
+		for i := range content {
+			if strings.HasPrefix(content[i:], snippet) {
+				want++
+			}
+		}
+		if want > maxReportedMatches {
+			want = maxReportedMatches
+		}
+		if count != want {
+			t.Fatalf("counted %d matches, want %d", count, want)
+		}
+	})
+}
+
+// FuzzEditFileRoundTrip checks that a failed edit leaves the file as it was,
+// that an exact match is replaced and nothing else changes, and that editing
+// the replacement back restores the original content
+func FuzzEditFileRoundTrip(f *testing.F) {
+	f.Add("package main\n\nfunc main() {}\n", "func main() {}", "func main() {\n\tprintln()\n}")
+	f.Add("aaa", "aa", "b")
+	f.Add("one\r\ntwo\r\nthree\r\n", "two\nthree", "2\n3")
+	f.Add("x := 1\n", "x := 1", "x := 1")
+	f.Add("text \xff\n", "text", "words")
+	f.Add("héllo", "é", "e")
+
+	f.Fuzz(func(t *testing.T, content, original, replacement string) {
+		fileOps := &FileOperations{config: config.Default()}
+		path := filepath.Join(t.TempDir(), "file.txt")
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		readBack := func() string {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return string(data)
+		}
+
+		index, count := findSnippet(content, original)
+		if _, err := fileOps.editFile(path, original, replacement); err != nil {
+			if got := readBack(); got != content {
+				t.Fatalf("failed edit (%v) changed the file to %q", err, got)
+			}
+			return
+		}
+
+		edited := readBack()
+		if !utf8.ValidString(edited) {
+			t.Fatalf("edit wrote invalid UTF-8: %q", edited)
+		}
+		if count != 1 {
+			// An approximate match replaces the closest region, which has no
+			// simple expected result
+			return
+		}
+		want := content[:index] + replacement + content[index+len(original):]
+		if edited != want {
+			t.Fatalf("edited file is %q, want %q", edited, want)
+		}
+
+		// Editing back only round-trips when the replacement is unambiguous
+		if _, count := findSnippet(edited, replacement); count != 1 {
+			return
+		}
+		if _, err := fileOps.editFile(path, replacement, original); err != nil {
+			t.Fatalf("editing back: %v", err)
+		}
+		if got := readBack(); got != content {
+			t.Fatalf("round trip gave %q, want %q", got, content)
+		}
+	})
+}
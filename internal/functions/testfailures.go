@@ -0,0 +1,179 @@
+package functions
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	// maxTestFailures caps the failing tests listed in a summary
+	maxTestFailures = 20
+
+	// maxFailureDetails caps the message lines kept for each failing test
+	maxFailureDetails = 6
+)
+
+// TestFailure is a failing test, or a package that failed to build, with the
+// first lines of what it reported
+type TestFailure struct {
+	Name    string
+	Details []string
+}
+
+var (
+	// goFailLine matches "--- FAIL: TestName (0.00s)", indented for subtests
+	goFailLine = regexp.MustCompile(`^\s*--- FAIL: (\S+)`)
+
+	// goPackageFail matches the "FAIL	pkg	0.01s" line ending a failed package
+	goPackageFail = regexp.MustCompile(`^FAIL\s+(\S+)`)
+
+	// goCompileError matches a compiler error such as "./x.go:5:2: undefined: y"
+	goCompileError = regexp.MustCompile(`^\S+\.go:\d+(:\d+)?: `)
+
+	// pytestFailLine matches the "FAILED path::test - message" summary lines
+	pytestFailLine = regexp.MustCompile(`^(FAILED|ERROR) (.+)$`)
+
+	// jsFailLine matches Jest's "● Suite › test" headings and the ✕ or ×
+	// marks Jest, Vitest and others put before a failing test
+	jsFailLine = regexp.MustCompile(`^\s*(?:●|✕|×)\s+(.+)$`)
+)
+
+// parseTestFailures picks the failing tests and their messages out of a test
+// run's output. Output it does not recognise gives no failures, leaving the
+// raw output to speak for itself.
+func parseTestFailures(runner, output string) []TestFailure {
+	lines := strings.Split(strings.ReplaceAll(output, "\r\n", "\n"), "\n")
+	switch runner {
+	case runnerGo:
+		return parseGoFailures(lines)
+	case runnerPytest:
+		return parsePytestFailures(lines)
+	default:
+		return parseJSFailures(lines)
+	}
+}
+
+// parseGoFailures reads go test output: each --- FAIL line with the indented
+// messages under it, compiler errors under a "# pkg" heading, and panics. The
+// package is added to the tests once its FAIL line is reached.
+func parseGoFailures(lines []string) []TestFailure {
+	var failures []TestFailure
+	current := -1    // Failure whose details are being collected
+	pending := 0     // First failure not yet given its package
+	collecting := "" // "test", "build" or "panic"
+
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case goFailLine.MatchString(line):
+			failures = append(failures, TestFailure{Name: goFailLine.FindStringSubmatch(line)[1]})
+			current, collecting = len(failures)-1, "test"
+		case strings.HasPrefix(line, "# "):
+			failures = append(failures, TestFailure{Name: "build failed: " + strings.Fields(line[2:])[0]})
+			current, collecting = len(failures)-1, "build"
+		case strings.HasPrefix(line, "panic: "):
+			failures = append(failures, TestFailure{Name: "panic", Details: []string{trimmed}})
+			current, collecting = len(failures)-1, "panic"
+		case goPackageFail.MatchString(line):
+			pkg := goPackageFail.FindStringSubmatch(line)[1]
+			for i := pending; i < len(failures); i++ {
+				if !strings.HasPrefix(failures[i].Name, "build failed") {
+					failures[i].Name += " (" + pkg + ")"
+				}
+			}
+			pending, current, collecting = len(failures), -1, ""
+		case current >= 0 && trimmed != "":
+			keep := false
+			switch collecting {
+			case "test":
+				keep = strings.HasPrefix(line, " ") && !strings.HasPrefix(trimmed, "=== ")
+			case "build":
+				keep = goCompileError.MatchString(trimmed)
+			case "panic":
+				keep = strings.HasSuffix(strings.Fields(trimmed)[0], ".go") || strings.Contains(trimmed, ".go:")
+			}
+			if !keep {
+				if collecting != "panic" {
+					current, collecting = -1, ""
+				}
+				continue
+			}
+			if len(failures[current].Details) < maxFailureDetails {
+				failures[current].Details = append(failures[current].Details, trimmed)
+			}
+		}
+	}
+	return failures
+}
+
+// parsePytestFailures reads the FAILED and ERROR lines of pytest's short test
+// summary, where the message follows the test id after " - "
+func parsePytestFailures(lines []string) []TestFailure {
+	var failures []TestFailure
+	for _, line := range lines {
+		match := pytestFailLine.FindStringSubmatch(strings.TrimSpace(line))
+		if match == nil {
+			continue
+		}
+		name, message, _ := strings.Cut(match[2], " - ")
+		failure := TestFailure{Name: name}
+		if match[1] == "ERROR" {
+			failure.Name = "error in " + name
+		}
+		if message != "" {
+			failure.Details = []string{message}
+		}
+		failures = append(failures, failure)
+	}
+	return failures
+}
+
+// parseJSFailures reads the failing tests JavaScript runners mark with ● (with
+// the message lines Jest prints under it) or with ✕ or ×
+func parseJSFailures(lines []string) []TestFailure {
+	var failures []TestFailure
+	seen := make(map[string]bool)
+	current := -1
+	for _, line := range lines {
+		if match := jsFailLine.FindStringSubmatch(line); match != nil {
+			name := strings.TrimSpace(match[1])
+			// Jest lists each failure twice, once in the run and once in the summary
+			if seen[name] || strings.HasPrefix(name, "Console") {
+				current = -1
+				continue
+			}
+			seen[name] = true
+			failures = append(failures, TestFailure{Name: name})
+			current = len(failures) - 1
+			continue
+		}
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "Test Suites:") || strings.HasPrefix(trimmed, "Test Files") {
+			// The closing totals belong to no test
+			current = -1
+		}
+		if current >= 0 && trimmed != "" && len(failures[current].Details) < maxFailureDetails {
+			failures[current].Details = append(failures[current].Details, trimmed)
+		}
+	}
+	return failures
+}
+
+// FormatTestFailures lists the failing tests with their messages, up to
+// maxTestFailures of them
+func FormatTestFailures(failures []TestFailure) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("%d failing:", len(failures)))
+	for i, failure := range failures {
+		if i == maxTestFailures {
+			b.WriteString(fmt.Sprintf("\n... and %d more", len(failures)-maxTestFailures))
+			break
+		}
+		b.WriteString("\n- " + failure.Name)
+		for _, detail := range failure.Details {
+			b.WriteString("\n    " + detail)
+		}
+	}
+	return b.String()
+}
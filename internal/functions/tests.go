@@ -0,0 +1,450 @@
+package functions
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// testTimeout bounds a single run_tests invocation
+	testTimeout = 10 * time.Minute
+
+	// maxTestOutput is how much of the end of the test output is returned to the model
+	maxTestOutput = 12000
+)
+
+// Test runners detected from the project layout
+const (
+	runnerGo     = "go"
+	runnerPytest = "pytest"
+	runnerNpm    = "npm"
+)
+
+// fileCoverage holds the uncovered lines of one source file
+type fileCoverage struct {
+	uncovered []int
+}
+
+// coverageReport is the parsed coverage of a test run
+type coverageReport struct {
+	percent float64
+	files   map[string]*fileCoverage // Keyed by absolute path
+}
+
+// TestRun is the outcome of a run of the project's test suite
+type TestRun struct {
+	Command  string // The command line, as shown to the model
+	Elapsed  time.Duration
+	Status   string        // PASSED, FAILED (exit status) or TIMED OUT
+	Failures []TestFailure // Failing tests found in the output
+	Output   string
+	Coverage string // Coverage summary, when coverage was collected
+}
+
+// Passed reports whether the suite passed
+func (r *TestRun) Passed() bool {
+	return r.Status == "PASSED"
+}
+
+// String formats the run for the model: the command and status, the failing
+// tests with their messages, then the end of the output
+func (r *TestRun) String() string {
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("Ran `%s` in %s — %s\n\n", r.Command, r.Elapsed, r.Status))
+	if len(r.Failures) > 0 {
+		result.WriteString(FormatTestFailures(r.Failures))
+		result.WriteString("\n\nOutput:\n")
+	}
+	result.WriteString(tailOutput(r.Output, maxTestOutput))
+	if r.Coverage != "" {
+		result.WriteString("\n\n")
+		result.WriteString(r.Coverage)
+	}
+	return result.String()
+}
+
+// runTests runs the project's test suite, optionally collecting coverage and
+// reporting the uncovered lines of the given files (or of the files edited this
+// session when none are given)
+func (f *FileOperations) runTests(target string, coverage bool, files []string) (string, error) {
+	run, err := f.testRun(target, coverage, files)
+	if err != nil {
+		return "", err
+	}
+	return run.String(), nil
+}
+
+// RunTests runs the project's test suite, or the packages or paths in target,
+// for /test
+func (f *FileOperations) RunTests(target string) (*TestRun, error) {
+	return f.testRun(target, false, nil)
+}
+
+// testRun detects the test runner, runs it and parses the failures
+func (f *FileOperations) testRun(target string, coverage bool, files []string) (*TestRun, error) {
+	root, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("getting working directory: %w", err)
+	}
+
+	runner := detectTestRunner(root)
+	if runner == "" {
+		return nil, fmt.Errorf("no supported test runner found (expected go.mod, pyproject.toml, setup.py, pytest.ini or package.json)")
+	}
+
+	// Coverage is written to a temp file so it never lands in the workspace
+	var coveragePath string
+	if coverage && runner != runnerNpm {
+		tmp, err := os.CreateTemp("", "riptide-coverage-*")
+		if err != nil {
+			return nil, fmt.Errorf("creating coverage file: %w", err)
+		}
+		coveragePath = tmp.Name()
+		tmp.Close()
+		defer os.Remove(coveragePath)
+	}
+
+	args := testCommand(runner, target, coveragePath)
+
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Dir = root
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	start := time.Now()
+	runErr := cmd.Run()
+	elapsed := time.Since(start).Round(100 * time.Millisecond)
+
+	status := "PASSED"
+	if ctx.Err() == context.DeadlineExceeded {
+		status = fmt.Sprintf("TIMED OUT after %s", testTimeout)
+	} else if runErr != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(runErr, &exitErr) {
+			return nil, fmt.Errorf("running %s: %w", args[0], runErr)
+		}
+		status = fmt.Sprintf("FAILED (%v)", runErr)
+	}
+
+	run := &TestRun{
+		Command: strings.Join(displayTestCommand(args, coveragePath), " "),
+		Elapsed: elapsed,
+		Status:  status,
+		Output:  output.String(),
+	}
+	if status != "PASSED" {
+		run.Failures = parseTestFailures(runner, run.Output)
+	}
+	if coverage {
+		run.Coverage = f.coverageSummary(runner, root, coveragePath, files)
+	}
+	return run, nil
+}
+
+// detectTestRunner picks a test runner from the files at the project root
+func detectTestRunner(root string) string {
+	exists := func(name string) bool {
+		_, err := os.Stat(filepath.Join(root, name))
+		return err == nil
+	}
+
+	switch {
+	case exists("go.mod"):
+		return runnerGo
+	case exists("pyproject.toml"), exists("setup.py"), exists("pytest.ini"), exists("setup.cfg"):
+		return runnerPytest
+	case exists("package.json"):
+		return runnerNpm
+	}
+	return ""
+}
+
+// testCommand builds the command line for a runner. An empty coveragePath runs
+// without coverage.
+func testCommand(runner, target, coveragePath string) []string {
+	switch runner {
+	case runnerGo:
+		if target == "" {
+			target = "./..."
+		}
+		args := []string{"go", "test"}
+		if coveragePath != "" {
+			args = append(args, "-coverprofile="+coveragePath)
+		}
+		return append(args, strings.Fields(target)...)
+
+	case runnerPytest:
+		args := []string{"python3", "-m", "pytest", "-q"}
+		if coveragePath != "" {
+			args = append(args, "--cov=.", "--cov-report=json:"+coveragePath)
+		}
+		return append(args, strings.Fields(target)...)
+
+	default:
+		args := []string{"npm", "test"}
+		if target != "" {
+			args = append(args, "--")
+			args = append(args, strings.Fields(target)...)
+		}
+		return args
+	}
+}
+
+// displayTestCommand hides the temp coverage path from the command shown to the model
+func displayTestCommand(args []string, coveragePath string) []string {
+	if coveragePath == "" {
+		return args
+	}
+	shown := make([]string, len(args))
+	for i, arg := range args {
+		shown[i] = strings.ReplaceAll(arg, coveragePath, "<tmp>")
+	}
+	return shown
+}
+
+// tailOutput keeps the last limit bytes of output, where failures are reported
+func tailOutput(output string, limit int) string {
+	output = strings.TrimRight(output, "\n")
+	if output == "" {
+		return "(no output)"
+	}
+	if len(output) <= limit {
+		return output
+	}
+
+	tail := output[len(output)-limit:]
+	if i := strings.IndexByte(tail, '\n'); i >= 0 {
+		tail = tail[i+1:]
+	}
+	return fmt.Sprintf("... (%d bytes of earlier output omitted)\n%s", len(output)-len(tail), tail)
+}
+
+// coverageSummary formats the coverage percentage and uncovered lines per file
+func (f *FileOperations) coverageSummary(runner, root, coveragePath string, files []string) string {
+	var report *coverageReport
+	var err error
+	switch runner {
+	case runnerGo:
+		report, err = parseGoCoverage(root, coveragePath)
+	case runnerPytest:
+		report, err = parsePytestCoverage(root, coveragePath)
+	default:
+		return "Coverage: not supported for npm projects; run the project's coverage script with the target instead."
+	}
+	if err != nil {
+		return fmt.Sprintf("Coverage: unavailable (%v)", err)
+	}
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("Coverage: %.1f%%", report.percent))
+
+	heading := "Uncovered lines in the requested files:"
+	if len(files) == 0 {
+		files = f.EditedFiles()
+		heading = "Uncovered lines in files edited this session:"
+	}
+	if len(files) == 0 {
+		b.WriteString("\nNo files were edited this session; pass file_paths to see uncovered lines.")
+		return b.String()
+	}
+
+	b.WriteString("\n" + heading)
+	for _, file := range files {
+		absPath, err := NormalizePath(file)
+		if err != nil {
+			absPath = file
+		}
+		display := absPath
+		if rel, err := filepath.Rel(root, absPath); err == nil && !strings.HasPrefix(rel, "..") {
+			display = rel
+		}
+
+		fc, ok := report.files[absPath]
+		switch {
+		case !ok:
+			b.WriteString(fmt.Sprintf("\n  %s: no coverage data (not exercised by these tests)", display))
+		case len(fc.uncovered) == 0:
+			b.WriteString(fmt.Sprintf("\n  %s: fully covered", display))
+		default:
+			b.WriteString(fmt.Sprintf("\n  %s: %s", display, formatLineRanges(fc.uncovered)))
+		}
+	}
+	return b.String()
+}
+
+// parseGoCoverage reads a go test -coverprofile file. Blocks are keyed by import
+// path, which is mapped back to files using the module path from go.mod.
+func parseGoCoverage(root, path string) (*coverageReport, error) {
+	modulePath, err := goModulePath(root)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening coverage profile: %w", err)
+	}
+	defer file.Close()
+
+	covered := make(map[string]map[int]bool)
+	uncovered := make(map[string]map[int]bool)
+	var totalStmts, coveredStmts int
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "mode:") || line == "" {
+			continue
+		}
+
+		// Format: import/path/file.go:startLine.startCol,endLine.endCol numStmts count
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			continue
+		}
+		colon := strings.LastIndex(fields[0], ":")
+		if colon < 0 {
+			continue
+		}
+		name, span := fields[0][:colon], fields[0][colon+1:]
+		startLine, endLine, ok := parseCoverSpan(span)
+		if !ok {
+			continue
+		}
+		stmts, _ := strconv.Atoi(fields[1])
+		count, _ := strconv.Atoi(fields[2])
+
+		absPath := filepath.Join(root, strings.TrimPrefix(strings.TrimPrefix(name, modulePath), "/"))
+		if covered[absPath] == nil {
+			covered[absPath] = make(map[int]bool)
+			uncovered[absPath] = make(map[int]bool)
+		}
+
+		lines := covered[absPath]
+		if count == 0 {
+			lines = uncovered[absPath]
+		} else {
+			coveredStmts += stmts
+		}
+		totalStmts += stmts
+		for l := startLine; l <= endLine; l++ {
+			lines[l] = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading coverage profile: %w", err)
+	}
+
+	report := &coverageReport{files: make(map[string]*fileCoverage)}
+	if totalStmts > 0 {
+		report.percent = 100 * float64(coveredStmts) / float64(totalStmts)
+	}
+	for absPath, hits := range covered {
+		fc := &fileCoverage{}
+		// A line shared by a covered and an uncovered block counts as covered
+		for l := range uncovered[absPath] {
+			if !hits[l] {
+				fc.uncovered = append(fc.uncovered, l)
+			}
+		}
+		sort.Ints(fc.uncovered)
+		report.files[absPath] = fc
+	}
+	return report, nil
+}
+
+// parseCoverSpan parses "12.3,15.4" into its start and end lines
+func parseCoverSpan(span string) (int, int, bool) {
+	start, end, ok := strings.Cut(span, ",")
+	if !ok {
+		return 0, 0, false
+	}
+	startLine, err1 := strconv.Atoi(strings.SplitN(start, ".", 2)[0])
+	endLine, err2 := strconv.Atoi(strings.SplitN(end, ".", 2)[0])
+	if err1 != nil || err2 != nil {
+		return 0, 0, false
+	}
+	return startLine, endLine, true
+}
+
+// goModulePath reads the module path from root/go.mod
+func goModulePath(root string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(root, "go.mod"))
+	if err != nil {
+		return "", fmt.Errorf("reading go.mod: %w", err)
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if fields := strings.Fields(line); len(fields) >= 2 && fields[0] == "module" {
+			return strings.Trim(fields[1], `"`), nil
+		}
+	}
+	return "", fmt.Errorf("no module directive in go.mod")
+}
+
+// parsePytestCoverage reads the JSON report written by pytest-cov
+func parsePytestCoverage(root, path string) (*coverageReport, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading coverage report: %w", err)
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("no report written; is pytest-cov installed?")
+	}
+
+	var raw struct {
+		Totals struct {
+			PercentCovered float64 `json:"percent_covered"`
+		} `json:"totals"`
+		Files map[string]struct {
+			MissingLines []int `json:"missing_lines"`
+		} `json:"files"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("parsing coverage report: %w", err)
+	}
+
+	report := &coverageReport{percent: raw.Totals.PercentCovered, files: make(map[string]*fileCoverage)}
+	for name, file := range raw.Files {
+		absPath := name
+		if !filepath.IsAbs(absPath) {
+			absPath = filepath.Join(root, name)
+		}
+		missing := append([]int(nil), file.MissingLines...)
+		sort.Ints(missing)
+		report.files[absPath] = &fileCoverage{uncovered: missing}
+	}
+	return report, nil
+}
+
+// formatLineRanges collapses sorted line numbers into ranges, e.g. "3-5, 9"
+func formatLineRanges(lines []int) string {
+	var parts []string
+	for i := 0; i < len(lines); {
+		j := i
+		for j+1 < len(lines) && lines[j+1] == lines[j]+1 {
+			j++
+		}
+		if i == j {
+			parts = append(parts, strconv.Itoa(lines[i]))
+		} else {
+			parts = append(parts, fmt.Sprintf("%d-%d", lines[i], lines[j]))
+		}
+		i = j + 1
+	}
+	return strings.Join(parts, ", ")
+}
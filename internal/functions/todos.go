@@ -0,0 +1,164 @@
+package functions
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/alchemy-labs-co/riptide/internal/config"
+)
+
+// maxTodos caps how many comments FindTodos returns
+const maxTodos = 500
+
+// maxTodoText caps the comment text kept for each match
+const maxTodoText = 200
+
+// DefaultTodoTags are the markers searched for when none are given
+var DefaultTodoTags = []string{"TODO", "FIXME", "HACK", "XXX"}
+
+// Todo is one TODO-style comment found in the workspace
+type Todo struct {
+	Path string // Relative to the scanned root
+	Line int
+	Tag  string
+	Text string // The rest of the line after the tag
+}
+
+// TodoResult is the outcome of a FindTodos scan
+type TodoResult struct {
+	Todos     []Todo
+	Truncated bool // More than maxTodos matches were found
+}
+
+// todoPattern builds a regexp matching any of tags as the first word of a
+// comment (//, #, /*, *, <!--, -- or ;), so prose and string literals that
+// merely mention a tag are not reported
+func todoPattern(tags []string) (*regexp.Regexp, error) {
+	quoted := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			continue
+		}
+		quoted = append(quoted, regexp.QuoteMeta(tag))
+	}
+	if len(quoted) == 0 {
+		return nil, fmt.Errorf("no tags given")
+	}
+	return regexp.Compile(`(?:^|\s)(?://+|#+|/\*+|\*|<!--|--|;+)\s*(` + strings.Join(quoted, "|") + `)\b:?`)
+}
+
+// FindTodos walks root for comments tagged with any of tags (DefaultTodoTags
+// when empty), skipping the same files as directory scans
+func FindTodos(cfg *config.Config, root string, tags []string) (*TodoResult, error) {
+	if len(tags) == 0 {
+		tags = DefaultTodoTags
+	}
+	pattern, err := todoPattern(tags)
+	if err != nil {
+		return nil, err
+	}
+
+	normalizedRoot, err := NormalizePath(root)
+	if err != nil {
+		return nil, fmt.Errorf("normalizing path: %w", err)
+	}
+	result := &TodoResult{}
+
+	scanFile := func(path string) error {
+		rel, err := filepath.Rel(normalizedRoot, path)
+		if err != nil || rel == "." {
+			rel = filepath.Base(path)
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			return nil
+		}
+		defer file.Close()
+
+		scanner := bufio.NewScanner(file)
+		scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+		for line := 1; scanner.Scan(); line++ {
+			text := scanner.Text()
+			loc := pattern.FindStringSubmatchIndex(text)
+			if loc == nil {
+				continue
+			}
+			if len(result.Todos) >= maxTodos {
+				result.Truncated = true
+				return filepath.SkipAll
+			}
+
+			rest := strings.TrimSpace(text[loc[1]:])
+			rest = strings.TrimSpace(strings.TrimSuffix(strings.TrimSuffix(rest, "*/"), "-->"))
+			if len(rest) > maxTodoText {
+				rest = rest[:maxTodoText] + "…"
+			}
+			result.Todos = append(result.Todos, Todo{
+				Path: filepath.ToSlash(rel),
+				Line: line,
+				Tag:  text[loc[2]:loc[3]],
+				Text: rest,
+			})
+		}
+		return nil
+	}
+
+	if err := walkTextFiles(cfg, normalizedRoot, scanFile); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// FormatTodos lists todos as "path:line: TAG text" under a summary of how many
+// of each tag were found
+func FormatTodos(result *TodoResult) string {
+	if len(result.Todos) == 0 {
+		return "No TODO comments found"
+	}
+
+	counts := make(map[string]int)
+	var order []string
+	for _, todo := range result.Todos {
+		if counts[todo.Tag] == 0 {
+			order = append(order, todo.Tag)
+		}
+		counts[todo.Tag]++
+	}
+	summary := make([]string, 0, len(order))
+	for _, tag := range order {
+		summary = append(summary, fmt.Sprintf("%d %s", counts[tag], tag))
+	}
+
+	var b strings.Builder
+	noun := "comments"
+	if len(result.Todos) == 1 {
+		noun = "comment"
+	}
+	b.WriteString(fmt.Sprintf("Found %d %s (%s)", len(result.Todos), noun, strings.Join(summary, ", ")))
+	if result.Truncated {
+		b.WriteString(fmt.Sprintf("; stopped after the first %d", maxTodos))
+	}
+	b.WriteString(":\n")
+	for _, todo := range result.Todos {
+		b.WriteString(fmt.Sprintf("%s:%d: %s %s\n", todo.Path, todo.Line, todo.Tag, todo.Text))
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// findTodos implements the find_todos tool
+func (f *FileOperations) findTodos(path string, tags []string) (string, error) {
+	if path == "" {
+		path = "."
+	}
+	result, err := FindTodos(f.config, path, tags)
+	if err != nil {
+		return "", fmt.Errorf("finding todos: %w", err)
+	}
+	return FormatTodos(result), nil
+}
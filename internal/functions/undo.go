@@ -0,0 +1,267 @@
+package functions
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// UndoDir holds the before-images of written files, one directory per session,
+	// relative to the workspace root
+	UndoDir = ".riptide/undo"
+
+	// undoLogFile is the changelog kept in each session's undo directory
+	undoLogFile = "changes.json"
+
+	// maxUndoSessions is how many sessions keep their backups; older ones are pruned
+	maxUndoSessions = 10
+)
+
+// ErrNothingToUndo is returned by Undo when every write has already been undone
+var ErrNothingToUndo = errors.New("nothing to undo")
+
+// UndoEntry is one file write that /undo can revert
+type UndoEntry struct {
+	ID        int       `json:"id"`
+	Call      int       `json:"call"` // Tool call that made the write; writes sharing one are undone together
+	Turn      int       `json:"turn"`
+	Time      time.Time `json:"time"`
+	Path      string    `json:"path"`
+	Tool      string    `json:"tool"`
+	Backup    string    `json:"backup,omitempty"` // SHA-256 of the saved before-image, empty when the file was created
+	AfterHash string    `json:"after_sha256"`
+	Undone    bool      `json:"undone,omitempty"`
+}
+
+// Created reports whether the write created the file, so undoing it deletes the file
+func (e UndoEntry) Created() bool {
+	return e.Backup == ""
+}
+
+// UndoConflictError reports files changed since Riptide wrote them, which /undo
+// refuses to overwrite
+type UndoConflictError struct {
+	Paths []string
+}
+
+func (e *UndoConflictError) Error() string {
+	return fmt.Sprintf("changed since Riptide wrote them: %s", strings.Join(e.Paths, ", "))
+}
+
+// UndoDirPath returns this session's undo directory, or "" when backups are disabled
+func (f *FileOperations) UndoDirPath() string {
+	return f.undoDir
+}
+
+// UndoLog returns this session's writes, oldest first, including undone ones
+func (f *FileOperations) UndoLog() []UndoEntry {
+	f.writeMu.Lock()
+	defer f.writeMu.Unlock()
+	return append([]UndoEntry(nil), f.undoLog...)
+}
+
+// saveBeforeImage stores the current content of path before it is overwritten and
+// returns its hash, or "" when the file does not exist. Callers hold writeMu.
+func (f *FileOperations) saveBeforeImage(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("reading file: %w", err)
+	}
+
+	if err := f.prepareUndoDir(); err != nil {
+		return "", err
+	}
+
+	hash := hashBytes(data)
+	blob := filepath.Join(f.undoDir, "blobs", hash)
+	if _, err := os.Stat(blob); err == nil {
+		return hash, nil
+	}
+	if err := os.WriteFile(blob, data, 0644); err != nil {
+		return "", fmt.Errorf("saving backup: %w", err)
+	}
+	return hash, nil
+}
+
+// prepareUndoDir creates this session's undo directory on its first write and
+// prunes the oldest sessions so at most maxUndoSessions keep backups. Callers hold writeMu.
+func (f *FileOperations) prepareUndoDir() error {
+	if f.undoReady {
+		return nil
+	}
+	pruneUndoSessions(filepath.Dir(f.undoDir), maxUndoSessions-1)
+	if err := os.MkdirAll(filepath.Join(f.undoDir, "blobs"), 0755); err != nil {
+		return fmt.Errorf("creating undo directory: %w", err)
+	}
+	f.undoReady = true
+	return nil
+}
+
+// recordUndoEntry adds a write to the changelog. Callers hold writeMu.
+func (f *FileOperations) recordUndoEntry(entry UndoEntry) error {
+	entry.ID = len(f.undoLog) + 1
+	f.undoLog = append(f.undoLog, entry)
+	return f.saveUndoLog()
+}
+
+// saveUndoLog writes the changelog next to the backups, so a crashed session can
+// still be restored by hand. Callers hold writeMu.
+func (f *FileOperations) saveUndoLog() error {
+	if err := f.prepareUndoDir(); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(f.undoLog, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling undo log: %w", err)
+	}
+	if err := replaceFile(filepath.Join(f.undoDir, undoLogFile), data); err != nil {
+		return fmt.Errorf("saving undo log: %w", err)
+	}
+	return nil
+}
+
+// Undo reverts the writes made by the last count tool calls that have not been
+// undone yet, newest first, and returns the entries it reverted. When turn is
+// set, every remaining write from the most recent turn is reverted instead. Files
+// changed since Riptide wrote them are left alone and reported as an
+// *UndoConflictError before anything is touched.
+func (f *FileOperations) Undo(count int, turn bool) ([]UndoEntry, error) {
+	f.writeMu.Lock()
+	defer f.writeMu.Unlock()
+
+	if f.closed {
+		return nil, fmt.Errorf("file operations are shut down")
+	}
+
+	// Collect the batch: whole tool calls, newest first
+	var batch []int
+	lastCall, calls, lastTurn := 0, 0, -1
+	for i := len(f.undoLog) - 1; i >= 0; i-- {
+		entry := f.undoLog[i]
+		if entry.Undone {
+			continue
+		}
+		if turn {
+			if lastTurn == -1 {
+				lastTurn = entry.Turn
+			}
+			if entry.Turn != lastTurn {
+				break
+			}
+		} else if entry.Call != lastCall {
+			if calls == count {
+				break
+			}
+			calls++
+			lastCall = entry.Call
+		}
+		batch = append(batch, i)
+	}
+	if len(batch) == 0 {
+		return nil, ErrNothingToUndo
+	}
+
+	// The newest write of each file must still match what is on disk
+	checked := make(map[string]bool)
+	var conflicts []string
+	for _, i := range batch {
+		entry := f.undoLog[i]
+		if checked[entry.Path] {
+			continue
+		}
+		checked[entry.Path] = true
+		if current, _ := snapshotFile(entry.Path); current != entry.AfterHash {
+			conflicts = append(conflicts, entry.Path)
+		}
+	}
+	if len(conflicts) > 0 {
+		sort.Strings(conflicts)
+		return nil, &UndoConflictError{Paths: conflicts}
+	}
+
+	var reverted []UndoEntry
+	for _, i := range batch {
+		entry := f.undoLog[i]
+		if err := f.revertEntry(entry); err != nil {
+			_ = f.saveUndoLog()
+			return reverted, fmt.Errorf("restoring %s: %w", entry.Path, err)
+		}
+		f.undoLog[i].Undone = true
+		reverted = append(reverted, f.undoLog[i])
+	}
+
+	// The files are already restored, so a changelog failure must not fail the undo
+	_ = f.saveUndoLog()
+	return reverted, nil
+}
+
+// revertEntry puts back the before-image of one write, deleting files it created,
+// and records the restore in the write ledger. Callers hold writeMu.
+func (f *FileOperations) revertEntry(entry UndoEntry) error {
+	_, bytesBefore := snapshotFile(entry.Path)
+	record := WriteRecord{
+		Time:        time.Now(),
+		Path:        entry.Path,
+		Tool:        "undo",
+		Turn:        f.turn,
+		BeforeHash:  entry.AfterHash,
+		BytesBefore: bytesBefore,
+	}
+
+	if entry.Created() {
+		if err := os.Remove(entry.Path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("removing file: %w", err)
+		}
+	} else {
+		data, err := os.ReadFile(filepath.Join(f.undoDir, "blobs", entry.Backup))
+		if err != nil {
+			return fmt.Errorf("reading backup: %w", err)
+		}
+		if err := os.MkdirAll(filepath.Dir(entry.Path), 0755); err != nil {
+			return fmt.Errorf("creating parent directory: %w", err)
+		}
+		if err := replaceFile(entry.Path, data); err != nil {
+			return err
+		}
+		record.AfterHash = entry.Backup
+		record.BytesAfter = len(data)
+	}
+
+	_ = f.appendWriteRecord(record)
+	return nil
+}
+
+// pruneUndoSessions removes the oldest session directories under dir so at most
+// keep remain. Session directories are named by start time, so they sort by age.
+func pruneUndoSessions(dir string, keep int) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	var sessions []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			sessions = append(sessions, entry.Name())
+		}
+	}
+	sort.Strings(sessions)
+	for len(sessions) > keep {
+		os.RemoveAll(filepath.Join(dir, sessions[0]))
+		sessions = sessions[1:]
+	}
+}
+
+// undoSessionName names a session's undo directory after its start time and
+// process, so concurrent sessions in one workspace never share backups
+func undoSessionName(start time.Time) string {
+	return fmt.Sprintf("%s-%d", start.Format("20060102-150405"), os.Getpid())
+}
@@ -0,0 +1,269 @@
+package functions
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"gopkg.in/yaml.v3"
+)
+
+// Formats validate_file can check beyond syntax
+const (
+	formatDockerCompose = "docker-compose"
+	formatGitHubActions = "github-actions"
+)
+
+// IsKnownFormat reports whether a validate_file schema names a built-in
+// format rather than a schema file
+func IsKnownFormat(schema string) bool {
+	return schema == formatDockerCompose || schema == formatGitHubActions
+}
+
+// maxValidationProblems caps how many schema or format problems are listed
+const maxValidationProblems = 50
+
+// validateFile checks that a JSON, YAML or TOML file parses and, when schema
+// names a JSON Schema file or a known format (or the format is recognized from
+// the file's path), that its content matches
+func (f *FileOperations) validateFile(filePath, schema string) (string, error) {
+	normalizedPath, err := NormalizePath(filePath)
+	if err != nil {
+		return "", fmt.Errorf("normalizing path: %w", err)
+	}
+	data, err := os.ReadFile(normalizedPath)
+	if err != nil {
+		return "", fmt.Errorf("reading file: %w", err)
+	}
+
+	syntax := syntaxForPath(normalizedPath)
+	if syntax == "" {
+		return "", fmt.Errorf("unsupported file type %q (expected .json, .yaml, .yml or .toml)", filepath.Ext(normalizedPath))
+	}
+
+	value, err := parseConfig(syntax, data)
+	if err != nil {
+		return fmt.Sprintf("INVALID %s: %s\n%v", strings.ToUpper(syntax), normalizedPath, err), nil
+	}
+
+	if schema == "" {
+		schema = detectFormat(normalizedPath)
+	}
+
+	var problems []string
+	var checked string
+	switch schema {
+	case "":
+		return fmt.Sprintf("VALID %s: %s (syntax only; pass a schema to check the content)", strings.ToUpper(syntax), normalizedPath), nil
+	case formatDockerCompose:
+		checked = "the Docker Compose format"
+		problems = checkCompose(value)
+	case formatGitHubActions:
+		checked = "the GitHub Actions workflow format"
+		problems = checkWorkflow(value)
+	default:
+		schemaPath, err := NormalizePath(schema)
+		if err != nil {
+			return "", fmt.Errorf("normalizing schema path: %w", err)
+		}
+		checked = "schema " + schemaPath
+		problems, err = checkJSONSchema(schemaPath, value)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if len(problems) == 0 {
+		return fmt.Sprintf("VALID %s: %s matches %s", strings.ToUpper(syntax), normalizedPath, checked), nil
+	}
+
+	var b strings.Builder
+	noun := "problems"
+	if len(problems) == 1 {
+		noun = "problem"
+	}
+	b.WriteString(fmt.Sprintf("INVALID: %s does not match %s (%d %s)\n", normalizedPath, checked, len(problems), noun))
+	for i, problem := range problems {
+		if i == maxValidationProblems {
+			b.WriteString(fmt.Sprintf("... and %d more\n", len(problems)-maxValidationProblems))
+			break
+		}
+		b.WriteString("- " + problem + "\n")
+	}
+	return strings.TrimRight(b.String(), "\n"), nil
+}
+
+// syntaxForPath returns json, yaml or toml from a file's extension
+func syntaxForPath(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return "json"
+	case ".yaml", ".yml":
+		return "yaml"
+	case ".toml":
+		return "toml"
+	}
+	return ""
+}
+
+// detectFormat recognizes Compose files and GitHub Actions workflows by path
+func detectFormat(path string) string {
+	slashed := filepath.ToSlash(path)
+	base := strings.ToLower(filepath.Base(path))
+	switch {
+	case strings.Contains(slashed, "/.github/workflows/") && syntaxForPath(path) == "yaml":
+		return formatGitHubActions
+	case strings.HasPrefix(base, "docker-compose") || strings.HasPrefix(base, "compose."):
+		if syntaxForPath(path) == "yaml" {
+			return formatDockerCompose
+		}
+	}
+	return ""
+}
+
+// parseConfig decodes data and returns it as JSON-compatible values (maps with
+// string keys, slices, strings, json.Number and bools), so every syntax is
+// checked the same way. Errors include the line where parsing stopped.
+func parseConfig(syntax string, data []byte) (interface{}, error) {
+	var value interface{}
+	switch syntax {
+	case "json":
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		if err := decoder.Decode(&value); err != nil {
+			var syntaxErr *json.SyntaxError
+			if errors.As(err, &syntaxErr) {
+				line, col := lineAndColumn(data, syntaxErr.Offset)
+				return nil, fmt.Errorf("line %d, column %d: %v", line, col, err)
+			}
+			return nil, err
+		}
+		if decoder.More() {
+			line, col := lineAndColumn(data, decoder.InputOffset())
+			return nil, fmt.Errorf("line %d, column %d: unexpected content after the top-level value", line, col)
+		}
+		return value, nil
+
+	case "yaml":
+		if err := yaml.Unmarshal(data, &value); err != nil {
+			return nil, err
+		}
+	case "toml":
+		if err := toml.Unmarshal(data, &value); err != nil {
+			return nil, err
+		}
+	}
+
+	// Round-trip through JSON to normalize map key and number types
+	value, err := jsonCompatible(value)
+	if err != nil {
+		return nil, err
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("converting to JSON: %w", err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+	var normalized interface{}
+	if err := decoder.Decode(&normalized); err != nil {
+		return nil, fmt.Errorf("converting to JSON: %w", err)
+	}
+	return normalized, nil
+}
+
+// jsonCompatible converts YAML maps with non-string keys into string-keyed maps
+func jsonCompatible(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			converted, err := jsonCompatible(item)
+			if err != nil {
+				return nil, err
+			}
+			v[key] = converted
+		}
+		return v, nil
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			converted, err := jsonCompatible(item)
+			if err != nil {
+				return nil, err
+			}
+			m[fmt.Sprint(key)] = converted
+		}
+		return m, nil
+	case []interface{}:
+		for i, item := range v {
+			converted, err := jsonCompatible(item)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = converted
+		}
+		return v, nil
+	}
+	return value, nil
+}
+
+// lineAndColumn converts a byte offset into a 1-based line and column
+func lineAndColumn(data []byte, offset int64) (int, int) {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	before := data[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	col := int(offset) - bytes.LastIndexByte(before, '\n')
+	return line, col
+}
+
+// checkJSONSchema validates value against the JSON Schema in schemaPath. Only
+// local files are loaded; remote $refs are not fetched.
+func checkJSONSchema(schemaPath string, value interface{}) ([]string, error) {
+	compiler := jsonschema.NewCompiler()
+	schema, err := compiler.Compile(schemaPath)
+	if err != nil {
+		return nil, fmt.Errorf("loading schema: %w", err)
+	}
+
+	err = schema.Validate(value)
+	if err == nil {
+		return nil, nil
+	}
+	var validationErr *jsonschema.ValidationError
+	if !errors.As(err, &validationErr) {
+		return nil, fmt.Errorf("validating: %w", err)
+	}
+
+	// The basic output lists every failing keyword; keep the leaves, which say
+	// what is actually wrong rather than which combinator failed
+	var problems []string
+	seen := make(map[string]bool)
+	for _, basic := range validationErr.BasicOutput().Errors {
+		if basic.Error == "" || strings.HasPrefix(basic.Error, "doesn't validate with") {
+			continue
+		}
+		location := basic.InstanceLocation
+		if location == "" {
+			location = "/"
+		}
+		problem := fmt.Sprintf("%s: %s", location, basic.Error)
+		if !seen[problem] {
+			seen[problem] = true
+			problems = append(problems, problem)
+		}
+	}
+	if len(problems) == 0 {
+		problems = append(problems, validationErr.Error())
+	}
+	sort.Strings(problems)
+	return problems, nil
+}
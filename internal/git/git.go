@@ -0,0 +1,142 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+const (
+	// commandTimeout bounds how long a single git invocation may take
+	commandTimeout = 2 * time.Second
+
+	// writeTimeout bounds diffs and commits, which may run hooks or walk a large tree
+	writeTimeout = time.Minute
+)
+
+// run executes a git command in dir and returns its trimmed output
+func run(dir string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("running git %s: %w", strings.Join(args, " "), err)
+	}
+	return strings.TrimRight(string(output), "\n"), nil
+}
+
+// IsRepository reports whether dir is inside a git working tree
+func IsRepository(dir string) bool {
+	out, err := run(dir, "rev-parse", "--is-inside-work-tree")
+	return err == nil && out == "true"
+}
+
+// CurrentBranch returns the checked-out branch, or the short commit hash when HEAD is detached
+func CurrentBranch(dir string) (string, error) {
+	branch, err := run(dir, "rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
+		return "", err
+	}
+	if branch == "HEAD" {
+		return run(dir, "rev-parse", "--short", "HEAD")
+	}
+	return branch, nil
+}
+
+// DirtyFiles returns the paths with uncommitted changes, including untracked files
+func DirtyFiles(dir string) ([]string, error) {
+	out, err := run(dir, "status", "--porcelain")
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, line := range strings.Split(out, "\n") {
+		// Porcelain lines are "XY path" (or "XY old -> new" for renames)
+		if len(line) < 4 {
+			continue
+		}
+		path := line[3:]
+		if idx := strings.Index(path, " -> "); idx >= 0 {
+			path = path[idx+4:]
+		}
+		files = append(files, strings.Trim(path, `"`))
+	}
+	return files, nil
+}
+
+// runWithInput executes a git command in dir with stdin, returning its trimmed
+// output. Errors include what git printed, such as a failing commit hook.
+func runWithInput(dir, stdin string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), writeTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Stdin = strings.NewReader(stdin)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		message := strings.TrimSpace(stderr.String())
+		if message == "" {
+			message = strings.TrimSpace(stdout.String())
+		}
+		if message != "" {
+			return "", fmt.Errorf("running git %s: %s", args[0], message)
+		}
+		return "", fmt.Errorf("running git %s: %w", args[0], err)
+	}
+	return strings.TrimRight(stdout.String(), "\n"), nil
+}
+
+// Status returns the branch and the short status of every changed file
+func Status(dir string) (string, error) {
+	return runWithInput(dir, "", "status", "--short", "--branch")
+}
+
+// Diff returns the unstaged changes, or the staged ones, limited to paths when any are given
+func Diff(dir string, staged bool, paths ...string) (string, error) {
+	args := []string{"diff", "--no-color", "--no-ext-diff"}
+	if staged {
+		args = append(args, "--cached")
+	}
+	if len(paths) > 0 {
+		args = append(append(args, "--"), paths...)
+	}
+	return runWithInput(dir, "", args...)
+}
+
+// DiffStat summarizes the staged changes, one line per file
+func DiffStat(dir string) (string, error) {
+	return runWithInput(dir, "", "diff", "--cached", "--stat", "--no-color")
+}
+
+// Add stages paths for the next commit
+func Add(dir string, paths ...string) error {
+	if len(paths) == 0 {
+		return nil
+	}
+	_, err := runWithInput(dir, "", append([]string{"add", "--"}, paths...)...)
+	return err
+}
+
+// Commit records the staged changes with message and returns the new commit's
+// short hash and subject
+func Commit(dir, message string) (string, error) {
+	message = strings.TrimSpace(message)
+	if message == "" {
+		return "", errors.New("commit message is empty")
+	}
+	if _, err := runWithInput(dir, message+"\n", "commit", "--file", "-"); err != nil {
+		return "", err
+	}
+	return runWithInput(dir, "", "log", "-1", "--format=%h %s")
+}
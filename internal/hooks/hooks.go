@@ -0,0 +1,135 @@
+// Package hooks runs user-configured commands at points in the session
+// lifecycle, passing a JSON description of the event on stdin.
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/alchemy-labs-co/riptide/internal/config"
+)
+
+// Lifecycle events hooks can subscribe to
+const (
+	PreTool    = "pre_tool"    // Before a tool runs; a failing hook blocks the call
+	PostEdit   = "post_edit"   // After a tool writes files
+	PostTurn   = "post_turn"   // After the model finishes answering a prompt
+	SessionEnd = "session_end" // When Riptide exits
+)
+
+// maxHookOutput caps how much hook output is included in error messages
+const maxHookOutput = 2000
+
+// Event is the JSON payload written to a hook's stdin
+type Event struct {
+	Event      string          `json:"event"`
+	Time       time.Time       `json:"time"`
+	SessionID  string          `json:"session_id,omitempty"`
+	WorkingDir string          `json:"working_dir"`
+	Turn       int             `json:"turn,omitempty"`
+	Tool       string          `json:"tool,omitempty"`
+	Arguments  json.RawMessage `json:"arguments,omitempty"`
+	Paths      []string        `json:"paths,omitempty"`
+	Response   string          `json:"response,omitempty"` // post_turn: the model's final answer
+}
+
+// Runner runs the configured hooks
+type Runner struct {
+	config config.HooksConfig
+}
+
+// NewRunner creates a hook runner from configuration
+func NewRunner(cfg config.HooksConfig) *Runner {
+	return &Runner{config: cfg}
+}
+
+// commands returns the commands configured for an event
+func (r *Runner) commands(event string) []string {
+	switch event {
+	case PreTool:
+		return r.config.PreTool
+	case PostEdit:
+		return r.config.PostEdit
+	case PostTurn:
+		return r.config.PostTurn
+	case SessionEnd:
+		return r.config.SessionEnd
+	}
+	return nil
+}
+
+// Has reports whether any hook is configured for event
+func (r *Runner) Has(event string) bool {
+	return r != nil && len(r.commands(event)) > 0
+}
+
+// Run runs every hook for the event in order, stopping at the first failure
+func (r *Runner) Run(event Event) error {
+	if !r.Has(event.Event) {
+		return nil
+	}
+
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	if event.WorkingDir == "" {
+		event.WorkingDir, _ = os.Getwd()
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("marshaling hook event: %w", err)
+	}
+
+	timeout := time.Duration(r.config.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+
+	for _, command := range r.commands(event.Event) {
+		if err := runHook(command, event.Event, payload, timeout); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runHook runs one hook command through the shell
+func runHook(command, event string, payload []byte, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Env = append(os.Environ(), "RIPTIDE_EVENT="+event)
+
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			err = fmt.Errorf("timed out after %s", timeout)
+		}
+		detail := strings.TrimSpace(output.String())
+		if len(detail) > maxHookOutput {
+			detail = detail[len(detail)-maxHookOutput:]
+		}
+		if detail != "" {
+			return fmt.Errorf("%s hook %q failed: %v\n%s", event, command, err, detail)
+		}
+		return fmt.Errorf("%s hook %q failed: %v", event, command, err)
+	}
+	return nil
+}
@@ -0,0 +1,365 @@
+// Package importer converts conversations exported from other assistants
+// (ChatGPT and Claude data exports, aider chat history files) into history
+// messages that can be saved as Riptide sessions.
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/alchemy-labs-co/riptide/internal/api"
+	"github.com/alchemy-labs-co/riptide/internal/conversation"
+)
+
+// Supported export formats
+const (
+	FormatChatGPT = "chatgpt"
+	FormatClaude  = "claude"
+	FormatAider   = "aider"
+)
+
+// aiderHeader starts each chat in an aider history file
+const aiderHeader = "# aider chat started at "
+
+// Conversation is one imported conversation
+type Conversation struct {
+	Title    string
+	Created  time.Time
+	Messages []api.ConversationMessage
+}
+
+// ParseFile reads an export and returns its conversations, oldest first. An
+// empty format is detected from the file name and content.
+func ParseFile(path, format string) ([]Conversation, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading export: %w", err)
+	}
+
+	if format == "" {
+		if format = Detect(filepath.Base(path), data); format == "" {
+			return nil, fmt.Errorf("unrecognized export format (expected ChatGPT or Claude conversations.json, or .aider.chat.history.md)")
+		}
+	}
+
+	var conversations []Conversation
+	switch format {
+	case FormatChatGPT:
+		conversations, err = parseChatGPT(data)
+	case FormatClaude:
+		conversations, err = parseClaude(data)
+	case FormatAider:
+		conversations = parseAider(data)
+	default:
+		return nil, fmt.Errorf("unknown format %q (use %s, %s or %s)", format, FormatChatGPT, FormatClaude, FormatAider)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Drop conversations with nothing to continue from
+	kept := conversations[:0]
+	for _, conv := range conversations {
+		if len(conv.Messages) > 0 {
+			kept = append(kept, conv)
+		}
+	}
+	sort.SliceStable(kept, func(i, j int) bool { return kept[i].Created.Before(kept[j].Created) })
+	return kept, nil
+}
+
+// Detect guesses the export format from the file name and content
+func Detect(name string, data []byte) string {
+	trimmed := bytes.TrimSpace(data)
+	if strings.HasSuffix(name, ".aider.chat.history.md") || bytes.HasPrefix(trimmed, []byte(aiderHeader)) {
+		return FormatAider
+	}
+
+	// Both services export an array of conversations; look at the first one's fields
+	var first map[string]json.RawMessage
+	if bytes.HasPrefix(trimmed, []byte("[")) {
+		var all []map[string]json.RawMessage
+		if err := json.Unmarshal(trimmed, &all); err != nil || len(all) == 0 {
+			return ""
+		}
+		first = all[0]
+	} else if err := json.Unmarshal(trimmed, &first); err != nil {
+		return ""
+	}
+
+	if _, ok := first["mapping"]; ok {
+		return FormatChatGPT
+	}
+	if _, ok := first["chat_messages"]; ok {
+		return FormatClaude
+	}
+	return ""
+}
+
+// unmarshalList decodes either a JSON array or a single object into a slice
+func unmarshalList[T any](data []byte) ([]T, error) {
+	trimmed := bytes.TrimSpace(data)
+	if bytes.HasPrefix(trimmed, []byte("[")) {
+		var list []T
+		err := json.Unmarshal(trimmed, &list)
+		return list, err
+	}
+	var one T
+	err := json.Unmarshal(trimmed, &one)
+	return []T{one}, err
+}
+
+// newMessage builds a history message with its token estimate
+func newMessage(role, content string, timestamp time.Time) api.ConversationMessage {
+	return api.ConversationMessage{
+		Role:      role,
+		Content:   content,
+		Timestamp: timestamp,
+		Tokens:    conversation.EstimateTokens(content),
+	}
+}
+
+// appendMessage adds a user or assistant message, merging it into the previous
+// one when both have the same role so the history keeps alternating
+func appendMessage(messages []api.ConversationMessage, role, content string, timestamp time.Time) []api.ConversationMessage {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return messages
+	}
+	if n := len(messages); n > 0 && messages[n-1].Role == role && messages[n-1].FilePath == "" {
+		messages[n-1].Content += "\n\n" + content
+		messages[n-1].Tokens = conversation.EstimateTokens(messages[n-1].Content)
+		return messages
+	}
+	return append(messages, newMessage(role, content, timestamp))
+}
+
+// chatGPTConversation is one conversation in a ChatGPT conversations.json. The
+// messages form a tree (edits create branches); current_node is the last
+// message of the branch that was on screen.
+type chatGPTConversation struct {
+	Title       string                 `json:"title"`
+	CreateTime  float64                `json:"create_time"`
+	CurrentNode string                 `json:"current_node"`
+	Mapping     map[string]chatGPTNode `json:"mapping"`
+}
+
+type chatGPTNode struct {
+	Parent  string `json:"parent"`
+	Message *struct {
+		Author struct {
+			Role string `json:"role"`
+		} `json:"author"`
+		CreateTime float64 `json:"create_time"`
+		Content    struct {
+			ContentType string            `json:"content_type"`
+			Parts       []json.RawMessage `json:"parts"`
+			Text        string            `json:"text"`
+		} `json:"content"`
+		Metadata struct {
+			Hidden bool `json:"is_visually_hidden_from_conversation"`
+		} `json:"metadata"`
+	} `json:"message"`
+}
+
+// parseChatGPT converts a ChatGPT data export, following each conversation's
+// visible branch
+func parseChatGPT(data []byte) ([]Conversation, error) {
+	exported, err := unmarshalList[chatGPTConversation](data)
+	if err != nil {
+		return nil, fmt.Errorf("parsing ChatGPT export: %w", err)
+	}
+
+	var conversations []Conversation
+	for _, exp := range exported {
+		conv := Conversation{Title: exp.Title, Created: unixTime(exp.CreateTime)}
+
+		// Walk up from the current node, then reverse into chronological order
+		var branch []chatGPTNode
+		seen := make(map[string]bool)
+		for id := exp.CurrentNode; id != "" && !seen[id]; {
+			seen[id] = true
+			node, ok := exp.Mapping[id]
+			if !ok {
+				break
+			}
+			branch = append(branch, node)
+			id = node.Parent
+		}
+
+		for i := len(branch) - 1; i >= 0; i-- {
+			msg := branch[i].Message
+			if msg == nil || msg.Metadata.Hidden {
+				continue
+			}
+			role := msg.Author.Role
+			if role != "user" && role != "assistant" {
+				continue
+			}
+
+			var text []string
+			if msg.Content.Text != "" {
+				text = append(text, msg.Content.Text)
+			}
+			for _, part := range msg.Content.Parts {
+				// Parts are strings, or objects for images and other attachments
+				var s string
+				if err := json.Unmarshal(part, &s); err == nil {
+					text = append(text, s)
+				}
+			}
+			timestamp := unixTime(msg.CreateTime)
+			if timestamp.IsZero() {
+				timestamp = conv.Created
+			}
+			conv.Messages = appendMessage(conv.Messages, role, strings.Join(text, "\n"), timestamp)
+		}
+		conversations = append(conversations, conv)
+	}
+	return conversations, nil
+}
+
+// unixTime converts fractional Unix seconds, treating zero as unset
+func unixTime(seconds float64) time.Time {
+	if seconds <= 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, int64(seconds*float64(time.Second)))
+}
+
+// claudeConversation is one conversation in a Claude conversations.json
+type claudeConversation struct {
+	Name         string    `json:"name"`
+	CreatedAt    time.Time `json:"created_at"`
+	ChatMessages []struct {
+		Sender    string    `json:"sender"`
+		Text      string    `json:"text"`
+		CreatedAt time.Time `json:"created_at"`
+		Content   []struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"content"`
+		Attachments []struct {
+			FileName         string `json:"file_name"`
+			ExtractedContent string `json:"extracted_content"`
+		} `json:"attachments"`
+	} `json:"chat_messages"`
+}
+
+// parseClaude converts a Claude data export. Attachments become files in context.
+func parseClaude(data []byte) ([]Conversation, error) {
+	exported, err := unmarshalList[claudeConversation](data)
+	if err != nil {
+		return nil, fmt.Errorf("parsing Claude export: %w", err)
+	}
+
+	var conversations []Conversation
+	for _, exp := range exported {
+		conv := Conversation{Title: exp.Name, Created: exp.CreatedAt}
+		for _, msg := range exp.ChatMessages {
+			role := "assistant"
+			if msg.Sender == "human" {
+				role = "user"
+			}
+
+			for _, attachment := range msg.Attachments {
+				if attachment.ExtractedContent == "" {
+					continue
+				}
+				// Worded like files added with /add so the model reads them the same way
+				content := fmt.Sprintf("Content of file '%s':\n\n%s", attachment.FileName, attachment.ExtractedContent)
+				file := newMessage("system", content, msg.CreatedAt)
+				file.FilePath = attachment.FileName
+				conv.Messages = append(conv.Messages, file)
+			}
+
+			// Newer exports split messages into typed blocks; only text blocks carry the reply
+			var blocks []string
+			for _, block := range msg.Content {
+				if block.Type == "text" && block.Text != "" {
+					blocks = append(blocks, block.Text)
+				}
+			}
+			text := strings.Join(blocks, "\n\n")
+			if text == "" {
+				text = msg.Text
+			}
+			conv.Messages = appendMessage(conv.Messages, role, text, msg.CreatedAt)
+		}
+		conversations = append(conversations, conv)
+	}
+	return conversations, nil
+}
+
+// parseAider converts an aider chat history file. Each "# aider chat started
+// at" section is a conversation; "####" lines are prompts, "> " lines are
+// aider's own output and are dropped, and everything else is the reply.
+func parseAider(data []byte) []Conversation {
+	var conversations []Conversation
+	var conv *Conversation
+	var role string
+	var block []string
+	inFence := false
+
+	flush := func() {
+		if conv != nil && role != "" {
+			conv.Messages = appendMessage(conv.Messages, role, strings.Join(block, "\n"), conv.Created)
+		}
+		block = nil
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if !inFence && strings.HasPrefix(line, aiderHeader) {
+			flush()
+			if conv != nil {
+				conversations = append(conversations, *conv)
+			}
+			started := strings.TrimSpace(strings.TrimPrefix(line, aiderHeader))
+			created, _ := time.ParseInLocation("2006-01-02 15:04:05", started, time.Local)
+			conv = &Conversation{Title: "aider chat " + started, Created: created}
+			role = ""
+			continue
+		}
+		if conv == nil {
+			continue
+		}
+
+		switch {
+		case !inFence && strings.HasPrefix(line, "####"):
+			if role != "user" {
+				flush()
+				role = "user"
+			}
+			block = append(block, strings.TrimPrefix(strings.TrimPrefix(line, "####"), " "))
+		case !inFence && (strings.HasPrefix(line, "> ") || line == ">"):
+			// aider's command echo and status output
+		default:
+			if role == "user" && strings.TrimSpace(line) != "" {
+				flush()
+				role = "assistant"
+			}
+			if role == "assistant" {
+				if strings.HasPrefix(strings.TrimSpace(line), "```") {
+					inFence = !inFence
+				}
+				block = append(block, line)
+			}
+		}
+	}
+	flush()
+	if conv != nil {
+		conversations = append(conversations, *conv)
+	}
+	return conversations
+}
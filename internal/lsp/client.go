@@ -0,0 +1,467 @@
+// Package lsp is a minimal Language Server Protocol client. It starts the
+// project's language servers over stdio and asks them for definitions,
+// references and document symbols, so code can be navigated by meaning
+// rather than by text search.
+package lsp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxStderr is how much of a server's stderr is kept for error messages
+const maxStderr = 2000
+
+// errContentModified is the error code servers answer with when the workspace
+// changed while a request was being worked on; the request is tried again
+const errContentModified = -32801
+
+// ResponseError is an error a language server answered a request with
+type ResponseError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *ResponseError) Error() string {
+	return fmt.Sprintf("%s (code %d)", e.Message, e.Code)
+}
+
+// message is any JSON-RPC message read from a server
+type message struct {
+	ID     json.RawMessage `json:"id,omitempty"`
+	Method string          `json:"method,omitempty"`
+	Params json.RawMessage `json:"params,omitempty"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  *ResponseError  `json:"error,omitempty"`
+}
+
+// request is a request or, without an ID, a notification sent to a server
+type request struct {
+	JSONRPC string      `json:"jsonrpc"`
+	ID      *int64      `json:"id,omitempty"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params,omitempty"`
+}
+
+// reply answers a request the server sent
+type reply struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result"`
+}
+
+// document is an open document and the content the server last saw
+type document struct {
+	version int
+	content string
+}
+
+// Client is a connection to one running language server
+type Client struct {
+	command string
+	root    string
+	cmd     *exec.Cmd
+	stdin   io.WriteCloser
+	stderr  *tailBuffer
+
+	writeMu sync.Mutex
+
+	mu        sync.Mutex
+	nextID    int64
+	pending   map[int64]chan message
+	documents map[string]*document // Keyed by absolute path
+
+	done chan struct{} // Closed when the server's output ends
+	err  error         // Why the output ended
+}
+
+// Start launches a language server from a command line and initializes it
+// for the workspace at root
+func Start(ctx context.Context, command, root string) (*Client, error) {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return nil, fmt.Errorf("empty language server command")
+	}
+
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Dir = root
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("opening server input: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("opening server output: %w", err)
+	}
+	stderr := &tailBuffer{limit: maxStderr}
+	cmd.Stderr = stderr
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("starting %s: %w", args[0], err)
+	}
+
+	c := &Client{
+		command:   command,
+		root:      root,
+		cmd:       cmd,
+		stdin:     stdin,
+		stderr:    stderr,
+		pending:   make(map[int64]chan message),
+		documents: make(map[string]*document),
+		done:      make(chan struct{}),
+	}
+	go c.readLoop(bufio.NewReader(stdout))
+
+	if err := c.initialize(ctx); err != nil {
+		c.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// initialize performs the initialize handshake, announcing only what this
+// client uses
+func (c *Client) initialize(ctx context.Context) error {
+	params := map[string]interface{}{
+		"processId": os.Getpid(),
+		"rootUri":   fileURI(c.root),
+		"workspaceFolders": []map[string]string{
+			{"uri": fileURI(c.root), "name": filepath.Base(c.root)},
+		},
+		"capabilities": map[string]interface{}{
+			"general": map[string]interface{}{
+				"positionEncodings": []string{"utf-16"},
+			},
+			"workspace": map[string]interface{}{
+				"workspaceFolders": true,
+				"configuration":    true,
+			},
+			"textDocument": map[string]interface{}{
+				"synchronization": map[string]interface{}{},
+				"definition":      map[string]interface{}{"linkSupport": true},
+				"references":      map[string]interface{}{},
+				"documentSymbol": map[string]interface{}{
+					"hierarchicalDocumentSymbolSupport": true,
+				},
+			},
+		},
+	}
+	if _, err := c.call(ctx, "initialize", params); err != nil {
+		return fmt.Errorf("initializing %s: %w", c.command, err)
+	}
+	return c.notify("initialized", map[string]interface{}{})
+}
+
+// Definition returns where the symbol at pos in path is declared
+func (c *Client) Definition(ctx context.Context, path string, pos Position) ([]Location, error) {
+	result, err := c.positionRequest(ctx, "textDocument/definition", path, pos, nil)
+	if err != nil {
+		return nil, err
+	}
+	return parseLocations(result)
+}
+
+// References returns where the symbol at pos in path is used, not counting
+// its declaration
+func (c *Client) References(ctx context.Context, path string, pos Position) ([]Location, error) {
+	result, err := c.positionRequest(ctx, "textDocument/references", path, pos, map[string]interface{}{
+		"context": map[string]bool{"includeDeclaration": false},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return parseLocations(result)
+}
+
+// Symbols returns the declarations in path, nested ones after their parent
+func (c *Client) Symbols(ctx context.Context, path string) ([]Symbol, error) {
+	if err := c.sync(path); err != nil {
+		return nil, err
+	}
+	result, err := c.call(ctx, "textDocument/documentSymbol", map[string]interface{}{
+		"textDocument": map[string]string{"uri": fileURI(path)},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return parseSymbols(result)
+}
+
+// positionRequest sends a request about a position in a document, after
+// making sure the server has the document's current content
+func (c *Client) positionRequest(ctx context.Context, method, path string, pos Position, extra map[string]interface{}) (json.RawMessage, error) {
+	if err := c.sync(path); err != nil {
+		return nil, err
+	}
+	params := map[string]interface{}{
+		"textDocument": map[string]string{"uri": fileURI(path)},
+		"position":     pos,
+	}
+	for key, value := range extra {
+		params[key] = value
+	}
+	return c.call(ctx, method, params)
+}
+
+// sync opens path on the server, or sends its new content when it changed on
+// disk since the server last saw it
+func (c *Client) sync(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading file: %w", err)
+	}
+	content := string(data)
+	uri := fileURI(path)
+
+	c.mu.Lock()
+	doc, open := c.documents[path]
+	if open && doc.content == content {
+		c.mu.Unlock()
+		return nil
+	}
+	if !open {
+		doc = &document{}
+		c.documents[path] = doc
+	}
+	doc.version++
+	doc.content = content
+	version := doc.version
+	c.mu.Unlock()
+
+	if !open {
+		return c.notify("textDocument/didOpen", map[string]interface{}{
+			"textDocument": map[string]interface{}{
+				"uri":        uri,
+				"languageId": languageID(path),
+				"version":    version,
+				"text":       content,
+			},
+		})
+	}
+	return c.notify("textDocument/didChange", map[string]interface{}{
+		"textDocument":   map[string]interface{}{"uri": uri, "version": version},
+		"contentChanges": []map[string]string{{"text": content}},
+	})
+}
+
+// call sends a request and waits for its result, trying again while the
+// server reports that the content changed under it
+func (c *Client) call(ctx context.Context, method string, params interface{}) (json.RawMessage, error) {
+	for {
+		result, err := c.callOnce(ctx, method, params)
+		var responseErr *ResponseError
+		if !errors.As(err, &responseErr) || responseErr.Code != errContentModified {
+			return result, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(200 * time.Millisecond):
+		}
+	}
+}
+
+// callOnce sends one request and waits for the response
+func (c *Client) callOnce(ctx context.Context, method string, params interface{}) (json.RawMessage, error) {
+	c.mu.Lock()
+	c.nextID++
+	id := c.nextID
+	responses := make(chan message, 1)
+	c.pending[id] = responses
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+	}()
+
+	if err := c.write(request{JSONRPC: "2.0", ID: &id, Method: method, Params: params}); err != nil {
+		return nil, err
+	}
+
+	select {
+	case response := <-responses:
+		if response.Error != nil {
+			return nil, response.Error
+		}
+		return response.Result, nil
+	case <-c.done:
+		return nil, c.exitError()
+	case <-ctx.Done():
+		_ = c.notify("$/cancelRequest", map[string]int64{"id": id})
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, fmt.Errorf("%s did not answer %s in time", c.command, method)
+		}
+		return nil, ctx.Err()
+	}
+}
+
+// notify sends a notification
+func (c *Client) notify(method string, params interface{}) error {
+	return c.write(request{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+// write sends one message with its Content-Length header
+func (c *Client) write(msg interface{}) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("marshaling message: %w", err)
+	}
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if _, err := fmt.Fprintf(c.stdin, "Content-Length: %d\r\n\r\n%s", len(body), body); err != nil {
+		select {
+		case <-c.done:
+			return c.exitError()
+		default:
+		}
+		return fmt.Errorf("writing to %s: %w", c.command, err)
+	}
+	return nil
+}
+
+// readLoop reads messages from the server until its output ends, delivering
+// responses and answering the server's own requests
+func (c *Client) readLoop(r *bufio.Reader) {
+	var err error
+	for {
+		var body []byte
+		if body, err = readMessage(r); err != nil {
+			break
+		}
+		var msg message
+		if json.Unmarshal(body, &msg) != nil {
+			continue
+		}
+
+		switch {
+		case msg.Method != "" && len(msg.ID) > 0:
+			c.answer(msg)
+		case msg.Method == "" && len(msg.ID) > 0:
+			id, parseErr := strconv.ParseInt(string(msg.ID), 10, 64)
+			if parseErr != nil {
+				continue
+			}
+			c.mu.Lock()
+			responses, ok := c.pending[id]
+			c.mu.Unlock()
+			if ok {
+				responses <- msg
+			}
+		}
+		// Notifications such as diagnostics and progress are not used
+	}
+
+	c.mu.Lock()
+	c.err = err
+	c.mu.Unlock()
+	close(c.done)
+}
+
+// answer replies to a request from the server. Configuration requests get an
+// empty setting per item, so servers fall back to their defaults; everything
+// else, such as registering capabilities, is acknowledged.
+func (c *Client) answer(msg message) {
+	var result interface{}
+	if msg.Method == "workspace/configuration" {
+		var params struct {
+			Items []json.RawMessage `json:"items"`
+		}
+		_ = json.Unmarshal(msg.Params, &params)
+		result = make([]interface{}, len(params.Items))
+	}
+	_ = c.write(reply{JSONRPC: "2.0", ID: msg.ID, Result: result})
+}
+
+// readMessage reads one Content-Length framed message body
+func readMessage(r *bufio.Reader) ([]byte, error) {
+	length := -1
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			break
+		}
+		if name, value, ok := strings.Cut(line, ":"); ok && strings.EqualFold(strings.TrimSpace(name), "Content-Length") {
+			if length, err = strconv.Atoi(strings.TrimSpace(value)); err != nil {
+				return nil, fmt.Errorf("parsing Content-Length: %w", err)
+			}
+		}
+	}
+	if length < 0 {
+		return nil, fmt.Errorf("message without Content-Length")
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+// exitError explains why the server stopped answering, with the end of what
+// it wrote to stderr
+func (c *Client) exitError() error {
+	detail := strings.TrimSpace(c.stderr.String())
+	if detail != "" {
+		return fmt.Errorf("%s exited: %s", c.command, detail)
+	}
+	return fmt.Errorf("%s exited", c.command)
+}
+
+// Close asks the server to shut down and exit, and kills it if it does not
+// within a few seconds
+func (c *Client) Close() {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if _, err := c.callOnce(ctx, "shutdown", nil); err == nil {
+		_ = c.notify("exit", nil)
+	}
+	c.stdin.Close()
+
+	select {
+	case <-c.done:
+	case <-time.After(2 * time.Second):
+	}
+	_ = c.cmd.Process.Kill()
+	_ = c.cmd.Wait()
+}
+
+// tailBuffer keeps the end of what is written to it
+type tailBuffer struct {
+	mu    sync.Mutex
+	buf   bytes.Buffer
+	limit int
+}
+
+// Write keeps the last limit bytes
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.buf.Write(p)
+	if extra := b.buf.Len() - b.limit; extra > 0 {
+		b.buf.Next(extra)
+	}
+	return len(p), nil
+}
+
+// String returns what has been kept
+func (b *tailBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
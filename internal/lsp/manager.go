@@ -0,0 +1,138 @@
+package lsp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alchemy-labs-co/riptide/internal/config"
+)
+
+// ErrNoServer is returned for files no language server is configured for
+var ErrNoServer = errors.New("no language server configured")
+
+// Manager starts language servers as files they handle are asked about and
+// keeps them running for the session, one per configured command
+type Manager struct {
+	config config.LSPConfig
+	root   string
+
+	mu      sync.Mutex
+	clients map[string]*Client
+	failed  map[string]error // Servers that are not installed, which are not looked for again
+}
+
+// NewManager creates a manager for the workspace at root. No server starts
+// until one is needed.
+func NewManager(cfg config.LSPConfig, root string) *Manager {
+	return &Manager{
+		config:  cfg,
+		root:    root,
+		clients: make(map[string]*Client),
+		failed:  make(map[string]error),
+	}
+}
+
+// Definition returns where the symbol at pos in path is declared
+func (m *Manager) Definition(path string, pos Position) ([]Location, error) {
+	ctx, cancel := m.context()
+	defer cancel()
+	client, err := m.client(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	return client.Definition(ctx, path, pos)
+}
+
+// References returns where the symbol at pos in path is used
+func (m *Manager) References(path string, pos Position) ([]Location, error) {
+	ctx, cancel := m.context()
+	defer cancel()
+	client, err := m.client(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	return client.References(ctx, path, pos)
+}
+
+// Symbols returns the declarations in path
+func (m *Manager) Symbols(path string) ([]Symbol, error) {
+	ctx, cancel := m.context()
+	defer cancel()
+	client, err := m.client(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	return client.Symbols(ctx, path)
+}
+
+// context bounds one request by the configured timeout
+func (m *Manager) context() (context.Context, context.CancelFunc) {
+	timeout := time.Duration(m.config.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = 60 * time.Second
+	}
+	return context.WithTimeout(context.Background(), timeout)
+}
+
+// client returns the running server for path's extension, starting it the
+// first time. A server that exited is started again.
+func (m *Manager) client(ctx context.Context, path string) (*Client, error) {
+	ext := strings.ToLower(filepath.Ext(path))
+	command := strings.TrimSpace(m.config.Servers[ext])
+	if command == "" {
+		return nil, fmt.Errorf("%w for %s files", ErrNoServer, ext)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.clients == nil {
+		return nil, fmt.Errorf("language servers are shut down")
+	}
+	if err, ok := m.failed[command]; ok {
+		return nil, err
+	}
+	if client, ok := m.clients[command]; ok {
+		select {
+		case <-client.done:
+			delete(m.clients, command)
+		default:
+			return client, nil
+		}
+	}
+
+	if _, err := exec.LookPath(strings.Fields(command)[0]); err != nil {
+		err = fmt.Errorf("%w for %s files: %s is not installed", ErrNoServer, ext, strings.Fields(command)[0])
+		m.failed[command] = err
+		return nil, err
+	}
+	client, err := Start(ctx, command, m.root)
+	if err != nil {
+		return nil, err
+	}
+	m.clients[command] = client
+	return client, nil
+}
+
+// Close shuts down every running server
+func (m *Manager) Close() {
+	m.mu.Lock()
+	clients := m.clients
+	m.clients = nil
+	m.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, client := range clients {
+		wg.Add(1)
+		go func(client *Client) {
+			defer wg.Done()
+			client.Close()
+		}(client)
+	}
+	wg.Wait()
+}
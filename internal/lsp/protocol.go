@@ -0,0 +1,241 @@
+package lsp
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"unicode/utf16"
+)
+
+// Position is a zero-based line and UTF-16 character offset in a document
+type Position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+// Range is a span of a document, end exclusive
+type Range struct {
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+}
+
+// Location is a range in a file
+type Location struct {
+	Path  string // Absolute path, from the location's file URI
+	Range Range
+}
+
+// wireLocation is a location as servers send it
+type wireLocation struct {
+	URI   string `json:"uri"`
+	Range Range  `json:"range"`
+}
+
+// wireLocationLink is the richer form some servers give for definitions
+type wireLocationLink struct {
+	TargetURI            string `json:"targetUri"`
+	TargetRange          Range  `json:"targetRange"`
+	TargetSelectionRange Range  `json:"targetSelectionRange"`
+}
+
+// Symbol is a declaration in a document. Nested declarations, such as methods
+// and fields, come after their parent with a greater depth.
+type Symbol struct {
+	Name      string
+	Kind      string
+	Detail    string // Such as a function's signature, when the server gives one
+	Container string // Enclosing declaration, for servers that list symbols flat
+	Range     Range
+	Depth     int
+}
+
+// documentSymbol is the hierarchical form of a document symbol
+type documentSymbol struct {
+	Name           string           `json:"name"`
+	Detail         string           `json:"detail"`
+	Kind           int              `json:"kind"`
+	Range          Range            `json:"range"`
+	SelectionRange Range            `json:"selectionRange"`
+	Children       []documentSymbol `json:"children"`
+}
+
+// symbolInformation is the flat form of a document symbol
+type symbolInformation struct {
+	Name          string       `json:"name"`
+	Kind          int          `json:"kind"`
+	Location      wireLocation `json:"location"`
+	ContainerName string       `json:"containerName"`
+}
+
+// symbolKinds names the LSP SymbolKind values, which start at 1
+var symbolKinds = []string{
+	"", "file", "module", "namespace", "package", "class", "method", "property",
+	"field", "constructor", "enum", "interface", "function", "variable",
+	"constant", "string", "number", "boolean", "array", "object", "key", "null",
+	"enum member", "struct", "event", "operator", "type parameter",
+}
+
+// symbolKind names a SymbolKind value
+func symbolKind(kind int) string {
+	if kind > 0 && kind < len(symbolKinds) {
+		return symbolKinds[kind]
+	}
+	return "symbol"
+}
+
+// languageIDs maps file extensions to the language identifiers sent when a
+// document is opened
+var languageIDs = map[string]string{
+	".go":   "go",
+	".py":   "python",
+	".js":   "javascript",
+	".jsx":  "javascriptreact",
+	".ts":   "typescript",
+	".tsx":  "typescriptreact",
+	".rs":   "rust",
+	".c":    "c",
+	".h":    "c",
+	".cc":   "cpp",
+	".cpp":  "cpp",
+	".hpp":  "cpp",
+	".java": "java",
+	".rb":   "ruby",
+}
+
+// languageID returns the language identifier for a file
+func languageID(path string) string {
+	if id, ok := languageIDs[strings.ToLower(filepath.Ext(path))]; ok {
+		return id
+	}
+	return strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+}
+
+// fileURI returns the file:// URI of an absolute path
+func fileURI(path string) string {
+	path = filepath.ToSlash(path)
+	if runtime.GOOS == "windows" {
+		path = "/" + path
+	}
+	return (&url.URL{Scheme: "file", Path: path}).String()
+}
+
+// uriPath returns the path of a file:// URI
+func uriPath(uri string) (string, error) {
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		return "", fmt.Errorf("parsing URI: %w", err)
+	}
+	if parsed.Scheme != "file" {
+		return "", fmt.Errorf("unsupported URI %s", uri)
+	}
+	path := parsed.Path
+	if runtime.GOOS == "windows" {
+		path = strings.TrimPrefix(path, "/")
+	}
+	return filepath.FromSlash(path), nil
+}
+
+// parseLocations reads a definition or references result, which may be null,
+// a single location, or a list of locations or location links
+func parseLocations(raw json.RawMessage) ([]Location, error) {
+	trimmed := strings.TrimSpace(string(raw))
+	if trimmed == "" || trimmed == "null" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(trimmed, "[") {
+		trimmed = "[" + trimmed + "]"
+	}
+
+	var items []json.RawMessage
+	if err := json.Unmarshal([]byte(trimmed), &items); err != nil {
+		return nil, fmt.Errorf("parsing locations: %w", err)
+	}
+	var locations []Location
+	for _, item := range items {
+		var link wireLocationLink
+		if err := json.Unmarshal(item, &link); err == nil && link.TargetURI != "" {
+			path, err := uriPath(link.TargetURI)
+			if err != nil {
+				continue
+			}
+			locations = append(locations, Location{Path: path, Range: link.TargetSelectionRange})
+			continue
+		}
+		var location wireLocation
+		if err := json.Unmarshal(item, &location); err != nil || location.URI == "" {
+			continue
+		}
+		path, err := uriPath(location.URI)
+		if err != nil {
+			continue
+		}
+		locations = append(locations, Location{Path: path, Range: location.Range})
+	}
+	return locations, nil
+}
+
+// parseSymbols reads a document symbol result in either of its forms
+func parseSymbols(raw json.RawMessage) ([]Symbol, error) {
+	var items []json.RawMessage
+	if err := json.Unmarshal(raw, &items); err != nil {
+		if strings.TrimSpace(string(raw)) == "null" {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("parsing symbols: %w", err)
+	}
+
+	var symbols []Symbol
+	var walk func(symbol documentSymbol, depth int)
+	walk = func(symbol documentSymbol, depth int) {
+		symbols = append(symbols, Symbol{
+			Name:   symbol.Name,
+			Kind:   symbolKind(symbol.Kind),
+			Detail: symbol.Detail,
+			Range:  symbol.SelectionRange,
+			Depth:  depth,
+		})
+		for _, child := range symbol.Children {
+			walk(child, depth+1)
+		}
+	}
+
+	for _, item := range items {
+		var probe struct {
+			Location *wireLocation `json:"location"`
+		}
+		if err := json.Unmarshal(item, &probe); err != nil {
+			return nil, fmt.Errorf("parsing symbols: %w", err)
+		}
+		if probe.Location != nil {
+			var info symbolInformation
+			if err := json.Unmarshal(item, &info); err != nil {
+				return nil, fmt.Errorf("parsing symbols: %w", err)
+			}
+			symbols = append(symbols, Symbol{
+				Name:      info.Name,
+				Kind:      symbolKind(info.Kind),
+				Container: info.ContainerName,
+				Range:     info.Location.Range,
+			})
+			continue
+		}
+		var symbol documentSymbol
+		if err := json.Unmarshal(item, &symbol); err != nil {
+			return nil, fmt.Errorf("parsing symbols: %w", err)
+		}
+		walk(symbol, 0)
+	}
+	return symbols, nil
+}
+
+// Character returns the UTF-16 offset the language server expects for a byte
+// offset into a line
+func Character(line string, byteOffset int) int {
+	if byteOffset > len(line) {
+		byteOffset = len(line)
+	}
+	return len(utf16.Encode([]rune(line[:byteOffset])))
+}
@@ -0,0 +1,324 @@
+// Package recipe stores reusable, parameterized prompt sequences. A recipe is
+// saved from a finished conversation (its prompts and the files it had in
+// context) with chosen values replaced by {{param}} placeholders, and re-run
+// headlessly with new values.
+package recipe
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/alchemy-labs-co/riptide/internal/api"
+	"github.com/alchemy-labs-co/riptide/internal/config"
+	"github.com/alchemy-labs-co/riptide/internal/watch"
+)
+
+// WorkspaceDir holds recipes checked into a project, relative to its root
+const WorkspaceDir = ".riptide/recipes"
+
+// maxContextFiles caps how many files a recipe's context globs may add
+const maxContextFiles = 100
+
+// namePattern restricts recipe names to what is safe as a file name
+var namePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// placeholderPattern matches {{param}} placeholders
+var placeholderPattern = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_-]*)\s*\}\}`)
+
+// Recipe is a saved prompt sequence with the context it needs
+type Recipe struct {
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	Params      []string  `json:"params,omitempty"`  // Placeholders that must be given with --arg
+	Context     []string  `json:"context,omitempty"` // Globs of files added before the first prompt
+	Prompts     []string  `json:"prompts"`           // Sent in order, each run until the model stops calling tools
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// DefaultDir returns the user's recipe directory under the XDG data home,
+// shared by every project
+func DefaultDir() (string, error) {
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("finding home directory: %w", err)
+		}
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(dataHome, "riptide", "recipes"), nil
+}
+
+// ValidateName reports whether name can be used for a recipe file
+func ValidateName(name string) error {
+	if !namePattern.MatchString(name) {
+		return fmt.Errorf("invalid recipe name %q (use letters, digits, '.', '_' and '-')", name)
+	}
+	return nil
+}
+
+// FromConversation builds a recipe from a conversation's user prompts and the
+// files it had in context. Each value in params is replaced by its {{key}}
+// placeholder, so "users" with name=users becomes "{{name}}".
+func FromConversation(name string, messages []api.ConversationMessage, root string, params map[string]string) (*Recipe, error) {
+	if err := ValidateName(name); err != nil {
+		return nil, err
+	}
+
+	// Replace longer values first so one value inside another is not split
+	keys := make([]string, 0, len(params))
+	for key, value := range params {
+		if !placeholderPattern.MatchString("{{" + key + "}}") {
+			return nil, fmt.Errorf("invalid parameter name %q", key)
+		}
+		if value == "" {
+			return nil, fmt.Errorf("parameter %q has no value to replace", key)
+		}
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return len(params[keys[i]]) > len(params[keys[j]]) })
+	parameterize := func(s string) string {
+		for _, key := range keys {
+			s = strings.ReplaceAll(s, params[key], "{{"+key+"}}")
+		}
+		return s
+	}
+
+	rec := &Recipe{Name: name, CreatedAt: time.Now()}
+	seen := make(map[string]bool)
+	for _, msg := range messages {
+		switch {
+		case msg.Role == "user":
+			rec.Prompts = append(rec.Prompts, parameterize(msg.Content))
+		case msg.Role == "system" && msg.FilePath != "":
+			rel, err := filepath.Rel(root, msg.FilePath)
+			if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+				continue // Only files inside the workspace make sense in another one
+			}
+			glob := parameterize(filepath.ToSlash(rel))
+			if !seen[glob] {
+				seen[glob] = true
+				rec.Context = append(rec.Context, glob)
+			}
+		}
+	}
+	if len(rec.Prompts) == 0 {
+		return nil, fmt.Errorf("the conversation has no prompts to save")
+	}
+
+	for _, key := range keys {
+		if !rec.uses(key) {
+			return nil, fmt.Errorf("value %q of parameter %q does not appear in any prompt or context file", params[key], key)
+		}
+	}
+	rec.Params = rec.placeholders()
+	return rec, nil
+}
+
+// placeholders returns the distinct placeholder names used, in sorted order
+func (r *Recipe) placeholders() []string {
+	seen := make(map[string]bool)
+	for _, s := range append(append([]string{}, r.Prompts...), r.Context...) {
+		for _, match := range placeholderPattern.FindAllStringSubmatch(s, -1) {
+			seen[match[1]] = true
+		}
+	}
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// uses reports whether the recipe has a {{key}} placeholder
+func (r *Recipe) uses(key string) bool {
+	for _, name := range r.placeholders() {
+		if name == key {
+			return true
+		}
+	}
+	return false
+}
+
+// Render returns the prompts and context globs with placeholders filled from
+// args. Every parameter must be given, and unknown arguments are rejected so
+// typos do not go unnoticed.
+func (r *Recipe) Render(args map[string]string) (prompts, context []string, err error) {
+	params := r.placeholders()
+	known := make(map[string]bool, len(params))
+	var missing []string
+	for _, name := range params {
+		known[name] = true
+		if _, ok := args[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, nil, fmt.Errorf("missing --arg for %s", strings.Join(missing, ", "))
+	}
+	for name := range args {
+		if !known[name] {
+			return nil, nil, fmt.Errorf("recipe %s has no parameter %q (parameters: %s)", r.Name, name, strings.Join(params, ", "))
+		}
+	}
+
+	fill := func(s string) string {
+		return placeholderPattern.ReplaceAllStringFunc(s, func(match string) string {
+			return args[placeholderPattern.FindStringSubmatch(match)[1]]
+		})
+	}
+	for _, prompt := range r.Prompts {
+		prompts = append(prompts, fill(prompt))
+	}
+	for _, glob := range r.Context {
+		context = append(context, fill(glob))
+	}
+	return prompts, context, nil
+}
+
+// Save writes the recipe to dir/name.json, replacing any recipe of that name
+func Save(dir string, rec *Recipe) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("creating recipe directory: %w", err)
+	}
+	data, err := json.MarshalIndent(rec, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("marshaling recipe: %w", err)
+	}
+	path := filepath.Join(dir, rec.Name+".json")
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return "", fmt.Errorf("writing recipe: %w", err)
+	}
+	return path, nil
+}
+
+// Load finds a recipe by name, looking in each directory in turn, so a
+// project's .riptide/recipes can override the user's own
+func Load(name string, dirs ...string) (*Recipe, error) {
+	if err := ValidateName(name); err != nil {
+		return nil, err
+	}
+	for _, dir := range dirs {
+		data, err := os.ReadFile(filepath.Join(dir, name+".json"))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading recipe: %w", err)
+		}
+		var rec Recipe
+		if err := json.Unmarshal(data, &rec); err != nil {
+			return nil, fmt.Errorf("parsing recipe %s: %w", name, err)
+		}
+		if rec.Name == "" {
+			rec.Name = name
+		}
+		if len(rec.Prompts) == 0 {
+			return nil, fmt.Errorf("recipe %s has no prompts", name)
+		}
+		return &rec, nil
+	}
+	return nil, fmt.Errorf("no recipe named %q", name)
+}
+
+// List returns the recipes in dirs by name; earlier directories win
+func List(dirs ...string) ([]*Recipe, error) {
+	byName := make(map[string]*Recipe)
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading recipe directory: %w", err)
+		}
+		for _, entry := range entries {
+			name := strings.TrimSuffix(entry.Name(), ".json")
+			if entry.IsDir() || name == entry.Name() || byName[name] != nil {
+				continue
+			}
+			if rec, err := Load(name, dir); err == nil {
+				byName[name] = rec
+			}
+		}
+	}
+
+	recipes := make([]*Recipe, 0, len(byName))
+	for _, rec := range byName {
+		recipes = append(recipes, rec)
+	}
+	sort.Slice(recipes, func(i, j int) bool { return recipes[i].Name < recipes[j].Name })
+	return recipes, nil
+}
+
+// ExpandContext returns the files under root matching the globs, skipping
+// hidden and excluded directories. Globs use the same syntax as riptide watch:
+// ** matches any number of directories and a glob without a slash matches the
+// file name at any depth.
+func ExpandContext(root string, globs []string) ([]string, error) {
+	if len(globs) == 0 {
+		return nil, nil
+	}
+	for _, glob := range globs {
+		if strings.HasPrefix(glob, "/") || glob == ".." || strings.HasPrefix(glob, "../") {
+			return nil, fmt.Errorf("context glob %q must be relative to the workspace", glob)
+		}
+	}
+
+	excluded := config.GetExcludedFiles()
+	matched := make(map[string]bool)
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if p != root && (strings.HasPrefix(d.Name(), ".") || excluded[d.Name()]) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		for _, glob := range globs {
+			if matchGlob(glob, rel) {
+				matched[p] = true
+				break
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("expanding context globs: %w", err)
+	}
+	if len(matched) > maxContextFiles {
+		return nil, fmt.Errorf("context globs match %d files (limit %d); narrow them in the recipe", len(matched), maxContextFiles)
+	}
+
+	files := make([]string, 0, len(matched))
+	for file := range matched {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// matchGlob matches a slash-separated relative path against a context glob
+func matchGlob(glob, rel string) bool {
+	if !strings.Contains(glob, "/") {
+		ok, _ := path.Match(glob, path.Base(rel))
+		return ok
+	}
+	return watch.Match(glob, rel)
+}
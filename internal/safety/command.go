@@ -0,0 +1,276 @@
+package safety
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/alchemy-labs-co/riptide/internal/config"
+)
+
+// CommandRisk is how dangerous a shell command is judged to be
+type CommandRisk int
+
+const (
+	RiskSafe    CommandRisk = iota
+	RiskConfirm             // Runs only after the user confirms twice
+	RiskBlocked             // Never runs
+)
+
+// CommandClassification explains why a command was flagged
+type CommandClassification struct {
+	Risk    CommandRisk
+	Rule    string // Name of the matching rule
+	Segment string // The part of the command that matched
+}
+
+// commandRule is a compiled rule applied to each segment of a command
+type commandRule struct {
+	name   string
+	re     *regexp.Regexp
+	action string // config.CommandActionConfirm or config.CommandActionBlock; empty uses the policy default
+}
+
+// builtinCommandRules flag common destructive or irreversible commands. They are
+// matched against each pipeline segment with sudo/env prefixes removed.
+var builtinCommandRules = []commandRule{
+	{name: "recursive force delete", re: regexp.MustCompile(`^rm\s+(.*\s)?(-[a-zA-Z]*r[a-zA-Z]*f|-[a-zA-Z]*f[a-zA-Z]*r|(-r|-R|--recursive)\s+(.*\s)?(-f|--force)|(-f|--force)\s+(.*\s)?(-r|-R|--recursive))\b`)},
+	{name: "force push", re: regexp.MustCompile(`^git\s+push\b.*\s(-f|--force|--force-with-lease)\b`)},
+	{name: "history rewrite", re: regexp.MustCompile(`^git\s+(reset\s+--hard|clean\s+-[a-zA-Z]*f|filter-branch|filter-repo)\b`)},
+	{name: "package publish", re: regexp.MustCompile(`^((npm|pnpm|yarn)\s+publish|cargo\s+publish|twine\s+upload|gem\s+push|poetry\s+publish|docker\s+push|gh\s+release\s+create)\b`)},
+	{name: "disk overwrite", re: regexp.MustCompile(`^(mkfs(\.\w+)?|dd\s+.*\bof=/dev/|shred)\b`)},
+	{name: "permission blast", re: regexp.MustCompile(`^(chmod|chown)\s+(-[a-zA-Z]*R[a-zA-Z]*\s+)\S+\s+/(\s|$)`)},
+	{name: "fork bomb", re: regexp.MustCompile(`:\(\)\s*\{\s*:\s*\|\s*:\s*&\s*\}\s*;\s*:`)},
+}
+
+// shellInterpreters are commands that execute whatever is piped into them
+var shellInterpreters = regexp.MustCompile(`^(sh|bash|zsh|dash|ksh|fish|python3?|perl|ruby|node)(\s+-\S+)*\s*$`)
+
+// downloaders fetch remote content
+var downloaders = regexp.MustCompile(`^(curl|wget|fetch)\b`)
+
+// commandPrefixes are wrappers stripped before matching, e.g. "sudo rm -rf x"
+var commandPrefixes = regexp.MustCompile(`^((sudo|doas|nohup|time|command|exec)(\s+-\S+)*\s+|env(\s+\w+=\S*)*\s+|\w+=\S*\s+)+`)
+
+// shellExpansions are characters that run or redirect something beyond a
+// segment's own command: substitutions, redirections and background jobs
+const shellExpansions = "`$<>&"
+
+// CommandClassifier flags dangerous shell commands using built-in and configured
+// rules, and matches commands against the configured allow and deny lists.
+// Every tool call carrying a shell command (run_command, start_process,
+// run_benchmarks with hyperfine, docker_run) is classified before it is
+// approved or run, in the TUI and in headless runs.
+type CommandClassifier struct {
+	rules         []commandRule
+	defaultAction string
+	allow         []string
+	deny          []string
+}
+
+// NewCommandClassifier creates a classifier from the command policy configuration
+func NewCommandClassifier(policy config.CommandPolicyConfig) (*CommandClassifier, error) {
+	defaultAction := policy.Dangerous
+	if defaultAction == "" {
+		defaultAction = config.CommandActionConfirm
+	}
+	if defaultAction != config.CommandActionConfirm && defaultAction != config.CommandActionBlock {
+		return nil, fmt.Errorf("invalid dangerous command policy %q (use %s or %s)",
+			defaultAction, config.CommandActionConfirm, config.CommandActionBlock)
+	}
+
+	rules := append([]commandRule{}, builtinCommandRules...)
+	for i, rule := range policy.Rules {
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("compiling command rule %d: %w", i+1, err)
+		}
+		if rule.Action != "" && rule.Action != config.CommandActionConfirm && rule.Action != config.CommandActionBlock {
+			return nil, fmt.Errorf("command rule %d: invalid action %q", i+1, rule.Action)
+		}
+
+		name := rule.Name
+		if name == "" {
+			name = fmt.Sprintf("custom rule %d", i+1)
+		}
+		rules = append(rules, commandRule{name: name, re: re, action: rule.Action})
+	}
+
+	return &CommandClassifier{
+		rules:         rules,
+		defaultAction: defaultAction,
+		allow:         normalizePrefixes(policy.Allow),
+		deny:          normalizePrefixes(policy.Deny),
+	}, nil
+}
+
+// Classify returns the most severe classification among the command's segments
+func (c *CommandClassifier) Classify(command string) CommandClassification {
+	result := CommandClassification{Risk: RiskSafe}
+
+	for _, pipeline := range splitCommandList(command) {
+		// Structural check: a download piped straight into an interpreter
+		for i := 1; i < len(pipeline); i++ {
+			if downloaders.MatchString(stripPrefixes(pipeline[i-1])) && shellInterpreters.MatchString(stripPrefixes(pipeline[i])) {
+				result = c.worse(result, CommandClassification{
+					Risk:    c.risk(""),
+					Rule:    "download piped to shell",
+					Segment: pipeline[i-1] + " | " + pipeline[i],
+				})
+			}
+		}
+
+		for _, segment := range pipeline {
+			stripped := stripPrefixes(segment)
+			if prefix := matchPrefix(stripped, c.deny); prefix != "" {
+				result = c.worse(result, CommandClassification{
+					Risk:    RiskBlocked,
+					Rule:    "deny " + prefix,
+					Segment: segment,
+				})
+			}
+			for _, rule := range c.rules {
+				if rule.re.MatchString(stripped) {
+					result = c.worse(result, CommandClassification{
+						Risk:    c.risk(rule.action),
+						Rule:    rule.name,
+						Segment: segment,
+					})
+				}
+			}
+		}
+	}
+
+	return result
+}
+
+// Allowed reports whether every segment of a command starts with a prefix on
+// the allow list. Commands with substitutions, redirections or background jobs
+// are never allowed, since the prefix would not describe everything they run,
+// and neither are segments behind sudo, env or VAR=value, which change what
+// the allowed command does (LD_PRELOAD=x.so go test).
+func (c *CommandClassifier) Allowed(command string) bool {
+	pipelines := splitCommandList(command)
+	if len(c.allow) == 0 || len(pipelines) == 0 {
+		return false
+	}
+	for _, pipeline := range pipelines {
+		for _, segment := range pipeline {
+			segment = strings.TrimSpace(segment)
+			if strings.ContainsAny(segment, shellExpansions) || stripPrefixes(segment) != segment || matchPrefix(segment, c.allow) == "" {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// risk maps a rule action to a risk level, falling back to the policy default
+func (c *CommandClassifier) risk(action string) CommandRisk {
+	if action == "" {
+		action = c.defaultAction
+	}
+	if action == config.CommandActionBlock {
+		return RiskBlocked
+	}
+	return RiskConfirm
+}
+
+// worse returns whichever classification is more severe, keeping the first on ties
+func (c *CommandClassifier) worse(current, candidate CommandClassification) CommandClassification {
+	if candidate.Risk > current.Risk {
+		return candidate
+	}
+	return current
+}
+
+// stripPrefixes removes wrappers such as sudo and VAR=value assignments
+func stripPrefixes(segment string) string {
+	return commandPrefixes.ReplaceAllString(strings.TrimSpace(segment), "")
+}
+
+// normalizePrefixes collapses the whitespace in configured command prefixes,
+// dropping empty ones
+func normalizePrefixes(prefixes []string) []string {
+	var normalized []string
+	for _, prefix := range prefixes {
+		if prefix = strings.Join(strings.Fields(prefix), " "); prefix != "" {
+			normalized = append(normalized, prefix)
+		}
+	}
+	return normalized
+}
+
+// matchPrefix returns the first prefix that a segment starts with as whole
+// words, so "go test" matches "go test ./..." but not "go tester"
+func matchPrefix(segment string, prefixes []string) string {
+	segment = strings.Join(strings.Fields(segment), " ")
+	for _, prefix := range prefixes {
+		if segment == prefix || strings.HasPrefix(segment, prefix+" ") {
+			return prefix
+		}
+	}
+	return ""
+}
+
+// splitCommandList splits a command line into pipelines (separated by ;, &&, || or
+// newlines), each made of its pipe-separated segments. Quoted text is kept intact
+// so separators inside strings are not treated as operators.
+func splitCommandList(command string) [][]string {
+	var pipelines [][]string
+	var pipeline []string
+	var current strings.Builder
+	var quote rune
+	escaped := false
+
+	flushSegment := func() {
+		if segment := strings.TrimSpace(current.String()); segment != "" {
+			pipeline = append(pipeline, segment)
+		}
+		current.Reset()
+	}
+	flushPipeline := func() {
+		flushSegment()
+		if len(pipeline) > 0 {
+			pipelines = append(pipelines, pipeline)
+		}
+		pipeline = nil
+	}
+
+	runes := []rune(command)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+
+		switch {
+		case escaped:
+			escaped = false
+			current.WriteRune(r)
+		case r == '\\' && quote != '\'':
+			escaped = true
+			current.WriteRune(r)
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+			current.WriteRune(r)
+		case r == '\'' || r == '"':
+			quote = r
+			current.WriteRune(r)
+		case r == ';' || r == '\n':
+			flushPipeline()
+		case r == '&' && i+1 < len(runes) && runes[i+1] == '&':
+			flushPipeline()
+			i++
+		case r == '|' && i+1 < len(runes) && runes[i+1] == '|':
+			flushPipeline()
+			i++
+		case r == '|':
+			flushSegment()
+		default:
+			current.WriteRune(r)
+		}
+	}
+	flushPipeline()
+
+	return pipelines
+}
@@ -0,0 +1,274 @@
+package safety
+
+import (
+	"fmt"
+	"math"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/alchemy-labs-co/riptide/internal/config"
+)
+
+// secretPattern is a named expression matching one kind of secret. When the
+// expression has capture groups, only the first that matched is replaced so
+// the surrounding key name stays readable.
+type secretPattern struct {
+	name string
+	re   *regexp.Regexp
+	skip func(value string) bool // Values that match but are not secrets; nil skips none
+}
+
+// builtinPatterns match common credentials that should never reach the API
+var builtinPatterns = []secretPattern{
+	{
+		name: "private-key",
+		re:   regexp.MustCompile(`-----BEGIN [A-Z0-9 ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z0-9 ]*PRIVATE KEY-----`),
+	},
+	{
+		name: "aws-access-key",
+		re:   regexp.MustCompile(`\b((?:AKIA|ASIA)[0-9A-Z]{16})\b`),
+	},
+	{
+		name: "aws-secret-key",
+		re:   regexp.MustCompile(`(?i)aws_secret_access_key["']?\s*[:=]\s*["']?([A-Za-z0-9/+=]{40})`),
+	},
+	{
+		name: "github-token",
+		re:   regexp.MustCompile(`\b(gh[pousr]_[A-Za-z0-9]{36,})\b`),
+	},
+	{
+		name: "slack-token",
+		re:   regexp.MustCompile(`\b(xox[abprs]-[A-Za-z0-9-]{10,})\b`),
+	},
+	{
+		name: "api-key",
+		re:   regexp.MustCompile(`\b(sk-[A-Za-z0-9_-]{20,})\b`),
+	},
+	{
+		// An upper-case key given a quoted literal, e.g. PASSWORD: "hunter2".
+		// Code such as MaxTokens: cfg.MaxTokens is left alone.
+		name: "secret-assignment",
+		re:   regexp.MustCompile(`(?m)^\s*(?:export\s+)?[A-Z0-9_]*(?:API_KEY|SECRET|TOKEN|PASSWORD|PASSWD)[A-Z0-9_]*["']?\s*[:=]\s*["']([^\s"']{4,})["']`),
+		skip: isReference,
+	},
+	{
+		// An upper-case key given a bare value on an env-style line, e.g.
+		// API_KEY=abc123, but not API_KEY = os.getenv("API_KEY")
+		name: "secret-assignment",
+		re:   regexp.MustCompile(`(?m)^\s*(?:export\s+)?[A-Z0-9_]*(?:API_KEY|SECRET|TOKEN|PASSWORD|PASSWD)[A-Z0-9_]*\s*=\s*([^\s"'#]{4,})\s*(?:#.*)?$`),
+		skip: func(value string) bool { return isReference(value) || identifierOrExpression.MatchString(value) },
+	},
+}
+
+// identifierOrExpression matches values that are not secrets: identifiers,
+// dotted names, plain numbers such as MAX_TOKENS=4096, and anything with
+// calls or operators
+var identifierOrExpression = regexp.MustCompile(`^[A-Za-z_][A-Za-z_]*(?:\.[A-Za-z_]\w*)*$|^\d+$|[()\[\]{},;+*]`)
+
+// isReference reports whether a value points at a secret kept elsewhere, such
+// as ${API_KEY}, %API_KEY% or a <placeholder>
+func isReference(value string) bool {
+	return strings.HasPrefix(value, "$") || strings.HasPrefix(value, "%") || strings.HasPrefix(value, "<") ||
+		strings.Contains(value, "{{")
+}
+
+// Keys without a recognizable prefix are caught by how random they look: a
+// quoted run of base64 or URL-safe characters at least minEntropyLength long,
+// with upper and lower case letters, several digits, and a Shannon entropy of at
+// least minEntropy bits per character. Hex strings such as commit hashes have
+// no upper case, and identifiers rarely have enough digits.
+const (
+	minEntropyLength = 32
+	minEntropy       = 4.2
+	minEntropyDigits = 3
+)
+
+// quotedToken matches a quoted base64 or URL-safe token, capturing it without
+// the quotes
+var quotedToken = regexp.MustCompile("[\"'`]([A-Za-z0-9+/_=-]{32,})[\"'`]")
+
+// integrityHash matches subresource integrity values such as those filling
+// package-lock.json, which are random but not secret
+var integrityHash = regexp.MustCompile(`^sha(1|256|384|512)-`)
+
+// Redactor replaces secrets in text with labeled placeholders
+type Redactor struct {
+	patterns []secretPattern
+	entropy  bool
+}
+
+// NewRedactor creates a redactor with the built-in patterns plus any extra
+// regular expressions from the configuration
+func NewRedactor(cfg config.RedactionConfig) (*Redactor, error) {
+	patterns := append([]secretPattern{}, builtinPatterns...)
+	for i, expr := range cfg.Patterns {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("compiling redaction pattern %d: %w", i+1, err)
+		}
+		patterns = append(patterns, secretPattern{name: "custom", re: re})
+	}
+	return &Redactor{patterns: patterns, entropy: cfg.Entropy}, nil
+}
+
+// Redaction summarizes what Redact removed from a piece of text
+type Redaction struct {
+	Count int
+	Kinds []string // Sorted, without duplicates
+}
+
+// String formats the redaction for notices, e.g. "2 secrets (api-key, private-key)"
+func (r Redaction) String() string {
+	noun := "secrets"
+	if r.Count == 1 {
+		noun = "secret"
+	}
+	return fmt.Sprintf("%d %s (%s)", r.Count, noun, strings.Join(r.Kinds, ", "))
+}
+
+// secretFileExtensions are config formats whose random-looking values are
+// more likely keys than data
+var secretFileExtensions = map[string]bool{
+	".env": true, ".ini": true, ".cfg": true, ".conf": true, ".properties": true,
+	".toml": true, ".yaml": true, ".yml": true, ".netrc": true, ".npmrc": true, ".pypirc": true,
+}
+
+// SecretFile reports whether path is an env or config file, where quoted
+// random-looking strings are redacted as keys. Elsewhere they are more often
+// base64 fixtures and test vectors, which must survive a read and edit.
+func SecretFile(path string) bool {
+	name := strings.ToLower(filepath.Base(path))
+	if name == ".env" || strings.HasPrefix(name, ".env.") || secretFileExtensions[filepath.Ext(name)] {
+		return true
+	}
+	if filepath.Ext(name) == ".json" {
+		for _, word := range []string{"config", "secret", "credential", "settings"} {
+			if strings.Contains(name, word) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// RedactFrom scrubs content read from paths, or produced by a tool when there
+// are none. The entropy check applies only when one of paths is a SecretFile.
+func (r *Redactor) RedactFrom(text string, paths ...string) (string, Redaction) {
+	entropy := false
+	for _, path := range paths {
+		entropy = entropy || SecretFile(path)
+	}
+	return r.redact(text, r.entropy && entropy)
+}
+
+// Redact returns text the user typed or pasted with every detected secret
+// replaced by [REDACTED:<kind>]
+func (r *Redactor) Redact(text string) (string, Redaction) {
+	return r.redact(text, r.entropy)
+}
+
+// redact replaces the secrets the patterns find, and random-looking quoted
+// strings when entropy is set
+func (r *Redactor) redact(text string, entropy bool) (string, Redaction) {
+	var result Redaction
+	kinds := make(map[string]bool)
+
+	for _, pattern := range r.patterns {
+		text = pattern.re.ReplaceAllStringFunc(text, func(match string) string {
+			placeholder := "[REDACTED:" + pattern.name + "]"
+
+			// Keep the text around the captured secret, such as "API_KEY="
+			sub := pattern.re.FindStringSubmatchIndex(match)
+			secret, replaced := match, placeholder
+			for group := 2; group+1 < len(sub); group += 2 {
+				if sub[group] >= 0 {
+					secret = match[sub[group]:sub[group+1]]
+					replaced = match[:sub[group]] + placeholder + match[sub[group+1]:]
+					break
+				}
+			}
+
+			// Leave placeholders from an earlier pattern alone
+			if strings.HasPrefix(secret, "[REDACTED:") || (pattern.skip != nil && pattern.skip(secret)) {
+				return match
+			}
+
+			result.Count++
+			kinds[pattern.name] = true
+			return replaced
+		})
+	}
+
+	if entropy {
+		text = quotedToken.ReplaceAllStringFunc(text, func(match string) string {
+			token := match[1 : len(match)-1]
+			if !looksRandom(token) {
+				return match
+			}
+			result.Count++
+			kinds["high-entropy"] = true
+			return match[:1] + "[REDACTED:high-entropy]" + match[len(match)-1:]
+		})
+	}
+
+	for kind := range kinds {
+		result.Kinds = append(result.Kinds, kind)
+	}
+	sort.Strings(result.Kinds)
+
+	return text, result
+}
+
+// placeholderPattern matches the placeholders redact leaves in place of secrets
+var placeholderPattern = regexp.MustCompile(`\[REDACTED:[a-z-]+\]`)
+
+// AddedPlaceholders lists the redaction placeholders after holds more often
+// than before. The model only ever sees placeholders for secrets, so a write
+// adding one would replace a real secret with the placeholder text.
+func AddedPlaceholders(before, after string) []string {
+	counts := make(map[string]int)
+	for _, placeholder := range placeholderPattern.FindAllString(before, -1) {
+		counts[placeholder]++
+	}
+	var added []string
+	for _, placeholder := range placeholderPattern.FindAllString(after, -1) {
+		if counts[placeholder]--; counts[placeholder] == -1 {
+			added = append(added, placeholder)
+		}
+	}
+	return added
+}
+
+// looksRandom reports whether a token is random enough to be a key
+func looksRandom(token string) bool {
+	if len(token) < minEntropyLength || integrityHash.MatchString(token) {
+		return false
+	}
+
+	var upper, lower, digits int
+	counts := make(map[rune]int)
+	for _, c := range token {
+		counts[c]++
+		switch {
+		case unicode.IsUpper(c):
+			upper++
+		case unicode.IsLower(c):
+			lower++
+		case unicode.IsDigit(c):
+			digits++
+		}
+	}
+	if upper == 0 || lower == 0 || digits < minEntropyDigits {
+		return false
+	}
+
+	var entropy float64
+	for _, n := range counts {
+		p := float64(n) / float64(len(token))
+		entropy -= p * math.Log2(p)
+	}
+	return entropy >= minEntropy
+}
@@ -0,0 +1,143 @@
+package session
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// maxPromptHistory is how many prompts the history keeps; the file is compacted
+// once it holds twice as many
+const maxPromptHistory = 1000
+
+// PromptHistory is the list of prompts typed in any session, oldest first, kept
+// in a file with one JSON string per line so multi-line prompts survive
+type PromptHistory struct {
+	path    string
+	prompts []string
+	lines   int // Lines in the file, including ones dropped from prompts
+}
+
+// DefaultPromptHistoryPath returns the prompt history file under the XDG data home
+func DefaultPromptHistoryPath() (string, error) {
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("finding home directory: %w", err)
+		}
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(dataHome, "riptide", "prompt_history"), nil
+}
+
+// LoadPromptHistory reads the prompt history at path. A missing file is an empty
+// history; an empty path keeps the history in memory only.
+func LoadPromptHistory(path string) (*PromptHistory, error) {
+	h := &PromptHistory{path: path}
+	if path == "" {
+		return h, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return h, nil
+		}
+		return h, fmt.Errorf("opening prompt history: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		h.lines++
+		var prompt string
+		if err := json.Unmarshal(scanner.Bytes(), &prompt); err != nil || prompt == "" {
+			// Skip lines damaged by an interrupted append
+			continue
+		}
+		h.prompts = append(h.prompts, prompt)
+	}
+	if err := scanner.Err(); err != nil {
+		return h, fmt.Errorf("reading prompt history: %w", err)
+	}
+
+	if len(h.prompts) > maxPromptHistory {
+		h.prompts = h.prompts[len(h.prompts)-maxPromptHistory:]
+	}
+	return h, nil
+}
+
+// Prompts returns the remembered prompts, oldest first
+func (h *PromptHistory) Prompts() []string {
+	return h.prompts
+}
+
+// Add remembers a prompt, unless it repeats the one before it, and appends it to
+// the history file
+func (h *PromptHistory) Add(prompt string) error {
+	if strings.TrimSpace(prompt) == "" {
+		return nil
+	}
+	if n := len(h.prompts); n > 0 && h.prompts[n-1] == prompt {
+		return nil
+	}
+	h.prompts = append(h.prompts, prompt)
+	if len(h.prompts) > maxPromptHistory {
+		h.prompts = h.prompts[len(h.prompts)-maxPromptHistory:]
+	}
+	if h.path == "" {
+		return nil
+	}
+
+	if h.lines >= 2*maxPromptHistory {
+		return h.compact()
+	}
+
+	if err := os.MkdirAll(filepath.Dir(h.path), 0700); err != nil {
+		return fmt.Errorf("creating prompt history directory: %w", err)
+	}
+	data, err := json.Marshal(prompt)
+	if err != nil {
+		return fmt.Errorf("marshaling prompt: %w", err)
+	}
+	file, err := os.OpenFile(h.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("opening prompt history: %w", err)
+	}
+	defer file.Close()
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("appending prompt: %w", err)
+	}
+	h.lines++
+	return nil
+}
+
+// compact rewrites the history file with only the prompts still remembered
+func (h *PromptHistory) compact() error {
+	var b strings.Builder
+	for _, prompt := range h.prompts {
+		data, err := json.Marshal(prompt)
+		if err != nil {
+			return fmt.Errorf("marshaling prompt: %w", err)
+		}
+		b.Write(data)
+		b.WriteByte('\n')
+	}
+
+	// Write to a temp file first so a crash never leaves a truncated history
+	tmpPath := h.path + ".tmp"
+	if err := os.WriteFile(tmpPath, []byte(b.String()), 0600); err != nil {
+		return fmt.Errorf("writing prompt history: %w", err)
+	}
+	if err := os.Rename(tmpPath, h.path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("replacing prompt history: %w", err)
+	}
+	h.lines = len(h.prompts)
+	return nil
+}
@@ -0,0 +1,269 @@
+package session
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/alchemy-labs-co/riptide/internal/api"
+)
+
+// previewLength caps the preview text stored for listings
+const previewLength = 80
+
+// Session is a saved conversation
+type Session struct {
+	ID         string                    `json:"id"`
+	CreatedAt  time.Time                 `json:"created_at"`
+	UpdatedAt  time.Time                 `json:"updated_at"`
+	WorkingDir string                    `json:"working_dir"`
+	Model      string                    `json:"model"`
+	Preview    string                    `json:"preview"`         // First user message, shortened
+	Title      string                    `json:"title,omitempty"` // Short title generated from the first exchange
+	Usage      Usage                     `json:"usage"`           // Totals over every run of the session
+	Messages   []api.ConversationMessage `json:"messages"`
+}
+
+// Usage is the tokens a session has used and what they cost
+type Usage struct {
+	InputTokens  int     `json:"input_tokens"`
+	OutputTokens int     `json:"output_tokens"`
+	CachedTokens int     `json:"cached_tokens"`
+	Cost         float64 `json:"cost"` // US dollars
+}
+
+// Add returns the sum of two usages
+func (u Usage) Add(other Usage) Usage {
+	return Usage{
+		InputTokens:  u.InputTokens + other.InputTokens,
+		OutputTokens: u.OutputTokens + other.OutputTokens,
+		CachedTokens: u.CachedTokens + other.CachedTokens,
+		Cost:         u.Cost + other.Cost,
+	}
+}
+
+// Tokens returns the total tokens used
+func (u Usage) Tokens() int {
+	return u.InputTokens + u.OutputTokens + u.CachedTokens
+}
+
+// Summary describes a saved session without loading its messages into the UI
+type Summary struct {
+	ID           string
+	Title        string
+	Preview      string
+	WorkingDir   string
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	MessageCount int
+	Usage        Usage
+}
+
+// Label names the session in listings: its title, or its first prompt until it has one
+func (s Summary) Label() string {
+	if s.Title != "" {
+		return s.Title
+	}
+	return s.Preview
+}
+
+// Store reads and writes sessions as JSON files in a directory
+type Store struct {
+	dir string
+}
+
+// NewStore creates a store rooted at dir
+func NewStore(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// DefaultDir returns the sessions directory under the XDG data home
+func DefaultDir() (string, error) {
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("finding home directory: %w", err)
+		}
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(dataHome, "riptide", "sessions"), nil
+}
+
+// New creates an unsaved session for the given working directory and model
+func New(workingDir, model string) *Session {
+	now := time.Now()
+	return &Session{
+		ID:         newID(now),
+		CreatedAt:  now,
+		UpdatedAt:  now,
+		WorkingDir: workingDir,
+		Model:      model,
+	}
+}
+
+// newID returns a sortable, unique session ID such as 20261016-150405-3fa9
+func newID(now time.Time) string {
+	suffix := make([]byte, 2)
+	if _, err := rand.Read(suffix); err != nil {
+		return now.Format("20060102-150405")
+	}
+	return now.Format("20060102-150405") + "-" + hex.EncodeToString(suffix)
+}
+
+// Save writes the session with its current messages, replacing any earlier copy
+func (s *Store) Save(sess *Session, messages []api.ConversationMessage) error {
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return fmt.Errorf("creating sessions directory: %w", err)
+	}
+
+	sess.Messages = messages
+	sess.UpdatedAt = time.Now()
+	if sess.Preview == "" {
+		sess.Preview = preview(messages)
+	}
+
+	data, err := json.MarshalIndent(sess, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling session: %w", err)
+	}
+
+	// Write to a temp file first so a crash never leaves a truncated session
+	path := s.path(sess.ID)
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return fmt.Errorf("writing session: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("replacing session: %w", err)
+	}
+
+	return nil
+}
+
+// Load reads a saved session by ID
+func (s *Store) Load(id string) (*Session, error) {
+	data, err := os.ReadFile(s.path(id))
+	if err != nil {
+		return nil, fmt.Errorf("reading session: %w", err)
+	}
+
+	var sess Session
+	if err := json.Unmarshal(data, &sess); err != nil {
+		return nil, fmt.Errorf("parsing session: %w", err)
+	}
+	return &sess, nil
+}
+
+// List returns up to limit saved sessions, most recently updated first.
+// A limit of zero or less returns every session.
+func (s *Store) List(limit int) ([]Summary, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading sessions directory: %w", err)
+	}
+
+	var summaries []Summary
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+
+		sess, err := s.Load(strings.TrimSuffix(entry.Name(), ".json"))
+		if err != nil {
+			// Skip unreadable sessions rather than hiding every other one
+			continue
+		}
+
+		summaries = append(summaries, Summary{
+			ID:           sess.ID,
+			Title:        sess.Title,
+			Preview:      sess.Preview,
+			WorkingDir:   sess.WorkingDir,
+			CreatedAt:    sess.CreatedAt,
+			UpdatedAt:    sess.UpdatedAt,
+			MessageCount: len(sess.Messages),
+			Usage:        sess.Usage,
+		})
+	}
+
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].UpdatedAt.After(summaries[j].UpdatedAt)
+	})
+
+	if limit > 0 && len(summaries) > limit {
+		summaries = summaries[:limit]
+	}
+	return summaries, nil
+}
+
+// Find returns the session a query names: the most recent session for an empty
+// query, otherwise the session whose ID starts with the query or, failing
+// that, the most recent one whose title or first prompt contains it
+func (s *Store) Find(query string) (Summary, error) {
+	summaries, err := s.List(0)
+	if err != nil {
+		return Summary{}, err
+	}
+	if len(summaries) == 0 {
+		return Summary{}, errors.New("no saved sessions")
+	}
+
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return summaries[0], nil
+	}
+	for _, summary := range summaries {
+		if strings.HasPrefix(summary.ID, query) {
+			return summary, nil
+		}
+	}
+	lower := strings.ToLower(query)
+	for _, summary := range summaries {
+		if strings.Contains(strings.ToLower(summary.Title), lower) || strings.Contains(strings.ToLower(summary.Preview), lower) {
+			return summary, nil
+		}
+	}
+	return Summary{}, fmt.Errorf("no saved session matches %q", query)
+}
+
+// Delete removes a saved session
+func (s *Store) Delete(id string) error {
+	if err := os.Remove(s.path(id)); err != nil {
+		return fmt.Errorf("deleting session: %w", err)
+	}
+	return nil
+}
+
+// path returns the file path for a session ID
+func (s *Store) path(id string) string {
+	return filepath.Join(s.dir, id+".json")
+}
+
+// preview returns the first user message, flattened and shortened for listings
+func preview(messages []api.ConversationMessage) string {
+	for _, msg := range messages {
+		if msg.Role != "user" {
+			continue
+		}
+
+		text := strings.Join(strings.Fields(msg.Content), " ")
+		runes := []rune(text)
+		if len(runes) > previewLength {
+			return string(runes[:previewLength-1]) + "…"
+		}
+		return text
+	}
+	return ""
+}
@@ -0,0 +1,368 @@
+// Package share exports a conversation for other people to read, as a
+// self-contained HTML page or as a secret GitHub gist.
+package share
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/alchemy-labs-co/riptide/internal/api"
+	"github.com/alchemy-labs-co/riptide/internal/session"
+)
+
+const (
+	// Dir is where HTML exports are written, relative to the workspace
+	Dir = ".riptide/shares"
+
+	// ExportDir is where /export writes when no path is given, relative to the workspace
+	ExportDir = ".riptide/exports"
+
+	// GistsURL is the GitHub API endpoint for creating gists
+	GistsURL = "https://api.github.com/gists"
+
+	// maxToolOutput caps each tool result in an export; the full output stays in the session
+	maxToolOutput = 4000
+)
+
+// Formats lists the export formats by file extension
+var Formats = []string{"md", "json", "html"}
+
+// Transcript is a conversation prepared for sharing. Redact is applied to all
+// text before it is rendered.
+type Transcript struct {
+	Title    string
+	Model    string
+	Created  time.Time
+	Messages []api.ConversationMessage
+	Redact   func(string) string
+	Usage    *session.Usage // Token and cost summary, omitted when nil
+	Full     bool           // Keep tool output whole instead of cutting it to maxToolOutput
+}
+
+// Entry is one rendered part of the conversation
+type Entry struct {
+	Kind     string // user, assistant, reasoning, tool-call, tool-result or file
+	Label    string
+	Content  string
+	Time     time.Time
+	Duration time.Duration // How long the model reasoned, for reasoning entries that recorded it
+}
+
+// Entries turns the history into displayable entries. The system prompt and
+// the content of files added to context are left out; files are listed by name.
+func (t Transcript) Entries() []Entry {
+	redact := t.Redact
+	if redact == nil {
+		redact = func(s string) string { return s }
+	}
+
+	// Tool results only carry the call ID, so remember each call's name
+	toolNames := make(map[string]string)
+
+	var entries []Entry
+	for _, msg := range t.Messages {
+		switch msg.Role {
+		case "user":
+			entries = append(entries, Entry{Kind: "user", Label: "You", Content: redact(msg.Content), Time: msg.Timestamp})
+
+		case "assistant":
+			if msg.ReasoningContent != "" {
+				entry := Entry{Kind: "reasoning", Label: "Reasoning", Content: redact(msg.ReasoningContent), Time: msg.Timestamp}
+				if msg.ReasoningMillis > 0 {
+					entry.Duration = time.Duration(msg.ReasoningMillis) * time.Millisecond
+					entry.Label = fmt.Sprintf("Thought for %s", entry.Duration.Round(100*time.Millisecond))
+				}
+				entries = append(entries, entry)
+			}
+			if msg.Content != "" {
+				entries = append(entries, Entry{Kind: "assistant", Label: "Riptide", Content: redact(msg.Content), Time: msg.Timestamp})
+			}
+			for _, call := range msg.ToolCalls {
+				toolNames[call.ID] = call.Function.Name
+				entries = append(entries, Entry{
+					Kind:    "tool-call",
+					Label:   call.Function.Name,
+					Content: redact(prettyArguments(call.Function.Arguments)),
+					Time:    msg.Timestamp,
+				})
+			}
+
+		case "tool":
+			label := toolNames[msg.ToolCallID]
+			if label == "" {
+				label = "tool"
+			}
+			content := msg.Content
+			if !t.Full {
+				content = truncate(content)
+			}
+			entries = append(entries, Entry{Kind: "tool-result", Label: label + " result", Content: redact(content), Time: msg.Timestamp})
+
+		case "system":
+			if msg.FilePath != "" {
+				entries = append(entries, Entry{Kind: "file", Label: "File added to context", Content: msg.FilePath, Time: msg.Timestamp})
+			}
+		}
+	}
+	return entries
+}
+
+// prettyArguments indents JSON tool arguments, leaving anything else as is
+func prettyArguments(arguments string) string {
+	var out bytes.Buffer
+	if err := json.Indent(&out, []byte(arguments), "", "  "); err != nil {
+		return arguments
+	}
+	return out.String()
+}
+
+// truncate shortens long tool output, keeping the beginning
+func truncate(s string) string {
+	if len(s) <= maxToolOutput {
+		return s
+	}
+	return s[:maxToolOutput] + fmt.Sprintf("\n... (%d more bytes)", len(s)-maxToolOutput)
+}
+
+// Markdown renders the transcript as Markdown, as used for gists
+func (t Transcript) Markdown() string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("# %s\n\n", t.Title))
+	b.WriteString(fmt.Sprintf("_%s · %s · shared from Riptide_\n", t.Model, t.Created.Format("2006-01-02 15:04")))
+	if t.Usage != nil {
+		b.WriteString(fmt.Sprintf("\n%s\n", t.usageLine()))
+	}
+
+	for _, entry := range t.Entries() {
+		b.WriteString("\n")
+		switch entry.Kind {
+		case "user", "assistant":
+			b.WriteString(fmt.Sprintf("## %s\n\n%s\n", entry.Label, entry.Content))
+		case "reasoning":
+			b.WriteString(fmt.Sprintf("<details><summary>%s</summary>\n\n%s\n\n</details>\n", entry.Label, fence(entry.Content, "")))
+		case "tool-call":
+			b.WriteString(fmt.Sprintf("**Tool call: `%s`**\n\n%s\n", entry.Label, fence(entry.Content, "json")))
+		case "tool-result":
+			b.WriteString(fmt.Sprintf("<details><summary>%s</summary>\n\n%s\n\n</details>\n", entry.Label, fence(entry.Content, "")))
+		case "file":
+			b.WriteString(fmt.Sprintf("_%s: `%s`_\n", entry.Label, entry.Content))
+		}
+	}
+	return b.String()
+}
+
+// usageLine summarizes the tokens and cost of the conversation
+func (t Transcript) usageLine() string {
+	return fmt.Sprintf("Tokens: %d input, %d output, %d cached · Cost: $%.4f",
+		t.Usage.InputTokens, t.Usage.OutputTokens, t.Usage.CachedTokens, t.Usage.Cost)
+}
+
+// fence wraps content in a code fence longer than any backtick run inside it
+func fence(content, language string) string {
+	ticks := "```"
+	for strings.Contains(content, ticks) {
+		ticks += "`"
+	}
+	return ticks + language + "\n" + content + "\n" + ticks
+}
+
+// htmlPage is the self-contained export page; all styling is inline
+var htmlPage = template.Must(template.New("share").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Helvetica, Arial, sans-serif; background: #0f172a; color: #e2e8f0; margin: 0; }
+main { max-width: 860px; margin: 0 auto; padding: 32px 20px 64px; }
+h1 { font-size: 1.5rem; margin-bottom: 4px; }
+.meta { color: #94a3b8; font-size: 0.9rem; margin-bottom: 32px; }
+.entry { margin: 16px 0; padding: 12px 16px; border-radius: 8px; background: #1e293b; }
+.entry.user { background: #1e3a5f; }
+.entry.file { background: none; padding: 4px 16px; color: #94a3b8; font-style: italic; }
+.label { font-weight: 600; font-size: 0.85rem; color: #38bdf8; margin-bottom: 6px; }
+.entry.user .label { color: #a5b4fc; }
+.entry.tool-call .label, .entry.tool-result .label { color: #fbbf24; }
+.time { float: right; color: #64748b; font-weight: normal; }
+.content { white-space: pre-wrap; word-wrap: break-word; line-height: 1.5; }
+pre { font-family: ui-monospace, SFMono-Regular, Menlo, Consolas, monospace; font-size: 0.85rem; white-space: pre-wrap; word-wrap: break-word; margin: 0; }
+details summary { cursor: pointer; font-weight: 600; font-size: 0.85rem; color: #fbbf24; }
+details.reasoning summary { color: #c084fc; }
+details pre { margin-top: 8px; }
+</style>
+</head>
+<body>
+<main>
+<h1>{{.Title}}</h1>
+<div class="meta">{{.Model}} · {{.Created.Format "2006-01-02 15:04"}} · shared from Riptide{{if .UsageLine}}<br>{{.UsageLine}}{{end}}</div>
+{{range .Entries}}
+{{- if eq .Kind "file"}}
+<div class="entry file">{{.Label}}: {{.Content}}</div>
+{{- else if eq .Kind "reasoning"}}
+<div class="entry"><details class="reasoning"><summary>{{.Label}}</summary><pre>{{.Content}}</pre></details></div>
+{{- else if eq .Kind "tool-result"}}
+<div class="entry tool-result"><details><summary>{{.Label}}</summary><pre>{{.Content}}</pre></details></div>
+{{- else if eq .Kind "tool-call"}}
+<div class="entry tool-call"><div class="label">Tool call: {{.Label}}</div><pre>{{.Content}}</pre></div>
+{{- else}}
+<div class="entry {{.Kind}}"><div class="label">{{.Label}}<span class="time">{{.Time.Format "15:04"}}</span></div><div class="content">{{.Content}}</div></div>
+{{- end}}
+{{end}}
+</main>
+</body>
+</html>
+`))
+
+// HTML renders the transcript as a single page with no external resources
+func (t Transcript) HTML() (string, error) {
+	var b strings.Builder
+	data := struct {
+		Transcript
+		Entries   []Entry
+		UsageLine string
+	}{Transcript: t, Entries: t.Entries()}
+	if t.Usage != nil {
+		data.UsageLine = t.usageLine()
+	}
+	if err := htmlPage.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("rendering transcript: %w", err)
+	}
+	return b.String(), nil
+}
+
+// JSON renders the transcript as an indented JSON document of its entries
+func (t Transcript) JSON() (string, error) {
+	type jsonEntry struct {
+		Kind              string    `json:"kind"`
+		Label             string    `json:"label"`
+		Content           string    `json:"content"`
+		Time              time.Time `json:"time"`
+		ReasoningDuration int64     `json:"reasoning_duration_ms,omitempty"`
+	}
+	doc := struct {
+		Title   string         `json:"title"`
+		Model   string         `json:"model"`
+		Created time.Time      `json:"created"`
+		Usage   *session.Usage `json:"usage,omitempty"`
+		Entries []jsonEntry    `json:"entries"`
+	}{Title: t.Title, Model: t.Model, Created: t.Created, Usage: t.Usage, Entries: []jsonEntry{}}
+	for _, entry := range t.Entries() {
+		doc.Entries = append(doc.Entries, jsonEntry{
+			Kind:              entry.Kind,
+			Label:             entry.Label,
+			Content:           entry.Content,
+			Time:              entry.Time,
+			ReasoningDuration: entry.Duration.Milliseconds(),
+		})
+	}
+
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("marshaling transcript: %w", err)
+	}
+	return string(data) + "\n", nil
+}
+
+// Render renders the transcript in one of Formats
+func (t Transcript) Render(format string) (string, error) {
+	switch format {
+	case "md":
+		return t.Markdown(), nil
+	case "json":
+		return t.JSON()
+	case "html":
+		return t.HTML()
+	default:
+		return "", fmt.Errorf("unknown export format %q (use %s)", format, strings.Join(Formats, ", "))
+	}
+}
+
+// FormatForPath picks the export format from a file extension, or "" when the
+// extension is not one of Formats
+func FormatForPath(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".md", ".markdown":
+		return "md"
+	case ".json":
+		return "json"
+	case ".html", ".htm":
+		return "html"
+	}
+	return ""
+}
+
+// WriteHTML writes the HTML export to dir/name.html and returns its path
+func (t Transcript) WriteHTML(dir, name string) (string, error) {
+	page, err := t.HTML()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("creating share directory: %w", err)
+	}
+	path := filepath.Join(dir, name+".html")
+	if err := os.WriteFile(path, []byte(page), 0644); err != nil {
+		return "", fmt.Errorf("writing share file: %w", err)
+	}
+	return path, nil
+}
+
+// Token returns the GitHub token used for gists, from GITHUB_TOKEN or GH_TOKEN
+func Token() string {
+	if token := os.Getenv("GITHUB_TOKEN"); token != "" {
+		return token
+	}
+	return os.Getenv("GH_TOKEN")
+}
+
+// CreateGist uploads the Markdown transcript as a secret gist and returns its URL
+func (t Transcript) CreateGist(ctx context.Context, token, name string) (string, error) {
+	payload := map[string]interface{}{
+		"description": t.Title,
+		"public":      false,
+		"files": map[string]interface{}{
+			name + ".md": map[string]string{"content": t.Markdown()},
+		},
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("marshaling gist: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, GistsURL, bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("creating gist request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("creating gist: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return "", fmt.Errorf("creating gist: %s: %s", resp.Status, strings.TrimSpace(string(detail)))
+	}
+
+	var created struct {
+		URL string `json:"html_url"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+		return "", fmt.Errorf("parsing gist response: %w", err)
+	}
+	return created.URL, nil
+}
@@ -0,0 +1,30 @@
+package ui
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// cancelStream stops the response being streamed without quitting. What has
+// arrived stays in the transcript and, when the model had started answering,
+// in the history, so a follow-up prompt can ask it to continue. Tool calls are
+// only complete at the end of a stream, so none are run.
+func (m Model) cancelStream() (tea.Model, tea.Cmd) {
+	if m.streamCancel != nil {
+		m.streamCancel()
+	}
+	m.streamEvents = nil
+
+	m.endReasoning()
+	m.finalizeCurrentMessage()
+	note := "Stopped the response"
+	if m.hasContent {
+		m.history.AddReasoningAssistantMessage(m.accumulatedContent, m.accumulatedReasoning, m.reasoningDuration, nil)
+		note = "Stopped the response; the partial answer is kept"
+	}
+	m.resetStreamState()
+	m.state = StateReady
+	m.addSystemMessage(FormatInfo(note, m.config.UI.EnableEmoji))
+	m.saveSession()
+	m.updateViewport()
+	return m, nil
+}
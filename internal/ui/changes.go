@@ -0,0 +1,168 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/alchemy-labs-co/riptide/internal/functions"
+)
+
+// maxPreviewLines caps how many lines of a proposed diff the transcript shows
+const maxPreviewLines = 200
+
+// ChangeEditedMsg carries the content the user saved in their editor for a
+// proposed file change
+type ChangeEditedMsg struct {
+	Content string
+	Err     error
+}
+
+// showChanges adds the diff of each proposed file change to the transcript
+func (m *Model) showChanges(changes []functions.FileChange) {
+	for _, change := range changes {
+		m.addDiffMessage(change.Diff(displayPath(m.workspaceRoot, change.Path)))
+	}
+	m.updateViewport()
+}
+
+// addDiffMessage adds a unified diff to the transcript, cut to maxPreviewLines
+func (m *Model) addDiffMessage(diff string) {
+	lines := strings.Split(strings.TrimSuffix(diff, "\n"), "\n")
+	if len(lines) > maxPreviewLines {
+		diff = strings.Join(lines[:maxPreviewLines], "\n") + fmt.Sprintf("\n... %d more lines", len(lines)-maxPreviewLines)
+	}
+	m.messages = append(m.messages, Message{Role: "diff", Content: strings.TrimSuffix(diff, "\n")})
+}
+
+// renderChangeQuestion asks whether to write the proposed file changes
+func (m Model) renderChangeQuestion(req *ApprovalRequestMsg) string {
+	changes := req.Changes
+	if req.edited != nil {
+		changes = req.edited
+	}
+
+	var paths []string
+	added, removed := 0, 0
+	for _, change := range changes {
+		paths = append(paths, displayPath(m.workspaceRoot, change.Path))
+		a, r := functions.DiffStat(change.Diff(change.Path))
+		added += a
+		removed += r
+	}
+
+	verb := "Apply"
+	if req.edited != nil {
+		verb = "Apply your version of"
+	}
+	question := fmt.Sprintf("%s %s (+%d -%d)?", verb, truncate(strings.Join(paths, ", "), max(m.width-60, 30)), added, removed)
+	keys := "(y/n, a: approve all edits"
+	if len(req.Changes) == 1 {
+		keys += ", e: edit"
+	}
+	return WarningStyle.Render(question) + " " + HelpStyle.Render(keys+")")
+}
+
+// editProposedChange opens the proposed content of a file in the user's editor
+func (m Model) editProposedChange() tea.Cmd {
+	change := m.pendingApproval.Changes[0]
+	if m.pendingApproval.edited != nil {
+		change = m.pendingApproval.edited[0]
+	}
+
+	// Keep the extension so the editor picks the right syntax
+	tmp, err := os.CreateTemp("", "riptide-*-"+filepath.Base(change.Path))
+	if err != nil {
+		return func() tea.Msg { return ChangeEditedMsg{Err: fmt.Errorf("creating temp file: %w", err)} }
+	}
+	tmpPath := tmp.Name()
+	_, err = tmp.WriteString(change.After)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return func() tea.Msg { return ChangeEditedMsg{Err: fmt.Errorf("writing temp file: %w", err)} }
+	}
+
+	editor := editorCommand()
+	cmd := exec.Command(editor[0], append(editor[1:], tmpPath)...)
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		defer os.Remove(tmpPath)
+		if err != nil {
+			return ChangeEditedMsg{Err: fmt.Errorf("running %s: %w", editor[0], err)}
+		}
+		content, err := os.ReadFile(tmpPath)
+		if err != nil {
+			return ChangeEditedMsg{Err: fmt.Errorf("reading edited file: %w", err)}
+		}
+		return ChangeEditedMsg{Content: string(content)}
+	})
+}
+
+// handleChangeEdited replaces the pending change with the user's version and
+// shows how it differs from the current file
+func (m Model) handleChangeEdited(msg ChangeEditedMsg) (tea.Model, tea.Cmd) {
+	if m.pendingApproval == nil || len(m.pendingApproval.Changes) != 1 {
+		return m, nil
+	}
+	if msg.Err != nil {
+		m.showError(msg.Err.Error(), false)
+		return m, nil
+	}
+
+	edited := m.pendingApproval.Changes[0]
+	if msg.Content == edited.After {
+		m.pendingApproval.edited = nil
+		m.addSystemMessage(FormatInfo("No changes to the proposed version", m.config.UI.EnableEmoji))
+		m.updateViewport()
+		return m, nil
+	}
+	edited.After = msg.Content
+	m.pendingApproval.edited = []functions.FileChange{edited}
+
+	m.addSystemMessage(FormatInfo("Your version:", m.config.UI.EnableEmoji))
+	m.showChanges(m.pendingApproval.edited)
+	return m, nil
+}
+
+// describeUserEdits tells the model how the user changed its proposed files
+// before they were written
+func describeUserEdits(proposed, edited []functions.FileChange, root string) string {
+	var b strings.Builder
+	b.WriteString("The user edited your change before it was written. Differences from your version:\n")
+	for i, change := range edited {
+		if i >= len(proposed) {
+			break
+		}
+		path := displayPath(root, change.Path)
+		b.WriteString("\n" + functions.UnifiedDiff(path, proposed[i].After, change.After, false))
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// editorCommand returns the user's editor from $VISUAL or $EDITOR, split into
+// the program and its arguments, falling back to vi
+func editorCommand() []string {
+	for _, name := range []string{"VISUAL", "EDITOR"} {
+		if fields := strings.Fields(os.Getenv(name)); len(fields) > 0 {
+			return fields
+		}
+	}
+	return []string{"vi"}
+}
+
+// displayPath shows a path relative to the workspace root when it is inside it
+func displayPath(root, path string) string {
+	if root == "" {
+		return path
+	}
+	if rel, err := filepath.Rel(root, path); err == nil && !strings.HasPrefix(rel, "..") {
+		return rel
+	}
+	return path
+}
@@ -1,11 +1,14 @@
 package ui
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/alchemy-labs-co/riptide/internal/conversation"
 	"github.com/alchemy-labs-co/riptide/internal/functions"
 )
 
@@ -19,9 +22,10 @@ func (m Model) handleAddCommand(path string) (tea.Model, tea.Cmd) {
 	}
 
 	m.state = StateProcessing
+	m.scanProgress = nil
 	enableEmoji := m.config.UI.EnableEmoji
 
-	return m, func() tea.Msg {
+	return m, tea.Batch(m.spinner.Tick, func() tea.Msg {
 		// Normalize the path
 		normalizedPath, err := functions.NormalizePath(path)
 		if err != nil {
@@ -33,9 +37,13 @@ func (m Model) handleAddCommand(path string) (tea.Model, tea.Cmd) {
 		// Check if it's a file or directory
 		fileInfo, err := os.Stat(normalizedPath)
 		if err != nil {
-			return ProcessCompleteMsg{
-				Error: fmt.Errorf("accessing path: %w", err),
+			msg := ProcessCompleteMsg{Error: fmt.Errorf("accessing path: %w", err)}
+			if errors.Is(err, fs.ErrNotExist) {
+				if match := suggestPath(m.workspaceRoot, path); match != "" {
+					msg.Suggestion = "/add " + match
+				}
 			}
+			return msg
 		}
 
 		if fileInfo.IsDir() {
@@ -45,9 +53,12 @@ func (m Model) handleAddCommand(path string) (tea.Model, tea.Cmd) {
 			// Handle single file
 			return m.addFileToContext(normalizedPath, enableEmoji)
 		}
-	}
+	})
 }
 
+// ScanProgressMsg reports the progress of the directory scan run by /add
+type ScanProgressMsg functions.ScanProgress
+
 // addFileToContext adds a single file to the conversation context
 func (m Model) addFileToContext(filePath string, enableEmoji bool) tea.Msg {
 	// Check if file is already in context
@@ -65,21 +76,37 @@ func (m Model) addFileToContext(filePath string, enableEmoji bool) tea.Msg {
 		}
 	}
 
+	// Scrub secrets and quarantine instruction-like text before the content reaches the history
+	content, redaction := m.redactFrom(content, filePath)
+	content, reasons := guardInjection(filePath, content)
+
 	// Add to history
-	m.history.AddSystemMessage(content)
+	m.history.AddFileMessage(filePath, content)
+
+	result := FormatSuccess(fmt.Sprintf("Added file '%s' to conversation (~%s tokens)",
+		FormatFilePath(filePath),
+		formatTokenCount(conversation.EstimateTokens(content)),
+	), enableEmoji)
+	if redaction.Count > 0 {
+		result += "\n" + FormatWarning("Redacted "+redaction.String()+" — use /redact off to send as-is", enableEmoji)
+	}
+	if len(reasons) > 0 {
+		result += "\n" + FormatWarning("Quarantined instruction-like text ("+strings.Join(reasons, ", ")+"); the model is told to treat it as data", enableEmoji)
+	}
 
 	return ProcessCompleteMsg{
-		Result: FormatSuccess(fmt.Sprintf("Added file '%s' to conversation", FormatFilePath(filePath)), enableEmoji),
+		Result: result,
 	}
 }
 
 // addDirectoryToContext adds all eligible files from a directory to context
 func (m Model) addDirectoryToContext(dirPath string, enableEmoji bool) tea.Msg {
-	// Show scanning status
-	// Scanning directory...
-
-	// Scan the directory
-	result, err := m.scanner.ScanDirectory(dirPath)
+	// Scan the directory, showing progress in the status line
+	result, err := m.scanner.ScanDirectoryWithProgress(dirPath, func(progress functions.ScanProgress) {
+		if m.program != nil {
+			m.program.Send(ScanProgressMsg(progress))
+		}
+	})
 	if err != nil {
 		return ProcessCompleteMsg{
 			Error: fmt.Errorf("scanning directory: %w", err),
@@ -97,9 +124,22 @@ func (m Model) addDirectoryToContext(dirPath string, enableEmoji bool) tea.Msg {
 
 		// Add each file to conversation history
 		addedCount := 0
+		addedTokens := 0
+		redactedSecrets := 0
+		var redactedFiles, quarantinedFiles []string
 		for filePath, content := range fileContents {
 			if !m.history.FileAlreadyInContext(filePath) {
-				m.history.AddSystemMessage(fmt.Sprintf("Content of file '%s':\n\n%s", filePath, content))
+				fileContent, redaction := m.redactFrom(fmt.Sprintf("Content of file '%s':\n\n%s", filePath, content), filePath)
+				if redaction.Count > 0 {
+					redactedSecrets += redaction.Count
+					redactedFiles = append(redactedFiles, filePath)
+				}
+				fileContent, reasons := guardInjection(filePath, fileContent)
+				if len(reasons) > 0 {
+					quarantinedFiles = append(quarantinedFiles, filePath)
+				}
+				m.history.AddFileMessage(filePath, fileContent)
+				addedTokens += conversation.EstimateTokens(fileContent)
 				addedCount++
 			}
 		}
@@ -107,7 +147,7 @@ func (m Model) addDirectoryToContext(dirPath string, enableEmoji bool) tea.Msg {
 		// Format result message
 		var resultMsg strings.Builder
 		resultMsg.WriteString(FormatSuccess(
-			fmt.Sprintf("Added folder '%s' to conversation", FormatFilePath(dirPath)),
+			fmt.Sprintf("Added folder '%s' to conversation (~%s tokens)", FormatFilePath(dirPath), formatTokenCount(addedTokens)),
 			enableEmoji,
 		))
 
@@ -133,6 +173,28 @@ func (m Model) addDirectoryToContext(dirPath string, enableEmoji bool) tea.Msg {
 			}
 		}
 
+		if redactedSecrets > 0 {
+			resultMsg.WriteString(fmt.Sprintf("\n%s Redacted %d secrets in %d files — use /redact off to send as-is\n",
+				GetIcon("warning", enableEmoji), redactedSecrets, len(redactedFiles)))
+			for _, filePath := range redactedFiles {
+				resultMsg.WriteString(fmt.Sprintf("  %s %s\n",
+					GetIcon("warning", enableEmoji),
+					FormatFilePath(filePath),
+				))
+			}
+		}
+
+		if len(quarantinedFiles) > 0 {
+			resultMsg.WriteString(fmt.Sprintf("\n%s Quarantined instruction-like text in %d files; the model is told to treat it as data\n",
+				GetIcon("warning", enableEmoji), len(quarantinedFiles)))
+			for _, filePath := range quarantinedFiles {
+				resultMsg.WriteString(fmt.Sprintf("  %s %s\n",
+					GetIcon("warning", enableEmoji),
+					FormatFilePath(filePath),
+				))
+			}
+		}
+
 		if len(result.SkippedFiles) > 0 {
 			resultMsg.WriteString(fmt.Sprintf("\n%s Skipped files: (%d)\n",
 				GetIcon("warning", enableEmoji), len(result.SkippedFiles)))
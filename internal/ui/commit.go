@@ -0,0 +1,145 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	openai "github.com/sashabaranov/go-openai"
+
+	"github.com/alchemy-labs-co/riptide/internal/api"
+	"github.com/alchemy-labs-co/riptide/internal/git"
+)
+
+// commitPrompt asks for a commit message describing a staged diff
+const commitPrompt = "Write a git commit message for the staged changes below. Use a subject line of at most 72 characters in the imperative mood (\"Add\", \"Fix\"), then, only if the change needs explaining, a blank line and a short body wrapped at 72 characters saying what changed and why. Reply with the message only, without code fences or commentary."
+
+// maxCommitDiff is how much of the staged diff is sent when writing a commit message
+const maxCommitDiff = 30000
+
+// CommitMessageMsg carries a generated commit message for the staged changes
+type CommitMessageMsg struct {
+	Message string
+	Usage   api.TokenUsage
+	Err     error
+}
+
+// handleCommitCommand commits the staged changes after confirmation, with the
+// given message or one written by the model from the staged diff
+func (m Model) handleCommitCommand(message string) (tea.Model, tea.Cmd) {
+	m.textInput.SetValue("")
+	if !git.IsRepository(m.workspaceRoot) {
+		m.addErrorMessage("Not inside a git repository")
+		m.updateViewport()
+		return m, nil
+	}
+	diff, err := git.Diff(m.workspaceRoot, true)
+	if err != nil {
+		m.showError(fmt.Sprintf("Reading staged changes: %v", err), false)
+		return m, nil
+	}
+	if diff == "" {
+		m.addErrorMessage("Nothing is staged; stage changes with git add first")
+		m.updateViewport()
+		return m, nil
+	}
+
+	if message != "" {
+		return m.handleCommitMessage(CommitMessageMsg{Message: message})
+	}
+
+	provider, ok := m.apiClient.(api.OverridableProvider)
+	if !ok {
+		m.addErrorMessage("The current provider cannot write commit messages; use /commit <message>")
+		m.updateViewport()
+		return m, nil
+	}
+	stat, _ := git.DiffStat(m.workspaceRoot)
+	if len(diff) > maxCommitDiff {
+		diff = diff[:maxCommitDiff] + "\n... (diff truncated)"
+	}
+	messages := []openai.ChatCompletionMessage{
+		{Role: openai.ChatMessageRoleSystem, Content: commitPrompt},
+		{Role: openai.ChatMessageRoleUser, Content: stat + "\n\n" + diff},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	m.streamCancel = cancel
+	m.state = StateStreaming
+	m.showWelcome = false
+	m.dismissError()
+	m.addSystemMessage(FormatInfo("Writing a commit message for the staged changes", m.config.UI.EnableEmoji))
+	m.updateViewport()
+
+	writer := provider.WithOverrides(api.Overrides{NoTools: true})
+	return m, tea.Batch(m.spinner.Tick, func() tea.Msg {
+		events, err := writer.CreateChatCompletionStream(ctx, messages)
+		if err != nil {
+			return CommitMessageMsg{Err: err}
+		}
+		content, usage, err := collectAnswer(ctx, events)
+		return CommitMessageMsg{Message: cleanCommitMessage(content), Usage: usage, Err: err}
+	})
+}
+
+// handleCommitMessage shows the proposed commit message and asks to commit with it
+func (m Model) handleCommitMessage(msg CommitMessageMsg) (tea.Model, tea.Cmd) {
+	m.state = StateReady
+	m.history.UpdateTokenUsage(msg.Usage.InputTokens, msg.Usage.OutputTokens, msg.Usage.CachedTokens)
+	if msg.Err != nil {
+		m.showError(fmt.Sprintf("Writing commit message: %v", msg.Err), false)
+		return m, nil
+	}
+	if msg.Message == "" {
+		m.showError("Writing commit message: the model returned an empty message", false)
+		return m, nil
+	}
+
+	m.pendingCommit = msg.Message
+	m.addSystemMessage("Commit message:\n\n" + indentLines(msg.Message, "  "))
+	m.updateViewport()
+	return m, nil
+}
+
+// handleCommitKeyPress answers the commit confirmation prompt
+func (m Model) handleCommitKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "y", "Y":
+		message := m.pendingCommit
+		m.pendingCommit = ""
+		commit, err := git.Commit(m.workspaceRoot, message)
+		if err != nil {
+			m.showError(err.Error(), false)
+			return m, nil
+		}
+		m.addSystemMessage(FormatSuccess("Committed "+commit, m.config.UI.EnableEmoji))
+		m.updateViewport()
+	case "n", "N", "esc":
+		m.pendingCommit = ""
+		m.addSystemMessage(FormatInfo("Commit cancelled; the changes are still staged", m.config.UI.EnableEmoji))
+		m.updateViewport()
+	case "ctrl+c":
+		return m.quit()
+	}
+	return m, nil
+}
+
+// cleanCommitMessage drops the code fence or label a model may wrap a message in
+func cleanCommitMessage(message string) string {
+	lines := strings.Split(strings.TrimSpace(message), "\n")
+	if len(lines) > 0 && strings.HasPrefix(lines[0], "```") {
+		lines = lines[1:]
+	}
+	if len(lines) > 0 && strings.HasPrefix(lines[len(lines)-1], "```") {
+		lines = lines[:len(lines)-1]
+	}
+	message = strings.TrimSpace(strings.Join(lines, "\n"))
+	message = strings.TrimSpace(strings.TrimPrefix(message, "Commit message:"))
+	return message
+}
+
+// indentLines prefixes every line of text
+func indentLines(text, prefix string) string {
+	return prefix + strings.ReplaceAll(text, "\n", "\n"+prefix)
+}
@@ -0,0 +1,307 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	openai "github.com/sashabaranov/go-openai"
+
+	"github.com/alchemy-labs-co/riptide/internal/api"
+	"github.com/alchemy-labs-co/riptide/internal/conversation"
+)
+
+// compareUsage is shown when /compare is given bad arguments
+const compareUsage = "Usage: /compare <model-a> <model-b> <prompt>"
+
+// compareState is a running or finished /compare: one prompt answered by two
+// models side by side. Neither answer is added to the conversation history.
+type compareState struct {
+	prompt string
+	panes  [2]*comparePane
+	cancel context.CancelFunc
+	scroll int // Lines scrolled from the top once both answers are in
+}
+
+// comparePane is one model's side of a comparison
+type comparePane struct {
+	model       string
+	reasoning   string
+	content     string
+	toolCalls   []api.ToolCall
+	usage       api.TokenUsage
+	estimated   bool // usage was estimated because the provider reported none
+	inputTokens int  // Estimated request size, used when no usage is reported
+	err         error
+	start       time.Time
+	elapsed     time.Duration
+	done        bool
+}
+
+// CompareEventMsg carries a stream event for one side of a comparison
+type CompareEventMsg struct {
+	Pane   int
+	Event  api.StreamEvent
+	Closed bool // The stream ended; Event is empty
+
+	events <-chan api.StreamEvent
+}
+
+// waitForCompare waits for the next event on one side of a comparison
+func waitForCompare(pane int, events <-chan api.StreamEvent) tea.Cmd {
+	return func() tea.Msg {
+		event, ok := <-events
+		return CompareEventMsg{Pane: pane, Event: event, Closed: !ok, events: events}
+	}
+}
+
+// handleCompareCommand sends the prompt to two models at once and shows their
+// streamed answers in split panes
+func (m Model) handleCompareCommand(arg string) (tea.Model, tea.Cmd) {
+	fields := strings.Fields(arg)
+	if len(fields) < 3 {
+		m.addErrorMessage(compareUsage)
+		m.updateViewport()
+		return m, nil
+	}
+	models := fields[:2]
+	prompt := strings.TrimSpace(arg)
+	for _, model := range models {
+		prompt = strings.TrimSpace(strings.TrimPrefix(prompt, model))
+	}
+
+	provider, ok := m.apiClient.(api.OverridableProvider)
+	if !ok {
+		m.addErrorMessage("The current provider cannot switch models")
+		m.updateViewport()
+		return m, nil
+	}
+
+	// The same request the next turn would send, without tools so both models answer directly
+	m.history.Trim()
+	messages := append(m.history.GetMessages(), openai.ChatCompletionMessage{
+		Role:    openai.ChatMessageRoleUser,
+		Content: prompt,
+	})
+	if reminder := conversation.BuildAmbientReminder(m.config.Ambient); reminder != "" {
+		messages = append(messages, openai.ChatCompletionMessage{
+			Role:    openai.ChatMessageRoleSystem,
+			Content: reminder,
+		})
+	}
+	inputTokens := 0
+	for _, msg := range messages {
+		inputTokens += conversation.EstimateTokens(msg.Content)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	compare := &compareState{prompt: prompt, cancel: cancel}
+	cmds := []tea.Cmd{m.spinner.Tick}
+	for i, model := range models {
+		pane := &comparePane{model: model, start: time.Now(), inputTokens: inputTokens}
+		compare.panes[i] = pane
+
+		events, err := provider.WithOverrides(api.Overrides{Model: model, NoTools: true}).CreateChatCompletionStream(ctx, messages)
+		if err != nil {
+			pane.err = err
+			pane.done = true
+			continue
+		}
+		cmds = append(cmds, waitForCompare(i, events))
+	}
+
+	m.compare = compare
+	m.state = StateStreaming
+	m.textInput.SetValue("")
+	m.checkCompareDone()
+	return m, tea.Batch(cmds...)
+}
+
+// handleCompareEvent records a streamed event in its pane and waits for the next
+func (m Model) handleCompareEvent(msg CompareEventMsg) (tea.Model, tea.Cmd) {
+	if m.compare == nil || msg.Pane < 0 || msg.Pane >= len(m.compare.panes) {
+		return m, nil
+	}
+	pane := m.compare.panes[msg.Pane]
+
+	if msg.Closed {
+		if !pane.done {
+			pane.done = true
+			pane.elapsed = time.Since(pane.start)
+			if pane.usage == (api.TokenUsage{}) && pane.err == nil {
+				pane.usage = api.TokenUsage{
+					InputTokens:  pane.inputTokens,
+					OutputTokens: conversation.EstimateTokens(pane.reasoning + pane.content),
+				}
+				pane.estimated = true
+			}
+		}
+		m.checkCompareDone()
+		return m, nil
+	}
+
+	switch msg.Event.Type {
+	case api.EventTypeReasoning:
+		pane.reasoning += msg.Event.ReasoningContent
+	case api.EventTypeContent:
+		pane.content += msg.Event.Content
+	case api.EventTypeToolCall:
+		pane.toolCalls = msg.Event.ToolCalls
+	case api.EventTypeError:
+		pane.err = msg.Event.Error
+	case api.EventTypeRetry:
+		if msg.Event.Retry.Restart {
+			pane.reasoning, pane.content = "", ""
+		}
+	case api.EventTypeDone:
+		if msg.Event.Usage != nil {
+			pane.usage = *msg.Event.Usage
+			m.history.UpdateTokenUsage(pane.usage.InputTokens, pane.usage.OutputTokens, pane.usage.CachedTokens)
+		}
+	}
+	return m, waitForCompare(msg.Pane, msg.events)
+}
+
+// checkCompareDone returns to the ready state once both answers are in
+func (m *Model) checkCompareDone() {
+	for _, pane := range m.compare.panes {
+		if !pane.done {
+			return
+		}
+	}
+	m.compare.cancel()
+	m.state = StateReady
+}
+
+// handleCompareKeyPress scrolls the panes, stops the comparison or closes it
+func (m Model) handleCompareKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc, tea.KeyCtrlC:
+		if m.state != StateReady {
+			// Stop both streams; the panes finish as the streams close
+			m.compare.cancel()
+			return m, nil
+		}
+		m.addSystemMessage(m.compare.summary())
+		m.compare = nil
+		m.updateViewport()
+
+	case tea.KeyUp:
+		m.compare.scroll = max(m.compare.scroll-1, 0)
+	case tea.KeyDown:
+		m.compare.scroll++
+	case tea.KeyPgUp:
+		m.compare.scroll = max(m.compare.scroll-10, 0)
+	case tea.KeyPgDown:
+		m.compare.scroll += 10
+	}
+	return m, nil
+}
+
+// cost returns the pane's price at the model's regular-hours rates
+func (p *comparePane) cost() float64 {
+	return api.PricingFor(p.model).Cost(p.usage)
+}
+
+// stats describes how long the answer took, its size and its cost
+func (p *comparePane) stats() string {
+	approx := ""
+	if p.estimated {
+		approx = "~"
+	}
+	return fmt.Sprintf("%.1fs · in %s%s / out %s%s tokens · %s$%.4f",
+		p.elapsed.Seconds(),
+		approx, formatTokenCount(p.usage.InputTokens),
+		approx, formatTokenCount(p.usage.OutputTokens),
+		approx, p.cost())
+}
+
+// answer returns the pane's reply, noting errors and tool calls it asked for
+func (p *comparePane) answer() string {
+	text := strings.TrimSpace(p.content)
+	if len(p.toolCalls) > 0 {
+		names := make([]string, len(p.toolCalls))
+		for i, call := range p.toolCalls {
+			names[i] = call.Function.Name
+		}
+		text += fmt.Sprintf("\n\n(asked to call %s; tools are not run in a comparison)", strings.Join(names, ", "))
+	}
+	if p.err != nil {
+		text += fmt.Sprintf("\n\nError: %v", p.err)
+	}
+	return strings.TrimSpace(text)
+}
+
+// summary renders the finished comparison for the transcript
+func (c *compareState) summary() string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("Compared %s and %s on: %s", c.panes[0].model, c.panes[1].model, c.prompt))
+	for _, pane := range c.panes {
+		b.WriteString(fmt.Sprintf("\n\n── %s · %s ──\n", pane.model, pane.stats()))
+		if answer := pane.answer(); answer != "" {
+			b.WriteString(answer)
+		} else {
+			b.WriteString("(no answer)")
+		}
+	}
+	return b.String()
+}
+
+// renderCompare renders both answers side by side with their cost
+func (m Model) renderCompare() string {
+	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(SecondaryColor)
+	title := titleStyle.Render("Compare") + HelpStyle.Render(" • "+truncate(firstLine(m.compare.prompt), max(m.width-14, 10)))
+
+	paneWidth := (m.width - 1) / 2
+	textWidth := max(paneWidth-4, 10)
+	bodyHeight := max(m.height-9, 3)
+
+	panes := make([]string, len(m.compare.panes))
+	for i, pane := range m.compare.panes {
+		var status string
+		switch {
+		case !pane.done:
+			status = m.spinner.View() + " " + InfoStyle.Render(fmt.Sprintf("streaming %.1fs", time.Since(pane.start).Seconds()))
+		case pane.err != nil && pane.content == "":
+			status = ErrorStyle.Render("failed")
+		default:
+			status = SuccessStyle.Render(pane.stats())
+		}
+
+		var text strings.Builder
+		if pane.reasoning != "" {
+			text.WriteString(HelpStyle.Width(textWidth).Render("Thinking: "+strings.TrimSpace(pane.reasoning)) + "\n\n")
+		}
+		text.WriteString(lipgloss.NewStyle().Width(textWidth).Render(pane.answer()))
+		lines := strings.Split(text.String(), "\n")
+
+		// Follow the stream while it runs; scroll from the top once both are in
+		start := 0
+		if m.state != StateReady {
+			start = max(len(lines)-bodyHeight, 0)
+		} else {
+			start = min(m.compare.scroll, max(len(lines)-bodyHeight, 0))
+		}
+		end := min(start+bodyHeight, len(lines))
+
+		body := lipgloss.NewStyle().Bold(true).Foreground(AccentColor).Render(pane.model) + "\n" +
+			status + "\n\n" + strings.Join(lines[start:end], "\n")
+		panes[i] = lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(SecondaryColor).
+			Padding(0, 1).
+			Width(paneWidth - 2).
+			Height(bodyHeight + 3).
+			Render(body)
+	}
+
+	footer := "↑/↓ PgUp/PgDn to scroll • Esc to close and keep both answers in the transcript"
+	if m.state != StateReady {
+		footer = "Esc to stop both models"
+	}
+
+	return title + "\n\n" + lipgloss.JoinHorizontal(lipgloss.Top, panes...) + "\n" + HelpStyle.Render(footer)
+}
@@ -0,0 +1,85 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// maxPathCompletions caps the paths offered at once; typing more narrows them
+const maxPathCompletions = 5
+
+// pathCompletionTarget finds the path being typed at the end of the input: the
+// argument of /add, or an @ mention in a prompt. It returns the input before the
+// path and the partial path, with ok false when no path is being typed.
+func pathCompletionTarget(value string) (prefix, partial string, ok bool) {
+	if len(value) > len("/add ") && strings.EqualFold(value[:len("/add ")], "/add ") {
+		partial = strings.TrimLeft(value[len("/add "):], " ")
+		return value[:len(value)-len(partial)], partial, true
+	}
+
+	start := strings.LastIndexAny(value, " \t\n") + 1
+	if word := value[start:]; strings.HasPrefix(word, "@") {
+		return value[:start+1], word[1:], true
+	}
+	return "", "", false
+}
+
+// completePath lists the files and directories that complete a partial path,
+// relative to the workspace unless it is absolute. Names the directory scanner
+// skips are left out, and directories end in a slash so completion can carry
+// on inside them.
+func (m Model) completePath(partial string) []Command {
+	dir, base := filepath.Split(partial)
+	searchDir := dir
+	if !filepath.IsAbs(searchDir) {
+		searchDir = filepath.Join(m.workspaceRoot, dir)
+	}
+	entries, err := os.ReadDir(searchDir)
+	if err != nil {
+		return nil
+	}
+
+	var matches []Command
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, base) || m.scanner.Excludes(name, entry.IsDir()) {
+			continue
+		}
+
+		completion, description := dir+name, "directory"
+		if entry.IsDir() {
+			completion += "/"
+		} else if info, err := entry.Info(); err == nil {
+			description = formatBytes(int(info.Size()))
+		}
+		if completion == partial {
+			continue
+		}
+
+		matches = append(matches, Command{Name: completion, Description: description})
+		if len(matches) == maxPathCompletions {
+			break
+		}
+	}
+	return matches
+}
+
+// selectAutocomplete makes the suggestion at index the one Tab fills in
+func (m *Model) selectAutocomplete(index int) {
+	m.autocompleteSelectedIndex = index
+	m.autocompleteSuggestion = m.autocompletePrefix + m.autocompleteMatches[index].Name
+	m.autocompleteCommand = &m.autocompleteMatches[index]
+}
+
+// acceptAutocomplete fills in the selected suggestion. Commands and files are
+// followed by a space; directories are not, so their contents are offered next.
+func (m *Model) acceptAutocomplete() {
+	value := m.autocompleteSuggestion
+	if !strings.HasSuffix(value, "/") {
+		value += " "
+	}
+	m.textInput.SetValue(value)
+	m.textInput.CursorEnd()
+	m.updateAutocomplete()
+}
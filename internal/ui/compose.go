@@ -0,0 +1,205 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/alchemy-labs-co/riptide/internal/api"
+	"github.com/alchemy-labs-co/riptide/internal/conversation"
+)
+
+// composePromptLines caps how many lines of the prompt the preview shows
+const composePromptLines = 8
+
+// composeVisibleFiles is how many context files the preview lists at once
+const composeVisibleFiles = 10
+
+// openCompose previews the request the current input would send
+func (m Model) openCompose() (tea.Model, tea.Cmd) {
+	if strings.HasPrefix(strings.TrimSpace(m.textInput.Value()), "/") {
+		m.addErrorMessage("Compose previews prompts; commands run directly")
+		m.updateViewport()
+		return m, nil
+	}
+	m.autocompleteActive = false
+	m.composeActive = true
+	m.composeIndex = 0
+	m.composeExcluded = make(map[string]bool)
+	m.composeReminderTokens = conversation.EstimateTokens(conversation.BuildAmbientReminder(m.config.Ambient))
+	return m, nil
+}
+
+// handleComposeKeyPress toggles context files and sends or leaves the preview
+func (m Model) handleComposeKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	files := m.history.GetContextItems()
+
+	switch msg.Type {
+	case tea.KeyEsc, tea.KeyCtrlE, tea.KeyCtrlC:
+		// Back to the input with the prompt as typed; deselections are discarded
+		m.composeActive = false
+		m.composeExcluded = nil
+
+	case tea.KeyUp, tea.KeyShiftTab:
+		if len(files) > 0 {
+			m.composeIndex = (m.composeIndex - 1 + len(files)) % len(files)
+		}
+
+	case tea.KeyDown, tea.KeyTab:
+		if len(files) > 0 {
+			m.composeIndex = (m.composeIndex + 1) % len(files)
+		}
+
+	case tea.KeySpace:
+		if m.composeIndex < len(files) {
+			path := files[m.composeIndex].Path
+			m.composeExcluded[path] = !m.composeExcluded[path]
+		}
+
+	case tea.KeyEnter:
+		input := strings.TrimSpace(m.textInput.Value())
+		if input == "" {
+			return m, nil
+		}
+		m.composeActive = false
+
+		var removed []string
+		for _, file := range files {
+			if m.composeExcluded[file.Path] && m.history.RemoveFile(file.Path) {
+				removed = append(removed, FormatFilePath(file.Path))
+			}
+		}
+		m.composeExcluded = nil
+		if len(removed) > 0 {
+			m.addSystemMessage(FormatInfo(fmt.Sprintf("Removed %d file(s) from context: %s",
+				len(removed), strings.Join(removed, ", ")), m.config.UI.EnableEmoji))
+		}
+		return m.submitPrompt(input)
+	}
+	return m, nil
+}
+
+// renderCompose renders the outgoing request: the prompt, what is sent with it
+// and the context files, each with its estimated token count
+func (m Model) renderCompose() string {
+	menuStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(SecondaryColor).
+		Padding(1, 2).
+		Width(m.width - 4).
+		Height(m.height - 4)
+
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(SecondaryColor)
+	headerStyle := lipgloss.NewStyle().Bold(true)
+	labelStyle := lipgloss.NewStyle().Width(40)
+
+	var content strings.Builder
+	content.WriteString(titleStyle.Render("Compose • Request Preview"))
+	content.WriteString("\n\n")
+
+	// The prompt as it will be sent
+	prompt := strings.TrimSpace(m.textInput.Value())
+	promptTokens := 0
+	if prompt == "" {
+		content.WriteString(headerStyle.Render("Prompt"))
+		content.WriteString("\n" + HelpStyle.Render("  (empty — press Esc and type a prompt first)") + "\n")
+	} else {
+		promptTokens = conversation.EstimateTokens(prompt)
+		content.WriteString(headerStyle.Render(fmt.Sprintf("Prompt (~%s tokens)", formatTokenCount(promptTokens))))
+		content.WriteString("\n")
+		lines := strings.Split(prompt, "\n")
+		for i, line := range lines {
+			if i == composePromptLines {
+				content.WriteString(HelpStyle.Render(fmt.Sprintf("  ... %d more line(s)", len(lines)-composePromptLines)) + "\n")
+				break
+			}
+			content.WriteString("  " + truncate(line, max(m.width-12, 20)) + "\n")
+		}
+	}
+	if m.editingPrompt > 0 {
+		content.WriteString(WarningStyle.Render("  Replaces an earlier prompt and everything after it") + "\n")
+	}
+	content.WriteString("\n")
+
+	// Everything already in the history goes with it
+	messages := m.history.GetRawMessages()
+	systemTokens, conversationTokens, conversationCount := 0, 0, 0
+	for i, msg := range messages {
+		switch {
+		case i == 0:
+			systemTokens = msg.Tokens
+		case msg.Role != "system" || msg.FilePath == "":
+			conversationTokens += msg.Tokens
+			conversationCount++
+		}
+	}
+
+	content.WriteString(headerStyle.Render("Sent with it"))
+	content.WriteString("\n")
+	content.WriteString("  " + labelStyle.Render("System prompt") + HelpStyle.Render("~"+formatTokenCount(systemTokens)) + "\n")
+	content.WriteString("  " + labelStyle.Render(fmt.Sprintf("Conversation (%d messages)", conversationCount)) +
+		HelpStyle.Render("~"+formatTokenCount(conversationTokens)) + "\n")
+	if m.composeReminderTokens > 0 {
+		content.WriteString("  " + labelStyle.Render("Ambient state reminder") + HelpStyle.Render("~"+formatTokenCount(m.composeReminderTokens)) + "\n")
+	}
+	content.WriteString("\n")
+
+	// Context files can be left out of this and later requests
+	files := m.history.GetContextItems()
+	fileTokens := 0
+	content.WriteString(headerStyle.Render(fmt.Sprintf("Context files (%d)", len(files))))
+	content.WriteString("\n")
+	if len(files) == 0 {
+		content.WriteString(HelpStyle.Render("  None — use /add to include files") + "\n")
+	}
+
+	start := 0
+	if m.composeIndex >= composeVisibleFiles {
+		start = m.composeIndex - composeVisibleFiles + 1
+	}
+	for i, file := range files {
+		if !m.composeExcluded[file.Path] {
+			fileTokens += file.Tokens
+		}
+		if i < start || i >= start+composeVisibleFiles {
+			continue
+		}
+
+		line := "  "
+		if i == m.composeIndex {
+			line = lipgloss.NewStyle().Foreground(AccentColor).Render("▶ ")
+		}
+		check := "[x] "
+		style := lipgloss.NewStyle().Width(36)
+		if m.composeExcluded[file.Path] {
+			check = "[ ] "
+			style = style.Foreground(DimTextColor).Strikethrough(true)
+		} else if i == m.composeIndex {
+			style = style.Bold(true).Foreground(AccentColor)
+		}
+		line += check + style.Render(truncate(file.Path, 35)) + HelpStyle.Render("~"+formatTokenCount(file.Tokens))
+		content.WriteString(line + "\n")
+	}
+	if len(files) > composeVisibleFiles {
+		content.WriteString(HelpStyle.Render(fmt.Sprintf("  (%d files; ↑/↓ to scroll)", len(files))) + "\n")
+	}
+	content.WriteString("\n")
+
+	total := promptTokens + systemTokens + conversationTokens + m.composeReminderTokens + fileTokens
+	summary := fmt.Sprintf("Total: ~%s tokens", formatTokenCount(total))
+	if window := api.ContextWindow(m.config.API.Model); window > 0 {
+		summary += fmt.Sprintf(" of a %s-token context window", formatTokenCount(window))
+	}
+	content.WriteString(headerStyle.Render(summary))
+	if budget := api.ContextBudget(m.config); budget > 0 && total > budget {
+		content.WriteString("\n" + WarningStyle.Render(fmt.Sprintf("Over the %s-token budget; the oldest messages will be trimmed", formatTokenCount(budget))))
+	}
+
+	footer := "\n\n" + HelpStyle.Render("↑/↓ to select • Space to include/exclude a file • Enter to send • Esc to keep editing")
+
+	return menuStyle.Render(content.String() + footer)
+}
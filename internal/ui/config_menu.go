@@ -3,9 +3,13 @@ package ui
 import (
 	"fmt"
 	"strconv"
+	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+
+	"github.com/alchemy-labs-co/riptide/internal/api"
+	"github.com/alchemy-labs-co/riptide/internal/config"
 )
 
 // ConfigOption represents a configuration option with possible values
@@ -95,11 +99,22 @@ func (m Model) saveConfigAndExit() (tea.Model, tea.Cmd) {
 	if m.configMenuChanged {
 		// Apply changes to config
 		for _, opt := range m.configOptions {
-			m.applyConfigOption(opt)
+			applyConfigOption(m.config, opt)
+		}
+		m.autoApproveEdits = m.config.Permissions.AutoApproveEdits
+
+		// Restyle the UI for a newly chosen theme
+		if m.config.UI.Theme != m.originalConfig.UI.Theme {
+			if err := ApplyTheme(m.config.UI.Theme, m.config.UI.Themes); err != nil {
+				m.addErrorMessage(fmt.Sprintf("Failed to apply theme: %v", err))
+			}
+		}
+		if m.config.UI.ShowReasoning != m.originalConfig.UI.ShowReasoning {
+			m.reasoningExpanded = m.config.UI.ShowReasoning == config.ReasoningAlways
 		}
 
 		// Save config to file
-		if err := m.config.Save("config.json"); err != nil {
+		if err := m.saveGlobalConfig(); err != nil {
 			m.addErrorMessage(fmt.Sprintf("Failed to save config: %v", err))
 		} else {
 			// Get the changes summary
@@ -121,38 +136,77 @@ func (m Model) saveConfigAndExit() (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
-// applyConfigOption applies a config option to the actual config struct
-func (m *Model) applyConfigOption(opt ConfigOption) {
+// saveGlobalConfig writes the options changed in the menu to the global config
+// file. The file is read afresh so options set by .riptide.json or environment
+// variables are not copied into it.
+func (m Model) saveGlobalConfig() error {
+	path := config.Path()
+	global, err := config.LoadFile(path)
+	if err != nil {
+		return err
+	}
+	for _, opt := range m.configOptions {
+		if m.getOriginalConfigValue(opt) != opt.CurrentValue {
+			applyConfigOption(global, opt)
+		}
+	}
+	return global.Save(path)
+}
+
+// applyConfigOption applies a config option to a config struct
+func applyConfigOption(cfg *config.Config, opt ConfigOption) {
 	switch opt.ConfigSection {
 	case "api":
 		switch opt.ConfigKey {
 		case "model":
-			m.config.API.Model = opt.CurrentValue
+			cfg.API.Model = opt.CurrentValue
 		case "max_completion_tokens":
 			if val, err := strconv.Atoi(opt.CurrentValue); err == nil {
-				m.config.API.MaxCompletionTokens = val
+				cfg.API.MaxCompletionTokens = val
 			}
 		case "timeout_seconds":
 			if val, err := strconv.Atoi(opt.CurrentValue); err == nil {
-				m.config.API.TimeoutSeconds = val
+				cfg.API.TimeoutSeconds = val
 			}
+		case "routing":
+			cfg.API.Routing = opt.CurrentValue == "true"
 		}
 	case "ui":
 		switch opt.ConfigKey {
 		case "theme":
-			m.config.UI.Theme = opt.CurrentValue
+			cfg.UI.Theme = opt.CurrentValue
 		case "enable_emoji":
-			m.config.UI.EnableEmoji = opt.CurrentValue == "true"
-		case "max_history_messages":
+			cfg.UI.EnableEmoji = opt.CurrentValue == "true"
+		case "timestamps":
+			cfg.UI.Timestamps = opt.CurrentValue
+		case "keymap":
+			cfg.UI.Keymap = opt.CurrentValue
+		case "show_reasoning":
+			cfg.UI.ShowReasoning = opt.CurrentValue
+		case "max_context_tokens":
 			if val, err := strconv.Atoi(opt.CurrentValue); err == nil {
-				m.config.UI.MaxHistoryMessages = val
+				cfg.UI.MaxContextTokens = val
 			}
 		}
 	case "file_operations":
 		switch opt.ConfigKey {
 		case "max_file_size_mb":
 			if val, err := strconv.Atoi(opt.CurrentValue); err == nil {
-				m.config.FileOperations.MaxFileSizeMB = val
+				cfg.FileOperations.MaxFileSizeMB = val
+			}
+		}
+	case "ambient":
+		switch opt.ConfigKey {
+		case "enabled":
+			cfg.Ambient.Enabled = opt.CurrentValue == "true"
+		}
+	case "permissions":
+		switch opt.ConfigKey {
+		case "auto_approve_edits":
+			cfg.Permissions.AutoApproveEdits = opt.CurrentValue == "true"
+		case "max_iterations":
+			if val, err := strconv.Atoi(opt.CurrentValue); err == nil {
+				cfg.Permissions.MaxIterations = val
 			}
 		}
 	}
@@ -176,16 +230,46 @@ func (m Model) getConfigChangesSummary() string {
 				}
 			case "theme":
 				changes = append(changes, fmt.Sprintf("Changed theme to %s", opt.CurrentValue))
+			case "timestamps":
+				changes = append(changes, fmt.Sprintf("Set timestamps to %s", opt.CurrentValue))
+			case "keymap":
+				changes = append(changes, fmt.Sprintf("Set keymap to %s", opt.CurrentValue))
+			case "show_reasoning":
+				changes = append(changes, fmt.Sprintf("Set reasoning display to %s", opt.CurrentValue))
 			case "model":
 				changes = append(changes, fmt.Sprintf("Changed model to %s", opt.CurrentValue))
-			case "max_history_messages":
-				changes = append(changes, fmt.Sprintf("Set max history to %s messages", opt.CurrentValue))
+			case "max_context_tokens":
+				changes = append(changes, fmt.Sprintf("Set max context to %s tokens", opt.CurrentValue))
 			case "max_completion_tokens":
 				changes = append(changes, fmt.Sprintf("Set max tokens to %s", opt.CurrentValue))
 			case "timeout_seconds":
 				changes = append(changes, fmt.Sprintf("Set timeout to %s seconds", opt.CurrentValue))
+			case "routing":
+				if opt.CurrentValue == "true" {
+					changes = append(changes, "Enabled model routing")
+				} else {
+					changes = append(changes, "Disabled model routing")
+				}
 			case "max_file_size_mb":
 				changes = append(changes, fmt.Sprintf("Set max file size to %s MB", opt.CurrentValue))
+			case "auto_approve_edits":
+				if opt.CurrentValue == "true" {
+					changes = append(changes, "File edits are written without asking")
+				} else {
+					changes = append(changes, "File edits wait for approval of their diff")
+				}
+			case "max_iterations":
+				if opt.CurrentValue == "0" {
+					changes = append(changes, "Tool calls run until the model stops asking for them")
+				} else {
+					changes = append(changes, fmt.Sprintf("Set max tool rounds per prompt to %s", opt.CurrentValue))
+				}
+			case "enabled":
+				if opt.CurrentValue == "true" {
+					changes = append(changes, "Enabled "+strings.ToLower(opt.Name))
+				} else {
+					changes = append(changes, "Disabled "+strings.ToLower(opt.Name))
+				}
 			default:
 				changes = append(changes, fmt.Sprintf("%s: %s → %s", opt.Name, originalValue, opt.CurrentValue))
 			}
@@ -224,6 +308,8 @@ func (m Model) getOriginalConfigValue(opt ConfigOption) string {
 			return strconv.Itoa(m.originalConfig.API.MaxCompletionTokens)
 		case "timeout_seconds":
 			return strconv.Itoa(m.originalConfig.API.TimeoutSeconds)
+		case "routing":
+			return strconv.FormatBool(m.originalConfig.API.Routing)
 		}
 	case "ui":
 		switch opt.ConfigKey {
@@ -231,14 +317,32 @@ func (m Model) getOriginalConfigValue(opt ConfigOption) string {
 			return m.originalConfig.UI.Theme
 		case "enable_emoji":
 			return strconv.FormatBool(m.originalConfig.UI.EnableEmoji)
-		case "max_history_messages":
-			return strconv.Itoa(m.originalConfig.UI.MaxHistoryMessages)
+		case "timestamps":
+			return m.originalConfig.UI.Timestamps
+		case "keymap":
+			return m.originalConfig.UI.Keymap
+		case "show_reasoning":
+			return m.originalConfig.UI.ShowReasoning
+		case "max_context_tokens":
+			return strconv.Itoa(m.originalConfig.UI.MaxContextTokens)
 		}
 	case "file_operations":
 		switch opt.ConfigKey {
 		case "max_file_size_mb":
 			return strconv.Itoa(m.originalConfig.FileOperations.MaxFileSizeMB)
 		}
+	case "ambient":
+		switch opt.ConfigKey {
+		case "enabled":
+			return strconv.FormatBool(m.originalConfig.Ambient.Enabled)
+		}
+	case "permissions":
+		switch opt.ConfigKey {
+		case "auto_approve_edits":
+			return strconv.FormatBool(m.originalConfig.Permissions.AutoApproveEdits)
+		case "max_iterations":
+			return strconv.Itoa(m.originalConfig.Permissions.MaxIterations)
+		}
 	}
 	return ""
 }
@@ -250,15 +354,23 @@ func (m *Model) initializeConfigOptions() {
 			Name:           "Model",
 			Description:    "DeepSeek model to use",
 			CurrentValue:   m.config.API.Model,
-			PossibleValues: []string{"deepseek-reasoner", "deepseek-chat"},
+			PossibleValues: api.ModelNames(),
 			ConfigKey:      "model",
 			ConfigSection:  "api",
 		},
+		{
+			Name:           "Model Routing",
+			Description:    "Pick deepseek-chat or deepseek-reasoner for each prompt",
+			CurrentValue:   strconv.FormatBool(m.config.API.Routing),
+			PossibleValues: []string{"true", "false"},
+			ConfigKey:      "routing",
+			ConfigSection:  "api",
+		},
 		{
 			Name:           "Theme",
 			Description:    "UI theme",
 			CurrentValue:   m.config.UI.Theme,
-			PossibleValues: []string{"default", "dark", "light"},
+			PossibleValues: ThemeNames(m.config.UI.Themes),
 			ConfigKey:      "theme",
 			ConfigSection:  "ui",
 		},
@@ -271,11 +383,35 @@ func (m *Model) initializeConfigOptions() {
 			ConfigSection:  "ui",
 		},
 		{
-			Name:           "Max History Messages",
-			Description:    "Maximum messages to keep in history",
-			CurrentValue:   strconv.Itoa(m.config.UI.MaxHistoryMessages),
-			PossibleValues: []string{"10", "15", "20", "30", "50"},
-			ConfigKey:      "max_history_messages",
+			Name:           "Timestamps",
+			Description:    "How message times are shown",
+			CurrentValue:   m.config.UI.Timestamps,
+			PossibleValues: []string{"relative", "absolute", "hidden"},
+			ConfigKey:      "timestamps",
+			ConfigSection:  "ui",
+		},
+		{
+			Name:           "Keymap",
+			Description:    "Key bindings for the input: default, or vim with normal and insert modes",
+			CurrentValue:   m.config.UI.Keymap,
+			PossibleValues: []string{config.KeymapDefault, config.KeymapVim},
+			ConfigKey:      "keymap",
+			ConfigSection:  "ui",
+		},
+		{
+			Name:           "Show Reasoning",
+			Description:    "Reasoning in the transcript: always, collapsed to one line (Ctrl+G expands), or never",
+			CurrentValue:   m.config.UI.ShowReasoning,
+			PossibleValues: []string{config.ReasoningAlways, config.ReasoningCollapsed, config.ReasoningNever},
+			ConfigKey:      "show_reasoning",
+			ConfigSection:  "ui",
+		},
+		{
+			Name:           "Max Context Tokens",
+			Description:    "Estimated tokens to keep in history before trimming (capped by the model's window)",
+			CurrentValue:   strconv.Itoa(m.config.UI.MaxContextTokens),
+			PossibleValues: []string{"16000", "32000", "48000", "64000"},
+			ConfigKey:      "max_context_tokens",
 			ConfigSection:  "ui",
 		},
 		{
@@ -302,6 +438,30 @@ func (m *Model) initializeConfigOptions() {
 			ConfigKey:      "max_file_size_mb",
 			ConfigSection:  "file_operations",
 		},
+		{
+			Name:           "Ambient Context",
+			Description:    "Send time, cwd, git and OS state with each request",
+			CurrentValue:   strconv.FormatBool(m.config.Ambient.Enabled),
+			PossibleValues: []string{"true", "false"},
+			ConfigKey:      "enabled",
+			ConfigSection:  "ambient",
+		},
+		{
+			Name:           "Auto-approve Edits",
+			Description:    "Write files without showing the diff for approval (edit mode)",
+			CurrentValue:   strconv.FormatBool(m.autoApproveEdits),
+			PossibleValues: []string{"true", "false"},
+			ConfigKey:      "auto_approve_edits",
+			ConfigSection:  "permissions",
+		},
+		{
+			Name:           "Max Iterations",
+			Description:    "Rounds of tool calls for one prompt before stopping (0 for no limit)",
+			CurrentValue:   strconv.Itoa(m.config.Permissions.MaxIterations),
+			PossibleValues: []string{"10", "25", "50", "0"},
+			ConfigKey:      "max_iterations",
+			ConfigSection:  "permissions",
+		},
 	}
 }
 
@@ -0,0 +1,241 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/alchemy-labs-co/riptide/internal/conversation"
+)
+
+// contextVisibleItems is how many context files the panel lists at once
+const contextVisibleItems = 12
+
+// openContextPanel lists the files in context for removal or refreshing. With
+// no files there is nothing to manage, so the token breakdown is shown instead.
+func (m Model) openContextPanel() (tea.Model, tea.Cmd) {
+	if len(m.history.GetContextItems()) == 0 {
+		m.addSystemMessage(m.getContextText())
+		m.updateViewport()
+		return m, nil
+	}
+	m.contextActive = true
+	m.contextIndex = 0
+	m.contextNotice = ""
+	return m, nil
+}
+
+// handleContextKeyPress moves through the context files, removing or
+// refreshing the selected one
+func (m Model) handleContextKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	items := m.history.GetContextItems()
+	if len(items) == 0 {
+		m.contextActive = false
+		m.updateViewport()
+		return m, nil
+	}
+	m.contextIndex = min(m.contextIndex, len(items)-1)
+	selected := items[m.contextIndex]
+
+	switch msg.String() {
+	case "esc", "ctrl+c", "q":
+		m.contextActive = false
+		m.updateViewport()
+
+	case "up", "k", "shift+tab":
+		m.contextIndex = (m.contextIndex - 1 + len(items)) % len(items)
+
+	case "down", "j", "tab":
+		m.contextIndex = (m.contextIndex + 1) % len(items)
+
+	case "d", "delete", "backspace":
+		if m.history.RemoveFile(selected.Path) {
+			m.contextNotice = fmt.Sprintf("Removed %s (~%s tokens)", selected.Path, formatTokenCount(selected.Tokens))
+			m.addSystemMessage(FormatInfo("Removed "+FormatFilePath(selected.Path)+" from context", m.config.UI.EnableEmoji))
+			m.saveSession()
+		}
+		if len(items) == 1 {
+			m.contextActive = false
+			m.updateViewport()
+		}
+
+	case "r":
+		m.refreshContextFiles([]conversation.ContextItem{selected})
+
+	case "a":
+		var stale []conversation.ContextItem
+		for _, item := range items {
+			if contextFileState(item) != "" {
+				stale = append(stale, item)
+			}
+		}
+		if len(stale) == 0 {
+			m.contextNotice = "Every file is up to date"
+			return m, nil
+		}
+		m.refreshContextFiles(stale)
+	}
+	return m, nil
+}
+
+// refreshContextFiles reads the selected files in context again and reports
+// the outcome in the panel
+func (m *Model) refreshContextFiles(items []conversation.ContextItem) {
+	refreshed, failed := m.rereadContextFiles(items)
+
+	var notes []string
+	if len(refreshed) > 0 {
+		notes = append(notes, "Refreshed "+strings.Join(refreshed, ", "))
+		paths := make([]string, len(refreshed))
+		for i, path := range refreshed {
+			paths[i] = FormatFilePath(path)
+		}
+		m.addSystemMessage(FormatInfo("Refreshed "+strings.Join(paths, ", ")+" in context", m.config.UI.EnableEmoji))
+		m.saveSession()
+	}
+	if len(failed) > 0 {
+		notes = append(notes, "Could not read "+strings.Join(failed, ", "))
+	}
+	if len(notes) == 0 {
+		notes = append(notes, "Already up to date")
+	}
+	m.contextNotice = strings.Join(notes, " • ")
+}
+
+// rereadContextFiles replaces the copies of files in context with their current
+// content, with the same redaction and quarantine as /add, returning the files
+// whose content changed. A file that can no longer be read is left as it was.
+func (m Model) rereadContextFiles(items []conversation.ContextItem) (refreshed, failed []string) {
+	for _, item := range items {
+		content, err := m.fileOps.ReadFileForContext(item.Path)
+		if err != nil {
+			failed = append(failed, item.Path)
+			continue
+		}
+		content, _ = m.redactFrom(content, item.Path)
+		content, _ = guardInjection(item.Path, content)
+		if m.history.ReplaceFile(item.Path, content) {
+			refreshed = append(refreshed, item.Path)
+		}
+	}
+	return refreshed, failed
+}
+
+// refreshChangedContext re-reads the files in context that were modified on
+// disk since they were read, whether by the user, another program or a tool
+// call, so requests never carry a stale copy. The model is told which files
+// changed. It returns their paths.
+func (m Model) refreshChangedContext() []string {
+	if !m.config.FileOperations.RefreshContext {
+		return nil
+	}
+	var changed []conversation.ContextItem
+	for _, item := range m.history.GetContextItems() {
+		// A missing file keeps its last copy; /context shows it as missing
+		if contextFileState(item) == "changed" {
+			changed = append(changed, item)
+		}
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+
+	refreshed, _ := m.rereadContextFiles(changed)
+	if len(refreshed) > 0 {
+		m.history.AddSystemMessage("These files changed on disk and their content in context has been replaced with the current version: " + strings.Join(refreshed, ", "))
+	}
+	return refreshed
+}
+
+// contextFileState describes how a file on disk differs from its copy in
+// context: "changed" when it was modified after being read, "missing" when it
+// is gone, and "" when the copy is current
+func contextFileState(item conversation.ContextItem) string {
+	info, err := os.Stat(item.Path)
+	switch {
+	case err != nil:
+		return "missing"
+	case info.ModTime().After(item.AddedAt):
+		return "changed"
+	}
+	return ""
+}
+
+// formatBytes formats a size such as "120 B" or "2.1 KB"
+func formatBytes(n int) string {
+	switch {
+	case n >= 1024*1024:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1024*1024))
+	case n >= 1024:
+		return fmt.Sprintf("%.1f KB", float64(n)/1024)
+	}
+	return fmt.Sprintf("%d B", n)
+}
+
+// renderContextPanel renders the context manager
+func (m Model) renderContextPanel() string {
+	menuStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(SecondaryColor).
+		Padding(1, 2).
+		Width(m.width - 4).
+		Height(m.height - 4)
+
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(SecondaryColor)
+
+	items := m.history.GetContextItems()
+	stats := m.history.GetStats()
+	fileTokens := 0
+	for _, item := range items {
+		fileTokens += item.Tokens
+	}
+
+	var content strings.Builder
+	content.WriteString(titleStyle.Render(fmt.Sprintf("Context • %d files, ~%s of ~%s tokens",
+		len(items), formatTokenCount(fileTokens), formatTokenCount(stats.ContextTokens))))
+	content.WriteString("\n\n")
+
+	// Scroll the list so the selection stays visible
+	index := min(m.contextIndex, max(len(items)-1, 0))
+	start := 0
+	if index >= contextVisibleItems {
+		start = index - contextVisibleItems + 1
+	}
+	end := min(start+contextVisibleItems, len(items))
+
+	pathWidth := max(m.width-50, 20)
+	for i := start; i < end; i++ {
+		item := items[i]
+
+		line := "  "
+		if i == index {
+			line = lipgloss.NewStyle().Foreground(AccentColor).Render("▶ ")
+		}
+		pathStyle := lipgloss.NewStyle().Width(pathWidth + 2)
+		if i == index {
+			pathStyle = pathStyle.Bold(true).Foreground(AccentColor)
+		}
+		line += pathStyle.Render(truncate(item.Path, pathWidth))
+		line += HelpStyle.Render(fmt.Sprintf("%9s  %14s  ", formatBytes(item.Bytes), "~"+formatTokenCount(item.Tokens)+" tokens"))
+		if state := contextFileState(item); state != "" {
+			line += WarningStyle.Render(state)
+		}
+		content.WriteString(line + "\n")
+	}
+	if len(items) > contextVisibleItems {
+		content.WriteString(HelpStyle.Render(fmt.Sprintf("  (%d files; ↑/↓ to scroll)", len(items))) + "\n")
+	}
+
+	if m.contextNotice != "" {
+		content.WriteString("\n" + InfoStyle.Render(m.contextNotice))
+	}
+
+	footer := "\n\n" + HelpStyle.Render("↑/↓ to select • d to remove • r to refresh • a to refresh all changed files • Esc to close")
+
+	return menuStyle.Render(content.String() + footer)
+}
@@ -0,0 +1,215 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/alchemy-labs-co/riptide/internal/api"
+)
+
+// editVisibleItems is how many earlier prompts the selection list shows at once
+const editVisibleItems = 12
+
+// isPrompt reports whether a transcript message is a prompt that reached the
+// history; echoed commands such as /config and failed /json prompts never do
+func isPrompt(msg Message) bool {
+	return msg.Role == "user" && msg.History > 0
+}
+
+// userPrompts returns the prompts in the transcript, most recent first
+func (m Model) userPrompts() []Message {
+	var prompts []Message
+	for i := len(m.messages) - 1; i >= 0; i-- {
+		if isPrompt(m.messages[i]) {
+			prompts = append(prompts, m.messages[i])
+		}
+	}
+	return prompts
+}
+
+// markPrompt records where the latest prompt in the transcript was added to
+// the history, so editing it rewinds to that message
+func (m *Model) markPrompt(index int) {
+	for i := len(m.messages) - 1; i >= 0; i-- {
+		if m.messages[i].Role == "user" {
+			m.messages[i].History = index
+			return
+		}
+	}
+}
+
+// openEditSelect shows the list of earlier prompts to pick one to edit, the
+// latest selected
+func (m Model) openEditSelect() (tea.Model, tea.Cmd) {
+	m.textInput.SetValue("")
+	if len(m.userPrompts()) == 0 {
+		m.addErrorMessage("No earlier prompts to edit")
+		m.updateViewport()
+		return m, nil
+	}
+	m.editSelectActive = true
+	m.editSelectIndex = 0
+	return m, nil
+}
+
+// handleEditSelectKeyPress moves through the earlier prompts and loads the
+// chosen one into the input for editing
+func (m Model) handleEditSelectKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	prompts := m.userPrompts()
+
+	switch msg.Type {
+	case tea.KeyEsc, tea.KeyCtrlP, tea.KeyCtrlC:
+		m.editSelectActive = false
+
+	case tea.KeyUp, tea.KeyShiftTab:
+		if len(prompts) > 0 {
+			m.editSelectIndex = (m.editSelectIndex - 1 + len(prompts)) % len(prompts)
+		}
+
+	case tea.KeyDown, tea.KeyTab, tea.KeyCtrlN:
+		if len(prompts) > 0 {
+			m.editSelectIndex = (m.editSelectIndex + 1) % len(prompts)
+		}
+
+	case tea.KeyEnter:
+		m.editSelectActive = false
+		if m.editSelectIndex < len(prompts) {
+			m.editingPrompt = m.editSelectIndex + 1
+			m.textInput.SetValue(prompts[m.editSelectIndex].Content)
+			m.textInput.CursorEnd()
+			m.updateAutocomplete()
+		}
+	}
+	return m, nil
+}
+
+// cancelEdit leaves edit mode and clears the input
+func (m *Model) cancelEdit() {
+	m.editingPrompt = 0
+	m.textInput.SetValue("")
+	m.updateAutocomplete()
+}
+
+// rewindForEdit drops the prompt being edited and everything after it from
+// the history and the transcript, so the edited prompt is sent in its place
+func (m *Model) rewindForEdit() error {
+	n := m.editingPrompt
+	m.editingPrompt = 0
+	prompts := m.userPrompts()
+	if n > len(prompts) {
+		return fmt.Errorf("that prompt is no longer in the transcript; send it as a new message instead")
+	}
+	// Trimming or summarizing older messages moves the prompt down the
+	// history; it is then found by counting back from the latest
+	prompt := prompts[n-1]
+	if !m.history.RewindTo(prompt.History, prompt.Content) && !m.history.Rewind(n) {
+		return fmt.Errorf("that prompt is no longer in the context (older messages were trimmed); send it as a new message instead")
+	}
+
+	seen := 0
+	for i := len(m.messages) - 1; i >= 0; i-- {
+		if !isPrompt(m.messages[i]) {
+			continue
+		}
+		seen++
+		if seen == n {
+			m.messages = m.messages[:i]
+			break
+		}
+	}
+	return nil
+}
+
+// retryLastPrompt drops the latest response from the history and the
+// transcript and asks for a new one to the same prompt, bypassing the response
+// cache, which would otherwise replay the dropped answer. Files the dropped
+// response wrote through tools stay as they are; /undo reverts them.
+func (m Model) retryLastPrompt() (tea.Model, tea.Cmd) {
+	m.textInput.SetValue("")
+	m.editingPrompt = 0
+
+	prompt, ok := m.history.GetLastUserMessage()
+	if !ok || !m.history.Rewind(1) {
+		m.addErrorMessage("No earlier prompt to retry")
+		m.updateViewport()
+		return m, nil
+	}
+	for i := len(m.messages) - 1; i >= 0; i-- {
+		if isPrompt(m.messages[i]) {
+			m.messages = m.messages[:i+1]
+			break
+		}
+	}
+
+	// The turn's overrides from /ask or routing still apply
+	overrides := api.Overrides{}
+	if m.turnOverrides != nil {
+		overrides = *m.turnOverrides
+	}
+	overrides.Fresh = true
+	m.turnOverrides = &overrides
+
+	m.dismissError()
+	m.addSystemMessage(FormatInfo("Regenerating the response", m.config.UI.EnableEmoji))
+	m.updateViewport()
+	return m.startConversation(prompt)
+}
+
+// renderEditSelect renders the earlier prompts for edit-and-resubmit
+func (m Model) renderEditSelect() string {
+	menuStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(SecondaryColor).
+		Padding(1, 2).
+		Width(m.width - 4).
+		Height(m.height - 4)
+
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(SecondaryColor)
+
+	var content strings.Builder
+	content.WriteString(titleStyle.Render("Edit a Previous Prompt"))
+	content.WriteString("\n")
+	content.WriteString(HelpStyle.Render("The prompt and everything after it are replaced by the edited version when you send it."))
+	content.WriteString("\n\n")
+
+	prompts := m.userPrompts()
+
+	// Scroll the list so the selection stays visible
+	start := 0
+	if m.editSelectIndex >= editVisibleItems {
+		start = m.editSelectIndex - editVisibleItems + 1
+	}
+	end := min(start+editVisibleItems, len(prompts))
+
+	now := time.Now()
+	for i := start; i < end; i++ {
+		prompt := prompts[i]
+
+		var line string
+		if i == m.editSelectIndex {
+			line += lipgloss.NewStyle().Foreground(AccentColor).Render("▶ ")
+		} else {
+			line += "  "
+		}
+
+		text := strings.Join(strings.Fields(prompt.Content), " ")
+		labelStyle := lipgloss.NewStyle()
+		if i == m.editSelectIndex {
+			labelStyle = labelStyle.Bold(true).Foreground(AccentColor)
+		}
+		line += labelStyle.Render(truncate(text, max(m.width-30, 20)))
+		line += HelpStyle.Render("  " + formatRelativeTime(prompt.Timestamp, now))
+
+		content.WriteString(line + "\n")
+	}
+
+	footer := "\n" + HelpStyle.Render("↑/↓ to select • Enter to edit • Esc to close")
+
+	return menuStyle.Render(content.String() + footer)
+}
@@ -0,0 +1,97 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// maxErrorLog caps how many errors are kept for /errors
+const maxErrorLog = 20
+
+// ErrorEntry records an API or tool error shown in the error banner
+type ErrorEntry struct {
+	Message    string
+	Timestamp  time.Time
+	Retryable  bool   // The failed request can be re-sent from the current history
+	Suggestion string // Command Tab runs instead, e.g. /add with a path that exists
+}
+
+// showError displays an error in the banner and records it for /errors
+func (m *Model) showError(message string, retryable bool) {
+	entry := ErrorEntry{
+		Message:   message,
+		Timestamp: time.Now(),
+		Retryable: retryable,
+	}
+
+	m.errorLog = append(m.errorLog, entry)
+	if len(m.errorLog) > maxErrorLog {
+		m.errorLog = m.errorLog[len(m.errorLog)-maxErrorLog:]
+	}
+
+	m.errorBanner = &entry
+	m.resizeViewport()
+}
+
+// showSuggestion displays an error in the banner, offering to run suggestion
+// instead when there is one
+func (m *Model) showSuggestion(message, suggestion string) {
+	m.showError(message, false)
+	m.errorBanner.Suggestion = suggestion
+}
+
+// acceptSuggestion runs the command suggested in the error banner
+func (m Model) acceptSuggestion() (tea.Model, tea.Cmd) {
+	suggestion := m.errorBanner.Suggestion
+	m.dismissError()
+	return m.handleCommand(suggestion)
+}
+
+// dismissError hides the error banner; the error stays available via /errors
+func (m *Model) dismissError() {
+	m.errorBanner = nil
+	m.resizeViewport()
+}
+
+// renderErrorBanner renders the current error with its available actions
+func (m Model) renderErrorBanner() string {
+	if m.errorBanner == nil {
+		return ""
+	}
+
+	actions := "Esc dismiss"
+	if m.errorBanner.Retryable {
+		actions = "Ctrl+R retry • " + actions
+	}
+	if m.errorBanner.Suggestion != "" {
+		actions = "Did you mean " + m.errorBanner.Suggestion + "? Tab to run it • " + actions
+	}
+
+	// Keep the banner on a single line so the footer height stays predictable
+	icon := GetIcon("error", m.config.UI.EnableEmoji)
+	available := m.width - len(actions) - 10
+	message := strings.ReplaceAll(m.errorBanner.Message, "\n", " ")
+	if available > 0 {
+		message = truncate(message, available)
+	}
+
+	return ErrorBannerStyle.Width(m.width-2).Render(fmt.Sprintf("%s %s", icon, message)) + "\n" +
+		HelpStyle.Render("  "+actions)
+}
+
+// getErrorsText lists the errors recorded this session, newest last
+func (m Model) getErrorsText() string {
+	if len(m.errorLog) == 0 {
+		return FormatInfo("No errors this session", m.config.UI.EnableEmoji)
+	}
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("%s Errors (%d)\n", GetIcon("error", m.config.UI.EnableEmoji), len(m.errorLog)))
+	for _, entry := range m.errorLog {
+		b.WriteString(fmt.Sprintf("\n  %s  %s", entry.Timestamp.Format("15:04:05"), entry.Message))
+	}
+	return b.String()
+}
@@ -0,0 +1,101 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/alchemy-labs-co/riptide/internal/session"
+	"github.com/alchemy-labs-co/riptide/internal/share"
+)
+
+// handleExportCommand writes the whole transcript, with reasoning, tool calls,
+// full tool results and a token and cost summary, as Markdown, JSON or HTML.
+// The format comes from the first argument or the path's extension, and
+// defaults to Markdown in .riptide/exports. Secrets are redacted as for /share.
+func (m Model) handleExportCommand(args string) (tea.Model, tea.Cmd) {
+	m.textInput.SetValue("")
+
+	format, path := "", strings.TrimSpace(args)
+	if first, rest, _ := strings.Cut(path, " "); isExportFormat(strings.ToLower(first)) {
+		format, path = strings.ToLower(first), strings.TrimSpace(rest)
+	}
+	if format == "" && path != "" {
+		format = share.FormatForPath(path)
+		if format == "" {
+			m.addErrorMessage(fmt.Sprintf("Cannot tell the format from %q; name it, e.g. /export md %s", path, path))
+			m.updateViewport()
+			return m, nil
+		}
+	}
+	if format == "" {
+		format = "md"
+	}
+
+	messages := m.history.GetRawMessages()
+	if _, ok := m.history.GetLastUserMessage(); !ok {
+		m.addErrorMessage("Nothing to export yet")
+		m.updateViewport()
+		return m, nil
+	}
+
+	if path == "" {
+		name := time.Now().Format("20060102-150405")
+		if m.session != nil {
+			name = m.session.ID
+		}
+		path = filepath.Join(share.ExportDir, name+"."+format)
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(m.workspaceRoot, path)
+	}
+
+	stats := m.history.GetStats()
+	usage := m.sessionBase.Add(session.Usage{
+		InputTokens:  stats.InputTokens,
+		OutputTokens: stats.OutputTokens,
+		CachedTokens: stats.CachedTokens,
+		Cost:         m.calculateTotalCost(stats),
+	})
+
+	redacted := 0
+	transcript := m.newTranscript(messages, &redacted)
+	transcript.Usage = &usage
+	transcript.Full = true
+
+	m.state = StateProcessing
+	enableEmoji := m.config.UI.EnableEmoji
+
+	return m, func() tea.Msg {
+		content, err := transcript.Render(format)
+		if err != nil {
+			return ProcessCompleteMsg{Error: err}
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return ProcessCompleteMsg{Error: fmt.Errorf("creating export directory: %w", err)}
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			return ProcessCompleteMsg{Error: fmt.Errorf("writing export: %w", err)}
+		}
+
+		result := FormatSuccess(fmt.Sprintf("Exported the conversation (%s) to %s", format, FormatFilePath(displayPath(m.workspaceRoot, path))), enableEmoji)
+		if redacted > 0 {
+			result += "\n" + FormatInfo(fmt.Sprintf("Redacted %d secrets from the export", redacted), enableEmoji)
+		}
+		return ProcessCompleteMsg{Result: result}
+	}
+}
+
+// isExportFormat reports whether s names one of the export formats
+func isExportFormat(s string) bool {
+	for _, format := range share.Formats {
+		if s == format {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,186 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/alchemy-labs-co/riptide/internal/api"
+	"github.com/alchemy-labs-co/riptide/internal/functions"
+)
+
+// HeadlessOptions configures a prompt run without the TUI
+type HeadlessOptions struct {
+	Output   io.Writer // Receives the streamed answer
+	Progress io.Writer // Receives a line per tool call and other notes; defaults to Output
+
+	// Yes approves tool calls that would otherwise ask. Dangerous commands are
+	// refused regardless, as nobody is there to confirm them.
+	Yes bool
+}
+
+// AddFile adds a file to the conversation context, as /add does
+func (m *Model) AddFile(path string) error {
+	if msg, ok := m.addFileToContext(path, false).(ProcessCompleteMsg); ok && msg.Error != nil {
+		return msg.Error
+	}
+	return nil
+}
+
+// RunPrompt sends prompt and streams the answer to opts.Output, running the
+// tool calls the model makes until it answers without one and reporting each to
+// opts.Progress. It applies the same
+// permission mode, command rules, hooks and redaction as the TUI, and honours
+// leading !key=value overrides for that prompt.
+func (m *Model) RunPrompt(ctx context.Context, prompt string, opts HeadlessOptions) error {
+	out := opts.Output
+	if out == nil {
+		out = io.Discard
+	}
+	notes := opts.Progress
+	if notes == nil {
+		notes = out
+	}
+	// Dangerous commands and paths outside the workspace need someone to
+	// confirm them, so --yes does not cover them
+	approve := func(_ int, _ api.ToolCall, danger string, _ []functions.FileChange, outside []string) approvalDecision {
+		return approvalDecision{Approved: opts.Yes && danger == "" && len(outside) == 0}
+	}
+
+	prompt, overrides, err := parseOverrides(prompt)
+	if err != nil {
+		return err
+	}
+	m.turnOverrides = overrides
+	if route := m.routeTurn(prompt); route != "" {
+		fmt.Fprintln(notes, route)
+	}
+
+	for _, file := range m.attachMentions(prompt) {
+		fmt.Fprintln(notes, file)
+	}
+	prompt, redaction := m.redact(prompt)
+	if redaction.Count > 0 {
+		fmt.Fprintf(notes, "Redacted %s from the prompt\n", redaction)
+	}
+
+	m.turn++
+	m.toolRounds = 0
+	m.history.AddUserMessage(prompt)
+	defer m.saveSession()
+
+	for {
+		toolCalls, err := m.streamHeadless(ctx, out, notes)
+		if err != nil {
+			return err
+		}
+		if len(toolCalls) == 0 {
+			break
+		}
+
+		m.fileOps.SetTurn(m.turn)
+		autoEdits := m.autoApproveEdits
+		for i, toolCall := range toolCalls {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			result, progress := m.runToolCall(i, toolCall, m.config.Permissions.Mode, m.workspaceRoot, &autoEdits, approve)
+			m.history.AddToolMessage(toolCall.ID, result)
+
+			fmt.Fprintln(notes, headlessToolLine(toolCall, progress))
+		}
+
+		m.toolRounds++
+		if m.reachedMaxIterations() {
+			fmt.Fprintf(notes, "Stopped after round %d of tool calls, the max_iterations limit\n", m.toolRounds)
+			break
+		}
+	}
+
+	return m.hooks.Run(m.postTurnEvent())
+}
+
+// headlessToolLine reports a finished tool call as plain text
+func headlessToolLine(toolCall api.ToolCall, progress ToolProgressMsg) string {
+	line := "✓ " + toolCall.Function.Name
+	if progress.State == ToolFailed {
+		line = "✗ " + toolCall.Function.Name
+	}
+	if summary := summarizeToolCall(toolCall); summary != "" {
+		line += " " + summary
+	}
+	if progress.Error != "" {
+		line += ": " + progress.Error
+	}
+	return line
+}
+
+// streamHeadless streams one response to out, with notes about it to notes,
+// records it in the history and returns the tool calls it asked for
+func (m *Model) streamHeadless(ctx context.Context, out, notes io.Writer) ([]api.ToolCall, error) {
+	if refreshed := m.refreshChangedContext(); len(refreshed) > 0 {
+		fmt.Fprintf(notes, "(refreshed %s in context: changed on disk)\n", strings.Join(refreshed, ", "))
+	}
+	events, err := m.provider().CreateChatCompletionStream(ctx, m.requestMessages())
+	if err != nil {
+		return nil, fmt.Errorf("creating stream: %w", err)
+	}
+
+	var content, reasoning string
+	var toolCalls []api.ToolCall
+	for {
+		var event api.StreamEvent
+		var ok bool
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case event, ok = <-events:
+		}
+		if !ok {
+			return nil, errors.New("stream ended without a response")
+		}
+
+		switch event.Type {
+		case api.EventTypeReasoning:
+			reasoning += event.ReasoningContent
+		case api.EventTypeContent:
+			content += event.Content
+			fmt.Fprint(out, event.Content)
+		case api.EventTypeToolCall:
+			toolCalls = event.ToolCalls
+		case api.EventTypeError:
+			return nil, event.Error
+		case api.EventTypeRetry:
+			if event.Retry.Restart {
+				// What was printed cannot be taken back, so mark where the new answer starts
+				if content != "" {
+					fmt.Fprintln(out)
+				}
+				fmt.Fprintf(notes, "(the response broke off: %v; starting it again)\n", event.Retry.Err)
+				content, reasoning = "", ""
+			} else {
+				fmt.Fprintf(notes, "(retrying in %s: %v)\n", event.Retry.Delay.Round(time.Second), event.Retry.Err)
+			}
+		case api.EventTypeDone:
+			if content != "" {
+				fmt.Fprintln(out)
+			}
+			if content != "" || len(toolCalls) > 0 {
+				m.history.AddReasoningAssistantMessage(content, reasoning, 0, toolCalls)
+			}
+			if event.Usage != nil {
+				m.history.UpdateTokenUsage(event.Usage.InputTokens, event.Usage.OutputTokens, event.Usage.CachedTokens)
+			}
+			if event.Cached {
+				fmt.Fprintln(notes, "(answered from the response cache)")
+			}
+			if event.FinishReason == api.FinishReasonLength {
+				fmt.Fprintln(notes, "(the answer was cut off at the completion token limit)")
+			}
+			return toolCalls, nil
+		}
+	}
+}
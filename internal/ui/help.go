@@ -0,0 +1,92 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// openHelp shows the full-screen help overlay on its first page
+func (m Model) openHelp() (tea.Model, tea.Cmd) {
+	m.helpActive = true
+	m.helpPage = 0
+	m.textInput.SetValue("")
+	m.updateAutocomplete()
+	return m, nil
+}
+
+// handleHelpKeyPress pages through the help overlay
+func (m Model) handleHelpKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	pages := m.helpPages()
+
+	switch msg.String() {
+	case "esc", "q", "ctrl+c":
+		m.helpActive = false
+	case "right", "pgdown", " ", "n", "l":
+		if m.helpPage < len(pages)-1 {
+			m.helpPage++
+		}
+	case "left", "pgup", "p", "h":
+		if m.helpPage > 0 {
+			m.helpPage--
+		}
+	case "home", "g":
+		m.helpPage = 0
+	case "end", "G":
+		m.helpPage = len(pages) - 1
+	}
+
+	return m, nil
+}
+
+// helpPages splits the help text into pages that fit the overlay
+func (m Model) helpPages() [][]string {
+	lines := strings.Split(m.getHelpText(), "\n")
+
+	// Box border, padding, title and footer take 10 lines
+	pageSize := m.height - 10
+	if pageSize < 5 {
+		pageSize = 5
+	}
+
+	var pages [][]string
+	for start := 0; start < len(lines); start += pageSize {
+		end := start + pageSize
+		if end > len(lines) {
+			end = len(lines)
+		}
+		pages = append(pages, lines[start:end])
+	}
+	return pages
+}
+
+// renderHelpOverlay renders the current help page
+func (m Model) renderHelpOverlay() string {
+	menuStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(SecondaryColor).
+		Padding(1, 2).
+		Width(m.width - 4).
+		Height(m.height - 4)
+
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(SecondaryColor)
+
+	pages := m.helpPages()
+	page := m.helpPage
+	if page >= len(pages) {
+		page = len(pages) - 1
+	}
+
+	var content strings.Builder
+	content.WriteString(titleStyle.Render("Help"))
+	content.WriteString("\n\n")
+	content.WriteString(strings.Join(pages[page], "\n"))
+
+	footer := "\n\n" + HelpStyle.Render(fmt.Sprintf("Page %d/%d • ←/→ or PgUp/PgDn to page • Esc or q to close", page+1, len(pages)))
+
+	return menuStyle.Render(content.String() + footer)
+}
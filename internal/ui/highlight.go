@@ -0,0 +1,443 @@
+package ui
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Fenced code blocks in answers are highlighted by a small lexer that knows the
+// keywords, comments and string syntax of common languages. It colors tokens
+// rather than parsing, which is enough to make code readable in the terminal.
+
+// codeTabWidth is how many spaces a tab in a code block takes
+const codeTabWidth = 4
+
+// codeFence matches the line opening or closing a fenced code block, with the
+// language name, if any, in the second group
+var codeFence = regexp.MustCompile("^\\s*(```|~~~)\\s*([\\w+#.-]*)")
+
+// tokenKind is the syntax class of a piece of code
+type tokenKind int
+
+const (
+	tokenPlain tokenKind = iota
+	tokenKeyword
+	tokenType
+	tokenString
+	tokenComment
+	tokenNumber
+	tokenFunction
+	tokenInserted // Diff lines
+	tokenDeleted
+	tokenHunk
+)
+
+// codeToken is a run of code of one kind; it may span lines
+type codeToken struct {
+	Text string
+	Kind tokenKind
+}
+
+// codeLanguage describes a language's lexical syntax
+type codeLanguage struct {
+	lineComments []string
+	blockComment [2]string // Opening and closing delimiters; empty when the language has none
+	quotes       string    // Characters that open strings ending on the same line
+	rawQuote     byte      // Opens a string that may span lines without escapes (Go and JavaScript backticks)
+	tripleQuotes bool      // Python's """ and ''' strings
+	keywords     map[string]bool
+	types        map[string]bool
+}
+
+// words builds a lookup set from a space-separated list
+func words(list string) map[string]bool {
+	set := make(map[string]bool)
+	for _, word := range strings.Fields(list) {
+		set[word] = true
+	}
+	return set
+}
+
+var (
+	cComment = [2]string{"/*", "*/"}
+
+	goLanguage = &codeLanguage{
+		lineComments: []string{"//"},
+		blockComment: cComment,
+		quotes:       `"'`,
+		rawQuote:     '`',
+		keywords: words("break case chan const continue default defer else fallthrough for func go goto if " +
+			"import interface map package range return select struct switch type var nil true false iota"),
+		types: words("bool byte complex64 complex128 error float32 float64 int int8 int16 int32 int64 rune " +
+			"string uint uint8 uint16 uint32 uint64 uintptr any comparable"),
+	}
+
+	pythonLanguage = &codeLanguage{
+		lineComments: []string{"#"},
+		quotes:       `"'`,
+		tripleQuotes: true,
+		keywords: words("and as assert async await break class continue def del elif else except finally for " +
+			"from global if import in is lambda nonlocal not or pass raise return try while with yield None True False self"),
+		types: words("int float str bool list dict set tuple bytes object type Exception"),
+	}
+
+	javascriptLanguage = &codeLanguage{
+		lineComments: []string{"//"},
+		blockComment: cComment,
+		quotes:       `"'`,
+		rawQuote:     '`',
+		keywords: words("async await break case catch class const continue debugger default delete do else export " +
+			"extends finally for from function if import in instanceof let new of return static super switch this " +
+			"throw try typeof var void while yield null undefined true false"),
+		types: words("Array Boolean Date Error Map Number Object Promise RegExp Set String Symbol"),
+	}
+
+	typescriptLanguage = &codeLanguage{
+		lineComments: javascriptLanguage.lineComments,
+		blockComment: cComment,
+		quotes:       `"'`,
+		rawQuote:     '`',
+		keywords: words("abstract as async await break case catch class const continue declare default delete do " +
+			"else enum export extends finally for from function if implements import in instanceof interface keyof " +
+			"let namespace new of private protected public readonly return static super switch this throw try type " +
+			"typeof var void while yield null undefined true false"),
+		types: words("any boolean never number object string symbol unknown void Array Map Promise Record Set"),
+	}
+
+	rustLanguage = &codeLanguage{
+		lineComments: []string{"//"},
+		blockComment: cComment,
+		quotes:       `"`,
+		keywords: words("as async await break const continue crate dyn else enum extern false fn for if impl in " +
+			"let loop match mod move mut pub ref return self Self static struct super trait true type unsafe use where while"),
+		types: words("bool char f32 f64 i8 i16 i32 i64 i128 isize str u8 u16 u32 u64 u128 usize String Vec Option Result Box"),
+	}
+
+	cLanguage = &codeLanguage{
+		lineComments: []string{"//"},
+		blockComment: cComment,
+		quotes:       `"'`,
+		keywords: words("auto break case catch class const constexpr continue default delete do else enum explicit " +
+			"extern for friend goto if inline namespace new noexcept nullptr operator private protected public " +
+			"register return sizeof static struct switch template this throw try typedef typename union using " +
+			"virtual volatile while true false NULL #include #define #ifdef #ifndef #endif #if #else #pragma"),
+		types: words("bool char double float int long short signed unsigned void size_t int32_t int64_t uint8_t " +
+			"uint32_t uint64_t std string vector"),
+	}
+
+	javaLanguage = &codeLanguage{
+		lineComments: []string{"//"},
+		blockComment: cComment,
+		quotes:       `"'`,
+		keywords: words("abstract assert break case catch class const continue default do else enum extends final " +
+			"finally for fun if implements import in instanceof interface is null object override package private " +
+			"protected public return sealed static super switch synchronized this throw throws try val var void " +
+			"when while true false"),
+		types: words("boolean byte char double float int long short String Integer Boolean List Map Object Unit Any"),
+	}
+
+	rubyLanguage = &codeLanguage{
+		lineComments: []string{"#"},
+		quotes:       `"'`,
+		keywords: words("alias and begin break case class def defined? do else elsif end ensure false for if in " +
+			"module next nil not or redo rescue retry return self super then true undef unless until when while yield " +
+			"require attr_reader attr_accessor"),
+	}
+
+	shellLanguage = &codeLanguage{
+		lineComments: []string{"#"},
+		quotes:       `"'`,
+		keywords: words("if then else elif fi for while until do done case esac in function return exit export " +
+			"local readonly set unset source echo cd"),
+	}
+
+	sqlLanguage = &codeLanguage{
+		lineComments: []string{"--"},
+		blockComment: cComment,
+		quotes:       `'"`,
+		keywords: words("select from where and or not insert into values update set delete create table alter drop " +
+			"index join left right inner outer on group by order having limit offset as distinct union all null is " +
+			"in like between case when then else end primary key foreign references default with explain " +
+			"SELECT FROM WHERE AND OR NOT INSERT INTO VALUES UPDATE SET DELETE CREATE TABLE ALTER DROP INDEX JOIN " +
+			"LEFT RIGHT INNER OUTER ON GROUP BY ORDER HAVING LIMIT OFFSET AS DISTINCT UNION ALL NULL IS IN LIKE " +
+			"BETWEEN CASE WHEN THEN ELSE END PRIMARY KEY FOREIGN REFERENCES DEFAULT WITH EXPLAIN"),
+		types: words("int integer bigint text varchar char boolean date timestamp serial numeric real " +
+			"INT INTEGER BIGINT TEXT VARCHAR CHAR BOOLEAN DATE TIMESTAMP SERIAL NUMERIC REAL"),
+	}
+
+	dataLanguage = &codeLanguage{
+		lineComments: []string{"#"},
+		quotes:       `"'`,
+		keywords:     words("true false null yes no on off"),
+	}
+
+	jsonLanguage = &codeLanguage{
+		quotes:   `"`,
+		keywords: words("true false null"),
+	}
+
+	cssLanguage = &codeLanguage{
+		blockComment: cComment,
+		quotes:       `"'`,
+		keywords:     words("important auto none inherit initial solid flex grid block inline absolute relative"),
+	}
+
+	// diffLanguage is lexed by line rather than by token
+	diffLanguage = &codeLanguage{}
+)
+
+// codeLanguages maps fence language names to their syntax
+var codeLanguages = map[string]*codeLanguage{
+	"go": goLanguage, "golang": goLanguage,
+	"python": pythonLanguage, "py": pythonLanguage, "python3": pythonLanguage,
+	"javascript": javascriptLanguage, "js": javascriptLanguage, "jsx": javascriptLanguage, "mjs": javascriptLanguage,
+	"typescript": typescriptLanguage, "ts": typescriptLanguage, "tsx": typescriptLanguage,
+	"rust": rustLanguage, "rs": rustLanguage,
+	"c": cLanguage, "h": cLanguage, "cpp": cLanguage, "c++": cLanguage, "cc": cLanguage, "hpp": cLanguage,
+	"java": javaLanguage, "kotlin": javaLanguage, "kt": javaLanguage, "csharp": javaLanguage, "cs": javaLanguage,
+	"ruby": rubyLanguage, "rb": rubyLanguage,
+	"sh": shellLanguage, "bash": shellLanguage, "shell": shellLanguage, "zsh": shellLanguage, "console": shellLanguage,
+	"sql":  sqlLanguage,
+	"yaml": dataLanguage, "yml": dataLanguage, "toml": dataLanguage, "ini": dataLanguage,
+	"dockerfile": dataLanguage, "makefile": dataLanguage, "make": dataLanguage,
+	"json": jsonLanguage, "jsonc": javascriptLanguage,
+	"css": cssLanguage, "scss": cssLanguage,
+	"diff": diffLanguage, "patch": diffLanguage,
+}
+
+// codePalette is the color of each token kind
+type codePalette map[tokenKind]lipgloss.Style
+
+// renderCodeBlock highlights code and frames it with a gutter, wrapping lines
+// longer than width; a width of zero or less never wraps
+func renderCodeBlock(language, code string, width int, palette codePalette) string {
+	code = strings.ReplaceAll(code, "\t", strings.Repeat(" ", codeTabWidth))
+	tokens := highlightCode(language, code)
+
+	gutter := HelpStyle.Render("│ ")
+	var lines []string
+	var line strings.Builder
+	column := 0
+	flush := func() {
+		lines = append(lines, gutter+line.String())
+		line.Reset()
+		column = 0
+	}
+
+	for _, token := range tokens {
+		style, styled := palette[token.Kind]
+		for i, part := range strings.Split(token.Text, "\n") {
+			if i > 0 {
+				flush()
+			}
+			for part != "" {
+				if width > 0 && column >= width {
+					flush()
+				}
+				piece := []rune(part)
+				if width > 0 && column+len(piece) > width {
+					piece = piece[:width-column]
+				}
+				text := string(piece)
+				if styled {
+					line.WriteString(style.Render(text))
+				} else {
+					line.WriteString(text)
+				}
+				column += len(piece)
+				part = part[len(text):]
+			}
+		}
+	}
+	if line.Len() > 0 || len(lines) == 0 {
+		flush()
+	}
+
+	header := HelpStyle.Render("╭─ " + language)
+	if language == "" {
+		header = HelpStyle.Render("╭─")
+	}
+	return header + "\n" + strings.Join(lines, "\n") + "\n" + HelpStyle.Render("╰─")
+}
+
+// highlightCode splits code into tokens of the named language; unknown
+// languages come back as a single plain token
+func highlightCode(language, code string) []codeToken {
+	lang, ok := codeLanguages[strings.ToLower(language)]
+	if !ok {
+		return []codeToken{{Text: code}}
+	}
+	if lang == diffLanguage {
+		return highlightDiff(code)
+	}
+
+	var tokens []codeToken
+	emit := func(text string, kind tokenKind) {
+		if text == "" {
+			return
+		}
+		// Merge neighbours of the same kind to keep the escape codes short
+		if n := len(tokens); n > 0 && tokens[n-1].Kind == kind {
+			tokens[n-1].Text += text
+			return
+		}
+		tokens = append(tokens, codeToken{Text: text, Kind: kind})
+	}
+
+	for i := 0; i < len(code); {
+		rest := code[i:]
+		c := code[i]
+
+		if prefix := lang.lineCommentAt(code, i); prefix {
+			end := strings.IndexByte(rest, '\n')
+			if end < 0 {
+				end = len(rest)
+			}
+			emit(rest[:end], tokenComment)
+			i += end
+			continue
+		}
+		if open := lang.blockComment[0]; open != "" && strings.HasPrefix(rest, open) {
+			end := strings.Index(rest[len(open):], lang.blockComment[1])
+			if end < 0 {
+				end = len(rest)
+			} else {
+				end += len(open) + len(lang.blockComment[1])
+			}
+			emit(rest[:end], tokenComment)
+			i += end
+			continue
+		}
+		if lang.tripleQuotes && (strings.HasPrefix(rest, `"""`) || strings.HasPrefix(rest, `'''`)) {
+			end := strings.Index(rest[3:], rest[:3])
+			if end < 0 {
+				end = len(rest)
+			} else {
+				end += 6
+			}
+			emit(rest[:end], tokenString)
+			i += end
+			continue
+		}
+		if lang.rawQuote != 0 && c == lang.rawQuote {
+			end := strings.IndexByte(rest[1:], c)
+			if end < 0 {
+				end = len(rest)
+			} else {
+				end += 2
+			}
+			emit(rest[:end], tokenString)
+			i += end
+			continue
+		}
+		if strings.IndexByte(lang.quotes, c) >= 0 {
+			end := quotedLength(rest)
+			emit(rest[:end], tokenString)
+			i += end
+			continue
+		}
+		if isDigit(c) {
+			end := 1
+			for end < len(rest) && (isWordByte(rest[end]) || rest[end] == '.') {
+				end++
+			}
+			emit(rest[:end], tokenNumber)
+			i += end
+			continue
+		}
+		if isWordStart(c) {
+			end := 1
+			for end < len(rest) && (isWordByte(rest[end]) || rest[end] == '?' && lang == rubyLanguage) {
+				end++
+			}
+			word := rest[:end]
+			// Preprocessor directives such as #include are keywords in C
+			if i > 0 && code[i-1] == '#' && lang.keywords["#"+word] {
+				tokens[len(tokens)-1].Text = strings.TrimSuffix(tokens[len(tokens)-1].Text, "#")
+				emit("#"+word, tokenKeyword)
+				i += end
+				continue
+			}
+			switch {
+			case lang.keywords[word]:
+				emit(word, tokenKeyword)
+			case lang.types[word]:
+				emit(word, tokenType)
+			case end < len(rest) && rest[end] == '(':
+				emit(word, tokenFunction)
+			default:
+				emit(word, tokenPlain)
+			}
+			i += end
+			continue
+		}
+		emit(rest[:1], tokenPlain)
+		i++
+	}
+	return tokens
+}
+
+// lineCommentAt reports whether a line comment starts at offset i. A # only
+// starts one at the beginning of a word, so shell's ${#x} and URLs stay code.
+func (lang *codeLanguage) lineCommentAt(code string, i int) bool {
+	for _, prefix := range lang.lineComments {
+		if !strings.HasPrefix(code[i:], prefix) {
+			continue
+		}
+		if prefix == "#" && i > 0 && !strings.ContainsRune(" \t\n", rune(code[i-1])) {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// quotedLength returns the length of the string literal at the start of s,
+// honoring backslash escapes. Unterminated strings end at the line.
+func quotedLength(s string) int {
+	quote := s[0]
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case quote:
+			return i + 1
+		case '\n':
+			return i
+		}
+	}
+	return len(s)
+}
+
+// highlightDiff colors added, removed and hunk header lines
+func highlightDiff(code string) []codeToken {
+	lines := strings.SplitAfter(code, "\n")
+	tokens := make([]codeToken, 0, len(lines))
+	for _, line := range lines {
+		kind := tokenPlain
+		switch {
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"), strings.HasPrefix(line, "@@"):
+			kind = tokenHunk
+		case strings.HasPrefix(line, "+"):
+			kind = tokenInserted
+		case strings.HasPrefix(line, "-"):
+			kind = tokenDeleted
+		}
+		tokens = append(tokens, codeToken{Text: line, Kind: kind})
+	}
+	return tokens
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isWordStart(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isWordByte(c byte) bool {
+	return isWordStart(c) || isDigit(c)
+}
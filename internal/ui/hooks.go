@@ -0,0 +1,82 @@
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/alchemy-labs-co/riptide/internal/api"
+	"github.com/alchemy-labs-co/riptide/internal/hooks"
+)
+
+// HookFailedMsg reports a lifecycle hook that failed outside a tool call
+type HookFailedMsg struct {
+	Err error
+}
+
+// hookEvent returns an event with the session details filled in
+func (m Model) hookEvent(name string) hooks.Event {
+	event := hooks.Event{
+		Event:      name,
+		WorkingDir: m.workspaceRoot,
+		Turn:       m.turn,
+	}
+	if m.session != nil {
+		event.SessionID = m.session.ID
+	}
+	return event
+}
+
+// toolHookEvent describes a tool call for pre_tool and post_edit hooks
+func (m Model) toolHookEvent(name string, toolCall api.ToolCall) hooks.Event {
+	event := m.hookEvent(name)
+	event.Tool = toolCall.Function.Name
+	event.Paths = toolCallPaths(toolCall)
+	if json.Valid([]byte(toolCall.Function.Arguments)) {
+		event.Arguments = json.RawMessage(toolCall.Function.Arguments)
+	}
+	return event
+}
+
+// runPreToolHooks runs the pre_tool hooks; an error blocks the tool call
+func (m Model) runPreToolHooks(toolCall api.ToolCall) error {
+	if err := m.hooks.Run(m.toolHookEvent(hooks.PreTool, toolCall)); err != nil {
+		return fmt.Errorf("%s was blocked: %w", toolCall.Function.Name, err)
+	}
+	return nil
+}
+
+// runPostEditHooks runs the post_edit hooks after a write tool succeeds and
+// returns the result with any hook failure appended, so the model sees it
+func (m Model) runPostEditHooks(toolCall api.ToolCall, result string) string {
+	if !api.IsWriteTool(toolCall.Function.Name) {
+		return result
+	}
+	if err := m.hooks.Run(m.toolHookEvent(hooks.PostEdit, toolCall)); err != nil {
+		return result + "\n\n" + err.Error()
+	}
+	return result
+}
+
+// postTurnEvent describes the finished turn, including the model's final answer
+func (m Model) postTurnEvent() hooks.Event {
+	event := m.hookEvent(hooks.PostTurn)
+	event.Response, _ = m.history.GetLastAssistantMessage()
+	return event
+}
+
+// postTurnHooks returns a command running the post_turn hooks, or nil when
+// none are configured
+func (m Model) postTurnHooks() tea.Cmd {
+	if !m.hooks.Has(hooks.PostTurn) {
+		return nil
+	}
+	event := m.postTurnEvent()
+
+	return func() tea.Msg {
+		if err := m.hooks.Run(event); err != nil {
+			return HookFailedMsg{Err: err}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,122 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// maxInputLines caps how many lines of a multi-line prompt the input box shows;
+// longer prompts scroll to keep the cursor in view
+const maxInputLines = 8
+
+// shiftEnterSequences are the escape sequences terminals send for Shift+Enter
+// when they report modified keys (the kitty keyboard protocol and xterm's
+// modifyOtherKeys). Bubble Tea does not decode them, so they are matched by
+// the description it gives unknown sequences.
+var shiftEnterSequences = map[string]bool{
+	fmt.Sprintf("?CSI%+v?", []byte("13;2u")):    true,
+	fmt.Sprintf("?CSI%+v?", []byte("27;2;13~")): true,
+}
+
+// newInputEditor creates the prompt editor. Enter is handled by the model to
+// send the prompt, so only Alt+Enter and Ctrl+J insert a newline here.
+func newInputEditor() textarea.Model {
+	ta := textarea.New()
+	ta.Placeholder = ""
+	ta.Prompt = ""
+	ta.ShowLineNumbers = false
+	ta.CharLimit = 0 // Large pastes are attached instead, see isLargePaste
+	ta.MaxHeight = 0
+	ta.KeyMap.InsertNewline = key.NewBinding(key.WithKeys("alt+enter", "ctrl+j"))
+	ta.SetWidth(80)
+	ta.Focus()
+	return ta
+}
+
+// isShiftEnter reports whether msg is a Shift+Enter the terminal sent as an
+// escape sequence Bubble Tea leaves undecoded
+func isShiftEnter(msg tea.Msg) bool {
+	if _, ok := msg.(tea.KeyMsg); ok {
+		return false
+	}
+	s, ok := msg.(fmt.Stringer)
+	return ok && shiftEnterSequences[s.String()]
+}
+
+// insertNewline breaks the prompt line at the cursor
+func (m *Model) insertNewline() {
+	m.textInput.InsertString("\n")
+	m.updateAutocomplete()
+}
+
+// inputLines returns how many lines the prompt editor shows
+func (m Model) inputLines() int {
+	return min(max(m.textInput.LineCount(), 1), maxInputLines)
+}
+
+// renderEditor renders the prompt being typed with a block cursor, continuation
+// lines indented under the prompt marker, and the dimmed rest of an autocomplete
+// suggestion after a slash command
+func (m Model) renderEditor(prompt string) string {
+	cursorStyle := lipgloss.NewStyle().Background(WhiteColor).Foreground(CursorTextColor)
+	cursor := cursorStyle.Render(" ")
+
+	value := m.textInput.Value()
+	if value == "" {
+		return prompt + cursor + HelpStyle.Render("Type your message...")
+	}
+
+	lines := strings.Split(value, "\n")
+	row := min(m.textInput.Line(), len(lines)-1)
+	info := m.textInput.LineInfo()
+	col := info.StartColumn + info.ColumnOffset
+
+	// Keep the cursor's line in the visible window
+	start := 0
+	if len(lines) > maxInputLines {
+		start = min(max(row-maxInputLines+1, 0), len(lines)-maxInputLines)
+		if row < start {
+			start = row
+		}
+	}
+	end := min(start+maxInputLines, len(lines))
+
+	var b strings.Builder
+	for i := start; i < end; i++ {
+		if i > start {
+			b.WriteString("\n")
+		}
+		if i == 0 {
+			b.WriteString(prompt)
+		} else {
+			b.WriteString("  ")
+		}
+
+		line := lines[i]
+		if i != row {
+			b.WriteString(line)
+			continue
+		}
+		runes := []rune(line)
+		col = min(col, len(runes))
+		b.WriteString(string(runes[:col]) + cursor + string(runes[col:]))
+		if m.autocompleteActive && m.autocompleteSuggestion != "" && len(lines) == 1 && len(m.autocompleteSuggestion) > len(value) {
+			b.WriteString(HelpStyle.Render(m.autocompleteSuggestion[len(value):]))
+		}
+	}
+	return b.String()
+}
+
+// inputStatus describes a multi-line prompt's size and how to send it
+func (m Model) inputStatus() string {
+	lines := m.textInput.LineCount()
+	if lines < 2 {
+		return ""
+	}
+	return HelpStyle.Render(fmt.Sprintf("line %d of %d • Enter sends • Shift+Enter or Alt+Enter adds a line", m.textInput.Line()+1, lines))
+}
@@ -0,0 +1,234 @@
+package ui
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	openai "github.com/sashabaranov/go-openai"
+
+	"github.com/alchemy-labs-co/riptide/internal/api"
+)
+
+// jsonUsage is shown when /json is given bad arguments
+const jsonUsage = "Usage: /json <schema-file> <prompt>"
+
+// jsonAttempts is how many answers are requested before giving up on one that
+// matches the schema
+const jsonAttempts = 3
+
+// JSONResultMsg carries the outcome of a structured JSON request
+type JSONResultMsg struct {
+	Prompt   string
+	JSON     string // The validated answer; empty on error
+	Attempts int
+	Usage    api.TokenUsage
+	Err      error
+}
+
+// ReadOutputSchema loads a JSON Schema file for structured answers
+func ReadOutputSchema(path string) (*api.OutputSchema, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading schema: %w", err)
+	}
+	return api.ParseOutputSchema(data)
+}
+
+// jsonPrompt asks for an answer that matches the schema and nothing else
+func jsonPrompt(prompt string, schema *api.OutputSchema) string {
+	return fmt.Sprintf("%s\n\nReply with a single JSON object that matches this JSON Schema, and nothing else:\n\n%s",
+		prompt, schema.Source)
+}
+
+// jsonMessages is the request for a structured answer: the conversation so far
+// followed by the prompt with the schema
+func (m Model) jsonMessages(prompt string, schema *api.OutputSchema) []openai.ChatCompletionMessage {
+	return append(m.requestMessages(), openai.ChatCompletionMessage{
+		Role:    openai.ChatMessageRoleUser,
+		Content: jsonPrompt(prompt, schema),
+	})
+}
+
+// requestJSON asks for a JSON answer until one matches the schema, telling the
+// model what was wrong with each rejected answer. Nothing is recorded in the
+// history; the caller does that with the result.
+func requestJSON(ctx context.Context, provider api.OverridableProvider, messages []openai.ChatCompletionMessage, schema *api.OutputSchema) JSONResultMsg {
+	var result JSONResultMsg
+	jsonProvider := provider.WithOverrides(api.Overrides{NoTools: true, JSON: true})
+
+	for result.Attempts < jsonAttempts {
+		result.Attempts++
+		events, err := jsonProvider.CreateChatCompletionStream(ctx, messages)
+		if err != nil {
+			result.Err = err
+			return result
+		}
+		content, usage, err := collectAnswer(ctx, events)
+		result.Usage.InputTokens += usage.InputTokens
+		result.Usage.OutputTokens += usage.OutputTokens
+		result.Usage.CachedTokens += usage.CachedTokens
+		if err != nil {
+			result.Err = err
+			return result
+		}
+
+		answer := extractJSON(content)
+		if result.Err = schema.Validate(answer); result.Err == nil {
+			result.JSON = answer
+			return result
+		}
+		messages = append(messages,
+			openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: content},
+			openai.ChatCompletionMessage{
+				Role:    openai.ChatMessageRoleUser,
+				Content: fmt.Sprintf("That %v\nReply with the corrected JSON object only.", result.Err),
+			})
+	}
+	return result
+}
+
+// collectAnswer reads a stream to the end and returns its content and usage
+func collectAnswer(ctx context.Context, events <-chan api.StreamEvent) (string, api.TokenUsage, error) {
+	var content strings.Builder
+	var usage api.TokenUsage
+	for {
+		select {
+		case <-ctx.Done():
+			return "", usage, ctx.Err()
+		case event, ok := <-events:
+			if !ok {
+				if content.Len() == 0 {
+					return "", usage, errors.New("stream ended without a response")
+				}
+				return content.String(), usage, nil
+			}
+			switch event.Type {
+			case api.EventTypeContent:
+				content.WriteString(event.Content)
+			case api.EventTypeError:
+				return "", usage, event.Error
+			case api.EventTypeRetry:
+				if event.Retry.Restart {
+					content.Reset()
+				}
+			case api.EventTypeDone:
+				if event.Usage != nil {
+					usage = *event.Usage
+				}
+			}
+		}
+	}
+}
+
+// extractJSON strips the code fence some models put around JSON despite being
+// asked not to
+func extractJSON(content string) string {
+	content = strings.TrimSpace(content)
+	if !strings.HasPrefix(content, "```") {
+		return content
+	}
+	_, body, _ := strings.Cut(content, "\n")
+	return strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(body), "```"))
+}
+
+// recordJSON adds a structured exchange to the history and saves the session
+func (m *Model) recordJSON(result JSONResultMsg) {
+	m.history.UpdateTokenUsage(result.Usage.InputTokens, result.Usage.OutputTokens, result.Usage.CachedTokens)
+	if result.Err != nil {
+		return
+	}
+	m.history.AddUserMessage(result.Prompt)
+	m.history.AddAssistantMessage(result.JSON, nil)
+	m.saveSession()
+}
+
+// RunJSON sends prompt with the conversation so far and returns an answer that
+// matches schema, retrying when the model's answer does not
+func (m *Model) RunJSON(ctx context.Context, schema *api.OutputSchema, prompt string) (string, error) {
+	provider, ok := m.apiClient.(api.OverridableProvider)
+	if !ok {
+		return "", errors.New("the current provider cannot request JSON output")
+	}
+	result := requestJSON(ctx, provider, m.jsonMessages(prompt, schema), schema)
+	result.Prompt = prompt
+	m.recordJSON(result)
+	if result.Err != nil {
+		return "", fmt.Errorf("no valid JSON after %d attempt(s): %w", result.Attempts, result.Err)
+	}
+	return result.JSON, nil
+}
+
+// handleJSONCommand asks for an answer matching a JSON Schema file
+func (m Model) handleJSONCommand(arg string) (tea.Model, tea.Cmd) {
+	schemaPath, prompt, _ := strings.Cut(strings.TrimSpace(arg), " ")
+	prompt = strings.TrimSpace(prompt)
+	if schemaPath == "" || prompt == "" {
+		m.addErrorMessage(jsonUsage)
+		m.updateViewport()
+		return m, nil
+	}
+
+	schema, err := ReadOutputSchema(schemaPath)
+	if err != nil {
+		m.addErrorMessage(err.Error())
+		m.updateViewport()
+		return m, nil
+	}
+	provider, ok := m.apiClient.(api.OverridableProvider)
+	if !ok {
+		m.addErrorMessage("The current provider cannot request JSON output")
+		m.updateViewport()
+		return m, nil
+	}
+
+	messages := m.jsonMessages(prompt, schema)
+	ctx, cancel := context.WithCancel(context.Background())
+	m.streamCancel = cancel
+	m.state = StateStreaming
+	m.showWelcome = false
+	m.dismissError()
+	m.addUserMessage(prompt)
+	m.addSystemMessage(FormatInfo("JSON answer matching "+FormatFilePath(schemaPath), m.config.UI.EnableEmoji))
+	m.textInput.SetValue("")
+	m.updateViewport()
+
+	return m, tea.Batch(m.spinner.Tick, func() tea.Msg {
+		result := requestJSON(ctx, provider, messages, schema)
+		result.Prompt = prompt
+		return result
+	})
+}
+
+// handleJSONResult shows the validated answer, or why none was accepted
+func (m Model) handleJSONResult(msg JSONResultMsg) (tea.Model, tea.Cmd) {
+	m.state = StateReady
+	index := m.history.GetConversationLength()
+	m.recordJSON(msg)
+	if msg.Err == nil {
+		m.markPrompt(index)
+	}
+
+	if msg.Err != nil {
+		m.addErrorMessage(fmt.Sprintf("No valid JSON after %d attempt(s): %v", msg.Attempts, msg.Err))
+	} else {
+		m.addAssistantLabel()
+		display := msg.JSON
+		var indented bytes.Buffer
+		if err := json.Indent(&indented, []byte(msg.JSON), "", "  "); err == nil {
+			display = indented.String()
+		}
+		m.messages = append(m.messages, Message{Role: "json", Content: display, Timestamp: time.Now()})
+		if msg.Attempts > 1 {
+			m.addSystemMessage(FormatInfo(fmt.Sprintf("Matched the schema on attempt %d of %d", msg.Attempts, jsonAttempts), m.config.UI.EnableEmoji))
+		}
+	}
+	m.updateViewport()
+	return m, nil
+}
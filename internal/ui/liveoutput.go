@@ -0,0 +1,71 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// commandPanelLines is how many of a running command's last output lines are shown
+const commandPanelLines = 8
+
+// commandRunning reports whether a run_command call of the batch is running,
+// which is when the live output panel is shown
+func (m Model) commandRunning() bool {
+	for _, status := range m.toolStatuses {
+		if status.Name == "run_command" && status.State == ToolRunning {
+			return true
+		}
+	}
+	return false
+}
+
+// commandPanelHeight is the number of lines the live output panel takes
+func (m Model) commandPanelHeight() int {
+	if !m.commandRunning() {
+		return 0
+	}
+	return commandPanelLines + 1
+}
+
+// stopCommand stops the command in the live output panel. Its output up to
+// that point still goes back to the model.
+func (m *Model) stopCommand() bool {
+	if !m.commandRunning() {
+		return false
+	}
+	return m.fileOps.StopCommand()
+}
+
+// renderCommandPanel shows the command being run with the time it has taken
+// and the end of its output so far, redrawn as the spinner ticks. Lines keep
+// only what follows a carriage return, so progress bars show their latest
+// state.
+func (m Model) renderCommandPanel() string {
+	running, ok := m.fileOps.RunningCommand()
+	elapsed := time.Duration(0)
+	if ok {
+		elapsed = time.Since(running.Started).Truncate(time.Second)
+	}
+
+	header := InfoStyle.Render(truncate("$ "+strings.Join(strings.Fields(running.Command), " "), max(m.width-30, 20))) +
+		HelpStyle.Render(fmt.Sprintf(" · %s · Esc to stop", elapsed))
+
+	var lines []string
+	for _, line := range strings.Split(strings.TrimRight(running.Output, "\n"), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if i := strings.LastIndex(line, "\r"); i >= 0 {
+			line = line[i+1:]
+		}
+		line = strings.ReplaceAll(ansiSequence.ReplaceAllString(line, ""), "\t", "    ")
+		lines = append(lines, "  "+HelpStyle.Render(truncate(line, max(m.width-4, 10))))
+	}
+	if len(lines) > commandPanelLines {
+		lines = lines[len(lines)-commandPanelLines:]
+	}
+	// Keep the height fixed so the transcript does not jump as output arrives
+	for len(lines) < commandPanelLines {
+		lines = append(lines, "")
+	}
+	return header + "\n" + strings.Join(lines, "\n")
+}
@@ -0,0 +1,64 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/alchemy-labs-co/riptide/internal/conversation"
+)
+
+// handleMemoryCommand shows the project memory file, or appends an instruction
+// to it with "add". The system prompt is rebuilt so the model follows the new
+// instruction from the next request.
+func (m Model) handleMemoryCommand(arg string) (tea.Model, tea.Cmd) {
+	m.textInput.SetValue("")
+
+	action, instruction, _ := strings.Cut(strings.TrimSpace(arg), " ")
+	switch strings.ToLower(action) {
+	case "":
+		m.addSystemMessage(m.memoryText())
+	case "add":
+		if strings.TrimSpace(instruction) == "" {
+			m.addErrorMessage("Usage: /memory add <instruction>")
+			break
+		}
+		path, err := conversation.AppendMemory(".", instruction)
+		if err != nil {
+			m.addErrorMessage(fmt.Sprintf("Failed to update project memory: %v", err))
+			break
+		}
+		prompt, err := conversation.SystemPrompt(m.config)
+		if err != nil {
+			m.addErrorMessage(fmt.Sprintf("Failed to reload project memory: %v", err))
+			break
+		}
+		m.history.SetSystemPrompt(prompt)
+		m.addSystemMessage(FormatSuccess(fmt.Sprintf("Added to %s; the model follows it from the next prompt", filepath.Base(path)), m.config.UI.EnableEmoji))
+	default:
+		m.addErrorMessage("Usage: /memory [add <instruction>]")
+	}
+
+	m.updateViewport()
+	return m, nil
+}
+
+// memoryText shows the project memory file the model is following
+func (m Model) memoryText() string {
+	path := conversation.FindMemory(".")
+	if path == "" {
+		return fmt.Sprintf("⎿  No project memory; /memory add <instruction> creates %s", conversation.MemoryFiles[0])
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return FormatError(fmt.Sprintf("Failed to read %s: %v", filepath.Base(path), err), m.config.UI.EnableEmoji)
+	}
+	content := strings.TrimSpace(string(data))
+	if content == "" {
+		content = "(empty)"
+	}
+	return fmt.Sprintf("%s Project memory (%s)\n\n%s", GetIcon("file", m.config.UI.EnableEmoji), filepath.Base(path), content)
+}
@@ -0,0 +1,127 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/alchemy-labs-co/riptide/internal/conversation"
+	"github.com/alchemy-labs-co/riptide/internal/functions"
+	"github.com/alchemy-labs-co/riptide/internal/safety"
+)
+
+// mentionPattern matches an @path word in a prompt
+var mentionPattern = regexp.MustCompile(`(?:^|\s)@(\S+)`)
+
+// mentionTrailing is punctuation that may end the sentence a mention is in
+const mentionTrailing = `.,;:!?)]}'"`
+
+// mention is a file named with @path in a prompt and what attaching it did
+type mention struct {
+	Name      string // As typed, without the @
+	Path      string // Absolute path of the file
+	Tokens    int
+	Redaction safety.Redaction
+	Reasons   []string // Kinds of instruction-like text that were quarantined
+	Skip      string   // Why the file was not attached; empty when it was
+}
+
+// String describes an attached mention in plain text, for headless runs
+func (f mention) String() string {
+	if f.Skip != "" {
+		return fmt.Sprintf("Did not attach @%s: %s", f.Name, f.Skip)
+	}
+	s := fmt.Sprintf("Attached %s (~%s tokens)", f.Name, formatTokenCount(f.Tokens))
+	if f.Redaction.Count > 0 {
+		s += "; redacted " + f.Redaction.String()
+	}
+	if len(f.Reasons) > 0 {
+		s += "; quarantined instruction-like text (" + strings.Join(f.Reasons, ", ") + ")"
+	}
+	return s
+}
+
+// mentionedFiles finds the @path mentions in a prompt that name files, relative
+// to root unless absolute. Punctuation ending a sentence is not part of the
+// path, and words naming nothing on disk, such as @username, are ignored.
+func mentionedFiles(root, prompt string) []mention {
+	var mentions []mention
+	seen := make(map[string]bool)
+	for _, match := range mentionPattern.FindAllStringSubmatch(prompt, -1) {
+		for _, name := range []string{match[1], strings.TrimRight(match[1], mentionTrailing)} {
+			path := name
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(root, path)
+			}
+			path, err := functions.NormalizePath(path)
+			if err != nil {
+				break
+			}
+			info, err := os.Stat(path)
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			if err == nil && !info.IsDir() && !seen[path] {
+				seen[path] = true
+				mentions = append(mentions, mention{Name: name, Path: path})
+			}
+			break
+		}
+	}
+	return mentions
+}
+
+// attachMentions adds the files mentioned with @path in a prompt to the context
+// ahead of it, scrubbed like files added with /add. Files already in context
+// are left out; binary and oversized ones are reported with why they were not
+// attached.
+func (m *Model) attachMentions(prompt string) []mention {
+	var attached []mention
+	for _, file := range mentionedFiles(m.workspaceRoot, prompt) {
+		if m.history.FileAlreadyInContext(file.Path) {
+			continue
+		}
+		if file.Skip = m.scanner.SkipReason(file.Path); file.Skip != "" {
+			attached = append(attached, file)
+			continue
+		}
+
+		content, err := m.fileOps.ReadFileForContext(file.Path)
+		if err != nil {
+			file.Skip = err.Error()
+			attached = append(attached, file)
+			continue
+		}
+		content, file.Redaction = m.redactFrom(content, file.Path)
+		content, file.Reasons = guardInjection(file.Path, content)
+		m.history.AddFileMessage(file.Path, content)
+		file.Tokens = conversation.EstimateTokens(content)
+		attached = append(attached, file)
+	}
+	return attached
+}
+
+// sendMentions attaches the files mentioned in a prompt, noting each in the
+// transcript
+func (m *Model) sendMentions(prompt string) {
+	enableEmoji := m.config.UI.EnableEmoji
+	for _, file := range m.attachMentions(prompt) {
+		if file.Skip != "" {
+			m.addSystemMessage(FormatWarning(fmt.Sprintf("Did not attach @%s: %s", file.Name, file.Skip), enableEmoji))
+			continue
+		}
+
+		result := FormatSuccess(fmt.Sprintf("Attached %s (~%s tokens)", FormatFilePath(file.Name), formatTokenCount(file.Tokens)), enableEmoji)
+		if file.Redaction.Count > 0 {
+			result += "\n" + FormatWarning("Redacted "+file.Redaction.String()+" — use /redact off to send as-is", enableEmoji)
+		}
+		if len(file.Reasons) > 0 {
+			result += "\n" + FormatWarning("Quarantined instruction-like text ("+strings.Join(file.Reasons, ", ")+"); the model is told to treat it as data", enableEmoji)
+		}
+		m.addSystemMessage(result)
+	}
+}
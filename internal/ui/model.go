@@ -3,18 +3,21 @@ package ui
 import (
 	"context"
 	"fmt"
+	"os"
 	"strings"
 	"time"
 
-	"github.com/charmbracelet/bubbles/spinner"
-	"github.com/charmbracelet/bubbles/textinput"
-	"github.com/charmbracelet/bubbles/viewport"
-	tea "github.com/charmbracelet/bubbletea"
-	"github.com/charmbracelet/lipgloss"
 	"github.com/alchemy-labs-co/riptide/internal/api"
 	"github.com/alchemy-labs-co/riptide/internal/config"
 	"github.com/alchemy-labs-co/riptide/internal/conversation"
 	"github.com/alchemy-labs-co/riptide/internal/functions"
+	"github.com/alchemy-labs-co/riptide/internal/hooks"
+	"github.com/alchemy-labs-co/riptide/internal/safety"
+	"github.com/alchemy-labs-co/riptide/internal/session"
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
 )
 
 // Command represents a slash command with its description
@@ -27,10 +30,33 @@ type Command struct {
 // Available slash commands
 var availableCommands = []Command{
 	{Name: "/add", Description: "Add file or directory to context", Usage: "/add <path>"},
+	{Name: "/ask", Description: "Send a prompt with a different model or parameters for one turn", Usage: "/ask [--model NAME] [--temp T] [--max-tokens N] <prompt>"},
 	{Name: "/clear", Description: "Clear conversation history", Usage: "/clear"},
+	{Name: "/commit", Description: "Commit staged changes with a message written by the model", Usage: "/commit [message]"},
+	{Name: "/compare", Description: "Answer a prompt with two models side by side", Usage: "/compare <model-a> <model-b> <prompt>"},
 	{Name: "/config", Description: "Configure settings", Usage: "/config"},
+	{Name: "/context", Description: "Show, remove or refresh the files in context", Usage: "/context"},
+	{Name: "/edit", Description: "Edit and resubmit a prompt", Usage: "/edit"},
+	{Name: "/errors", Description: "Show errors from this session", Usage: "/errors"},
+	{Name: "/export", Description: "Save the full transcript as Markdown, JSON or HTML", Usage: "/export [md|json|html] [path]"},
 	{Name: "/help", Description: "Show help information", Usage: "/help"},
+	{Name: "/json", Description: "Get an answer as JSON matching a schema", Usage: "/json <schema-file> <prompt>"},
+	{Name: "/map", Description: "Add a map of the project's files and declarations to context", Usage: "/map [path]"},
+	{Name: "/memory", Description: "Show or add to the project instructions in RIPTIDE.md", Usage: "/memory [add <instruction>]"},
+	{Name: "/mode", Description: "Show or set the permission mode", Usage: "/mode <readonly|edit|auto>"},
+	{Name: "/retry", Description: "Drop the last response and generate a new one", Usage: "/retry"},
+	{Name: "/resume", Description: "Resume the last or a named saved session", Usage: "/resume [id or title]"},
+	{Name: "/sessions", Description: "Browse, resume and delete saved sessions", Usage: "/sessions"},
+	{Name: "/share", Description: "Export the conversation as HTML or a gist", Usage: "/share [html|gist]"},
 	{Name: "/status", Description: "Show current configuration and pricing", Usage: "/status"},
+	{Name: "/readonly", Description: "Turn read-only mode on or off", Usage: "/readonly [on|off]"},
+	{Name: "/recipe", Description: "List recipes or save this conversation as one", Usage: "/recipe [save <name> [param=value ...]]"},
+	{Name: "/redact", Description: "Turn secret redaction on or off", Usage: "/redact <on|off>"},
+	{Name: "/test", Description: "Run the tests and hand failures to the model", Usage: "/test [target]"},
+	{Name: "/todos", Description: "List TODO/FIXME comments and add them to context", Usage: "/todos [path]"},
+	{Name: "/undo", Description: "Revert the last file changes or list them", Usage: "/undo [list|turn|n]"},
+	{Name: "/vim", Description: "Turn vim keybindings on or off", Usage: "/vim [on|off]"},
+	{Name: "/writes", Description: "Show files Riptide has written", Usage: "/writes [path filter]"},
 	{Name: "/quit", Description: "Quit the application", Usage: "/quit"},
 }
 
@@ -50,14 +76,17 @@ const (
 type Model struct {
 	// Core components
 	config    *config.Config
-	apiClient *api.Client
+	apiClient api.Provider
 	fileOps   *functions.FileOperations
 	scanner   *functions.DirectoryScanner
 	history   *conversation.History
+	redactor  *safety.Redactor
+	commands  *safety.CommandClassifier
+	hooks     *hooks.Runner
 
 	// UI components
 	viewport  viewport.Model
-	textInput textinput.Model
+	textInput textarea.Model
 	spinner   spinner.Model
 
 	// State
@@ -76,6 +105,7 @@ type Model struct {
 	autocompleteCommand       *Command
 	autocompleteMatches       []Command
 	autocompleteSelectedIndex int
+	autocompletePrefix        string // Input before the path being completed; empty when completing a command
 
 	// Config menu state
 	configMenuActive  bool
@@ -92,6 +122,137 @@ type Model struct {
 	streamEvents       <-chan api.StreamEvent
 	accumulatedContent string
 	hasContent         bool
+	streamStart        int             // Index of the first message the current response added
+	streamRetry        *api.RetryInfo  // The retry in progress, shown in the status line
+	streamRetryAt      time.Time       // When the retry's attempt starts
+	streamRender       *streamRender   // The transcript before the message being streamed, already rendered
+	answerRender       *markdownStream // The complete blocks of the answer being streamed, already rendered
+
+	// Reasoning phase tracking
+	accumulatedReasoning string
+	reasoningStart       time.Time
+	reasoningDuration    time.Duration
+
+	// Whether reasoning is shown in full; Ctrl+G toggles it
+	reasoningExpanded bool
+
+	// Whether the oldest turns are being summarized before the next request
+	summarizing bool
+
+	// Progress of the directory scan run by /add
+	scanProgress *functions.ScanProgress
+
+	// Tool execution state
+	toolStatuses []ToolStatus
+
+	// Error banner state
+	errorBanner *ErrorEntry
+	errorLog    []ErrorEntry
+
+	// Quit confirmation while a response or tool batch is in flight
+	confirmingQuit bool
+
+	// Tool call waiting for the user's approval
+	pendingApproval *ApprovalRequestMsg
+
+	// Commit message from /commit waiting for confirmation
+	pendingCommit string
+
+	// Workspace root; tool calls reaching outside it and the allowed paths are refused
+	workspaceRoot string
+
+	// Number of user messages sent this session, recorded in the write ledger
+	turn int
+
+	// Rounds of tool calls run for the current prompt
+	toolRounds int
+
+	// Permission mode to return to when /readonly is switched off
+	modeBeforeReadOnly string
+
+	// Whether tool cards in the transcript show their output, toggled with Ctrl+L
+	toolOutputExpanded bool
+
+	// Help overlay state
+	helpActive bool
+	helpPage   int
+
+	// Command palette state
+	paletteActive  bool
+	paletteQuery   string
+	paletteIndex   int
+	paletteMatches []PaletteItem
+	recentFiles    []string
+
+	// Write files without approval; starts from permissions.auto_approve_edits
+	// and is turned on by answering "a" to a file change. Tool batches take a
+	// copy, so it is only read and written on the UI goroutine.
+	autoApproveEdits bool
+
+	// Edit-and-resubmit state; editingPrompt counts back from the latest prompt (0 when not editing)
+	editSelectActive bool
+	editSelectIndex  int
+	editingPrompt    int
+
+	// Compose preview state; composeExcluded holds context files deselected for the next prompt
+	composeActive         bool
+	composeIndex          int
+	composeExcluded       map[string]bool
+	composeReminderTokens int // Ambient reminder size, measured when the preview opens
+
+	// Running or finished /compare, shown instead of the transcript until closed
+	compare *compareState
+
+	// Model and parameter overrides for the current turn only (nil when none)
+	turnOverrides *api.Overrides
+
+	// Large pastes attached to the next prompt instead of typed into the input
+	pastes []pastedText
+
+	// Prompt recall with Up/Down; historyIndex counts back from the latest prompt (0 when not browsing)
+	promptHistory *session.PromptHistory
+	historyIndex  int
+	historyDraft  string // What was typed before browsing started
+
+	// Model pinned with Ctrl+O while routing is on; empty routes each prompt
+	routePin string
+
+	// Session persistence
+	sessionStore   *session.Store // nil when no data directory is available
+	session        *session.Session
+	recentSessions []session.Summary
+	sessionBase    session.Usage // Usage saved before this run of the session
+	titledSession  string        // ID of the session a title was last requested for
+
+	// Context manager opened with /context
+	contextActive bool
+	contextIndex  int
+	contextNotice string // The outcome of the last removal or refresh
+
+	// Session browser opened with /sessions
+	sessionsActive   bool
+	sessionsList     []session.Summary
+	sessionsIndex    int
+	sessionsDeleting bool // Waiting for y to delete the selected session
+
+	// Vim keymap state; vimPending holds the first key of gg or dd
+	vimNormal  bool
+	vimPending string
+
+	// Transcript search opened with Ctrl+F, or / in vim normal mode
+	searchOpen    bool   // The search bar takes n, N and Enter
+	searchEditing bool   // Keys edit the query
+	searchQuery   string // Highlighted in the transcript until Esc clears it
+	searchOrigin  int    // Viewport offset when the search opened; typing searches from here
+	searchLine    int    // Transcript line of the current match
+	searchMatch   int    // 1-based index of the current match, 0 when none
+	searchTotal   int
+
+	// Welcome screen tip rotation
+	tipIndex int
+
+	// Set when the background check finds a newer release
+	updateNotice string
 
 	// Program reference for sending messages
 	program *tea.Program
@@ -103,11 +264,16 @@ type Message struct {
 	Content   string
 	Timestamp time.Time
 	IsError   bool
+	Tokens    int           // Estimated tokens, shown next to bulky system messages and collapsed reasoning
+	Duration  time.Duration // Reasoning time, set on the reasoning label once thinking ends
+	Tool      *ToolStatus   // The finished call a "tool" message shows as a card
+	History   int           // Where a prompt sits in the conversation history; 0 when it never reached it
 }
 
-// StreamMsg is sent when streaming content is received
+// StreamMsg is sent with the stream events gathered since the last one, so a
+// fast stream redraws the transcript once per batch rather than per token
 type StreamMsg struct {
-	Event api.StreamEvent
+	Events []api.StreamEvent
 }
 
 // StreamCompleteMsg is sent when streaming is complete
@@ -117,14 +283,37 @@ type StreamCompleteMsg struct {
 
 // ProcessCompleteMsg is sent when processing is complete
 type ProcessCompleteMsg struct {
-	Result string
-	Error  error
+	Result     string
+	Error      error
+	Suggestion string // Command to offer instead after an error, e.g. /add with a close match
 }
 
 // NewModel creates a new Bubble Tea model
 func NewModel(cfg *config.Config) (*Model, error) {
+	// Fail fast on a malformed tool definition rather than confusing the model
+	if err := api.ValidateToolDefinitions(); err != nil {
+		return nil, fmt.Errorf("validating tool definitions: %w", err)
+	}
+	if err := api.RegisterModels(cfg.Models); err != nil {
+		return nil, fmt.Errorf("registering models: %w", err)
+	}
+	if err := ApplyTheme(cfg.UI.Theme, cfg.UI.Themes); err != nil {
+		return nil, fmt.Errorf("applying theme: %w", err)
+	}
+
+	// A configured workspace root becomes the working directory, so relative
+	// paths in tool calls, the write ledger and undo backups all start there
+	if root := cfg.FileOperations.WorkspaceRoot; root != "" {
+		if err := os.Chdir(expandHome(root)); err != nil {
+			return nil, fmt.Errorf("entering workspace root: %w", err)
+		}
+	}
+
 	// Create API client
-	apiClient := api.NewClient(cfg)
+	apiClient, err := api.NewClient(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("creating API client: %w", err)
+	}
 
 	// Create file operations handler
 	fileOps := functions.NewFileOperations(cfg)
@@ -133,17 +322,25 @@ func NewModel(cfg *config.Config) (*Model, error) {
 	scanner := functions.NewDirectoryScanner(cfg)
 
 	// Create conversation history
-	history := conversation.NewHistory(cfg)
-
-	// Create text input
-	ti := textinput.New()
-	ti.Placeholder = "" // Disable placeholder to prevent double-line issue
-	ti.Focus()
-	ti.CharLimit = 1000
-	ti.Width = 80
-	ti.Prompt = ""
-	ti.TextStyle = lipgloss.NewStyle().Foreground(WhiteColor)
-	ti.Cursor.Style = lipgloss.NewStyle().Background(WhiteColor).Foreground(lipgloss.Color("#000000"))
+	history, err := conversation.NewHistory(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("creating conversation history: %w", err)
+	}
+
+	// Create secret redactor
+	redactor, err := safety.NewRedactor(cfg.Redaction)
+	if err != nil {
+		return nil, fmt.Errorf("creating redactor: %w", err)
+	}
+
+	// Create dangerous command classifier
+	commands, err := safety.NewCommandClassifier(cfg.Commands)
+	if err != nil {
+		return nil, fmt.Errorf("creating command classifier: %w", err)
+	}
+
+	// Create the prompt editor
+	ti := newInputEditor()
 
 	// Create spinner
 	s := spinner.New()
@@ -153,33 +350,81 @@ func NewModel(cfg *config.Config) (*Model, error) {
 	// Create viewport
 	vp := viewport.New(80, 20)
 
-	return &Model{
-		config:      cfg,
-		apiClient:   apiClient,
-		fileOps:     fileOps,
-		scanner:     scanner,
-		history:     history,
-		viewport:    vp,
-		textInput:   ti,
-		spinner:     s,
-		state:       StateReady,
-		messages:    make([]Message, 0),
-		showWelcome: true,
-	}, nil
+	// Open the session store; sessions are simply not saved if it is unavailable
+	var store *session.Store
+	if dir, err := session.DefaultDir(); err == nil {
+		store = session.NewStore(dir)
+	}
+
+	// Prompt history is shared by all sessions; without a data directory it lasts for this run
+	historyPath, _ := session.DefaultPromptHistoryPath()
+	promptHistory, _ := session.LoadPromptHistory(historyPath)
+
+	// The working directory is the workspace root
+	workspaceRoot, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("getting working directory: %w", err)
+	}
+
+	m := &Model{
+		config:        cfg,
+		apiClient:     apiClient,
+		fileOps:       fileOps,
+		scanner:       scanner,
+		history:       history,
+		redactor:      redactor,
+		commands:      commands,
+		hooks:         hooks.NewRunner(cfg.Hooks),
+		viewport:      vp,
+		textInput:     ti,
+		spinner:       s,
+		state:         StateReady,
+		messages:      make([]Message, 0),
+		showWelcome:   true,
+		sessionStore:  store,
+		promptHistory: promptHistory,
+		workspaceRoot: workspaceRoot,
+
+		reasoningExpanded: cfg.UI.ShowReasoning == config.ReasoningAlways,
+		autoApproveEdits:  cfg.Permissions.AutoApproveEdits,
+	}
+	m.refreshRecentSessions()
+
+	return m, nil
 }
 
+// timestampTickMsg refreshes relative timestamps in the transcript
+type timestampTickMsg struct{}
+
+// timestampRefreshInterval is how often relative timestamps are re-rendered
+const timestampRefreshInterval = 30 * time.Second
+
 // Init initializes the model
 func (m Model) Init() tea.Cmd {
 	return tea.Batch(
 		m.spinner.Tick,
-		textinput.Blink,
+		textarea.Blink,
+		tickTimestamps(),
+		tickTips(),
 	)
 }
 
+// tickTimestamps schedules the next relative timestamp refresh
+func tickTimestamps() tea.Cmd {
+	return tea.Tick(timestampRefreshInterval, func(time.Time) tea.Msg {
+		return timestampTickMsg{}
+	})
+}
+
 // Update handles messages
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmds []tea.Cmd
 
+	// Treat Shift+Enter like Alt+Enter wherever it arrives
+	if isShiftEnter(msg) {
+		return m.handleKeyPress(tea.KeyMsg{Type: tea.KeyEnter, Alt: true})
+	}
+
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		return m.handleKeyPress(msg)
@@ -188,49 +433,132 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.width = msg.Width
 		m.height = msg.Height
 		m.viewport.Width = msg.Width - 4
-		// Calculate dynamic footer height
-		// Header: 2 lines (title + spacing)
-		// Status line: 1 line
-		// Input box: 3 lines (border + content + status)
-		// Status text below input: 1 line
-		// Autocomplete dropdown: variable (up to 5 lines)
-		// Extra padding: 3 lines for safety
-		footerHeight := 10
-		if m.autocompleteActive && len(m.autocompleteMatches) > 0 {
-			// Add lines for dropdown + hint
-			footerHeight += min(len(m.autocompleteMatches), 5) + 2
-		}
-		m.viewport.Height = msg.Height - footerHeight
-		m.textInput.Width = msg.Width - 4
+		m.resizeViewport()
+		m.textInput.SetWidth(msg.Width - 6)
 		return m, nil
 
 	case StreamMsg:
-		return m.handleStreamEvent(msg.Event)
+		if m.state != StateStreaming {
+			// The stream was cancelled and this event was already on its way
+			return m, nil
+		}
+		return m.handleStreamEvents(msg.Events)
 
 	case StreamCompleteMsg:
+		if m.state != StateStreaming {
+			return m, nil
+		}
 		m.state = StateReady
 		if msg.Error != nil {
-			m.addErrorMessage(fmt.Sprintf("Stream error: %v", msg.Error))
+			m.showError(fmt.Sprintf("Stream error: %v", msg.Error), true)
+		}
+		m.saveSession()
+		m.updateViewport()
+		if msg.Error != nil {
+			return m, nil
 		}
+		return m, tea.Batch(m.postTurnHooks(), m.titleSession())
+
+	case SummaryMsg:
+		return m.handleSummary(msg)
+
+	case HookFailedMsg:
+		m.showError(msg.Err.Error(), false)
 		m.updateViewport()
 		return m, nil
 
+	case ScanProgressMsg:
+		if m.state == StateProcessing {
+			progress := functions.ScanProgress(msg)
+			m.scanProgress = &progress
+		}
+		return m, nil
+
 	case ProcessCompleteMsg:
 		m.state = StateReady
+		m.scanProgress = nil
 		if msg.Error != nil {
-			m.addErrorMessage(fmt.Sprintf("Process error: %v", msg.Error))
+			m.showSuggestion(fmt.Sprintf("Process error: %v", msg.Error), msg.Suggestion)
 		} else if msg.Result != "" {
 			m.addSystemMessage(msg.Result)
 		}
 		m.updateViewport()
 		return m, nil
 
+	case ApprovalRequestMsg:
+		m.pendingApproval = &msg
+		if msg.Index >= 0 && msg.Index < len(m.toolStatuses) {
+			m.toolStatuses[msg.Index].State = ToolAwaitingApproval
+		}
+		if len(msg.Changes) > 0 {
+			m.showChanges(msg.Changes)
+		}
+		return m, nil
+
+	case ChangeEditedMsg:
+		return m.handleChangeEdited(msg)
+
+	case ToolProgressMsg:
+		if msg.Index >= 0 && msg.Index < len(m.toolStatuses) {
+			m.toolStatuses[msg.Index].State = msg.State
+			m.toolStatuses[msg.Index].Error = msg.Error
+			m.toolStatuses[msg.Index].Redacted = msg.Redacted
+			m.toolStatuses[msg.Index].Quarantined = msg.Quarantined
+			m.toolStatuses[msg.Index].Duration = msg.Duration
+			m.toolStatuses[msg.Index].Result = msg.Result
+			// The command output panel comes and goes with run_command
+			m.resizeViewport()
+		}
+		return m, nil
+
 	case FollowUpMsg:
+		// Leave a card for each call of the finished batch in the transcript
+		if len(m.toolStatuses) > 0 {
+			for _, status := range m.toolStatuses {
+				m.addToolMessage(status)
+			}
+			m.toolStatuses = nil
+			m.resizeViewport()
+		}
 		return m.handleFollowUp()
 
 	case ExecuteToolsMsg:
 		return m.handleExecuteTools(msg.ToolCalls)
 
+	case CompareEventMsg:
+		return m.handleCompareEvent(msg)
+
+	case JSONResultMsg:
+		return m.handleJSONResult(msg)
+
+	case TestResultMsg:
+		return m.handleTestResult(msg)
+
+	case CommitMessageMsg:
+		return m.handleCommitMessage(msg)
+
+	case SessionTitleMsg:
+		return m.handleSessionTitle(msg)
+
+	case timestampTickMsg:
+		if m.config.UI.Timestamps == config.TimestampsRelative {
+			m.updateViewport()
+		}
+		return m, tickTimestamps()
+
+	case UpdateAvailableMsg:
+		m.handleUpdateAvailable(msg)
+		return m, nil
+
+	case tipTickMsg:
+		// Stop rotating once the welcome screen is gone; /clear restarts it
+		if !m.showWelcome || len(m.messages) > 0 {
+			return m, nil
+		}
+		m.tipIndex = (m.tipIndex + visibleTips) % len(welcomeTips)
+		m.updateViewport()
+		return m, tickTips()
+
 	case spinner.TickMsg:
 		if m.state == StateProcessing || m.state == StateStreaming {
 			var cmd tea.Cmd
@@ -261,6 +589,29 @@ func (m Model) View() string {
 		return m.renderConfigMenu()
 	}
 
+	// Full-screen overlays
+	if m.helpActive {
+		return m.renderHelpOverlay()
+	}
+	if m.paletteActive {
+		return m.renderPalette()
+	}
+	if m.editSelectActive {
+		return m.renderEditSelect()
+	}
+	if m.sessionsActive {
+		return m.renderSessions()
+	}
+	if m.contextActive {
+		return m.renderContextPanel()
+	}
+	if m.composeActive {
+		return m.renderCompose()
+	}
+	if m.compare != nil {
+		return m.renderCompare()
+	}
+
 	var content strings.Builder
 
 	// Show welcome screen on first run only if no messages
@@ -269,8 +620,17 @@ func (m Model) View() string {
 		content.WriteString("\n\n")
 	}
 
-	// Render messages
-	content.WriteString(m.renderMessages())
+	// Render messages, marking search matches
+	content.WriteString(m.highlightMatches(m.renderMessages()))
+
+	// A multi-line prompt grows the input box, so the transcript gives up the lines
+	if extra := m.inputLines() - 1; extra > 0 && m.state == StateReady {
+		atBottom := m.viewport.AtBottom()
+		m.viewport.Height = max(m.viewport.Height-extra, 1)
+		if atBottom {
+			m.viewport.GotoBottom()
+		}
+	}
 
 	// Update viewport content
 	m.viewport.SetContent(content.String())
@@ -286,6 +646,30 @@ func (m Model) View() string {
 	view.WriteString(m.viewport.View())
 	view.WriteString("\n")
 
+	// Output of a command while it runs
+	if m.commandRunning() {
+		view.WriteString(m.renderCommandPanel())
+		view.WriteString("\n")
+	}
+
+	// Live tool checklist while tools execute
+	if len(m.toolStatuses) > 0 {
+		view.WriteString(m.renderToolStatusList())
+		view.WriteString("\n")
+	}
+
+	// Error banner stays above the input until dismissed
+	if m.errorBanner != nil {
+		view.WriteString(m.renderErrorBanner())
+		view.WriteString("\n")
+	}
+
+	// Pastes waiting to go with the next prompt
+	if len(m.pastes) > 0 {
+		view.WriteString(m.renderPastes())
+		view.WriteString("\n")
+	}
+
 	// Status line
 	view.WriteString(m.renderStatusLine())
 	view.WriteString("\n")
@@ -303,62 +687,175 @@ func (m Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m.handleConfigMenuKeyPress(msg)
 	}
 
+	// Handle overlays
+	if m.helpActive {
+		return m.handleHelpKeyPress(msg)
+	}
+	if m.paletteActive {
+		return m.handlePaletteKeyPress(msg)
+	}
+	if m.editSelectActive {
+		return m.handleEditSelectKeyPress(msg)
+	}
+	if m.sessionsActive {
+		return m.handleSessionsKeyPress(msg)
+	}
+	if m.contextActive {
+		return m.handleContextKeyPress(msg)
+	}
+	if m.composeActive {
+		return m.handleComposeKeyPress(msg)
+	}
+	if m.compare != nil {
+		return m.handleCompareKeyPress(msg)
+	}
+
+	// Answer the tool approval prompt
+	if m.pendingApproval != nil {
+		return m.handleApprovalKeyPress(msg)
+	}
+
+	// Answer the commit confirmation prompt
+	if m.pendingCommit != "" {
+		return m.handleCommitKeyPress(msg)
+	}
+
+	// Answer the quit confirmation prompt
+	if m.confirmingQuit {
+		switch msg.String() {
+		case "y", "Y", "ctrl+c":
+			m.answerApproval(false)
+			return m.quit()
+		case "n", "N", "esc":
+			m.confirmingQuit = false
+		}
+		return m, nil
+	}
+
+	// The search bar, then the vim keymap, take keys before the defaults
+	if m.searchOpen && m.handleSearchKey(msg) {
+		return m, nil
+	}
+	if m.vimKeymap() && m.handleVimKey(msg) {
+		return m, nil
+	}
+
 	// Handle special keys first
 	switch msg.Type {
 	case tea.KeyCtrlC:
-		// Ctrl+C pressed
-		if m.streamCancel != nil {
-			// Canceling stream
-			m.streamCancel()
+		// Ctrl+C force quits without confirmation
+		return m.quit()
+
+	case tea.KeyCtrlX:
+		if m.state == StateStreaming {
+			return m.cancelStream()
+		}
+		if m.state == StateProcessing && m.stopCommand() {
+			return m, nil
 		}
-		m.state = StateQuitting
-		return m, tea.Quit
 
 	case tea.KeyCtrlD:
 		if m.state == StateReady {
-			m.state = StateQuitting
-			return m, tea.Quit
+			return m.quit()
+		}
+		if m.state == StateStreaming || m.state == StateProcessing {
+			m.confirmingQuit = true
+			return m, nil
 		}
 
 	case tea.KeyEnter:
+		// Alt+Enter (and Shift+Enter, see isShiftEnter) starts a new line
+		if msg.Alt {
+			if m.state == StateReady {
+				m.insertNewline()
+			}
+			return m, nil
+		}
 		if m.state == StateReady {
-			// If autocomplete is active, fill the command instead of submitting
-			if m.autocompleteActive && m.autocompleteSuggestion != "" {
-				m.textInput.SetValue(m.autocompleteSuggestion + " ")
-				m.textInput.SetCursor(len(m.autocompleteSuggestion) + 1)
-				m.updateAutocomplete()
+			// If a command is suggested, fill it instead of submitting; paths
+			// are only filled by Tab, so a prompt mentioning one still sends
+			if m.autocompleteActive && m.autocompleteSuggestion != "" && m.autocompletePrefix == "" {
+				m.acceptAutocomplete()
 				return m, nil
 			}
 
 			input := strings.TrimSpace(m.textInput.Value())
+			if input == "" && len(m.pastes) > 0 {
+				// Pastes sent on their own are the whole prompt
+				input = m.pasteLabels()
+			}
 			if input == "" {
 				return m, nil
 			}
+			m.rememberPrompt(strings.TrimSpace(m.textInput.Value()))
+			m.vimNormal = false
 
 			// Check for commands
 			if strings.HasPrefix(input, "/") {
+				m.editingPrompt = 0
 				return m.handleCommand(input)
 			}
 
 			// Check for exit commands
 			if input == "exit" || input == "quit" {
-				m.state = StateQuitting
-				return m, tea.Quit
+				return m.quit()
 			}
 
 			// Process regular input
-			m.showWelcome = false
-			m.addUserMessage(input)
-			m.textInput.SetValue("")
+			return m.submitPrompt(input)
+		}
 
-			// Force scroll to bottom for new user messages
-			content := m.renderMessages()
-			m.viewport.SetContent(content)
-			m.viewport.GotoBottom()
+	case tea.KeyCtrlK:
+		if m.state == StateReady {
+			return m.openPalette()
+		}
+		return m, nil
 
-			// Start processing
-			return m.startConversation(input)
+	case tea.KeyCtrlF:
+		m.openSearch()
+		return m, nil
+
+	case tea.KeyCtrlP:
+		if m.state == StateReady {
+			return m.openEditSelect()
 		}
+		return m, nil
+
+	case tea.KeyCtrlE:
+		if m.state == StateReady {
+			return m.openCompose()
+		}
+		return m, nil
+
+	case tea.KeyCtrlR:
+		// Retry the request that failed, using the history as it stands
+		if m.state == StateReady && m.errorBanner != nil && m.errorBanner.Retryable {
+			m.dismissError()
+			return m.openStream()
+		}
+		return m, nil
+
+	case tea.KeyCtrlO:
+		return m.cycleRoutePin()
+
+	case tea.KeyCtrlG:
+		return m.toggleReasoning()
+
+	case tea.KeyCtrlL:
+		return m.toggleToolOutput()
+
+	case tea.KeyCtrlT:
+		// Cycle timestamp display: relative → absolute → hidden
+		switch m.config.UI.Timestamps {
+		case config.TimestampsRelative:
+			m.config.UI.Timestamps = config.TimestampsAbsolute
+		case config.TimestampsAbsolute:
+			m.config.UI.Timestamps = config.TimestampsHidden
+		default:
+			m.config.UI.Timestamps = config.TimestampsRelative
+		}
+		m.updateViewport()
+		return m, nil
 
 	case tea.KeyPgUp:
 		m.viewport.LineUp(5)
@@ -369,23 +866,33 @@ func (m Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case tea.KeyTab:
+		// Run the command suggested after an unknown command or missing path
+		if m.state == StateReady && m.textInput.Value() == "" && m.errorBanner != nil && m.errorBanner.Suggestion != "" {
+			return m.acceptSuggestion()
+		}
 		// Accept autocomplete suggestion
 		if m.state == StateReady && m.autocompleteActive && m.autocompleteSuggestion != "" {
-			m.textInput.SetValue(m.autocompleteSuggestion + " ")
-			m.textInput.SetCursor(len(m.autocompleteSuggestion) + 1)
-			m.updateAutocomplete()
+			m.acceptAutocomplete()
 			return m, nil
 		}
 
 	case tea.KeyUp:
 		// Navigate up in autocomplete list
 		if m.state == StateReady && m.autocompleteActive && len(m.autocompleteMatches) > 0 {
-			m.autocompleteSelectedIndex--
-			if m.autocompleteSelectedIndex < 0 {
-				m.autocompleteSelectedIndex = len(m.autocompleteMatches) - 1
+			index := m.autocompleteSelectedIndex - 1
+			if index < 0 {
+				index = len(m.autocompleteMatches) - 1
 			}
-			m.autocompleteSuggestion = m.autocompleteMatches[m.autocompleteSelectedIndex].Name
-			m.autocompleteCommand = &m.autocompleteMatches[m.autocompleteSelectedIndex]
+			m.selectAutocomplete(index)
+			return m, nil
+		}
+		// Move between the lines of a multi-line prompt
+		if m.state == StateReady && m.textInput.Line() > 0 {
+			m.textInput.CursorUp()
+			return m, nil
+		}
+		// Recall an earlier prompt from an empty input, or keep going back
+		if m.state == StateReady && (m.textInput.Value() == "" || m.historyIndex > 0) && m.recallPrompt(1) {
 			return m, nil
 		}
 		// Otherwise, scroll viewport up
@@ -395,12 +902,19 @@ func (m Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	case tea.KeyDown:
 		// Navigate down in autocomplete list
 		if m.state == StateReady && m.autocompleteActive && len(m.autocompleteMatches) > 0 {
-			m.autocompleteSelectedIndex++
-			if m.autocompleteSelectedIndex >= len(m.autocompleteMatches) {
-				m.autocompleteSelectedIndex = 0
+			index := m.autocompleteSelectedIndex + 1
+			if index >= len(m.autocompleteMatches) {
+				index = 0
 			}
-			m.autocompleteSuggestion = m.autocompleteMatches[m.autocompleteSelectedIndex].Name
-			m.autocompleteCommand = &m.autocompleteMatches[m.autocompleteSelectedIndex]
+			m.selectAutocomplete(index)
+			return m, nil
+		}
+		if m.state == StateReady && m.textInput.Line() < m.textInput.LineCount()-1 {
+			m.textInput.CursorDown()
+			return m, nil
+		}
+		if m.state == StateReady && m.historyIndex > 0 {
+			m.recallPrompt(-1)
 			return m, nil
 		}
 		// Otherwise, scroll viewport down
@@ -408,6 +922,18 @@ func (m Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case tea.KeyEsc:
+		// Stop the response being streamed, or the command being run
+		if m.state == StateStreaming {
+			return m.cancelStream()
+		}
+		if m.state == StateProcessing && m.stopCommand() {
+			return m, nil
+		}
+		// Clear the highlighted search matches
+		if m.searchQuery != "" {
+			m.closeSearch()
+			return m, nil
+		}
 		// Cancel autocomplete
 		if m.state == StateReady && m.autocompleteActive {
 			m.autocompleteActive = false
@@ -417,6 +943,32 @@ func (m Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			m.autocompleteSelectedIndex = 0
 			return m, nil
 		}
+		// Leave edit mode without resubmitting
+		if m.state == StateReady && m.editingPrompt > 0 {
+			m.cancelEdit()
+			return m, nil
+		}
+		// Dismiss the error banner
+		if m.errorBanner != nil {
+			m.dismissError()
+			return m, nil
+		}
+	}
+
+	// Number keys resume a recent session from the welcome screen
+	if m.state == StateReady && m.welcomeSessionsVisible() && m.textInput.Value() == "" &&
+		msg.Type == tea.KeyRunes && len(msg.Runes) == 1 {
+		if n := int(msg.Runes[0] - '1'); n >= 0 && n < len(m.recentSessions) {
+			return m.resumeSession(m.recentSessions[n].ID)
+		}
+	}
+
+	// Large pastes are attached rather than squeezed onto the input line
+	if m.state == StateReady && msg.Paste && m.isLargePaste(string(msg.Runes)) {
+		return m.attachPaste(string(msg.Runes))
+	}
+	if m.state == StateReady && msg.Type == tea.KeyBackspace && m.textInput.Value() == "" && len(m.pastes) > 0 {
+		return m.removeLastPaste()
 	}
 
 	// For all other keys, update the text input if we're in ready state
@@ -444,28 +996,186 @@ func (m Model) handleCommand(input string) (tea.Model, tea.Cmd) {
 			return m, nil
 		}
 		m.textInput.SetValue("")
+		m.recordRecentFile(strings.TrimSpace(parts[1]))
 		return m.handleAddCommand(parts[1])
 
 	case "/clear":
+		// Tips stop rotating once the welcome screen is gone, so restart them
+		tipsStopped := !m.showWelcome || len(m.messages) > 0
 		m.messages = []Message{}
 		m.history.Clear()
 		m.showWelcome = true
+		m.session = nil
+		m.turn = 0
+		m.refreshRecentSessions()
 		m.textInput.SetValue("")
 		m.updateViewport()
+		if tipsStopped {
+			return m, tickTips()
+		}
 		return m, nil
 
 	case "/help":
-		m.addSystemMessage(m.getHelpText())
+		return m.openHelp()
+
+	case "/edit":
+		return m.openEditSelect()
+
+	case "/retry":
+		return m.retryLastPrompt()
+
+	case "/status":
+		m.addSystemMessage(m.getStatusText())
 		m.textInput.SetValue("")
 		m.updateViewport()
 		return m, nil
 
-	case "/status":
-		m.addSystemMessage(m.getStatusText())
+	case "/errors":
+		m.addSystemMessage(m.getErrorsText())
 		m.textInput.SetValue("")
 		m.updateViewport()
 		return m, nil
 
+	case "/map":
+		path := ""
+		if len(parts) > 1 {
+			path = parts[1]
+		}
+		m.textInput.SetValue("")
+		return m.handleMapCommand(path)
+
+	case "/memory":
+		arg := ""
+		if len(parts) > 1 {
+			arg = parts[1]
+		}
+		return m.handleMemoryCommand(arg)
+
+	case "/mode":
+		arg := ""
+		if len(parts) > 1 {
+			arg = parts[1]
+		}
+		return m.handleModeCommand(arg)
+
+	case "/readonly":
+		arg := ""
+		if len(parts) > 1 {
+			arg = parts[1]
+		}
+		return m.handleReadOnlyCommand(arg)
+
+	case "/commit":
+		message := ""
+		if len(parts) > 1 {
+			message = strings.TrimSpace(parts[1])
+		}
+		return m.handleCommitCommand(message)
+
+	case "/resume":
+		query := ""
+		if len(parts) > 1 {
+			query = strings.TrimSpace(parts[1])
+		}
+		return m.handleResumeCommand(query)
+
+	case "/sessions":
+		m.textInput.SetValue("")
+		return m.openSessions()
+
+	case "/share":
+		target := ""
+		if len(parts) > 1 {
+			target = parts[1]
+		}
+		m.textInput.SetValue("")
+		return m.handleShareCommand(target)
+
+	case "/export":
+		args := ""
+		if len(parts) > 1 {
+			args = parts[1]
+		}
+		return m.handleExportCommand(args)
+
+	case "/test":
+		arg := ""
+		if len(parts) > 1 {
+			arg = parts[1]
+		}
+		return m.handleTestCommand(arg)
+
+	case "/todos":
+		path := ""
+		if len(parts) > 1 {
+			path = parts[1]
+		}
+		m.textInput.SetValue("")
+		return m.handleTodosCommand(path)
+
+	case "/undo":
+		arg := ""
+		if len(parts) > 1 {
+			arg = parts[1]
+		}
+		return m.handleUndoCommand(arg)
+
+	case "/writes":
+		filter := ""
+		if len(parts) > 1 {
+			filter = strings.TrimSpace(parts[1])
+		}
+		m.addSystemMessage(m.getWritesText(filter))
+		m.textInput.SetValue("")
+		m.updateViewport()
+		return m, nil
+
+	case "/recipe":
+		arg := ""
+		if len(parts) > 1 {
+			arg = parts[1]
+		}
+		return m.handleRecipeCommand(arg)
+
+	case "/vim":
+		arg := ""
+		if len(parts) > 1 {
+			arg = parts[1]
+		}
+		return m.handleVimCommand(arg)
+
+	case "/redact":
+		arg := ""
+		if len(parts) > 1 {
+			arg = parts[1]
+		}
+		return m.handleRedactCommand(arg)
+
+	case "/context":
+		m.textInput.SetValue("")
+		return m.openContextPanel()
+
+	case "/ask":
+		arg := ""
+		if len(parts) > 1 {
+			arg = parts[1]
+		}
+		return m.handleAskCommand(arg)
+
+	case "/json":
+		arg := ""
+		if len(parts) > 1 {
+			arg = parts[1]
+		}
+		return m.handleJSONCommand(arg)
+
+	case "/compare":
+		arg := ""
+		if len(parts) > 1 {
+			arg = parts[1]
+		}
+		return m.handleCompareCommand(arg)
+
 	case "/config":
 		// Enter config menu
 		m.configMenuActive = true
@@ -473,32 +1183,102 @@ func (m Model) handleCommand(input string) (tea.Model, tea.Cmd) {
 		m.configMenuIndex = 0
 		m.configMenuChanged = false
 		m.originalConfig = *m.config // Save original config for comparison
+		m.originalConfig.Permissions.AutoApproveEdits = m.autoApproveEdits
 		m.initializeConfigOptions()
 		m.textInput.SetValue("")
 		return m, nil
 
 	case "/quit":
-		m.state = StateQuitting
-		return m, tea.Quit
+		return m.quit()
 
 	default:
-		m.addErrorMessage(fmt.Sprintf("Unknown command: %s", command))
 		m.textInput.SetValue("")
+		if suggestion := suggestCommand(input); suggestion != "" {
+			m.showSuggestion(fmt.Sprintf("Unknown command: %s", command), suggestion)
+			return m, nil
+		}
+		m.addErrorMessage(fmt.Sprintf("Unknown command: %s", command))
+		m.updateViewport()
+		return m, nil
+	}
+}
+
+// submitPrompt sends a prompt typed by the user, replacing the prompt being
+// edited (and everything after it) when in edit mode
+func (m Model) submitPrompt(input string) (tea.Model, tea.Cmd) {
+	// Leading !key=value directives override the model or parameters for this turn
+	prompt, overrides, err := parseOverrides(input)
+	if err == nil && prompt == "" {
+		err = fmt.Errorf("nothing to send after the overrides")
+	}
+	if err != nil {
+		m.addErrorMessage(err.Error())
 		m.updateViewport()
 		return m, nil
 	}
+	return m.sendPrompt(prompt, overrides)
+}
+
+// sendPrompt sends a prompt with the given overrides (nil for none) for this turn
+func (m Model) sendPrompt(input string, overrides *api.Overrides) (tea.Model, tea.Cmd) {
+	// An edited prompt replaces the original and everything after it
+	if m.editingPrompt > 0 {
+		if err := m.rewindForEdit(); err != nil {
+			m.addErrorMessage(err.Error())
+			m.updateViewport()
+			return m, nil
+		}
+	}
+
+	m.showWelcome = false
+	m.dismissError()
+	m.sendPastes()
+	m.sendMentions(input)
+	input = m.redactPrompt(input)
+	m.addUserMessage(input)
+	m.textInput.SetValue("")
+	m.turnOverrides = overrides
+	if overrides != nil {
+		m.addSystemMessage(FormatInfo("This turn only: "+describeOverrides(overrides), m.config.UI.EnableEmoji))
+	}
+	if route := m.routeTurn(input); route != "" {
+		m.addSystemMessage(FormatInfo(route, m.config.UI.EnableEmoji))
+	}
+
+	// Force scroll to bottom for new user messages
+	content := m.renderMessages()
+	m.viewport.SetContent(content)
+	m.viewport.GotoBottom()
+
+	// Start processing
+	return m.startConversation(input)
 }
 
 // startConversation starts a new conversation with the API
 func (m Model) startConversation(input string) (tea.Model, tea.Cmd) {
 	// Starting conversation
-	m.state = StateStreaming
+	m.turn++
+	m.toolRounds = 0
+	m.markPrompt(m.history.GetConversationLength())
 	m.history.AddUserMessage(input)
-	m.currentContent = ""
-	m.isReasoning = false
-	m.pendingToolCalls = nil
-	m.accumulatedContent = ""
-	m.hasContent = false
+	return m.openStream()
+}
+
+// openStream streams a response for the current history. It is shared by new
+// messages, tool follow-ups and retries after a failed request.
+func (m Model) openStream() (tea.Model, tea.Cmd) {
+	// Condense the turns that no longer fit before sending the request
+	if cmd := m.summarizeOverflow(); cmd != nil {
+		return m, cmd
+	}
+	return m.sendRequest()
+}
+
+// sendRequest opens the stream for the current history
+func (m Model) sendRequest() (tea.Model, tea.Cmd) {
+	m.state = StateStreaming
+	m.resetStreamState()
+	m.streamStart = len(m.messages)
 
 	// Don't add seeking indicator to messages - it's shown in status area
 
@@ -508,17 +1288,26 @@ func (m Model) startConversation(input string) (tea.Model, tea.Cmd) {
 	m.streamCancel = cancel
 	// Stream context created
 
-	// Get messages from history
-	messages := m.history.GetMessages()
+	// Get messages from history, with files in context that changed re-read
+	if refreshed := m.refreshChangedContext(); len(refreshed) > 0 {
+		paths := make([]string, len(refreshed))
+		for i, path := range refreshed {
+			paths[i] = FormatFilePath(path)
+		}
+		m.addSystemMessage(FormatInfo("Refreshed "+strings.Join(paths, ", ")+" in context (changed on disk)", m.config.UI.EnableEmoji))
+		m.updateViewport()
+	}
+	messages := m.requestMessages()
 	// Retrieved messages from history
 
 	// Create stream
 	// Creating stream
-	eventChan, err := m.apiClient.CreateChatCompletionStream(ctx, messages)
+	eventChan, err := m.provider().CreateChatCompletionStream(ctx, messages)
 	if err != nil {
-		// Failed to create stream
-		m.state = StateError
-		m.addErrorMessage(fmt.Sprintf("Failed to create stream: %v", err))
+		// Failed to create stream; stay ready so the request can be retried
+		cancel()
+		m.state = StateReady
+		m.showError(fmt.Sprintf("Failed to create stream: %v", err), true)
 		return m, nil
 	}
 	// Stream created
@@ -550,18 +1339,43 @@ func (m Model) nextStreamMsg() tea.Cmd {
 				// Stream complete
 				return StreamCompleteMsg{}
 			}
-			// Event received
-			return StreamMsg{Event: event}
+			// Event received; draw it with the deltas right behind it
+			return StreamMsg{Events: m.gatherStreamEvents(event)}
 		}
 	}
 }
 
-// handleStreamEvent handles streaming events
-func (m Model) handleStreamEvent(event api.StreamEvent) (tea.Model, tea.Cmd) {
+// handleStreamEvents applies a batch of stream events and redraws the transcript
+// once. Only the last event of a batch can be something other than a delta.
+func (m Model) handleStreamEvents(events []api.StreamEvent) (tea.Model, tea.Cmd) {
+	deltas := events
+	last := events[len(events)-1]
+	if !isStreamDelta(last) {
+		deltas = events[:len(events)-1]
+	}
+	for _, event := range deltas {
+		m.applyStreamDelta(event)
+	}
+	if len(deltas) > 0 {
+		m.updateStreamingViewport()
+	}
+	if len(deltas) < len(events) {
+		return m.handleStreamEvent(last)
+	}
+	return m, m.nextStreamMsg()
+}
+
+// applyStreamDelta adds reasoning or answer text to the message being streamed,
+// starting a new message when the stream switches from one to the other. The
+// caller redraws the transcript.
+func (m *Model) applyStreamDelta(event api.StreamEvent) {
+	m.streamRetry = nil
+
 	switch event.Type {
 	case api.EventTypeReasoning:
 		if !m.isReasoning {
 			m.isReasoning = true
+			m.reasoningStart = time.Now()
 			// Seeking status is shown in input area, no need to remove from messages
 			m.addReasoningLabel()
 			// Add empty reasoning message immediately after label
@@ -572,12 +1386,16 @@ func (m Model) handleStreamEvent(event api.StreamEvent) (tea.Model, tea.Cmd) {
 			})
 		}
 		m.currentContent += event.ReasoningContent
+		m.accumulatedReasoning += event.ReasoningContent
 		m.updateCurrentMessage()
 
 	case api.EventTypeContent:
 		if m.isReasoning {
-			m.isReasoning = false
+			// Flush the reasoning into its own message before switching roles;
+			// flushing afterwards would write it over the latest content message
+			m.endReasoning()
 			m.finalizeCurrentMessage()
+			m.isReasoning = false
 			m.currentContent = "" // Reset current content after finalizing reasoning
 			m.addAssistantLabel()
 			// Add empty content message immediately after assistant label
@@ -601,24 +1419,43 @@ func (m Model) handleStreamEvent(event api.StreamEvent) (tea.Model, tea.Cmd) {
 		m.accumulatedContent += event.Content
 		m.hasContent = true
 		m.updateCurrentMessage()
+	}
+}
+
+// handleStreamEvent handles the stream events that are not deltas
+func (m Model) handleStreamEvent(event api.StreamEvent) (tea.Model, tea.Cmd) {
+	if event.Type != api.EventTypeRetry {
+		m.streamRetry = nil
+	}
 
+	switch event.Type {
 	case api.EventTypeToolCall:
 		m.pendingToolCalls = event.ToolCalls
+		m.endReasoning()
 		if len(m.currentContent) > 0 {
 			m.finalizeCurrentMessage()
 		}
+		m.updateViewport()
 		// Tool calls will be executed when stream completes
 
 	case api.EventTypeDone:
+		m.endReasoning()
 		m.finalizeCurrentMessage()
-		// Store in history
+		// Store in history, keeping the reasoning and its duration for exports
 		if m.hasContent || len(m.pendingToolCalls) > 0 {
-			m.history.AddAssistantMessage(m.accumulatedContent, m.pendingToolCalls)
+			m.history.AddReasoningAssistantMessage(m.accumulatedContent, m.accumulatedReasoning, m.reasoningDuration, m.pendingToolCalls)
 		}
 		// Update token usage if available
 		if event.Usage != nil {
 			m.history.UpdateTokenUsage(event.Usage.InputTokens, event.Usage.OutputTokens, event.Usage.CachedTokens)
 		}
+		if event.Cached {
+			m.addSystemMessage(FormatInfo("Answered from the response cache (start Riptide with --no-cache for a fresh answer)", m.config.UI.EnableEmoji))
+		}
+		if event.FinishReason == api.FinishReasonLength {
+			m.addSystemMessage(FormatWarning("The answer was cut off at the completion token limit; raise it with !max_tokens=N or ask the model to continue", m.config.UI.EnableEmoji))
+		}
+		m.updateViewport()
 		// Check if we need to execute tools
 		if len(m.pendingToolCalls) > 0 {
 			return m, func() tea.Msg {
@@ -633,37 +1470,50 @@ func (m Model) handleStreamEvent(event api.StreamEvent) (tea.Model, tea.Cmd) {
 		return m, func() tea.Msg {
 			return StreamCompleteMsg{Error: event.Error}
 		}
+
+	case api.EventTypeRetry:
+		// The new attempt streams the answer from the start, so drop what the
+		// broken one showed
+		if event.Retry.Restart {
+			m.messages = m.messages[:min(m.streamStart, len(m.messages))]
+			m.resetStreamState()
+			m.updateViewport()
+		}
+		m.streamRetry = event.Retry
+		m.streamRetryAt = time.Now().Add(event.Retry.Delay)
 	}
 
 	// Continue reading from stream
 	return m, m.nextStreamMsg()
 }
 
-// handleExecuteTools executes the tool calls
+// handleExecuteTools executes the tool calls, reporting progress to the live checklist
 func (m Model) handleExecuteTools(toolCalls []api.ToolCall) (tea.Model, tea.Cmd) {
 	m.state = StateProcessing
+	m.toolStatuses = newToolStatuses(toolCalls)
+	m.resizeViewport()
+	for _, toolCall := range toolCalls {
+		for _, path := range toolCallPaths(toolCall) {
+			m.recordRecentFile(path)
+		}
+	}
+
+	// Use the mode in effect when the batch started for every call in it
+	mode := m.config.Permissions.Mode
+	root := m.workspaceRoot
+	autoEdits := m.autoApproveEdits
+	m.fileOps.SetTurn(m.turn)
 
 	return m, func() tea.Msg {
 		// Execute each tool call
-		for _, toolCall := range toolCalls {
-			// Show function being executed
-			functionName := fmt.Sprintf("→ Executing: %s", toolCall.Function.Name)
-			if m.program != nil {
-				m.program.Send(ProcessCompleteMsg{Result: functionName})
-			}
-
-			// Execute the function
-			result, err := m.fileOps.ExecuteFunction(toolCall)
-			if err != nil {
-				result = fmt.Sprintf("Error: %v", err)
-			}
+		for i, toolCall := range toolCalls {
+			result, progress := m.runToolCall(i, toolCall, mode, root, &autoEdits, m.requestApproval)
 
 			// Add tool response to history
 			m.history.AddToolMessage(toolCall.ID, result)
 
-			// Show result
 			if m.program != nil {
-				m.program.Send(ProcessCompleteMsg{Result: result})
+				m.program.Send(progress)
 			}
 		}
 
@@ -672,6 +1522,100 @@ func (m Model) handleExecuteTools(toolCalls []api.ToolCall) (tea.Model, tea.Cmd)
 	}
 }
 
+// approvalFunc asks whether a tool call that needs approval may run
+type approvalFunc func(index int, toolCall api.ToolCall, danger string, changes []functions.FileChange, outside []string) approvalDecision
+
+// runToolCall checks and executes one tool call, returning the result for the
+// model and its final progress. It is shared by the TUI and headless runs.
+// autoEdits is the batch's copy of the auto-approve setting, turned on when
+// the user approves an edit with "a" so the rest of the batch follows.
+func (m Model) runToolCall(i int, toolCall api.ToolCall, mode, root string, autoEdits *bool, approve approvalFunc) (string, ToolProgressMsg) {
+	progress := ToolProgressMsg{Index: i, State: ToolSucceeded}
+
+	// Check the permission mode, workspace boundary and command rules, asking for approval when required
+	needsApproval, err := checkPermission(mode, toolCall)
+	if needsApproval && commandAllowed(m.commands, toolCall) {
+		needsApproval = false
+	}
+	if needsApproval && api.IsWriteTool(toolCall.Function.Name) && *autoEdits {
+		needsApproval = false
+	}
+	danger, cmdErr := checkCommand(m.commands, toolCall)
+	if err == nil {
+		err = cmdErr
+	}
+	var outside []string
+	if err == nil {
+		outside, err = m.checkWorkspace(root, mode, toolCall)
+	}
+
+	// Work out what a file write would do so it can be approved as a diff, and
+	// so a write never swaps a secret for the placeholder the model saw
+	var changes []functions.FileChange
+	if err == nil && api.IsWriteTool(toolCall.Function.Name) {
+		changes, err = m.fileOps.PreviewWrite(toolCall)
+		if err == nil {
+			err = checkPlaceholders(changes)
+		}
+	}
+	var decision approvalDecision
+	if err == nil && (needsApproval || danger != "" || len(outside) > 0) {
+		if decision = approve(i, toolCall, danger, changes, outside); !decision.Approved {
+			err = fmt.Errorf("%s was denied by the user", toolCall.Function.Name)
+		}
+		if decision.AutoApproveEdits {
+			*autoEdits = true
+		}
+	}
+	if err == nil {
+		err = m.runPreToolHooks(toolCall)
+	}
+
+	var result string
+	if err == nil {
+		if m.program != nil {
+			m.program.Send(ToolProgressMsg{Index: i, State: ToolRunning})
+		}
+		start := time.Now()
+
+		// Execute the function, or write the user's version of its change
+		if decision.Edited != nil {
+			result, err = m.fileOps.ApplyChanges(toolCall.Function.Name, decision.Edited)
+			if err == nil {
+				result += "\n\n" + describeUserEdits(changes, decision.Edited, root)
+			}
+		} else {
+			result, err = m.fileOps.ExecuteFunction(toolCall)
+		}
+		progress.Duration = time.Since(start)
+	}
+	if err == nil {
+		if api.IsWriteTool(toolCall.Function.Name) {
+			if report := m.fileOps.FormatFiles(toolCallPaths(toolCall)); report != "" {
+				result += "\n\n" + report
+			}
+		}
+		result = m.runPostEditHooks(toolCall, result)
+
+		// Scrub secrets before the output reaches the history
+		var redaction safety.Redaction
+		result, redaction = m.redactFrom(result, toolCallPaths(toolCall)...)
+		progress.Redacted = redaction.Count
+
+		// Keep instruction-like text in files from steering the model
+		var reasons []string
+		result, reasons = guardInjection(toolSource(toolCall), result)
+		progress.Quarantined = len(reasons) > 0
+	}
+	if err != nil {
+		result = fmt.Sprintf("Error: %v", err)
+		progress.State = ToolFailed
+		progress.Error = err.Error()
+	}
+	progress.Result = result
+	return result, progress
+}
+
 // Message rendering helpers
 func (m *Model) addUserMessage(content string) {
 	m.messages = append(m.messages, Message{
@@ -702,6 +1646,7 @@ func (m *Model) addSystemMessage(content string) {
 		Role:      "system",
 		Content:   content,
 		Timestamp: time.Now(),
+		Tokens:    conversation.EstimateTokens(content),
 	})
 }
 
@@ -738,7 +1683,9 @@ func (m *Model) updateCurrentMessage() {
 	for i := len(m.messages) - 1; i >= 0; i-- {
 		if m.messages[i].Role == messageRole {
 			m.messages[i].Content = m.currentContent
-			m.updateViewport()
+			if messageRole == "reasoning" {
+				m.messages[i].Tokens = conversation.EstimateTokens(m.currentContent)
+			}
 			return
 		}
 	}
@@ -749,7 +1696,35 @@ func (m *Model) updateCurrentMessage() {
 		Content:   m.currentContent,
 		Timestamp: time.Now(),
 	})
-	m.updateViewport()
+}
+
+// resetStreamState clears per-response streaming state before a new request
+func (m *Model) resetStreamState() {
+	m.currentContent = ""
+	m.isReasoning = false
+	m.pendingToolCalls = nil
+	m.accumulatedContent = ""
+	m.hasContent = false
+	m.accumulatedReasoning = ""
+	m.reasoningStart = time.Time{}
+	m.reasoningDuration = 0
+	m.streamRetry = nil
+	m.answerRender = nil
+}
+
+// endReasoning records how long the reasoning phase took on its label
+func (m *Model) endReasoning() {
+	if m.reasoningStart.IsZero() || m.reasoningDuration > 0 {
+		return
+	}
+	m.reasoningDuration = time.Since(m.reasoningStart)
+
+	for i := len(m.messages) - 1; i >= 0; i-- {
+		if m.messages[i].Role == "reasoning-label" {
+			m.messages[i].Duration = m.reasoningDuration
+			break
+		}
+	}
 }
 
 func (m *Model) finalizeCurrentMessage() {
@@ -760,13 +1735,19 @@ func (m *Model) finalizeCurrentMessage() {
 }
 
 func (m *Model) updateViewport() {
+	// A full render may follow changes anywhere in the transcript
+	m.streamRender = nil
+	m.setViewportContent(m.renderMessages())
+}
+
+// setViewportContent replaces the transcript shown in the viewport
+func (m *Model) setViewportContent(content string) {
 	// Only auto-scroll if we're already at or near the bottom
 	// This preserves the user's scroll position if they've scrolled up
 	atBottom := m.viewport.AtBottom()
 	nearBottom := m.viewport.YOffset >= (m.viewport.TotalLineCount() - m.viewport.Height - 10)
 
 	// Update the content
-	content := m.renderMessages()
 	m.viewport.SetContent(content)
 
 	// Only scroll to bottom if we were already there or near there
@@ -775,17 +1756,48 @@ func (m *Model) updateViewport() {
 	}
 }
 
+// quit cancels any in-flight stream and exits the program
+func (m Model) quit() (tea.Model, tea.Cmd) {
+	if m.streamCancel != nil {
+		m.streamCancel()
+	}
+	m.confirmingQuit = false
+	m.state = StateQuitting
+	return m, tea.Quit
+}
+
+// Shutdown releases resources after the program exits. It cancels the stream and
+// waits for any file write in progress, so no partially written file is left behind,
+// then runs the session_end hooks.
+func (m Model) Shutdown() {
+	if m.streamCancel != nil {
+		m.streamCancel()
+	}
+	m.fileOps.Cleanup()
+
+	if err := m.hooks.Run(m.hookEvent(hooks.SessionEnd)); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+}
+
 // SetProgram sets the tea.Program reference for streaming
 func (m *Model) SetProgram(p *tea.Program) {
 	m.program = p
 }
 
+// SetProvider replaces the API client used for chat completions
+func (m *Model) SetProvider(p api.Provider) {
+	m.apiClient = p
+}
+
 // updateAutocomplete updates the autocomplete suggestion based on current input
 func (m *Model) updateAutocomplete() {
 	currentValue := m.textInput.Value()
+	prefix, partial, completingPath := pathCompletionTarget(currentValue)
+	m.autocompletePrefix = ""
 
-	// Reset autocomplete if input is empty or doesn't start with /
-	if currentValue == "" || !strings.HasPrefix(currentValue, "/") {
+	// Reset autocomplete if input is empty, or neither a command nor a path is being typed
+	if currentValue == "" || (!completingPath && !strings.HasPrefix(currentValue, "/")) {
 		m.autocompleteActive = false
 		m.autocompleteSuggestion = ""
 		m.autocompleteCommand = nil
@@ -794,13 +1806,17 @@ func (m *Model) updateAutocomplete() {
 		return
 	}
 
-	// Find all matching commands
-	lowerInput := strings.ToLower(currentValue)
+	// Find all matching commands, or the paths completing the one being typed
 	m.autocompleteMatches = []Command{}
-
-	for _, cmd := range availableCommands {
-		if strings.HasPrefix(cmd.Name, lowerInput) && cmd.Name != lowerInput {
-			m.autocompleteMatches = append(m.autocompleteMatches, cmd)
+	if completingPath {
+		m.autocompletePrefix = prefix
+		m.autocompleteMatches = m.completePath(partial)
+	} else {
+		lowerInput := strings.ToLower(currentValue)
+		for _, cmd := range availableCommands {
+			if strings.HasPrefix(cmd.Name, lowerInput) && cmd.Name != lowerInput {
+				m.autocompleteMatches = append(m.autocompleteMatches, cmd)
+			}
 		}
 	}
 
@@ -812,8 +1828,7 @@ func (m *Model) updateAutocomplete() {
 		if m.autocompleteSelectedIndex >= len(m.autocompleteMatches) {
 			m.autocompleteSelectedIndex = 0
 		}
-		m.autocompleteSuggestion = m.autocompleteMatches[m.autocompleteSelectedIndex].Name
-		m.autocompleteCommand = &m.autocompleteMatches[m.autocompleteSelectedIndex]
+		m.selectAutocomplete(m.autocompleteSelectedIndex)
 	} else {
 		// No matches found
 		m.autocompleteActive = false
@@ -821,16 +1836,46 @@ func (m *Model) updateAutocomplete() {
 		m.autocompleteCommand = nil
 		m.autocompleteSelectedIndex = 0
 	}
-	
+
 	// If autocomplete state changed, update viewport height
-	if wasActive != m.autocompleteActive && m.height > 0 {
-		footerHeight := 10
-		if m.autocompleteActive && len(m.autocompleteMatches) > 0 {
-			// Add lines for dropdown + hint
-			footerHeight += min(len(m.autocompleteMatches), 5) + 2
-		}
-		m.viewport.Height = m.height - footerHeight
+	if wasActive != m.autocompleteActive {
+		m.resizeViewport()
+	}
+}
+
+// resizeViewport recalculates the viewport height from what the footer currently shows
+func (m *Model) resizeViewport() {
+	if m.height == 0 {
+		return
+	}
+
+	// Calculate dynamic footer height
+	// Header: 2 lines (title + spacing)
+	// Status line: 1 line
+	// Input box: 3 lines (border + content + status)
+	// Status text below input: 1 line
+	// Autocomplete dropdown: variable (up to 5 lines)
+	// Tool checklist: 1 line while tools execute
+	// Command output: a header and 8 lines while run_command runs
+	// Error banner: 2 lines (message + actions) until dismissed
+	// Attached pastes: 1 line until sent
+	// Extra padding: 3 lines for safety
+	footerHeight := 10
+	if m.autocompleteActive && len(m.autocompleteMatches) > 0 {
+		// Add lines for dropdown + hint
+		footerHeight += min(len(m.autocompleteMatches), 5) + 2
+	}
+	if len(m.toolStatuses) > 0 {
+		footerHeight++
+	}
+	footerHeight += m.commandPanelHeight()
+	if m.errorBanner != nil {
+		footerHeight += 2
+	}
+	if len(m.pastes) > 0 {
+		footerHeight++
 	}
+	m.viewport.Height = m.height - footerHeight
 }
 
 // min returns the minimum of two integers
@@ -0,0 +1,127 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/alchemy-labs-co/riptide/internal/api"
+)
+
+// askUsage is shown when /ask is given bad arguments
+const askUsage = "Usage: /ask [--model NAME] [--temp T] [--max-tokens N] <prompt>"
+
+// setOverride applies one model or parameter override by name
+func setOverride(overrides *api.Overrides, key, value string) error {
+	switch strings.ToLower(key) {
+	case "model":
+		if value == "" {
+			return fmt.Errorf("model needs a name")
+		}
+		overrides.Model = value
+	case "temp", "temperature":
+		t, err := strconv.ParseFloat(value, 32)
+		if err != nil || t < 0 || t > 2 {
+			return fmt.Errorf("temperature must be a number from 0 to 2, got %q", value)
+		}
+		temperature := float32(t)
+		overrides.Temperature = &temperature
+	case "max_tokens", "max-tokens":
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			return fmt.Errorf("max_tokens must be a positive number, got %q", value)
+		}
+		overrides.MaxTokens = n
+	default:
+		return fmt.Errorf("unknown override %q (use model, temp or max_tokens)", key)
+	}
+	return nil
+}
+
+// parseOverrides strips leading !key=value directives (such as
+// "!model=deepseek-chat !temp=0.2") from a prompt and returns the prompt and
+// the overrides they set, or nil overrides when there are none. Only leading
+// directives count, so a "!" later in the prompt is left alone.
+func parseOverrides(input string) (string, *api.Overrides, error) {
+	var overrides *api.Overrides
+	rest := strings.TrimSpace(input)
+	for strings.HasPrefix(rest, "!") {
+		token, remainder, _ := strings.Cut(rest, " ")
+		if i := strings.IndexAny(token, "\t\n"); i >= 0 {
+			token, remainder = token[:i], token[i:]+" "+remainder
+		}
+		key, value, ok := strings.Cut(strings.TrimPrefix(token, "!"), "=")
+		if !ok {
+			break // Plain text such as "!important"
+		}
+		if overrides == nil {
+			overrides = &api.Overrides{}
+		}
+		if err := setOverride(overrides, key, value); err != nil {
+			return "", nil, err
+		}
+		rest = strings.TrimSpace(remainder)
+	}
+	return rest, overrides, nil
+}
+
+// describeOverrides lists the overrides for the transcript annotation
+func describeOverrides(overrides *api.Overrides) string {
+	var parts []string
+	if overrides.Model != "" {
+		parts = append(parts, "model="+overrides.Model)
+	}
+	if overrides.Temperature != nil {
+		parts = append(parts, "temperature="+strconv.FormatFloat(float64(*overrides.Temperature), 'g', -1, 32))
+	}
+	if overrides.MaxTokens > 0 {
+		parts = append(parts, "max_tokens="+strconv.Itoa(overrides.MaxTokens))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// provider returns the API client for the current turn, with its overrides applied
+func (m Model) provider() api.Provider {
+	if m.turnOverrides == nil {
+		return m.apiClient
+	}
+	if provider, ok := m.apiClient.(api.OverridableProvider); ok {
+		return provider.WithOverrides(*m.turnOverrides)
+	}
+	return m.apiClient
+}
+
+// handleAskCommand sends a prompt with --model, --temp and --max-tokens
+// overrides for that turn only
+func (m Model) handleAskCommand(arg string) (tea.Model, tea.Cmd) {
+	overrides := &api.Overrides{}
+	rest := strings.TrimSpace(arg)
+	for strings.HasPrefix(rest, "--") {
+		var flag string
+		flag, rest, _ = strings.Cut(rest, " ")
+		rest = strings.TrimSpace(rest)
+
+		key, value, hasValue := strings.Cut(strings.TrimPrefix(flag, "--"), "=")
+		if !hasValue {
+			value, rest, _ = strings.Cut(rest, " ")
+			rest = strings.TrimSpace(rest)
+		}
+		if err := setOverride(overrides, key, value); err != nil {
+			m.addErrorMessage(fmt.Sprintf("%v\n%s", err, askUsage))
+			m.updateViewport()
+			return m, nil
+		}
+	}
+
+	if rest == "" {
+		m.addErrorMessage(askUsage)
+		m.updateViewport()
+		return m, nil
+	}
+	if *overrides == (api.Overrides{}) {
+		overrides = nil
+	}
+	return m.sendPrompt(rest, overrides)
+}
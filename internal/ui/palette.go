@@ -0,0 +1,267 @@
+package ui
+
+import (
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// maxRecentFiles caps how many recently used files the palette remembers
+const maxRecentFiles = 20
+
+// paletteVisibleItems is how many matches the palette lists at once
+const paletteVisibleItems = 12
+
+// PaletteItem is a selectable entry in the command palette
+type PaletteItem struct {
+	Kind   string // "command", "file" or "session"
+	Label  string
+	Detail string
+	Value  string // Session ID for session entries
+}
+
+// openPalette shows the command palette with an empty query
+func (m Model) openPalette() (tea.Model, tea.Cmd) {
+	m.paletteActive = true
+	m.paletteQuery = ""
+	m.paletteIndex = 0
+	m.paletteMatches = m.filterPalette("")
+	return m, nil
+}
+
+// handlePaletteKeyPress filters and selects palette entries
+func (m Model) handlePaletteKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc, tea.KeyCtrlK, tea.KeyCtrlC:
+		m.paletteActive = false
+		return m, nil
+
+	case tea.KeyUp, tea.KeyCtrlP:
+		if len(m.paletteMatches) > 0 {
+			m.paletteIndex = (m.paletteIndex - 1 + len(m.paletteMatches)) % len(m.paletteMatches)
+		}
+		return m, nil
+
+	case tea.KeyDown, tea.KeyCtrlN, tea.KeyTab:
+		if len(m.paletteMatches) > 0 {
+			m.paletteIndex = (m.paletteIndex + 1) % len(m.paletteMatches)
+		}
+		return m, nil
+
+	case tea.KeyEnter:
+		m.paletteActive = false
+		if m.paletteIndex < len(m.paletteMatches) {
+			return m.selectPaletteItem(m.paletteMatches[m.paletteIndex])
+		}
+		return m, nil
+
+	case tea.KeyBackspace:
+		if len(m.paletteQuery) > 0 {
+			runes := []rune(m.paletteQuery)
+			m.paletteQuery = string(runes[:len(runes)-1])
+		}
+
+	case tea.KeySpace:
+		m.paletteQuery += " "
+
+	case tea.KeyRunes:
+		m.paletteQuery += string(msg.Runes)
+
+	default:
+		return m, nil
+	}
+
+	m.paletteMatches = m.filterPalette(m.paletteQuery)
+	m.paletteIndex = 0
+	return m, nil
+}
+
+// selectPaletteItem runs a command or prepares the input for the chosen entry
+func (m Model) selectPaletteItem(item PaletteItem) (tea.Model, tea.Cmd) {
+	switch item.Kind {
+	case "command":
+		for _, cmd := range availableCommands {
+			if cmd.Name == item.Label && cmd.Usage == cmd.Name {
+				// Commands without arguments run immediately
+				return m.handleCommand(cmd.Name)
+			}
+		}
+		m.textInput.SetValue(item.Label + " ")
+	case "file":
+		m.textInput.SetValue("/add " + item.Label)
+	case "session":
+		return m.resumeSession(item.Value)
+	}
+
+	m.textInput.CursorEnd()
+	m.updateAutocomplete()
+	return m, nil
+}
+
+// paletteItems lists everything the palette can search
+func (m Model) paletteItems() []PaletteItem {
+	items := make([]PaletteItem, 0, len(availableCommands)+len(m.recentFiles)+len(m.recentSessions))
+	for _, cmd := range availableCommands {
+		items = append(items, PaletteItem{Kind: "command", Label: cmd.Name, Detail: cmd.Description})
+	}
+	for i := len(m.recentFiles) - 1; i >= 0; i-- {
+		items = append(items, PaletteItem{Kind: "file", Label: m.recentFiles[i], Detail: "recent file"})
+	}
+	now := time.Now()
+	for _, summary := range m.recentSessions {
+		items = append(items, PaletteItem{
+			Kind:   "session",
+			Label:  summary.Label(),
+			Detail: "session, " + formatRelativeTime(summary.UpdatedAt, now),
+			Value:  summary.ID,
+		})
+	}
+	return items
+}
+
+// filterPalette returns the palette items matching the query, best match first
+func (m Model) filterPalette(query string) []PaletteItem {
+	items := m.paletteItems()
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return items
+	}
+
+	type scored struct {
+		item  PaletteItem
+		score int
+	}
+	var matches []scored
+	for _, item := range items {
+		if score, ok := fuzzyScore(query, item.Label); ok {
+			matches = append(matches, scored{item: item, score: score})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].score > matches[j].score
+	})
+
+	result := make([]PaletteItem, len(matches))
+	for i, match := range matches {
+		result[i] = match.item
+	}
+	return result
+}
+
+// fuzzyScore reports whether every rune of query appears in target in order,
+// scoring consecutive runs and matches at word boundaries higher
+func fuzzyScore(query, target string) (int, bool) {
+	q := []rune(strings.ToLower(query))
+	t := []rune(strings.ToLower(target))
+
+	score := 0
+	qi := 0
+	prevMatch := -2
+	for ti := 0; ti < len(t) && qi < len(q); ti++ {
+		if t[ti] != q[qi] {
+			continue
+		}
+
+		score++
+		if ti == prevMatch+1 {
+			score += 3
+		}
+		if ti == 0 || !unicode.IsLetter(t[ti-1]) && !unicode.IsDigit(t[ti-1]) {
+			score += 2
+		}
+		prevMatch = ti
+		qi++
+	}
+
+	if qi < len(q) {
+		return 0, false
+	}
+
+	// Prefer shorter targets when the match quality is otherwise equal
+	return score*10 - len(t), true
+}
+
+// recordRecentFile remembers a file path for the command palette
+func (m *Model) recordRecentFile(path string) {
+	if path == "" {
+		return
+	}
+	for i, existing := range m.recentFiles {
+		if existing == path {
+			m.recentFiles = append(m.recentFiles[:i], m.recentFiles[i+1:]...)
+			break
+		}
+	}
+	m.recentFiles = append(m.recentFiles, path)
+	if len(m.recentFiles) > maxRecentFiles {
+		m.recentFiles = m.recentFiles[len(m.recentFiles)-maxRecentFiles:]
+	}
+}
+
+// renderPalette renders the command palette overlay
+func (m Model) renderPalette() string {
+	menuStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(SecondaryColor).
+		Padding(1, 2).
+		Width(m.width - 4).
+		Height(m.height - 4)
+
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(SecondaryColor)
+
+	var content strings.Builder
+	content.WriteString(titleStyle.Render("Command Palette"))
+	content.WriteString("\n\n")
+
+	// Query line with cursor
+	cursor := lipgloss.NewStyle().Background(WhiteColor).Foreground(CursorTextColor).Render(" ")
+	prompt := lipgloss.NewStyle().Foreground(SecondaryColor).Render("▶ ")
+	if m.paletteQuery == "" {
+		content.WriteString(prompt + cursor + HelpStyle.Render("Search commands, files and sessions..."))
+	} else {
+		content.WriteString(prompt + m.paletteQuery + cursor)
+	}
+	content.WriteString("\n\n")
+
+	if len(m.paletteMatches) == 0 {
+		content.WriteString(HelpStyle.Render("  No matches"))
+	}
+
+	// Scroll the list so the selection stays visible
+	start := 0
+	if m.paletteIndex >= paletteVisibleItems {
+		start = m.paletteIndex - paletteVisibleItems + 1
+	}
+	end := min(start+paletteVisibleItems, len(m.paletteMatches))
+
+	for i := start; i < end; i++ {
+		item := m.paletteMatches[i]
+
+		var line string
+		if i == m.paletteIndex {
+			line += lipgloss.NewStyle().Foreground(AccentColor).Render("▶ ")
+		} else {
+			line += "  "
+		}
+
+		labelStyle := lipgloss.NewStyle().Width(30)
+		if i == m.paletteIndex {
+			labelStyle = labelStyle.Bold(true).Foreground(AccentColor)
+		}
+		line += labelStyle.Render(truncate(item.Label, 29))
+		line += HelpStyle.Render(item.Detail)
+
+		content.WriteString(line + "\n")
+	}
+
+	footer := "\n" + HelpStyle.Render("Type to filter • ↑/↓ to select • Enter to run • Esc to close")
+
+	return menuStyle.Render(content.String() + footer)
+}
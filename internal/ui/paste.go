@@ -0,0 +1,110 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/alchemy-labs-co/riptide/internal/conversation"
+)
+
+// pastedText is a large paste attached to the next prompt instead of typed into the input
+type pastedText struct {
+	Label string // e.g. "[pasted 412 lines]", also its name in the context
+	Text  string
+}
+
+// isLargePaste reports whether a paste is over either configured threshold
+func (m Model) isLargePaste(text string) bool {
+	lines := strings.Count(strings.TrimRight(text, "\n"), "\n") + 1
+	if limit := m.config.UI.PasteLines; limit > 0 && lines > limit {
+		return true
+	}
+	if limit := m.config.UI.PasteChars; limit > 0 && utf8.RuneCountInString(text) > limit {
+		return true
+	}
+	return false
+}
+
+// attachPaste keeps a large paste out of the input, to be sent as context with
+// the next prompt
+func (m Model) attachPaste(text string) (tea.Model, tea.Cmd) {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	lines := strings.Count(strings.TrimRight(text, "\n"), "\n") + 1
+	label := fmt.Sprintf("[pasted %d lines]", lines)
+	if lines == 1 {
+		label = fmt.Sprintf("[pasted %d characters]", utf8.RuneCountInString(text))
+	}
+
+	// Labels name the pastes in /context, so each needs its own
+	base := label
+	for n := 2; m.pasteLabelUsed(label); n++ {
+		label = fmt.Sprintf("%s #%d]", strings.TrimSuffix(base, "]"), n)
+	}
+
+	m.pastes = append(m.pastes, pastedText{Label: label, Text: text})
+	m.resizeViewport()
+	return m, nil
+}
+
+// pasteLabelUsed reports whether a label is taken by a waiting paste or a context item
+func (m Model) pasteLabelUsed(label string) bool {
+	for _, paste := range m.pastes {
+		if paste.Label == label {
+			return true
+		}
+	}
+	return m.history.FileAlreadyInContext(label)
+}
+
+// removeLastPaste drops the most recently attached paste before it is sent
+func (m Model) removeLastPaste() (tea.Model, tea.Cmd) {
+	m.pastes = m.pastes[:len(m.pastes)-1]
+	m.resizeViewport()
+	return m, nil
+}
+
+// pasteLabels is the prompt sent when only pastes were attached
+func (m Model) pasteLabels() string {
+	labels := make([]string, len(m.pastes))
+	for i, paste := range m.pastes {
+		labels[i] = paste.Label
+	}
+	return strings.Join(labels, " ")
+}
+
+// sendPastes adds the attached pastes to the context ahead of the prompt, scrubbed
+// like files added with /add
+func (m *Model) sendPastes() {
+	enableEmoji := m.config.UI.EnableEmoji
+	for _, paste := range m.pastes {
+		content, redaction := m.redact(paste.Text)
+		content, reasons := guardInjection(paste.Label, content)
+		content = fmt.Sprintf("Text pasted by the user %s:\n\n%s", paste.Label, content)
+		m.history.AddFileMessage(paste.Label, content)
+
+		result := FormatSuccess(fmt.Sprintf("Attached %s (~%s tokens)", paste.Label, formatTokenCount(conversation.EstimateTokens(content))), enableEmoji)
+		if redaction.Count > 0 {
+			result += "\n" + FormatWarning("Redacted "+redaction.String()+" — use /redact off to send as-is", enableEmoji)
+		}
+		if len(reasons) > 0 {
+			result += "\n" + FormatWarning("Quarantined instruction-like text ("+strings.Join(reasons, ", ")+"); the model is told to treat it as data", enableEmoji)
+		}
+		m.addSystemMessage(result)
+	}
+	m.pastes = nil
+	m.resizeViewport()
+}
+
+// renderPastes lists the pastes waiting to be sent with the next prompt
+func (m Model) renderPastes() string {
+	labels := make([]string, len(m.pastes))
+	for i, paste := range m.pastes {
+		labels[i] = lipgloss.NewStyle().Foreground(SecondaryColor).Render(paste.Label)
+	}
+	return "  " + GetIcon("file", m.config.UI.EnableEmoji) + " " + strings.Join(labels, " ") +
+		HelpStyle.Render(" • sent with your next message • Backspace on an empty prompt removes the last")
+}
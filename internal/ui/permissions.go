@@ -0,0 +1,353 @@
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/alchemy-labs-co/riptide/internal/api"
+	"github.com/alchemy-labs-co/riptide/internal/config"
+	"github.com/alchemy-labs-co/riptide/internal/functions"
+	"github.com/alchemy-labs-co/riptide/internal/safety"
+)
+
+// ApprovalRequestMsg asks the user to approve a tool call before it runs.
+// The tool goroutine blocks until a decision is sent on Reply.
+type ApprovalRequestMsg struct {
+	Index    int
+	ToolCall api.ToolCall
+	Danger   string                 // Dangerous command rule that matched; needs a second confirmation
+	Changes  []functions.FileChange // What a file write would do, shown as a diff
+	Outside  []string               // Absolute paths outside the workspace the call would touch
+	Reply    chan approvalDecision
+
+	// The user's own version of the change, written instead of the tool's when approved
+	edited        []functions.FileChange
+	confirmedOnce bool
+}
+
+// approvalDecision is the answer to an approval prompt. Edited holds the file
+// contents the user changed before accepting, to be written instead of the tool's.
+type approvalDecision struct {
+	Approved         bool
+	Edited           []functions.FileChange
+	AutoApproveEdits bool // Approve the edits after this one without asking
+}
+
+// permissionModes lists the valid /mode arguments in order of increasing autonomy
+var permissionModes = []string{config.ModeReadOnly, config.ModeEdit, config.ModeAuto}
+
+// readOnlyReminder is sent with each request in read-only mode, so the model
+// proposes changes instead of trying tools it no longer has
+const readOnlyReminder = "Read-only mode: you can read and search the project but not change files or run commands. " +
+	"Propose changes as unified diffs in your answer for the user to review and apply."
+
+// checkPermission decides whether a tool call may run in the given mode, and whether
+// the user must approve it first
+func checkPermission(mode string, toolCall api.ToolCall) (needsApproval bool, err error) {
+	if mode == config.ModeReadOnly && !api.IsReadOnlyTool(toolCall.Function.Name) {
+		return false, fmt.Errorf("%s is not allowed in read-only mode", toolCall.Function.Name)
+	}
+	if !api.IsWriteTool(toolCall.Function.Name) && !api.IsExecTool(toolCall.Function.Name) {
+		return false, nil
+	}
+
+	switch mode {
+	case config.ModeAuto:
+		return false, nil
+	default:
+		return true, nil
+	}
+}
+
+// checkCommand classifies the shell command a tool call would run. Blocked commands
+// return an error; commands needing double confirmation return the matching rule.
+func checkCommand(classifier *safety.CommandClassifier, toolCall api.ToolCall) (danger string, err error) {
+	command := toolCallCommand(toolCall)
+	if command == "" || classifier == nil {
+		return "", nil
+	}
+
+	classification := classifier.Classify(command)
+	switch classification.Risk {
+	case safety.RiskBlocked:
+		return "", fmt.Errorf("command blocked by rule %q: %s", classification.Rule, classification.Segment)
+	case safety.RiskConfirm:
+		return classification.Rule, nil
+	}
+	return "", nil
+}
+
+// commandAllowed reports whether a run_command call is on the configured
+// allowlist, so it runs without asking
+func commandAllowed(classifier *safety.CommandClassifier, toolCall api.ToolCall) bool {
+	if toolCall.Function.Name != "run_command" || classifier == nil {
+		return false
+	}
+	return classifier.Allowed(toolCallCommand(toolCall))
+}
+
+// checkWorkspace returns the absolute paths a tool call would touch outside
+// the workspace root and the directories allowed by
+// file_operations.allowed_paths, which the user must approve whatever the
+// permission mode. In read-only mode, or with file_operations.block_outside
+// set, such a call is refused instead.
+func (m Model) checkWorkspace(root, mode string, toolCall api.ToolCall) ([]string, error) {
+	if root == "" {
+		return nil, nil
+	}
+	roots := append([]string{root}, m.allowedPaths(root)...)
+
+	var outside []string
+	for _, path := range append(toolCallPaths(toolCall), toolCallInputPaths(toolCall)...) {
+		absPath, err := functions.NormalizePath(path)
+		if err != nil {
+			// Not every tool normalizes its paths, so one that cannot be
+			// checked is refused here
+			return nil, fmt.Errorf("invalid path %q: %w", path, err)
+		}
+		if !slices.ContainsFunc(roots, func(root string) bool { return functions.IsWithinRoot(root, absPath) }) {
+			outside = append(outside, absPath)
+		}
+	}
+	if len(outside) > 0 && (mode == config.ModeReadOnly || m.config.FileOperations.BlockOutside) {
+		return nil, fmt.Errorf("%s is outside the workspace %s; add it to allowed_paths under file_operations to allow it", strings.Join(outside, ", "), root)
+	}
+	return outside, nil
+}
+
+// allowedPaths resolves the configured allowed_paths: ~/ is the home directory
+// and relative paths start from the workspace root
+func (m Model) allowedPaths(root string) []string {
+	paths := make([]string, len(m.config.FileOperations.AllowedPaths))
+	for i, path := range m.config.FileOperations.AllowedPaths {
+		if path = expandHome(path); !filepath.IsAbs(path) {
+			path = filepath.Join(root, path)
+		}
+		paths[i] = path
+	}
+	return paths
+}
+
+// expandHome replaces a leading ~ in a configured path with the home directory
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[1:])
+}
+
+// requestApproval asks the UI to approve a tool call and waits for the answer.
+// It runs on the tool goroutine; without a program or once the request is
+// canceled, the call is denied.
+func (m Model) requestApproval(index int, toolCall api.ToolCall, danger string, changes []functions.FileChange, outside []string) approvalDecision {
+	if m.program == nil {
+		return approvalDecision{}
+	}
+
+	reply := make(chan approvalDecision, 1)
+	m.program.Send(ApprovalRequestMsg{Index: index, ToolCall: toolCall, Danger: danger, Changes: changes, Outside: outside, Reply: reply})
+
+	select {
+	case decision := <-reply:
+		return decision
+	case <-m.streamCtx.Done():
+		return approvalDecision{}
+	}
+}
+
+// handleApprovalKeyPress answers the pending approval prompt
+func (m Model) handleApprovalKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "y", "Y":
+		// Dangerous commands need a second, explicit yes
+		if m.pendingApproval.Danger != "" && !m.pendingApproval.confirmedOnce {
+			m.pendingApproval.confirmedOnce = true
+			return m, nil
+		}
+		m.answerApproval(true)
+	case "a", "A":
+		// Trust the rest of this session's edits
+		if len(m.pendingApproval.Changes) > 0 {
+			m.autoApproveEdits = true
+			m.addSystemMessage(FormatInfo("Auto-approving file edits for this session; turn it off in /config", m.config.UI.EnableEmoji))
+			m.updateViewport()
+			m.answerApproval(true)
+		}
+	case "e", "E":
+		if len(m.pendingApproval.Changes) == 1 {
+			return m, m.editProposedChange()
+		}
+	case "n", "N", "esc":
+		m.answerApproval(false)
+	case "ctrl+c":
+		m.answerApproval(false)
+		return m.quit()
+	}
+	return m, nil
+}
+
+// answerApproval sends the decision back to the waiting tool goroutine
+func (m *Model) answerApproval(approved bool) {
+	if m.pendingApproval == nil {
+		return
+	}
+	decision := approvalDecision{Approved: approved}
+	if approved {
+		decision.Edited = m.pendingApproval.edited
+		decision.AutoApproveEdits = m.autoApproveEdits
+	}
+	m.pendingApproval.Reply <- decision
+	m.pendingApproval = nil
+}
+
+// renderApprovalPrompt renders the question shown in the input area while a tool waits
+func (m Model) renderApprovalPrompt() string {
+	req := m.pendingApproval
+
+	if req.Danger != "" {
+		command := truncate(toolCallCommand(req.ToolCall), 60)
+		if req.confirmedOnce {
+			return ErrorStyle.Render(fmt.Sprintf("Really run `%s`? This may not be reversible", command)) + " " + HelpStyle.Render("(y/n)")
+		}
+		return ErrorStyle.Render(fmt.Sprintf("Dangerous command (%s): `%s` — run it?", req.Danger, command)) + " " + HelpStyle.Render("(y/n, asks twice)")
+	}
+
+	// Name every outside path in full, so the user sees exactly what is reached
+	if len(req.Outside) > 0 {
+		question := fmt.Sprintf("%s reaches outside the workspace: %s — allow?", req.ToolCall.Function.Name, strings.Join(req.Outside, ", "))
+		return ErrorStyle.Render(question) + " " + HelpStyle.Render("(y/n)")
+	}
+
+	// Show the whole command, since approving it is approving everything it does
+	if req.ToolCall.Function.Name == "run_command" {
+		command := strings.ReplaceAll(strings.TrimSpace(toolCallCommand(req.ToolCall)), "\n", " ↵ ")
+		question := fmt.Sprintf("Run `%s`?", truncate(command, max(m.width-20, 40)))
+		return WarningStyle.Render(question) + " " + HelpStyle.Render("(y/n)")
+	}
+
+	if len(req.Changes) > 0 {
+		return m.renderChangeQuestion(req)
+	}
+
+	if req.ToolCall.Function.Name == "git_commit" {
+		var args api.FileOperationArgs
+		json.Unmarshal([]byte(req.ToolCall.Function.Arguments), &args)
+		subject, _, _ := strings.Cut(strings.TrimSpace(args.Message), "\n")
+		question := fmt.Sprintf("Commit %q?", truncate(subject, max(m.width-40, 30)))
+		if len(args.FilePaths) > 0 {
+			question = fmt.Sprintf("Stage %s and commit %q?", strings.Join(args.FilePaths, ", "), truncate(subject, max(m.width-60, 30)))
+		}
+		return WarningStyle.Render(question) + " " + HelpStyle.Render("(y/n)")
+	}
+
+	target := strings.Join(toolCallPaths(req.ToolCall), ", ")
+	if target == "" {
+		target = summarizeToolCall(req.ToolCall)
+	}
+
+	question := fmt.Sprintf("Allow %s %s?", req.ToolCall.Function.Name, target)
+	return WarningStyle.Render(question) + " " + HelpStyle.Render("(y/n)")
+}
+
+// handleModeCommand shows or changes the permission mode for this session
+func (m Model) handleModeCommand(arg string) (tea.Model, tea.Cmd) {
+	arg = strings.ToLower(strings.TrimSpace(arg))
+	m.textInput.SetValue("")
+
+	if arg == "" {
+		m.addSystemMessage(fmt.Sprintf("Permission mode: %s\n  %s", m.config.Permissions.Mode, describeMode(m.config.Permissions.Mode)))
+		m.updateViewport()
+		return m, nil
+	}
+
+	valid := false
+	for _, mode := range permissionModes {
+		if arg == mode {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		m.addErrorMessage(fmt.Sprintf("Unknown mode: %s (use %s)", arg, strings.Join(permissionModes, ", ")))
+		m.updateViewport()
+		return m, nil
+	}
+
+	m.config.Permissions.Mode = arg
+	m.addSystemMessage(fmt.Sprintf("⎿  Permission mode set to %s: %s", arg, describeMode(arg)))
+	m.updateViewport()
+	return m, nil
+}
+
+// handleReadOnlyCommand switches read-only mode on or off for this session, or
+// toggles it without an argument. Switching it off restores the mode in use
+// before it was switched on.
+func (m Model) handleReadOnlyCommand(arg string) (tea.Model, tea.Cmd) {
+	m.textInput.SetValue("")
+
+	readOnly := m.config.Permissions.Mode == config.ModeReadOnly
+	switch strings.ToLower(strings.TrimSpace(arg)) {
+	case "":
+		readOnly = !readOnly
+	case "on":
+		readOnly = true
+	case "off":
+		readOnly = false
+	default:
+		m.addErrorMessage("Usage: /readonly [on|off]")
+		m.updateViewport()
+		return m, nil
+	}
+
+	switch {
+	case readOnly && m.config.Permissions.Mode != config.ModeReadOnly:
+		m.modeBeforeReadOnly = m.config.Permissions.Mode
+		m.config.Permissions.Mode = config.ModeReadOnly
+	case !readOnly && m.config.Permissions.Mode == config.ModeReadOnly:
+		m.config.Permissions.Mode = m.modeBeforeReadOnly
+		if m.config.Permissions.Mode == "" {
+			m.config.Permissions.Mode = config.ModeEdit
+		}
+	}
+
+	if readOnly {
+		m.addSystemMessage("⎿  Read-only mode on: the model can read the project and propose diffs, but not change files or run commands")
+	} else {
+		m.addSystemMessage(fmt.Sprintf("⎿  Read-only mode off; permission mode is %s: %s", m.config.Permissions.Mode, describeMode(m.config.Permissions.Mode)))
+	}
+	m.updateViewport()
+	return m, nil
+}
+
+// describeMode explains what a permission mode allows
+func describeMode(mode string) string {
+	switch mode {
+	case config.ModeReadOnly:
+		return "the model can only read files"
+	case config.ModeAuto:
+		return "every tool runs without asking"
+	default:
+		return "file writes and commands wait for your approval"
+	}
+}
+
+// renderModeBadge renders the permission mode for the status line
+func renderModeBadge(mode string) string {
+	style := SuccessStyle
+	switch mode {
+	case config.ModeEdit:
+		style = InfoStyle
+	case config.ModeAuto:
+		style = WarningStyle
+	}
+	return style.Render(mode)
+}
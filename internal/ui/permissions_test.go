@@ -0,0 +1,99 @@
+package ui
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/alchemy-labs-co/riptide/internal/api"
+	"github.com/alchemy-labs-co/riptide/internal/config"
+)
+
+// newToolCall builds a call of the named tool with the given arguments
+func newToolCall(t *testing.T, name string, args map[string]any) api.ToolCall {
+	t.Helper()
+	data, err := json.Marshal(args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var call api.ToolCall
+	call.Function.Name = name
+	call.Function.Arguments = string(data)
+	return call
+}
+
+func TestCheckWorkspace(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(root); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	cfg := config.Default()
+	cfg.FileOperations.AllowedPaths = []string{"../" + filepath.Base(outside) + "/shared"}
+	m := Model{config: cfg}
+
+	// Each call is either inside the workspace, outside it and so asked
+	// about, or refused
+	const (
+		inside = iota
+		ask
+		refuse
+	)
+	tests := []struct {
+		name string
+		tool string
+		args map[string]any
+		want int
+	}{
+		{"read inside", "read_file", map[string]any{"file_path": "main.go"}, inside},
+		{"read outside", "read_file", map[string]any{"file_path": "/etc/passwd"}, ask},
+		{"traversal", "read_file", map[string]any{"file_path": "../../etc/passwd"}, refuse},
+		{"allowed path", "read_file", map[string]any{"file_path": filepath.Join(outside, "shared", "notes.md")}, inside},
+		{"next to allowed path", "read_file", map[string]any{"file_path": filepath.Join(outside, "private.md")}, ask},
+		{"one of many outside", "read_multiple_files", map[string]any{"file_paths": []string{"a.go", "/etc/shadow"}}, ask},
+		{"create outside", "create_multiple_files", map[string]any{"files": []map[string]string{{"path": "/tmp/x", "content": ""}}}, ask},
+		{"schema inside", "validate_file", map[string]any{"file_path": "c.json", "schema": "schema.json"}, inside},
+		{"schema outside", "validate_file", map[string]any{"file_path": "c.json", "schema": "/etc/passwd"}, ask},
+		{"known format", "validate_file", map[string]any{"file_path": "compose.yml", "schema": "docker-compose"}, inside},
+		{"go pattern", "run_tests", map[string]any{"target": "./internal/... ./cmd"}, inside},
+		{"pytest node", "run_tests", map[string]any{"target": "tests/test_api.py::test_get -x"}, inside},
+		{"target outside", "run_tests", map[string]any{"target": "/home/other/project/..."}, ask},
+		{"target traversal", "run_benchmarks", map[string]any{"target": "../other/..."}, refuse},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			call := newToolCall(t, tt.tool, tt.args)
+			paths, err := m.checkWorkspace(root, config.ModeEdit, call)
+			got := inside
+			switch {
+			case err != nil:
+				got = refuse
+			case len(paths) > 0:
+				got = ask
+			}
+			if got != tt.want {
+				t.Fatalf("checkWorkspace = %v, %v; want outcome %d", paths, err, tt.want)
+			}
+
+			// Read-only mode and block_outside refuse what would be asked about
+			if tt.want == ask {
+				if _, err := m.checkWorkspace(root, config.ModeReadOnly, call); err == nil {
+					t.Error("read-only mode did not refuse the call")
+				}
+				blocking := Model{config: config.Default()}
+				blocking.config.FileOperations = cfg.FileOperations
+				blocking.config.FileOperations.BlockOutside = true
+				if _, err := blocking.checkWorkspace(root, config.ModeAuto, call); err == nil {
+					t.Error("block_outside did not refuse the call")
+				}
+			}
+		})
+	}
+}
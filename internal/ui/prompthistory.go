@@ -0,0 +1,48 @@
+package ui
+
+// rememberPrompt adds a sent prompt or command to the prompt history and ends
+// any recall in progress
+func (m *Model) rememberPrompt(input string) {
+	m.historyIndex = 0
+	m.historyDraft = ""
+	if m.promptHistory == nil || input == "" {
+		return
+	}
+	// The history is a convenience, so failing to save it must not block the prompt
+	_ = m.promptHistory.Add(input)
+}
+
+// recallPrompt moves through the prompt history like a shell: a positive step
+// loads an older prompt, a negative one a newer prompt, and stepping past the
+// newest restores what was typed before browsing. It reports whether the input
+// changed.
+func (m *Model) recallPrompt(step int) bool {
+	if m.promptHistory == nil {
+		return false
+	}
+	prompts := m.promptHistory.Prompts()
+	index := min(max(m.historyIndex+step, 0), len(prompts))
+	if index == m.historyIndex {
+		return false
+	}
+
+	if m.historyIndex == 0 {
+		m.historyDraft = m.textInput.Value()
+	}
+	m.historyIndex = index
+	if index == 0 {
+		m.textInput.SetValue(m.historyDraft)
+		m.historyDraft = ""
+	} else {
+		m.textInput.SetValue(prompts[len(prompts)-index])
+	}
+
+	// A recalled command is complete, so do not offer to complete it
+	m.autocompleteActive = false
+	m.autocompleteSuggestion = ""
+	m.autocompleteCommand = nil
+	m.autocompleteMatches = nil
+	m.autocompleteSelectedIndex = 0
+	m.resizeViewport()
+	return true
+}
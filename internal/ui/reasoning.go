@@ -0,0 +1,20 @@
+package ui
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/alchemy-labs-co/riptide/internal/config"
+)
+
+// toggleReasoning expands every reasoning block in the transcript, or collapses
+// them to their one-line labels. Reasoning hidden with show_reasoning set to
+// never stays hidden.
+func (m Model) toggleReasoning() (tea.Model, tea.Cmd) {
+	if m.config.UI.ShowReasoning == config.ReasoningNever {
+		m.addSystemMessage("⎿  Reasoning is hidden; set Show Reasoning to always or collapsed in /config to see it")
+	} else {
+		m.reasoningExpanded = !m.reasoningExpanded
+	}
+	m.updateViewport()
+	return m, nil
+}
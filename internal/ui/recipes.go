@@ -0,0 +1,145 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/alchemy-labs-co/riptide/internal/recipe"
+)
+
+// recipeUsage is shown when /recipe is given bad arguments
+const recipeUsage = "Usage: /recipe [list] | /recipe save <name> [param=value ...]"
+
+// RecipeDirs returns where recipes are looked up: the workspace's
+// .riptide/recipes first, then the user's recipe directory
+func (m *Model) RecipeDirs() []string {
+	dirs := []string{filepath.Join(m.workspaceRoot, recipe.WorkspaceDir)}
+	if userDir, err := recipe.DefaultDir(); err == nil {
+		dirs = append(dirs, userDir)
+	}
+	return dirs
+}
+
+// handleRecipeCommand lists the saved recipes or saves the conversation as one
+func (m Model) handleRecipeCommand(arg string) (tea.Model, tea.Cmd) {
+	fields := strings.Fields(arg)
+	enableEmoji := m.config.UI.EnableEmoji
+
+	if len(fields) == 0 || fields[0] == "list" {
+		recipes, err := recipe.List(m.RecipeDirs()...)
+		if err != nil {
+			m.addErrorMessage(fmt.Sprintf("Listing recipes: %v", err))
+		} else if len(recipes) == 0 {
+			m.addSystemMessage(FormatInfo("No recipes yet. Finish a task, then use /recipe save <name> [param=value ...]", enableEmoji))
+		} else {
+			var b strings.Builder
+			b.WriteString(fmt.Sprintf("%s Recipes (run with riptide run --recipe <name> --arg param=value):\n", GetIcon("file", enableEmoji)))
+			for _, rec := range recipes {
+				line := fmt.Sprintf("  %s — %d prompt(s)", rec.Name, len(rec.Prompts))
+				if len(rec.Params) > 0 {
+					line += ", params: " + strings.Join(rec.Params, ", ")
+				}
+				if rec.Description != "" {
+					line += " — " + rec.Description
+				}
+				b.WriteString(line + "\n")
+			}
+			m.addSystemMessage(strings.TrimRight(b.String(), "\n"))
+		}
+		m.textInput.SetValue("")
+		m.updateViewport()
+		return m, nil
+	}
+
+	if fields[0] != "save" || len(fields) < 2 {
+		m.addErrorMessage(recipeUsage)
+		m.updateViewport()
+		return m, nil
+	}
+
+	params := make(map[string]string)
+	for _, field := range fields[2:] {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok {
+			m.addErrorMessage(recipeUsage)
+			m.updateViewport()
+			return m, nil
+		}
+		params[key] = value
+	}
+
+	m.textInput.SetValue("")
+	rec, err := recipe.FromConversation(fields[1], m.history.GetRawMessages(), m.workspaceRoot, params)
+	if err == nil {
+		var dir string
+		if dir, err = recipe.DefaultDir(); err == nil {
+			var path string
+			if path, err = recipe.Save(dir, rec); err == nil {
+				result := FormatSuccess(fmt.Sprintf("Saved recipe '%s' to %s (%d prompt(s), %d context glob(s))",
+					rec.Name, FormatFilePath(path), len(rec.Prompts), len(rec.Context)), enableEmoji)
+				run := "riptide run --recipe " + rec.Name
+				for _, param := range rec.Params {
+					run += fmt.Sprintf(" --arg %s=...", param)
+				}
+				result += "\n" + FormatInfo("Run it with: "+run, enableEmoji)
+				m.addSystemMessage(result)
+			}
+		}
+	}
+	if err != nil {
+		m.addErrorMessage(fmt.Sprintf("Saving recipe: %v", err))
+	}
+	m.updateViewport()
+	return m, nil
+}
+
+// RunRecipe adds the recipe's context files and sends its prompts in order,
+// each run headlessly to completion before the next. It stops at the first
+// prompt that fails.
+func (m *Model) RunRecipe(ctx context.Context, rec *recipe.Recipe, args map[string]string, opts HeadlessOptions) error {
+	prompts, globs, err := rec.Render(args)
+	if err != nil {
+		return err
+	}
+	files, err := recipe.ExpandContext(m.workspaceRoot, globs)
+	if err != nil {
+		return err
+	}
+
+	out := opts.Output
+	if out == nil {
+		out = io.Discard
+	}
+
+	for _, file := range files {
+		if err := m.AddFile(file); err != nil {
+			return err
+		}
+	}
+	if len(files) > 0 {
+		fmt.Fprintf(out, "Added %d file(s) to context\n", len(files))
+	}
+
+	for i, prompt := range prompts {
+		fmt.Fprintf(out, "\n[%d/%d] %s\n\n", i+1, len(prompts), firstLine(prompt))
+		if err := m.RunPrompt(ctx, prompt, opts); err != nil {
+			return fmt.Errorf("running prompt %d: %w", i+1, err)
+		}
+		fmt.Fprintln(out)
+	}
+	return nil
+}
+
+// firstLine returns the first line of s, marking that more follows
+func firstLine(s string) string {
+	line, rest, _ := strings.Cut(strings.TrimSpace(s), "\n")
+	if rest != "" {
+		line += " …"
+	}
+	return line
+}
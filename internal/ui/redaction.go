@@ -0,0 +1,84 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/alchemy-labs-co/riptide/internal/functions"
+	"github.com/alchemy-labs-co/riptide/internal/safety"
+)
+
+// redact scrubs secrets from text unless redaction is turned off for this session
+func (m Model) redact(text string) (string, safety.Redaction) {
+	if !m.config.Redaction.Enabled || m.redactor == nil {
+		return text, safety.Redaction{}
+	}
+	return m.redactor.Redact(text)
+}
+
+// redactFrom scrubs file content or tool output, read from paths if any,
+// unless redaction is turned off for this session
+func (m Model) redactFrom(text string, paths ...string) (string, safety.Redaction) {
+	if !m.config.Redaction.Enabled || m.redactor == nil {
+		return text, safety.Redaction{}
+	}
+	return m.redactor.RedactFrom(text, paths...)
+}
+
+// checkPlaceholders refuses file writes that would put redaction placeholders
+// in place of the secrets they stand for, such as a create_file rewriting a
+// .env the model only saw redacted
+func checkPlaceholders(changes []functions.FileChange) error {
+	for _, change := range changes {
+		if added := safety.AddedPlaceholders(change.Before, change.After); len(added) > 0 {
+			return fmt.Errorf("the new content of %s adds %s; that text stands for a secret the model cannot see, so edit around the secret instead of rewriting it", change.Path, strings.Join(added, ", "))
+		}
+	}
+	return nil
+}
+
+// redactPrompt scrubs secrets typed or pasted into a prompt before it is sent,
+// noting what was removed in the transcript
+func (m *Model) redactPrompt(prompt string) string {
+	prompt, redaction := m.redact(prompt)
+	if redaction.Count > 0 {
+		m.addSystemMessage(FormatWarning("Redacted "+redaction.String()+" from your prompt — use /redact off to send as-is", m.config.UI.EnableEmoji))
+	}
+	return prompt
+}
+
+// guardInjection quarantines content that contains instruction-like text so the
+// model treats it as data. It returns the kinds of text that were found.
+func guardInjection(source, content string) (string, []string) {
+	reasons := safety.DetectInjection(content)
+	if len(reasons) == 0 {
+		return content, nil
+	}
+	return safety.Quarantine(source, content, reasons), reasons
+}
+
+// handleRedactCommand shows or overrides secret redaction for this session
+func (m Model) handleRedactCommand(arg string) (tea.Model, tea.Cmd) {
+	m.textInput.SetValue("")
+
+	switch strings.ToLower(strings.TrimSpace(arg)) {
+	case "":
+		state := "on"
+		if !m.config.Redaction.Enabled {
+			state = "off"
+		}
+		m.addSystemMessage(fmt.Sprintf("Secret redaction is %s", state))
+	case "on":
+		m.config.Redaction.Enabled = true
+		m.addSystemMessage("⎿  Secret redaction enabled")
+	case "off":
+		m.config.Redaction.Enabled = false
+		m.addSystemMessage(FormatWarning("Secret redaction disabled for this session — file content and tool output are sent as-is", m.config.UI.EnableEmoji))
+	default:
+		m.addErrorMessage("Usage: /redact <on|off>")
+	}
+
+	m.updateViewport()
+	return m, nil
+}
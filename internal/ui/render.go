@@ -3,11 +3,14 @@ package ui
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"regexp"
 	"strings"
 	"time"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/alchemy-labs-co/riptide/internal/api"
+	"github.com/alchemy-labs-co/riptide/internal/config"
 	"github.com/alchemy-labs-co/riptide/internal/conversation"
 )
 
@@ -88,8 +91,7 @@ func (m Model) renderWelcome() string {
 	}
 
 	// Create the welcome panel content
-	panelContent := lipgloss.JoinVertical(
-		lipgloss.Left,
+	sections := []string{
 		headerContent,
 		"",
 		subtitle,
@@ -97,7 +99,25 @@ func (m Model) renderWelcome() string {
 		cwdLine,
 		"",
 		lipgloss.NewStyle().Foreground(DimTextColor).Render(timeAndPricing),
-	)
+		"",
+		m.renderTips(),
+	}
+	if m.updateNotice != "" {
+		sections = append(sections, "", InfoStyle.Render(m.updateNotice))
+	}
+	if len(m.config.ProjectIgnored) > 0 {
+		sections = append(sections, "", WarningStyle.Render(projectIgnoredNotice(m.config)))
+	}
+	for _, note := range m.config.Outdated {
+		sections = append(sections, "", WarningStyle.Render(note))
+	}
+	if m.config.API.InsecureSkipVerify {
+		sections = append(sections, "", ErrorStyle.Render("TLS certificate checks are off (api.insecure_skip_verify); anyone on the network path can read your requests and API key"))
+	}
+	if len(m.recentSessions) > 0 {
+		sections = append(sections, "", m.renderRecentSessions())
+	}
+	panelContent := lipgloss.JoinVertical(lipgloss.Left, sections...)
 
 	// Apply panel styling - don't set width, let it auto-fit
 	panel := WelcomePanelStyle.Render(panelContent)
@@ -105,6 +125,16 @@ func (m Model) renderWelcome() string {
 	return panel
 }
 
+// projectIgnoredNotice names the project options that were not applied
+// because the project file is not trusted
+func projectIgnoredNotice(cfg *config.Config) string {
+	return fmt.Sprintf("Ignoring %s from the untrusted %s; restart Riptide in a terminal to review and trust it",
+		strings.Join(cfg.ProjectIgnored, ", "), cfg.ProjectPath)
+}
+
+// bulkyMessageTokens is the size above which system messages show their token estimate
+const bulkyMessageTokens = 500
+
 // renderHeader renders the header
 func (m Model) renderHeader() string {
 	enableEmoji := m.config.UI.EnableEmoji
@@ -112,21 +142,30 @@ func (m Model) renderHeader() string {
 	return TitleStyle.Render(title)
 }
 
+// messagesPadding follows the transcript so its end stays visible when scrolled to the bottom
+const messagesPadding = "\n\n\n\n\n"
+
 // renderMessages renders all messages
 func (m Model) renderMessages() string {
+	return m.renderMessageList(m.messages, "", time.Now()) + messagesPadding
+}
+
+// renderMessageList renders messages that follow one with role lastRole, which
+// decides how the first of them is laid out
+func (m Model) renderMessageList(messages []Message, lastRole string, now time.Time) string {
 	var content strings.Builder
-	var lastRole string
+	codePalette := codeStyles
 
-	for _, msg := range m.messages {
+	for i, msg := range messages {
 		switch msg.Role {
 		case "user":
 			// Blue triangle for user messages
 			blueTriangle := lipgloss.NewStyle().Foreground(SecondaryColor).Render("▶")
-			content.WriteString(fmt.Sprintf("\n%s %s %s\n",
-				blueTriangle,
-				msg.Content,
-				HelpStyle.Render(msg.Timestamp.Format("15:04:05")),
-			))
+			content.WriteString(fmt.Sprintf("\n%s %s", blueTriangle, msg.Content))
+			if timestamp := m.formatTimestamp(msg.Timestamp, now); timestamp != "" {
+				content.WriteString(" " + HelpStyle.Render(timestamp))
+			}
+			content.WriteString("\n")
 
 		case "assistant-label":
 			// White dot for output tokens - no label text
@@ -135,17 +174,42 @@ func (m Model) renderMessages() string {
 			content.WriteString(fmt.Sprintf("\n\n%s ", whiteDot))
 
 		case "reasoning-label":
+			if m.config.UI.ShowReasoning == config.ReasoningNever {
+				break
+			}
 			// Blue dot for reasoning tokens
-			blueDot := lipgloss.NewStyle().Foreground(lipgloss.Color("#60a5fa")).Render("●")
+			blueDot := lipgloss.NewStyle().Foreground(ReasoningColor).Render("●")
+			// Once reasoning ends the label reports how long it took
+			label := "Thinking..."
+			if msg.Duration > 0 {
+				label = fmt.Sprintf("Thought for %s", formatDuration(msg.Duration))
+			}
+			// The label says how much reasoning it heads and whether it is folded away
+			marker := "▸ "
+			if m.reasoningExpanded {
+				marker = "▾ "
+			}
+			var size string
+			if i+1 < len(messages) && messages[i+1].Role == "reasoning" && messages[i+1].Tokens > 0 {
+				size = " " + HelpStyle.Render(fmt.Sprintf("(%s tokens)", formatTokenCount(messages[i+1].Tokens)))
+			}
 			// Add extra newline before thinking label for spacing
-			content.WriteString(fmt.Sprintf("\n\n%s %s\n",
+			content.WriteString(fmt.Sprintf("\n\n%s %s%s\n",
 				blueDot,
-				ReasoningLabelStyle.Render("Thinking..."),
+				ReasoningLabelStyle.Render(marker+label),
+				size,
 			))
 
 		case "content":
 			// Apply markdown rendering to content
-			renderedContent := renderMarkdown(msg.Content)
+			// Code lines are wrapped inside the padding and the block's gutter
+			var renderedContent string
+			if m.answerRender != nil && m.streamingAnswer() && i == len(messages)-1 {
+				// The answer still streaming keeps the blocks it has finished
+				renderedContent = m.answerRender.render(msg.Content, m.viewport.Width-4, codePalette)
+			} else {
+				renderedContent = renderMarkdown(msg.Content, m.viewport.Width-4, codePalette)
+			}
 			// Apply padding to each line to align with labels
 			lines := strings.Split(renderedContent, "\n")
 			for i, line := range lines {
@@ -159,7 +223,19 @@ func (m Model) renderMessages() string {
 				content.WriteString("\n")
 			}
 
+		case "json":
+			// Structured answers are shown verbatim, as markdown would mangle them
+			for i, line := range strings.Split(msg.Content, "\n") {
+				if i > 0 || lastRole != "assistant-label" {
+					content.WriteString("  ")
+				}
+				content.WriteString(line + "\n")
+			}
+
 		case "reasoning":
+			if !m.reasoningExpanded || m.config.UI.ShowReasoning == config.ReasoningNever {
+				break
+			}
 			// Show reasoning content with consistent blue styling
 			lines := strings.Split(msg.Content, "\n")
 			for _, line := range lines {
@@ -171,7 +247,24 @@ func (m Model) renderMessages() string {
 			}
 
 		case "system":
-			content.WriteString(fmt.Sprintf("\n%s\n", InfoStyle.Render(msg.Content)))
+			content.WriteString(fmt.Sprintf("\n%s", InfoStyle.Render(msg.Content)))
+			if msg.Tokens >= bulkyMessageTokens {
+				content.WriteString(" " + HelpStyle.Render(fmt.Sprintf("(~%s tokens)", formatTokenCount(msg.Tokens))))
+			}
+			content.WriteString("\n")
+
+		case "tool":
+			content.WriteString("\n")
+			for _, line := range strings.Split(m.renderToolCard(*msg.Tool), "\n") {
+				content.WriteString("  " + line + "\n")
+			}
+
+		case "diff":
+			// Proposed file changes, framed like a fenced diff block
+			content.WriteString("\n")
+			for _, line := range strings.Split(renderCodeBlock("diff", msg.Content, m.viewport.Width-4, codePalette), "\n") {
+				content.WriteString("  " + line + "\n")
+			}
 
 		case "error":
 			content.WriteString(fmt.Sprintf("\n%s\n", ErrorStyle.Render(msg.Content)))
@@ -179,43 +272,13 @@ func (m Model) renderMessages() string {
 		lastRole = msg.Role
 	}
 
-	// Add bottom padding to ensure content is visible when scrolled to bottom
-	content.WriteString("\n\n\n\n\n")
-
 	return content.String()
 }
 
 // renderStatusLine renders the status line
 func (m Model) renderStatusLine() string {
 	stats := m.history.GetStats()
-
-	// DeepSeek pricing per million tokens (regular hours)
-	inputTokensPriceCached := 0.14 / 1_000_000 // $0.14 per 1M tokens
-	inputTokensPrice := 0.55 / 1_000_000       // $0.55 per 1M tokens
-	outputTokensPrice := 2.19 / 1_000_000      // $2.19 per 1M tokens
-
-	// Off-peak discount (75% off during 16:30-00:30 UTC)
-	// Off-peak prices: Cached Input: $0.035, Input: $0.135, Output: $0.550 per 1M tokens
-	offPeakDiscount := 0.25 // 75% off means paying 25% of original price
-
-	// Calculate regular hour tokens (total - off-peak)
-	regularInputTokens := stats.InputTokens - stats.OffPeakInputTokens
-	regularOutputTokens := stats.OutputTokens - stats.OffPeakOutputTokens
-	regularCachedTokens := stats.CachedTokens - stats.OffPeakCachedTokens
-
-	// Calculate costs for regular hours
-	regularInputCost := float64(regularInputTokens) * inputTokensPrice
-	regularOutputCost := float64(regularOutputTokens) * outputTokensPrice
-	regularCachedCost := float64(regularCachedTokens) * inputTokensPriceCached
-
-	// Calculate costs for off-peak hours
-	offPeakInputCost := float64(stats.OffPeakInputTokens) * inputTokensPrice * offPeakDiscount
-	offPeakOutputCost := float64(stats.OffPeakOutputTokens) * outputTokensPrice * offPeakDiscount
-	offPeakCachedCost := float64(stats.OffPeakCachedTokens) * inputTokensPriceCached * offPeakDiscount
-
-	// Total cost
-	totalCost := regularInputCost + regularOutputCost + regularCachedCost +
-		offPeakInputCost + offPeakOutputCost + offPeakCachedCost
+	totalCost := m.calculateTotalCost(stats)
 
 	// Check if we're currently in off-peak hours
 	now := time.Now().UTC()
@@ -238,10 +301,17 @@ func (m Model) renderStatusLine() string {
 		costString,
 	))
 
-	right := HelpStyle.Render(fmt.Sprintf(
-		"Model: %s",
-		m.config.API.Model,
-	))
+	// The gauge follows the prompt as it is typed
+	right := ""
+	if m.config.API.InsecureSkipVerify {
+		right = ErrorStyle.Render("TLS unverified") + HelpStyle.Render(" | ")
+	}
+	right += renderContextGauge(m.history.RequestTokens(m.textInput.Value()), api.ContextWindow(m.config.API.Model)) +
+		HelpStyle.Render(" | Mode: ") + renderModeBadge(m.config.Permissions.Mode) +
+		HelpStyle.Render(fmt.Sprintf(
+			" | Model: %s",
+			m.modelLabel(),
+		))
 
 	statusLine := lipgloss.JoinHorizontal(
 		lipgloss.Top,
@@ -252,6 +322,34 @@ func (m Model) renderStatusLine() string {
 	return statusLine
 }
 
+// contextGaugeCells is the number of cells in the context utilization bar
+const contextGaugeCells = 8
+
+// renderContextGauge renders a compact bar of estimated context usage versus the
+// model's window, turning yellow and then red as it fills up
+func renderContextGauge(used, window int) string {
+	if window <= 0 {
+		return ""
+	}
+
+	ratio := float64(used) / float64(window)
+	if ratio > 1 {
+		ratio = 1
+	}
+	filled := int(ratio*contextGaugeCells + 0.5)
+
+	style := HelpStyle
+	switch {
+	case ratio >= 0.9:
+		style = ErrorStyle
+	case ratio >= 0.7:
+		style = WarningStyle
+	}
+
+	bar := strings.Repeat("▓", filled) + strings.Repeat("░", contextGaugeCells-filled)
+	return style.Render(fmt.Sprintf("ctx %s %d%%", bar, int(ratio*100)))
+}
+
 // renderInput renders the input area
 func (m Model) renderInput() string {
 	// Use a blue triangle instead of "You"
@@ -259,49 +357,19 @@ func (m Model) renderInput() string {
 
 	var inputContent string
 	
-	if m.state != StateReady {
+	if m.confirmingQuit {
+		inputContent = prompt + WarningStyle.Render("A response is in progress — cancel and quit? (y/n)")
+	} else if m.pendingApproval != nil {
+		inputContent = prompt + m.renderApprovalPrompt()
+	} else if m.pendingCommit != "" {
+		subject, _, _ := strings.Cut(m.pendingCommit, "\n")
+		inputContent = prompt + WarningStyle.Render(fmt.Sprintf("Commit staged changes as %q?", truncate(subject, max(m.width-40, 30)))) + " " + HelpStyle.Render("(y/n)")
+	} else if m.searchOpen {
+		inputContent = prompt + m.renderSearchBar()
+	} else if m.state != StateReady {
 		inputContent = prompt + HelpStyle.Render("(waiting...)")
 	} else {
-		// Get current input and build content
-		currentValue := m.textInput.Value()
-		cursorPos := m.textInput.Position()
-
-	// Add autocomplete suggestion if active
-	if m.autocompleteActive && m.autocompleteSuggestion != "" {
-		// Show the suggestion as dimmed text after the current input
-		suggestionPart := m.autocompleteSuggestion[len(currentValue):]
-		dimmedSuggestion := HelpStyle.Render(suggestionPart)
-
-		// Build with cursor
-		if cursorPos < len(currentValue) {
-			// Cursor in middle of text
-			before := currentValue[:cursorPos]
-			after := currentValue[cursorPos:]
-			cursor := lipgloss.NewStyle().Background(WhiteColor).Foreground(lipgloss.Color("#000000")).Render(" ")
-			inputContent = prompt + before + cursor + after + dimmedSuggestion
-		} else {
-			// Cursor at end
-			cursor := lipgloss.NewStyle().Background(WhiteColor).Foreground(lipgloss.Color("#000000")).Render(" ")
-			inputContent = prompt + currentValue + cursor + dimmedSuggestion
-		}
-	} else if currentValue == "" {
-		// Show placeholder when empty with cursor
-		cursor := lipgloss.NewStyle().Background(WhiteColor).Foreground(lipgloss.Color("#000000")).Render(" ")
-		inputContent = prompt + cursor + HelpStyle.Render("Type your message...")
-	} else {
-		// Show typed content with cursor
-		if cursorPos < len(currentValue) {
-			// Cursor in middle of text
-			before := currentValue[:cursorPos]
-			after := currentValue[cursorPos:]
-			cursor := lipgloss.NewStyle().Background(WhiteColor).Foreground(lipgloss.Color("#000000")).Render(" ")
-			inputContent = prompt + before + cursor + after
-		} else {
-			// Cursor at end
-			cursor := lipgloss.NewStyle().Background(WhiteColor).Foreground(lipgloss.Color("#000000")).Render(" ")
-			inputContent = prompt + currentValue + cursor
-		}
-	}
+		inputContent = m.renderEditor(prompt)
 	}
 
 	// Create input box with full width
@@ -316,6 +384,9 @@ func (m Model) renderInput() string {
 	if m.autocompleteActive && len(m.autocompleteMatches) > 0 {
 		dropdown := m.renderAutocompleteDropdown()
 		hintLine := HelpStyle.Render("  Enter/Tab to complete • ↑↓ to navigate • Esc to cancel")
+		if m.autocompletePrefix != "" {
+			hintLine = HelpStyle.Render("  Tab to complete • ↑↓ to navigate • Esc to cancel")
+		}
 		return inputBox + "\n" + dropdown + "\n" + hintLine
 	}
 
@@ -323,13 +394,38 @@ func (m Model) renderInput() string {
 	var statusText string
 	switch m.state {
 	case StateStreaming:
-		statusText = m.spinner.View() + " " + InfoStyle.Render("Seeking...")
+		statusText = m.spinner.View() + " " + InfoStyle.Render("Seeking...") + " " + HelpStyle.Render("(Esc to stop)")
+		if step := m.iterationStatus(); step != "" {
+			statusText = m.spinner.View() + " " + InfoStyle.Render("Seeking...") + " " + HelpStyle.Render("("+step+" • Esc to stop)")
+		}
+		if m.streamRetry != nil {
+			statusText = m.spinner.View() + " " + WarningStyle.Render(m.retryStatus())
+		}
 	case StateProcessing:
 		statusText = m.spinner.View() + " " + InfoStyle.Render("Processing...")
+		if step := m.iterationStatus(); step != "" && len(m.toolStatuses) > 0 {
+			statusText = m.spinner.View() + " " + InfoStyle.Render("Processing...") + " " + HelpStyle.Render("("+step+")")
+		}
+		if p := m.scanProgress; p != nil {
+			statusText = m.spinner.View() + " " + InfoStyle.Render(fmt.Sprintf("Scanning... %d files found, %d checked, %d added", p.Found, p.Checked, p.Added))
+		} else if m.summarizing {
+			statusText = m.spinner.View() + " " + InfoStyle.Render("Summarizing earlier messages to fit the context window...")
+		}
 	case StateError:
 		statusText = ErrorStyle.Render("Error occurred")
 	case StateReady:
 		statusText = SuccessStyle.Render("Ready")
+		if m.editingPrompt > 0 {
+			statusText = WarningStyle.Render("Editing an earlier prompt — Enter resubmits it and drops what came after • Esc cancels")
+		} else if status := m.inputStatus(); status != "" {
+			statusText = status + "  " + statusText
+		}
+	}
+
+	for _, status := range []string{m.vimStatus(), m.searchStatus()} {
+		if status != "" {
+			statusText = status + "  " + statusText
+		}
 	}
 
 	// Place status on the right below the input box
@@ -353,13 +449,46 @@ func (m Model) getHelpText() string {
 
 %s Commands:
   /add <path>     - Add file or directory to conversation context
+  /ask [opts] p   - Send prompt p with --model, --temp or --max-tokens for one turn
   /clear          - Clear conversation history
+  /commit [msg]   - Commit staged changes with msg, or a message the model writes
+  /compare a b p  - Answer prompt p with models a and b side by side, with cost
   /config         - Configure settings
-  /help           - Show this help message
+  /context        - Show files in context with sizes and tokens; remove or refresh them
+  /edit           - Edit and resubmit a prompt, the last one selected (Ctrl+P)
+  /errors         - Show errors from this session
+  /export [f] [p] - Save the transcript as md, json or html, with tool output and cost
+  /help           - Show this help (paged)
+  /json file p    - Answer prompt p with JSON matching the schema in file
+  /map [path]     - Add a map of the project's files and top-level declarations to context
+  /memory [add i] - Show the project memory, or add instruction i to RIPTIDE.md
+  /mode [mode]    - Show or set permissions: readonly, edit (approve writes), auto
+  /readonly       - Toggle read-only mode: the model reads and proposes diffs only
+  /redact on|off  - Turn secret redaction on or off for this session
+  /retry          - Drop the last response and generate a new one
+  /resume [name]  - Resume the last session, or one by ID prefix or title
+  /sessions       - Browse saved sessions by title, with tokens and cost; resume or delete
+  /test [target]  - Run the tests; failures go to the model, ready to ask for a fix
+  /undo [n|turn]  - Revert the last tool call's file changes, the last n, or the last turn
+  /undo list      - Show this session's file changes and which are undone
+  /vim [on|off]   - Vim keys: Esc for normal mode, j/k scroll, gg/G jump, / search
+  /writes [path]  - Show files written this project, with hashes and size changes
   /status         - Show current configuration and pricing info
   quit (exit)     - Exit the application
   Ctrl+C          - Force quit
-  Ctrl+D          - Quit (when ready)
+  Ctrl+D          - Quit (confirms if a response is in progress)
+  Ctrl+E          - Preview the request (prompt, context files, tokens) before sending
+  Ctrl+K          - Command palette (commands and recent files)
+  Ctrl+F          - Search the conversation; n/N jump between matches, Esc clears
+  Ctrl+P          - Edit and resubmit an earlier prompt
+  Ctrl+R          - Retry after an error
+  Ctrl+O          - With routing on, cycle auto, pin deepseek-chat, pin deepseek-reasoner
+  Ctrl+T          - Cycle timestamps (relative, absolute, hidden)
+  Ctrl+G          - Expand or collapse the model's reasoning
+  Ctrl+L          - Expand or collapse tool output
+  Esc             - Stop a streaming response or running command (also Ctrl+X), or dismiss the error banner
+  Shift/Alt+Enter - New line in the prompt (also Ctrl+J); Enter sends
+  Up/Down         - On an empty prompt, recall earlier prompts from any session
   PgUp/PgDown     - Scroll conversation
 
 %s File Operations:
@@ -390,6 +519,15 @@ func (m Model) getStatusText() string {
 	if err != nil {
 		cwd = "Unknown"
 	}
+	if m.config.ProjectPath != "" {
+		cwd += "\n└ Project config: " + m.config.ProjectPath
+		if len(m.config.ProjectIgnored) > 0 {
+			cwd += " (untrusted; ignoring " + strings.Join(m.config.ProjectIgnored, ", ") + ")"
+		}
+	}
+	if memory := conversation.FindMemory("."); memory != "" {
+		cwd += "\n└ Project memory: " + filepath.Base(memory)
+	}
 
 	// Get current time info
 	now := time.Now()
@@ -439,7 +577,8 @@ func (m Model) getStatusText() string {
 %s
 
 %s
-└ Default: %s with 64K context window
+└ Default: %s with %dK context window, %dK max output
+└ Supports: %s
 
 %s
 └ Messages: %d
@@ -457,6 +596,9 @@ func (m Model) getStatusText() string {
 		pricingStatusLine,
 		headerStyle.Render("Model • /model"),
 		m.config.API.Model,
+		api.ContextWindow(m.config.API.Model)/1000,
+		api.MaxOutputTokens(m.config)/1000,
+		describeCapabilities(api.LookupModel(m.config.API.Model)),
 		headerStyle.Render("Session • /clear"),
 		stats.TotalMessages,
 		stats.InputTokens,
@@ -468,12 +610,54 @@ func (m Model) getStatusText() string {
 	return statusText + "\n\nPress Enter to continue..."
 }
 
+// describeCapabilities lists the features a model supports
+func describeCapabilities(info api.ModelInfo) string {
+	var features []string
+	if info.Tools {
+		features = append(features, "tools")
+	}
+	if info.Reasoning {
+		features = append(features, "reasoning")
+	}
+	if len(features) == 0 {
+		return "plain answers only"
+	}
+	return strings.Join(features, ", ")
+}
+
+// getContextText returns a breakdown of what is currently in the conversation context
+func (m Model) getContextText() string {
+	headerStyle := lipgloss.NewStyle().Bold(true).Foreground(SecondaryColor)
+	stats := m.history.GetStats()
+	items := m.history.GetContextItems()
+
+	fileTokens := 0
+	for _, item := range items {
+		fileTokens += item.Tokens
+	}
+
+	var sb strings.Builder
+	sb.WriteString(headerStyle.Render(fmt.Sprintf("Context • ~%s tokens", formatTokenCount(stats.ContextTokens))))
+	sb.WriteString(fmt.Sprintf("\n└ Budget: %s tokens before trimming", formatTokenCount(api.ContextBudget(m.config))))
+	sb.WriteString(fmt.Sprintf("\n└ Files (%d): ~%s tokens", len(items), formatTokenCount(fileTokens)))
+	for _, item := range items {
+		sb.WriteString(fmt.Sprintf("\n   %s  ~%s", FormatFilePath(item.Path), formatTokenCount(item.Tokens)))
+	}
+	sb.WriteString(fmt.Sprintf("\n└ Conversation (%d messages): ~%s tokens",
+		stats.TotalMessages-len(items),
+		formatTokenCount(stats.ContextTokens-fileTokens),
+	))
+
+	return sb.String()
+}
+
 // calculateTotalCost calculates the total cost from stats
 func (m Model) calculateTotalCost(stats conversation.ConversationStats) float64 {
-	// DeepSeek pricing per million tokens (regular hours)
-	inputTokensPriceCached := 0.14 / 1_000_000
-	inputTokensPrice := 0.55 / 1_000_000
-	outputTokensPrice := 2.19 / 1_000_000
+	// Prices per token at regular hours, from the model registry
+	pricing := api.PricingFor(m.config.API.Model)
+	inputTokensPriceCached := pricing.CachedInput / 1_000_000
+	inputTokensPrice := pricing.Input / 1_000_000
+	outputTokensPrice := pricing.Output / 1_000_000
 
 	// Off-peak discount (75% off)
 	offPeakDiscount := 0.25
@@ -558,8 +742,79 @@ func formatDuration(d time.Duration) string {
 	}
 }
 
-// renderMarkdown applies basic markdown formatting to text
-func renderMarkdown(text string) string {
+// formatTimestamp formats a message time according to the configured timestamp mode
+func (m Model) formatTimestamp(t, now time.Time) string {
+	switch m.config.UI.Timestamps {
+	case config.TimestampsHidden:
+		return ""
+	case config.TimestampsAbsolute:
+		return t.Format("15:04:05")
+	default:
+		return formatRelativeTime(t, now)
+	}
+}
+
+// formatRelativeTime formats a time relative to now (e.g. "2m ago")
+func formatRelativeTime(t, now time.Time) string {
+	d := now.Sub(t)
+	switch {
+	case d < 10*time.Second:
+		return "just now"
+	case d < time.Minute:
+		return fmt.Sprintf("%ds ago", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	default:
+		return t.Format("Jan 2 15:04")
+	}
+}
+
+// formatTokenCount formats a token count compactly (e.g. 850, 1.2k)
+func formatTokenCount(n int) string {
+	if n < 1000 {
+		return fmt.Sprintf("%d", n)
+	}
+	return fmt.Sprintf("%.1fk", float64(n)/1000)
+}
+
+// renderMarkdown applies basic markdown formatting to text, highlighting fenced
+// code blocks and wrapping their lines to width. An unclosed block, as while an
+// answer streams, runs to the end of the text.
+func renderMarkdown(text string, width int, palette codePalette) string {
+	var out, prose, code []string
+	fence, language := "", ""
+	for _, line := range strings.Split(text, "\n") {
+		match := codeFence.FindStringSubmatch(line)
+		switch {
+		case fence == "" && match != nil:
+			if len(prose) > 0 {
+				out = append(out, renderProse(strings.Join(prose, "\n")))
+				prose = nil
+			}
+			fence, language = match[1], match[2]
+		case fence != "" && strings.TrimSpace(line) == fence:
+			out = append(out, renderCodeBlock(language, strings.Join(code, "\n"), width, palette))
+			fence, code = "", nil
+		case fence != "":
+			code = append(code, line)
+		default:
+			prose = append(prose, line)
+		}
+	}
+	if fence != "" {
+		out = append(out, renderCodeBlock(language, strings.Join(code, "\n"), width, palette))
+	}
+	if len(prose) > 0 {
+		out = append(out, renderProse(strings.Join(prose, "\n")))
+	}
+	return strings.Join(out, "\n")
+}
+
+// renderProse applies bold, inline code, header and list formatting to text
+// outside code blocks
+func renderProse(text string) string {
 	// Bold text: **text** or __text__
 	boldRegex := regexp.MustCompile(`\*\*([^*]+)\*\*|__([^_]+)__`)
 	text = boldRegex.ReplaceAllStringFunc(text, func(match string) string {
@@ -657,7 +912,7 @@ func (m Model) renderAutocompleteDropdown() string {
 				Padding(0, 1)
 
 			descStyle := lipgloss.NewStyle().
-				Foreground(lipgloss.Color("#9CA3AF")).
+				Foreground(MutedTextColor).
 				Padding(0, 1)
 
 			item = fmt.Sprintf("%s  %s",
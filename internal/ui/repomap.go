@@ -0,0 +1,51 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/alchemy-labs-co/riptide/internal/conversation"
+)
+
+// repoMapDisplayLimit caps how many lines of the map /map shows; all of it goes to the model
+const repoMapDisplayLimit = 40
+
+// handleMapCommand builds the repository map of path, shows it, and adds it
+// to the conversation so the model knows the project's layout without
+// reading every file
+func (m Model) handleMapCommand(path string) (tea.Model, tea.Cmd) {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		path = m.workspaceRoot
+	}
+
+	m.state = StateProcessing
+	enableEmoji := m.config.UI.EnableEmoji
+
+	return m, func() tea.Msg {
+		repoMap, err := conversation.BuildRepoMap(m.config, path)
+		if err != nil {
+			return ProcessCompleteMsg{Error: fmt.Errorf("building repository map: %w", err)}
+		}
+		m.history.AddSystemMessage(repoMap.Text)
+
+		lines := strings.Split(repoMap.Text, "\n")
+		var b strings.Builder
+		b.WriteString(fmt.Sprintf("%s %s\n", GetIcon("folder", enableEmoji), lines[0]))
+		for i, line := range lines[1:] {
+			if i == repoMapDisplayLimit {
+				b.WriteString(HelpStyle.Render(fmt.Sprintf("  ... and %d more lines", len(lines)-1-repoMapDisplayLimit)) + "\n")
+				break
+			}
+			b.WriteString("  " + line + "\n")
+		}
+		b.WriteString("\n" + FormatSuccess(fmt.Sprintf("Added the map to the conversation (~%s tokens)", formatTokenCount(repoMap.Tokens)), enableEmoji))
+		if repoMap.Detail != "" {
+			b.WriteString("\n" + FormatInfo(fmt.Sprintf("Trimmed to %s to fit repo_map.max_tokens", repoMap.Detail), enableEmoji))
+		}
+
+		return ProcessCompleteMsg{Result: b.String()}
+	}
+}
@@ -0,0 +1,72 @@
+package ui
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/alchemy-labs-co/riptide/internal/api"
+)
+
+// routePins are the choices Ctrl+O cycles through: automatic routing, then
+// each model pinned for the prompts that follow
+var routePins = []string{"", api.ChatModel, api.ReasonerModel}
+
+// cycleRoutePin switches between automatic routing and pinning either model
+func (m Model) cycleRoutePin() (tea.Model, tea.Cmd) {
+	enableEmoji := m.config.UI.EnableEmoji
+	if !m.config.API.Routing {
+		m.addSystemMessage(FormatInfo(`Model routing is off; set "routing": true under "api" in config.json or turn it on in /config`, enableEmoji))
+		m.updateViewport()
+		return m, nil
+	}
+
+	next := 0
+	for i, pin := range routePins {
+		if pin == m.routePin {
+			next = (i + 1) % len(routePins)
+		}
+	}
+	m.routePin = routePins[next]
+
+	if m.routePin == "" {
+		m.addSystemMessage(FormatInfo("Routing each prompt to deepseek-chat or deepseek-reasoner automatically", enableEmoji))
+	} else {
+		m.addSystemMessage(FormatInfo(fmt.Sprintf("Sending prompts to %s until you press Ctrl+O again", m.routePin), enableEmoji))
+	}
+	m.updateViewport()
+	return m, nil
+}
+
+// routeTurn picks the model for the current turn when routing is on and
+// returns a note for the transcript, or "" when the configured model is used.
+// A model named with a directive or /ask wins over the router.
+func (m *Model) routeTurn(prompt string) string {
+	if !m.config.API.Routing || (m.turnOverrides != nil && m.turnOverrides.Model != "") {
+		return ""
+	}
+
+	route := api.Route{Model: m.routePin, Reason: "pinned with Ctrl+O"}
+	if m.routePin == "" {
+		route = api.RoutePrompt(prompt, len(m.history.GetContextItems()))
+	}
+
+	overrides := api.Overrides{}
+	if m.turnOverrides != nil {
+		overrides = *m.turnOverrides
+	}
+	overrides.Model = route.Model
+	m.turnOverrides = &overrides
+	return fmt.Sprintf("Routed to %s (%s)", route.Model, route.Reason)
+}
+
+// modelLabel names the model in the status bar
+func (m Model) modelLabel() string {
+	switch {
+	case !m.config.API.Routing:
+		return m.config.API.Model
+	case m.routePin != "":
+		return m.routePin + " (pinned)"
+	}
+	return "auto"
+}
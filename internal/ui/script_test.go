@@ -0,0 +1,84 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alchemy-labs-co/riptide/internal/config"
+	"github.com/alchemy-labs-co/riptide/internal/demo"
+	"github.com/alchemy-labs-co/riptide/tuitest"
+)
+
+// TestScripts runs each script in testdata/scripts against the TUI on the demo
+// project, with canned responses and no API key
+func TestScripts(t *testing.T) {
+	scripts, err := filepath.Glob(filepath.Join("testdata", "scripts", "*.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(scripts) == 0 {
+		t.Fatal("no scripts in testdata/scripts")
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, script := range scripts {
+		script := filepath.Join(wd, script)
+		t.Run(strings.TrimSuffix(filepath.Base(script), ".txt"), func(t *testing.T) {
+			runScript(t, script)
+		})
+	}
+}
+
+// runScript starts a model in a fresh copy of the demo project and drives it
+// with script
+func runScript(t *testing.T, script string) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	t.Setenv("XDG_DATA_HOME", filepath.Join(home, ".local", "share"))
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(home, ".cache"))
+
+	dir, err := demo.Setup()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	cfg := config.Default()
+	cfg.APIKey = "demo"
+	cfg.API.Model = "demo"
+	cfg.Permissions.Mode = "auto"
+	model, err := NewModel(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	model.SetProvider(demo.NewProvider())
+
+	file, err := os.Open(script)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	driver := tuitest.New(model, tuitest.WithSize(120, 40), tuitest.WithProgram(model.SetProgram))
+	if err := tuitest.RunScript(driver, file); err != nil {
+		driver.Quit(tuitest.DefaultTimeout)
+		t.Fatal(err)
+	}
+	if _, err := driver.Quit(tuitest.DefaultTimeout); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,203 @@
+package ui
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// searchContext is how many lines above a search match stay in view
+const searchContext = 2
+
+// ansiSequence matches the escape sequences styling rendered transcript lines
+var ansiSequence = regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]|\x1b\][^\x07]*\x07`)
+
+// openSearch shows the search bar with the query ready to edit
+func (m *Model) openSearch() {
+	m.searchOpen, m.searchEditing = true, true
+	m.searchOrigin = m.viewport.YOffset
+}
+
+// closeSearch hides the search bar and clears the highlighted matches
+func (m *Model) closeSearch() {
+	m.searchOpen, m.searchEditing = false, false
+	m.searchQuery = ""
+	m.searchMatch, m.searchTotal = 0, 0
+}
+
+// handleSearchKey handles a key while the search bar is open and reports
+// whether it was used. While the query is typed each change jumps to the first
+// match below where the search started; after Enter, n and N move between
+// matches. Other keys close the bar, leaving the matches highlighted, and are
+// left to the usual bindings.
+func (m *Model) handleSearchKey(msg tea.KeyMsg) bool {
+	switch msg.Type {
+	case tea.KeyCtrlC:
+		return false
+	case tea.KeyEsc:
+		m.closeSearch()
+		return true
+	case tea.KeyCtrlF:
+		m.searchEditing = true
+		return true
+	case tea.KeyDown:
+		m.findMatch(1)
+		return true
+	case tea.KeyUp:
+		m.findMatch(-1)
+		return true
+	}
+
+	if m.searchEditing {
+		switch msg.Type {
+		case tea.KeyEnter:
+			m.searchEditing = false
+			if m.searchQuery == "" {
+				m.closeSearch()
+			}
+		case tea.KeyBackspace:
+			if runes := []rune(m.searchQuery); len(runes) > 0 {
+				m.searchQuery = string(runes[:len(runes)-1])
+				m.searchFromOrigin()
+			}
+		case tea.KeyRunes, tea.KeySpace:
+			m.searchQuery += string(msg.Runes)
+			m.searchFromOrigin()
+		}
+		return true
+	}
+
+	switch msg.String() {
+	case "enter", "n":
+		m.findMatch(1)
+	case "N":
+		m.findMatch(-1)
+	case "/":
+		m.searchEditing = true
+	default:
+		m.searchOpen = false
+		return false
+	}
+	return true
+}
+
+// searchFromOrigin jumps to the first match at or below where the search
+// started, or back to that point when nothing matches
+func (m *Model) searchFromOrigin() {
+	m.searchLine = m.searchOrigin - 1
+	m.findMatch(1)
+	if m.searchTotal == 0 {
+		m.viewport.SetYOffset(m.searchOrigin)
+	}
+}
+
+// searchPattern matches the query ignoring case, or is nil when there is none
+func (m Model) searchPattern() *regexp.Regexp {
+	if m.searchQuery == "" {
+		return nil
+	}
+	return regexp.MustCompile("(?i)" + regexp.QuoteMeta(m.searchQuery))
+}
+
+// findMatch scrolls the transcript to the next line matching the search query
+// after the current match (dir 1) or the previous one (dir -1), wrapping around
+// at either end. Matching ignores case and styling.
+func (m *Model) findMatch(dir int) {
+	m.searchMatch, m.searchTotal = 0, 0
+	pattern := m.searchPattern()
+	if pattern == nil {
+		return
+	}
+
+	var matches []int
+	for i, line := range strings.Split(m.renderMessages(), "\n") {
+		if pattern.MatchString(ansiSequence.ReplaceAllString(line, "")) {
+			matches = append(matches, i)
+		}
+	}
+	if len(matches) == 0 {
+		return
+	}
+
+	// Start from the first match past the current one, or wrap to the other end
+	next := 0
+	if dir < 0 {
+		next = len(matches) - 1
+		for i := len(matches) - 1; i >= 0; i-- {
+			if matches[i] < m.searchLine {
+				next = i
+				break
+			}
+		}
+	} else {
+		for i, line := range matches {
+			if line > m.searchLine {
+				next = i
+				break
+			}
+		}
+	}
+
+	m.searchLine = matches[next]
+	m.searchMatch, m.searchTotal = next+1, len(matches)
+	m.viewport.SetYOffset(max(m.searchLine-searchContext, 0))
+}
+
+// highlightMatches marks every match of the search query in the rendered
+// transcript, the current one standing out. Lines with a match lose their own
+// styling so the highlights can be placed by position.
+func (m Model) highlightMatches(content string) string {
+	pattern := m.searchPattern()
+	if pattern == nil {
+		return content
+	}
+
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		plain := ansiSequence.ReplaceAllString(line, "")
+		matches := pattern.FindAllStringIndex(plain, -1)
+		if matches == nil {
+			continue
+		}
+		style := SearchMatchStyle
+		if i == m.searchLine && m.searchMatch > 0 {
+			style = SearchCurrentStyle
+		}
+
+		var b strings.Builder
+		last := 0
+		for _, match := range matches {
+			b.WriteString(plain[last:match[0]])
+			b.WriteString(style.Render(plain[match[0]:match[1]]))
+			last = match[1]
+		}
+		b.WriteString(plain[last:])
+		lines[i] = b.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// renderSearchBar renders the search query in place of the prompt
+func (m Model) renderSearchBar() string {
+	label := InfoStyle.Render("Find: ")
+	if m.searchEditing {
+		cursor := lipgloss.NewStyle().Background(WhiteColor).Foreground(CursorTextColor).Render(" ")
+		return label + m.searchQuery + cursor + "  " + HelpStyle.Render("Enter to search • ↑↓ matches • Esc to close")
+	}
+	return label + m.searchQuery + "  " + HelpStyle.Render("n/N next/previous • Ctrl+F to edit • Esc to close")
+}
+
+// searchStatus shows which match is in view, for the status line
+func (m Model) searchStatus() string {
+	switch {
+	case m.searchQuery == "":
+		return ""
+	case m.searchTotal == 0:
+		return WarningStyle.Render("No matches")
+	default:
+		return InfoStyle.Render(fmt.Sprintf("Match %d of %d", m.searchMatch, m.searchTotal))
+	}
+}
@@ -0,0 +1,166 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// sessionsVisibleItems is how many sessions the browser lists at once
+const sessionsVisibleItems = 12
+
+// openSessions shows every saved session, most recent first
+func (m Model) openSessions() (tea.Model, tea.Cmd) {
+	if m.sessionStore == nil {
+		m.addErrorMessage("Sessions are not saved: no data directory is available")
+		m.updateViewport()
+		return m, nil
+	}
+	summaries, err := m.sessionStore.List(0)
+	if err != nil {
+		m.showError(fmt.Sprintf("Listing sessions: %v", err), false)
+		return m, nil
+	}
+	if len(summaries) == 0 {
+		m.addSystemMessage(FormatInfo("No saved sessions yet", m.config.UI.EnableEmoji))
+		m.updateViewport()
+		return m, nil
+	}
+
+	m.sessionsActive = true
+	m.sessionsList = summaries
+	m.sessionsIndex = 0
+	m.sessionsDeleting = false
+	return m, nil
+}
+
+// handleSessionsKeyPress moves through the saved sessions, resuming or deleting the selected one
+func (m Model) handleSessionsKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	// Deleting asks for confirmation first
+	if m.sessionsDeleting {
+		m.sessionsDeleting = false
+		if msg.String() == "y" || msg.String() == "Y" {
+			return m.deleteSelectedSession()
+		}
+		return m, nil
+	}
+
+	switch msg.String() {
+	case "esc", "ctrl+c", "q":
+		m.sessionsActive = false
+
+	case "up", "k", "shift+tab":
+		m.sessionsIndex = (m.sessionsIndex - 1 + len(m.sessionsList)) % len(m.sessionsList)
+
+	case "down", "j", "tab", "ctrl+n":
+		m.sessionsIndex = (m.sessionsIndex + 1) % len(m.sessionsList)
+
+	case "enter":
+		m.sessionsActive = false
+		return m.resumeSession(m.sessionsList[m.sessionsIndex].ID)
+
+	case "d", "delete":
+		m.sessionsDeleting = true
+	}
+	return m, nil
+}
+
+// deleteSelectedSession removes the selected session from disk and the list.
+// Deleting the current session starts a new one on the next save.
+func (m Model) deleteSelectedSession() (tea.Model, tea.Cmd) {
+	id := m.sessionsList[m.sessionsIndex].ID
+	if err := m.sessionStore.Delete(id); err != nil {
+		m.sessionsActive = false
+		m.showError(err.Error(), false)
+		return m, nil
+	}
+	if m.session != nil && m.session.ID == id {
+		m.session = nil
+	}
+
+	m.sessionsList = append(m.sessionsList[:m.sessionsIndex:m.sessionsIndex], m.sessionsList[m.sessionsIndex+1:]...)
+	m.refreshRecentSessions()
+	if len(m.sessionsList) == 0 {
+		m.sessionsActive = false
+		m.addSystemMessage(FormatInfo("Deleted the last saved session", m.config.UI.EnableEmoji))
+		m.updateViewport()
+		return m, nil
+	}
+	m.sessionsIndex = min(m.sessionsIndex, len(m.sessionsList)-1)
+	return m, nil
+}
+
+// renderSessions renders the session browser
+func (m Model) renderSessions() string {
+	menuStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(SecondaryColor).
+		Padding(1, 2).
+		Width(m.width - 4).
+		Height(m.height - 4)
+
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(SecondaryColor)
+
+	var content strings.Builder
+	content.WriteString(titleStyle.Render(fmt.Sprintf("Sessions (%d)", len(m.sessionsList))))
+	content.WriteString("\n\n")
+
+	// Scroll the list so the selection stays visible
+	start := 0
+	if m.sessionsIndex >= sessionsVisibleItems {
+		start = m.sessionsIndex - sessionsVisibleItems + 1
+	}
+	end := min(start+sessionsVisibleItems, len(m.sessionsList))
+
+	now := time.Now()
+	labelWidth := max(m.width-80, 20)
+	for i := start; i < end; i++ {
+		summary := m.sessionsList[i]
+
+		var line string
+		if i == m.sessionsIndex {
+			line += lipgloss.NewStyle().Foreground(AccentColor).Render("▶ ")
+		} else {
+			line += "  "
+		}
+
+		label := summary.Label()
+		if label == "" {
+			label = "(no messages)"
+		}
+		if m.session != nil && m.session.ID == summary.ID {
+			label += " (current)"
+		}
+		labelStyle := lipgloss.NewStyle().Width(labelWidth + 2)
+		if i == m.sessionsIndex {
+			labelStyle = labelStyle.Bold(true).Foreground(AccentColor)
+		}
+		line += labelStyle.Render(truncate(label, labelWidth))
+		line += HelpStyle.Render(fmt.Sprintf("%-12s %-12s %4d msgs  %7s tokens  $%.4f",
+			summary.UpdatedAt.Format("Jan 2 15:04"),
+			formatRelativeTime(summary.UpdatedAt, now),
+			summary.MessageCount,
+			formatTokenCount(summary.Usage.Tokens()),
+			summary.Usage.Cost,
+		))
+
+		content.WriteString(line + "\n")
+	}
+
+	// The selected session's directory, since sessions from several projects are listed
+	if selected := m.sessionsList[m.sessionsIndex]; selected.WorkingDir != "" {
+		content.WriteString("\n" + HelpStyle.Render("  "+selected.WorkingDir))
+	}
+
+	footer := "\n\n" + HelpStyle.Render("↑/↓ to select • Enter to resume • d to delete • Esc to close")
+	if m.sessionsDeleting {
+		footer = "\n\n" + WarningStyle.Render(fmt.Sprintf("Delete %q? (y/n)", truncate(m.sessionsList[m.sessionsIndex].Label(), 50)))
+	}
+
+	return menuStyle.Render(content.String() + footer)
+}
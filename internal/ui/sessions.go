@@ -0,0 +1,320 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/alchemy-labs-co/riptide/internal/api"
+	"github.com/alchemy-labs-co/riptide/internal/conversation"
+	"github.com/alchemy-labs-co/riptide/internal/session"
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// maxWelcomeSessions is how many recent sessions the welcome screen offers
+const maxWelcomeSessions = 5
+
+// Session titles are a few words from a cheap request after the first exchange
+const (
+	titlePrompt    = "Write a title of at most six words for the conversation below, naming its topic. Reply with the title only, without quotes or punctuation at the end."
+	titleMaxTokens = 30
+	titleTimeout   = 30 * time.Second
+	maxTitleLength = 60
+)
+
+// SessionTitleMsg carries a generated title for a session
+type SessionTitleMsg struct {
+	SessionID string
+	Title     string
+	Usage     api.TokenUsage
+	Err       error
+}
+
+// saveSession writes the conversation to the session store after a turn
+func (m *Model) saveSession() {
+	if m.sessionStore == nil {
+		return
+	}
+	if _, ok := m.history.GetLastUserMessage(); !ok {
+		return
+	}
+
+	if m.session == nil {
+		cwd, err := os.Getwd()
+		if err != nil {
+			cwd = ""
+		}
+		m.session = session.New(cwd, m.config.API.Model)
+		m.sessionBase = session.Usage{}
+	}
+
+	// The history counts tokens since the session was started or resumed
+	stats := m.history.GetStats()
+	m.session.Usage = m.sessionBase.Add(session.Usage{
+		InputTokens:  stats.InputTokens,
+		OutputTokens: stats.OutputTokens,
+		CachedTokens: stats.CachedTokens,
+		Cost:         m.calculateTotalCost(stats),
+	})
+
+	if err := m.sessionStore.Save(m.session, m.history.GetRawMessages()); err != nil {
+		m.showError(fmt.Sprintf("Saving session: %v", err), false)
+	}
+}
+
+// refreshRecentSessions reloads the sessions listed on the welcome screen
+func (m *Model) refreshRecentSessions() {
+	if m.sessionStore == nil {
+		return
+	}
+	summaries, err := m.sessionStore.List(maxWelcomeSessions)
+	if err != nil {
+		return
+	}
+	m.recentSessions = summaries
+}
+
+// resumeSession restores a saved session into the history and transcript
+func (m Model) resumeSession(id string) (tea.Model, tea.Cmd) {
+	if m.sessionStore == nil {
+		return m, nil
+	}
+
+	sess, err := m.sessionStore.Load(id)
+	if err != nil {
+		m.showError(fmt.Sprintf("Resuming session: %v", err), false)
+		return m, nil
+	}
+	m.restoreSession(sess)
+	return m, nil
+}
+
+// Resume restores the saved session a query names before the program starts,
+// for riptide --resume; an empty query picks the most recent session
+func (m *Model) Resume(query string) error {
+	if m.sessionStore == nil {
+		return errors.New("sessions are not saved: no data directory is available")
+	}
+	summary, err := m.sessionStore.Find(query)
+	if err != nil {
+		return err
+	}
+	sess, err := m.sessionStore.Load(summary.ID)
+	if err != nil {
+		return err
+	}
+	m.restoreSession(sess)
+	return nil
+}
+
+// handleResumeCommand resumes the session named by an ID prefix or part of its
+// title, or the most recent other session when query is empty
+func (m Model) handleResumeCommand(query string) (tea.Model, tea.Cmd) {
+	m.textInput.SetValue("")
+	if m.sessionStore == nil {
+		m.addErrorMessage("Sessions are not saved: no data directory is available")
+		m.updateViewport()
+		return m, nil
+	}
+
+	if query == "" && m.session != nil {
+		// The current session is the most recent, so resume the one before it
+		summaries, err := m.sessionStore.List(0)
+		if err != nil {
+			m.showError(fmt.Sprintf("Listing sessions: %v", err), false)
+			return m, nil
+		}
+		for _, summary := range summaries {
+			if summary.ID != m.session.ID {
+				return m.resumeSession(summary.ID)
+			}
+		}
+		m.showError("Resuming session: no other saved sessions", false)
+		return m, nil
+	}
+
+	summary, err := m.sessionStore.Find(query)
+	if err != nil {
+		m.showError(fmt.Sprintf("Resuming session: %v", err), false)
+		return m, nil
+	}
+	return m.resumeSession(summary.ID)
+}
+
+// restoreSession replaces the history and transcript with a saved session's
+func (m *Model) restoreSession(sess *session.Session) {
+	m.history.Restore(sess.Messages)
+	m.session = sess
+	m.sessionBase = sess.Usage
+	m.turn = m.history.GetStats().UserMessages
+	m.editingPrompt = 0
+	m.messages = transcriptFromHistory(sess.Messages)
+	m.showWelcome = false
+	m.textInput.SetValue("")
+	m.addSystemMessage(FormatInfo(fmt.Sprintf("Resumed session from %s (%d messages)",
+		formatRelativeTime(sess.UpdatedAt, time.Now()), len(sess.Messages)), m.config.UI.EnableEmoji))
+
+	m.viewport.SetContent(m.renderMessages())
+	m.viewport.GotoBottom()
+}
+
+// transcriptFromHistory rebuilds the displayed transcript from saved history messages
+func transcriptFromHistory(messages []api.ConversationMessage) []Message {
+	var transcript []Message
+	toolCalls := make(map[string]api.ToolCall)
+	// Restoring puts the current system prompt in place of the saved one, or
+	// in front when none was saved
+	offset := 1
+	if len(messages) > 0 && messages[0].Role == "system" && messages[0].FilePath == "" {
+		offset = 0
+	}
+	for i, msg := range messages {
+		switch msg.Role {
+		case "user":
+			transcript = append(transcript, Message{Role: "user", Content: msg.Content, Timestamp: msg.Timestamp, History: i + offset})
+
+		case "assistant":
+			for _, toolCall := range msg.ToolCalls {
+				toolCalls[toolCall.ID] = toolCall
+			}
+			if msg.ReasoningContent != "" {
+				transcript = append(transcript,
+					Message{
+						Role:      "reasoning-label",
+						Timestamp: msg.Timestamp,
+						Duration:  time.Duration(msg.ReasoningMillis) * time.Millisecond,
+					},
+					Message{Role: "reasoning", Content: msg.ReasoningContent, Timestamp: msg.Timestamp, Tokens: conversation.EstimateTokens(msg.ReasoningContent)},
+				)
+			}
+			if msg.Content != "" {
+				transcript = append(transcript,
+					Message{Role: "assistant-label", Timestamp: msg.Timestamp},
+					Message{Role: "content", Content: msg.Content, Timestamp: msg.Timestamp},
+				)
+			}
+
+		case "tool":
+			// Durations are not saved, so restored cards leave them out
+			toolCall, ok := toolCalls[msg.ToolCallID]
+			if !ok {
+				break
+			}
+			status := newToolStatuses([]api.ToolCall{toolCall})[0]
+			status.State, status.Result = ToolSucceeded, msg.Content
+			if errText, failed := strings.CutPrefix(msg.Content, "Error: "); failed {
+				status.State, status.Error = ToolFailed, errText
+			}
+			transcript = append(transcript, Message{Role: "tool", Tool: &status, Timestamp: msg.Timestamp})
+
+		case "system":
+			// Only files added to context are shown; the system prompt stays hidden
+			if msg.FilePath != "" {
+				transcript = append(transcript, Message{
+					Role:      "system",
+					Content:   fmt.Sprintf("Added file '%s' to conversation", FormatFilePath(msg.FilePath)),
+					Timestamp: msg.Timestamp,
+				})
+			}
+		}
+	}
+	return transcript
+}
+
+// welcomeSessionsVisible reports whether the number keys resume recent sessions
+func (m Model) welcomeSessionsVisible() bool {
+	return m.showWelcome && len(m.messages) == 0 && len(m.recentSessions) > 0
+}
+
+// renderRecentSessions renders the numbered recent-session list for the welcome screen
+func (m Model) renderRecentSessions() string {
+	now := time.Now()
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("Recent sessions %s", HelpStyle.Render(fmt.Sprintf("(press 1-%d to resume)", len(m.recentSessions)))))
+	for i, summary := range m.recentSessions {
+		preview := summary.Label()
+		if preview == "" {
+			preview = "(no messages)"
+		}
+		b.WriteString(fmt.Sprintf("\n  %s %s %s",
+			InfoStyle.Render(fmt.Sprintf("%d", i+1)),
+			truncate(preview, 50),
+			HelpStyle.Render(formatRelativeTime(summary.UpdatedAt, now)),
+		))
+	}
+	return b.String()
+}
+
+// titleSession asks the title model for a short title once the session's first
+// exchange has an answer. It is tried once per session; until it succeeds the
+// session is listed by its first prompt.
+func (m *Model) titleSession() tea.Cmd {
+	model := m.config.API.TitleModel
+	if m.session == nil || m.session.Title != "" || model == "" || m.titledSession == m.session.ID {
+		return nil
+	}
+	prompt, answer := firstExchange(m.history.GetRawMessages())
+	if prompt == "" || answer == "" {
+		return nil
+	}
+	provider, ok := m.apiClient.(api.OverridableProvider)
+	if !ok {
+		return nil
+	}
+	m.titledSession = m.session.ID
+
+	id := m.session.ID
+	titler := provider.WithOverrides(api.Overrides{Model: model, MaxTokens: titleMaxTokens, NoTools: true})
+	messages := []openai.ChatCompletionMessage{
+		{Role: openai.ChatMessageRoleSystem, Content: titlePrompt},
+		{Role: openai.ChatMessageRoleUser, Content: fmt.Sprintf("User: %s\n\nAssistant: %s", truncate(prompt, 2000), truncate(answer, 2000))},
+	}
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), titleTimeout)
+		defer cancel()
+		events, err := titler.CreateChatCompletionStream(ctx, messages)
+		if err != nil {
+			return SessionTitleMsg{SessionID: id, Err: err}
+		}
+		title, usage, err := collectAnswer(ctx, events)
+		return SessionTitleMsg{SessionID: id, Title: cleanTitle(title), Usage: usage, Err: err}
+	}
+}
+
+// handleSessionTitle stores a generated title with the session it was made for
+func (m Model) handleSessionTitle(msg SessionTitleMsg) (tea.Model, tea.Cmd) {
+	if msg.Err != nil || msg.Title == "" || m.session == nil || m.session.ID != msg.SessionID {
+		return m, nil
+	}
+	m.session.Title = msg.Title
+	m.history.UpdateTokenUsage(msg.Usage.InputTokens, msg.Usage.OutputTokens, msg.Usage.CachedTokens)
+	m.saveSession()
+	return m, nil
+}
+
+// firstExchange returns the first prompt and the first answer with text
+func firstExchange(messages []api.ConversationMessage) (string, string) {
+	prompt := ""
+	for _, msg := range messages {
+		switch {
+		case msg.Role == "user" && prompt == "":
+			prompt = msg.Content
+		case msg.Role == "assistant" && prompt != "" && msg.Content != "":
+			return prompt, msg.Content
+		}
+	}
+	return prompt, ""
+}
+
+// cleanTitle keeps the first line of a generated title without quotes or a label
+func cleanTitle(title string) string {
+	title, _, _ = strings.Cut(strings.TrimSpace(title), "\n")
+	title = strings.TrimPrefix(title, "Title:")
+	title = strings.Trim(strings.TrimSpace(title), "\"'`*#.")
+	return truncate(strings.Join(strings.Fields(title), " "), maxTitleLength)
+}
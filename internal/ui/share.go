@@ -0,0 +1,131 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/alchemy-labs-co/riptide/internal/api"
+	"github.com/alchemy-labs-co/riptide/internal/share"
+)
+
+// shareTimeout bounds uploading a gist
+const shareTimeout = 30 * time.Second
+
+// shareTitleLength caps the first user message used as the export title
+const shareTitleLength = 80
+
+// handleShareCommand exports the conversation as an HTML file in the workspace
+// or as a secret gist. Secrets are always redacted, even with /redact off,
+// since the export is meant for other people.
+func (m Model) handleShareCommand(target string) (tea.Model, tea.Cmd) {
+	target = strings.ToLower(strings.TrimSpace(target))
+	if target == "" {
+		target = "html"
+	}
+	if target != "html" && target != "gist" {
+		m.addErrorMessage("Usage: /share [html|gist]")
+		m.updateViewport()
+		return m, nil
+	}
+
+	messages := m.history.GetRawMessages()
+	if _, ok := m.history.GetLastUserMessage(); !ok {
+		m.addErrorMessage("Nothing to share yet")
+		m.updateViewport()
+		return m, nil
+	}
+
+	token := share.Token()
+	if target == "gist" && token == "" {
+		m.addErrorMessage("Set GITHUB_TOKEN or GH_TOKEN (with the gist scope) to share as a gist, or use /share html")
+		m.updateViewport()
+		return m, nil
+	}
+
+	name := time.Now().Format("20060102-150405")
+	if m.session != nil {
+		name = m.session.ID
+	}
+
+	redacted := 0
+	transcript := m.newTranscript(messages, &redacted)
+
+	m.state = StateProcessing
+	enableEmoji := m.config.UI.EnableEmoji
+
+	return m, func() tea.Msg {
+		var result string
+		switch target {
+		case "gist":
+			ctx, cancel := context.WithTimeout(context.Background(), shareTimeout)
+			defer cancel()
+			url, err := transcript.CreateGist(ctx, token, "riptide-"+name)
+			if err != nil {
+				return ProcessCompleteMsg{Error: err}
+			}
+			result = FormatSuccess("Shared as a secret gist: "+url, enableEmoji)
+
+		default:
+			path, err := transcript.WriteHTML(filepath.Join(m.workspaceRoot, share.Dir), name)
+			if err != nil {
+				return ProcessCompleteMsg{Error: err}
+			}
+			result = FormatSuccess("Saved the conversation to "+FormatFilePath(path), enableEmoji)
+		}
+
+		if redacted > 0 {
+			result += "\n" + FormatInfo(fmt.Sprintf("Redacted %d secrets from the export", redacted), enableEmoji)
+		}
+		return ProcessCompleteMsg{Result: result}
+	}
+}
+
+// newTranscript prepares the conversation for export, redacting secrets and
+// counting them in redacted
+func (m Model) newTranscript(messages []api.ConversationMessage, redacted *int) share.Transcript {
+	return share.Transcript{
+		Title:    shareTitle(messages, m.redactTitle),
+		Model:    m.config.API.Model,
+		Created:  messages[0].Timestamp,
+		Messages: messages,
+		Redact: func(text string) string {
+			if m.redactor == nil {
+				return text
+			}
+			text, redaction := m.redactor.Redact(text)
+			*redacted += redaction.Count
+			return text
+		},
+	}
+}
+
+// redactTitle redacts secrets from the export title, which repeats part of a
+// message already counted in the body
+func (m Model) redactTitle(text string) string {
+	if m.redactor == nil {
+		return text
+	}
+	text, _ = m.redactor.Redact(text)
+	return text
+}
+
+// shareTitle names an export after the first user message, redacted before it
+// is shortened so a secret is not cut past recognition
+func shareTitle(messages []api.ConversationMessage, redact func(string) string) string {
+	for _, msg := range messages {
+		if msg.Role != "user" {
+			continue
+		}
+		text := strings.Join(strings.Fields(redact(msg.Content)), " ")
+		if runes := []rune(text); len(runes) > shareTitleLength {
+			text = string(runes[:shareTitleLength-1]) + "…"
+		}
+		return text
+	}
+	return "Riptide conversation"
+}
@@ -1,11 +1,15 @@
 package ui
 
 import (
-	"context"
 	"fmt"
+	"strings"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/alchemy-labs-co/riptide/internal/api"
+	"github.com/alchemy-labs-co/riptide/internal/config"
+	"github.com/alchemy-labs-co/riptide/internal/conversation"
+	openai "github.com/sashabaranov/go-openai"
 )
 
 // StreamManager handles the streaming integration between API and UI
@@ -30,41 +34,66 @@ type ExecuteToolsMsg struct {
 	ToolCalls []api.ToolCall
 }
 
-// handleFollowUp processes the follow-up after tool execution
+// handleFollowUp sends the results of a round of tool calls back to the model,
+// which answers or asks for another round. After max_iterations rounds the
+// loop stops and the turn ends, leaving the results for the next prompt.
 func (m Model) handleFollowUp() (tea.Model, tea.Cmd) {
-	m.state = StateStreaming
-	m.currentContent = ""
-	m.isReasoning = false
-	m.accumulatedContent = ""
-	m.hasContent = false
-	m.pendingToolCalls = nil
-
-	// Create new context
-	ctx, cancel := context.WithCancel(context.Background())
-	m.streamCtx = ctx
-	m.streamCancel = cancel
+	m.toolRounds++
+	if m.reachedMaxIterations() {
+		m.state = StateReady
+		m.addSystemMessage(FormatWarning(fmt.Sprintf("Stopped after round %d of tool calls, the max_iterations limit; send \"continue\" to let the model carry on", m.toolRounds), m.config.UI.EnableEmoji))
+		m.saveSession()
+		m.updateViewport()
+		return m, tea.Batch(m.postTurnHooks(), m.titleSession())
+	}
 
 	// Add a system message indicating we're processing results
 	m.addSystemMessage("Processing results...")
 
-	// Get messages from history (includes tool responses)
-	messages := m.history.GetMessages()
+	// Stream the follow-up from history (includes tool responses)
+	return m.openStream()
+}
 
-	// Create stream for follow-up
-	eventChan, err := m.apiClient.CreateChatCompletionStream(ctx, messages)
-	if err != nil {
-		m.state = StateError
-		m.addErrorMessage(fmt.Sprintf("Failed to create follow-up stream: %v", err))
-		return m, nil
+// reachedMaxIterations reports whether the current prompt has run as many
+// rounds of tool calls as max_iterations allows
+func (m Model) reachedMaxIterations() bool {
+	limit := m.config.Permissions.MaxIterations
+	return limit > 0 && m.toolRounds >= limit
+}
+
+// iterationStatus shows which request of the current prompt is in progress once
+// the model has called tools, for the status line
+func (m Model) iterationStatus() string {
+	if m.toolRounds == 0 {
+		return ""
+	}
+	if limit := m.config.Permissions.MaxIterations; limit > 0 {
+		return fmt.Sprintf("step %d of %d", m.toolRounds+1, limit)
 	}
+	return fmt.Sprintf("step %d", m.toolRounds+1)
+}
 
-	// Store the event channel
-	m.streamEvents = eventChan
+// requestMessages trims the history to the token budget and returns the messages
+// for the next API request, followed by a freshly built ambient state reminder
+func (m Model) requestMessages() []openai.ChatCompletionMessage {
+	m.refreshChangedContext()
+	m.history.Trim()
+	messages := m.history.GetMessages()
+
+	if reminder := conversation.BuildAmbientReminder(m.config.Ambient); reminder != "" {
+		messages = append(messages, openai.ChatCompletionMessage{
+			Role:    openai.ChatMessageRoleSystem,
+			Content: reminder,
+		})
+	}
+	if m.config.Permissions.Mode == config.ModeReadOnly {
+		messages = append(messages, openai.ChatCompletionMessage{
+			Role:    openai.ChatMessageRoleSystem,
+			Content: readOnlyReminder,
+		})
+	}
 
-	return m, tea.Batch(
-		m.nextStreamMsg(),
-		m.spinner.Tick,
-	)
+	return messages
 }
 
 // Attach attaches the stream manager to a model
@@ -86,3 +115,167 @@ func formatToolResult(success bool, message string, enableEmoji bool) string {
 	}
 	return FormatError(message, enableEmoji)
 }
+
+const (
+	// streamFlushInterval is how long deltas are gathered before the transcript is
+	// redrawn, so a fast stream does not redraw it for every token
+	streamFlushInterval = 50 * time.Millisecond
+
+	// streamFlushBytes ends a batch early once this much text has been gathered
+	streamFlushBytes = 4096
+)
+
+// isStreamDelta reports whether an event only adds text to the message being streamed
+func isStreamDelta(event api.StreamEvent) bool {
+	return event.Type == api.EventTypeContent || event.Type == api.EventTypeReasoning
+}
+
+// gatherStreamEvents collects the deltas that arrive within streamFlushInterval
+// of first, up to streamFlushBytes of text, so they are drawn together. The
+// batch ends early at an event that is not a delta, which is included, and when
+// the stream closes or is cancelled.
+func (m Model) gatherStreamEvents(first api.StreamEvent) []api.StreamEvent {
+	events := []api.StreamEvent{first}
+	if !isStreamDelta(first) {
+		return events
+	}
+
+	size := len(first.Content) + len(first.ReasoningContent)
+	timer := time.NewTimer(streamFlushInterval)
+	defer timer.Stop()
+	for size < streamFlushBytes {
+		select {
+		case <-m.streamCtx.Done():
+			return events
+		case <-timer.C:
+			return events
+		case event, ok := <-m.streamEvents:
+			if !ok {
+				return events
+			}
+			events = append(events, event)
+			if !isStreamDelta(event) {
+				return events
+			}
+			size += len(event.Content) + len(event.ReasoningContent)
+		}
+	}
+	return events
+}
+
+// streamRender is the rendered transcript before the message being streamed,
+// which stays the same while deltas arrive
+type streamRender struct {
+	count int // Messages rendered
+	width int // Viewport width they were rendered for
+	text  string
+}
+
+// updateStreamingViewport redraws the transcript after a batch of deltas. Only
+// the message being streamed is rendered again, with the reasoning label whose
+// token count follows it; the messages before it are reused from the last
+// redraw until their number or the width changes, and an answer's complete
+// markdown blocks are reused as well.
+func (m *Model) updateStreamingViewport() {
+	stable := len(m.messages) - 1
+	if stable > 0 && m.messages[stable-1].Role == "reasoning-label" {
+		stable--
+	}
+	if stable < 1 {
+		m.updateViewport()
+		return
+	}
+
+	if m.answerRender == nil {
+		m.answerRender = &markdownStream{}
+	}
+
+	now := time.Now()
+	if r := m.streamRender; r == nil || r.count != stable || r.width != m.viewport.Width {
+		m.streamRender = &streamRender{
+			count: stable,
+			width: m.viewport.Width,
+			text:  m.renderMessageList(m.messages[:stable], "", now),
+		}
+	}
+	tail := m.renderMessageList(m.messages[stable:], m.messages[stable-1].Role, now)
+	m.setViewportContent(m.streamRender.text + tail + messagesPadding)
+}
+
+// streamingAnswer reports whether answer text is arriving for the last content
+// message; once the stream ends or stops the message is rendered in full
+func (m Model) streamingAnswer() bool {
+	return m.currentContent != "" && !m.isReasoning
+}
+
+// markdownStream renders an answer while it streams. Blocks that are complete
+// (paragraphs ended by a blank line, prose ended by a code fence, and closed
+// code blocks) are rendered once and kept, so only the block still being
+// written is rendered again for each batch of deltas. A line that could open a
+// fence, and the unfinished line of a code block, are held back until their
+// newline arrives, so the block is never drawn half parsed.
+type markdownStream struct {
+	width   int
+	settled string   // Start of the answer whose blocks are complete
+	blocks  []string // Those blocks, rendered
+}
+
+// render returns text rendered as markdown, reusing the blocks rendered for an
+// earlier, shorter version of it
+func (s *markdownStream) render(text string, width int, palette codePalette) string {
+	if width != s.width || !strings.HasPrefix(text, s.settled) {
+		*s = markdownStream{width: width}
+	}
+
+	// The last line has no newline yet and may still change
+	lines := strings.Split(text[len(s.settled):], "\n")
+	partial := lines[len(lines)-1]
+	lines = lines[:len(lines)-1]
+
+	start, fence := 0, ""
+	for i, line := range lines {
+		match := codeFence.FindStringSubmatch(line)
+		switch {
+		case fence == "" && match != nil:
+			s.settle(lines[start:i], palette)
+			start, fence = i, match[1]
+		case fence != "" && strings.TrimSpace(line) == fence:
+			s.settle(lines[start:i+1], palette)
+			start, fence = i+1, ""
+		case fence == "" && strings.TrimSpace(line) == "":
+			s.settle(lines[start:i+1], palette)
+			start = i + 1
+		}
+	}
+
+	pending := append([]string(nil), lines[start:]...)
+	if fence == "" && partial != "" && !codeFence.MatchString(partial) {
+		pending = append(pending, partial)
+	}
+	out := s.blocks
+	if len(pending) > 0 {
+		out = append(out[:len(out):len(out)], renderMarkdown(strings.Join(pending, "\n"), width, palette))
+	}
+	return strings.Join(out, "\n")
+}
+
+// settle renders a complete block and adds it to the ones kept
+func (s *markdownStream) settle(lines []string, palette codePalette) {
+	if len(lines) == 0 {
+		return
+	}
+	block := strings.Join(lines, "\n")
+	s.blocks = append(s.blocks, renderMarkdown(block, s.width, palette))
+	s.settled += block + "\n"
+}
+
+// retryStatus describes the retry in progress: which attempt is next, how long
+// until it starts and what went wrong with the last one
+func (m Model) retryStatus() string {
+	retry := m.streamRetry
+	status := fmt.Sprintf("Retrying (attempt %d of %d)", retry.Attempt, retry.MaxAttempts)
+	if wait := time.Until(m.streamRetryAt).Round(time.Second); wait > 0 {
+		status += fmt.Sprintf(" in %s", wait)
+	}
+	return status + " after " + truncate(retry.Err.Error(), max(m.width/3, 30))
+}
@@ -4,146 +4,252 @@ import (
 	"github.com/charmbracelet/lipgloss"
 )
 
-// Color palette
+// activeTheme is the palette the colors and styles below were built from
+var activeTheme = defaultTheme
+
+// Color palette, set from the active theme
 var (
 	// Primary colors
-	PrimaryColor   = lipgloss.Color("#0066ff")
-	SecondaryColor = lipgloss.Color("#3b82f6")
-	AccentColor    = lipgloss.Color("#00d4ff")
+	PrimaryColor   lipgloss.Color
+	SecondaryColor lipgloss.Color
+	AccentColor    lipgloss.Color
 
 	// Status colors
-	SuccessColor = lipgloss.Color("#10b981")
-	WarningColor = lipgloss.Color("#f59e0b")
-	ErrorColor   = lipgloss.Color("#ef4444")
+	SuccessColor lipgloss.Color
+	WarningColor lipgloss.Color
+	ErrorColor   lipgloss.Color
 
 	// Text colors
-	BrightCyan   = lipgloss.Color("#00ffff")
-	DimTextColor = lipgloss.Color("#6b7280")
-	WhiteColor   = lipgloss.Color("#ffffff")
-	LinkColor    = lipgloss.Color("#2e7de9")
+	BrightCyan      lipgloss.Color // File paths
+	DimTextColor    lipgloss.Color
+	MutedTextColor  lipgloss.Color
+	WhiteColor      lipgloss.Color // Emphasized text; dark in light themes
+	LinkColor       lipgloss.Color
+	ReasoningColor  lipgloss.Color
+	CursorTextColor lipgloss.Color
 
 	// Background colors
-	DarkBgColor  = lipgloss.Color("#1e3a8a")
-	LightBgColor = lipgloss.Color("#e0f2fe")
+	DarkBgColor  lipgloss.Color
+	LightBgColor lipgloss.Color
 )
 
-// Styles for different UI elements
+// Styles for different UI elements, set from the active theme
 var (
 	// Title and header styles
+	TitleStyle     lipgloss.Style
+	HeaderStyle    lipgloss.Style
+	SubheaderStyle lipgloss.Style
+
+	// Panel styles
+	PanelStyle        lipgloss.Style
+	WelcomePanelStyle lipgloss.Style
+
+	// Message styles
+	UserPromptStyle       lipgloss.Style
+	AssistantLabelStyle   lipgloss.Style
+	ReasoningLabelStyle   lipgloss.Style
+	ReasoningContentStyle lipgloss.Style
+
+	// Status styles
+	SuccessStyle lipgloss.Style
+	WarningStyle lipgloss.Style
+	ErrorStyle   lipgloss.Style
+	InfoStyle    lipgloss.Style
+
+	// ErrorBannerStyle renders the dismissible error banner above the input
+	ErrorBannerStyle lipgloss.Style
+
+	// File operation styles
+	FilePathStyle lipgloss.Style
+	FileIconStyle lipgloss.Style
+
+	// Content styles
+	ContentStyle    lipgloss.Style
+	CodeBlockStyle  lipgloss.Style
+	InlineCodeStyle lipgloss.Style
+
+	// Input styles
+	InputStyle lipgloss.Style
+
+	// Spinner styles
+	SpinnerStyle lipgloss.Style
+
+	// Help styles
+	HelpStyle lipgloss.Style
+
+	// Table styles for diffs
+	TableHeaderStyle lipgloss.Style
+	TableCellStyle   lipgloss.Style
+	DiffOldStyle     lipgloss.Style
+	DiffNewStyle     lipgloss.Style
+
+	// Search match styles; other matches are shown in reverse video so they
+	// stand out in any theme
+	SearchMatchStyle   lipgloss.Style
+	SearchCurrentStyle lipgloss.Style
+
+	// codeStyles colors highlighted code
+	codeStyles codePalette
+)
+
+func init() {
+	buildStyles()
+}
+
+// buildStyles sets the colors and styles from the active theme
+func buildStyles() {
+	t := activeTheme
+
+	PrimaryColor = lipgloss.Color(t.Primary)
+	SecondaryColor = lipgloss.Color(t.Secondary)
+	AccentColor = lipgloss.Color(t.Accent)
+
+	SuccessColor = lipgloss.Color(t.Success)
+	WarningColor = lipgloss.Color(t.Warning)
+	ErrorColor = lipgloss.Color(t.Error)
+
+	BrightCyan = lipgloss.Color(t.FilePath)
+	DimTextColor = lipgloss.Color(t.DimText)
+	MutedTextColor = lipgloss.Color(t.MutedText)
+	WhiteColor = lipgloss.Color(t.Text)
+	LinkColor = lipgloss.Color(t.Link)
+	ReasoningColor = lipgloss.Color(t.Reasoning)
+	CursorTextColor = lipgloss.Color(t.CursorText)
+
+	DarkBgColor = lipgloss.Color(t.HeaderBackground)
+	LightBgColor = lipgloss.Color(t.HighlightBackground)
+
 	TitleStyle = lipgloss.NewStyle().
-			Bold(true).
-			Foreground(PrimaryColor).
-			MarginBottom(1)
+		Bold(true).
+		Foreground(PrimaryColor).
+		MarginBottom(1)
 
 	HeaderStyle = lipgloss.NewStyle().
-			Bold(true).
-			Foreground(SecondaryColor).
-			Padding(0, 2)
+		Bold(true).
+		Foreground(SecondaryColor).
+		Padding(0, 2)
 
 	SubheaderStyle = lipgloss.NewStyle().
-			Bold(true).
-			Foreground(SecondaryColor)
+		Bold(true).
+		Foreground(SecondaryColor)
 
-	// Panel styles
 	PanelStyle = lipgloss.NewStyle().
-			Border(lipgloss.RoundedBorder()).
-			BorderForeground(SecondaryColor).
-			Padding(1, 2)
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(SecondaryColor).
+		Padding(1, 2)
 
 	WelcomePanelStyle = lipgloss.NewStyle().
-				Border(lipgloss.RoundedBorder()).
-				BorderForeground(SecondaryColor). // Use our light blue
-				PaddingLeft(10).
-				PaddingRight(10).
-				PaddingTop(1).
-				PaddingBottom(1)
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(SecondaryColor).
+		PaddingLeft(10).
+		PaddingRight(10).
+		PaddingTop(1).
+		PaddingBottom(1)
 
-	// Message styles
 	UserPromptStyle = lipgloss.NewStyle().
-			Bold(true).
-			Foreground(PrimaryColor)
+		Bold(true).
+		Foreground(PrimaryColor)
 
 	AssistantLabelStyle = lipgloss.NewStyle().
-				Bold(true).
-				Foreground(SecondaryColor)
+		Bold(true).
+		Foreground(SecondaryColor)
 
 	ReasoningLabelStyle = lipgloss.NewStyle().
-				Bold(true).
-				Foreground(lipgloss.Color("#60a5fa"))
-	
+		Bold(true).
+		Foreground(ReasoningColor)
+
 	ReasoningContentStyle = lipgloss.NewStyle().
-				Foreground(lipgloss.Color("#60a5fa"))
+		Foreground(ReasoningColor)
 
-	// Status styles
 	SuccessStyle = lipgloss.NewStyle().
-			Bold(true).
-			Foreground(SuccessColor)
+		Bold(true).
+		Foreground(SuccessColor)
 
 	WarningStyle = lipgloss.NewStyle().
-			Bold(true).
-			Foreground(WarningColor)
+		Bold(true).
+		Foreground(WarningColor)
 
 	ErrorStyle = lipgloss.NewStyle().
-			Bold(true).
-			Foreground(ErrorColor)
+		Bold(true).
+		Foreground(ErrorColor)
 
 	InfoStyle = lipgloss.NewStyle().
-			Bold(true).
-			Foreground(AccentColor)
+		Bold(true).
+		Foreground(AccentColor)
+
+	ErrorBannerStyle = lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color(t.BannerText)).
+		Background(ErrorColor).
+		Padding(0, 1)
 
-	// File operation styles
 	FilePathStyle = lipgloss.NewStyle().
-			Foreground(BrightCyan)
+		Foreground(BrightCyan)
 
 	FileIconStyle = lipgloss.NewStyle().
-			Foreground(AccentColor)
+		Foreground(AccentColor)
 
-	// Content styles
 	ContentStyle = lipgloss.NewStyle().
-			PaddingLeft(2)
+		PaddingLeft(2)
 
 	CodeBlockStyle = lipgloss.NewStyle().
-			Background(lipgloss.Color("#1f2937")).
-			Foreground(WhiteColor).
-			Padding(1).
-			MarginTop(1).
-			MarginBottom(1)
+		Background(lipgloss.Color(t.CodeBackground)).
+		Foreground(WhiteColor).
+		Padding(1).
+		MarginTop(1).
+		MarginBottom(1)
 
 	InlineCodeStyle = lipgloss.NewStyle().
-			Background(lipgloss.Color("#374151")).
-			Foreground(lipgloss.Color("#f9fafb")).
-			Padding(0, 1)
+		Background(lipgloss.Color(t.InlineCodeBackground)).
+		Foreground(lipgloss.Color(t.InlineCodeText)).
+		Padding(0, 1)
 
-	// Input styles
 	InputStyle = lipgloss.NewStyle().
-			Foreground(PrimaryColor).
-			Bold(true)
+		Foreground(PrimaryColor).
+		Bold(true)
 
-	// Spinner styles
 	SpinnerStyle = lipgloss.NewStyle().
-			Foreground(AccentColor)
+		Foreground(AccentColor)
 
-	// Help styles
 	HelpStyle = lipgloss.NewStyle().
-			Foreground(DimTextColor)
+		Foreground(DimTextColor)
 
-	// Table styles for diffs
 	TableHeaderStyle = lipgloss.NewStyle().
-				Bold(true).
-				Foreground(SecondaryColor).
-				Background(DarkBgColor).
-				Padding(0, 1)
+		Bold(true).
+		Foreground(SecondaryColor).
+		Background(DarkBgColor).
+		Padding(0, 1)
 
 	TableCellStyle = lipgloss.NewStyle().
-			Padding(0, 1)
+		Padding(0, 1)
 
 	DiffOldStyle = lipgloss.NewStyle().
-			Foreground(ErrorColor).
-			Faint(true)
+		Foreground(ErrorColor).
+		Faint(true)
 
 	DiffNewStyle = lipgloss.NewStyle().
-			Foreground(SuccessColor)
-)
+		Foreground(SuccessColor)
+
+	SearchMatchStyle = lipgloss.NewStyle().
+		Reverse(true)
+
+	SearchCurrentStyle = lipgloss.NewStyle().
+		Bold(true).
+		Foreground(CursorTextColor).
+		Background(WarningColor)
+
+	codeStyles = codePalette{
+		tokenKeyword:  lipgloss.NewStyle().Foreground(lipgloss.Color(t.CodeKeyword)),
+		tokenType:     lipgloss.NewStyle().Foreground(lipgloss.Color(t.CodeType)),
+		tokenString:   lipgloss.NewStyle().Foreground(lipgloss.Color(t.CodeString)),
+		tokenComment:  lipgloss.NewStyle().Foreground(lipgloss.Color(t.CodeComment)).Italic(true),
+		tokenNumber:   lipgloss.NewStyle().Foreground(lipgloss.Color(t.CodeNumber)),
+		tokenFunction: lipgloss.NewStyle().Foreground(lipgloss.Color(t.CodeFunction)),
+		tokenInserted: lipgloss.NewStyle().Foreground(lipgloss.Color(t.CodeInserted)),
+		tokenDeleted:  lipgloss.NewStyle().Foreground(lipgloss.Color(t.CodeDeleted)),
+		tokenHunk:     lipgloss.NewStyle().Foreground(lipgloss.Color(t.CodeHunk)),
+	}
+}
 
 // GetIcon returns an icon with optional emoji support
 func GetIcon(iconType string, enableEmoji bool) string {
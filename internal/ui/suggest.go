@@ -0,0 +1,140 @@
+package ui
+
+import (
+	"io/fs"
+	"path/filepath"
+	"strings"
+
+	"github.com/alchemy-labs-co/riptide/internal/config"
+	"github.com/alchemy-labs-co/riptide/internal/functions"
+)
+
+// maxSuggestionPaths caps how many workspace paths are compared with a path
+// that doesn't exist, so a suggestion stays instant in large trees
+const maxSuggestionPaths = 20000
+
+// suggestCommand returns the command line to run instead of an unknown
+// command, keeping its arguments, or "" when nothing is close enough
+func suggestCommand(input string) string {
+	name, args, _ := strings.Cut(input, " ")
+	names := make([]string, len(availableCommands))
+	for i, cmd := range availableCommands {
+		names[i] = cmd.Name
+	}
+
+	name = strings.ToLower(name)
+	match := closestMatch(name, names, strings.ToLower)
+	if match == "" {
+		// Abbreviations such as /cfg match as subsequences
+		match = fuzzyMatch(name, names)
+	}
+	if match == "" {
+		return ""
+	}
+	if args = strings.TrimSpace(args); args != "" {
+		return match + " " + args
+	}
+	return match
+}
+
+// suggestPath returns the file or directory under root closest to a path that
+// doesn't exist, relative to root, or "" when nothing is close enough
+func suggestPath(root, path string) string {
+	path = filepath.Clean(strings.TrimSpace(path))
+	if filepath.IsAbs(path) {
+		rel, err := filepath.Rel(root, path)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			return ""
+		}
+		path = rel
+	}
+
+	excluded := config.GetExcludedFiles()
+	var candidates []string
+	_ = filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || p == root {
+			return nil
+		}
+		if functions.IsHiddenFile(d.Name()) || excluded[d.Name()] {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if rel, err := filepath.Rel(root, p); err == nil {
+			candidates = append(candidates, rel)
+		}
+		if len(candidates) >= maxSuggestionPaths {
+			return filepath.SkipAll
+		}
+		return nil
+	})
+
+	// Try the whole path first, then the file name alone for a file that is
+	// in another directory
+	if match := closestMatch(strings.ToLower(path), candidates, strings.ToLower); match != "" {
+		return match
+	}
+	return closestMatch(strings.ToLower(filepath.Base(path)), candidates, func(candidate string) string {
+		return strings.ToLower(filepath.Base(candidate))
+	})
+}
+
+// closestMatch returns the candidate within a few edits of query, preferring
+// fewer edits and then the shortest candidate. key maps a candidate to the
+// text compared with query.
+func closestMatch(query string, candidates []string, key func(string) string) string {
+	if len(query) < 2 {
+		return ""
+	}
+
+	// Allow roughly one typo for every three characters
+	limit := max(1, len([]rune(query))/3)
+	best, bestDistance := "", limit+1
+	for _, candidate := range candidates {
+		distance := editDistance(query, key(candidate))
+		if distance < bestDistance || distance == bestDistance && len(candidate) < len(best) {
+			best, bestDistance = candidate, distance
+		}
+	}
+	return best
+}
+
+// fuzzyMatch returns the candidate that best contains query as a subsequence
+func fuzzyMatch(query string, candidates []string) string {
+	best, bestScore := "", 0
+	for _, candidate := range candidates {
+		if score, ok := fuzzyScore(query, candidate); ok && (best == "" || score > bestScore) {
+			best, bestScore = candidate, score
+		}
+	}
+	return best
+}
+
+// editDistance counts the insertions, deletions, substitutions and swaps of
+// adjacent characters that turn a into b
+func editDistance(a, b string) int {
+	s, t := []rune(a), []rune(b)
+	rows := make([][]int, len(s)+1)
+	for i := range rows {
+		rows[i] = make([]int, len(t)+1)
+		rows[i][0] = i
+	}
+	for j := range rows[0] {
+		rows[0][j] = j
+	}
+
+	for i := 1; i <= len(s); i++ {
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+			rows[i][j] = min(min(rows[i-1][j]+1, rows[i][j-1]+1), rows[i-1][j-1]+cost)
+			if i > 1 && j > 1 && s[i-1] == t[j-2] && s[i-2] == t[j-1] {
+				rows[i][j] = min(rows[i][j], rows[i-2][j-2]+1)
+			}
+		}
+	}
+	return rows[len(s)][len(t)]
+}
@@ -0,0 +1,77 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/alchemy-labs-co/riptide/internal/api"
+	"github.com/alchemy-labs-co/riptide/internal/conversation"
+)
+
+// summaryTimeout bounds the request that summarizes turns trimmed from the context
+const summaryTimeout = 90 * time.Second
+
+// SummaryMsg carries the summary of the turns that no longer fit the context window
+type SummaryMsg struct {
+	Messages []api.ConversationMessage // The turns the summary replaces
+	Summary  string
+	Usage    api.TokenUsage
+	Err      error
+}
+
+// summarizeOverflow starts summarizing the oldest turns when the history has
+// outgrown the context budget, so they are condensed rather than dropped. It
+// returns nil when nothing needs summarizing or no summary model is set, in
+// which case Trim drops the turns as before.
+func (m *Model) summarizeOverflow() tea.Cmd {
+	model := m.config.API.SummaryModel
+	if model == "" {
+		return nil
+	}
+	provider, ok := m.apiClient.(api.OverridableProvider)
+	if !ok {
+		return nil
+	}
+	previous, messages := m.history.Overflow()
+	if len(messages) == 0 {
+		return nil
+	}
+
+	m.state = StateProcessing
+	m.summarizing = true
+	summarizer := provider.WithOverrides(api.Overrides{Model: model, MaxTokens: conversation.SummaryMaxTokens, NoTools: true})
+	request := conversation.SummaryRequest(previous, messages)
+	return tea.Batch(m.spinner.Tick, func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), summaryTimeout)
+		defer cancel()
+		events, err := summarizer.CreateChatCompletionStream(ctx, request)
+		if err != nil {
+			return SummaryMsg{Messages: messages, Err: err}
+		}
+		summary, usage, err := collectAnswer(ctx, events)
+		return SummaryMsg{Messages: messages, Summary: summary, Usage: usage, Err: err}
+	})
+}
+
+// handleSummary puts the summary in place of the turns it covers and sends the
+// request that was waiting for it. When summarizing failed the turns are
+// dropped with a note instead, as Trim always did.
+func (m Model) handleSummary(msg SummaryMsg) (tea.Model, tea.Cmd) {
+	if !m.summarizing || m.state != StateProcessing {
+		return m, nil
+	}
+	m.summarizing = false
+	m.history.UpdateTokenUsage(msg.Usage.InputTokens, msg.Usage.OutputTokens, msg.Usage.CachedTokens)
+
+	switch {
+	case msg.Err != nil:
+		m.addSystemMessage(FormatWarning(fmt.Sprintf("Could not summarize earlier messages (%v); they were dropped to fit the context window", msg.Err), m.config.UI.EnableEmoji))
+	case m.history.Summarize(msg.Messages, msg.Summary):
+		m.addSystemMessage(FormatInfo(fmt.Sprintf("Summarized %d earlier messages to fit the context window", len(msg.Messages)), m.config.UI.EnableEmoji))
+	}
+	m.updateViewport()
+	return m.sendRequest()
+}
@@ -0,0 +1,83 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/alchemy-labs-co/riptide/internal/functions"
+)
+
+// testFixPrompt is offered in the input after /test finds failures
+const testFixPrompt = "Fix the failing tests"
+
+// TestResultMsg carries the outcome of a /test run
+type TestResultMsg struct {
+	Run *functions.TestRun
+	Err error
+}
+
+// handleTestCommand runs the project's test suite, or the packages or paths in
+// target, without waiting on the model
+func (m Model) handleTestCommand(target string) (tea.Model, tea.Cmd) {
+	m.textInput.SetValue("")
+	m.state = StateProcessing
+	m.addSystemMessage(FormatInfo("Running the tests", m.config.UI.EnableEmoji))
+	m.updateViewport()
+
+	target = strings.TrimSpace(target)
+	return m, tea.Batch(m.spinner.Tick, func() tea.Msg {
+		run, err := m.fileOps.RunTests(target)
+		return TestResultMsg{Run: run, Err: err}
+	})
+}
+
+// handleTestResult shows the outcome of /test. Failures are added to the
+// conversation, with the failing tests summarised ahead of the output, and
+// the input is filled with a prompt asking the model to fix them.
+func (m Model) handleTestResult(msg TestResultMsg) (tea.Model, tea.Cmd) {
+	m.state = StateReady
+	enableEmoji := m.config.UI.EnableEmoji
+	if msg.Err != nil {
+		m.addErrorMessage(fmt.Sprintf("Running tests: %v", msg.Err))
+		m.updateViewport()
+		return m, nil
+	}
+
+	run := msg.Run
+	if run.Passed() {
+		m.addSystemMessage(FormatSuccess(fmt.Sprintf("Tests passed: %s in %s", run.Command, run.Elapsed), enableEmoji))
+		m.updateViewport()
+		return m, nil
+	}
+
+	content, redaction := m.redactFrom(run.String())
+	content, reasons := guardInjection(run.Command, content)
+	m.history.AddSystemMessage("The user ran the tests and they failed:\n\n" + content)
+
+	var b strings.Builder
+	b.WriteString(FormatError(fmt.Sprintf("Tests %s: %s in %s", strings.ToLower(run.Status), run.Command, run.Elapsed), enableEmoji))
+	if len(run.Failures) > 0 {
+		for _, line := range strings.Split(functions.FormatTestFailures(run.Failures), "\n") {
+			b.WriteString("\n  " + line)
+		}
+	} else {
+		b.WriteString("\n" + HelpStyle.Render("  No failing tests recognised in the output; the model gets the end of it"))
+	}
+	b.WriteString("\n\n" + FormatInfo("Added the results to the conversation; press Enter to ask the model to fix them", enableEmoji))
+	if redaction.Count > 0 {
+		b.WriteString("\n" + FormatWarning("Redacted "+redaction.String()+" — use /redact off to send as-is", enableEmoji))
+	}
+	if len(reasons) > 0 {
+		b.WriteString("\n" + FormatWarning("Quarantined instruction-like text ("+strings.Join(reasons, ", ")+"); the model is told to treat it as data", enableEmoji))
+	}
+	m.addSystemMessage(b.String())
+
+	if m.textInput.Value() == "" {
+		m.textInput.SetValue(testFixPrompt)
+		m.textInput.CursorEnd()
+	}
+	m.updateViewport()
+	return m, nil
+}
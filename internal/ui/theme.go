@@ -0,0 +1,227 @@
+package ui
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/alchemy-labs-co/riptide/internal/config"
+)
+
+// builtinThemeNames lists the bundled themes in the order they are offered
+var builtinThemeNames = []string{"default", "dark", "light", "solarized"}
+
+// defaultTheme is the original Riptide palette. Every other theme is based on it,
+// so it must set every color.
+var defaultTheme = config.ThemeConfig{
+	Primary:   "#0066ff",
+	Secondary: "#3b82f6",
+	Accent:    "#00d4ff",
+	Success:   "#10b981",
+	Warning:   "#f59e0b",
+	Error:     "#ef4444",
+
+	Text:      "#ffffff",
+	DimText:   "#6b7280",
+	MutedText: "#9ca3af",
+	Link:      "#2e7de9",
+	FilePath:  "#00ffff",
+	Reasoning: "#60a5fa",
+
+	HeaderBackground:     "#1e3a8a",
+	HighlightBackground:  "#e0f2fe",
+	CodeBackground:       "#1f2937",
+	InlineCodeBackground: "#374151",
+	InlineCodeText:       "#f9fafb",
+	BannerText:           "#ffffff",
+	CursorText:           "#000000",
+
+	CodeKeyword:  "#c678dd",
+	CodeType:     "#e5c07b",
+	CodeString:   "#98c379",
+	CodeComment:  "#6b7280",
+	CodeNumber:   "#d19a66",
+	CodeFunction: "#61afef",
+	CodeInserted: "#10b981",
+	CodeDeleted:  "#ef4444",
+	CodeHunk:     "#00d4ff",
+}
+
+// builtinThemes are the bundled palettes; all but default only list what they change
+var builtinThemes = map[string]config.ThemeConfig{
+	"default": defaultTheme,
+
+	// Lighter tones that stand out on a black background
+	"dark": {
+		Primary:        "#60a5fa",
+		Secondary:      "#93c5fd",
+		Accent:         "#22d3ee",
+		DimText:        "#9ca3af",
+		MutedText:      "#d1d5db",
+		Link:           "#60a5fa",
+		FilePath:       "#67e8f9",
+		Reasoning:      "#a5b4fc",
+		CodeBackground: "#111827",
+		CodeComment:    "#9ca3af",
+		CodeHunk:       "#22d3ee",
+	},
+
+	// Darker tones with enough contrast on a white background
+	"light": {
+		Primary:   "#1d4ed8",
+		Secondary: "#2563eb",
+		Accent:    "#0369a1",
+		Success:   "#047857",
+		Warning:   "#b45309",
+		Error:     "#b91c1c",
+
+		Text:      "#111827",
+		DimText:   "#6b7280",
+		MutedText: "#4b5563",
+		Link:      "#1d4ed8",
+		FilePath:  "#0e7490",
+		Reasoning: "#4f46e5",
+
+		HeaderBackground:     "#dbeafe",
+		CodeBackground:       "#f3f4f6",
+		InlineCodeBackground: "#e5e7eb",
+		InlineCodeText:       "#111827",
+		CursorText:           "#ffffff",
+
+		CodeKeyword:  "#a626a4",
+		CodeType:     "#986801",
+		CodeString:   "#50a14f",
+		CodeComment:  "#a0a1a7",
+		CodeNumber:   "#b76b01",
+		CodeFunction: "#4078f2",
+		CodeInserted: "#22863a",
+		CodeDeleted:  "#cb2431",
+		CodeHunk:     "#0366d6",
+	},
+
+	// Ethan Schoonover's Solarized, on its dark background
+	"solarized": {
+		Primary:   "#268bd2",
+		Secondary: "#6c71c4",
+		Accent:    "#2aa198",
+		Success:   "#859900",
+		Warning:   "#b58900",
+		Error:     "#dc322f",
+
+		Text:      "#fdf6e3",
+		DimText:   "#586e75",
+		MutedText: "#93a1a1",
+		Link:      "#268bd2",
+		FilePath:  "#2aa198",
+		Reasoning: "#6c71c4",
+
+		HeaderBackground:     "#073642",
+		HighlightBackground:  "#eee8d5",
+		CodeBackground:       "#073642",
+		InlineCodeBackground: "#073642",
+		InlineCodeText:       "#eee8d5",
+		BannerText:           "#fdf6e3",
+		CursorText:           "#002b36",
+
+		CodeKeyword:  "#859900",
+		CodeType:     "#b58900",
+		CodeString:   "#2aa198",
+		CodeComment:  "#586e75",
+		CodeNumber:   "#d33682",
+		CodeFunction: "#268bd2",
+		CodeInserted: "#859900",
+		CodeDeleted:  "#dc322f",
+		CodeHunk:     "#6c71c4",
+	},
+}
+
+// hexColor matches the #rgb and #rrggbb forms lipgloss accepts
+var hexColor = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// ThemeNames lists the bundled themes followed by the custom ones, sorted
+func ThemeNames(custom map[string]config.ThemeConfig) []string {
+	names := append([]string(nil), builtinThemeNames...)
+	var extra []string
+	for name := range custom {
+		if _, ok := builtinThemes[name]; !ok {
+			extra = append(extra, name)
+		}
+	}
+	sort.Strings(extra)
+	return append(names, extra...)
+}
+
+// resolveTheme returns the named theme with every color set, following the
+// chain of base themes. A custom theme may reuse a bundled name to adjust it.
+func resolveTheme(name string, custom map[string]config.ThemeConfig) (config.ThemeConfig, error) {
+	if name == "" {
+		name = "default"
+	}
+
+	var theme config.ThemeConfig
+	seen := make(map[string]bool)
+	for {
+		c, ok := custom[name]
+		if !ok || seen[name] {
+			break
+		}
+		seen[name] = true
+		theme = theme.Inherit(c)
+
+		base := c.Base
+		if base == "" {
+			if _, bundled := builtinThemes[name]; bundled {
+				// A custom theme under a bundled name adjusts that theme
+				break
+			}
+			base = "default"
+		}
+		if _, bundled := builtinThemes[base]; seen[base] && !bundled {
+			return config.ThemeConfig{}, fmt.Errorf("theme %q: its bases loop back to %q", name, base)
+		}
+		name = base
+	}
+
+	builtin, ok := builtinThemes[name]
+	if !ok {
+		return config.ThemeConfig{}, fmt.Errorf("unknown theme %q (available: %s)", name, strings.Join(ThemeNames(custom), ", "))
+	}
+	return theme.Inherit(builtin).Inherit(defaultTheme), nil
+}
+
+// validateTheme reports every color that lipgloss would not understand
+func validateTheme(name string, theme config.ThemeConfig) error {
+	var invalid []string
+	for field, color := range theme.Colors() {
+		if hexColor.MatchString(color) {
+			continue
+		}
+		if n, err := strconv.Atoi(color); err == nil && n >= 0 && n <= 255 {
+			continue
+		}
+		invalid = append(invalid, fmt.Sprintf("%s %q", field, color))
+	}
+	if len(invalid) > 0 {
+		sort.Strings(invalid)
+		return fmt.Errorf("theme %s has invalid colors (use #rrggbb or 0-255): %s", name, strings.Join(invalid, ", "))
+	}
+	return nil
+}
+
+// ApplyTheme makes the named theme the active one, rebuilding every style from
+// its colors so the next render uses them. The active theme is left unchanged
+// when the name is unknown or a color is invalid.
+func ApplyTheme(name string, custom map[string]config.ThemeConfig) error {
+	theme, err := resolveTheme(name, custom)
+	if err != nil {
+		return err
+	}
+	if err := validateTheme(name, theme); err != nil {
+		return err
+	}
+	activeTheme = theme
+	buildStyles()
+	return nil
+}
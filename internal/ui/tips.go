@@ -0,0 +1,57 @@
+package ui
+
+import (
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// tipRotationInterval is how often the welcome screen shows the next tips
+const tipRotationInterval = 8 * time.Second
+
+// visibleTips is how many tips the welcome screen shows at once
+const visibleTips = 3
+
+// welcomeTips are short usage hints shown on the welcome screen
+var welcomeTips = []string{
+	"Press Ctrl+K to search commands and recent files",
+	"Use /add <path> to put a file or folder in context",
+	"Run /context to see what is using your token budget",
+	"Press Ctrl+T to switch between relative and absolute timestamps",
+	"After an error, press Ctrl+R to retry the request",
+	"Press Ctrl+P to edit an earlier prompt and resubmit it",
+	"Press Ctrl+E to preview what a prompt will send and drop files from context",
+	"Mention file names naturally and the AI will read them",
+	"Use /config to change the model or trim budget",
+	"Scroll the conversation with PgUp/PgDown",
+}
+
+// tipTickMsg advances the rotating welcome tips
+type tipTickMsg struct{}
+
+// tickTips schedules the next tip rotation
+func tickTips() tea.Cmd {
+	return tea.Tick(tipRotationInterval, func(time.Time) tea.Msg {
+		return tipTickMsg{}
+	})
+}
+
+// currentTips returns the tips to show for the current rotation
+func (m Model) currentTips() []string {
+	tips := make([]string, 0, visibleTips)
+	for i := 0; i < visibleTips && i < len(welcomeTips); i++ {
+		tips = append(tips, welcomeTips[(m.tipIndex+i)%len(welcomeTips)])
+	}
+	return tips
+}
+
+// renderTips renders the current tips as a bulleted list
+func (m Model) renderTips() string {
+	var b strings.Builder
+	b.WriteString("Tips")
+	for _, tip := range m.currentTips() {
+		b.WriteString("\n  " + HelpStyle.Render("• "+tip))
+	}
+	return b.String()
+}
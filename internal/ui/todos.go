@@ -0,0 +1,62 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/alchemy-labs-co/riptide/internal/conversation"
+	"github.com/alchemy-labs-co/riptide/internal/functions"
+)
+
+// todoDisplayLimit caps how many comments /todos shows; all of them go to the model
+const todoDisplayLimit = 50
+
+// handleTodosCommand scans path for TODO-style comments, shows them, and adds
+// the full list to the conversation so the model can triage or fix them
+func (m Model) handleTodosCommand(path string) (tea.Model, tea.Cmd) {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		path = m.workspaceRoot
+	}
+
+	m.state = StateProcessing
+	enableEmoji := m.config.UI.EnableEmoji
+
+	return m, func() tea.Msg {
+		result, err := functions.FindTodos(m.config, path, nil)
+		if err != nil {
+			return ProcessCompleteMsg{Error: fmt.Errorf("finding todos: %w", err)}
+		}
+		if len(result.Todos) == 0 {
+			return ProcessCompleteMsg{Result: FormatInfo("No TODO, FIXME, HACK or XXX comments found", enableEmoji)}
+		}
+
+		listing := functions.FormatTodos(result)
+		content, redaction := m.redactFrom(listing)
+		content, reasons := guardInjection(path, content)
+		m.history.AddSystemMessage(fmt.Sprintf("TODO comments under %s:\n\n%s", path, content))
+
+		lines := strings.Split(listing, "\n")
+		var b strings.Builder
+		b.WriteString(fmt.Sprintf("%s %s\n", GetIcon("file", enableEmoji), lines[0]))
+		for i, line := range lines[1:] {
+			if i == todoDisplayLimit {
+				b.WriteString(HelpStyle.Render(fmt.Sprintf("  ... and %d more", len(lines)-1-todoDisplayLimit)) + "\n")
+				break
+			}
+			b.WriteString("  " + line + "\n")
+		}
+		b.WriteString("\n" + FormatSuccess(fmt.Sprintf("Added the list to the conversation (~%s tokens); ask the model to triage or fix them",
+			formatTokenCount(conversation.EstimateTokens(content))), enableEmoji))
+		if redaction.Count > 0 {
+			b.WriteString("\n" + FormatWarning("Redacted "+redaction.String()+" — use /redact off to send as-is", enableEmoji))
+		}
+		if len(reasons) > 0 {
+			b.WriteString("\n" + FormatWarning("Quarantined instruction-like text ("+strings.Join(reasons, ", ")+"); the model is told to treat it as data", enableEmoji))
+		}
+
+		return ProcessCompleteMsg{Result: b.String()}
+	}
+}
@@ -0,0 +1,127 @@
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/alchemy-labs-co/riptide/internal/api"
+)
+
+// maxToolArgLen is how much of a string argument a tool card shows
+const maxToolArgLen = 40
+
+// summarizeToolArgs lists every argument of a tool call in brief, e.g.
+// `file_path: "main.go" · limit: 40`. Long strings are cut short, multi-line
+// ones are counted in lines and lists in items.
+func summarizeToolArgs(toolCall api.ToolCall) string {
+	var args map[string]any
+	if err := json.Unmarshal([]byte(toolCall.Function.Arguments), &args); err != nil {
+		return ""
+	}
+
+	keys := make([]string, 0, len(args))
+	for key := range args {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, len(keys))
+	for i, key := range keys {
+		var value string
+		switch v := args[key].(type) {
+		case string:
+			if lines := strings.Count(v, "\n") + 1; lines > 1 {
+				value = fmt.Sprintf("(%d lines)", lines)
+			} else {
+				value = strconv.Quote(truncate(v, maxToolArgLen))
+			}
+		case []any:
+			value = fmt.Sprintf("[%d items]", len(v))
+		case map[string]any:
+			value = "{…}"
+		default:
+			value = fmt.Sprint(v)
+		}
+		parts[i] = key + ": " + value
+	}
+	return strings.Join(parts, " · ")
+}
+
+// addToolMessage leaves a card for a finished tool call in the transcript
+func (m *Model) addToolMessage(status ToolStatus) {
+	m.messages = append(m.messages, Message{
+		Role:      "tool",
+		Tool:      &status,
+		Timestamp: time.Now(),
+	})
+}
+
+// renderToolCard renders a finished tool call as a bordered card: its name and
+// arguments, how long it ran and how it ended, then its output, folded to a
+// line count unless tool output is expanded
+func (m Model) renderToolCard(status ToolStatus) string {
+	width := max(m.viewport.Width-4, 20)
+
+	header := toolStateIcon(status.State) + " " + lipgloss.NewStyle().Bold(true).Render(status.Name)
+	if status.Summary != "" {
+		header += " " + status.Summary
+	}
+	var notes []string
+	switch {
+	case status.Duration >= time.Millisecond:
+		notes = append(notes, formatDuration(status.Duration))
+	case status.Duration > 0:
+		notes = append(notes, "<1ms")
+	}
+	if status.Redacted > 0 {
+		notes = append(notes, fmt.Sprintf("%d redacted", status.Redacted))
+	}
+	if status.Quarantined {
+		notes = append(notes, "quarantined")
+	}
+	if len(notes) > 0 {
+		header += HelpStyle.Render(" · " + strings.Join(notes, " · "))
+	}
+
+	lines := []string{header}
+	if status.Args != "" {
+		lines = append(lines, HelpStyle.Render(truncate(status.Args, width-4)))
+	}
+	switch {
+	case status.State == ToolFailed:
+		lines = append(lines, ErrorStyle.Render(status.Error))
+	case status.Result != "":
+		count := strings.Count(status.Result, "\n") + 1
+		label := fmt.Sprintf("%d lines of output", count)
+		if count == 1 {
+			label = "1 line of output"
+		}
+		if m.toolOutputExpanded {
+			lines = append(lines, HelpStyle.Render("▾ "+label), strings.ReplaceAll(status.Result, "\t", "    "))
+		} else {
+			lines = append(lines, HelpStyle.Render("▸ "+label))
+		}
+	}
+
+	return lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(DimTextColor).
+		Padding(0, 1).
+		Width(width).
+		Render(strings.Join(lines, "\n"))
+}
+
+// toggleToolOutput shows the output of every tool card in the transcript, or
+// folds them back to their line counts
+func (m Model) toggleToolOutput() (tea.Model, tea.Cmd) {
+	m.toolOutputExpanded = !m.toolOutputExpanded
+	m.updateViewport()
+	return m, nil
+}
@@ -0,0 +1,213 @@
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/alchemy-labs-co/riptide/internal/api"
+	"github.com/alchemy-labs-co/riptide/internal/functions"
+)
+
+// ToolState represents the execution state of a single tool call
+type ToolState int
+
+const (
+	ToolPending ToolState = iota
+	ToolAwaitingApproval
+	ToolRunning
+	ToolSucceeded
+	ToolFailed
+)
+
+// ToolStatus tracks the progress of a tool call while a batch is executing
+type ToolStatus struct {
+	Name        string
+	Summary     string // Short argument summary, e.g. the file name
+	Args        string // Every argument in brief, for the transcript card
+	State       ToolState
+	Error       string
+	Redacted    int           // Secrets removed from the output
+	Quarantined bool          // Output contained instruction-like text
+	Duration    time.Duration // How long the call ran, once finished
+	Result      string        // Output sent back to the model, once finished
+}
+
+// ToolProgressMsg is sent when a tool call changes state during execution
+type ToolProgressMsg struct {
+	Index       int
+	State       ToolState
+	Error       string
+	Redacted    int
+	Quarantined bool
+	Duration    time.Duration
+	Result      string
+}
+
+// newToolStatuses creates a pending status entry for each tool call
+func newToolStatuses(toolCalls []api.ToolCall) []ToolStatus {
+	statuses := make([]ToolStatus, len(toolCalls))
+	for i, toolCall := range toolCalls {
+		statuses[i] = ToolStatus{
+			Name:    toolCall.Function.Name,
+			Summary: summarizeToolCall(toolCall),
+			Args:    summarizeToolArgs(toolCall),
+			State:   ToolPending,
+		}
+	}
+	return statuses
+}
+
+// summarizeToolCall extracts the most descriptive argument of a tool call for display
+func summarizeToolCall(toolCall api.ToolCall) string {
+	var args api.FileOperationArgs
+	if err := json.Unmarshal([]byte(toolCall.Function.Arguments), &args); err != nil {
+		return ""
+	}
+
+	switch {
+	case args.FilePath != "":
+		return filepath.Base(args.FilePath)
+	case len(args.FilePaths) == 1:
+		return filepath.Base(args.FilePaths[0])
+	case len(args.FilePaths) > 1:
+		return fmt.Sprintf("%d files", len(args.FilePaths))
+	case len(args.Files) == 1:
+		return filepath.Base(args.Files[0].Path)
+	case len(args.Files) > 1:
+		return fmt.Sprintf("%d files", len(args.Files))
+	case args.Command != "":
+		return truncate(args.Command, 40)
+	case args.Pattern != "":
+		return truncate(args.Pattern, 40)
+	case args.Benchmark != "":
+		return args.Benchmark
+	case args.Target != "":
+		return args.Target
+	case args.Name != "":
+		return args.Name
+	case args.Path != "":
+		return args.Path
+	case args.Image != "":
+		return args.Image
+	case args.Language != "":
+		return args.Language
+	}
+	return ""
+}
+
+// toolSource describes where a tool's output came from, e.g. "read_file main.go"
+func toolSource(toolCall api.ToolCall) string {
+	paths := toolCallPaths(toolCall)
+	if len(paths) == 0 {
+		return toolCall.Function.Name
+	}
+	return toolCall.Function.Name + " " + strings.Join(paths, ", ")
+}
+
+// toolCallCommand returns the shell command a tool call would run, if any
+func toolCallCommand(toolCall api.ToolCall) string {
+	var args api.FileOperationArgs
+	if err := json.Unmarshal([]byte(toolCall.Function.Arguments), &args); err != nil {
+		return ""
+	}
+	return args.Command
+}
+
+// toolCallPaths returns every file path a tool call touches
+func toolCallPaths(toolCall api.ToolCall) []string {
+	var args api.FileOperationArgs
+	if err := json.Unmarshal([]byte(toolCall.Function.Arguments), &args); err != nil {
+		return nil
+	}
+
+	var paths []string
+	if args.FilePath != "" {
+		paths = append(paths, args.FilePath)
+	}
+	if args.Path != "" {
+		paths = append(paths, args.Path)
+	}
+	paths = append(paths, args.FilePaths...)
+	for _, file := range args.Files {
+		paths = append(paths, file.Path)
+	}
+	return paths
+}
+
+// toolCallInputPaths returns the other paths a tool call reads: a
+// validate_file schema file and the paths among run_tests and run_benchmarks
+// targets, with Go's /... and pytest's ::test suffixes removed. Package names
+// are returned too and resolve inside the workspace.
+func toolCallInputPaths(toolCall api.ToolCall) []string {
+	var args api.FileOperationArgs
+	if err := json.Unmarshal([]byte(toolCall.Function.Arguments), &args); err != nil {
+		return nil
+	}
+
+	var paths []string
+	if args.Schema != "" && !functions.IsKnownFormat(args.Schema) {
+		paths = append(paths, args.Schema)
+	}
+	for _, field := range strings.Fields(args.Target) {
+		if strings.HasPrefix(field, "-") {
+			continue
+		}
+		field, _, _ = strings.Cut(field, "::")
+		if field = strings.TrimSuffix(field, "..."); field == "" {
+			field = "."
+		}
+		paths = append(paths, field)
+	}
+	return paths
+}
+
+// toolStateIcon returns the styled checklist marker for a tool state
+func toolStateIcon(state ToolState) string {
+	switch state {
+	case ToolAwaitingApproval:
+		return lipgloss.NewStyle().Foreground(WarningColor).Render("?")
+	case ToolRunning:
+		return lipgloss.NewStyle().Foreground(AccentColor).Render("⟳")
+	case ToolSucceeded:
+		return lipgloss.NewStyle().Foreground(SuccessColor).Render("✓")
+	case ToolFailed:
+		return lipgloss.NewStyle().Foreground(ErrorColor).Render("✗")
+	default:
+		return lipgloss.NewStyle().Foreground(DimTextColor).Render("○")
+	}
+}
+
+// formatToolStatus renders a single checklist entry
+func formatToolStatus(status ToolStatus) string {
+	label := status.Name
+	if status.Summary != "" {
+		label += " " + status.Summary
+	}
+	if status.Redacted > 0 {
+		label += fmt.Sprintf(" (%d redacted)", status.Redacted)
+	}
+	if status.Quarantined {
+		label += " (quarantined)"
+	}
+	return toolStateIcon(status.State) + " " + label
+}
+
+// renderToolStatusList renders the live checklist shown while tools execute
+func (m Model) renderToolStatusList() string {
+	if len(m.toolStatuses) == 0 {
+		return ""
+	}
+
+	entries := make([]string, len(m.toolStatuses))
+	for i, status := range m.toolStatuses {
+		entries[i] = formatToolStatus(status)
+	}
+
+	return lipgloss.NewStyle().
+		MaxWidth(m.width).
+		Render(strings.Join(entries, HelpStyle.Render(" · ")))
+}
@@ -0,0 +1,135 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/alchemy-labs-co/riptide/internal/functions"
+)
+
+// undoListLimit caps how many changelog entries /undo list shows
+const undoListLimit = 30
+
+// handleUndoCommand reverts the last tool call's file writes, the last n calls'
+// writes, or every write from the last turn, or lists the changelog
+func (m Model) handleUndoCommand(arg string) (tea.Model, tea.Cmd) {
+	enableEmoji := m.config.UI.EnableEmoji
+	m.textInput.SetValue("")
+
+	if m.fileOps.UndoDirPath() == "" {
+		m.addSystemMessage(FormatInfo("Undo is unavailable without a working directory", enableEmoji))
+		m.updateViewport()
+		return m, nil
+	}
+
+	count, turn := 1, false
+	switch arg = strings.TrimSpace(arg); arg {
+	case "":
+	case "list":
+		m.addSystemMessage(m.getUndoListText())
+		m.updateViewport()
+		return m, nil
+	case "turn":
+		turn = true
+	default:
+		n, err := strconv.Atoi(arg)
+		if err != nil || n < 1 {
+			m.showError("Usage: /undo [list|turn|<number of tool calls>]", false)
+			return m, nil
+		}
+		count = n
+	}
+
+	reverted, err := m.fileOps.Undo(count, turn)
+	var conflict *functions.UndoConflictError
+	switch {
+	case errors.As(err, &conflict):
+		var paths []string
+		for _, path := range conflict.Paths {
+			paths = append(paths, displayPath(m.workspaceRoot, path))
+		}
+		m.showError(fmt.Sprintf("Not undoing: %s changed since Riptide wrote them", strings.Join(paths, ", ")), false)
+		return m, nil
+	case errors.Is(err, functions.ErrNothingToUndo):
+		m.addSystemMessage(FormatInfo("Nothing to undo in this session", enableEmoji))
+		m.updateViewport()
+		return m, nil
+	case err != nil && len(reverted) == 0:
+		m.showError(fmt.Sprintf("Undo: %v", err), false)
+		return m, nil
+	}
+
+	noun := "writes"
+	if len(reverted) == 1 {
+		noun = "write"
+	}
+	var b strings.Builder
+	var notes []string
+	b.WriteString(FormatSuccess(fmt.Sprintf("Reverted %d %s", len(reverted), noun), enableEmoji))
+	for _, entry := range reverted {
+		path := displayPath(m.workspaceRoot, entry.Path)
+		action := "restored"
+		if entry.Created() {
+			action = "deleted"
+		}
+		b.WriteString(fmt.Sprintf("\n  %s %s  %s", FormatFilePath(path), HelpStyle.Render(action), HelpStyle.Render(entry.Tool)))
+		notes = append(notes, fmt.Sprintf("%s (%s, from %s)", path, action, entry.Tool))
+	}
+	if err != nil {
+		b.WriteString("\n" + FormatError(err.Error(), enableEmoji))
+	}
+
+	// Tell the model so it does not build on changes that are gone
+	m.history.AddSystemMessage("The user undid these file changes, which are no longer on disk: " + strings.Join(notes, "; "))
+
+	m.addSystemMessage(b.String())
+	m.updateViewport()
+	return m, nil
+}
+
+// getUndoListText formats this session's changelog, newest first
+func (m Model) getUndoListText() string {
+	enableEmoji := m.config.UI.EnableEmoji
+
+	entries := m.fileOps.UndoLog()
+	if len(entries) == 0 {
+		return FormatInfo("No file writes to undo in this session", enableEmoji)
+	}
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("%s Changes this session (backups in %s)\n", GetIcon("file", enableEmoji),
+		displayPath(m.workspaceRoot, m.fileOps.UndoDirPath())))
+	shown := 0
+	for i := len(entries) - 1; i >= 0 && shown < undoListLimit; i-- {
+		entry := entries[i]
+		shown++
+
+		kind := "modified"
+		if entry.Created() {
+			kind = "created"
+		}
+		status := ""
+		if entry.Undone {
+			status = "  " + HelpStyle.Render("(undone)")
+		}
+		b.WriteString(fmt.Sprintf("\n  #%-3d %s  turn %-3d call %-3d %-22s %s  %s%s",
+			entry.ID,
+			entry.Time.Format("15:04:05"),
+			entry.Turn,
+			entry.Call,
+			entry.Tool,
+			FormatFilePath(displayPath(m.workspaceRoot, entry.Path)),
+			HelpStyle.Render(kind),
+			status,
+		))
+	}
+	if len(entries) > shown {
+		b.WriteString("\n  " + HelpStyle.Render(fmt.Sprintf("... and %d earlier", len(entries)-shown)))
+	}
+	b.WriteString("\n\n" + HelpStyle.Render("/undo reverts the last tool call, /undo <n> the last n calls, /undo turn the last turn"))
+	return b.String()
+}
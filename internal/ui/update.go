@@ -0,0 +1,21 @@
+package ui
+
+import (
+	"fmt"
+)
+
+// UpdateAvailableMsg reports a newer release found by the background update check
+type UpdateAvailableMsg struct {
+	Current string
+	Latest  string
+}
+
+// handleUpdateAvailable shows the update notice on the welcome screen, or in the
+// transcript once a conversation has started
+func (m *Model) handleUpdateAvailable(msg UpdateAvailableMsg) {
+	m.updateNotice = fmt.Sprintf("Riptide %s is available (you have %s) — run 'riptide update'", msg.Latest, msg.Current)
+	if !m.showWelcome || len(m.messages) > 0 {
+		m.addSystemMessage(FormatInfo(m.updateNotice, m.config.UI.EnableEmoji))
+	}
+	m.updateViewport()
+}
@@ -0,0 +1,163 @@
+package ui
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/alchemy-labs-co/riptide/internal/config"
+)
+
+// vimKeymap reports whether the vim key bindings are on
+func (m Model) vimKeymap() bool {
+	return m.config.UI.Keymap == config.KeymapVim
+}
+
+// handleVimKey applies the vim bindings to a key and reports whether it was
+// used. Esc leaves insert mode; in normal mode letters move the cursor, edit the
+// prompt and scroll or search the transcript instead of being typed. Keys such
+// as Enter, arrows and control keys are left to the default bindings.
+func (m *Model) handleVimKey(msg tea.KeyMsg) bool {
+	if !m.vimNormal {
+		if msg.Type == tea.KeyEsc && m.state == StateReady && !m.autocompleteActive {
+			m.vimNormal = true
+			m.editInput(tea.KeyMsg{Type: tea.KeyLeft})
+			return true
+		}
+		return false
+	}
+
+	if msg.Paste || (msg.Type != tea.KeyRunes && msg.Type != tea.KeySpace && msg.Type != tea.KeyBackspace) {
+		m.vimPending = ""
+		return false
+	}
+
+	key := msg.String()
+	pending := m.vimPending
+	m.vimPending = ""
+	switch pending + key {
+	case "gg":
+		m.viewport.GotoTop()
+		return true
+	case "dd":
+		if m.state == StateReady {
+			m.textInput.SetValue("")
+			m.updateAutocomplete()
+		}
+		return true
+	}
+
+	ready := m.state == StateReady
+	switch key {
+	// Transcript
+	case "j":
+		m.viewport.LineDown(1)
+	case "k":
+		m.viewport.LineUp(1)
+	case "g", "d":
+		m.vimPending = key
+	case "G":
+		m.viewport.GotoBottom()
+	case "/":
+		m.openSearch()
+	case "n":
+		m.findMatch(1)
+	case "N":
+		m.findMatch(-1)
+
+	// Prompt
+	case "h", "backspace":
+		m.editInput(tea.KeyMsg{Type: tea.KeyLeft})
+	case "l", " ":
+		m.editInput(tea.KeyMsg{Type: tea.KeyRight})
+	case "w":
+		m.editInput(tea.KeyMsg{Type: tea.KeyRight, Alt: true})
+	case "b":
+		m.editInput(tea.KeyMsg{Type: tea.KeyLeft, Alt: true})
+	case "0", "^":
+		m.editInput(tea.KeyMsg{Type: tea.KeyHome})
+	case "$":
+		m.editInput(tea.KeyMsg{Type: tea.KeyEnd})
+	case "x":
+		if ready {
+			m.editInput(tea.KeyMsg{Type: tea.KeyDelete})
+		}
+	case "D":
+		if ready {
+			m.editInput(tea.KeyMsg{Type: tea.KeyCtrlK})
+		}
+
+	// Back to insert mode
+	case "i":
+		m.vimNormal = false
+	case "a":
+		m.editInput(tea.KeyMsg{Type: tea.KeyRight})
+		m.vimNormal = false
+	case "I":
+		m.editInput(tea.KeyMsg{Type: tea.KeyHome})
+		m.vimNormal = false
+	case "A":
+		m.editInput(tea.KeyMsg{Type: tea.KeyEnd})
+		m.vimNormal = false
+	case "o":
+		if ready {
+			m.editInput(tea.KeyMsg{Type: tea.KeyEnd})
+			m.insertNewline()
+		}
+		m.vimNormal = false
+	}
+
+	// Other keys do nothing in normal mode rather than being typed
+	return true
+}
+
+// editInput passes keys to the prompt editor
+func (m *Model) editInput(keys ...tea.KeyMsg) {
+	for _, key := range keys {
+		m.textInput, _ = m.textInput.Update(key)
+	}
+	m.updateAutocomplete()
+}
+
+// vimStatus shows the vim mode in the status line
+func (m Model) vimStatus() string {
+	if !m.vimKeymap() {
+		return ""
+	}
+	if m.vimNormal {
+		return InfoStyle.Render("-- NORMAL --")
+	}
+	return HelpStyle.Render("-- INSERT --")
+}
+
+// handleVimCommand turns the vim key bindings on or off for this session, or
+// toggles them without an argument
+func (m Model) handleVimCommand(arg string) (tea.Model, tea.Cmd) {
+	m.textInput.SetValue("")
+
+	switch strings.ToLower(strings.TrimSpace(arg)) {
+	case "":
+		if m.vimKeymap() {
+			m.config.UI.Keymap = config.KeymapDefault
+		} else {
+			m.config.UI.Keymap = config.KeymapVim
+		}
+	case "on":
+		m.config.UI.Keymap = config.KeymapVim
+	case "off":
+		m.config.UI.Keymap = config.KeymapDefault
+	default:
+		m.addErrorMessage("Usage: /vim [on|off]")
+		m.updateViewport()
+		return m, nil
+	}
+
+	m.vimNormal, m.vimPending = false, ""
+	if m.vimKeymap() {
+		m.addSystemMessage("⎿  Vim keybindings on: Esc for normal mode (j/k scroll, gg/G jump, / search, n/N next/previous match), i to type")
+	} else {
+		m.addSystemMessage("⎿  Vim keybindings off")
+	}
+	m.updateViewport()
+	return m, nil
+}
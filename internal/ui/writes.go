@@ -0,0 +1,98 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/alchemy-labs-co/riptide/internal/functions"
+)
+
+// writeLogDisplayLimit caps how many ledger entries /writes shows
+const writeLogDisplayLimit = 20
+
+// getWritesText formats the most recent entries of the write ledger, optionally
+// keeping only paths that contain filter
+func (m Model) getWritesText(filter string) string {
+	enableEmoji := m.config.UI.EnableEmoji
+
+	logPath := m.fileOps.WriteLogPath()
+	if logPath == "" {
+		return FormatInfo("The write ledger is unavailable without a working directory", enableEmoji)
+	}
+
+	records, err := functions.ReadWriteLog(logPath, 0)
+	if err != nil {
+		return FormatError(fmt.Sprintf("Reading write ledger: %v", err), enableEmoji)
+	}
+
+	if filter != "" {
+		var matching []functions.WriteRecord
+		for _, record := range records {
+			if strings.Contains(record.Path, filter) {
+				matching = append(matching, record)
+			}
+		}
+		records = matching
+	}
+
+	if len(records) == 0 {
+		return FormatInfo("No file writes recorded yet", enableEmoji)
+	}
+
+	total := len(records)
+	if total > writeLogDisplayLimit {
+		records = records[total-writeLogDisplayLimit:]
+	}
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("%s Writes (%d of %d) from %s\n", GetIcon("file", enableEmoji), len(records), total, functions.WriteLogFile))
+	for _, record := range records {
+		path := record.Path
+		if rel, err := filepath.Rel(m.workspaceRoot, record.Path); err == nil && !strings.HasPrefix(rel, "..") {
+			path = rel
+		}
+
+		before := "new"
+		if record.BeforeHash != "" {
+			before = shortHash(record.BeforeHash)
+		}
+
+		after := "deleted"
+		if record.AfterHash != "" {
+			after = shortHash(record.AfterHash)
+		}
+
+		b.WriteString(fmt.Sprintf("\n  %s  turn %-3d %-22s %s  %s  %s → %s",
+			record.Time.Format("Jan 2 15:04:05"),
+			record.Turn,
+			record.Tool,
+			FormatFilePath(path),
+			formatByteDelta(record.Delta()),
+			HelpStyle.Render(before),
+			HelpStyle.Render(after),
+		))
+	}
+	return b.String()
+}
+
+// shortHash abbreviates a hex digest for display
+func shortHash(hash string) string {
+	if len(hash) > 8 {
+		return hash[:8]
+	}
+	return hash
+}
+
+// formatByteDelta formats a size change such as "+120 B" or "-2.1 KB"
+func formatByteDelta(delta int) string {
+	sign := "+"
+	if delta < 0 {
+		sign = "-"
+		delta = -delta
+	}
+	if delta >= 1024 {
+		return fmt.Sprintf("%s%.1f KB", sign, float64(delta)/1024)
+	}
+	return fmt.Sprintf("%s%d B", sign, delta)
+}
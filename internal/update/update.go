@@ -0,0 +1,302 @@
+// Package update checks GitHub for newer Riptide releases and replaces the
+// running binary with the release built for this platform.
+package update
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// LatestReleaseURL is the GitHub API endpoint for the newest release
+	LatestReleaseURL = "https://api.github.com/repos/alchemy-labs-co/riptide/releases/latest"
+
+	// checksumsAsset lists "<sha256>  <asset name>" for every release binary
+	checksumsAsset = "checksums.txt"
+
+	// checkInterval is how often the background check contacts GitHub
+	checkInterval = 24 * time.Hour
+
+	// maxBinaryBytes guards against downloading something that is not a binary
+	maxBinaryBytes = 200 * 1024 * 1024
+)
+
+// Release is a published GitHub release
+type Release struct {
+	Version string  `json:"tag_name"`
+	URL     string  `json:"html_url"`
+	Assets  []Asset `json:"assets"`
+}
+
+// Asset is a file attached to a release
+type Asset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
+}
+
+// checkState records the last background check so GitHub is asked at most daily
+type checkState struct {
+	CheckedAt time.Time `json:"checked_at"`
+	Latest    string    `json:"latest"`
+}
+
+// Latest fetches the newest release from GitHub
+func Latest(ctx context.Context) (*Release, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, LatestReleaseURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating release request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetching latest release: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching latest release: %s", resp.Status)
+	}
+
+	var release Release
+	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+		return nil, fmt.Errorf("parsing release: %w", err)
+	}
+	return &release, nil
+}
+
+// IsNewer reports whether latest is a higher version than current. Versions that
+// do not start with a number (such as "dev" builds) never compare as older.
+func IsNewer(current, latest string) bool {
+	cur, ok := parseVersion(current)
+	if !ok {
+		return false
+	}
+	lat, ok := parseVersion(latest)
+	if !ok {
+		return false
+	}
+	for i := range cur {
+		if lat[i] != cur[i] {
+			return lat[i] > cur[i]
+		}
+	}
+	return false
+}
+
+// parseVersion reads major.minor.patch from versions such as "v1.4.2",
+// "1.4" or "v1.4.2-3-gabcdef-dirty"
+func parseVersion(version string) ([3]int, bool) {
+	var parts [3]int
+	version = strings.TrimPrefix(strings.TrimSpace(version), "v")
+	if i := strings.IndexAny(version, "-+"); i >= 0 {
+		version = version[:i]
+	}
+
+	fields := strings.Split(version, ".")
+	if len(fields) == 0 || len(fields) > 3 {
+		return parts, false
+	}
+	for i, field := range fields {
+		n, err := strconv.Atoi(field)
+		if err != nil {
+			return parts, false
+		}
+		parts[i] = n
+	}
+	return parts, true
+}
+
+// IsRelease reports whether version is a comparable release version
+func IsRelease(version string) bool {
+	_, ok := parseVersion(version)
+	return ok
+}
+
+// AssetName returns the release asset built for this platform, matching the
+// names produced by make build-all
+func AssetName() string {
+	name := fmt.Sprintf("riptide-%s-%s", runtime.GOOS, runtime.GOARCH)
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	return name
+}
+
+// CheckDaily returns the latest release when it is newer than current, asking
+// GitHub at most once a day and otherwise reusing the last answer
+func CheckDaily(ctx context.Context, current string) (string, error) {
+	if !IsRelease(current) {
+		return "", nil
+	}
+
+	statePath, err := statePath()
+	if err != nil {
+		return "", err
+	}
+
+	var state checkState
+	if data, err := os.ReadFile(statePath); err == nil {
+		_ = json.Unmarshal(data, &state)
+	}
+
+	if time.Since(state.CheckedAt) >= checkInterval {
+		release, err := Latest(ctx)
+		if err != nil {
+			return "", err
+		}
+		state = checkState{CheckedAt: time.Now(), Latest: release.Version}
+
+		if err := os.MkdirAll(filepath.Dir(statePath), 0755); err != nil {
+			return "", fmt.Errorf("creating cache directory: %w", err)
+		}
+		data, err := json.Marshal(state)
+		if err != nil {
+			return "", fmt.Errorf("marshaling update state: %w", err)
+		}
+		if err := os.WriteFile(statePath, data, 0644); err != nil {
+			return "", fmt.Errorf("writing update state: %w", err)
+		}
+	}
+
+	if IsNewer(current, state.Latest) {
+		return state.Latest, nil
+	}
+	return "", nil
+}
+
+// statePath returns where the background check remembers its last result
+func statePath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("finding cache directory: %w", err)
+	}
+	return filepath.Join(dir, "riptide", "update-check.json"), nil
+}
+
+// Apply downloads the release binary for this platform, verifies it against the
+// release checksums, and replaces the running executable. A release without
+// checksums is refused unless unverified is set. It returns the path that was
+// replaced.
+func Apply(ctx context.Context, release *Release, unverified bool) (string, error) {
+	name := AssetName()
+	var binary, checksums *Asset
+	for i := range release.Assets {
+		switch release.Assets[i].Name {
+		case name:
+			binary = &release.Assets[i]
+		case checksumsAsset:
+			checksums = &release.Assets[i]
+		}
+	}
+	if binary == nil {
+		return "", fmt.Errorf("release %s has no %s binary; download it from %s", release.Version, name, release.URL)
+	}
+	if checksums == nil && !unverified {
+		return "", fmt.Errorf("release %s publishes no %s, so the download cannot be verified; use --skip-verify to install it anyway", release.Version, checksumsAsset)
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("finding executable: %w", err)
+	}
+	if exe, err = filepath.EvalSymlinks(exe); err != nil {
+		return "", fmt.Errorf("resolving executable: %w", err)
+	}
+
+	// Download next to the executable so the final rename stays on one filesystem
+	tmp, err := os.CreateTemp(filepath.Dir(exe), ".riptide-update-*")
+	if err != nil {
+		return "", fmt.Errorf("creating download file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+
+	sum, err := download(ctx, binary.URL, tmp)
+	tmp.Close()
+	if err != nil {
+		return "", err
+	}
+
+	if checksums != nil {
+		want, err := expectedChecksum(ctx, checksums.URL, name)
+		if err != nil {
+			return "", err
+		}
+		if want != sum {
+			return "", fmt.Errorf("checksum mismatch for %s: got %s, want %s", name, sum, want)
+		}
+	}
+
+	if err := os.Chmod(tmpPath, 0755); err != nil {
+		return "", fmt.Errorf("setting file mode: %w", err)
+	}
+
+	// Windows cannot replace a running executable, but it can rename it
+	if runtime.GOOS == "windows" {
+		old := exe + ".old"
+		os.Remove(old)
+		if err := os.Rename(exe, old); err != nil {
+			return "", fmt.Errorf("moving old executable: %w", err)
+		}
+	}
+	if err := os.Rename(tmpPath, exe); err != nil {
+		return "", fmt.Errorf("replacing executable: %w", err)
+	}
+	return exe, nil
+}
+
+// download writes url to w and returns the hex SHA-256 of the content
+func download(ctx context.Context, url string, w io.Writer) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", fmt.Errorf("creating download request: %w", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("downloading %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("downloading %s: %s", url, resp.Status)
+	}
+
+	hash := sha256.New()
+	n, err := io.Copy(io.MultiWriter(w, hash), io.LimitReader(resp.Body, maxBinaryBytes+1))
+	if err != nil {
+		return "", fmt.Errorf("downloading %s: %w", url, err)
+	}
+	if n > maxBinaryBytes {
+		return "", fmt.Errorf("downloading %s: larger than %d bytes", url, maxBinaryBytes)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// expectedChecksum finds the SHA-256 for name in a checksums.txt asset
+func expectedChecksum(ctx context.Context, url, name string) (string, error) {
+	var buf strings.Builder
+	if _, err := download(ctx, url, &buf); err != nil {
+		return "", err
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(buf.String()))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == name {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+	return "", fmt.Errorf("%s does not list %s", checksumsAsset, name)
+}
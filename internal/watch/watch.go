@@ -0,0 +1,237 @@
+// Package watch polls a directory tree for changes to files matching glob
+// patterns, grouping bursts of changes and spacing out the callbacks they trigger.
+package watch
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/alchemy-labs-co/riptide/internal/config"
+)
+
+// Options controls how often the tree is scanned and how changes are grouped
+type Options struct {
+	Interval time.Duration // Time between scans
+	Debounce time.Duration // Quiet period after the last change before firing
+	Cooldown time.Duration // Minimum time from the end of one callback to the start of the next
+}
+
+// DefaultOptions returns the options used when none are given
+func DefaultOptions() Options {
+	return Options{
+		Interval: 500 * time.Millisecond,
+		Debounce: time.Second,
+		Cooldown: 10 * time.Second,
+	}
+}
+
+// fileState is what a scan remembers about a file to detect changes
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// Watcher reports changes to matching files under a root directory
+type Watcher struct {
+	root     string
+	patterns []string
+	options  Options
+	excluded map[string]bool
+}
+
+// New creates a watcher for files under root matching any of patterns. Patterns
+// use path.Match syntax plus ** for any number of directories; a pattern
+// without a slash matches the file name at any depth.
+func New(root string, patterns []string, options Options) (*Watcher, error) {
+	if len(patterns) == 0 {
+		return nil, fmt.Errorf("no file patterns to watch")
+	}
+	for _, pattern := range patterns {
+		if _, err := path.Match(strings.ReplaceAll(pattern, "**", "*"), ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+
+	defaults := DefaultOptions()
+	if options.Interval <= 0 {
+		options.Interval = defaults.Interval
+	}
+	if options.Debounce < 0 {
+		options.Debounce = 0
+	}
+	if options.Cooldown < 0 {
+		options.Cooldown = 0
+	}
+
+	return &Watcher{
+		root:     root,
+		patterns: patterns,
+		options:  options,
+		excluded: config.GetExcludedFiles(),
+	}, nil
+}
+
+// Run scans until ctx is done, calling onChange with the sorted relative paths
+// of matching files that were created, modified or deleted. Changes made while
+// onChange runs (such as edits it makes itself) do not trigger another call.
+func (w *Watcher) Run(ctx context.Context, onChange func(paths []string)) error {
+	known, err := w.scan()
+	if err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(w.options.Interval)
+	defer ticker.Stop()
+
+	pending := make(map[string]bool)
+	var lastChange, lastRun time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		current, err := w.scan()
+		if err != nil {
+			return err
+		}
+		if changed := diff(known, current); len(changed) > 0 {
+			for _, name := range changed {
+				pending[name] = true
+			}
+			lastChange = time.Now()
+		}
+		known = current
+
+		if len(pending) == 0 || time.Since(lastChange) < w.options.Debounce {
+			continue
+		}
+		if !lastRun.IsZero() && time.Since(lastRun) < w.options.Cooldown {
+			continue
+		}
+
+		paths := make([]string, 0, len(pending))
+		for name := range pending {
+			paths = append(paths, name)
+		}
+		sort.Strings(paths)
+		pending = make(map[string]bool)
+
+		onChange(paths)
+		lastRun = time.Now()
+
+		// Start from the tree as the callback left it
+		if known, err = w.scan(); err != nil {
+			return err
+		}
+	}
+}
+
+// scan records the state of every matching file
+func (w *Watcher) scan() (map[string]fileState, error) {
+	files := make(map[string]fileState)
+	err := filepath.WalkDir(w.root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Files can vanish mid-walk; the next scan sees the deletion
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+
+		name := d.Name()
+		if d.IsDir() {
+			if p != w.root && (strings.HasPrefix(name, ".") || w.excluded[name]) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		rel, err := filepath.Rel(w.root, p)
+		if err != nil {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		if !w.matches(rel) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		files[rel] = fileState{modTime: info.ModTime(), size: info.Size()}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("scanning %s: %w", w.root, err)
+	}
+	return files, nil
+}
+
+// matches reports whether a slash-separated relative path matches any pattern
+func (w *Watcher) matches(rel string) bool {
+	for _, pattern := range w.patterns {
+		if !strings.Contains(pattern, "/") {
+			if ok, _ := path.Match(pattern, path.Base(rel)); ok {
+				return true
+			}
+			continue
+		}
+		if Match(pattern, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// Match reports whether a slash-separated path matches pattern, where a **
+// segment matches zero or more directories
+func Match(pattern, name string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// diff returns the paths created, modified or deleted between two scans
+func diff(before, after map[string]fileState) []string {
+	var changed []string
+	for name, state := range after {
+		if old, ok := before[name]; !ok || old.size != state.size || !old.modTime.Equal(state.modTime) {
+			changed = append(changed, name)
+		}
+	}
+	for name := range before {
+		if _, ok := after[name]; !ok {
+			changed = append(changed, name)
+		}
+	}
+	return changed
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/alchemy-labs-co/riptide/internal/config"
+	"github.com/alchemy-labs-co/riptide/internal/ui"
+)
+
+// runJSONCommand handles "riptide json --schema FILE PROMPT" and returns the
+// exit code. Only the validated JSON is written to stdout, so the output can
+// be piped into other tools; piped stdin is sent along as the input to work on.
+func runJSONCommand(args []string) int {
+	flags := flag.NewFlagSet("riptide json", flag.ContinueOnError)
+	schemaPath := flags.String("schema", "", "JSON Schema file the answer must match")
+	var files patternList
+	flags.Var(&files, "file", "file to add to the context (repeatable)")
+	deterministic := flags.Bool("deterministic", false, "temperature 0, fixed seed and no time in the prompt")
+	noCache := flags.Bool("no-cache", false, "always call the API instead of answering repeated requests from the response cache")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	prompt := strings.Join(flags.Args(), " ")
+	if *schemaPath == "" || prompt == "" {
+		fmt.Fprintln(os.Stderr, "Usage: riptide json --schema FILE [--file PATH...] [--deterministic] [--no-cache] PROMPT")
+		return 2
+	}
+
+	schema, err := ui.ReadOutputSchema(*schemaPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	// Data piped in is what the prompt is about
+	if stat, err := os.Stdin.Stat(); err == nil && stat.Mode()&os.ModeCharDevice == 0 {
+		input, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading stdin: %v\n", err)
+			return 1
+		}
+		if text := strings.TrimSpace(string(input)); text != "" {
+			prompt += "\n\nInput:\n\n" + text
+		}
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading configuration: %v\n", err)
+		return 1
+	}
+	warnConfig(cfg)
+	if *deterministic {
+		cfg.MakeDeterministic()
+	}
+	if *noCache {
+		cfg.Cache.Enabled = false
+	}
+
+	model, err := ui.NewModel(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating model: %v\n", err)
+		return 1
+	}
+	defer model.Shutdown()
+
+	for _, file := range files {
+		if err := model.AddFile(file); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	answer, err := model.RunJSON(ctx, schema, prompt)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	fmt.Println(answer)
+	return 0
+}
@@ -1,47 +1,212 @@
 package main
 
 import (
+	"bufio"
+	"errors"
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
+	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/alchemy-labs-co/riptide/internal/config"
+	"github.com/alchemy-labs-co/riptide/internal/daemon"
+	"github.com/alchemy-labs-co/riptide/internal/demo"
 	"github.com/alchemy-labs-co/riptide/internal/ui"
 )
 
 func main() {
+	demoMode := hasFlag("--demo")
+
 	// Load configuration
-	cfg, err := config.Load()
-	if err != nil {
+	var cfg *config.Config
+	var err error
+	if demoMode {
+		cfg, err = setupDemo()
+		if err != nil {
+			log.Fatal("Error setting up demo:", err)
+		}
+	} else if cfg, err = loadConfigOrSetup(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error loading configuration: %v\n", err)
-		fmt.Fprintf(os.Stderr, "\nPlease ensure:\n")
-		fmt.Fprintf(os.Stderr, "1. DEEPSEEK_API_KEY environment variable is set\n")
-		fmt.Fprintf(os.Stderr, "2. config.json exists (optional)\n")
-		fmt.Fprintf(os.Stderr, "\nExample:\n")
-		fmt.Fprintf(os.Stderr, "  export DEEPSEEK_API_KEY=your_api_key_here\n")
-		fmt.Fprintf(os.Stderr, "  ./riptide\n")
+		if errors.Is(err, config.ErrNoAPIKey) {
+			fmt.Fprintf(os.Stderr, "\nRun \"riptide init\" to set up the API key, or set it yourself:\n")
+			fmt.Fprintf(os.Stderr, "  export DEEPSEEK_API_KEY=your_api_key_here\n")
+		}
 		os.Exit(1)
 	}
 
+	// Answer repeated requests from the API, not the response cache
+	if hasFlag("--no-cache") {
+		cfg.Cache.Enabled = false
+	}
+
+	// Plan mode: the model reads and proposes, but changes nothing
+	if hasFlag("--plan") {
+		cfg.Permissions.Mode = config.ModeReadOnly
+	}
+
+	// Reproducible runs: fixed sampling and no clock in the prompt, set before
+	// the system prompt is built
+	if hasFlag("--deterministic") {
+		cfg.MakeDeterministic()
+	}
+
 	// Create the model
 	model, err := ui.NewModel(cfg)
 	if err != nil {
 		log.Fatal("Error creating model:", err)
 	}
 
-	// Create the Bubble Tea program
-	p := tea.NewProgram(model, tea.WithAltScreen())
+	// Pick up a saved conversation where it left off
+	if hasFlag("--resume") {
+		query := flagValue("--resume")
+		if strings.HasPrefix(query, "-") {
+			query = ""
+		}
+		if err := model.Resume(query); err != nil {
+			fmt.Fprintf(os.Stderr, "Error resuming session: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	// Demo mode replaces the API with canned responses
+	if demoMode {
+		model.SetProvider(demo.NewProvider())
+	}
+
+	// Create the Bubble Tea program, on this terminal or behind a socket for riptide attach
+	options := []tea.ProgramOption{tea.WithAltScreen()}
+	var server *daemon.Server
+	if socket := flagValue("--serve"); socket != "" {
+		if server, err = startServer(socket); err != nil {
+			log.Fatal("Error starting session server:", err)
+		}
+		options = append(options, server.ProgramOptions()...)
+	}
+	p := tea.NewProgram(model, options...)
 
 	// Set up the program reference for streaming
 	model.SetProgram(p)
+	if server != nil {
+		go server.Serve(p)
+	}
+
+	// Look for a newer release unless disabled; demo and deterministic runs stay offline
+	if cfg.Updates.Check && !demoMode && !cfg.API.Deterministic {
+		checkForUpdateInBackground(func(latest string) {
+			p.Send(ui.UpdateAvailableMsg{Current: version, Latest: latest})
+		})
+	}
 
 	// Run the program
-	if _, err := p.Run(); err != nil {
+	finalModel, err := p.Run()
+
+	// Cancel in-flight work and let pending file writes settle before exiting
+	switch m := finalModel.(type) {
+	case ui.Model:
+		m.Shutdown()
+	case *ui.Model:
+		m.Shutdown()
+	}
+	if server != nil {
+		server.Close()
+	}
+
+	if err != nil {
 		log.Fatal("Error running program:", err)
 	}
 }
 
+// hasFlag reports whether a boolean flag was passed on the command line
+func hasFlag(name string) bool {
+	for _, arg := range os.Args[1:] {
+		if arg == name {
+			return true
+		}
+	}
+	return false
+}
+
+// flagValue returns the argument following name on the command line, or ""
+func flagValue(name string) string {
+	for i := 1; i+1 < len(os.Args); i++ {
+		if os.Args[i] == name {
+			return os.Args[i+1]
+		}
+	}
+	return ""
+}
+
+// loadConfigOrSetup loads the configuration, offering the setup wizard on a
+// terminal when no API key is found yet
+func loadConfigOrSetup() (*config.Config, error) {
+	cfg, err := config.Load()
+	if !errors.Is(err, config.ErrNoAPIKey) || !isInteractive() {
+		return cfg, err
+	}
+
+	fmt.Print("No DeepSeek API key found. Set up Riptide now? [Y/n] ")
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	if answer = strings.ToLower(strings.TrimSpace(answer)); answer != "" && answer != "y" && answer != "yes" {
+		return nil, err
+	}
+	if err := runSetup(os.Stdin, os.Stdout); err != nil {
+		return nil, err
+	}
+	return config.Load()
+}
+
+// confirmProject asks on the terminal whether to apply a project file's
+// options that only a trusted project may set
+func confirmProject(path string, keys []string) bool {
+	fmt.Printf("%s sets options that can run commands or change where the API key is sent:\n", path)
+	for _, key := range keys {
+		fmt.Printf("  %s\n", key)
+	}
+	fmt.Print("Trust this project file and apply them? [y/N] ")
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// warnConfig tells a headless run which project options were skipped, whether
+// TLS certificates go unchecked and which options no longer apply
+func warnConfig(cfg *config.Config) {
+	if len(cfg.ProjectIgnored) > 0 {
+		fmt.Fprintf(os.Stderr, "Warning: ignoring %s from untrusted %s; run riptide in a terminal to trust it\n",
+			strings.Join(cfg.ProjectIgnored, ", "), cfg.ProjectPath)
+	}
+	if cfg.API.InsecureSkipVerify {
+		fmt.Fprintln(os.Stderr, "Warning: api.insecure_skip_verify is on, so the API server's TLS certificate is not checked")
+	}
+	for _, note := range cfg.Outdated {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", note)
+	}
+}
+
+// setupDemo copies the sample project to a temporary directory, moves into it and
+// returns a default configuration that needs no API key
+func setupDemo() (*config.Config, error) {
+	dir, err := demo.Setup()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chdir(dir); err != nil {
+		return nil, fmt.Errorf("entering demo directory: %w", err)
+	}
+
+	// Keep demo sessions out of the real session list
+	if err := os.Setenv("XDG_DATA_HOME", filepath.Join(dir, ".riptide", "data")); err != nil {
+		return nil, fmt.Errorf("isolating demo sessions: %w", err)
+	}
+
+	cfg := config.Default()
+	cfg.APIKey = "demo"
+	cfg.API.Model = "demo"
+	return cfg, nil
+}
+
 // Version information
 var (
 	version = "dev"
@@ -50,6 +215,12 @@ var (
 )
 
 func init() {
+	// Ask before applying project options that run commands or change API
+	// settings; without a terminal they are skipped
+	if isInteractive() {
+		config.ConfirmProject = confirmProject
+	}
+
 	// Handle version flag
 	if len(os.Args) > 1 && (os.Args[1] == "--version" || os.Args[1] == "-v") {
 		fmt.Printf("Riptide\n")
@@ -59,23 +230,67 @@ func init() {
 		os.Exit(0)
 	}
 
+	// Handle subcommands before the API key is required
+	if len(os.Args) > 1 && os.Args[1] == "init" {
+		os.Exit(runInitCommand(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "telemetry" {
+		os.Exit(runTelemetryCommand(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "update" {
+		os.Exit(runUpdateCommand(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "attach" {
+		os.Exit(runAttachCommand(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "watch" {
+		os.Exit(runWatchCommand(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "run" {
+		os.Exit(runRunCommand(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		os.Exit(runImportCommand(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "json" {
+		os.Exit(runJSONCommand(os.Args[2:]))
+	}
+	if hasFlag("-p") || hasFlag("--print") {
+		os.Exit(runPrintCommand(os.Args[1:]))
+	}
+
 	// Handle help flag
 	if len(os.Args) > 1 && (os.Args[1] == "--help" || os.Args[1] == "-h") {
 		fmt.Println("Riptide - AI-powered coding assistant")
 		fmt.Println()
 		fmt.Println("Usage:")
 		fmt.Println("  riptide [options]")
+		fmt.Println("  riptide -p [--yes] [--file PATH...] [--model NAME] [--quiet] [--deterministic] [--no-cache] [--plan] PROMPT")
+		fmt.Println("  riptide init")
+		fmt.Println("  riptide telemetry [status|off]")
+		fmt.Println("  riptide update [--check] [--force] [--skip-verify]")
+		fmt.Println("  riptide attach [name] [--list] [--demo] [--deterministic] [--no-cache] [--plan]")
+		fmt.Println("  riptide watch --on-change PATTERN --prompt TEXT [--debounce D] [--cooldown D] [--yes]")
+		fmt.Println("  riptide run --recipe NAME [--arg KEY=VALUE...] [--yes] [--list]")
+		fmt.Println("  riptide import [--format chatgpt|claude|aider] [--dir DIR] FILE...")
+		fmt.Println("  riptide json --schema FILE [--file PATH...] [--deterministic] [--no-cache] PROMPT")
 		fmt.Println()
 		fmt.Println("Options:")
-		fmt.Println("  -h, --help     Show this help message")
-		fmt.Println("  -v, --version  Show version information")
+		fmt.Println("  --demo           Try the TUI on a sample project with canned responses (no API key)")
+		fmt.Println("  --deterministic  Temperature 0, fixed seed and no time in the prompt, for reproducible runs")
+		fmt.Println("  --no-cache       Call the API even for a request answered before (see the cache config)")
+		fmt.Println("  --resume [name]  Continue the most recent session, or one by ID prefix or title")
+		fmt.Println("  --plan           Start in read-only mode: the model can read and propose diffs, not change anything")
+		fmt.Println("  -p, --print      Answer one prompt (or piped stdin) on stdout without the TUI and exit")
+		fmt.Println("  -h, --help       Show this help message")
+		fmt.Println("  -v, --version    Show version information")
 		fmt.Println()
 		fmt.Println("Environment Variables:")
-		fmt.Println("  DEEPSEEK_API_KEY       Your DeepSeek API key (required)")
+		fmt.Println("  DEEPSEEK_API_KEY       Your DeepSeek API key (or save it with riptide init)")
 		fmt.Println("  DEEPSEEK_CONFIG_PATH   Path to config.json (optional)")
 		fmt.Println()
 		fmt.Println("Configuration:")
-		fmt.Println("  Create a config.json file to customize settings")
+		fmt.Printf("  Create %s to customize settings\n", config.Path())
 		fmt.Println("  See config.json.example for available options")
 		os.Exit(0)
 	}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/alchemy-labs-co/riptide/internal/config"
+	"github.com/alchemy-labs-co/riptide/internal/ui"
+)
+
+// Exit codes of a headless prompt run
+const (
+	exitOK          = 0
+	exitError       = 1
+	exitUsage       = 2
+	exitInterrupted = 130
+)
+
+// runPrintCommand handles "riptide -p PROMPT" and returns the exit code. The
+// answer is streamed to stdout without starting the TUI, tool calls run as in
+// the TUI's permission mode (with --yes approving those that would ask) and are
+// reported on stderr, so the output can be piped. Without a prompt argument the
+// prompt is read from stdin; with one, piped stdin is sent along as input.
+func runPrintCommand(args []string) int {
+	flags := flag.NewFlagSet("riptide -p", flag.ContinueOnError)
+	flags.Bool("p", false, "answer one prompt without the TUI")
+	flags.Bool("print", false, "answer one prompt without the TUI")
+	yes := flags.Bool("yes", false, "approve file writes and commands without asking (dangerous commands are still refused)")
+	var files patternList
+	flags.Var(&files, "file", "file to add to the context (repeatable)")
+	model := flags.String("model", "", "model to use instead of the configured one")
+	quiet := flags.Bool("quiet", false, "do not report tool calls on stderr")
+	deterministic := flags.Bool("deterministic", false, "temperature 0, fixed seed and no time in the prompt")
+	noCache := flags.Bool("no-cache", false, "always call the API instead of answering repeated requests from the response cache")
+	plan := flags.Bool("plan", false, "read-only mode: read and propose changes without making them")
+	if err := flags.Parse(interleavedFlags(args)); err != nil {
+		return exitUsage
+	}
+
+	prompt := strings.Join(flags.Args(), " ")
+	if prompt == "-" {
+		prompt = ""
+	}
+	wait := stdinGracePeriod
+	if prompt == "" {
+		wait = 0
+	}
+	input, err := readPipedStdin(wait)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading stdin: %v\n", err)
+		return exitError
+	}
+	switch {
+	case prompt == "":
+		prompt = input
+	case input != "":
+		prompt += "\n\nInput:\n\n" + input
+	}
+	if prompt == "" {
+		fmt.Fprintln(os.Stderr, "Usage: riptide -p [--yes] [--file PATH...] [--model NAME] [--quiet] [--deterministic] [--no-cache] [--plan] PROMPT")
+		fmt.Fprintln(os.Stderr, "       command | riptide -p [options]")
+		return exitUsage
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading configuration: %v\n", err)
+		return exitError
+	}
+	warnConfig(cfg)
+	if *model != "" {
+		cfg.API.Model = *model
+		cfg.API.Routing = false
+	}
+	if *deterministic {
+		cfg.MakeDeterministic()
+	}
+	if *noCache {
+		cfg.Cache.Enabled = false
+	}
+	if *plan {
+		cfg.Permissions.Mode = config.ModeReadOnly
+	}
+
+	m, err := ui.NewModel(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating model: %v\n", err)
+		return exitError
+	}
+	defer m.Shutdown()
+
+	for _, file := range files {
+		if err := m.AddFile(file); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return exitError
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	opts := ui.HeadlessOptions{Output: os.Stdout, Progress: os.Stderr, Yes: *yes}
+	if *quiet {
+		opts.Progress = io.Discard
+	}
+	if err := m.RunPrompt(ctx, prompt, opts); err != nil {
+		if errors.Is(err, context.Canceled) {
+			fmt.Fprintln(os.Stderr, "Interrupted")
+			return exitInterrupted
+		}
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitError
+	}
+	return exitOK
+}
+
+// stdinGracePeriod is how long a run with a prompt argument waits for piped
+// input to start, so an inherited stdin that never closes, as some CI runners
+// leave it, does not hang the run
+const stdinGracePeriod = 3 * time.Second
+
+// readPipedStdin returns what is piped to stdin, or "" when stdin is a terminal.
+// With a wait, it gives up and returns "" if nothing arrives in that time; a zero
+// wait reads until the input ends.
+func readPipedStdin(wait time.Duration) (string, error) {
+	stat, err := os.Stdin.Stat()
+	if err != nil || stat.Mode()&os.ModeCharDevice != 0 {
+		return "", nil
+	}
+
+	type result struct {
+		input string
+		err   error
+	}
+	started := make(chan struct{})
+	done := make(chan result, 1)
+	go func() {
+		var buf strings.Builder
+		first := make([]byte, 1)
+		n, err := os.Stdin.Read(first)
+		close(started)
+		buf.Write(first[:n])
+		if err == nil {
+			_, err = io.Copy(&buf, os.Stdin)
+		}
+		if errors.Is(err, io.EOF) {
+			err = nil
+		}
+		done <- result{buf.String(), err}
+	}()
+
+	if wait > 0 {
+		select {
+		case <-started:
+		case <-time.After(wait):
+			return "", nil
+		}
+	}
+	r := <-done
+	if r.err != nil {
+		return "", r.err
+	}
+	return strings.TrimSpace(r.input), nil
+}
+
+// interleavedFlags moves flags after the prompt in front of it, so
+// "riptide -p 'fix the build' --yes" works as well as the usual order
+func interleavedFlags(args []string) []string {
+	var flagArgs, positional []string
+	valueFlags := map[string]bool{"-file": true, "--file": true, "-model": true, "--model": true}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--":
+			positional = append(positional, args[i+1:]...)
+			i = len(args)
+		case strings.HasPrefix(arg, "-") && arg != "-":
+			flagArgs = append(flagArgs, arg)
+			if valueFlags[arg] && i+1 < len(args) {
+				i++
+				flagArgs = append(flagArgs, args[i])
+			}
+		default:
+			positional = append(positional, arg)
+		}
+	}
+	return append(append(flagArgs, "--"), positional...)
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/alchemy-labs-co/riptide/internal/config"
+	"github.com/alchemy-labs-co/riptide/internal/recipe"
+	"github.com/alchemy-labs-co/riptide/internal/ui"
+)
+
+// runRunCommand handles "riptide run --recipe NAME [--arg KEY=VALUE...]" and
+// returns the exit code. The recipe's prompts run headlessly, one after
+// another, in a single conversation saved as a session.
+func runRunCommand(args []string) int {
+	flags := flag.NewFlagSet("riptide run", flag.ContinueOnError)
+	name := flags.String("recipe", "", "name of the recipe to run")
+	var recipeArgs patternList
+	flags.Var(&recipeArgs, "arg", "recipe parameter as KEY=VALUE (repeatable)")
+	list := flags.Bool("list", false, "list the available recipes")
+	yes := flags.Bool("yes", false, "approve file writes and commands without asking (dangerous commands are still refused)")
+	deterministic := flags.Bool("deterministic", false, "temperature 0, fixed seed and no time in the prompt")
+	noCache := flags.Bool("no-cache", false, "always call the API instead of answering repeated requests from the response cache")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *name == "" && !*list {
+		fmt.Fprintln(os.Stderr, "Usage: riptide run --recipe NAME [--arg KEY=VALUE...] [--yes] | riptide run --list")
+		return 2
+	}
+
+	values := make(map[string]string)
+	for _, arg := range recipeArgs {
+		key, value, ok := strings.Cut(arg, "=")
+		if !ok || key == "" {
+			fmt.Fprintf(os.Stderr, "Error: --arg %q must be KEY=VALUE\n", arg)
+			return 2
+		}
+		values[key] = value
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading configuration: %v\n", err)
+		return 1
+	}
+	warnConfig(cfg)
+	if *deterministic {
+		cfg.MakeDeterministic()
+	}
+	if *noCache {
+		cfg.Cache.Enabled = false
+	}
+
+	model, err := ui.NewModel(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating model: %v\n", err)
+		return 1
+	}
+	defer model.Shutdown()
+
+	if *list {
+		recipes, err := recipe.List(model.RecipeDirs()...)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		for _, rec := range recipes {
+			fmt.Printf("%s\t%d prompt(s)\t%s\n", rec.Name, len(rec.Prompts), strings.Join(rec.Params, ", "))
+		}
+		return 0
+	}
+
+	rec, err := recipe.Load(*name, model.RecipeDirs()...)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := model.RunRecipe(ctx, rec, values, ui.HeadlessOptions{Output: os.Stdout, Yes: *yes}); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/alchemy-labs-co/riptide/internal/config"
+)
+
+// telemetryDisclosure states exactly what leaves the machine
+const telemetryDisclosure = `Riptide does not collect usage analytics. Nothing is sent anywhere except the
+requests you make to the configured API endpoint (your messages, the files you
+add to context and tool results) and a once-a-day request for the latest release
+version to GitHub (turn off with "updates": {"check": false}). Sessions, the
+write ledger and configuration stay on this machine.`
+
+// runTelemetryCommand handles "riptide telemetry status|off" and returns the exit code
+func runTelemetryCommand(args []string) int {
+	action := "status"
+	if len(args) > 0 {
+		action = args[0]
+	}
+
+	path := config.Path()
+	cfg, err := config.LoadFile(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading configuration: %v\n", err)
+		return 1
+	}
+
+	switch action {
+	case "status":
+		state := "off (default)"
+		if cfg.Telemetry.Enabled {
+			state = "opted in (this build has no analytics, so nothing is sent)"
+		}
+		fmt.Printf("Telemetry: %s\n", state)
+		fmt.Printf("Config:    %s\n\n", path)
+		fmt.Println(telemetryDisclosure)
+		return 0
+
+	case "off":
+		if !cfg.Telemetry.Enabled {
+			fmt.Println("Telemetry is already off.")
+			return 0
+		}
+		cfg.Telemetry.Enabled = false
+		if err := cfg.Save(path); err != nil {
+			fmt.Fprintf(os.Stderr, "Error saving configuration: %v\n", err)
+			return 1
+		}
+		fmt.Printf("Telemetry turned off in %s\n", path)
+		return 0
+
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown telemetry command %q (use status or off)\n", action)
+		return 2
+	}
+}
@@ -0,0 +1,270 @@
+// Package tuitest drives Bubble Tea programs from tests and automation scripts.
+// It runs a model in a real tea.Program without a terminal, feeds it key
+// presses and messages, and records every rendered frame so callers can wait
+// for and assert on what the user would see.
+package tuitest
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// DefaultTimeout bounds WaitFor and Wait when no timeout is given
+const DefaultTimeout = 5 * time.Second
+
+// pollInterval is how often WaitFor re-checks the latest frame
+const pollInterval = 10 * time.Millisecond
+
+// ErrTimeout is returned when a condition is not met in time
+var ErrTimeout = errors.New("timed out")
+
+// ansiSequence matches terminal escape sequences stripped from frames
+var ansiSequence = regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]|\x1b\][^\x07]*\x07`)
+
+// Option configures a Driver
+type Option func(*Driver)
+
+// WithSize sends an initial window size so layout code runs as in a terminal
+func WithSize(width, height int) Option {
+	return func(d *Driver) {
+		d.width, d.height = width, height
+	}
+}
+
+// WithProgram calls setup with the program before it starts, for models that
+// need the program reference (e.g. to send messages from goroutines)
+func WithProgram(setup func(*tea.Program)) Option {
+	return func(d *Driver) {
+		d.setup = setup
+	}
+}
+
+// Driver runs a model and records its frames
+type Driver struct {
+	program *tea.Program
+	setup   func(*tea.Program)
+
+	width, height int
+
+	mu    sync.Mutex
+	frame string // Latest rendered view
+
+	done     chan struct{}
+	final    tea.Model
+	runError error
+}
+
+// recorder wraps the model under test and captures every view it renders
+type recorder struct {
+	model  tea.Model
+	driver *Driver
+}
+
+func (r recorder) Init() tea.Cmd {
+	return r.model.Init()
+}
+
+func (r recorder) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	model, cmd := r.model.Update(msg)
+	return recorder{model: model, driver: r.driver}, cmd
+}
+
+func (r recorder) View() string {
+	view := r.model.View()
+
+	r.driver.mu.Lock()
+	r.driver.frame = view
+	r.driver.mu.Unlock()
+
+	return view
+}
+
+// New starts model in a headless program and returns its driver. Call Quit or
+// Wait when done so the program's goroutines exit.
+func New(model tea.Model, opts ...Option) *Driver {
+	d := &Driver{done: make(chan struct{})}
+	for _, opt := range opts {
+		opt(d)
+	}
+
+	d.program = tea.NewProgram(
+		recorder{model: model, driver: d},
+		tea.WithInput(nil),
+		tea.WithoutRenderer(),
+		tea.WithoutSignalHandler(),
+	)
+	if d.setup != nil {
+		d.setup(d.program)
+	}
+
+	go func() {
+		defer close(d.done)
+		final, err := d.program.Run()
+		if rec, ok := final.(recorder); ok {
+			final = rec.model
+		}
+		d.final, d.runError = final, err
+	}()
+
+	if d.width > 0 && d.height > 0 {
+		d.Resize(d.width, d.height)
+	}
+	return d
+}
+
+// Send delivers a message to the model
+func (d *Driver) Send(msg tea.Msg) {
+	d.program.Send(msg)
+}
+
+// Type sends text one rune at a time, as if typed
+func (d *Driver) Type(text string) {
+	for _, r := range text {
+		if r == ' ' {
+			d.Send(tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}})
+			continue
+		}
+		d.Send(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+}
+
+// Press sends named keys such as "enter", "esc", "up", "ctrl+k" or "pgdown".
+// A single character is sent as typed text.
+func (d *Driver) Press(keys ...string) error {
+	for _, name := range keys {
+		msg, err := ParseKey(name)
+		if err != nil {
+			return err
+		}
+		d.Send(msg)
+	}
+	return nil
+}
+
+// Resize sends a window size message
+func (d *Driver) Resize(width, height int) {
+	d.width, d.height = width, height
+	d.Send(tea.WindowSizeMsg{Width: width, Height: height})
+}
+
+// Frame returns the latest rendered view with escape sequences removed
+func (d *Driver) Frame() string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return StripANSI(d.frame)
+}
+
+// RawFrame returns the latest rendered view including styling
+func (d *Driver) RawFrame() string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.frame
+}
+
+// WaitFor polls the latest frame until cond holds. A zero timeout uses DefaultTimeout.
+func (d *Driver) WaitFor(cond func(frame string) bool, timeout time.Duration) error {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	deadline := time.Now().Add(timeout)
+
+	for {
+		if cond(d.Frame()) {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return ErrTimeout
+		}
+		select {
+		case <-d.done:
+			if cond(d.Frame()) {
+				return nil
+			}
+			return fmt.Errorf("program exited before the condition was met")
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// WaitForText waits until the frame contains text, reporting the last frame on failure
+func (d *Driver) WaitForText(text string, timeout time.Duration) error {
+	err := d.WaitFor(func(frame string) bool { return strings.Contains(frame, text) }, timeout)
+	if err != nil {
+		return fmt.Errorf("waiting for %q: %w\nlast frame:\n%s", text, err, d.Frame())
+	}
+	return nil
+}
+
+// Quit asks the program to exit and waits for it
+func (d *Driver) Quit(timeout time.Duration) (tea.Model, error) {
+	d.program.Quit()
+	return d.Wait(timeout)
+}
+
+// Wait waits for the program to exit on its own and returns the final model
+func (d *Driver) Wait(timeout time.Duration) (tea.Model, error) {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	select {
+	case <-d.done:
+		return d.final, d.runError
+	case <-time.After(timeout):
+		d.program.Kill()
+		<-d.done
+		return d.final, fmt.Errorf("waiting for program to exit: %w", ErrTimeout)
+	}
+}
+
+// StripANSI removes terminal escape sequences from s
+func StripANSI(s string) string {
+	return ansiSequence.ReplaceAllString(s, "")
+}
+
+// keysByName maps Bubble Tea key names ("enter", "ctrl+c", ...) to key types
+var keysByName = func() map[string]tea.KeyType {
+	names := make(map[string]tea.KeyType)
+	// Special keys are negative and control keys fill 0-127; both have names
+	for k := tea.KeyType(-200); k <= 127; k++ {
+		if name := k.String(); name != "" {
+			if _, exists := names[name]; !exists {
+				names[name] = k
+			}
+		}
+	}
+	names["enter"] = tea.KeyEnter
+	names["space"] = tea.KeySpace
+	return names
+}()
+
+// ParseKey converts a key name such as "enter", "ctrl+k" or "alt+enter" into a key message
+func ParseKey(name string) (tea.KeyMsg, error) {
+	lower := strings.ToLower(name)
+	if strings.HasPrefix(lower, "alt+") && len([]rune(name)) == 5 {
+		return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(name[4:]), Alt: true}, nil
+	}
+	alt := false
+	if rest, ok := strings.CutPrefix(lower, "alt+"); ok {
+		if _, named := keysByName[rest]; named {
+			lower, alt = rest, true
+		}
+	}
+	if k, ok := keysByName[lower]; ok {
+		msg := tea.KeyMsg{Type: k, Alt: alt}
+		if k == tea.KeySpace {
+			msg.Runes = []rune{' '}
+		}
+		return msg, nil
+	}
+	if runes := []rune(name); len(runes) == 1 {
+		return tea.KeyMsg{Type: tea.KeyRunes, Runes: runes}, nil
+	}
+	return tea.KeyMsg{}, fmt.Errorf("unknown key %q", name)
+}
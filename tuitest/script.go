@@ -0,0 +1,120 @@
+package tuitest
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RunScript drives d with a line-based script. Blank lines and lines starting
+// with # are ignored. Commands:
+//
+//	resize <width> <height>   send a window size
+//	type <text>               type text (quote it to keep surrounding spaces)
+//	press <key>...            press named keys, e.g. enter, esc, ctrl+k
+//	wait "<text>" [timeout]   wait until the frame contains text (default 5s)
+//	expect "<text>"           fail unless the current frame contains text
+//	reject "<text>"           fail if the current frame contains text
+//	sleep <duration>          pause, e.g. 200ms
+//	quit                      quit the program and wait for it to exit
+func RunScript(d *Driver, script io.Reader) error {
+	scanner := bufio.NewScanner(script)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if err := runLine(d, line); err != nil {
+			return fmt.Errorf("line %d (%s): %w", lineNum, line, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("reading script: %w", err)
+	}
+	return nil
+}
+
+// runLine executes one script command
+func runLine(d *Driver, line string) error {
+	command, rest, _ := strings.Cut(line, " ")
+	rest = strings.TrimSpace(rest)
+
+	switch command {
+	case "resize":
+		var width, height int
+		if _, err := fmt.Sscanf(rest, "%d %d", &width, &height); err != nil {
+			return fmt.Errorf("expected width and height: %w", err)
+		}
+		d.Resize(width, height)
+
+	case "type":
+		text, _, err := scriptString(rest)
+		if err != nil {
+			return err
+		}
+		d.Type(text)
+
+	case "press":
+		return d.Press(strings.Fields(rest)...)
+
+	case "wait":
+		text, remainder, err := scriptString(rest)
+		if err != nil {
+			return err
+		}
+		timeout := DefaultTimeout
+		if remainder != "" {
+			if timeout, err = time.ParseDuration(remainder); err != nil {
+				return fmt.Errorf("parsing timeout: %w", err)
+			}
+		}
+		return d.WaitForText(text, timeout)
+
+	case "expect", "reject":
+		text, _, err := scriptString(rest)
+		if err != nil {
+			return err
+		}
+		if contains := strings.Contains(d.Frame(), text); contains != (command == "expect") {
+			return fmt.Errorf("frame check failed for %q\nframe:\n%s", text, d.Frame())
+		}
+
+	case "sleep":
+		duration, err := time.ParseDuration(rest)
+		if err != nil {
+			return fmt.Errorf("parsing duration: %w", err)
+		}
+		time.Sleep(duration)
+
+	case "quit":
+		_, err := d.Quit(DefaultTimeout)
+		return err
+
+	default:
+		return fmt.Errorf("unknown command %q", command)
+	}
+	return nil
+}
+
+// scriptString reads a quoted or bare string argument and returns what follows it.
+// A bare argument takes the rest of the line.
+func scriptString(arg string) (string, string, error) {
+	if !strings.HasPrefix(arg, `"`) {
+		return arg, "", nil
+	}
+
+	prefix, err := strconv.QuotedPrefix(arg)
+	if err != nil {
+		return "", "", fmt.Errorf("parsing quoted string: %w", err)
+	}
+	text, err := strconv.Unquote(prefix)
+	if err != nil {
+		return "", "", fmt.Errorf("parsing quoted string: %w", err)
+	}
+	return text, strings.TrimSpace(arg[len(prefix):]), nil
+}
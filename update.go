@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/alchemy-labs-co/riptide/internal/update"
+)
+
+// runUpdateCommand handles "riptide update [--check] [--force] [--skip-verify]"
+// and returns the exit code
+func runUpdateCommand(args []string) int {
+	checkOnly, force, skipVerify := false, false, false
+	for _, arg := range args {
+		switch arg {
+		case "--check":
+			checkOnly = true
+		case "--force":
+			force = true
+		case "--skip-verify":
+			skipVerify = true
+		default:
+			fmt.Fprintf(os.Stderr, "Unknown update option %q (use --check, --force or --skip-verify)\n", arg)
+			return 2
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	release, err := update.Latest(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error checking for updates: %v\n", err)
+		return 1
+	}
+
+	fmt.Printf("Current version: %s\n", version)
+	fmt.Printf("Latest release:  %s (%s)\n", release.Version, release.URL)
+
+	switch {
+	case !update.IsRelease(version) && !force:
+		fmt.Println("\nThis is a development build, so versions cannot be compared. Use --force to install the latest release anyway.")
+		return 0
+	case !update.IsNewer(version, release.Version) && !force:
+		fmt.Println("\nRiptide is up to date.")
+		return 0
+	case checkOnly:
+		fmt.Println("\nRun 'riptide update' to install it.")
+		return 0
+	}
+
+	fmt.Printf("\nDownloading %s...\n", update.AssetName())
+	path, err := update.Apply(ctx, release, skipVerify)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error updating: %v\n", err)
+		return 1
+	}
+	fmt.Printf("Updated %s to %s\n", path, release.Version)
+	return 0
+}
+
+// checkForUpdateInBackground tells the UI when a newer release is out. GitHub is
+// asked at most once a day; failures are ignored so being offline is silent.
+func checkForUpdateInBackground(notify func(latest string)) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		latest, err := update.CheckDaily(ctx, version)
+		if err == nil && latest != "" {
+			notify(latest)
+		}
+	}()
+}
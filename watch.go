@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/alchemy-labs-co/riptide/internal/config"
+	"github.com/alchemy-labs-co/riptide/internal/ui"
+	"github.com/alchemy-labs-co/riptide/internal/watch"
+)
+
+// patternList collects a repeatable string flag
+type patternList []string
+
+func (p *patternList) String() string { return strings.Join(*p, ", ") }
+
+func (p *patternList) Set(value string) error {
+	*p = append(*p, value)
+	return nil
+}
+
+// runWatchCommand handles "riptide watch --on-change PATTERN --prompt TEXT" and
+// returns the exit code. Each burst of changes to matching files runs the prompt
+// headlessly in one ongoing conversation until interrupted.
+func runWatchCommand(args []string) int {
+	defaults := watch.DefaultOptions()
+	flags := flag.NewFlagSet("riptide watch", flag.ContinueOnError)
+	var patterns patternList
+	flags.Var(&patterns, "on-change", "glob of files to watch, e.g. \"**/*.go\" (repeatable)")
+	prompt := flags.String("prompt", "", "prompt to run when matching files change")
+	debounce := flags.Duration("debounce", defaults.Debounce, "wait this long after the last change before running")
+	cooldown := flags.Duration("cooldown", defaults.Cooldown, "minimum time between the end of one run and the start of the next")
+	yes := flags.Bool("yes", false, "approve file writes and commands without asking (dangerous commands are still refused)")
+	deterministic := flags.Bool("deterministic", false, "temperature 0, fixed seed and no time in the prompt")
+	noCache := flags.Bool("no-cache", false, "always call the API instead of answering repeated requests from the response cache")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if len(patterns) == 0 || strings.TrimSpace(*prompt) == "" {
+		fmt.Fprintln(os.Stderr, "Usage: riptide watch --on-change PATTERN [--on-change PATTERN...] --prompt TEXT [options]")
+		return 2
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading configuration: %v\n", err)
+		return 1
+	}
+	warnConfig(cfg)
+	if *deterministic {
+		cfg.MakeDeterministic()
+	}
+	if *noCache {
+		cfg.Cache.Enabled = false
+	}
+
+	model, err := ui.NewModel(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating model: %v\n", err)
+		return 1
+	}
+	defer model.Shutdown()
+
+	root, err := os.Getwd()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error getting working directory: %v\n", err)
+		return 1
+	}
+	watcher, err := watch.New(root, patterns, watch.Options{Debounce: *debounce, Cooldown: *cooldown})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	fmt.Printf("Watching %s for changes to %s (Ctrl+C to stop)\n", root, patterns.String())
+	err = watcher.Run(ctx, func(paths []string) {
+		fmt.Printf("\n[%s] Changed: %s\n", time.Now().Format("15:04:05"), strings.Join(paths, ", "))
+
+		message := fmt.Sprintf("%s\n\nFiles changed since the last run:\n- %s", *prompt, strings.Join(paths, "\n- "))
+		if err := model.RunPrompt(ctx, message, ui.HeadlessOptions{Output: os.Stdout, Yes: *yes}); err != nil && ctx.Err() == nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		}
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error watching files: %v\n", err)
+		return 1
+	}
+	return 0
+}